@@ -19,29 +19,29 @@ import (
 	"log"
 	"net/http"
 	"os"
-	"time"
+	"strings"
+	_ "time/tzdata" // resolve -timezone on hosts without a zoneinfo database
 
 	"github.com/rsvihladremio/ddd/internal/config"
 	"github.com/rsvihladremio/ddd/internal/database"
+	"github.com/rsvihladremio/ddd/internal/events"
 	"github.com/rsvihladremio/ddd/internal/handlers"
+	"github.com/rsvihladremio/ddd/internal/metrics"
 	"github.com/rsvihladremio/ddd/internal/workers"
 )
 
 func main() {
-	var (
-		port       = flag.String("port", "8080", "Server port")
-		dbPath     = flag.String("db", "./ddd.db", "SQLite database path")
-		uploadsDir = flag.String("uploads", "./uploads", "Uploads directory")
-	)
+	flags := config.RegisterFlags(flag.CommandLine)
 	flag.Parse()
 
-	// Initialize configuration
-	cfg := &config.Config{
-		Port:              *port,
-		DBPath:            *dbPath,
-		UploadsDir:        *uploadsDir,
-		MaxDiskUsage:      0.5, // Default fallback value
-		FileRetentionDays: 14,  // Default fallback value
+	// Load configuration with precedence flags > env > file > defaults
+	cfg, err := config.Load(flags.ConfigPath)
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	flags.Apply(cfg)
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
 	}
 
 	// Create uploads directory if it doesn't exist
@@ -60,40 +60,73 @@ func main() {
 		}
 	}()
 
-	// Initialize settings in database with sensible defaults
-	defaultSettings := map[string]string{
-		"max_disk_usage":      "0.500000", // 50%
-		"file_retention_days": "14",       // 14 days
-	}
+	// Initialize settings in database with sensible defaults. Configured values only seed
+	// settings that do not exist yet; after that they are managed through the web UI
+	defaultSettings := handlers.DefaultSettings(cfg)
 	if err := db.InitializeSettings(defaultSettings); err != nil {
 		log.Fatalf("Failed to initialize settings: %v", err)
 	}
 
-	// Start background workers
-	reportWorker := workers.NewReportWorker(db, cfg)
+	// Start background workers; report status changes are pushed to browsers through the broker
+	broker := events.NewBroker()
+	reportWorker := workers.NewReportWorker(db, cfg, broker)
 	cleanupWorker := workers.NewCleanupWorker(db, cfg)
+	backupWorker := workers.NewBackupWorker(db, cfg)
 
 	go reportWorker.Start()
 	go cleanupWorker.Start()
+	go backupWorker.Start()
 
 	// Initialize handlers with cleanup worker reference
-	h := handlers.New(db, cfg, cleanupWorker)
+	h := handlers.New(db, cfg, cleanupWorker, reportWorker, broker)
 
 	// Setup HTTP routes
 	mux := http.NewServeMux()
 
+	// Health check for container orchestration probes
+	mux.HandleFunc("/healthz", h.HandleHealth)
+
+	// Prometheus metrics are opt-in
+	if cfg.Metrics {
+		mux.Handle("/metrics", metrics.Handler(db, cfg.UploadsDir))
+	}
+
 	// Static files
-	mux.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir("./web/static/"))))
+	mux.Handle("/static/", h.HandleStatic())
+	mux.HandleFunc("/favicon.ico", h.HandleFavicon)
 
 	// API routes
 	mux.HandleFunc("/api/upload", h.HandleUpload)
+	mux.HandleFunc("/api/detect", h.HandleDetectPreview)
 	mux.HandleFunc("/api/files", h.HandleFiles)
+	mux.HandleFunc("/api/files/stream", h.HandleFilesStream)
 	mux.HandleFunc("/api/files/", h.HandleFileOperations)
 	mux.HandleFunc("/api/files/{id}/redetect", h.HandleRedetectFileType)
+	mux.HandleFunc("/api/files/{id}/type", h.HandleSetFileType)
+	mux.HandleFunc("/api/files/{id}/pin", h.HandlePinFile)
+	mux.HandleFunc("/api/files/{id}/download", h.HandleDownloadFile)
+	mux.HandleFunc("/api/files/{id}/history", h.HandleFileReportHistory)
+	mux.HandleFunc("/api/groups/{id}", h.HandleFilesByGroup)
 	mux.HandleFunc("/api/reports/", h.HandleReports)
 	mux.HandleFunc("/api/reports/content/", h.HandleReportContent)
+	mux.HandleFunc("/api/reports/meta/", h.HandleReportMeta)
+	mux.HandleFunc("/api/reports/failed", h.HandleFailedReports)
+	mux.HandleFunc("/api/reports/failed/retry", h.HandleRetryFailed)
+	mux.HandleFunc("/api/reports/import", h.HandleImportReport)
+	mux.HandleFunc("/api/reports/aggregate", h.HandleAggregateReport)
+	mux.HandleFunc("/api/reports/correlate", h.HandleCorrelate)
+	mux.HandleFunc("/api/search", h.HandleSearchReports)
+	mux.HandleFunc("/api/baselines", h.HandleBaselines)
+	mux.HandleFunc("/api/baselines/", h.HandleBaselines)
+	mux.HandleFunc("/api/workers/throughput", h.HandleWorkerThroughput)
+	mux.HandleFunc("/api/stats", h.HandleStats)
+	mux.HandleFunc("/api/capabilities", h.HandleCapabilities)
 	mux.HandleFunc("/api/disk-usage", h.HandleDiskUsage)
 	mux.HandleFunc("/api/settings", h.HandleSettings)
+	mux.HandleFunc("/api/settings/history", h.HandleSettingsHistory)
+	mux.HandleFunc("/api/admin/reprocess", h.HandleReprocessByType)
+	mux.HandleFunc("/api/admin/backup", h.HandleBackup)
+	mux.HandleFunc("/api/admin/consistency", h.HandleConsistencyCheck)
 
 	// Report viewer page
 	mux.HandleFunc("/report/", h.HandleReportPage)
@@ -104,15 +137,33 @@ func main() {
 	log.Printf("Starting DDD server on port %s", cfg.Port)
 	log.Printf("Database: %s", cfg.DBPath)
 	log.Printf("Uploads directory: %s", cfg.UploadsDir)
+	log.Printf("Backup directory: %s", cfg.BackupDir)
+	log.Printf("Capture timestamps are interpreted as %s", cfg.Timezone)
 	log.Printf("Settings are managed in database and configurable via web UI")
+	if cfg.ReadOnly {
+		log.Printf("Read-only mode: uploads and changes are refused and no reports are generated")
+	}
+	if cfg.Metrics {
+		log.Printf("Prometheus metrics enabled at /metrics")
+	}
+	if cfg.WebDir != "" {
+		log.Printf("Serving web UI from %s", cfg.WebDir)
+	}
+	if len(cfg.CORSOrigins) > 0 {
+		log.Printf("CORS enabled for API origins: %s", strings.Join(cfg.CORSOrigins, ", "))
+	}
+
+	log.Printf("Server timeouts: read %s, write %s, idle %s, uploads and downloads %s",
+		cfg.ReadTimeout(), cfg.WriteTimeout(), cfg.IdleTimeout(), cfg.TransferTimeout())
 
-	// Create HTTP server with timeouts for security
+	// Create HTTP server with timeouts for security. Uploads and downloads get their own,
+	// longer timeout since large captures take a while on slow links
 	server := &http.Server{
 		Addr:         ":" + cfg.Port,
-		Handler:      mux,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		Handler:      handlers.TransferTimeout(cfg.TransferTimeout(), handlers.Gzip(handlers.CORS(cfg.CORSOrigins, h.ReadOnly(mux)))),
+		ReadTimeout:  cfg.ReadTimeout(),
+		WriteTimeout: cfg.WriteTimeout(),
+		IdleTimeout:  cfg.IdleTimeout(),
 	}
 
 	if err := server.ListenAndServe(); err != nil {
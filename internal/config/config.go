@@ -12,13 +12,452 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+// Package config builds the DDD configuration. Each value is taken from the first
+// source that sets it, in this order:
+//
+//  1. command line flags (-port, -db, -uploads, -metrics, -web-dir, -cors-origins, -timezone,
+//     -read-only, -read-timeout-seconds, -write-timeout-seconds, -idle-timeout-seconds,
+//     -transfer-timeout-seconds)
+//  2. environment variables (DDD_PORT, DDD_DB_PATH, DDD_UPLOADS_DIR, DDD_MAX_DISK_USAGE,
+//     DDD_FILE_RETENTION_DAYS, DDD_METRICS, DDD_WEB_DIR, DDD_CORS_ORIGINS, DDD_TIMEZONE,
+//     DDD_MAX_REPORT_BYTES, DDD_MAX_CHART_POINTS, DDD_REPORT_TIMEOUT_SECONDS, DDD_BACKUP_DIR,
+//     DDD_BACKUP_INTERVAL_HOURS, DDD_BACKUP_RETENTION, DDD_REPORT_RETENTION_DAYS,
+//     DDD_REPORT_DATA_RETENTION_DAYS, DDD_READ_ONLY, DDD_READ_TIMEOUT_SECONDS,
+//     DDD_WRITE_TIMEOUT_SECONDS, DDD_IDLE_TIMEOUT_SECONDS, DDD_TRANSFER_TIMEOUT_SECONDS)
+//  3. the YAML or JSON file given with -config
+//  4. the built-in defaults
 package config
 
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
 // Config holds the application configuration
 type Config struct {
-	Port              string
-	DBPath            string
-	UploadsDir        string
-	MaxDiskUsage      float64 // 0.0 to 1.0
-	FileRetentionDays int
+	Port                    string   `json:"port" yaml:"port"`
+	DBPath                  string   `json:"db_path" yaml:"db_path"`
+	UploadsDir              string   `json:"uploads_dir" yaml:"uploads_dir"`
+	MaxDiskUsage            float64  `json:"max_disk_usage" yaml:"max_disk_usage"` // 0.0 to 1.0
+	FileRetentionDays       int      `json:"file_retention_days" yaml:"file_retention_days"`
+	Metrics                 bool     `json:"metrics" yaml:"metrics"`
+	WebDir                  string   `json:"web_dir" yaml:"web_dir"`                                       // Serve the UI from here instead of the embedded copy
+	CORSOrigins             []string `json:"cors_origins" yaml:"cors_origins"`                             // Origins allowed to call the API, "*" for any
+	Timezone                string   `json:"timezone" yaml:"timezone"`                                     // IANA zone that iostat and ttop timestamps were captured in
+	MaxReportBytes          int64    `json:"max_report_bytes" yaml:"max_report_bytes"`                     // Largest report stored before it is downsampled or truncated, 0 for no limit
+	MaxChartPoints          int      `json:"max_chart_points" yaml:"max_chart_points"`                     // Time series charts average adjacent snapshots down to this many points, 0 for no limit
+	ReportTimeoutSeconds    int      `json:"report_timeout_seconds" yaml:"report_timeout_seconds"`         // Reports still generating after this long are failed, 0 for no limit
+	BackupDir               string   `json:"backup_dir" yaml:"backup_dir"`                                 // Database backups are written here
+	BackupIntervalHours     int      `json:"backup_interval_hours" yaml:"backup_interval_hours"`           // Hours between scheduled backups, 0 to only back up on demand
+	BackupRetention         int      `json:"backup_retention" yaml:"backup_retention"`                     // Newest backups kept when pruning, 0 to keep all
+	ReportRetentionDays     int      `json:"report_retention_days" yaml:"report_retention_days"`           // Days report data is kept after its file is deleted, 0 to keep it forever
+	ReportDataRetentionDays int      `json:"report_data_retention_days" yaml:"report_data_retention_days"` // Days reports keep their charts and raw data before only the summary is kept, 0 to keep it forever
+	ReadOnly                bool     `json:"read_only" yaml:"read_only"`                                   // Refuse uploads and other changes and pause report generation, e.g. during maintenance
+	ReadTimeoutSeconds      int      `json:"read_timeout_seconds" yaml:"read_timeout_seconds"`             // Longest a request may take to be read, 0 for no limit
+	WriteTimeoutSeconds     int      `json:"write_timeout_seconds" yaml:"write_timeout_seconds"`           // Longest a response may take to be written, 0 for no limit
+	IdleTimeoutSeconds      int      `json:"idle_timeout_seconds" yaml:"idle_timeout_seconds"`             // Longest a keep-alive connection waits for its next request, 0 to use the read timeout
+	TransferTimeoutSeconds  int      `json:"transfer_timeout_seconds" yaml:"transfer_timeout_seconds"`     // Read and write timeout of uploads and downloads instead of the above, 0 for no limit
+}
+
+// Defaults returns the configuration used when no file, environment variable or flag sets a value
+func Defaults() *Config {
+	return &Config{
+		Port:                   "8080",
+		DBPath:                 "./ddd.db",
+		UploadsDir:             "./uploads",
+		MaxDiskUsage:           0.5,
+		FileRetentionDays:      14,
+		Timezone:               "UTC",
+		MaxReportBytes:         32 * 1024 * 1024,
+		MaxChartPoints:         500,
+		ReportTimeoutSeconds:   600,
+		BackupDir:              "./backups",
+		BackupIntervalHours:    24,
+		BackupRetention:        7,
+		ReadTimeoutSeconds:     15,
+		WriteTimeoutSeconds:    15,
+		IdleTimeoutSeconds:     60,
+		TransferTimeoutSeconds: 3600,
+	}
+}
+
+// Load builds the configuration from the defaults, then the YAML or JSON file at path
+// (skipped when path is empty), then DDD_* environment variables. Command line flags
+// are applied on top by the caller, giving flags > env > file > defaults.
+func Load(path string) (*Config, error) {
+	cfg := Defaults()
+
+	if path != "" {
+		if err := loadFile(cfg, path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := applyEnv(cfg, os.LookupEnv); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Validate checks that the configuration values are usable
+func (c *Config) Validate() error {
+	if c.Port == "" {
+		return fmt.Errorf("port must not be empty")
+	}
+	if c.DBPath == "" {
+		return fmt.Errorf("db_path must not be empty")
+	}
+	if c.UploadsDir == "" {
+		return fmt.Errorf("uploads_dir must not be empty")
+	}
+	if c.MaxDiskUsage <= 0 || c.MaxDiskUsage > 1 {
+		return fmt.Errorf("max_disk_usage must be between 0 and 1, got %v", c.MaxDiskUsage)
+	}
+	if c.FileRetentionDays < 1 {
+		return fmt.Errorf("file_retention_days must be at least 1, got %d", c.FileRetentionDays)
+	}
+	if c.MaxReportBytes < 0 {
+		return fmt.Errorf("max_report_bytes must not be negative, got %d", c.MaxReportBytes)
+	}
+	if c.MaxChartPoints < 0 {
+		return fmt.Errorf("max_chart_points must not be negative, got %d", c.MaxChartPoints)
+	}
+	if c.ReportTimeoutSeconds < 0 {
+		return fmt.Errorf("report_timeout_seconds must not be negative, got %d", c.ReportTimeoutSeconds)
+	}
+	if c.BackupDir == "" {
+		return fmt.Errorf("backup_dir must not be empty")
+	}
+	if c.BackupIntervalHours < 0 {
+		return fmt.Errorf("backup_interval_hours must not be negative, got %d", c.BackupIntervalHours)
+	}
+	if c.BackupRetention < 0 {
+		return fmt.Errorf("backup_retention must not be negative, got %d", c.BackupRetention)
+	}
+	if c.ReportRetentionDays < 0 {
+		return fmt.Errorf("report_retention_days must not be negative, got %d", c.ReportRetentionDays)
+	}
+	if c.ReportDataRetentionDays < 0 {
+		return fmt.Errorf("report_data_retention_days must not be negative, got %d", c.ReportDataRetentionDays)
+	}
+	if c.ReadTimeoutSeconds < 0 {
+		return fmt.Errorf("read_timeout_seconds must not be negative, got %d", c.ReadTimeoutSeconds)
+	}
+	if c.WriteTimeoutSeconds < 0 {
+		return fmt.Errorf("write_timeout_seconds must not be negative, got %d", c.WriteTimeoutSeconds)
+	}
+	if c.IdleTimeoutSeconds < 0 {
+		return fmt.Errorf("idle_timeout_seconds must not be negative, got %d", c.IdleTimeoutSeconds)
+	}
+	if c.TransferTimeoutSeconds < 0 {
+		return fmt.Errorf("transfer_timeout_seconds must not be negative, got %d", c.TransferTimeoutSeconds)
+	}
+	if _, err := c.Location(); err != nil {
+		return err
+	}
+	for _, origin := range c.CORSOrigins {
+		if origin != "*" && !strings.Contains(origin, "://") {
+			return fmt.Errorf("cors_origins entries must be * or a scheme and host such as https://dashboard.example.com, got %q", origin)
+		}
+	}
+	return nil
+}
+
+// Location returns the time zone captured timestamps are interpreted in, UTC when none is set
+func (c *Config) Location() (*time.Location, error) {
+	if c.Timezone == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("timezone must be an IANA zone name such as UTC or America/New_York, got %q", c.Timezone)
+	}
+	return loc, nil
+}
+
+// ReportTimeout returns how long a single report may take to generate, 0 for no limit
+func (c *Config) ReportTimeout() time.Duration {
+	return time.Duration(c.ReportTimeoutSeconds) * time.Second
+}
+
+// ReadTimeout returns how long the server may take to read a request, 0 for no limit
+func (c *Config) ReadTimeout() time.Duration {
+	return time.Duration(c.ReadTimeoutSeconds) * time.Second
+}
+
+// WriteTimeout returns how long the server may take to write a response, 0 for no limit
+func (c *Config) WriteTimeout() time.Duration {
+	return time.Duration(c.WriteTimeoutSeconds) * time.Second
+}
+
+// IdleTimeout returns how long a keep-alive connection waits for its next request, 0 to
+// use the read timeout
+func (c *Config) IdleTimeout() time.Duration {
+	return time.Duration(c.IdleTimeoutSeconds) * time.Second
+}
+
+// TransferTimeout returns how long an upload or download may take to be read and written,
+// 0 for no limit
+func (c *Config) TransferTimeout() time.Duration {
+	return time.Duration(c.TransferTimeoutSeconds) * time.Second
+}
+
+// BackupInterval returns how often the database is backed up, 0 when backups only run on demand
+func (c *Config) BackupInterval() time.Duration {
+	return time.Duration(c.BackupIntervalHours) * time.Hour
+}
+
+// loadFile overlays the values set in a YAML or JSON config file onto cfg.
+// Files ending in .json are decoded as JSON, anything else as YAML.
+func loadFile(cfg *Config, path string) error {
+	content, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		decoder := json.NewDecoder(bytes.NewReader(content))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(cfg); err != nil {
+			return fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+		return nil
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyEnv overlays DDD_* environment variables onto cfg
+func applyEnv(cfg *Config, lookup func(string) (string, bool)) error {
+	if value, ok := lookup("DDD_PORT"); ok {
+		cfg.Port = value
+	}
+	if value, ok := lookup("DDD_DB_PATH"); ok {
+		cfg.DBPath = value
+	}
+	if value, ok := lookup("DDD_UPLOADS_DIR"); ok {
+		cfg.UploadsDir = value
+	}
+	if value, ok := lookup("DDD_MAX_DISK_USAGE"); ok {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid DDD_MAX_DISK_USAGE %q: %w", value, err)
+		}
+		cfg.MaxDiskUsage = parsed
+	}
+	if value, ok := lookup("DDD_FILE_RETENTION_DAYS"); ok {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid DDD_FILE_RETENTION_DAYS %q: %w", value, err)
+		}
+		cfg.FileRetentionDays = parsed
+	}
+	if value, ok := lookup("DDD_METRICS"); ok {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid DDD_METRICS %q: %w", value, err)
+		}
+		cfg.Metrics = parsed
+	}
+	if value, ok := lookup("DDD_WEB_DIR"); ok {
+		cfg.WebDir = value
+	}
+	if value, ok := lookup("DDD_CORS_ORIGINS"); ok {
+		cfg.CORSOrigins = splitList(value)
+	}
+	if value, ok := lookup("DDD_TIMEZONE"); ok {
+		cfg.Timezone = value
+	}
+	if value, ok := lookup("DDD_MAX_REPORT_BYTES"); ok {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid DDD_MAX_REPORT_BYTES %q: %w", value, err)
+		}
+		cfg.MaxReportBytes = parsed
+	}
+	if value, ok := lookup("DDD_MAX_CHART_POINTS"); ok {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid DDD_MAX_CHART_POINTS %q: %w", value, err)
+		}
+		cfg.MaxChartPoints = parsed
+	}
+	if value, ok := lookup("DDD_REPORT_TIMEOUT_SECONDS"); ok {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid DDD_REPORT_TIMEOUT_SECONDS %q: %w", value, err)
+		}
+		cfg.ReportTimeoutSeconds = parsed
+	}
+	if value, ok := lookup("DDD_BACKUP_DIR"); ok {
+		cfg.BackupDir = value
+	}
+	if value, ok := lookup("DDD_BACKUP_INTERVAL_HOURS"); ok {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid DDD_BACKUP_INTERVAL_HOURS %q: %w", value, err)
+		}
+		cfg.BackupIntervalHours = parsed
+	}
+	if value, ok := lookup("DDD_BACKUP_RETENTION"); ok {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid DDD_BACKUP_RETENTION %q: %w", value, err)
+		}
+		cfg.BackupRetention = parsed
+	}
+	if value, ok := lookup("DDD_REPORT_RETENTION_DAYS"); ok {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid DDD_REPORT_RETENTION_DAYS %q: %w", value, err)
+		}
+		cfg.ReportRetentionDays = parsed
+	}
+	if value, ok := lookup("DDD_REPORT_DATA_RETENTION_DAYS"); ok {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid DDD_REPORT_DATA_RETENTION_DAYS %q: %w", value, err)
+		}
+		cfg.ReportDataRetentionDays = parsed
+	}
+	if value, ok := lookup("DDD_READ_ONLY"); ok {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid DDD_READ_ONLY %q: %w", value, err)
+		}
+		cfg.ReadOnly = parsed
+	}
+	if value, ok := lookup("DDD_READ_TIMEOUT_SECONDS"); ok {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid DDD_READ_TIMEOUT_SECONDS %q: %w", value, err)
+		}
+		cfg.ReadTimeoutSeconds = parsed
+	}
+	if value, ok := lookup("DDD_WRITE_TIMEOUT_SECONDS"); ok {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid DDD_WRITE_TIMEOUT_SECONDS %q: %w", value, err)
+		}
+		cfg.WriteTimeoutSeconds = parsed
+	}
+	if value, ok := lookup("DDD_IDLE_TIMEOUT_SECONDS"); ok {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid DDD_IDLE_TIMEOUT_SECONDS %q: %w", value, err)
+		}
+		cfg.IdleTimeoutSeconds = parsed
+	}
+	if value, ok := lookup("DDD_TRANSFER_TIMEOUT_SECONDS"); ok {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid DDD_TRANSFER_TIMEOUT_SECONDS %q: %w", value, err)
+		}
+		cfg.TransferTimeoutSeconds = parsed
+	}
+	return nil
+}
+
+// splitList splits a comma separated list, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Flags holds the command line flags that override the configuration
+type Flags struct {
+	ConfigPath string
+
+	fs          *flag.FlagSet
+	port        string
+	dbPath      string
+	uploadsDir  string
+	metrics     bool
+	webDir      string
+	corsOrigins string
+	timezone    string
+	readOnly    bool
+
+	readTimeoutSeconds     int
+	writeTimeoutSeconds    int
+	idleTimeoutSeconds     int
+	transferTimeoutSeconds int
+}
+
+// RegisterFlags defines the DDD command line flags on fs
+func RegisterFlags(fs *flag.FlagSet) *Flags {
+	defaults := Defaults()
+	f := &Flags{fs: fs}
+	fs.StringVar(&f.ConfigPath, "config", "", "Path to a YAML or JSON config file")
+	fs.StringVar(&f.port, "port", defaults.Port, "Server port (env DDD_PORT)")
+	fs.StringVar(&f.dbPath, "db", defaults.DBPath, "SQLite database path (env DDD_DB_PATH)")
+	fs.StringVar(&f.uploadsDir, "uploads", defaults.UploadsDir, "Uploads directory (env DDD_UPLOADS_DIR)")
+	fs.BoolVar(&f.metrics, "metrics", defaults.Metrics, "Expose Prometheus metrics at /metrics (env DDD_METRICS)")
+	fs.StringVar(&f.webDir, "web-dir", defaults.WebDir, "Serve the web UI from this directory instead of the embedded copy, for development (env DDD_WEB_DIR)")
+	fs.StringVar(&f.corsOrigins, "cors-origins", "", "Comma separated origins allowed to call the API, or * for any; CORS is off when empty (env DDD_CORS_ORIGINS)")
+	fs.StringVar(&f.timezone, "timezone", defaults.Timezone, "IANA time zone that iostat and ttop captures were taken in (env DDD_TIMEZONE)")
+	fs.BoolVar(&f.readOnly, "read-only", defaults.ReadOnly, "Start in read-only mode: existing reports are served but uploads, deletes and settings changes are refused (env DDD_READ_ONLY)")
+	fs.IntVar(&f.readTimeoutSeconds, "read-timeout-seconds", defaults.ReadTimeoutSeconds, "Seconds a request may take to be read, 0 for no limit (env DDD_READ_TIMEOUT_SECONDS)")
+	fs.IntVar(&f.writeTimeoutSeconds, "write-timeout-seconds", defaults.WriteTimeoutSeconds, "Seconds a response may take to be written, 0 for no limit (env DDD_WRITE_TIMEOUT_SECONDS)")
+	fs.IntVar(&f.idleTimeoutSeconds, "idle-timeout-seconds", defaults.IdleTimeoutSeconds, "Seconds a keep-alive connection waits for its next request, 0 to use the read timeout (env DDD_IDLE_TIMEOUT_SECONDS)")
+	fs.IntVar(&f.transferTimeoutSeconds, "transfer-timeout-seconds", defaults.TransferTimeoutSeconds, "Seconds an upload or download may take, replacing the read and write timeouts for them; 0 for no limit (env DDD_TRANSFER_TIMEOUT_SECONDS)")
+	return f
+}
+
+// Apply copies the flags explicitly set on the command line onto cfg, leaving
+// values from the environment, config file or defaults in place for the rest
+func (f *Flags) Apply(cfg *Config) {
+	f.fs.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "port":
+			cfg.Port = f.port
+		case "db":
+			cfg.DBPath = f.dbPath
+		case "uploads":
+			cfg.UploadsDir = f.uploadsDir
+		case "metrics":
+			cfg.Metrics = f.metrics
+		case "web-dir":
+			cfg.WebDir = f.webDir
+		case "cors-origins":
+			cfg.CORSOrigins = splitList(f.corsOrigins)
+		case "timezone":
+			cfg.Timezone = f.timezone
+		case "read-only":
+			cfg.ReadOnly = f.readOnly
+		case "read-timeout-seconds":
+			cfg.ReadTimeoutSeconds = f.readTimeoutSeconds
+		case "write-timeout-seconds":
+			cfg.WriteTimeoutSeconds = f.writeTimeoutSeconds
+		case "idle-timeout-seconds":
+			cfg.IdleTimeoutSeconds = f.idleTimeoutSeconds
+		case "transfer-timeout-seconds":
+			cfg.TransferTimeoutSeconds = f.transferTimeoutSeconds
+		}
+	})
 }
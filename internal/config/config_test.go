@@ -0,0 +1,299 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("Defaults without a file", func(t *testing.T) {
+		cfg, err := Load("")
+		require.NoError(t, err)
+		assert.Equal(t, Defaults(), cfg)
+	})
+
+	t.Run("YAML file overrides defaults", func(t *testing.T) {
+		path := writeConfigFile(t, "config.yaml", `
+port: "9090"
+uploads_dir: /data/uploads
+file_retention_days: 30
+metrics: true
+web_dir: ./web
+`)
+		cfg, err := Load(path)
+		require.NoError(t, err)
+
+		assert.Equal(t, "9090", cfg.Port)
+		assert.Equal(t, "/data/uploads", cfg.UploadsDir)
+		assert.Equal(t, 30, cfg.FileRetentionDays)
+		assert.True(t, cfg.Metrics)
+		assert.Equal(t, "./web", cfg.WebDir)
+		// Unset keys keep their defaults
+		assert.Equal(t, "./ddd.db", cfg.DBPath)
+		assert.Equal(t, 0.5, cfg.MaxDiskUsage)
+	})
+
+	t.Run("JSON file overrides defaults", func(t *testing.T) {
+		path := writeConfigFile(t, "config.json", `{"db_path": "/data/ddd.db", "max_disk_usage": 0.8, "max_report_bytes": 0, "max_chart_points": 200, "report_timeout_seconds": 60}`)
+		cfg, err := Load(path)
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(0), cfg.MaxReportBytes)
+		assert.Equal(t, 200, cfg.MaxChartPoints)
+		assert.Equal(t, 60, cfg.ReportTimeoutSeconds)
+		assert.Equal(t, time.Minute, cfg.ReportTimeout())
+
+		assert.Equal(t, "/data/ddd.db", cfg.DBPath)
+		assert.Equal(t, 0.8, cfg.MaxDiskUsage)
+		assert.Equal(t, "8080", cfg.Port)
+	})
+
+	t.Run("CORS origins from a file", func(t *testing.T) {
+		path := writeConfigFile(t, "config.yaml", "cors_origins:\n  - https://dashboard.example.com\n  - \"*\"\n")
+		cfg, err := Load(path)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"https://dashboard.example.com", "*"}, cfg.CORSOrigins)
+	})
+
+	t.Run("Empty YAML file", func(t *testing.T) {
+		cfg, err := Load(writeConfigFile(t, "config.yaml", ""))
+		require.NoError(t, err)
+		assert.Equal(t, Defaults(), cfg)
+	})
+
+	t.Run("Environment overrides file", func(t *testing.T) {
+		path := writeConfigFile(t, "config.yaml", "port: \"9090\"\nfile_retention_days: 30\n")
+		t.Setenv("DDD_PORT", "7070")
+		t.Setenv("DDD_METRICS", "true")
+		t.Setenv("DDD_READ_ONLY", "1")
+
+		cfg, err := Load(path)
+		require.NoError(t, err)
+
+		assert.Equal(t, "7070", cfg.Port)
+		assert.True(t, cfg.Metrics)
+		assert.True(t, cfg.ReadOnly)
+		assert.Equal(t, 30, cfg.FileRetentionDays)
+	})
+
+	t.Run("Unknown keys are rejected", func(t *testing.T) {
+		_, err := Load(writeConfigFile(t, "config.yaml", "prot: \"9090\"\n"))
+		assert.Error(t, err)
+
+		_, err = Load(writeConfigFile(t, "config.json", `{"prot": "9090"}`))
+		assert.Error(t, err)
+	})
+
+	t.Run("Missing file", func(t *testing.T) {
+		_, err := Load(filepath.Join(t.TempDir(), "missing.yaml"))
+		assert.ErrorContains(t, err, "failed to read config file")
+	})
+
+	t.Run("Invalid environment value", func(t *testing.T) {
+		t.Setenv("DDD_FILE_RETENTION_DAYS", "two weeks")
+		_, err := Load("")
+		assert.ErrorContains(t, err, "DDD_FILE_RETENTION_DAYS")
+	})
+
+	t.Run("Invalid values fail validation", func(t *testing.T) {
+		_, err := Load(writeConfigFile(t, "config.yaml", "max_disk_usage: 50\n"))
+		assert.ErrorContains(t, err, "max_disk_usage must be between 0 and 1")
+
+		_, err = Load(writeConfigFile(t, "config.yaml", "cors_origins: [dashboard.example.com]\n"))
+		assert.ErrorContains(t, err, "cors_origins entries must be")
+
+		_, err = Load(writeConfigFile(t, "config.yaml", "timezone: Mars/Olympus_Mons\n"))
+		assert.ErrorContains(t, err, "timezone must be an IANA zone name")
+
+		_, err = Load(writeConfigFile(t, "config.yaml", "max_report_bytes: -1\n"))
+		assert.ErrorContains(t, err, "max_report_bytes must not be negative")
+
+		_, err = Load(writeConfigFile(t, "config.yaml", "max_chart_points: -1\n"))
+		assert.ErrorContains(t, err, "max_chart_points must not be negative")
+
+		_, err = Load(writeConfigFile(t, "config.yaml", "report_timeout_seconds: -1\n"))
+		assert.ErrorContains(t, err, "report_timeout_seconds must not be negative")
+
+		_, err = Load(writeConfigFile(t, "config.yaml", "backup_interval_hours: -1\n"))
+		assert.ErrorContains(t, err, "backup_interval_hours must not be negative")
+
+		_, err = Load(writeConfigFile(t, "config.yaml", "backup_retention: -1\n"))
+		assert.ErrorContains(t, err, "backup_retention must not be negative")
+
+		_, err = Load(writeConfigFile(t, "config.yaml", "report_retention_days: -1\n"))
+		assert.ErrorContains(t, err, "report_retention_days must not be negative")
+
+		_, err = Load(writeConfigFile(t, "config.yaml", "report_data_retention_days: -1\n"))
+		assert.ErrorContains(t, err, "report_data_retention_days must not be negative")
+
+		for _, key := range []string{"read_timeout_seconds", "write_timeout_seconds", "idle_timeout_seconds", "transfer_timeout_seconds"} {
+			_, err = Load(writeConfigFile(t, "config.yaml", key+": -1\n"))
+			assert.ErrorContains(t, err, key+" must not be negative")
+		}
+	})
+
+	t.Run("Server timeouts from a file", func(t *testing.T) {
+		cfg, err := Load(writeConfigFile(t, "config.yaml", "read_timeout_seconds: 30\ntransfer_timeout_seconds: 0\n"))
+		require.NoError(t, err)
+
+		assert.Equal(t, 30*time.Second, cfg.ReadTimeout())
+		assert.Zero(t, cfg.TransferTimeout())
+		// Unset timeouts keep their defaults
+		assert.Equal(t, 15*time.Second, cfg.WriteTimeout())
+		assert.Equal(t, time.Minute, cfg.IdleTimeout())
+	})
+}
+
+func TestConfig_Location(t *testing.T) {
+	t.Run("Defaults to UTC", func(t *testing.T) {
+		loc, err := Defaults().Location()
+		require.NoError(t, err)
+		assert.Equal(t, time.UTC, loc)
+
+		loc, err = (&Config{}).Location()
+		require.NoError(t, err)
+		assert.Equal(t, time.UTC, loc)
+	})
+
+	t.Run("Named zone", func(t *testing.T) {
+		loc, err := (&Config{Timezone: "America/New_York"}).Location()
+		require.NoError(t, err)
+		assert.Equal(t, "America/New_York", loc.String())
+	})
+}
+
+func TestLoad_Environment(t *testing.T) {
+	t.Setenv("DDD_PORT", "9000")
+	t.Setenv("DDD_DB_PATH", "/var/lib/ddd/ddd.db")
+	t.Setenv("DDD_UPLOADS_DIR", "/var/lib/ddd/uploads")
+	t.Setenv("DDD_MAX_DISK_USAGE", "0.75")
+	t.Setenv("DDD_FILE_RETENTION_DAYS", "7")
+	t.Setenv("DDD_WEB_DIR", "/src/ddd/web")
+	t.Setenv("DDD_CORS_ORIGINS", "https://dashboard.example.com, http://localhost:3000,")
+	t.Setenv("DDD_TIMEZONE", "Europe/Berlin")
+	t.Setenv("DDD_MAX_REPORT_BYTES", "1048576")
+	t.Setenv("DDD_MAX_CHART_POINTS", "0")
+	t.Setenv("DDD_REPORT_TIMEOUT_SECONDS", "0")
+	t.Setenv("DDD_BACKUP_DIR", "/var/lib/ddd/backups")
+	t.Setenv("DDD_BACKUP_INTERVAL_HOURS", "6")
+	t.Setenv("DDD_BACKUP_RETENTION", "0")
+	t.Setenv("DDD_REPORT_RETENTION_DAYS", "90")
+	t.Setenv("DDD_REPORT_DATA_RETENTION_DAYS", "30")
+	t.Setenv("DDD_READ_ONLY", "true")
+	t.Setenv("DDD_READ_TIMEOUT_SECONDS", "20")
+	t.Setenv("DDD_WRITE_TIMEOUT_SECONDS", "25")
+	t.Setenv("DDD_IDLE_TIMEOUT_SECONDS", "0")
+	t.Setenv("DDD_TRANSFER_TIMEOUT_SECONDS", "7200")
+
+	cfg, err := Load("")
+	require.NoError(t, err)
+
+	assert.Equal(t, "9000", cfg.Port)
+	assert.Equal(t, "/var/lib/ddd/ddd.db", cfg.DBPath)
+	assert.Equal(t, "/var/lib/ddd/uploads", cfg.UploadsDir)
+	assert.Equal(t, 0.75, cfg.MaxDiskUsage)
+	assert.Equal(t, 7, cfg.FileRetentionDays)
+	assert.Equal(t, "/src/ddd/web", cfg.WebDir)
+	assert.Equal(t, []string{"https://dashboard.example.com", "http://localhost:3000"}, cfg.CORSOrigins)
+	assert.Equal(t, "Europe/Berlin", cfg.Timezone)
+	assert.Equal(t, int64(1048576), cfg.MaxReportBytes)
+	assert.Equal(t, 0, cfg.MaxChartPoints)
+	assert.Zero(t, cfg.ReportTimeout())
+	assert.Equal(t, "/var/lib/ddd/backups", cfg.BackupDir)
+	assert.Equal(t, 6*time.Hour, cfg.BackupInterval())
+	assert.Equal(t, 0, cfg.BackupRetention)
+	assert.Equal(t, 90, cfg.ReportRetentionDays)
+	assert.Equal(t, 30, cfg.ReportDataRetentionDays)
+	assert.True(t, cfg.ReadOnly)
+	assert.Equal(t, 20*time.Second, cfg.ReadTimeout())
+	assert.Equal(t, 25*time.Second, cfg.WriteTimeout())
+	assert.Zero(t, cfg.IdleTimeout())
+	assert.Equal(t, 2*time.Hour, cfg.TransferTimeout())
+}
+
+func TestFlags_Apply(t *testing.T) {
+	t.Setenv("DDD_PORT", "9000")
+	t.Setenv("DDD_DB_PATH", "/var/lib/ddd/ddd.db")
+	t.Setenv("DDD_UPLOADS_DIR", "/var/lib/ddd/uploads")
+
+	t.Run("Explicit flags override the environment", func(t *testing.T) {
+		fs := flag.NewFlagSet("ddd", flag.ContinueOnError)
+		flags := RegisterFlags(fs)
+		require.NoError(t, fs.Parse([]string{"-port", "8181", "-uploads", "/tmp/uploads", "-metrics", "-web-dir", "./web",
+			"--cors-origins", "https://dashboard.example.com", "-timezone", "Asia/Tokyo", "--read-only",
+			"-write-timeout-seconds", "45", "-transfer-timeout-seconds", "0"}))
+
+		cfg, err := Load(flags.ConfigPath)
+		require.NoError(t, err)
+		flags.Apply(cfg)
+
+		assert.Equal(t, "8181", cfg.Port)
+		assert.Equal(t, "/tmp/uploads", cfg.UploadsDir)
+		assert.True(t, cfg.Metrics)
+		assert.Equal(t, "./web", cfg.WebDir)
+		assert.Equal(t, []string{"https://dashboard.example.com"}, cfg.CORSOrigins)
+		assert.Equal(t, "Asia/Tokyo", cfg.Timezone)
+		assert.True(t, cfg.ReadOnly)
+		assert.Equal(t, 45*time.Second, cfg.WriteTimeout())
+		assert.Zero(t, cfg.TransferTimeout())
+		// Unset flags keep the environment value instead of the flag default
+		assert.Equal(t, "/var/lib/ddd/ddd.db", cfg.DBPath)
+	})
+
+	t.Run("Unset flags leave the environment in place", func(t *testing.T) {
+		fs := flag.NewFlagSet("ddd", flag.ContinueOnError)
+		flags := RegisterFlags(fs)
+		require.NoError(t, fs.Parse(nil))
+
+		cfg, err := Load(flags.ConfigPath)
+		require.NoError(t, err)
+		flags.Apply(cfg)
+
+		assert.Equal(t, "9000", cfg.Port)
+		assert.Equal(t, "/var/lib/ddd/uploads", cfg.UploadsDir)
+		assert.False(t, cfg.Metrics)
+		assert.False(t, cfg.ReadOnly)
+	})
+
+	t.Run("Config path comes from the flag", func(t *testing.T) {
+		path := writeConfigFile(t, "config.yaml", "file_retention_days: 21\n")
+		fs := flag.NewFlagSet("ddd", flag.ContinueOnError)
+		flags := RegisterFlags(fs)
+		require.NoError(t, fs.Parse([]string{"-config", path}))
+
+		cfg, err := Load(flags.ConfigPath)
+		require.NoError(t, err)
+		flags.Apply(cfg)
+
+		assert.Equal(t, 21, cfg.FileRetentionDays)
+		assert.Equal(t, "9000", cfg.Port)
+	})
+}
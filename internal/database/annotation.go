@@ -0,0 +1,139 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"database/sql"
+	"log"
+	"time"
+)
+
+// ReportAnnotation labels a moment on a report's charts, e.g. when a deploy started or an
+// incident was declared
+type ReportAnnotation struct {
+	ID          int       `json:"id"`
+	ReportID    int       `json:"report_id"`
+	Timestamp   time.Time `json:"timestamp"`
+	Label       string    `json:"label"`
+	CreatedTime time.Time `json:"created_time"`
+}
+
+func createAnnotationTables(db *sql.DB) error {
+	_, err := db.Exec(`
+	CREATE TABLE IF NOT EXISTS report_annotations (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		report_id INTEGER NOT NULL,
+		timestamp DATETIME NOT NULL,
+		label TEXT NOT NULL,
+		created_time DATETIME NOT NULL,
+		FOREIGN KEY (report_id) REFERENCES reports(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_report_annotations_report_id ON report_annotations(report_id);
+	`)
+	return err
+}
+
+// InsertReportAnnotation adds an annotation to a report, setting its ID and created time.
+// Timestamps are kept in UTC so they sort in time order whatever zone they were given in
+func (db *DB) InsertReportAnnotation(annotation *ReportAnnotation) error {
+	annotation.Timestamp = annotation.Timestamp.UTC()
+	annotation.CreatedTime = time.Now()
+	result, err := db.Exec(`INSERT INTO report_annotations (report_id, timestamp, label, created_time) VALUES (?, ?, ?, ?)`,
+		annotation.ReportID, annotation.Timestamp, annotation.Label, annotation.CreatedTime)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	annotation.ID = int(id)
+	return nil
+}
+
+// GetReportAnnotation retrieves one of a report's annotations
+func (db *DB) GetReportAnnotation(reportID, annotationID int) (*ReportAnnotation, error) {
+	query := `
+		SELECT id, report_id, timestamp, label, created_time
+		FROM report_annotations WHERE report_id = ? AND id = ?
+	`
+	annotation := &ReportAnnotation{}
+	err := db.QueryRow(query, reportID, annotationID).Scan(&annotation.ID, &annotation.ReportID, &annotation.Timestamp, &annotation.Label, &annotation.CreatedTime)
+	if err != nil {
+		return nil, err
+	}
+	return annotation, nil
+}
+
+// GetReportAnnotations lists a report's annotations in time order
+func (db *DB) GetReportAnnotations(reportID int) ([]*ReportAnnotation, error) {
+	query := `
+		SELECT id, report_id, timestamp, label, created_time
+		FROM report_annotations WHERE report_id = ?
+		ORDER BY timestamp, id
+	`
+	rows, err := db.Query(query, reportID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Error closing rows: %v", err)
+		}
+	}()
+
+	annotations := make([]*ReportAnnotation, 0)
+	for rows.Next() {
+		annotation := &ReportAnnotation{}
+		if err := rows.Scan(&annotation.ID, &annotation.ReportID, &annotation.Timestamp, &annotation.Label, &annotation.CreatedTime); err != nil {
+			return nil, err
+		}
+		annotations = append(annotations, annotation)
+	}
+	return annotations, rows.Err()
+}
+
+// UpdateReportAnnotation moves or relabels an annotation
+func (db *DB) UpdateReportAnnotation(annotation *ReportAnnotation) error {
+	annotation.Timestamp = annotation.Timestamp.UTC()
+	result, err := db.Exec(`UPDATE report_annotations SET timestamp = ?, label = ? WHERE report_id = ? AND id = ?`,
+		annotation.Timestamp, annotation.Label, annotation.ReportID, annotation.ID)
+	if err != nil {
+		return err
+	}
+	return requireRowsAffected(result)
+}
+
+// DeleteReportAnnotation removes one of a report's annotations
+func (db *DB) DeleteReportAnnotation(reportID, annotationID int) error {
+	result, err := db.Exec(`DELETE FROM report_annotations WHERE report_id = ? AND id = ?`, reportID, annotationID)
+	if err != nil {
+		return err
+	}
+	return requireRowsAffected(result)
+}
+
+// requireRowsAffected returns sql.ErrNoRows when a statement changed nothing
+func requireRowsAffected(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabase_ReportAnnotations(t *testing.T) {
+	db := testDB(t)
+
+	file := &File{Hash: "annotation-hash", OriginalName: "iostat.txt", FileType: "iostat", FileSize: 10, UploadTime: time.Now(), FilePath: "/uploads/annotation-hash"}
+	require.NoError(t, db.InsertFile(file))
+	report := &Report{FileID: file.ID, ReportType: "iostat", Status: "completed", CreatedTime: time.Now(), DDDVersion: "1.0.0", ReportData: `{"type":"iostat"}`}
+	require.NoError(t, db.InsertReport(report))
+
+	annotations, err := db.GetReportAnnotations(report.ID)
+	require.NoError(t, err)
+	assert.Empty(t, annotations)
+
+	start := time.Date(2025, 3, 4, 12, 0, 0, 0, time.UTC)
+	deploy := &ReportAnnotation{ReportID: report.ID, Timestamp: start.Add(10 * time.Minute), Label: "deploy started"}
+	require.NoError(t, db.InsertReportAnnotation(deploy))
+	assert.NotZero(t, deploy.ID)
+	assert.False(t, deploy.CreatedTime.IsZero())
+
+	// Given in another zone, it still sorts by the moment it names
+	incident := &ReportAnnotation{ReportID: report.ID, Timestamp: start.Add(5 * time.Minute).In(time.FixedZone("EST", -5*3600)), Label: "incident declared"}
+	require.NoError(t, db.InsertReportAnnotation(incident))
+
+	annotations, err = db.GetReportAnnotations(report.ID)
+	require.NoError(t, err)
+	require.Len(t, annotations, 2)
+	assert.Equal(t, "incident declared", annotations[0].Label, "Annotations are ordered by time")
+	assert.True(t, start.Add(5*time.Minute).Equal(annotations[0].Timestamp))
+	assert.Equal(t, "deploy started", annotations[1].Label)
+
+	t.Run("Update an annotation", func(t *testing.T) {
+		deploy.Label = "deploy finished"
+		deploy.Timestamp = start.Add(20 * time.Minute)
+		require.NoError(t, db.UpdateReportAnnotation(deploy))
+
+		updated, err := db.GetReportAnnotation(report.ID, deploy.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "deploy finished", updated.Label)
+		assert.True(t, start.Add(20*time.Minute).Equal(updated.Timestamp))
+
+		missing := &ReportAnnotation{ID: deploy.ID, ReportID: report.ID + 1, Timestamp: start, Label: "elsewhere"}
+		assert.ErrorIs(t, db.UpdateReportAnnotation(missing), sql.ErrNoRows, "Annotations belong to their report")
+	})
+
+	t.Run("Delete an annotation", func(t *testing.T) {
+		require.NoError(t, db.DeleteReportAnnotation(report.ID, incident.ID))
+		assert.ErrorIs(t, db.DeleteReportAnnotation(report.ID, incident.ID), sql.ErrNoRows)
+
+		_, err := db.GetReportAnnotation(report.ID, incident.ID)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+
+	t.Run("Deleting a report removes its annotations", func(t *testing.T) {
+		require.NoError(t, db.DeleteReport(report.ID))
+
+		annotations, err := db.GetReportAnnotations(report.ID)
+		require.NoError(t, err)
+		assert.Empty(t, annotations)
+	})
+}
@@ -0,0 +1,100 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	backupPrefix = "ddd-"
+	backupSuffix = ".db"
+	// backupTimeFormat sorts backups oldest first by name
+	backupTimeFormat = "20060102-150405.000"
+)
+
+// Backup is a point-in-time copy of the database
+type Backup struct {
+	Path        string    `json:"path"`
+	Size        int64     `json:"size"`
+	CreatedTime time.Time `json:"created_time"`
+}
+
+// Backup writes a timestamped copy of the database to dir with VACUUM INTO, which only
+// holds a read transaction so reports keep being generated while it runs. Afterwards the
+// oldest backups in dir are removed so at most retain remain, all of them when retain is 0
+func (db *DB) Backup(dir string, retain int) (*Backup, error) {
+	db.backupMu.Lock()
+	defer db.backupMu.Unlock()
+
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	now := time.Now()
+	path := filepath.Join(dir, backupPrefix+now.UTC().Format(backupTimeFormat)+backupSuffix)
+	if _, err := db.Exec(`VACUUM INTO ?`, path); err != nil {
+		return nil, fmt.Errorf("failed to back up database: %w", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat backup: %w", err)
+	}
+
+	if retain > 0 {
+		if err := pruneBackups(dir, retain); err != nil {
+			// The backup itself succeeded, an old one lingering is not worth failing it
+			log.Printf("Error pruning old backups in %s: %v", dir, err)
+		}
+	}
+
+	return &Backup{Path: path, Size: info.Size(), CreatedTime: now}, nil
+}
+
+// pruneBackups removes the oldest backups in dir so at most retain remain. Only files
+// named like the backups DDD writes are considered
+func pruneBackups(dir string, retain int) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.Type().IsRegular() && strings.HasPrefix(name, backupPrefix) && strings.HasSuffix(name, backupSuffix) {
+			names = append(names, name)
+		}
+	}
+	if len(names) <= retain {
+		return nil
+	}
+
+	sort.Strings(names)
+	for _, name := range names[:len(names)-retain] {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			return err
+		}
+		log.Printf("Removed old backup %s", name)
+	}
+	return nil
+}
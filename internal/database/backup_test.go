@@ -0,0 +1,69 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabase_Backup(t *testing.T) {
+	db := testDB(t)
+	require.NoError(t, db.SetSetting("report_theme", "dark"))
+	dir := filepath.Join(t.TempDir(), "backups")
+
+	t.Run("Backup is a usable copy", func(t *testing.T) {
+		backup, err := db.Backup(dir, 0)
+		require.NoError(t, err)
+
+		assert.Equal(t, dir, filepath.Dir(backup.Path))
+		assert.Regexp(t, `^ddd-\d{8}-\d{6}\.\d{3}\.db$`, filepath.Base(backup.Path))
+		info, err := os.Stat(backup.Path)
+		require.NoError(t, err)
+		assert.Equal(t, info.Size(), backup.Size)
+		assert.Positive(t, backup.Size)
+
+		restored, err := Initialize(backup.Path)
+		require.NoError(t, err)
+		defer func() {
+			assert.NoError(t, restored.Close())
+		}()
+		value, err := restored.GetSetting("report_theme")
+		require.NoError(t, err)
+		assert.Equal(t, "dark", value)
+	})
+
+	t.Run("Old backups are pruned", func(t *testing.T) {
+		unrelated := filepath.Join(dir, "notes.txt")
+		require.NoError(t, os.WriteFile(unrelated, []byte("keep me"), 0600))
+
+		var newest *Backup
+		for i := 0; i < 3; i++ {
+			backup, err := db.Backup(dir, 2)
+			require.NoError(t, err)
+			newest = backup
+		}
+
+		matches, err := filepath.Glob(filepath.Join(dir, "ddd-*.db"))
+		require.NoError(t, err)
+		assert.Len(t, matches, 2)
+		assert.Contains(t, matches, newest.Path)
+		assert.FileExists(t, unrelated)
+	})
+}
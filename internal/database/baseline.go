@@ -0,0 +1,118 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"database/sql"
+	"log"
+	"time"
+)
+
+// Baseline names a completed report as the known-good profile other reports are compared against,
+// e.g. one per hardware class
+type Baseline struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	ReportID    int       `json:"report_id"`
+	ReportType  string    `json:"report_type"`
+	CreatedTime time.Time `json:"created_time"`
+}
+
+func createBaselineTables(db *sql.DB) error {
+	_, err := db.Exec(`
+	CREATE TABLE IF NOT EXISTS baselines (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT UNIQUE NOT NULL,
+		report_id INTEGER NOT NULL,
+		created_time DATETIME NOT NULL,
+		FOREIGN KEY (report_id) REFERENCES reports(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_baselines_report_id ON baselines(report_id);
+	`)
+	return err
+}
+
+// SaveBaseline marks a report as the baseline called name. Saving an existing name points it at
+// the new report, so a baseline can be refreshed without renaming it
+func (db *DB) SaveBaseline(name string, reportID int) (*Baseline, error) {
+	query := `
+		INSERT INTO baselines (name, report_id, created_time) VALUES (?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET report_id = excluded.report_id, created_time = excluded.created_time
+	`
+	if _, err := db.Exec(query, name, reportID, time.Now()); err != nil {
+		return nil, err
+	}
+	return db.GetBaseline(name)
+}
+
+// GetBaseline retrieves a baseline by name
+func (db *DB) GetBaseline(name string) (*Baseline, error) {
+	query := `
+		SELECT b.id, b.name, b.report_id, r.report_type, b.created_time
+		FROM baselines b JOIN reports r ON r.id = b.report_id
+		WHERE b.name = ?
+	`
+	baseline := &Baseline{}
+	err := db.QueryRow(query, name).Scan(&baseline.ID, &baseline.Name, &baseline.ReportID, &baseline.ReportType, &baseline.CreatedTime)
+	if err != nil {
+		return nil, err
+	}
+	return baseline, nil
+}
+
+// GetBaselines lists every baseline ordered by name
+func (db *DB) GetBaselines() ([]*Baseline, error) {
+	query := `
+		SELECT b.id, b.name, b.report_id, r.report_type, b.created_time
+		FROM baselines b JOIN reports r ON r.id = b.report_id
+		ORDER BY b.name
+	`
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Error closing rows: %v", err)
+		}
+	}()
+
+	baselines := make([]*Baseline, 0)
+	for rows.Next() {
+		baseline := &Baseline{}
+		if err := rows.Scan(&baseline.ID, &baseline.Name, &baseline.ReportID, &baseline.ReportType, &baseline.CreatedTime); err != nil {
+			return nil, err
+		}
+		baselines = append(baselines, baseline)
+	}
+	return baselines, rows.Err()
+}
+
+// DeleteBaseline removes a baseline, the report it points at is kept
+func (db *DB) DeleteBaseline(name string) error {
+	result, err := db.Exec(`DELETE FROM baselines WHERE name = ?`, name)
+	if err != nil {
+		return err
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabase_Baselines(t *testing.T) {
+	db := testDB(t)
+
+	file := &File{Hash: "baseline-hash", OriginalName: "iostat.txt", FileType: "iostat", FileSize: 10, UploadTime: time.Now(), FilePath: "/uploads/baseline-hash"}
+	require.NoError(t, db.InsertFile(file))
+	var reports []*Report
+	for i := 0; i < 2; i++ {
+		report := &Report{FileID: file.ID, ReportType: "iostat", Status: "completed", CreatedTime: time.Now(), DDDVersion: "1.0.0", ReportData: `{"type":"iostat"}`}
+		require.NoError(t, db.InsertReport(report))
+		reports = append(reports, report)
+	}
+
+	baselines, err := db.GetBaselines()
+	require.NoError(t, err)
+	assert.Empty(t, baselines)
+
+	saved, err := db.SaveBaseline("r5.2xlarge", reports[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "r5.2xlarge", saved.Name)
+	assert.Equal(t, reports[0].ID, saved.ReportID)
+	assert.Equal(t, "iostat", saved.ReportType)
+
+	_, err = db.SaveBaseline("i3.large", reports[0].ID)
+	require.NoError(t, err)
+
+	// Saving an existing name points it at the new report
+	updated, err := db.SaveBaseline("r5.2xlarge", reports[1].ID)
+	require.NoError(t, err)
+	assert.Equal(t, saved.ID, updated.ID)
+	assert.Equal(t, reports[1].ID, updated.ReportID)
+
+	baselines, err = db.GetBaselines()
+	require.NoError(t, err)
+	require.Len(t, baselines, 2)
+	assert.Equal(t, "i3.large", baselines[0].Name, "Baselines are ordered by name")
+	assert.Equal(t, "r5.2xlarge", baselines[1].Name)
+
+	_, err = db.GetBaseline("m5.large")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	t.Run("Baselines are not purged", func(t *testing.T) {
+		_, err := db.Exec(`UPDATE files SET deleted = TRUE, deleted_time = ? WHERE id = ?`, time.Now().Add(-48*time.Hour), file.ID)
+		require.NoError(t, err)
+
+		purgeable, err := db.GetReportsForPurge(time.Now())
+		require.NoError(t, err)
+		assert.Empty(t, purgeable, "Both reports are baselines")
+	})
+
+	t.Run("Deleting a report removes its baselines", func(t *testing.T) {
+		require.NoError(t, db.DeleteReport(reports[0].ID))
+
+		_, err := db.GetBaseline("i3.large")
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+
+	t.Run("Delete a baseline", func(t *testing.T) {
+		require.NoError(t, db.DeleteBaseline("r5.2xlarge"))
+		assert.ErrorIs(t, db.DeleteBaseline("r5.2xlarge"), sql.ErrNoRows)
+
+		_, err := db.GetReportByID(reports[1].ID)
+		assert.NoError(t, err, "The report outlives its baseline")
+	})
+}
@@ -16,8 +16,12 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"strings"
+	"sync"
 	"time"
 
 	_ "github.com/glebarez/go-sqlite"
@@ -26,6 +30,21 @@ import (
 // DB wraps the sql.DB with additional methods
 type DB struct {
 	*sql.DB
+
+	backupMu sync.Mutex // Serializes backups so they get distinct file names and prune safely
+
+	// Settings are read on every request and worker pass but rarely written, so GetSetting
+	// keeps the values it has read, including settings that are not set. SetSetting bumps
+	// settingsGen, so a value read before a change can't be cached after it
+	settingsMu    sync.RWMutex
+	settingsCache map[string]cachedSetting
+	settingsGen   uint64
+}
+
+// cachedSetting is a setting value read by GetSetting, or a marker that it is not set
+type cachedSetting struct {
+	value string
+	set   bool
 }
 
 // Initialize creates and initializes the SQLite database
@@ -45,7 +64,7 @@ func Initialize(dbPath string) (*DB, error) {
 		return nil, err
 	}
 
-	return &DB{db}, nil
+	return &DB{DB: db}, nil
 }
 
 func createTables(db *sql.DB) error {
@@ -59,22 +78,48 @@ func createTables(db *sql.DB) error {
 		upload_time DATETIME NOT NULL,
 		file_path TEXT NOT NULL,
 		deleted BOOLEAN DEFAULT FALSE,
-		deleted_time DATETIME
+		deleted_time DATETIME,
+		detection_confidence REAL NOT NULL DEFAULT 0,
+		detection_signal TEXT NOT NULL DEFAULT '',
+		upload_group_id TEXT NOT NULL DEFAULT '', -- Shared by files uploaded together, '' when uploaded alone
+		supersedes_file_id INTEGER REFERENCES files(id) ON DELETE SET NULL, -- The earlier capture this file is a newer version of
+		pinned BOOLEAN NOT NULL DEFAULT FALSE, -- Pinned files are never removed by cleanup
+		hash_algorithm TEXT NOT NULL DEFAULT 'sha256' -- Algorithm the content hash was computed with
 	);
 
 	CREATE TABLE IF NOT EXISTS reports (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		file_id INTEGER NOT NULL,
 		report_type TEXT NOT NULL,
-		status TEXT NOT NULL, -- 'pending', 'running', 'completed', 'failed'
+		status TEXT NOT NULL, -- 'pending', 'running', 'completed', 'failed', 'cancelled', 'purged'
 		created_time DATETIME NOT NULL,
 		completed_time DATETIME,
+		generation_ms INTEGER NOT NULL DEFAULT 0,
+		priority INTEGER NOT NULL DEFAULT 0, -- Higher priorities are generated first
+		starred BOOLEAN NOT NULL DEFAULT FALSE,
 		ddd_version TEXT NOT NULL,
 		report_data TEXT, -- JSON data
 		error_message TEXT,
 		FOREIGN KEY (file_id) REFERENCES files(id)
 	);
 
+	CREATE TABLE IF NOT EXISTS file_uploads (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		file_id INTEGER NOT NULL,
+		original_name TEXT NOT NULL,
+		upload_time DATETIME NOT NULL,
+		FOREIGN KEY (file_id) REFERENCES files(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS report_logs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		report_id INTEGER NOT NULL,
+		timestamp DATETIME NOT NULL,
+		level TEXT NOT NULL, -- 'INFO', 'WARN', 'ERROR'
+		message TEXT NOT NULL,
+		FOREIGN KEY (report_id) REFERENCES reports(id) ON DELETE CASCADE
+	);
+
 	CREATE TABLE IF NOT EXISTS worker_status (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		worker_type TEXT NOT NULL,
@@ -89,29 +134,161 @@ func createTables(db *sql.DB) error {
 		updated_time DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
 	);
 
+	CREATE TABLE IF NOT EXISTS settings_audit (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		key TEXT NOT NULL,
+		old_value TEXT, -- NULL when the setting was first created
+		new_value TEXT NOT NULL,
+		changed_time DATETIME NOT NULL
+	);
+
 	CREATE INDEX IF NOT EXISTS idx_files_hash ON files(hash);
 	CREATE INDEX IF NOT EXISTS idx_files_upload_time ON files(upload_time);
 	CREATE INDEX IF NOT EXISTS idx_reports_file_id ON reports(file_id);
 	CREATE INDEX IF NOT EXISTS idx_reports_status ON reports(status);
+	CREATE INDEX IF NOT EXISTS idx_report_logs_report_id ON report_logs(report_id);
+	CREATE INDEX IF NOT EXISTS idx_file_uploads_file_id ON file_uploads(file_id);
+	CREATE INDEX IF NOT EXISTS idx_settings_audit_key ON settings_audit(key);
 	`
 
-	_, err := db.Exec(schema)
+	if _, err := db.Exec(schema); err != nil {
+		return err
+	}
+
+	// Columns added after the initial schema, needed for databases created by older versions
+	if err := addColumnIfMissing(db, "files", "detection_confidence", "REAL NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := addColumnIfMissing(db, "files", "detection_signal", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := addColumnIfMissing(db, "files", "upload_group_id", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_files_upload_group_id ON files(upload_group_id)`); err != nil {
+		return err
+	}
+	if err := addColumnIfMissing(db, "files", "supersedes_file_id", "INTEGER REFERENCES files(id) ON DELETE SET NULL"); err != nil {
+		return err
+	}
+	if err := createSupersedesIndex(db); err != nil {
+		return err
+	}
+	if err := addColumnIfMissing(db, "files", "pinned", "BOOLEAN NOT NULL DEFAULT FALSE"); err != nil {
+		return err
+	}
+	if err := addColumnIfMissing(db, "files", "hash_algorithm", "TEXT NOT NULL DEFAULT 'sha256'"); err != nil {
+		return err
+	}
+	if err := addColumnIfMissing(db, "reports", "generation_ms", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := addColumnIfMissing(db, "reports", "priority", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := addColumnIfMissing(db, "reports", "starred", "BOOLEAN NOT NULL DEFAULT FALSE"); err != nil {
+		return err
+	}
+	if err := addTrimmedTimeColumn(db); err != nil {
+		return err
+	}
+	if err := createActiveReportIndex(db); err != nil {
+		return err
+	}
+
+	// Files uploaded before occurrences were tracked get their original upload as the first one
+	if _, err := db.Exec(`
+		INSERT INTO file_uploads (file_id, original_name, upload_time)
+		SELECT id, original_name, upload_time FROM files
+		WHERE id NOT IN (SELECT file_id FROM file_uploads)
+	`); err != nil {
+		return err
+	}
+
+	if err := createBaselineTables(db); err != nil {
+		return err
+	}
+
+	if err := createAnnotationTables(db); err != nil {
+		return err
+	}
+
+	return createSearchTables(db)
+}
+
+// addTrimmedTimeColumn adds reports.trimmed_time, recording when a report's data was
+// trimmed. Reports trimmed before the column existed are found once by the marker in
+// their data, so later trim passes don't have to search every report's data for it
+func addTrimmedTimeColumn(db *sql.DB) error {
+	exists, err := hasColumn(db, "reports", "trimmed_time")
+	if err != nil || exists {
+		return err
+	}
+	if _, err := db.Exec(`ALTER TABLE reports ADD COLUMN trimmed_time DATETIME`); err != nil {
+		return err
+	}
+	_, err = db.Exec(`UPDATE reports SET trimmed_time = ? WHERE instr(report_data, '"`+reportDataTrimmedKey+`"') > 0`, time.Now())
+	return err
+}
+
+// addColumnIfMissing adds a column to an existing table when it is not already present
+func addColumnIfMissing(db *sql.DB, table, column, definition string) error {
+	exists, err := hasColumn(db, table, column)
+	if err != nil || exists {
+		return err
+	}
+	_, err = db.Exec("ALTER TABLE " + table + " ADD COLUMN " + column + " " + definition)
 	return err
 }
 
+// hasColumn reports whether a table has a column
+func hasColumn(db *sql.DB, table, column string) (bool, error) {
+	rows, err := db.Query("PRAGMA table_info(" + table + ")")
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Error closing rows: %v", err)
+		}
+	}()
+
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			return false, err
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
+
 // File represents a file record in the database
 type File struct {
-	ID           int        `json:"id"`
-	Hash         string     `json:"hash"`
-	OriginalName string     `json:"original_name"`
-	FileType     string     `json:"file_type"`
-	FileSize     int64      `json:"file_size"`
-	UploadTime   time.Time  `json:"upload_time"`
-	FilePath     string     `json:"file_path"`
-	Deleted      bool       `json:"deleted"`
-	DeletedTime  *time.Time `json:"deleted_time,omitempty"`
+	ID                  int        `json:"id"`
+	Hash                string     `json:"hash"`
+	OriginalName        string     `json:"original_name"`
+	FileType            string     `json:"file_type"`
+	FileSize            int64      `json:"file_size"`
+	UploadTime          time.Time  `json:"upload_time"`
+	FilePath            string     `json:"file_path"`
+	Deleted             bool       `json:"deleted"`
+	DeletedTime         *time.Time `json:"deleted_time,omitempty"`
+	DetectionConfidence float64    `json:"detection_confidence"`
+	DetectionSignal     string     `json:"detection_signal"`
+	UploadGroupID       string     `json:"upload_group_id"`              // Shared by files uploaded together, empty when uploaded alone
+	SupersedesFileID    *int       `json:"supersedes_file_id,omitempty"` // The earlier capture this file is a newer version of
+	Pinned              bool       `json:"pinned"`                       // Pinned files are never removed by cleanup
+	HashAlgorithm       string     `json:"hash_algorithm"`               // Algorithm Hash was computed with, DefaultHashAlgorithm when unset
 }
 
+// DefaultHashAlgorithm is the content hash algorithm of files stored before it was configurable
+const DefaultHashAlgorithm = "sha256"
+
 // Report represents a report record in the database
 type Report struct {
 	ID            int        `json:"id"`
@@ -120,11 +297,32 @@ type Report struct {
 	Status        string     `json:"status"`
 	CreatedTime   time.Time  `json:"created_time"`
 	CompletedTime *time.Time `json:"completed_time,omitempty"`
+	GenerationMs  int64      `json:"generation_ms,omitempty"` // How long the worker took to generate the report
+	Priority      int        `json:"priority"`                // Pending reports with higher priorities are generated first
+	Starred       bool       `json:"starred"`                 // Marked by the user as one of the reports that mattered
 	DDDVersion    string     `json:"ddd_version"`
 	ReportData    string     `json:"report_data,omitempty"`
 	ErrorMessage  string     `json:"error_message,omitempty"`
 }
 
+// FileUpload records one upload of a file's content. Identical content uploaded under
+// several names, e.g. the same iostat from two nodes, is stored once with an upload each
+type FileUpload struct {
+	ID           int       `json:"id"`
+	FileID       int       `json:"file_id"`
+	OriginalName string    `json:"original_name"`
+	UploadTime   time.Time `json:"upload_time"`
+}
+
+// ReportLog represents a log line written while generating a report
+type ReportLog struct {
+	ID        int       `json:"id"`
+	ReportID  int       `json:"report_id"`
+	Timestamp time.Time `json:"timestamp"`
+	Level     string    `json:"level"`
+	Message   string    `json:"message"`
+}
+
 // WorkerStatus represents worker status in the database
 type WorkerStatus struct {
 	ID         int       `json:"id"`
@@ -134,6 +332,71 @@ type WorkerStatus struct {
 	Message    string    `json:"message"`
 }
 
+// ErrAlreadySuperseded is returned by InsertFile when the file it supersedes already has a
+// newer version, e.g. when two uploads supersede the same file at once
+var ErrAlreadySuperseded = errors.New("file is already superseded")
+
+// createSupersedesIndex makes sure every file is superseded by at most one newer version.
+// Databases from before the index was unique keep the first newer version of a file and
+// lose the link from any later one
+func createSupersedesIndex(db *sql.DB) error {
+	if _, err := db.Exec(`DROP INDEX IF EXISTS idx_files_supersedes_file_id`); err != nil {
+		return err
+	}
+	result, err := db.Exec(`
+		UPDATE files SET supersedes_file_id = NULL
+		WHERE supersedes_file_id IS NOT NULL AND id != (
+			SELECT MIN(id) FROM files AS first WHERE first.supersedes_file_id = files.supersedes_file_id
+		)
+	`)
+	if err != nil {
+		return err
+	}
+	if unlinked, err := result.RowsAffected(); err == nil && unlinked > 0 {
+		log.Printf("Unlinked %d files from earlier versions that already had a newer one", unlinked)
+	}
+	_, err = db.Exec(`
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_files_supersedes_file_id_unique
+		ON files(supersedes_file_id) WHERE supersedes_file_id IS NOT NULL
+	`)
+	return err
+}
+
+// ErrReportAlreadyActive is returned when a report would become pending while another
+// report of the same type for the same file is pending or running, e.g. when two uploads
+// queue the same report at once
+var ErrReportAlreadyActive = errors.New("report is already pending or running")
+
+// createActiveReportIndex makes sure a file has at most one pending or running report of
+// each type. Databases from before the index keep the first queued report and fail the rest
+func createActiveReportIndex(db *sql.DB) error {
+	result, err := db.Exec(`
+		UPDATE reports SET status = 'failed', completed_time = ?, error_message = 'Duplicate of a report that was already queued'
+		WHERE status IN ('pending', 'running') AND id != (
+			SELECT MIN(id) FROM reports AS first
+			WHERE first.file_id = reports.file_id AND first.report_type = reports.report_type
+			  AND first.status IN ('pending', 'running')
+		)
+	`, time.Now())
+	if err != nil {
+		return err
+	}
+	if failed, err := result.RowsAffected(); err == nil && failed > 0 {
+		log.Printf("Failed %d duplicate queued reports", failed)
+	}
+	_, err = db.Exec(`
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_reports_active_unique
+		ON reports(file_id, report_type) WHERE status IN ('pending', 'running')
+	`)
+	return err
+}
+
+// isActiveReportConflict reports whether err is the active report index refusing a second
+// pending or running report
+func isActiveReportConflict(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed: reports.file_id, reports.report_type")
+}
+
 // Setting represents a configuration setting in the database
 type Setting struct {
 	Key         string    `json:"key"`
@@ -141,15 +404,32 @@ type Setting struct {
 	UpdatedTime time.Time `json:"updated_time"`
 }
 
+// SettingChange is an entry in the settings audit log
+type SettingChange struct {
+	ID          int       `json:"id"`
+	Key         string    `json:"key"`
+	OldValue    *string   `json:"old_value"` // nil when the change created the setting
+	NewValue    string    `json:"new_value"`
+	ChangedTime time.Time `json:"changed_time"`
+}
+
 // InsertFile inserts a new file record
 func (db *DB) InsertFile(file *File) error {
 	query := `
-		INSERT INTO files (hash, original_name, file_type, file_size, upload_time, file_path)
-		VALUES (?, ?, ?, ?, ?, ?)
+		INSERT INTO files (hash, hash_algorithm, original_name, file_type, file_size, upload_time, file_path, detection_confidence,
+		                   detection_signal, upload_group_id, supersedes_file_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
-	result, err := db.Exec(query, file.Hash, file.OriginalName, file.FileType,
-		file.FileSize, file.UploadTime, file.FilePath)
+	if file.HashAlgorithm == "" {
+		file.HashAlgorithm = DefaultHashAlgorithm
+	}
+	result, err := db.Exec(query, file.Hash, file.HashAlgorithm, file.OriginalName, file.FileType,
+		file.FileSize, file.UploadTime, file.FilePath, file.DetectionConfidence, file.DetectionSignal, file.UploadGroupID,
+		file.SupersedesFileID)
 	if err != nil {
+		if file.SupersedesFileID != nil && strings.Contains(err.Error(), "UNIQUE constraint failed: files.supersedes_file_id") {
+			return ErrAlreadySuperseded
+		}
 		return err
 	}
 
@@ -158,30 +438,103 @@ func (db *DB) InsertFile(file *File) error {
 		return err
 	}
 	file.ID = int(id)
+
+	return db.InsertFileUpload(&FileUpload{FileID: file.ID, OriginalName: file.OriginalName, UploadTime: file.UploadTime})
+}
+
+// InsertFileUpload records another upload of an existing file's content
+func (db *DB) InsertFileUpload(upload *FileUpload) error {
+	result, err := db.Exec(`INSERT INTO file_uploads (file_id, original_name, upload_time) VALUES (?, ?, ?)`,
+		upload.FileID, upload.OriginalName, upload.UploadTime)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	upload.ID = int(id)
 	return nil
 }
 
-// GetFileByHash retrieves a file by its hash
+// GetFileUploads retrieves every recorded upload of a file, oldest first
+func (db *DB) GetFileUploads(fileID int) ([]*FileUpload, error) {
+	query := `
+		SELECT id, file_id, original_name, upload_time
+		FROM file_uploads WHERE file_id = ? ORDER BY upload_time ASC, id ASC
+	`
+	rows, err := db.Query(query, fileID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Error closing rows: %v", err)
+		}
+	}()
+
+	uploads := make([]*FileUpload, 0)
+	for rows.Next() {
+		upload := &FileUpload{}
+		if err := rows.Scan(&upload.ID, &upload.FileID, &upload.OriginalName, &upload.UploadTime); err != nil {
+			return nil, err
+		}
+		uploads = append(uploads, upload)
+	}
+	return uploads, rows.Err()
+}
+
+// GetFileByHash retrieves a file by its hash. Digests of the supported algorithms differ in
+// length, so a hash identifies its file whichever algorithm it was computed with
 func (db *DB) GetFileByHash(hash string) (*File, error) {
 	query := `
-		SELECT id, hash, original_name, file_type, file_size, upload_time, file_path, deleted, deleted_time
+		SELECT id, hash, original_name, file_type, file_size, upload_time, file_path, deleted, deleted_time,
+		       detection_confidence, detection_signal, upload_group_id, supersedes_file_id, pinned, hash_algorithm
 		FROM files WHERE hash = ?
 	`
 	row := db.QueryRow(query, hash)
 
 	file := &File{}
 	err := row.Scan(&file.ID, &file.Hash, &file.OriginalName, &file.FileType,
-		&file.FileSize, &file.UploadTime, &file.FilePath, &file.Deleted, &file.DeletedTime)
+		&file.FileSize, &file.UploadTime, &file.FilePath, &file.Deleted, &file.DeletedTime,
+		&file.DetectionConfidence, &file.DetectionSignal, &file.UploadGroupID, &file.SupersedesFileID, &file.Pinned, &file.HashAlgorithm)
 	if err != nil {
 		return nil, err
 	}
 	return file, nil
 }
 
-// GetFiles retrieves files with optional filters
-func (db *DB) GetFiles(limit, offset int, includeDeleted bool, searchQuery string) ([]*File, error) {
+// GetHashAlgorithms returns the content hash algorithms stored files of fileSize bytes were
+// hashed with
+func (db *DB) GetHashAlgorithms(fileSize int64) ([]string, error) {
+	rows, err := db.Query(`SELECT DISTINCT hash_algorithm FROM files WHERE file_size = ? ORDER BY hash_algorithm`, fileSize)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Error closing rows: %v", err)
+		}
+	}()
+
+	algorithms := make([]string, 0)
+	for rows.Next() {
+		var algorithm string
+		if err := rows.Scan(&algorithm); err != nil {
+			return nil, err
+		}
+		algorithms = append(algorithms, algorithm)
+	}
+	return algorithms, rows.Err()
+}
+
+// GetFiles retrieves files with optional filters, newest first. When after is set the page starts
+// right after that cursor and offset should be 0
+func (db *DB) GetFiles(limit, offset int, includeDeleted bool, searchQuery string, after *FileCursor) ([]*File, error) {
 	query := `
-		SELECT id, hash, original_name, file_type, file_size, upload_time, file_path, deleted, deleted_time
+		SELECT id, hash, original_name, file_type, file_size, upload_time, file_path, deleted, deleted_time,
+		       detection_confidence, detection_signal, upload_group_id, supersedes_file_id, pinned, hash_algorithm
 		FROM files
 	`
 	args := []interface{}{}
@@ -197,11 +550,16 @@ func (db *DB) GetFiles(limit, offset int, includeDeleted bool, searchQuery strin
 		args = append(args, searchPattern, searchPattern, searchPattern)
 	}
 
+	if after != nil {
+		conditions = append(conditions, "(upload_time < ? OR (upload_time = ? AND id < ?))")
+		args = append(args, after.UploadTime, after.UploadTime, after.ID)
+	}
+
 	if len(conditions) > 0 {
 		query += " WHERE " + strings.Join(conditions, " AND ")
 	}
 
-	query += " ORDER BY upload_time DESC LIMIT ? OFFSET ?"
+	query += " ORDER BY upload_time DESC, id DESC LIMIT ? OFFSET ?"
 	args = append(args, limit, offset)
 
 	rows, err := db.Query(query, args...)
@@ -218,7 +576,8 @@ func (db *DB) GetFiles(limit, offset int, includeDeleted bool, searchQuery strin
 	for rows.Next() {
 		file := &File{}
 		err := rows.Scan(&file.ID, &file.Hash, &file.OriginalName, &file.FileType,
-			&file.FileSize, &file.UploadTime, &file.FilePath, &file.Deleted, &file.DeletedTime)
+			&file.FileSize, &file.UploadTime, &file.FilePath, &file.Deleted, &file.DeletedTime,
+			&file.DetectionConfidence, &file.DetectionSignal, &file.UploadGroupID, &file.SupersedesFileID, &file.Pinned, &file.HashAlgorithm)
 		if err != nil {
 			return nil, err
 		}
@@ -256,6 +615,64 @@ func (db *DB) GetFilesCount(includeDeleted bool, searchQuery string) (int, error
 	return count, nil
 }
 
+// StreamFiles calls fn for every file in ID order, reading batchSize rows at a time with an
+// id cursor so no query stays open while fn runs. Streaming stops at the first error fn returns
+func (db *DB) StreamFiles(includeDeleted bool, batchSize int, fn func(*File) error) error {
+	query := `
+		SELECT id, hash, original_name, file_type, file_size, upload_time, file_path, deleted, deleted_time,
+		       detection_confidence, detection_signal, upload_group_id, supersedes_file_id, pinned, hash_algorithm
+		FROM files
+		WHERE id > ?
+	`
+	if !includeDeleted {
+		query += " AND deleted = FALSE"
+	}
+	query += " ORDER BY id ASC LIMIT ?"
+
+	cursor := 0
+	for {
+		batch, err := db.queryFiles(query, cursor, batchSize)
+		if err != nil {
+			return err
+		}
+		for _, file := range batch {
+			if err := fn(file); err != nil {
+				return err
+			}
+		}
+		if len(batch) < batchSize {
+			return nil
+		}
+		cursor = batch[len(batch)-1].ID
+	}
+}
+
+// queryFiles reads every file row returned by query
+func (db *DB) queryFiles(query string, args ...interface{}) ([]*File, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Error closing rows: %v", err)
+		}
+	}()
+
+	files := make([]*File, 0)
+	for rows.Next() {
+		file := &File{}
+		err := rows.Scan(&file.ID, &file.Hash, &file.OriginalName, &file.FileType,
+			&file.FileSize, &file.UploadTime, &file.FilePath, &file.Deleted, &file.DeletedTime,
+			&file.DetectionConfidence, &file.DetectionSignal, &file.UploadGroupID, &file.SupersedesFileID, &file.Pinned, &file.HashAlgorithm)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	return files, rows.Err()
+}
+
 // MarkFileDeleted marks a file as deleted
 func (db *DB) MarkFileDeleted(fileID int) error {
 	query := `UPDATE files SET deleted = TRUE, deleted_time = ? WHERE id = ?`
@@ -280,14 +697,25 @@ func (db *DB) RestoreFile(fileID int, originalName, fileType string, fileSize in
 	return err
 }
 
-// InsertReport inserts a new report record
+// UpdateFileDetection records how confident the file type detection was and which signal it matched
+func (db *DB) UpdateFileDetection(fileID int, confidence float64, signal string) error {
+	query := `UPDATE files SET detection_confidence = ?, detection_signal = ? WHERE id = ?`
+	_, err := db.Exec(query, confidence, signal, fileID)
+	return err
+}
+
+// InsertReport inserts a new report record. A pending or running report returns
+// ErrReportAlreadyActive when its file already has one of the same type
 func (db *DB) InsertReport(report *Report) error {
 	query := `
-		INSERT INTO reports (file_id, report_type, status, created_time, ddd_version, report_data, error_message, completed_time)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO reports (file_id, report_type, status, created_time, priority, ddd_version, report_data, error_message, completed_time)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 	result, err := db.Exec(query, report.FileID, report.ReportType, report.Status,
-		report.CreatedTime, report.DDDVersion, report.ReportData, report.ErrorMessage, report.CompletedTime)
+		report.CreatedTime, report.Priority, report.DDDVersion, report.ReportData, report.ErrorMessage, report.CompletedTime)
+	if isActiveReportConflict(err) {
+		return ErrReportAlreadyActive
+	}
 	if err != nil {
 		return err
 	}
@@ -297,6 +725,10 @@ func (db *DB) InsertReport(report *Report) error {
 		return err
 	}
 	report.ID = int(id)
+
+	if report.Status == "completed" && report.ReportData != "" {
+		return indexReport(db.DB, report.ID, report.ReportData)
+	}
 	return nil
 }
 
@@ -304,66 +736,351 @@ func (db *DB) InsertReport(report *Report) error {
 func (db *DB) UpdateReport(reportID int, status string, reportData, errorMessage string) error {
 	query := `
 		UPDATE reports
-		SET status = ?, completed_time = ?, report_data = ?, error_message = ?
+		SET status = ?, completed_time = ?, report_data = ?, error_message = ?, trimmed_time = NULL
 		WHERE id = ?
 	`
 	completedTime := time.Now()
-	_, err := db.Exec(query, status, completedTime, reportData, errorMessage, reportID)
-	return err
+	if _, err := db.Exec(query, status, completedTime, reportData, errorMessage, reportID); err != nil {
+		return err
+	}
+
+	// Keep the search index in step with the report content
+	if status == "completed" {
+		return indexReport(db.DB, reportID, reportData)
+	}
+	return unindexReport(db.DB, reportID)
 }
 
-// GetReportsByFileID retrieves all reports for a file (without report data for efficiency)
-func (db *DB) GetReportsByFileID(fileID int) ([]*Report, error) {
-	query := `
-		SELECT id, file_id, report_type, status, created_time, completed_time,
-		       ddd_version, COALESCE(error_message, '') as error_message
-		FROM reports WHERE file_id = ? ORDER BY created_time DESC
-	`
-	rows, err := db.Query(query, fileID)
+// SetReportPriority changes the priority of a pending report. It returns sql.ErrNoRows when
+// the report does not exist or is no longer pending
+func (db *DB) SetReportPriority(reportID, priority int) error {
+	result, err := db.Exec(`UPDATE reports SET priority = ? WHERE id = ? AND status = 'pending'`, priority, reportID)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer func() {
-		if err := rows.Close(); err != nil {
-			log.Printf("Error closing rows: %v", err)
-		}
-	}()
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
 
-	reports := make([]*Report, 0)
-	for rows.Next() {
-		report := &Report{}
-		err := rows.Scan(&report.ID, &report.FileID, &report.ReportType, &report.Status,
-			&report.CreatedTime, &report.CompletedTime, &report.DDDVersion, &report.ErrorMessage)
-		if err != nil {
-			return nil, err
-		}
-		reports = append(reports, report)
+// SetReportStarred marks or unmarks a report as starred. It returns sql.ErrNoRows when the
+// report does not exist
+func (db *DB) SetReportStarred(reportID int, starred bool) error {
+	result, err := db.Exec(`UPDATE reports SET starred = ? WHERE id = ?`, starred, reportID)
+	if err != nil {
+		return err
 	}
-	return reports, nil
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
 }
 
-// GetPendingReports retrieves reports with pending status
-func (db *DB) GetPendingReports() ([]*Report, error) {
+// RequeueFailedReport puts a failed report back in the queue as pending, clearing its error
+// and recording dddVersion as the version that will generate it. It returns sql.ErrNoRows
+// when the report does not exist or is no longer failed, and ErrReportAlreadyActive when
+// another report of its type for its file is already queued
+func (db *DB) RequeueFailedReport(reportID int, dddVersion string) error {
 	query := `
-		SELECT id, file_id, report_type, status, created_time, completed_time,
-		       ddd_version, COALESCE(report_data, '') as report_data, COALESCE(error_message, '') as error_message
-		FROM reports WHERE status = 'pending' ORDER BY created_time ASC
+		UPDATE reports
+		SET status = 'pending', completed_time = NULL, generation_ms = 0, report_data = NULL, error_message = NULL, trimmed_time = NULL, ddd_version = ?
+		WHERE id = ? AND status = 'failed'
 	`
-	rows, err := db.Query(query)
+	result, err := db.Exec(query, dddVersion, reportID)
+	if isActiveReportConflict(err) {
+		return ErrReportAlreadyActive
+	}
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer func() {
-		if err := rows.Close(); err != nil {
-			log.Printf("Error closing rows: %v", err)
-		}
-	}()
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
 
-	reports := make([]*Report, 0)
+// StartReport moves a pending report to running. It returns sql.ErrNoRows when the report
+// does not exist or is no longer pending, e.g. because it was cancelled while queued
+func (db *DB) StartReport(reportID int) error {
+	query := `
+		UPDATE reports
+		SET status = 'running', completed_time = ?, report_data = '', error_message = '', trimmed_time = NULL
+		WHERE id = ? AND status = 'pending'
+	`
+	result, err := db.Exec(query, time.Now(), reportID)
+	if err != nil {
+		return err
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return sql.ErrNoRows
+	}
+	return unindexReport(db.DB, reportID)
+}
+
+// CancelPendingReport marks a pending report cancelled so the worker never picks it up.
+// It returns sql.ErrNoRows when the report does not exist or is no longer pending
+func (db *DB) CancelPendingReport(reportID int, message string) error {
+	query := `
+		UPDATE reports
+		SET status = 'cancelled', completed_time = ?, error_message = ?
+		WHERE id = ? AND status = 'pending'
+	`
+	result, err := db.Exec(query, time.Now(), message, reportID)
+	if err != nil {
+		return err
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// SetReportGenerationTime records how long the worker spent generating a report
+func (db *DB) SetReportGenerationTime(reportID int, duration time.Duration) error {
+	_, err := db.Exec(`UPDATE reports SET generation_ms = ? WHERE id = ?`, duration.Milliseconds(), reportID)
+	return err
+}
+
+// ReportThroughputBucket counts the reports queued and finished in one interval
+type ReportThroughputBucket struct {
+	Start           time.Time `json:"start"`             // Start of the interval
+	Queued          int       `json:"queued"`            // Reports created in the interval
+	Completed       int       `json:"completed"`         // Reports that completed in the interval
+	Failed          int       `json:"failed"`            // Reports that failed in the interval
+	AvgGenerationMs float64   `json:"avg_generation_ms"` // Mean generation time of the reports finished in the interval
+}
+
+// GetReportThroughput counts the reports queued, completed and failed in each interval from
+// since until now, oldest first. Every interval has a bucket, so quiet periods show as zeros
+func (db *DB) GetReportThroughput(since time.Time, interval time.Duration) ([]*ReportThroughputBucket, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("interval must be positive, got %s", interval)
+	}
+
+	count := int(time.Since(since)/interval) + 1
+	if count < 1 {
+		count = 1
+	}
+	buckets := make([]*ReportThroughputBucket, count)
+	for i := range buckets {
+		buckets[i] = &ReportThroughputBucket{Start: since.Add(time.Duration(i) * interval)}
+	}
+	bucketIndex := func(t time.Time) int {
+		i := int(t.Sub(since) / interval)
+		if t.Before(since) || i >= len(buckets) {
+			return -1
+		}
+		return i
+	}
+
+	query := `
+		SELECT status, created_time, completed_time, generation_ms
+		FROM reports WHERE created_time >= ? OR completed_time >= ?
+	`
+	rows, err := db.Query(query, since, since)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Error closing rows: %v", err)
+		}
+	}()
+
+	totalMs := make([]int64, len(buckets))
+	for rows.Next() {
+		var status string
+		var createdTime time.Time
+		var completedTime *time.Time
+		var generationMs int64
+		if err := rows.Scan(&status, &createdTime, &completedTime, &generationMs); err != nil {
+			return nil, err
+		}
+
+		if i := bucketIndex(createdTime); i >= 0 {
+			buckets[i].Queued++
+		}
+
+		// completed_time is also stamped when a report starts running, so only finished reports count
+		if completedTime == nil || (status != "completed" && status != "failed") {
+			continue
+		}
+		i := bucketIndex(*completedTime)
+		if i < 0 {
+			continue
+		}
+		if status == "completed" {
+			buckets[i].Completed++
+		} else {
+			buckets[i].Failed++
+		}
+		totalMs[i] += generationMs
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i, bucket := range buckets {
+		if finished := bucket.Completed + bucket.Failed; finished > 0 {
+			bucket.AvgGenerationMs = float64(totalMs[i]) / float64(finished)
+		}
+	}
+	return buckets, nil
+}
+
+// Stats holds the headline numbers shown on the dashboard
+type Stats struct {
+	TotalFiles      int            `json:"total_files"`       // Files still stored, deleted files are not counted
+	TotalBytes      int64          `json:"total_bytes"`       // Size of the files still stored
+	FilesByType     map[string]int `json:"files_by_type"`     // Stored files per file type
+	TotalReports    int            `json:"total_reports"`     // Reports in every status
+	ReportsByStatus map[string]int `json:"reports_by_status"` // Reports per status
+	ReportsLast24h  int            `json:"reports_last_24h"`  // Reports completed in the last 24 hours
+}
+
+// GetStats aggregates file and report counts for the dashboard
+func (db *DB) GetStats() (*Stats, error) {
+	stats := &Stats{
+		FilesByType:     make(map[string]int),
+		ReportsByStatus: make(map[string]int),
+	}
+
+	countBy := func(query string, counts map[string]int, args ...interface{}) (int, error) {
+		rows, err := db.Query(query, args...)
+		if err != nil {
+			return 0, err
+		}
+		defer func() {
+			if err := rows.Close(); err != nil {
+				log.Printf("Error closing rows: %v", err)
+			}
+		}()
+
+		total := 0
+		for rows.Next() {
+			var key string
+			var count int
+			if err := rows.Scan(&key, &count); err != nil {
+				return 0, err
+			}
+			counts[key] = count
+			total += count
+		}
+		return total, rows.Err()
+	}
+
+	var err error
+	stats.TotalFiles, err = countBy(`SELECT file_type, COUNT(*) FROM files WHERE deleted = FALSE GROUP BY file_type`, stats.FilesByType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count files: %w", err)
+	}
+	stats.TotalReports, err = countBy(`SELECT status, COUNT(*) FROM reports GROUP BY status`, stats.ReportsByStatus)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count reports: %w", err)
+	}
+
+	if err := db.QueryRow(`SELECT COALESCE(SUM(file_size), 0) FROM files WHERE deleted = FALSE`).Scan(&stats.TotalBytes); err != nil {
+		return nil, fmt.Errorf("failed to sum file sizes: %w", err)
+	}
+
+	since := time.Now().Add(-24 * time.Hour)
+	err = db.QueryRow(`SELECT COUNT(*) FROM reports WHERE status = 'completed' AND completed_time >= ?`, since).Scan(&stats.ReportsLast24h)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count recent reports: %w", err)
+	}
+	return stats, nil
+}
+
+// GetReportsByFileID retrieves all reports for a file (without report data for efficiency)
+func (db *DB) GetReportsByFileID(fileID int) ([]*Report, error) {
+	// A negative LIMIT means no limit in SQLite
+	return db.GetReportsByFileIDPaged(fileID, -1, 0)
+}
+
+// GetReportsByFileIDPaged retrieves a page of reports for a file, newest first
+func (db *DB) GetReportsByFileIDPaged(fileID, limit, offset int) ([]*Report, error) {
+	return db.getReportsByFileIDPaged(fileID, false, limit, offset)
+}
+
+// GetStarredReportsByFileIDPaged retrieves a page of a file's starred reports, newest first
+func (db *DB) GetStarredReportsByFileIDPaged(fileID, limit, offset int) ([]*Report, error) {
+	return db.getReportsByFileIDPaged(fileID, true, limit, offset)
+}
+
+func (db *DB) getReportsByFileIDPaged(fileID int, starredOnly bool, limit, offset int) ([]*Report, error) {
+	query := `
+		SELECT id, file_id, report_type, status, created_time, completed_time, generation_ms, priority, starred,
+		       ddd_version, COALESCE(error_message, '') as error_message
+		FROM reports WHERE file_id = ? AND (? = FALSE OR starred = TRUE) ORDER BY created_time DESC, id DESC
+		LIMIT ? OFFSET ?
+	`
+	rows, err := db.Query(query, fileID, starredOnly, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Error closing rows: %v", err)
+		}
+	}()
+
+	reports := make([]*Report, 0)
+	for rows.Next() {
+		report := &Report{}
+		err := rows.Scan(&report.ID, &report.FileID, &report.ReportType, &report.Status,
+			&report.CreatedTime, &report.CompletedTime, &report.GenerationMs, &report.Priority, &report.Starred, &report.DDDVersion, &report.ErrorMessage)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+// GetPendingReports retrieves reports with pending status in the order they should be
+// generated: highest priority first, then oldest first
+func (db *DB) GetPendingReports() ([]*Report, error) {
+	query := `
+		SELECT id, file_id, report_type, status, created_time, completed_time, generation_ms, priority, starred,
+		       ddd_version, COALESCE(report_data, '') as report_data, COALESCE(error_message, '') as error_message
+		FROM reports WHERE status = 'pending' ORDER BY priority DESC, created_time ASC, id ASC
+	`
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Error closing rows: %v", err)
+		}
+	}()
+
+	reports := make([]*Report, 0)
 	for rows.Next() {
 		report := &Report{}
 		err := rows.Scan(&report.ID, &report.FileID, &report.ReportType, &report.Status,
-			&report.CreatedTime, &report.CompletedTime, &report.DDDVersion,
+			&report.CreatedTime, &report.CompletedTime, &report.GenerationMs, &report.Priority, &report.Starred, &report.DDDVersion,
 			&report.ReportData, &report.ErrorMessage)
 		if err != nil {
 			return nil, err
@@ -373,12 +1090,274 @@ func (db *DB) GetPendingReports() ([]*Report, error) {
 	return reports, nil
 }
 
+// GetCompletedReportsByType retrieves every completed report of a type (without report
+// data for efficiency), oldest first
+func (db *DB) GetCompletedReportsByType(reportType string) ([]*Report, error) {
+	query := `
+		SELECT id, file_id, report_type, status, created_time, completed_time, generation_ms, priority, starred,
+		       ddd_version, COALESCE(error_message, '') as error_message
+		FROM reports WHERE report_type = ? AND status = 'completed' ORDER BY created_time ASC, id ASC
+	`
+	rows, err := db.Query(query, reportType)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Error closing rows: %v", err)
+		}
+	}()
+
+	reports := make([]*Report, 0)
+	for rows.Next() {
+		report := &Report{}
+		err := rows.Scan(&report.ID, &report.FileID, &report.ReportType, &report.Status,
+			&report.CreatedTime, &report.CompletedTime, &report.GenerationMs, &report.Priority, &report.Starred, &report.DDDVersion, &report.ErrorMessage)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+// GetReportsForPurge returns the reports that still hold data although their file was
+// deleted before cutoff, oldest deletion first. Baselines are kept so comparisons against them keep working
+func (db *DB) GetReportsForPurge(cutoff time.Time) ([]*Report, error) {
+	query := `
+		SELECT r.id, r.file_id, r.report_type, r.status, r.created_time, r.completed_time, r.generation_ms, r.priority, r.starred,
+		       r.ddd_version, COALESCE(r.error_message, '') as error_message
+		FROM reports r
+		JOIN files f ON f.id = r.file_id
+		WHERE f.deleted = TRUE AND f.deleted_time < ? AND r.status != 'purged' AND COALESCE(r.report_data, '') != ''
+		  AND r.id NOT IN (SELECT report_id FROM baselines)
+		ORDER BY f.deleted_time ASC, r.id ASC
+	`
+	rows, err := db.Query(query, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Error closing rows: %v", err)
+		}
+	}()
+
+	reports := make([]*Report, 0)
+	for rows.Next() {
+		report := &Report{}
+		err := rows.Scan(&report.ID, &report.FileID, &report.ReportType, &report.Status,
+			&report.CreatedTime, &report.CompletedTime, &report.GenerationMs, &report.Priority, &report.Starred, &report.DDDVersion, &report.ErrorMessage)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+	return reports, rows.Err()
+}
+
+// FailedReport is a failed report with the name and type of the file it was generated from
+type FailedReport struct {
+	*Report
+	FileName string `json:"file_name"`
+	FileType string `json:"file_type"`
+}
+
+// failedReportsSearch returns the conditions and arguments selecting failed reports
+// whose file name, file type, report type or error message contains searchQuery
+func failedReportsSearch(searchQuery string) (string, []interface{}) {
+	conditions := "r.status = 'failed'"
+	args := []interface{}{}
+	if searchQuery != "" {
+		conditions += " AND (f.original_name LIKE ? OR f.file_type LIKE ? OR r.report_type LIKE ? OR r.error_message LIKE ?)"
+		searchPattern := "%" + searchQuery + "%"
+		args = append(args, searchPattern, searchPattern, searchPattern, searchPattern)
+	}
+	return conditions, args
+}
+
+// GetFailedReports retrieves a page of failed reports across all files, most recent failure first
+func (db *DB) GetFailedReports(limit, offset int) ([]*FailedReport, error) {
+	return db.SearchFailedReports("", limit, offset)
+}
+
+// SearchFailedReports retrieves a page of the failed reports matching searchQuery, most
+// recent failure first. An empty searchQuery matches every failed report
+func (db *DB) SearchFailedReports(searchQuery string, limit, offset int) ([]*FailedReport, error) {
+	conditions, args := failedReportsSearch(searchQuery)
+	query := `
+		SELECT r.id, r.file_id, r.report_type, r.status, r.created_time, r.completed_time, r.generation_ms, r.priority, r.starred,
+		       r.ddd_version, COALESCE(r.error_message, '') as error_message, f.original_name, f.file_type
+		FROM reports r
+		JOIN files f ON f.id = r.file_id
+		WHERE ` + conditions + `
+		ORDER BY r.completed_time DESC, r.id DESC
+		LIMIT ? OFFSET ?
+	`
+	rows, err := db.Query(query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Error closing rows: %v", err)
+		}
+	}()
+
+	reports := make([]*FailedReport, 0)
+	for rows.Next() {
+		report := &FailedReport{Report: &Report{}}
+		err := rows.Scan(&report.ID, &report.FileID, &report.ReportType, &report.Status,
+			&report.CreatedTime, &report.CompletedTime, &report.GenerationMs, &report.Priority, &report.Starred, &report.DDDVersion, &report.ErrorMessage,
+			&report.FileName, &report.FileType)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+	return reports, rows.Err()
+}
+
+// CountFailedReports returns the number of failed reports matching searchQuery
+func (db *DB) CountFailedReports(searchQuery string) (int, error) {
+	conditions, args := failedReportsSearch(searchQuery)
+	var count int
+	err := db.QueryRow(`SELECT COUNT(*) FROM reports r JOIN files f ON f.id = r.file_id WHERE `+conditions, args...).Scan(&count)
+	return count, err
+}
+
+// PurgeReport drops a report's data and removes it from the search index, leaving the
+// row as a tombstone with status 'purged' so the report's history is still listed
+func (db *DB) PurgeReport(reportID int) error {
+	if _, err := db.Exec(`UPDATE reports SET status = 'purged', report_data = NULL WHERE id = ?`, reportID); err != nil {
+		return err
+	}
+	return unindexReport(db.DB, reportID)
+}
+
+// trimmedReportDataKeys are the parts of report data dropped once it passes the report data
+// retention: the rendered page, the chart series and raw thread dump stacks. The summary,
+// analysis and findings of a report are small and kept
+var trimmedReportDataKeys = []string{"html_report", "chart_data", "charts", "stack_groups"}
+
+// reportDataTrimmedKey marks report data that has been trimmed, with the time it was.
+// reports.trimmed_time records the same so trimmed reports are found without the data
+const reportDataTrimmedKey = "data_trimmed_at"
+
+// GetReportsForTrim retrieves the completed reports created before cutoff whose data has not
+// been trimmed yet, oldest first. Baselines keep their data
+func (db *DB) GetReportsForTrim(cutoff time.Time) ([]*Report, error) {
+	query := `
+		SELECT id, file_id, report_type, status, created_time, completed_time, generation_ms, priority, starred,
+		       ddd_version, COALESCE(error_message, '') as error_message
+		FROM reports
+		WHERE status = 'completed' AND created_time < ? AND COALESCE(report_data, '') != ''
+		  AND trimmed_time IS NULL
+		  AND id NOT IN (SELECT report_id FROM baselines)
+		ORDER BY created_time ASC, id ASC
+	`
+	rows, err := db.Query(query, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Error closing rows: %v", err)
+		}
+	}()
+
+	reports := make([]*Report, 0)
+	for rows.Next() {
+		report := &Report{}
+		err := rows.Scan(&report.ID, &report.FileID, &report.ReportType, &report.Status,
+			&report.CreatedTime, &report.CompletedTime, &report.GenerationMs, &report.Priority, &report.Starred, &report.DDDVersion, &report.ErrorMessage)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+	return reports, rows.Err()
+}
+
+// TrimReportData drops the heavy parts of a report's data, its rendered page and raw series,
+// keeping the summary, analysis and metadata so the findings outlive the data retention.
+// The report is marked as trimmed and reindexed for search on what is left
+func (db *DB) TrimReportData(reportID int) error {
+	var reportData sql.NullString
+	if err := db.QueryRow(`SELECT report_data FROM reports WHERE id = ?`, reportID).Scan(&reportData); err != nil {
+		return err
+	}
+	if !reportData.Valid || reportData.String == "" {
+		return nil
+	}
+
+	var data map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(reportData.String), &data); err != nil {
+		return fmt.Errorf("failed to parse data of report %d: %w", reportID, err)
+	}
+	if _, ok := data[reportDataTrimmedKey]; ok {
+		// Already trimmed, e.g. imported from a bundle without its charts
+		_, err := db.Exec(`UPDATE reports SET trimmed_time = COALESCE(trimmed_time, ?) WHERE id = ?`, time.Now(), reportID)
+		return err
+	}
+	trimmed, err := TrimmedReportData(reportData.String)
+	if err != nil {
+		return fmt.Errorf("failed to trim data of report %d: %w", reportID, err)
+	}
+	if _, err := db.Exec(`UPDATE reports SET report_data = ?, trimmed_time = ? WHERE id = ?`, string(trimmed), time.Now(), reportID); err != nil {
+		return err
+	}
+	return indexReport(db.DB, reportID, string(trimmed))
+}
+
+// TrimmedReportData returns report data without its rendered page and raw series, marked
+// with the time it was trimmed
+func TrimmedReportData(reportData string) (string, error) {
+	var data map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(reportData), &data); err != nil {
+		return "", err
+	}
+	for _, key := range trimmedReportDataKeys {
+		delete(data, key)
+	}
+	trimmedAt, err := json.Marshal(time.Now().UTC())
+	if err != nil {
+		return "", err
+	}
+	data[reportDataTrimmedKey] = trimmedAt
+
+	trimmed, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	return string(trimmed), nil
+}
+
+// CountReportsByStatus returns the number of reports with the given status
+func (db *DB) CountReportsByStatus(status string) (int, error) {
+	var count int
+	err := db.QueryRow(`SELECT COUNT(*) FROM reports WHERE status = ?`, status).Scan(&count)
+	return count, err
+}
+
+// GetActiveFiles retrieves every file that has not been deleted, oldest first
+func (db *DB) GetActiveFiles() ([]*File, error) {
+	return db.queryFiles(`
+		SELECT id, hash, original_name, file_type, file_size, upload_time, file_path, deleted, deleted_time,
+		       detection_confidence, detection_signal, upload_group_id, supersedes_file_id, pinned, hash_algorithm
+		FROM files
+		WHERE deleted = FALSE
+		ORDER BY id ASC
+	`)
+}
+
 // GetFilesOlderThan retrieves files older than the specified time
 func (db *DB) GetFilesOlderThan(cutoffTime time.Time) ([]*File, error) {
 	query := `
-		SELECT id, hash, original_name, file_type, file_size, upload_time, file_path, deleted, deleted_time
+		SELECT id, hash, original_name, file_type, file_size, upload_time, file_path, deleted, deleted_time,
+		       detection_confidence, detection_signal, upload_group_id, supersedes_file_id, pinned, hash_algorithm
 		FROM files
-		WHERE deleted = FALSE AND upload_time < ?
+		WHERE deleted = FALSE AND pinned = FALSE AND upload_time < ?
 		ORDER BY upload_time ASC
 	`
 	rows, err := db.Query(query, cutoffTime)
@@ -395,7 +1374,8 @@ func (db *DB) GetFilesOlderThan(cutoffTime time.Time) ([]*File, error) {
 	for rows.Next() {
 		file := &File{}
 		err := rows.Scan(&file.ID, &file.Hash, &file.OriginalName, &file.FileType,
-			&file.FileSize, &file.UploadTime, &file.FilePath, &file.Deleted, &file.DeletedTime)
+			&file.FileSize, &file.UploadTime, &file.FilePath, &file.Deleted, &file.DeletedTime,
+			&file.DetectionConfidence, &file.DetectionSignal, &file.UploadGroupID, &file.SupersedesFileID, &file.Pinned, &file.HashAlgorithm)
 		if err != nil {
 			return nil, err
 		}
@@ -404,10 +1384,21 @@ func (db *DB) GetFilesOlderThan(cutoffTime time.Time) ([]*File, error) {
 	return files, nil
 }
 
+// GetFilesOlderThanByType retrieves files of one type older than the specified time
+func (db *DB) GetFilesOlderThanByType(fileType string, cutoffTime time.Time) ([]*File, error) {
+	return db.queryFiles(`
+		SELECT id, hash, original_name, file_type, file_size, upload_time, file_path, deleted, deleted_time,
+		       detection_confidence, detection_signal, upload_group_id, supersedes_file_id, pinned, hash_algorithm
+		FROM files
+		WHERE deleted = FALSE AND pinned = FALSE AND file_type = ? AND upload_time < ?
+		ORDER BY upload_time ASC
+	`, fileType, cutoffTime)
+}
+
 // GetReportByID retrieves a specific report by ID
 func (db *DB) GetReportByID(reportID int) (*Report, error) {
 	query := `
-		SELECT id, file_id, report_type, status, created_time, completed_time,
+		SELECT id, file_id, report_type, status, created_time, completed_time, generation_ms, priority, starred,
 		       ddd_version, COALESCE(report_data, '') as report_data, COALESCE(error_message, '') as error_message
 		FROM reports WHERE id = ?
 	`
@@ -415,7 +1406,7 @@ func (db *DB) GetReportByID(reportID int) (*Report, error) {
 
 	report := &Report{}
 	err := row.Scan(&report.ID, &report.FileID, &report.ReportType, &report.Status,
-		&report.CreatedTime, &report.CompletedTime, &report.DDDVersion,
+		&report.CreatedTime, &report.CompletedTime, &report.GenerationMs, &report.Priority, &report.Starred, &report.DDDVersion,
 		&report.ReportData, &report.ErrorMessage)
 	if err != nil {
 		return nil, err
@@ -423,13 +1414,119 @@ func (db *DB) GetReportByID(reportID int) (*Report, error) {
 	return report, nil
 }
 
-// DeleteReport deletes a report by ID
+// GetReportMetaByID retrieves a report by ID without its report data, which for completed
+// reports embeds the whole HTML report
+func (db *DB) GetReportMetaByID(reportID int) (*Report, error) {
+	query := `
+		SELECT id, file_id, report_type, status, created_time, completed_time, generation_ms, priority, starred,
+		       ddd_version, COALESCE(error_message, '') as error_message
+		FROM reports WHERE id = ?
+	`
+	row := db.QueryRow(query, reportID)
+
+	report := &Report{}
+	err := row.Scan(&report.ID, &report.FileID, &report.ReportType, &report.Status,
+		&report.CreatedTime, &report.CompletedTime, &report.GenerationMs, &report.Priority, &report.Starred, &report.DDDVersion,
+		&report.ErrorMessage)
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// GetActiveReport retrieves the newest pending or running report of a type for a file.
+// It returns sql.ErrNoRows when there is none.
+func (db *DB) GetActiveReport(fileID int, reportType string) (*Report, error) {
+	query := `
+		SELECT id, file_id, report_type, status, created_time, completed_time, generation_ms, priority, starred,
+		       ddd_version, COALESCE(report_data, '') as report_data, COALESCE(error_message, '') as error_message
+		FROM reports WHERE file_id = ? AND report_type = ? AND status IN ('pending', 'running')
+		ORDER BY created_time DESC, id DESC LIMIT 1
+	`
+	row := db.QueryRow(query, fileID, reportType)
+
+	report := &Report{}
+	err := row.Scan(&report.ID, &report.FileID, &report.ReportType, &report.Status,
+		&report.CreatedTime, &report.CompletedTime, &report.GenerationMs, &report.Priority, &report.Starred, &report.DDDVersion,
+		&report.ReportData, &report.ErrorMessage)
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// DeleteReport deletes a report, its logs and its search index entry by ID
 func (db *DB) DeleteReport(reportID int) error {
+	if err := db.DeleteReportLogs(reportID); err != nil {
+		return err
+	}
+	if err := unindexReport(db.DB, reportID); err != nil {
+		return err
+	}
 	query := `DELETE FROM reports WHERE id = ?`
 	_, err := db.Exec(query, reportID)
 	return err
 }
 
+// InsertReportLog stores a log line for a report
+func (db *DB) InsertReportLog(entry *ReportLog) error {
+	query := `INSERT INTO report_logs (report_id, timestamp, level, message) VALUES (?, ?, ?, ?)`
+	result, err := db.Exec(query, entry.ReportID, entry.Timestamp, entry.Level, entry.Message)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	entry.ID = int(id)
+	return nil
+}
+
+// GetReportLogs retrieves the log lines for a report in the order they were written,
+// optionally filtered by level and by a case-insensitive search of the message
+func (db *DB) GetReportLogs(reportID int, level, searchQuery string) ([]*ReportLog, error) {
+	query := `SELECT id, report_id, timestamp, level, message FROM report_logs WHERE report_id = ?`
+	args := []interface{}{reportID}
+
+	if level != "" {
+		query += " AND level = ?"
+		args = append(args, strings.ToUpper(level))
+	}
+	if searchQuery != "" {
+		query += " AND message LIKE ?"
+		args = append(args, "%"+searchQuery+"%")
+	}
+	query += " ORDER BY id ASC"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Error closing rows: %v", err)
+		}
+	}()
+
+	logs := make([]*ReportLog, 0)
+	for rows.Next() {
+		entry := &ReportLog{}
+		if err := rows.Scan(&entry.ID, &entry.ReportID, &entry.Timestamp, &entry.Level, &entry.Message); err != nil {
+			return nil, err
+		}
+		logs = append(logs, entry)
+	}
+	return logs, rows.Err()
+}
+
+// DeleteReportLogs removes all log lines for a report
+func (db *DB) DeleteReportLogs(reportID int) error {
+	_, err := db.Exec(`DELETE FROM report_logs WHERE report_id = ?`, reportID)
+	return err
+}
+
 // GetReportCountByFileID returns the number of reports for a given file
 func (db *DB) GetReportCountByFileID(fileID int) (int, error) {
 	query := `SELECT COUNT(*) FROM reports WHERE file_id = ?`
@@ -441,8 +1538,22 @@ func (db *DB) GetReportCountByFileID(fileID int) (int, error) {
 	return count, nil
 }
 
+// GetStarredReportCountByFileID returns the number of starred reports for a file
+func (db *DB) GetStarredReportCountByFileID(fileID int) (int, error) {
+	query := `SELECT COUNT(*) FROM reports WHERE file_id = ? AND starred = TRUE`
+	var count int
+	err := db.QueryRow(query, fileID).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
 // DeleteFileCompletely removes a file entry completely from the database
 func (db *DB) DeleteFileCompletely(fileID int) error {
+	if _, err := db.Exec(`DELETE FROM file_uploads WHERE file_id = ?`, fileID); err != nil {
+		return err
+	}
 	query := `DELETE FROM files WHERE id = ?`
 	_, err := db.Exec(query, fileID)
 	return err
@@ -470,23 +1581,112 @@ func (db *DB) UpdateFileFileType(fileID int, fileType string) error {
 	return err
 }
 
+// SetFilePinned pins or unpins a file. Pinned files are skipped by every cleanup pass
+func (db *DB) SetFilePinned(fileID int, pinned bool) error {
+	_, err := db.Exec(`UPDATE files SET pinned = ? WHERE id = ?`, pinned, fileID)
+	return err
+}
+
+// AssignFileUploadGroup puts a file in an upload group. A file stays in the group it was
+// first uploaded with, so files that already belong to a group are left unchanged
+func (db *DB) AssignFileUploadGroup(fileID int, groupID string) error {
+	_, err := db.Exec(`UPDATE files SET upload_group_id = ? WHERE id = ? AND upload_group_id = ''`, groupID, fileID)
+	return err
+}
+
+// GetFilesByUploadGroup retrieves the files uploaded together in a group, in upload order
+func (db *DB) GetFilesByUploadGroup(groupID string) ([]*File, error) {
+	query := `
+		SELECT id, hash, original_name, file_type, file_size, upload_time, file_path, deleted, deleted_time,
+		       detection_confidence, detection_signal, upload_group_id, supersedes_file_id, pinned, hash_algorithm
+		FROM files
+		WHERE upload_group_id = ?
+		ORDER BY upload_time ASC, id ASC
+	`
+	rows, err := db.Query(query, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Error closing rows: %v", err)
+		}
+	}()
+
+	files := make([]*File, 0)
+	for rows.Next() {
+		file := &File{}
+		err := rows.Scan(&file.ID, &file.Hash, &file.OriginalName, &file.FileType,
+			&file.FileSize, &file.UploadTime, &file.FilePath, &file.Deleted, &file.DeletedTime,
+			&file.DetectionConfidence, &file.DetectionSignal, &file.UploadGroupID, &file.SupersedesFileID, &file.Pinned, &file.HashAlgorithm)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	return files, rows.Err()
+}
+
 // GetFileByID retrieves a file by ID
 func (db *DB) GetFileByID(fileID int) (*File, error) {
 	query := `
-		SELECT id, hash, original_name, file_type, file_size, upload_time, file_path, deleted, deleted_time
+		SELECT id, hash, original_name, file_type, file_size, upload_time, file_path, deleted, deleted_time,
+		       detection_confidence, detection_signal, upload_group_id, supersedes_file_id, pinned, hash_algorithm
 		FROM files WHERE id = ?
 	`
 	row := db.QueryRow(query, fileID)
 
 	file := &File{}
 	err := row.Scan(&file.ID, &file.Hash, &file.OriginalName, &file.FileType,
-		&file.FileSize, &file.UploadTime, &file.FilePath, &file.Deleted, &file.DeletedTime)
+		&file.FileSize, &file.UploadTime, &file.FilePath, &file.Deleted, &file.DeletedTime,
+		&file.DetectionConfidence, &file.DetectionSignal, &file.UploadGroupID, &file.SupersedesFileID, &file.Pinned, &file.HashAlgorithm)
 	if err != nil {
 		return nil, err
 	}
 	return file, nil
 }
 
+// GetFileSupersededBy returns the newer version of a file, sql.ErrNoRows when it has none
+func (db *DB) GetFileSupersededBy(fileID int) (*File, error) {
+	files, err := db.queryFiles(`
+		SELECT id, hash, original_name, file_type, file_size, upload_time, file_path, deleted, deleted_time,
+		       detection_confidence, detection_signal, upload_group_id, supersedes_file_id, pinned, hash_algorithm
+		FROM files WHERE supersedes_file_id = ?
+		ORDER BY id ASC LIMIT 1
+	`, fileID)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return files[0], nil
+}
+
+// GetFileVersions returns every version of the capture a file belongs to, following
+// supersedes_file_id in both directions, oldest first
+func (db *DB) GetFileVersions(fileID int) ([]*File, error) {
+	return db.queryFiles(`
+		WITH RECURSIVE
+			older(id) AS (
+				SELECT ?
+				UNION
+				SELECT f.supersedes_file_id FROM files f JOIN older o ON f.id = o.id
+				WHERE f.supersedes_file_id IS NOT NULL
+			),
+			newer(id) AS (
+				SELECT ?
+				UNION
+				SELECT f.id FROM files f JOIN newer n ON f.supersedes_file_id = n.id
+			)
+		SELECT id, hash, original_name, file_type, file_size, upload_time, file_path, deleted, deleted_time,
+		       detection_confidence, detection_signal, upload_group_id, supersedes_file_id, pinned, hash_algorithm
+		FROM files
+		WHERE id IN (SELECT id FROM older UNION SELECT id FROM newer)
+		ORDER BY upload_time ASC, id ASC
+	`, fileID, fileID)
+}
+
 // UpdateWorkerStatus updates or inserts worker status
 func (db *DB) UpdateWorkerStatus(workerType, status, message string) error {
 	query := `
@@ -497,27 +1697,127 @@ func (db *DB) UpdateWorkerStatus(workerType, status, message string) error {
 	return err
 }
 
-// GetSetting retrieves a setting value by key
+// GetSetting retrieves a setting value by key, from the settings cache once it has been
+// read. A setting that is not set returns sql.ErrNoRows, and is cached as not set too
 func (db *DB) GetSetting(key string) (string, error) {
-	query := `SELECT value FROM settings WHERE key = ?`
-	row := db.QueryRow(query, key)
+	db.settingsMu.RLock()
+	cached, ok := db.settingsCache[key]
+	gen := db.settingsGen
+	db.settingsMu.RUnlock()
+	if ok {
+		if !cached.set {
+			return "", sql.ErrNoRows
+		}
+		return cached.value, nil
+	}
 
+	// Queried without holding settingsMu, so a slow read doesn't block other settings
 	var value string
-	err := row.Scan(&value)
-	if err != nil {
+	query := `SELECT value FROM settings WHERE key = ?`
+	err := db.QueryRow(query, key).Scan(&value)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		return "", err
 	}
+	set := err == nil
+
+	db.settingsMu.Lock()
+	// Only cache what was read if no setting changed meanwhile
+	if db.settingsGen == gen {
+		if db.settingsCache == nil {
+			db.settingsCache = make(map[string]cachedSetting)
+		}
+		db.settingsCache[key] = cachedSetting{value: value, set: set}
+	}
+	db.settingsMu.Unlock()
+	if !set {
+		return "", sql.ErrNoRows
+	}
 	return value, nil
 }
 
-// SetSetting sets a setting value by key
+// SetSetting sets a setting value by key, recording the change in the settings audit
+// log. Writing the value a setting already has is not recorded
 func (db *DB) SetSetting(key, value string) error {
+	db.settingsMu.Lock()
+	defer db.settingsMu.Unlock()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var oldValue sql.NullString
+	err = tx.QueryRow(`SELECT value FROM settings WHERE key = ?`, key).Scan(&oldValue)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if oldValue.Valid && oldValue.String == value {
+		return nil
+	}
+
+	now := time.Now()
 	query := `
 		INSERT OR REPLACE INTO settings (key, value, updated_time)
 		VALUES (?, ?, ?)
 	`
-	_, err := db.Exec(query, key, value, time.Now())
-	return err
+	if _, err := tx.Exec(query, key, value, now); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO settings_audit (key, old_value, new_value, changed_time)
+		VALUES (?, ?, ?, ?)
+	`, key, oldValue, value, now); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	delete(db.settingsCache, key)
+	db.settingsGen++
+	return nil
+}
+
+// GetSettingsHistory returns the settings audit log, most recent change first, limited
+// to the changes of key when it is not empty and to at most limit entries when positive
+func (db *DB) GetSettingsHistory(key string, limit int) ([]*SettingChange, error) {
+	query := `SELECT id, key, old_value, new_value, changed_time FROM settings_audit`
+	var args []interface{}
+	if key != "" {
+		query += " WHERE key = ?"
+		args = append(args, key)
+	}
+	query += " ORDER BY id DESC"
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Error closing rows: %v", err)
+		}
+	}()
+
+	changes := make([]*SettingChange, 0)
+	for rows.Next() {
+		change := &SettingChange{}
+		var oldValue sql.NullString
+		if err := rows.Scan(&change.ID, &change.Key, &oldValue, &change.NewValue, &change.ChangedTime); err != nil {
+			return nil, err
+		}
+		if oldValue.Valid {
+			change.OldValue = &oldValue.String
+		}
+		changes = append(changes, change)
+	}
+	return changes, rows.Err()
 }
 
 // GetAllSettings retrieves all settings as a map
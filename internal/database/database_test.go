@@ -15,7 +15,12 @@
 package database
 
 import (
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 
@@ -107,6 +112,41 @@ func TestDatabase_FileOperations(t *testing.T) {
 		require.Error(t, err)
 	})
 
+	t.Run("GetFileByHash with mixed hash algorithms", func(t *testing.T) {
+		db := testDB(t)
+
+		sha256File := &File{Hash: "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", OriginalName: "slow.txt", FileType: "iostat", FileSize: 1024,
+			UploadTime: time.Now(), FilePath: "/uploads/slow"}
+		require.NoError(t, db.InsertFile(sha256File))
+		assert.Equal(t, DefaultHashAlgorithm, sha256File.HashAlgorithm)
+
+		algorithms, err := db.GetHashAlgorithms(1024)
+		require.NoError(t, err)
+		assert.Equal(t, []string{DefaultHashAlgorithm}, algorithms)
+
+		xxhashFile := &File{Hash: "0123456789abcdef", HashAlgorithm: "xxhash", OriginalName: "fast.txt", FileType: "iostat",
+			FileSize: 1024, UploadTime: time.Now(), FilePath: "/uploads/fast"}
+		require.NoError(t, db.InsertFile(xxhashFile))
+
+		retrieved, err := db.GetFileByHash(xxhashFile.Hash)
+		require.NoError(t, err)
+		assert.Equal(t, xxhashFile.ID, retrieved.ID)
+		assert.Equal(t, "xxhash", retrieved.HashAlgorithm)
+		retrieved, err = db.GetFileByHash(sha256File.Hash)
+		require.NoError(t, err)
+		assert.Equal(t, sha256File.ID, retrieved.ID)
+		assert.Equal(t, DefaultHashAlgorithm, retrieved.HashAlgorithm)
+
+		algorithms, err = db.GetHashAlgorithms(1024)
+		require.NoError(t, err)
+		assert.Equal(t, []string{DefaultHashAlgorithm, "xxhash"}, algorithms)
+
+		// Only files of the same size can hold the same content
+		algorithms, err = db.GetHashAlgorithms(2048)
+		require.NoError(t, err)
+		assert.Empty(t, algorithms)
+	})
+
 	t.Run("GetFiles", func(t *testing.T) {
 		// Insert multiple test files
 		files := []*File{
@@ -134,7 +174,7 @@ func TestDatabase_FileOperations(t *testing.T) {
 		}
 
 		// Test pagination without search
-		retrievedFiles, err := db.GetFiles(10, 0, false, "")
+		retrievedFiles, err := db.GetFiles(10, 0, false, "", nil)
 		require.NoError(t, err)
 		assert.GreaterOrEqual(t, len(retrievedFiles), 2, "Should retrieve at least 2 files")
 
@@ -180,7 +220,7 @@ func TestDatabase_FileOperations(t *testing.T) {
 		}
 
 		// Test search by filename
-		files, err := db.GetFiles(10, 0, false, "performance")
+		files, err := db.GetFiles(10, 0, false, "performance", nil)
 		require.NoError(t, err)
 		assert.Len(t, files, 2, "Should find 2 files with 'performance' in name")
 
@@ -193,7 +233,7 @@ func TestDatabase_FileOperations(t *testing.T) {
 		assert.True(t, foundHashes["search-hash-3"], "Should find database_performance.csv")
 
 		// Test search by file type
-		files, err = db.GetFiles(10, 0, false, "iostat")
+		files, err = db.GetFiles(10, 0, false, "iostat", nil)
 		require.NoError(t, err)
 		// Find our specific test file among the results
 		foundTestFile := false
@@ -206,35 +246,35 @@ func TestDatabase_FileOperations(t *testing.T) {
 		assert.True(t, foundTestFile, "Should find our test file with 'iostat' type")
 
 		// Test search by hash
-		files, err = db.GetFiles(10, 0, false, "search-hash-1")
+		files, err = db.GetFiles(10, 0, false, "search-hash-1", nil)
 		require.NoError(t, err)
 		assert.Len(t, files, 1, "Should find exactly 1 file with matching hash")
 		assert.Equal(t, "search-hash-1", files[0].Hash)
 
 		// Test search by partial hash - use a more specific pattern
-		files, err = db.GetFiles(10, 0, false, "search-hash-2")
+		files, err = db.GetFiles(10, 0, false, "search-hash-2", nil)
 		require.NoError(t, err)
 		assert.Len(t, files, 1, "Should find file with partial hash match")
 		assert.Equal(t, "search-hash-2", files[0].Hash)
 
 		// Test case-insensitive search
-		files, err = db.GetFiles(10, 0, false, "PERFORMANCE")
+		files, err = db.GetFiles(10, 0, false, "PERFORMANCE", nil)
 		require.NoError(t, err)
 		assert.GreaterOrEqual(t, len(files), 2, "Search should be case-insensitive and find at least 2 files")
 
 		// Test partial match - search for "performance_data" to be more specific
-		files, err = db.GetFiles(10, 0, false, "performance_data")
+		files, err = db.GetFiles(10, 0, false, "performance_data", nil)
 		require.NoError(t, err)
 		assert.Len(t, files, 1, "Should find exactly 1 file with 'performance_data' in name")
 		assert.Equal(t, "search-hash-1", files[0].Hash)
 
 		// Test search with no results
-		files, err = db.GetFiles(10, 0, false, "nonexistent")
+		files, err = db.GetFiles(10, 0, false, "nonexistent", nil)
 		require.NoError(t, err)
 		assert.Len(t, files, 0, "Should find no files for non-existent search term")
 
 		// Test empty search query (should return all files)
-		files, err = db.GetFiles(10, 0, false, "")
+		files, err = db.GetFiles(10, 0, false, "", nil)
 		require.NoError(t, err)
 		assert.GreaterOrEqual(t, len(files), 3, "Empty search should return all files")
 	})
@@ -258,12 +298,12 @@ func TestDatabase_FileOperations(t *testing.T) {
 		require.NoError(t, err)
 
 		// Search without including deleted files
-		files, err := db.GetFiles(10, 0, false, "deleted_performance")
+		files, err := db.GetFiles(10, 0, false, "deleted_performance", nil)
 		require.NoError(t, err)
 		assert.Len(t, files, 0, "Should not find deleted files when includeDeleted=false")
 
 		// Search including deleted files
-		files, err = db.GetFiles(10, 0, true, "deleted_performance")
+		files, err = db.GetFiles(10, 0, true, "deleted_performance", nil)
 		require.NoError(t, err)
 		assert.Len(t, files, 1, "Should find deleted files when includeDeleted=true")
 		assert.True(t, files[0].Deleted, "Found file should be marked as deleted")
@@ -304,17 +344,17 @@ func TestDatabase_FileOperations(t *testing.T) {
 		}
 
 		// Test first page
-		files, err := db.GetFiles(2, 0, false, "test_file")
+		files, err := db.GetFiles(2, 0, false, "test_file", nil)
 		require.NoError(t, err)
 		assert.Len(t, files, 2, "First page should have 2 files")
 
 		// Test second page
-		files, err = db.GetFiles(2, 2, false, "test_file")
+		files, err = db.GetFiles(2, 2, false, "test_file", nil)
 		require.NoError(t, err)
 		assert.Len(t, files, 1, "Second page should have 1 file")
 
 		// Verify ordering (should be DESC by upload_time)
-		allFiles, err := db.GetFiles(10, 0, false, "test_file")
+		allFiles, err := db.GetFiles(10, 0, false, "test_file", nil)
 		require.NoError(t, err)
 		assert.GreaterOrEqual(t, len(allFiles), 3, "Should have at least 3 test files")
 
@@ -346,22 +386,22 @@ func TestDatabase_FileOperations(t *testing.T) {
 		}
 
 		// Test limit of 5
-		files, err := db.GetFiles(5, 0, false, "")
+		files, err := db.GetFiles(5, 0, false, "", nil)
 		require.NoError(t, err)
 		assert.LessOrEqual(t, len(files), 5, "Should return at most 5 files")
 
 		// Test limit of 3
-		files, err = db.GetFiles(3, 0, false, "")
+		files, err = db.GetFiles(3, 0, false, "", nil)
 		require.NoError(t, err)
 		assert.LessOrEqual(t, len(files), 3, "Should return at most 3 files")
 
 		// Test limit of 1
-		files, err = db.GetFiles(1, 0, false, "")
+		files, err = db.GetFiles(1, 0, false, "", nil)
 		require.NoError(t, err)
 		assert.LessOrEqual(t, len(files), 1, "Should return at most 1 file")
 
 		// Test limit with search
-		files, err = db.GetFiles(3, 0, false, "limit_test")
+		files, err = db.GetFiles(3, 0, false, "limit_test", nil)
 		require.NoError(t, err)
 		assert.LessOrEqual(t, len(files), 3, "Should return at most 3 files with search")
 
@@ -535,6 +575,377 @@ func TestDatabase_FileOperations(t *testing.T) {
 		// Upload time should be updated
 		assert.True(t, restoredFile.UploadTime.After(file.UploadTime))
 	})
+
+	t.Run("UpdateFileDetection", func(t *testing.T) {
+		file := &File{
+			Hash:                "detection-test-hash",
+			OriginalName:        "iostat.log",
+			FileType:            "iostat",
+			FileSize:            256,
+			UploadTime:          time.Now(),
+			FilePath:            "/uploads/detection-test-hash",
+			DetectionConfidence: 0.4,
+			DetectionSignal:     "extension",
+		}
+		require.NoError(t, db.InsertFile(file))
+
+		inserted, err := db.GetFileByID(file.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 0.4, inserted.DetectionConfidence)
+		assert.Equal(t, "extension", inserted.DetectionSignal)
+
+		require.NoError(t, db.UpdateFileDetection(file.ID, 0.9, "content"))
+
+		updated, err := db.GetFileByID(file.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 0.9, updated.DetectionConfidence)
+		assert.Equal(t, "content", updated.DetectionSignal)
+	})
+
+	t.Run("Upload groups", func(t *testing.T) {
+		grouped := []*File{
+			{Hash: "group-hash-1", OriginalName: "ttop-node1.txt", FileType: "ttop", UploadTime: time.Now(), UploadGroupID: "group-a"},
+			{Hash: "group-hash-2", OriginalName: "iostat-node1.txt", FileType: "iostat", UploadTime: time.Now(), UploadGroupID: "group-a"},
+		}
+		for _, file := range grouped {
+			require.NoError(t, db.InsertFile(file))
+		}
+		alone := &File{Hash: "group-hash-3", OriginalName: "jstack.txt", FileType: "thread_dump", UploadTime: time.Now()}
+		require.NoError(t, db.InsertFile(alone))
+
+		files, err := db.GetFilesByUploadGroup("group-a")
+		require.NoError(t, err)
+		require.Len(t, files, 2)
+		assert.Equal(t, "ttop-node1.txt", files[0].OriginalName)
+		assert.Equal(t, "iostat-node1.txt", files[1].OriginalName)
+		assert.Equal(t, "group-a", files[1].UploadGroupID)
+
+		// A file uploaded alone can join a group, but then stays in it
+		require.NoError(t, db.AssignFileUploadGroup(alone.ID, "group-b"))
+		require.NoError(t, db.AssignFileUploadGroup(alone.ID, "group-c"))
+		updated, err := db.GetFileByID(alone.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "group-b", updated.UploadGroupID)
+
+		files, err = db.GetFilesByUploadGroup("missing-group")
+		require.NoError(t, err)
+		assert.Empty(t, files)
+	})
+}
+
+func TestDatabase_StreamFiles(t *testing.T) {
+	db := testDB(t)
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, db.InsertFile(&File{
+			Hash:         fmt.Sprintf("stream%d", i),
+			OriginalName: fmt.Sprintf("stream%d.txt", i),
+			FileType:     "iostat",
+			FileSize:     100,
+			UploadTime:   time.Now(),
+			FilePath:     fmt.Sprintf("/uploads/stream%d", i),
+		}))
+	}
+	require.NoError(t, db.MarkFileDeleted(3))
+
+	collect := func(includeDeleted bool, batchSize int) []int {
+		ids := make([]int, 0)
+		require.NoError(t, db.StreamFiles(includeDeleted, batchSize, func(file *File) error {
+			ids = append(ids, file.ID)
+			return nil
+		}))
+		return ids
+	}
+
+	t.Run("Batches cover every file in ID order", func(t *testing.T) {
+		assert.Equal(t, []int{1, 2, 4, 5}, collect(false, 2))
+		assert.Equal(t, []int{1, 2, 3, 4, 5}, collect(true, 2))
+		assert.Equal(t, []int{1, 2, 3, 4, 5}, collect(true, 5), "A full last batch ends with an empty one")
+		assert.Equal(t, []int{1, 2, 3, 4, 5}, collect(true, 100))
+	})
+
+	t.Run("Stop at the first callback error", func(t *testing.T) {
+		stop := errors.New("stop")
+		seen := 0
+		err := db.StreamFiles(true, 2, func(file *File) error {
+			seen++
+			if seen == 3 {
+				return stop
+			}
+			return nil
+		})
+		assert.ErrorIs(t, err, stop)
+		assert.Equal(t, 3, seen)
+	})
+}
+
+func TestDatabase_FileCursorPagination(t *testing.T) {
+	db := testDB(t)
+
+	// Files 2 and 3 share an upload time, the ID breaks the tie
+	base := time.Now().Add(-time.Hour)
+	uploadTimes := []time.Time{base, base.Add(time.Minute), base.Add(time.Minute), base.Add(2 * time.Minute)}
+	for i, uploadTime := range uploadTimes {
+		require.NoError(t, db.InsertFile(&File{
+			Hash:         fmt.Sprintf("cursor%d", i),
+			OriginalName: fmt.Sprintf("cursor%d.txt", i),
+			FileType:     "iostat",
+			FileSize:     100,
+			UploadTime:   uploadTime,
+			FilePath:     fmt.Sprintf("/uploads/cursor%d", i),
+		}))
+	}
+
+	var ids []int
+	var after *FileCursor
+	for {
+		files, err := db.GetFiles(3, 0, false, "", after)
+		require.NoError(t, err)
+		for _, file := range files {
+			ids = append(ids, file.ID)
+		}
+		if len(files) < 3 {
+			break
+		}
+		after = NewFileCursor(files[len(files)-1])
+	}
+	assert.Equal(t, []int{4, 3, 2, 1}, ids)
+
+	t.Run("Cursor tokens round trip", func(t *testing.T) {
+		cursor := &FileCursor{UploadTime: base, ID: 42}
+		parsed, err := ParseFileCursor(cursor.String())
+		require.NoError(t, err)
+		assert.Equal(t, 42, parsed.ID)
+		assert.True(t, base.Equal(parsed.UploadTime))
+
+		for _, token := range []string{"", "!!!", "bm8tc2VwYXJhdG9y", "eHx5"} {
+			_, err := ParseFileCursor(token)
+			assert.Error(t, err, token)
+		}
+	})
+}
+
+func TestDatabase_MigratesDetectionColumns(t *testing.T) {
+	cfg := testutil.TestConfig(t)
+
+	// Create a files table using the schema from before detection confidence was stored
+	oldDB, err := sql.Open("sqlite", cfg.DBPath)
+	require.NoError(t, err)
+	_, err = oldDB.Exec(`CREATE TABLE files (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		hash TEXT UNIQUE NOT NULL,
+		original_name TEXT NOT NULL,
+		file_type TEXT NOT NULL,
+		file_size INTEGER NOT NULL,
+		upload_time DATETIME NOT NULL,
+		file_path TEXT NOT NULL,
+		deleted BOOLEAN DEFAULT FALSE,
+		deleted_time DATETIME
+	)`)
+	require.NoError(t, err)
+	_, err = oldDB.Exec(`INSERT INTO files (hash, original_name, file_type, file_size, upload_time, file_path)
+		VALUES ('old-hash', 'old.txt', 'ttop', 10, ?, '/uploads/old-hash')`, time.Now())
+	require.NoError(t, err)
+	// And a reports table from before generation times and priorities were stored
+	_, err = oldDB.Exec(`CREATE TABLE reports (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		file_id INTEGER NOT NULL,
+		report_type TEXT NOT NULL,
+		status TEXT NOT NULL,
+		created_time DATETIME NOT NULL,
+		completed_time DATETIME,
+		ddd_version TEXT NOT NULL,
+		report_data TEXT,
+		error_message TEXT
+	)`)
+	require.NoError(t, err)
+	_, err = oldDB.Exec(`INSERT INTO reports (file_id, report_type, status, created_time, ddd_version)
+		VALUES (1, 'ttop', 'pending', ?, '0.9.0')`, time.Now())
+	require.NoError(t, err)
+	require.NoError(t, oldDB.Close())
+
+	db, err := Initialize(cfg.DBPath)
+	require.NoError(t, err)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("Error closing database: %v", err)
+		}
+	}()
+
+	file, err := db.GetFileByHash("old-hash")
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, file.DetectionConfidence)
+	assert.Equal(t, "", file.DetectionSignal)
+	assert.Equal(t, "", file.UploadGroupID)
+
+	// The original upload is backfilled as the file's first occurrence
+	uploads, err := db.GetFileUploads(file.ID)
+	require.NoError(t, err)
+	require.Len(t, uploads, 1)
+	assert.Equal(t, "old.txt", uploads[0].OriginalName)
+
+	// Existing reports keep the default priority
+	pending, err := db.GetPendingReports()
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, 0, pending[0].Priority)
+	assert.Zero(t, pending[0].GenerationMs)
+}
+
+func TestDatabase_FileUploads(t *testing.T) {
+	db := testDB(t)
+
+	uploadTime := time.Now().Add(-time.Hour)
+	file := &File{
+		Hash:         "uploads-hash",
+		OriginalName: "iostat-node1.txt",
+		FileType:     "iostat",
+		FileSize:     100,
+		UploadTime:   uploadTime,
+		FilePath:     "/uploads/uploads-hash",
+	}
+	require.NoError(t, db.InsertFile(file))
+
+	t.Run("First upload is recorded with the file", func(t *testing.T) {
+		uploads, err := db.GetFileUploads(file.ID)
+		require.NoError(t, err)
+		require.Len(t, uploads, 1)
+		assert.Equal(t, "iostat-node1.txt", uploads[0].OriginalName)
+		assert.True(t, uploads[0].UploadTime.Equal(uploadTime))
+	})
+
+	t.Run("Later uploads are appended", func(t *testing.T) {
+		second := &FileUpload{FileID: file.ID, OriginalName: "iostat-node2.txt", UploadTime: time.Now()}
+		require.NoError(t, db.InsertFileUpload(second))
+		assert.NotZero(t, second.ID)
+
+		uploads, err := db.GetFileUploads(file.ID)
+		require.NoError(t, err)
+		require.Len(t, uploads, 2)
+		assert.Equal(t, "iostat-node1.txt", uploads[0].OriginalName)
+		assert.Equal(t, "iostat-node2.txt", uploads[1].OriginalName)
+	})
+
+	t.Run("Removed with the file", func(t *testing.T) {
+		require.NoError(t, db.DeleteFileCompletely(file.ID))
+
+		uploads, err := db.GetFileUploads(file.ID)
+		require.NoError(t, err)
+		assert.Empty(t, uploads)
+	})
+}
+
+func TestDatabase_FileVersions(t *testing.T) {
+	db := testDB(t)
+
+	var previous *File
+	versions := make([]*File, 0, 3)
+	for i := 0; i < 3; i++ {
+		file := &File{
+			Hash:         fmt.Sprintf("version%d", i),
+			OriginalName: "gc.log",
+			FileType:     "gc",
+			FileSize:     int64(100 * (i + 1)),
+			UploadTime:   time.Now().Add(time.Duration(i) * time.Minute),
+			FilePath:     fmt.Sprintf("/uploads/version%d", i),
+		}
+		if previous != nil {
+			file.SupersedesFileID = &previous.ID
+		}
+		require.NoError(t, db.InsertFile(file))
+		versions = append(versions, file)
+		previous = file
+	}
+
+	t.Run("Chain from any version", func(t *testing.T) {
+		for _, version := range versions {
+			chain, err := db.GetFileVersions(version.ID)
+			require.NoError(t, err)
+			require.Len(t, chain, 3)
+			for i, file := range chain {
+				assert.Equal(t, versions[i].ID, file.ID)
+			}
+		}
+
+		first, err := db.GetFileByID(versions[0].ID)
+		require.NoError(t, err)
+		assert.Nil(t, first.SupersedesFileID)
+	})
+
+	t.Run("Superseded by", func(t *testing.T) {
+		newer, err := db.GetFileSupersededBy(versions[0].ID)
+		require.NoError(t, err)
+		assert.Equal(t, versions[1].ID, newer.ID)
+
+		_, err = db.GetFileSupersededBy(versions[2].ID)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+
+	t.Run("A version can only be superseded once", func(t *testing.T) {
+		branch := &File{
+			Hash:             "version-branch",
+			OriginalName:     "gc.log",
+			FileType:         "gc",
+			FileSize:         150,
+			UploadTime:       time.Now(),
+			FilePath:         "/uploads/version-branch",
+			SupersedesFileID: &versions[0].ID,
+		}
+		assert.ErrorIs(t, db.InsertFile(branch), ErrAlreadySuperseded)
+
+		_, err := db.GetFileByHash("version-branch")
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+
+	t.Run("Deleting a version unlinks it", func(t *testing.T) {
+		require.NoError(t, db.DeleteFileCompletely(versions[1].ID))
+
+		last, err := db.GetFileByID(versions[2].ID)
+		require.NoError(t, err)
+		assert.Nil(t, last.SupersedesFileID)
+
+		chain, err := db.GetFileVersions(versions[2].ID)
+		require.NoError(t, err)
+		assert.Len(t, chain, 1)
+	})
+}
+
+func TestDatabase_SupersedesIndexMigration(t *testing.T) {
+	cfg := testutil.TestConfig(t)
+
+	db, err := Initialize(cfg.DBPath)
+	require.NoError(t, err)
+	first := &File{Hash: "first", OriginalName: "gc.log", FileType: "gc", FileSize: 100, UploadTime: time.Now(), FilePath: "/uploads/first"}
+	require.NoError(t, db.InsertFile(first))
+
+	// Simulate a database from before the index was unique, where two uploads superseded the same file
+	_, err = db.Exec(`DROP INDEX idx_files_supersedes_file_id_unique`)
+	require.NoError(t, err)
+	newer := make([]*File, 0, 2)
+	for i := 0; i < 2; i++ {
+		file := &File{Hash: fmt.Sprintf("newer%d", i), OriginalName: "gc.log", FileType: "gc", FileSize: 200, UploadTime: time.Now(),
+			FilePath: fmt.Sprintf("/uploads/newer%d", i), SupersedesFileID: &first.ID}
+		require.NoError(t, db.InsertFile(file))
+		newer = append(newer, file)
+	}
+	require.NoError(t, db.Close())
+
+	db, err = Initialize(cfg.DBPath)
+	require.NoError(t, err)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("Error closing database: %v", err)
+		}
+	}()
+
+	kept, err := db.GetFileByID(newer[0].ID)
+	require.NoError(t, err)
+	require.NotNil(t, kept.SupersedesFileID)
+	assert.Equal(t, first.ID, *kept.SupersedesFileID)
+
+	unlinked, err := db.GetFileByID(newer[1].ID)
+	require.NoError(t, err)
+	assert.Nil(t, unlinked.SupersedesFileID)
 }
 
 func TestDatabase_ReportOperations(t *testing.T) {
@@ -553,6 +964,17 @@ func TestDatabase_ReportOperations(t *testing.T) {
 	err := db.InsertFile(file)
 	require.NoError(t, err)
 
+	// A file has one pending or running report of each type, so subtests queueing several
+	// reports put each on its own copy of the file
+	copies := 0
+	copyFile := func(t *testing.T) *File {
+		copies++
+		copied := *file
+		copied.Hash = fmt.Sprintf("%s-%d", file.Hash, copies)
+		require.NoError(t, db.InsertFile(&copied))
+		return &copied
+	}
+
 	t.Run("InsertReport", func(t *testing.T) {
 		report := &Report{
 			FileID:      file.ID,
@@ -570,7 +992,7 @@ func TestDatabase_ReportOperations(t *testing.T) {
 	t.Run("GetPendingReports", func(t *testing.T) {
 		// Insert a pending report
 		pendingReport := &Report{
-			FileID:      file.ID,
+			FileID:      copyFile(t).ID,
 			ReportType:  "ttop",
 			Status:      "pending",
 			CreatedTime: time.Now(),
@@ -594,10 +1016,114 @@ func TestDatabase_ReportOperations(t *testing.T) {
 		assert.True(t, found, "Should find the pending report")
 	})
 
+	t.Run("GetPendingReports orders by priority then age", func(t *testing.T) {
+		base := time.Now().Add(-time.Hour)
+		oldest := &Report{FileID: copyFile(t).ID, ReportType: "ttop", Status: "pending", CreatedTime: base, DDDVersion: "1.0.0"}
+		newer := &Report{FileID: copyFile(t).ID, ReportType: "ttop", Status: "pending", CreatedTime: base.Add(time.Minute), DDDVersion: "1.0.0"}
+		urgent := &Report{FileID: copyFile(t).ID, ReportType: "ttop", Status: "pending", CreatedTime: base.Add(2 * time.Minute), DDDVersion: "1.0.0"}
+		for _, report := range []*Report{newer, urgent, oldest} {
+			require.NoError(t, db.InsertReport(report))
+		}
+		require.NoError(t, db.SetReportPriority(urgent.ID, 5))
+
+		reports, err := db.GetPendingReports()
+		require.NoError(t, err)
+		var order []int
+		for _, report := range reports {
+			if report.ID == oldest.ID || report.ID == newer.ID || report.ID == urgent.ID {
+				order = append(order, report.ID)
+			}
+		}
+		assert.Equal(t, []int{urgent.ID, oldest.ID, newer.ID}, order)
+		assert.Equal(t, 5, reports[0].Priority)
+
+		// Only pending reports can be reprioritized
+		require.NoError(t, db.UpdateReportStatus(oldest.ID, "running"))
+		assert.ErrorIs(t, db.SetReportPriority(oldest.ID, 1), sql.ErrNoRows)
+		assert.ErrorIs(t, db.SetReportPriority(99999, 1), sql.ErrNoRows)
+
+		for _, report := range []*Report{oldest, newer, urgent} {
+			require.NoError(t, db.DeleteReport(report.ID))
+		}
+	})
+
+	t.Run("SetReportStarred", func(t *testing.T) {
+		plain := &Report{FileID: file.ID, ReportType: "ttop", Status: "completed", CreatedTime: time.Now(), DDDVersion: "1.0.0"}
+		starred := &Report{FileID: file.ID, ReportType: "ttop", Status: "failed", CreatedTime: time.Now(), DDDVersion: "1.0.0"}
+		for _, report := range []*Report{plain, starred} {
+			require.NoError(t, db.InsertReport(report))
+		}
+		require.NoError(t, db.SetReportStarred(starred.ID, true))
+
+		stored, err := db.GetReportByID(starred.ID)
+		require.NoError(t, err)
+		assert.True(t, stored.Starred)
+
+		listed, err := db.GetStarredReportsByFileIDPaged(file.ID, -1, 0)
+		require.NoError(t, err)
+		require.Len(t, listed, 1)
+		assert.Equal(t, starred.ID, listed[0].ID)
+		count, err := db.GetStarredReportCountByFileID(file.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+
+		require.NoError(t, db.SetReportStarred(starred.ID, false))
+		count, err = db.GetStarredReportCountByFileID(file.ID)
+		require.NoError(t, err)
+		assert.Zero(t, count)
+		assert.ErrorIs(t, db.SetReportStarred(99999, true), sql.ErrNoRows)
+
+		for _, report := range []*Report{plain, starred} {
+			require.NoError(t, db.DeleteReport(report.ID))
+		}
+	})
+
+	t.Run("StartReport and CancelPendingReport only change pending reports", func(t *testing.T) {
+		started := &Report{FileID: copyFile(t).ID, ReportType: "ttop", Status: "pending", CreatedTime: time.Now(), DDDVersion: "1.0.0"}
+		cancelled := &Report{FileID: copyFile(t).ID, ReportType: "ttop", Status: "pending", CreatedTime: time.Now(), DDDVersion: "1.0.0"}
+		require.NoError(t, db.InsertReport(started))
+		require.NoError(t, db.InsertReport(cancelled))
+
+		require.NoError(t, db.StartReport(started.ID))
+		report, err := db.GetReportByID(started.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "running", report.Status)
+
+		require.NoError(t, db.CancelPendingReport(cancelled.ID, "Cancelled by test"))
+		report, err = db.GetReportByID(cancelled.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "cancelled", report.Status)
+		assert.Equal(t, "Cancelled by test", report.ErrorMessage)
+		assert.NotNil(t, report.CompletedTime)
+
+		// A report that left the queue can neither be started nor cancelled again
+		assert.ErrorIs(t, db.StartReport(started.ID), sql.ErrNoRows)
+		assert.ErrorIs(t, db.StartReport(cancelled.ID), sql.ErrNoRows)
+		assert.ErrorIs(t, db.CancelPendingReport(started.ID, "Cancelled by test"), sql.ErrNoRows)
+		assert.ErrorIs(t, db.CancelPendingReport(99999, "Cancelled by test"), sql.ErrNoRows)
+
+		for _, report := range []*Report{started, cancelled} {
+			require.NoError(t, db.DeleteReport(report.ID))
+		}
+	})
+
+	t.Run("CountReportsByStatus", func(t *testing.T) {
+		pending, err := db.GetPendingReports()
+		require.NoError(t, err)
+
+		count, err := db.CountReportsByStatus("pending")
+		require.NoError(t, err)
+		assert.Equal(t, len(pending), count)
+
+		count, err = db.CountReportsByStatus("no-such-status")
+		require.NoError(t, err)
+		assert.Zero(t, count)
+	})
+
 	t.Run("UpdateReportStatus", func(t *testing.T) {
 		// Insert a report
 		report := &Report{
-			FileID:      file.ID,
+			FileID:      copyFile(t).ID,
 			ReportType:  "ttop",
 			Status:      "pending",
 			CreatedTime: time.Now(),
@@ -620,7 +1146,7 @@ func TestDatabase_ReportOperations(t *testing.T) {
 	t.Run("CompleteReport", func(t *testing.T) {
 		// Insert a report
 		report := &Report{
-			FileID:      file.ID,
+			FileID:      copyFile(t).ID,
 			ReportType:  "ttop",
 			Status:      "running",
 			CreatedTime: time.Now(),
@@ -647,7 +1173,7 @@ func TestDatabase_ReportOperations(t *testing.T) {
 	t.Run("FailReport", func(t *testing.T) {
 		// Insert a report
 		report := &Report{
-			FileID:      file.ID,
+			FileID:      copyFile(t).ID,
 			ReportType:  "ttop",
 			Status:      "running",
 			CreatedTime: time.Now(),
@@ -705,6 +1231,263 @@ func TestDatabase_ReportOperations(t *testing.T) {
 			assert.Equal(t, file.ID, report.FileID)
 		}
 	})
+
+	t.Run("GetReportsByFileIDPaged", func(t *testing.T) {
+		pagedFile := &File{
+			Hash:         "paged-reports-hash",
+			OriginalName: "paged.txt",
+			FileType:     "ttop",
+			FileSize:     100,
+			UploadTime:   time.Now(),
+			FilePath:     "/uploads/paged-reports-hash",
+		}
+		require.NoError(t, db.InsertFile(pagedFile))
+
+		base := time.Now().Add(-time.Hour)
+		for i := 0; i < 5; i++ {
+			require.NoError(t, db.InsertReport(&Report{
+				FileID:      pagedFile.ID,
+				ReportType:  "ttop",
+				Status:      "completed",
+				CreatedTime: base.Add(time.Duration(i) * time.Minute),
+				DDDVersion:  "1.0.0",
+			}))
+		}
+
+		all, err := db.GetReportsByFileID(pagedFile.ID)
+		require.NoError(t, err)
+		require.Len(t, all, 5)
+
+		firstPage, err := db.GetReportsByFileIDPaged(pagedFile.ID, 2, 0)
+		require.NoError(t, err)
+		require.Len(t, firstPage, 2)
+		assert.Equal(t, all[0].ID, firstPage[0].ID, "Newest report should come first")
+		assert.Equal(t, all[1].ID, firstPage[1].ID)
+
+		lastPage, err := db.GetReportsByFileIDPaged(pagedFile.ID, 2, 4)
+		require.NoError(t, err)
+		require.Len(t, lastPage, 1)
+		assert.Equal(t, all[4].ID, lastPage[0].ID)
+
+		pastEnd, err := db.GetReportsByFileIDPaged(pagedFile.ID, 2, 10)
+		require.NoError(t, err)
+		assert.Empty(t, pastEnd)
+	})
+
+	t.Run("GetCompletedReportsByType", func(t *testing.T) {
+		typedFile := &File{
+			Hash:         "completed-by-type-hash",
+			OriginalName: "typed.txt",
+			FileType:     "queries_json",
+			FileSize:     100,
+			UploadTime:   time.Now(),
+			FilePath:     "/uploads/completed-by-type-hash",
+		}
+		require.NoError(t, db.InsertFile(typedFile))
+
+		base := time.Now()
+		older := &Report{FileID: typedFile.ID, ReportType: "queries_json", Status: "completed", CreatedTime: base, DDDVersion: "0.9.0"}
+		newer := &Report{FileID: typedFile.ID, ReportType: "queries_json", Status: "completed", CreatedTime: base.Add(time.Minute), DDDVersion: "1.0.0"}
+		failed := &Report{FileID: typedFile.ID, ReportType: "queries_json", Status: "failed", CreatedTime: base, DDDVersion: "1.0.0"}
+		for _, report := range []*Report{newer, older, failed} {
+			require.NoError(t, db.InsertReport(report))
+		}
+
+		reports, err := db.GetCompletedReportsByType("queries_json")
+		require.NoError(t, err)
+		require.Len(t, reports, 2, "Only completed reports should be returned")
+		assert.Equal(t, older.ID, reports[0].ID, "Oldest report should come first")
+		assert.Equal(t, "0.9.0", reports[0].DDDVersion)
+		assert.Equal(t, newer.ID, reports[1].ID)
+
+		none, err := db.GetCompletedReportsByType("no_such_type")
+		require.NoError(t, err)
+		assert.Empty(t, none)
+	})
+
+	t.Run("GetActiveReport", func(t *testing.T) {
+		activeFile := &File{
+			Hash:         "active-reports-hash",
+			OriginalName: "active.txt",
+			FileType:     "ttop",
+			FileSize:     100,
+			UploadTime:   time.Now(),
+			FilePath:     "/uploads/active-reports-hash",
+		}
+		require.NoError(t, db.InsertFile(activeFile))
+
+		_, err := db.GetActiveReport(activeFile.ID, "ttop")
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+
+		completed := &Report{FileID: activeFile.ID, ReportType: "ttop", Status: "completed", CreatedTime: time.Now(), DDDVersion: "1.0.0"}
+		require.NoError(t, db.InsertReport(completed))
+		_, err = db.GetActiveReport(activeFile.ID, "ttop")
+		assert.ErrorIs(t, err, sql.ErrNoRows, "Completed reports are not active")
+
+		running := &Report{FileID: activeFile.ID, ReportType: "ttop", Status: "running", CreatedTime: time.Now(), DDDVersion: "1.0.0"}
+		require.NoError(t, db.InsertReport(running))
+		active, err := db.GetActiveReport(activeFile.ID, "ttop")
+		require.NoError(t, err)
+		assert.Equal(t, running.ID, active.ID)
+
+		_, err = db.GetActiveReport(activeFile.ID, "iostat")
+		assert.ErrorIs(t, err, sql.ErrNoRows, "Active reports of other types should not match")
+
+		// Only one report of a type can be queued for a file at a time
+		pending := &Report{FileID: activeFile.ID, ReportType: "ttop", Status: "pending", CreatedTime: time.Now(), DDDVersion: "1.0.0"}
+		assert.ErrorIs(t, db.InsertReport(pending), ErrReportAlreadyActive)
+		failed := &Report{FileID: activeFile.ID, ReportType: "ttop", Status: "failed", CreatedTime: time.Now(), DDDVersion: "1.0.0"}
+		require.NoError(t, db.InsertReport(failed))
+		assert.ErrorIs(t, db.RequeueFailedReport(failed.ID, "1.0.0"), ErrReportAlreadyActive)
+
+		require.NoError(t, db.CompleteReport(running.ID, `{"type": "ttop"}`))
+		require.NoError(t, db.RequeueFailedReport(failed.ID, "1.0.0"))
+	})
+}
+
+func TestDatabase_ActiveReportIndexMigration(t *testing.T) {
+	cfg := testutil.TestConfig(t)
+
+	db, err := Initialize(cfg.DBPath)
+	require.NoError(t, err)
+	file := &File{Hash: "queued", OriginalName: "ttop.txt", FileType: "ttop", FileSize: 100, UploadTime: time.Now(), FilePath: "/uploads/queued"}
+	require.NoError(t, db.InsertFile(file))
+
+	// Simulate a database from before the index, where the same report was queued twice
+	_, err = db.Exec(`DROP INDEX idx_reports_active_unique`)
+	require.NoError(t, err)
+	queued := make([]*Report, 0, 2)
+	for i := 0; i < 2; i++ {
+		report := &Report{FileID: file.ID, ReportType: "ttop", Status: "pending", CreatedTime: time.Now(), DDDVersion: "1.0.0"}
+		require.NoError(t, db.InsertReport(report))
+		queued = append(queued, report)
+	}
+	require.NoError(t, db.Close())
+
+	db, err = Initialize(cfg.DBPath)
+	require.NoError(t, err)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("Error closing database: %v", err)
+		}
+	}()
+
+	kept, err := db.GetReportByID(queued[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "pending", kept.Status)
+
+	duplicate, err := db.GetReportByID(queued[1].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "failed", duplicate.Status)
+	assert.Contains(t, duplicate.ErrorMessage, "Duplicate")
+}
+
+func TestDatabase_ReportThroughput(t *testing.T) {
+	db := testDB(t)
+
+	file := &File{
+		Hash:         "throughput-hash",
+		OriginalName: "throughput.txt",
+		FileType:     "ttop",
+		FileSize:     100,
+		UploadTime:   time.Now(),
+		FilePath:     "/uploads/throughput-hash",
+	}
+	require.NoError(t, db.InsertFile(file))
+
+	since := time.Now().Add(-3 * time.Minute).Truncate(time.Minute)
+	at := func(offset time.Duration) *time.Time {
+		ts := since.Add(offset)
+		return &ts
+	}
+	reports := []*Report{
+		// Finished in the first minute
+		{Status: "completed", CreatedTime: *at(10 * time.Second), CompletedTime: at(20 * time.Second), GenerationMs: 1000},
+		{Status: "failed", CreatedTime: *at(15 * time.Second), CompletedTime: at(30 * time.Second), GenerationMs: 3000},
+		// Queued in the second minute, finished in the third
+		{Status: "completed", CreatedTime: *at(70 * time.Second), CompletedTime: at(130 * time.Second), GenerationMs: 500},
+		// Still running, so its completed_time does not count
+		{Status: "running", CreatedTime: *at(75 * time.Second), CompletedTime: at(80 * time.Second)},
+		// Finished before the window
+		{Status: "completed", CreatedTime: *at(-time.Hour), CompletedTime: at(-time.Hour + time.Second)},
+	}
+	for _, report := range reports {
+		report.FileID = file.ID
+		report.ReportType = "ttop"
+		report.DDDVersion = "1.0.0"
+		require.NoError(t, db.InsertReport(report))
+		require.NoError(t, db.SetReportGenerationTime(report.ID, time.Duration(report.GenerationMs)*time.Millisecond))
+	}
+
+	t.Run("Buckets by interval", func(t *testing.T) {
+		buckets, err := db.GetReportThroughput(since, time.Minute)
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, len(buckets), 3)
+
+		assert.True(t, buckets[0].Start.Equal(since))
+		assert.Equal(t, 2, buckets[0].Queued)
+		assert.Equal(t, 1, buckets[0].Completed)
+		assert.Equal(t, 1, buckets[0].Failed)
+		assert.Equal(t, 2000.0, buckets[0].AvgGenerationMs)
+
+		assert.Equal(t, 2, buckets[1].Queued)
+		assert.Equal(t, 0, buckets[1].Completed)
+		assert.Equal(t, 0.0, buckets[1].AvgGenerationMs)
+
+		assert.Equal(t, 0, buckets[2].Queued)
+		assert.Equal(t, 1, buckets[2].Completed)
+		assert.Equal(t, 500.0, buckets[2].AvgGenerationMs)
+	})
+
+	t.Run("Generation time is stored on the report", func(t *testing.T) {
+		report, err := db.GetReportByID(reports[1].ID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3000), report.GenerationMs)
+	})
+
+	t.Run("Invalid interval", func(t *testing.T) {
+		_, err := db.GetReportThroughput(since, 0)
+		assert.Error(t, err)
+	})
+}
+
+func TestDatabase_Stats(t *testing.T) {
+	db := testDB(t)
+
+	stats, err := db.GetStats()
+	require.NoError(t, err)
+	assert.Equal(t, 0, stats.TotalFiles)
+	assert.Equal(t, int64(0), stats.TotalBytes)
+	assert.Empty(t, stats.FilesByType)
+	assert.Empty(t, stats.ReportsByStatus)
+
+	var files []*File
+	for i, fileType := range []string{"ttop", "ttop", "iostat", "jfr"} {
+		file := &File{Hash: fmt.Sprintf("stats-hash-%d", i), OriginalName: "file.txt", FileType: fileType, FileSize: int64(100 * (i + 1)), UploadTime: time.Now(), FilePath: "/uploads/stats"}
+		require.NoError(t, db.InsertFile(file))
+		files = append(files, file)
+	}
+	require.NoError(t, db.MarkFileDeleted(files[3].ID))
+
+	now := time.Now()
+	yesterday := now.Add(-48 * time.Hour)
+	for _, report := range []*Report{
+		{FileID: files[0].ID, ReportType: "ttop", Status: "completed", CreatedTime: now, CompletedTime: &now, DDDVersion: "1.0.0"},
+		{FileID: files[1].ID, ReportType: "ttop", Status: "completed", CreatedTime: yesterday, CompletedTime: &yesterday, DDDVersion: "1.0.0"},
+		{FileID: files[2].ID, ReportType: "iostat", Status: "failed", CreatedTime: now, CompletedTime: &now, DDDVersion: "1.0.0"},
+		{FileID: files[2].ID, ReportType: "iostat", Status: "pending", CreatedTime: now, DDDVersion: "1.0.0"},
+	} {
+		require.NoError(t, db.InsertReport(report))
+	}
+
+	stats, err = db.GetStats()
+	require.NoError(t, err)
+	assert.Equal(t, 3, stats.TotalFiles, "Deleted files are not counted")
+	assert.Equal(t, int64(600), stats.TotalBytes)
+	assert.Equal(t, map[string]int{"ttop": 2, "iostat": 1}, stats.FilesByType)
+	assert.Equal(t, 4, stats.TotalReports)
+	assert.Equal(t, map[string]int{"completed": 2, "failed": 1, "pending": 1}, stats.ReportsByStatus)
+	assert.Equal(t, 1, stats.ReportsLast24h, "Only reports completed in the last 24 hours are counted")
 }
 
 func TestDatabase_Settings(t *testing.T) {
@@ -779,6 +1562,327 @@ func TestDatabase_Settings(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, "0.5", value)
 	})
+
+	t.Run("Reads are cached until the setting is written", func(t *testing.T) {
+		require.NoError(t, db.SetSetting("cached_key", "first"))
+		value, err := db.GetSetting("cached_key")
+		require.NoError(t, err)
+		assert.Equal(t, "first", value)
+
+		// A change behind SetSetting's back isn't seen, the value comes from the cache
+		_, err = db.Exec(`UPDATE settings SET value = 'unseen' WHERE key = 'cached_key'`)
+		require.NoError(t, err)
+		value, err = db.GetSetting("cached_key")
+		require.NoError(t, err)
+		assert.Equal(t, "first", value)
+
+		require.NoError(t, db.SetSetting("cached_key", "second"))
+		value, err = db.GetSetting("cached_key")
+		require.NoError(t, err)
+		assert.Equal(t, "second", value)
+	})
+
+	t.Run("Settings that are not set are cached until they are written", func(t *testing.T) {
+		_, err := db.GetSetting("unset_key")
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+
+		// Served from the cache, the row inserted behind SetSetting's back isn't seen
+		_, err = db.Exec(`INSERT INTO settings (key, value, updated_time) VALUES ('unset_key', 'unseen', ?)`, time.Now())
+		require.NoError(t, err)
+		_, err = db.GetSetting("unset_key")
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+
+		require.NoError(t, db.SetSetting("unset_key", "set"))
+		value, err := db.GetSetting("unset_key")
+		require.NoError(t, err)
+		assert.Equal(t, "set", value)
+	})
+
+	t.Run("Concurrent reads and writes", func(t *testing.T) {
+		require.NoError(t, db.SetSetting("concurrent_key", "0"))
+
+		var wg sync.WaitGroup
+		for reader := 0; reader < 4; reader++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 50; i++ {
+					_, err := db.GetSetting("concurrent_key")
+					assert.NoError(t, err)
+				}
+			}()
+		}
+		for i := 1; i <= 20; i++ {
+			require.NoError(t, db.SetSetting("concurrent_key", strconv.Itoa(i)))
+		}
+		wg.Wait()
+
+		value, err := db.GetSetting("concurrent_key")
+		require.NoError(t, err)
+		assert.Equal(t, "20", value, "The last write is read once the writers are done")
+	})
+}
+
+func TestDatabase_SettingsHistory(t *testing.T) {
+	db := testDB(t)
+
+	require.NoError(t, db.SetSetting("max_disk_usage", "0.5"))
+	require.NoError(t, db.SetSetting("max_disk_usage", "0.9"))
+	require.NoError(t, db.SetSetting("max_disk_usage", "0.9")) // unchanged, not recorded
+	require.NoError(t, db.SetSetting("file_retention_days", "7"))
+
+	t.Run("All changes newest first", func(t *testing.T) {
+		changes, err := db.GetSettingsHistory("", 0)
+		require.NoError(t, err)
+		require.Len(t, changes, 3)
+
+		assert.Equal(t, "file_retention_days", changes[0].Key)
+		assert.Equal(t, "max_disk_usage", changes[1].Key)
+		require.NotNil(t, changes[1].OldValue)
+		assert.Equal(t, "0.5", *changes[1].OldValue)
+		assert.Equal(t, "0.9", changes[1].NewValue)
+		assert.Nil(t, changes[2].OldValue, "First write of a setting has no old value")
+		assert.False(t, changes[2].ChangedTime.IsZero())
+	})
+
+	t.Run("Filter by key and limit", func(t *testing.T) {
+		changes, err := db.GetSettingsHistory("max_disk_usage", 1)
+		require.NoError(t, err)
+		require.Len(t, changes, 1)
+		assert.Equal(t, "0.9", changes[0].NewValue)
+
+		changes, err = db.GetSettingsHistory("unknown", 0)
+		require.NoError(t, err)
+		assert.Empty(t, changes)
+	})
+}
+
+func TestDatabase_ReportPurge(t *testing.T) {
+	db := testDB(t)
+
+	insertFile := func(t *testing.T, hash string, deletedAgo time.Duration) *File {
+		file := &File{Hash: hash, OriginalName: hash + ".txt", FileType: "ttop", FileSize: 10, UploadTime: time.Now(), FilePath: "/uploads/" + hash}
+		require.NoError(t, db.InsertFile(file))
+		if deletedAgo > 0 {
+			_, err := db.Exec(`UPDATE files SET deleted = TRUE, deleted_time = ? WHERE id = ?`, time.Now().Add(-deletedAgo), file.ID)
+			require.NoError(t, err)
+		}
+		return file
+	}
+	insertReport := func(t *testing.T, file *File, status, data string) *Report {
+		report := &Report{FileID: file.ID, ReportType: "ttop", Status: status, CreatedTime: time.Now(), DDDVersion: "1.0.0", ReportData: data}
+		require.NoError(t, db.InsertReport(report))
+		return report
+	}
+
+	longDeleted := insertFile(t, "long-deleted", 40*24*time.Hour)
+	recentlyDeleted := insertFile(t, "recently-deleted", 24*time.Hour)
+	active := insertFile(t, "active", 0)
+
+	purgeable := insertReport(t, longDeleted, "completed", `{"summary":"zanzibar threads"}`)
+	insertReport(t, longDeleted, "failed", "")
+	insertReport(t, recentlyDeleted, "completed", `{"summary":"recent"}`)
+	insertReport(t, active, "completed", `{"summary":"active"}`)
+
+	reports, err := db.GetReportsForPurge(time.Now().Add(-30 * 24 * time.Hour))
+	require.NoError(t, err)
+	require.Len(t, reports, 1, "Only reports with data whose file was deleted before the cutoff are purged")
+	assert.Equal(t, purgeable.ID, reports[0].ID)
+
+	results, err := db.SearchReports("zanzibar", 10)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+
+	require.NoError(t, db.PurgeReport(purgeable.ID))
+
+	tombstone, err := db.GetReportByID(purgeable.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "purged", tombstone.Status)
+	assert.Empty(t, tombstone.ReportData)
+
+	results, err = db.SearchReports("zanzibar", 10)
+	require.NoError(t, err)
+	assert.Empty(t, results, "Purged reports leave the search index")
+
+	reports, err = db.GetReportsForPurge(time.Now().Add(-30 * 24 * time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, reports, "Tombstones are not purged again")
+}
+
+func TestDatabase_TrimReportData(t *testing.T) {
+	db := testDB(t)
+
+	file := &File{Hash: "trim-hash", OriginalName: "iostat.txt", FileType: "iostat", FileSize: 10, UploadTime: time.Now(), FilePath: "/uploads/trim-hash"}
+	require.NoError(t, db.InsertFile(file))
+	insertReport := func(t *testing.T, status string, age time.Duration) *Report {
+		report := &Report{FileID: file.ID, ReportType: "iostat", Status: status, CreatedTime: time.Now().Add(-age), DDDVersion: "1.0.0",
+			ReportData: `{"type":"iostat","summary":"zanzibar disks","analysis":"sda saturated","findings":[{"device":"sda"}],` +
+				`"html_report":"<html><body>quokka chart</body></html>","chart_data":{"cpu":{"labels":["12:00:00"]}}}`}
+		require.NoError(t, db.InsertReport(report))
+		return report
+	}
+	old := insertReport(t, "completed", 40*24*time.Hour)
+	insertReport(t, "completed", 24*time.Hour)
+	insertReport(t, "failed", 40*24*time.Hour)
+	baseline := insertReport(t, "completed", 50*24*time.Hour)
+	_, err := db.SaveBaseline("r5.2xlarge", baseline.ID)
+	require.NoError(t, err)
+
+	cutoff := time.Now().Add(-30 * 24 * time.Hour)
+	reports, err := db.GetReportsForTrim(cutoff)
+	require.NoError(t, err)
+	require.Len(t, reports, 1, "Only completed reports created before the cutoff are trimmed, baselines keep their data")
+	assert.Equal(t, old.ID, reports[0].ID)
+
+	require.NoError(t, db.TrimReportData(old.ID))
+
+	trimmed, err := db.GetReportByID(old.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "completed", trimmed.Status)
+	var data map[string]any
+	require.NoError(t, json.Unmarshal([]byte(trimmed.ReportData), &data))
+	assert.NotContains(t, data, "html_report")
+	assert.NotContains(t, data, "chart_data")
+	assert.Equal(t, "zanzibar disks", data["summary"])
+	assert.Equal(t, "sda saturated", data["analysis"])
+	assert.Len(t, data["findings"], 1)
+	assert.Contains(t, data, "data_trimmed_at")
+
+	results, err := db.SearchReports("zanzibar", 10)
+	require.NoError(t, err)
+	assert.Len(t, results, 3, "Trimmed reports are still found by their summary")
+	results, err = db.SearchReports("quokka", 10)
+	require.NoError(t, err)
+	assert.Len(t, results, 2, "but not by the charts they no longer have")
+
+	reports, err = db.GetReportsForTrim(cutoff)
+	require.NoError(t, err)
+	assert.Empty(t, reports, "Trimmed reports are not trimmed again")
+	var trimmedTime sql.NullTime
+	require.NoError(t, db.QueryRow(`SELECT trimmed_time FROM reports WHERE id = ?`, old.ID).Scan(&trimmedTime))
+	assert.True(t, trimmedTime.Valid)
+
+	// Trimming again changes nothing
+	require.NoError(t, db.TrimReportData(old.ID))
+	again, err := db.GetReportByID(old.ID)
+	require.NoError(t, err)
+	assert.Equal(t, trimmed.ReportData, again.ReportData)
+
+	assert.ErrorIs(t, db.TrimReportData(999), sql.ErrNoRows)
+
+	t.Run("Reports stored already trimmed are only marked", func(t *testing.T) {
+		imported := insertReport(t, "completed", 40*24*time.Hour)
+		data, err := TrimmedReportData(imported.ReportData)
+		require.NoError(t, err)
+		require.NoError(t, db.CompleteReport(imported.ID, data))
+
+		reports, err := db.GetReportsForTrim(cutoff)
+		require.NoError(t, err)
+		require.Len(t, reports, 1)
+		require.NoError(t, db.TrimReportData(imported.ID))
+		reports, err = db.GetReportsForTrim(cutoff)
+		require.NoError(t, err)
+		assert.Empty(t, reports)
+	})
+
+	t.Run("Regenerated reports can be trimmed again", func(t *testing.T) {
+		require.NoError(t, db.CompleteReport(old.ID, `{"type":"iostat","html_report":"<html></html>"}`))
+		reports, err := db.GetReportsForTrim(cutoff)
+		require.NoError(t, err)
+		require.Len(t, reports, 1)
+		assert.Equal(t, old.ID, reports[0].ID)
+	})
+}
+
+func TestDatabase_TrimmedTimeMigration(t *testing.T) {
+	cfg := testutil.TestConfig(t)
+	oldDB, err := sql.Open("sqlite", cfg.DBPath)
+	require.NoError(t, err)
+	_, err = oldDB.Exec(`
+		CREATE TABLE reports (id INTEGER PRIMARY KEY AUTOINCREMENT, report_data TEXT);
+		INSERT INTO reports (report_data) VALUES ('{"type":"iostat","data_trimmed_at":"2025-01-01T00:00:00Z"}'), ('{"type":"iostat"}');
+	`)
+	require.NoError(t, err)
+
+	require.NoError(t, addTrimmedTimeColumn(oldDB))
+	rows, err := oldDB.Query(`SELECT id FROM reports WHERE trimmed_time IS NOT NULL`)
+	require.NoError(t, err)
+	var ids []int
+	for rows.Next() {
+		var id int
+		require.NoError(t, rows.Scan(&id))
+		ids = append(ids, id)
+	}
+	require.NoError(t, rows.Err())
+	require.NoError(t, rows.Close())
+	assert.Equal(t, []int{1}, ids, "Reports trimmed before the column existed are marked")
+
+	// Running it again leaves the column alone
+	require.NoError(t, addTrimmedTimeColumn(oldDB))
+	require.NoError(t, oldDB.Close())
+}
+
+func TestDatabase_FailedReports(t *testing.T) {
+	db := testDB(t)
+
+	ttopFile := &File{Hash: "failed-ttop", OriginalName: "node1-ttop.txt", FileType: "ttop", FileSize: 10, UploadTime: time.Now(), FilePath: "/uploads/failed-ttop"}
+	require.NoError(t, db.InsertFile(ttopFile))
+	iostatFile := &File{Hash: "failed-iostat", OriginalName: "node1-iostat.txt", FileType: "iostat", FileSize: 10, UploadTime: time.Now(), FilePath: "/uploads/failed-iostat"}
+	require.NoError(t, db.InsertFile(iostatFile))
+
+	insertReport := func(t *testing.T, file *File, status, errorMessage string) *Report {
+		report := &Report{FileID: file.ID, ReportType: file.FileType, Status: "pending", CreatedTime: time.Now(), DDDVersion: "1.0.0"}
+		require.NoError(t, db.InsertReport(report))
+		require.NoError(t, db.UpdateReport(report.ID, status, "", errorMessage))
+		return report
+	}
+
+	first := insertReport(t, ttopFile, "failed", "failed to parse ttop content: unexpected EOF")
+	insertReport(t, ttopFile, "completed", "")
+	second := insertReport(t, iostatFile, "failed", "File not found")
+
+	reports, err := db.GetFailedReports(10, 0)
+	require.NoError(t, err)
+	require.Len(t, reports, 2)
+	assert.Equal(t, second.ID, reports[0].ID, "Most recent failure first")
+	assert.Equal(t, "File not found", reports[0].ErrorMessage)
+	assert.Equal(t, "node1-iostat.txt", reports[0].FileName)
+	assert.Equal(t, "iostat", reports[0].FileType)
+	assert.Equal(t, first.ID, reports[1].ID)
+
+	reports, err = db.GetFailedReports(1, 1)
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	assert.Equal(t, first.ID, reports[0].ID)
+
+	count, err := db.CountFailedReports("")
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	t.Run("RequeueFailedReport", func(t *testing.T) {
+		completed := insertReport(t, iostatFile, "completed", "")
+		assert.ErrorIs(t, db.RequeueFailedReport(completed.ID, "2.0.0"), sql.ErrNoRows)
+
+		failed := insertReport(t, iostatFile, "failed", "boom")
+		require.NoError(t, db.RequeueFailedReport(failed.ID, "2.0.0"))
+		requeued, err := db.GetReportByID(failed.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "pending", requeued.Status)
+		assert.Equal(t, "2.0.0", requeued.DDDVersion)
+		assert.Empty(t, requeued.ErrorMessage)
+		assert.Nil(t, requeued.CompletedTime)
+	})
+
+	for _, searchQuery := range []string{"EOF", "node1-ttop", "ttop"} {
+		reports, err = db.SearchFailedReports(searchQuery, 10, 0)
+		require.NoError(t, err)
+		require.Len(t, reports, 1, searchQuery)
+		assert.Equal(t, first.ID, reports[0].ID, searchQuery)
+
+		count, err = db.CountFailedReports(searchQuery)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count, searchQuery)
+	}
 }
 
 func TestDatabase_FileCleanup(t *testing.T) {
@@ -0,0 +1,62 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FileCursor marks the last file of a page in the files listing, which is ordered by
+// upload time and then ID, both descending. The next page starts right after it, so files
+// uploaded or deleted while a client pages through the listing never shift later pages
+type FileCursor struct {
+	UploadTime time.Time
+	ID         int
+}
+
+// NewFileCursor returns the cursor pointing just past file
+func NewFileCursor(file *File) *FileCursor {
+	return &FileCursor{UploadTime: file.UploadTime, ID: file.ID}
+}
+
+// String encodes the cursor as an opaque token for clients to send back
+func (c *FileCursor) String() string {
+	raw := c.UploadTime.Format(time.RFC3339Nano) + "|" + strconv.Itoa(c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseFileCursor decodes a token made by FileCursor.String
+func ParseFileCursor(token string) (*FileCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor %q", token)
+	}
+	uploadTime, id, found := strings.Cut(string(raw), "|")
+	if !found {
+		return nil, fmt.Errorf("invalid cursor %q", token)
+	}
+	cursor := &FileCursor{}
+	if cursor.UploadTime, err = time.Parse(time.RFC3339Nano, uploadTime); err != nil {
+		return nil, fmt.Errorf("invalid cursor %q", token)
+	}
+	if cursor.ID, err = strconv.Atoi(id); err != nil {
+		return nil, fmt.Errorf("invalid cursor %q", token)
+	}
+	return cursor, nil
+}
@@ -0,0 +1,235 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Markers wrapped around matched terms in search snippets. They are control characters
+// so callers can escape the snippet text before turning them into highlighting.
+const (
+	SnippetMatchStart = "\x02"
+	SnippetMatchEnd   = "\x03"
+)
+
+var (
+	styleBlockRegex = regexp.MustCompile(`(?is)<style[^>]*>.*?</style>`)
+	htmlTagRegex    = regexp.MustCompile(`<[^>]+>`)
+	whitespaceRegex = regexp.MustCompile(`\s+`)
+)
+
+// ReportSearchResult is a report whose content matched a full-text search
+type ReportSearchResult struct {
+	ReportID    int       `json:"report_id"`
+	FileID      int       `json:"file_id"`
+	FileName    string    `json:"file_name"`
+	FileDeleted bool      `json:"file_deleted"`
+	ReportType  string    `json:"report_type"`
+	Status      string    `json:"status"`
+	CreatedTime time.Time `json:"created_time"`
+	Snippet     string    `json:"snippet"`
+}
+
+// createSearchTables creates the FTS5 index over report content, drops entries whose
+// report no longer exists and indexes any completed reports missing from it, e.g. ones
+// created before the index existed
+func createSearchTables(db *sql.DB) error {
+	if _, err := db.Exec(`CREATE VIRTUAL TABLE IF NOT EXISTS report_search USING fts5(content)`); err != nil {
+		return fmt.Errorf("failed to create report search index: %w", err)
+	}
+
+	// Reports deleted by any path, including with their file, leave the index with them
+	if _, err := db.Exec(`
+		CREATE TRIGGER IF NOT EXISTS reports_unindex AFTER DELETE ON reports
+		BEGIN
+			DELETE FROM report_search WHERE rowid = OLD.id;
+		END
+	`); err != nil {
+		return fmt.Errorf("failed to create report search trigger: %w", err)
+	}
+
+	// Databases from before the trigger may hold entries of reports deleted since
+	result, err := db.Exec(`DELETE FROM report_search WHERE rowid NOT IN (SELECT id FROM reports)`)
+	if err != nil {
+		return fmt.Errorf("failed to remove stale report search entries: %w", err)
+	}
+	if removed, err := result.RowsAffected(); err == nil && removed > 0 {
+		log.Printf("Removed %d search entries of deleted reports", removed)
+	}
+
+	rows, err := db.Query(`
+		SELECT id, report_data FROM reports
+		WHERE status = 'completed' AND COALESCE(report_data, '') != ''
+		  AND id NOT IN (SELECT rowid FROM report_search)
+	`)
+	if err != nil {
+		return err
+	}
+	pending := map[int]string{}
+	for rows.Next() {
+		var id int
+		var data string
+		if err := rows.Scan(&id, &data); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		pending[id] = data
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+
+	for id, data := range pending {
+		if err := indexReport(db, id, data); err != nil {
+			return err
+		}
+	}
+	if len(pending) > 0 {
+		log.Printf("Indexed %d existing reports for search", len(pending))
+	}
+	return nil
+}
+
+// indexReport replaces the search index entry for a report with its current content
+func indexReport(db *sql.DB, reportID int, reportData string) error {
+	if err := unindexReport(db, reportID); err != nil {
+		return err
+	}
+	if reportData == "" {
+		return nil
+	}
+	_, err := db.Exec(`INSERT INTO report_search (rowid, content) VALUES (?, ?)`, reportID, reportSearchText(reportData))
+	return err
+}
+
+// unindexReport removes a report from the search index
+func unindexReport(db *sql.DB, reportID int) error {
+	_, err := db.Exec(`DELETE FROM report_search WHERE rowid = ?`, reportID)
+	return err
+}
+
+// reportSearchText turns report JSON into plain text for indexing. Top-level fields are
+// flattened to their values and the HTML report is reduced to its visible text and chart
+// data, so thread names, devices and query ids are all searchable.
+func reportSearchText(reportData string) string {
+	var data map[string]any
+	if err := json.Unmarshal([]byte(reportData), &data); err != nil {
+		// Not JSON, index it as-is
+		return reportData
+	}
+
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, key := range keys {
+		if key == "html_report" {
+			continue
+		}
+		parts = appendSearchValues(parts, data[key])
+	}
+	if report, ok := data["html_report"].(string); ok {
+		text := styleBlockRegex.ReplaceAllString(report, " ")
+		text = htmlTagRegex.ReplaceAllString(text, " ")
+		parts = append(parts, html.UnescapeString(text))
+	}
+
+	return strings.TrimSpace(whitespaceRegex.ReplaceAllString(strings.Join(parts, " "), " "))
+}
+
+// appendSearchValues appends the string and number leaves of a decoded JSON value
+func appendSearchValues(parts []string, value any) []string {
+	switch v := value.(type) {
+	case string:
+		return append(parts, v)
+	case float64:
+		return append(parts, fmt.Sprint(v))
+	case []any:
+		for _, item := range v {
+			parts = appendSearchValues(parts, item)
+		}
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			parts = appendSearchValues(parts, v[key])
+		}
+	}
+	return parts
+}
+
+// ftsQuery turns free text into an FTS5 query matching reports containing every term.
+// Terms are quoted so punctuation in device or thread names is not read as FTS syntax.
+func ftsQuery(query string) string {
+	var terms []string
+	for _, term := range strings.Fields(query) {
+		terms = append(terms, `"`+strings.ReplaceAll(term, `"`, `""`)+`"`)
+	}
+	return strings.Join(terms, " ")
+}
+
+// SearchReports returns reports whose content contains every term in query, best matches
+// first. Matched terms in the snippet are wrapped in SnippetMatchStart and SnippetMatchEnd.
+func (db *DB) SearchReports(query string, limit int) ([]*ReportSearchResult, error) {
+	results := make([]*ReportSearchResult, 0)
+	match := ftsQuery(query)
+	if match == "" {
+		return results, nil
+	}
+
+	rows, err := db.Query(`
+		SELECT r.id, r.file_id, f.original_name, f.deleted, r.report_type, r.status, r.created_time,
+		       snippet(report_search, 0, ?, ?, '...', 16)
+		FROM report_search
+		JOIN reports r ON r.id = report_search.rowid
+		JOIN files f ON f.id = r.file_id
+		WHERE report_search MATCH ?
+		ORDER BY rank
+		LIMIT ?
+	`, SnippetMatchStart, SnippetMatchEnd, match, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Error closing rows: %v", err)
+		}
+	}()
+
+	for rows.Next() {
+		result := &ReportSearchResult{}
+		if err := rows.Scan(&result.ReportID, &result.FileID, &result.FileName, &result.FileDeleted,
+			&result.ReportType, &result.Status, &result.CreatedTime, &result.Snippet); err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
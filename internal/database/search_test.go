@@ -0,0 +1,188 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rsvihladremio/ddd/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const iostatReportData = `{"type":"iostat","summary":"IOStat analysis report covering 2 snapshots with 2 devices monitored",` +
+	`"findings":[{"device":"nvme0n1","metric":"%util","value":99.5}],` +
+	`"html_report":"<html><style>.chart { width: 100%; }</style><body><td>nvme0n1</td><td>sda &amp; sdb</td></body></html>"}`
+
+func insertSearchFixture(t *testing.T, db *DB, name, status, reportData string) *Report {
+	t.Helper()
+
+	file := &File{
+		Hash:         name + "-hash",
+		OriginalName: name,
+		FileType:     "iostat",
+		FileSize:     100,
+		UploadTime:   time.Now(),
+		FilePath:     "/uploads/" + name + "-hash",
+	}
+	require.NoError(t, db.InsertFile(file))
+
+	report := &Report{
+		FileID:      file.ID,
+		ReportType:  "iostat",
+		Status:      status,
+		CreatedTime: time.Now(),
+		DDDVersion:  "1.0.0",
+		ReportData:  reportData,
+	}
+	require.NoError(t, db.InsertReport(report))
+	return report
+}
+
+func TestReportSearchText(t *testing.T) {
+	text := reportSearchText(iostatReportData)
+
+	assert.Contains(t, text, "IOStat analysis report covering 2 snapshots")
+	assert.Contains(t, text, "nvme0n1 %util 99.5")
+	assert.Contains(t, text, "sda & sdb")
+	assert.NotContains(t, text, "<td>")
+	assert.NotContains(t, text, "width: 100%")
+
+	assert.Equal(t, "not json", reportSearchText("not json"))
+}
+
+func TestDatabase_SearchReports(t *testing.T) {
+	db := testDB(t)
+
+	t.Run("Completed reports are searchable", func(t *testing.T) {
+		report := insertSearchFixture(t, db, "node1-iostat.txt", "completed", iostatReportData)
+
+		results, err := db.SearchReports("nvme0n1", 10)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, report.ID, results[0].ReportID)
+		assert.Equal(t, "node1-iostat.txt", results[0].FileName)
+		assert.Equal(t, "iostat", results[0].ReportType)
+		assert.Contains(t, results[0].Snippet, SnippetMatchStart+"nvme0n1"+SnippetMatchEnd)
+	})
+
+	t.Run("Every term must match", func(t *testing.T) {
+		results, err := db.SearchReports("nvme0n1 saturation", 10)
+		require.NoError(t, err)
+		assert.Empty(t, results)
+	})
+
+	t.Run("FTS syntax in queries is treated as text", func(t *testing.T) {
+		results, err := db.SearchReports(`"%util" OR NEAR(`, 10)
+		require.NoError(t, err)
+		assert.Empty(t, results)
+
+		results, err = db.SearchReports("   ", 10)
+		require.NoError(t, err)
+		assert.Empty(t, results)
+	})
+
+	t.Run("Reports are indexed when they complete", func(t *testing.T) {
+		report := insertSearchFixture(t, db, "node2-iostat.txt", "pending", "")
+
+		results, err := db.SearchReports("xvdf", 10)
+		require.NoError(t, err)
+		assert.Empty(t, results)
+
+		require.NoError(t, db.UpdateReport(report.ID, "completed", `{"summary":"device xvdf saturated"}`, ""))
+		results, err = db.SearchReports("xvdf", 10)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, report.ID, results[0].ReportID)
+
+		// Re-running the report removes the stale content from the index
+		require.NoError(t, db.UpdateReport(report.ID, "running", "", ""))
+		results, err = db.SearchReports("xvdf", 10)
+		require.NoError(t, err)
+		assert.Empty(t, results)
+	})
+
+	t.Run("Deleted reports are removed from the index", func(t *testing.T) {
+		report := insertSearchFixture(t, db, "node3-iostat.txt", "completed", `{"summary":"device dm-3 saturated"}`)
+		require.NoError(t, db.DeleteReport(report.ID))
+
+		results, err := db.SearchReports("dm-3", 10)
+		require.NoError(t, err)
+		assert.Empty(t, results)
+	})
+
+	t.Run("Reports deleted with their file are removed from the index", func(t *testing.T) {
+		report := insertSearchFixture(t, db, "node4-iostat.txt", "completed", `{"summary":"device md0 saturated"}`)
+		_, err := db.Exec(`DELETE FROM reports WHERE file_id = ?`, report.FileID)
+		require.NoError(t, err)
+		require.NoError(t, db.DeleteFileCompletely(report.FileID))
+
+		var entries int
+		require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM report_search WHERE rowid = ?`, report.ID).Scan(&entries))
+		assert.Zero(t, entries)
+	})
+}
+
+func TestDatabase_SearchIndexBackfill(t *testing.T) {
+	cfg := testutil.TestConfig(t)
+
+	db, err := Initialize(cfg.DBPath)
+	require.NoError(t, err)
+	report := insertSearchFixture(t, db, "old-iostat.txt", "completed", iostatReportData)
+
+	// Simulate a database created before the search index existed
+	_, err = db.Exec(`DROP TABLE report_search`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	db, err = Initialize(cfg.DBPath)
+	require.NoError(t, err)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("Error closing database: %v", err)
+		}
+	}()
+
+	results, err := db.SearchReports("nvme0n1", 10)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, report.ID, results[0].ReportID)
+}
+
+func TestDatabase_SearchIndexRemovesStaleEntries(t *testing.T) {
+	cfg := testutil.TestConfig(t)
+
+	db, err := Initialize(cfg.DBPath)
+	require.NoError(t, err)
+	report := insertSearchFixture(t, db, "kept-iostat.txt", "completed", iostatReportData)
+
+	// Simulate an entry left behind by a report deleted before the trigger existed
+	_, err = db.Exec(`INSERT INTO report_search (rowid, content) VALUES (?, 'sdz saturated')`, report.ID+1000)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	db, err = Initialize(cfg.DBPath)
+	require.NoError(t, err)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("Error closing database: %v", err)
+		}
+	}()
+
+	var entries int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM report_search`).Scan(&entries))
+	assert.Equal(t, 1, entries)
+}
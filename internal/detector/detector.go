@@ -19,9 +19,13 @@ import (
 	"archive/zip"
 	"bytes"
 	"compress/gzip"
+	"encoding/binary"
 	"encoding/json"
+	"errors"
+	"io"
 	"log"
 	"path/filepath"
+	"slices"
 	"strings"
 )
 
@@ -29,50 +33,226 @@ import (
 const (
 	FileTypeJFR           = "jfr"
 	FileTypeTTop          = "ttop"
+	FileTypeTop           = "top"
 	FileTypeIOStat        = "iostat"
+	FileTypeVMStat        = "vmstat"
+	FileTypeDremioProfile = "dremio_profile"
+	FileTypeThreadDump    = "thread_dump"
+	FileTypeQueriesJSON   = "queries_json"
+	FileTypeDremioConfig  = "dremio_config"
 	FileTypeArchive       = "archive"
 	FileTypeUnknown       = "unknown"
 )
 
+// KnownFileTypes returns every file type the detector can produce
+func KnownFileTypes() []string {
+	return []string{FileTypeJFR, FileTypeTTop, FileTypeTop, FileTypeIOStat, FileTypeVMStat, FileTypeDremioProfile,
+		FileTypeThreadDump, FileTypeQueriesJSON, FileTypeDremioConfig, FileTypeArchive, FileTypeUnknown}
+}
+
+// IsKnownFileType reports whether fileType is one of the file types the detector can produce
+func IsKnownFileType(fileType string) bool {
+	return slices.Contains(KnownFileTypes(), fileType)
+}
+
+// Detection signals describing what a detection was based on
+const (
+	SignalContent   = "content"
+	SignalExtension = "extension"
+	SignalNone      = "none"
+	SignalManual    = "manual"
+)
+
+// Confidence at or below which a detection should be double-checked by the user
+const LowConfidenceThreshold = 0.5
+
+// PrefixBytes is how much of a file detection looks at. Every signal is in the first few
+// KB of a capture, so larger files are never read further than this
+const PrefixBytes = 64 * 1024
+
+// Detection is the result of file type detection
+type Detection struct {
+	FileType   string  `json:"file_type"`  // Detected file type
+	Confidence float64 `json:"confidence"` // Confidence in the detection from 0.0 to 1.0
+	Signal     string  `json:"signal"`     // What the detection matched on (content, extension or none)
+}
+
 // DetectFileType detects the type of file based on content first, then filename as fallback
 func DetectFileType(filename string, content []byte) string {
+	return DetectFileTypeWithConfidence(filename, content).FileType
+}
+
+// DetectFileTypeWithConfidence detects the type of file based on content first, then filename
+// as fallback, and reports how confident the detection is and which signal it matched.
+// Only the first PrefixBytes of content are examined
+func DetectFileTypeWithConfidence(filename string, content []byte) Detection {
+	truncated := len(content) > PrefixBytes
+	if truncated {
+		content = content[:PrefixBytes]
+	}
+	return detectPrefix(filename, content, truncated)
+}
+
+// DetectFileTypeFromReader detects the type of the file name like DetectFileTypeWithConfidence,
+// reading no more than PrefixBytes from r
+func DetectFileTypeFromReader(name string, r io.Reader) (Detection, error) {
+	content, err := io.ReadAll(io.LimitReader(r, PrefixBytes+1))
+	if err != nil {
+		return Detection{}, err
+	}
+	return DetectFileTypeWithConfidence(name, content), nil
+}
+
+// detectPrefix detects the file type from the start of its content. truncated is set when
+// the file continues past content, so its last line or JSON value may be cut short
+func detectPrefix(filename string, content []byte, truncated bool) Detection {
 	ext := strings.ToLower(filepath.Ext(filename))
 
+	// A gzipped capture, e.g. iostat.log.gz, is the type of what it decompresses to
+	if inner, innerTruncated, ok := gunzipPrefix(content, truncated); ok {
+		return detectPrefix(strings.TrimSuffix(filename, filepath.Ext(filename)), inner, innerTruncated)
+	}
+
 	// Handle archives first (they need special processing)
 	if isArchive(ext) {
 		return detectArchiveContent(content)
 	}
 
+	// Binary formats carry magic bytes, so they are recognized whatever they are named
+	if detection, ok := detectByMagic(content); ok {
+		return detection
+	}
+
 	// If we have content, prioritize content-based detection
 	if len(content) > 0 {
-		// Try content-based detection first
+		// Profiles are JSON documents so check them first, before the looser text heuristics
+		if isDremioProfileFile(content) {
+			return Detection{FileType: FileTypeDremioProfile, Confidence: 0.95, Signal: SignalContent}
+		}
+
+		if isQueriesJSONFile(content, truncated) {
+			return Detection{FileType: FileTypeQueriesJSON, Confidence: 0.9, Signal: SignalContent}
+		}
+
+		if confidence := threadDumpConfidence(content); confidence > 0 {
+			return Detection{FileType: FileTypeThreadDump, Confidence: confidence, Signal: SignalContent}
+		}
+
+		// ttop runs top in thread mode, so plain top -b output has to be told apart first
+		if isTopBatchFile(content) {
+			return Detection{FileType: FileTypeTop, Confidence: 0.85, Signal: SignalContent}
+		}
+
 		if isTTopFile(content) {
-			return FileTypeTTop
+			return Detection{FileType: FileTypeTTop, Confidence: 0.8, Signal: SignalContent}
 		}
 
 		if isIOStatFile(content) {
-			return FileTypeIOStat
+			return Detection{FileType: FileTypeIOStat, Confidence: 0.9, Signal: SignalContent}
+		}
+
+		if isVMStatFile(content) {
+			return Detection{FileType: FileTypeVMStat, Confidence: 0.9, Signal: SignalContent}
+		}
+
+		if confidence := dremioConfigConfidence(content); confidence > 0 {
+			return Detection{FileType: FileTypeDremioConfig, Confidence: confidence, Signal: SignalContent}
 		}
 	}
 
 	// Fallback to filename-based detection
 	baseName := strings.ToLower(filepath.Base(filename))
 
-	// JFR files (primarily filename-based since they're binary)
+	// JFR files without the chunk magic, e.g. a recording cut short before its header
 	if ext == ".jfr" {
-		return FileTypeJFR
+		return Detection{FileType: FileTypeJFR, Confidence: 0.7, Signal: SignalExtension}
 	}
 
 	// Filename-based fallbacks for when content is not available or doesn't match
 	if strings.Contains(baseName, "ttop") && (ext == ".txt" || ext == "") {
-		return FileTypeTTop
+		return Detection{FileType: FileTypeTTop, Confidence: 0.4, Signal: SignalExtension}
+	}
+
+	if isTopName(baseName) {
+		return Detection{FileType: FileTypeTop, Confidence: 0.4, Signal: SignalExtension}
 	}
 
 	if strings.Contains(baseName, "iostat") {
-		return FileTypeIOStat
+		return Detection{FileType: FileTypeIOStat, Confidence: 0.4, Signal: SignalExtension}
 	}
 
-	return FileTypeUnknown
+	if strings.Contains(baseName, "vmstat") {
+		return Detection{FileType: FileTypeVMStat, Confidence: 0.4, Signal: SignalExtension}
+	}
+
+	if isThreadDumpName(baseName) {
+		return Detection{FileType: FileTypeThreadDump, Confidence: 0.4, Signal: SignalExtension}
+	}
+
+	if isQueriesJSONName(baseName) {
+		return Detection{FileType: FileTypeQueriesJSON, Confidence: 0.4, Signal: SignalExtension}
+	}
+
+	if isDremioConfigName(baseName) {
+		return Detection{FileType: FileTypeDremioConfig, Confidence: 0.4, Signal: SignalExtension}
+	}
+
+	return Detection{FileType: FileTypeUnknown, Confidence: 0, Signal: SignalNone}
+}
+
+// Magic bytes at the start of the binary formats DDD recognizes
+var (
+	jfrMagic  = []byte("FLR\x00")
+	gzipMagic = []byte{0x1f, 0x8b}
+	zipMagic  = []byte("PK\x03\x04")
+)
+
+// detectByMagic detects binary formats from the magic bytes their content starts with,
+// so a JFR recording or an archive uploaded as e.g. capture.dat is still recognized.
+// ok is false when prefix does not start with a known magic
+func detectByMagic(prefix []byte) (detection Detection, ok bool) {
+	switch {
+	case bytes.HasPrefix(prefix, jfrMagic):
+		return Detection{FileType: FileTypeJFR, Confidence: 0.95, Signal: SignalContent}, true
+	case bytes.HasPrefix(prefix, gzipMagic), bytes.HasPrefix(prefix, zipMagic):
+		detection = detectArchiveContent(prefix)
+		detection.Signal = SignalContent
+		return detection, true
+	default:
+		return Detection{}, false
+	}
+}
+
+// gunzipPrefix decompresses the start of a gzipped single file, so it can be detected like
+// the file itself. ok is false when content is not gzipped, or is a gzipped tar or nested
+// gzip stream that is left to archive detection. A prefix cut short mid-stream still
+// decompresses as far as it goes, so innerTruncated is set when either side is partial
+func gunzipPrefix(content []byte, truncated bool) (inner []byte, innerTruncated bool, ok bool) {
+	if !bytes.HasPrefix(content, gzipMagic) {
+		return nil, false, false
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, false, false
+	}
+	inner, err = io.ReadAll(io.LimitReader(gz, PrefixBytes+1))
+	if len(inner) == 0 || (err != nil && !truncated) {
+		return nil, false, false
+	}
+	if isTarHeader(inner) || bytes.HasPrefix(inner, gzipMagic) {
+		return nil, false, false
+	}
+
+	innerTruncated = truncated || err != nil || len(inner) > PrefixBytes
+	if len(inner) > PrefixBytes {
+		inner = inner[:PrefixBytes]
+	}
+	return inner, innerTruncated, true
+}
+
+// isTarHeader checks for the ustar magic in the first header block of a tar archive
+func isTarHeader(content []byte) bool {
+	return len(content) >= 262 && string(content[257:262]) == "ustar"
 }
 
 // isArchive checks if the file extension indicates an archive
@@ -86,27 +266,31 @@ func isArchive(ext string) bool {
 	return false
 }
 
-// detectArchiveContent analyzes archive content to determine the primary file type
-func detectArchiveContent(content []byte) string {
-	// Try to detect ZIP files
-	if zipFiles := extractZipFileList(content); len(zipFiles) > 0 {
-		return analyzeFileList(zipFiles)
+// detectArchiveContent analyzes archive content to determine the primary file type.
+// Archives are only matched on their member names, so confidence stays moderate.
+func detectArchiveContent(content []byte) Detection {
+	files := extractZipFileList(content)
+	if len(files) == 0 {
+		// Try to detect TAR files (including gzipped)
+		files = extractTarFileList(content)
 	}
 
-	// Try to detect TAR files (including gzipped)
-	if tarFiles := extractTarFileList(content); len(tarFiles) > 0 {
-		return analyzeFileList(tarFiles)
+	if len(files) > 0 {
+		if fileType := analyzeFileList(files); fileType != FileTypeArchive {
+			return Detection{FileType: fileType, Confidence: 0.6, Signal: SignalExtension}
+		}
 	}
 
-	return FileTypeArchive
+	return Detection{FileType: FileTypeArchive, Confidence: 0.5, Signal: SignalExtension}
 }
 
-// extractZipFileList extracts file list from ZIP archive
+// extractZipFileList extracts file list from ZIP archive. The list is kept at the end of
+// the archive, so when only a prefix is available the local file headers are read instead
 func extractZipFileList(content []byte) []string {
 	reader := bytes.NewReader(content)
 	zipReader, err := zip.NewReader(reader, int64(len(content)))
 	if err != nil {
-		return nil
+		return zipLocalFileNames(content)
 	}
 
 	var files []string
@@ -118,6 +302,29 @@ func extractZipFileList(content []byte) []string {
 	return files
 }
 
+// zipLocalFileNames lists the files whose local file headers start in content
+func zipLocalFileNames(content []byte) []string {
+	const headerSize = 30 // Fixed part of a local file header, followed by the name
+	signature := []byte("PK\x03\x04")
+
+	var files []string
+	for {
+		idx := bytes.Index(content, signature)
+		if idx < 0 || len(content)-idx < headerSize {
+			return files
+		}
+		header := content[idx:]
+		nameLen := int(binary.LittleEndian.Uint16(header[26:28]))
+		if len(header) < headerSize+nameLen {
+			return files
+		}
+		if name := string(header[headerSize : headerSize+nameLen]); !strings.HasSuffix(name, "/") {
+			files = append(files, name)
+		}
+		content = header[headerSize+nameLen:]
+	}
+}
+
 // extractTarFileList extracts file list from TAR archive (handles gzip compression)
 func extractTarFileList(content []byte) []string {
 	reader := bytes.NewReader(content)
@@ -161,7 +368,13 @@ func readTarFiles(tarReader *tar.Reader) []string {
 func analyzeFileList(files []string) string {
 	jfrCount := 0
 	ttopCount := 0
+	topCount := 0
 	iostatCount := 0
+	vmstatCount := 0
+	profileCount := 0
+	threadDumpCount := 0
+	queriesCount := 0
+	configCount := 0
 
 	for _, filename := range files {
 		// Use filename-based detection only for archives
@@ -171,8 +384,20 @@ func analyzeFileList(files []string) string {
 			jfrCount++
 		case FileTypeTTop:
 			ttopCount++
+		case FileTypeTop:
+			topCount++
 		case FileTypeIOStat:
 			iostatCount++
+		case FileTypeVMStat:
+			vmstatCount++
+		case FileTypeDremioProfile:
+			profileCount++
+		case FileTypeThreadDump:
+			threadDumpCount++
+		case FileTypeQueriesJSON:
+			queriesCount++
+		case FileTypeDremioConfig:
+			configCount++
 		}
 	}
 
@@ -183,9 +408,29 @@ func analyzeFileList(files []string) string {
 	if ttopCount > 0 {
 		return FileTypeTTop
 	}
+	if topCount > 0 {
+		return FileTypeTop
+	}
 	if iostatCount > 0 {
 		return FileTypeIOStat
 	}
+	if vmstatCount > 0 {
+		return FileTypeVMStat
+	}
+	if profileCount > 0 {
+		return FileTypeDremioProfile
+	}
+	if threadDumpCount > 0 {
+		return FileTypeThreadDump
+	}
+	if queriesCount > 0 {
+		return FileTypeQueriesJSON
+	}
+	// Support bundles always carry config files, so they only decide the type when
+	// nothing more specific is in the archive
+	if configCount > 0 {
+		return FileTypeDremioConfig
+	}
 
 	return FileTypeArchive
 }
@@ -205,14 +450,131 @@ func detectFileTypeByName(filename string) string {
 		return FileTypeTTop
 	}
 
+	// Plain top -b captures
+	if isTopName(baseName) {
+		return FileTypeTop
+	}
+
 	// IOStat files
 	if strings.Contains(baseName, "iostat") {
 		return FileTypeIOStat
 	}
 
+	// VMStat files
+	if strings.Contains(baseName, "vmstat") {
+		return FileTypeVMStat
+	}
+
+	// Thread dumps
+	if isThreadDumpName(baseName) {
+		return FileTypeThreadDump
+	}
+
+	// Dremio profile downloads contain profile_attempt_N.json entries
+	if strings.HasPrefix(baseName, "profile_attempt_") && ext == ".json" {
+		return FileTypeDremioProfile
+	}
+
+	// Dremio query logs, including rotated queries.2024-01-01.0.json files
+	if isQueriesJSONName(baseName) {
+		return FileTypeQueriesJSON
+	}
+
+	// Dremio and Hadoop configuration files from support bundles
+	if isDremioConfigName(baseName) {
+		return FileTypeDremioConfig
+	}
+
 	return FileTypeUnknown
 }
 
+// threadDumpConfidence returns how confident we are that content is a JVM thread dump,
+// or 0 when it does not look like one
+func threadDumpConfidence(content []byte) float64 {
+	contentStr := string(content[:min(8192, len(content))])
+	if strings.Contains(contentStr, "Full thread dump") {
+		return 0.9
+	}
+	// Dumps captured by some tools lose the header but keep the per-thread state lines
+	if strings.Contains(contentStr, "java.lang.Thread.State:") {
+		return 0.75
+	}
+	return 0
+}
+
+// isThreadDumpName checks if a lowercase file name looks like a thread dump
+func isThreadDumpName(baseName string) bool {
+	return strings.Contains(baseName, "jstack") ||
+		strings.Contains(baseName, "threaddump") ||
+		strings.Contains(baseName, "thread_dump") ||
+		strings.Contains(baseName, "thread-dump")
+}
+
+// isTopName checks if a lowercase file name looks like a plain top -b capture, e.g. top.txt
+// or top-executor1.log. Names are only matched on a leading "top" word so topology.json
+// and the like are left alone
+func isTopName(baseName string) bool {
+	ext := filepath.Ext(baseName)
+	if ext != ".txt" && ext != ".log" && ext != "" {
+		return false
+	}
+	stem := strings.TrimSuffix(baseName, ext)
+	return stem == "top" || strings.HasPrefix(stem, "top-") || strings.HasPrefix(stem, "top_")
+}
+
+// isQueriesJSONName checks if a lowercase file name looks like a Dremio queries.json log,
+// including the rotated queries.<date>.<n>.json files
+func isQueriesJSONName(baseName string) bool {
+	return strings.HasPrefix(baseName, "queries") && strings.HasSuffix(baseName, ".json")
+}
+
+// isDremioConfigName checks if a lowercase file name looks like a Dremio configuration file,
+// either dremio.conf, dremio-env or one of the Hadoop *-site.xml files Dremio reads
+func isDremioConfigName(baseName string) bool {
+	return baseName == "dremio.conf" || baseName == "dremio-env" || strings.HasSuffix(baseName, "-site.xml")
+}
+
+// dremioConfigConfidence returns how confident we are that content is a Dremio configuration
+// file, or 0 when it does not look like one. Hadoop site files are XML <configuration>
+// documents of <property> entries, dremio.conf is HOCON with the paths and services sections
+// and dremio-env is a shell file of DREMIO_* variables
+func dremioConfigConfidence(content []byte) float64 {
+	contentStr := string(content[:min(8192, len(content))])
+	if strings.Contains(contentStr, "<configuration") && strings.Contains(contentStr, "<property>") {
+		return 0.85
+	}
+	if hasHOCONSection(contentStr, "paths") && hasHOCONSection(contentStr, "services") {
+		return 0.8
+	}
+	if strings.Contains(contentStr, "DREMIO_MAX_HEAP_MEMORY_SIZE_MB") ||
+		strings.Contains(contentStr, "DREMIO_MAX_MEMORY_SIZE_MB") ||
+		strings.Contains(contentStr, "DREMIO_MAX_DIRECT_MEMORY_SIZE_MB") {
+		return 0.75
+	}
+	return 0
+}
+
+// hasHOCONSection checks if a line of content starts the named HOCON object, either
+// as "name {", "name: {" or "name = {", or sets a dotted key beneath it
+func hasHOCONSection(content, name string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		rest, found := strings.CutPrefix(line, name)
+		if !found {
+			continue
+		}
+		rest = strings.TrimSpace(rest)
+		if strings.HasPrefix(rest, ".") {
+			return true
+		}
+		rest = strings.TrimSpace(strings.TrimLeft(rest, ":="))
+		if strings.HasPrefix(rest, "{") {
+			return true
+		}
+	}
+	return false
+}
+
 // isTTopFile checks if content looks like a ttop file
 func isTTopFile(content []byte) bool {
 	contentStr := string(content[:min(1000, len(content))])
@@ -222,6 +584,16 @@ func isTTopFile(content []byte) bool {
 		(strings.Contains(contentStr, "TIME") || strings.Contains(contentStr, "%CPU"))
 }
 
+// isTopBatchFile checks if content looks like plain top -b output. It shares ttop's
+// "top - " snapshot header and column layout, but its summary counts "Tasks:" where ttop,
+// which runs top in thread mode, counts "Threads:"
+func isTopBatchFile(content []byte) bool {
+	contentStr := string(content[:min(1000, len(content))])
+	return strings.Contains(contentStr, "top - ") &&
+		strings.Contains(contentStr, "\nTasks:") &&
+		strings.Contains(contentStr, "PID")
+}
+
 // isIOStatFile checks if content looks like an iostat file
 func isIOStatFile(content []byte) bool {
 	contentStr := string(content[:min(1000, len(content))])
@@ -232,35 +604,132 @@ func isIOStatFile(content []byte) bool {
 			strings.Contains(contentStr, "r/s"))
 }
 
-// isQueriesJSONFile checks if content looks like a queries.json file
-func isQueriesJSONFile(content []byte) bool {
-	// Try to parse as JSON and check for query-like structure
-	var data any 
-	if err := json.Unmarshal(content, &data); err != nil {
-		return false
+// isVMStatFile checks if content looks like vmstat output, which repeats a column header
+// starting "r b" and naming the swpd memory and cs context switch columns
+func isVMStatFile(content []byte) bool {
+	contentStr := string(content[:min(1000, len(content))])
+	for _, line := range strings.Split(contentStr, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 2 && fields[0] == "r" && fields[1] == "b" &&
+			slices.Contains(fields, "swpd") && slices.Contains(fields, "cs") {
+			return true
+		}
 	}
+	return false
+}
 
-	// Check if it's an array or object with query-related fields
-	contentStr := string(content[:min(1000, len(content))])
-	return strings.Contains(contentStr, "query") ||
-		strings.Contains(contentStr, "sql") ||
-		strings.Contains(contentStr, "execution")
+// queriesJSONLinesToCheck is how many leading records are checked before a file is
+// detected as queries.json
+const queriesJSONLinesToCheck = 3
+
+// isQueriesJSONFile checks if content looks like the newline-delimited queries.json log
+// Dremio writes, where every line is a JSON object describing one finished query. When
+// content is truncated its last, partial line is not checked
+func isQueriesJSONFile(content []byte, truncated bool) bool {
+	if truncated {
+		idx := bytes.LastIndexByte(content, '\n')
+		if idx < 0 {
+			return false
+		}
+		content = content[:idx]
+	}
+
+	checked := 0
+	for len(content) > 0 && checked < queriesJSONLinesToCheck {
+		line := content
+		if idx := bytes.IndexByte(content, '\n'); idx >= 0 {
+			line, content = content[:idx], content[idx+1:]
+		} else {
+			content = nil
+		}
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		if !isQueryRecord(line) {
+			return false
+		}
+		checked++
+	}
+	return checked > 0
+}
+
+// isQueryRecord checks if a single line is a queries.json record
+func isQueryRecord(line []byte) bool {
+	if line[0] != '{' {
+		return false
+	}
+	var record map[string]json.RawMessage
+	if err := json.Unmarshal(line, &record); err != nil {
+		return false
+	}
+	if _, ok := record["queryId"]; !ok {
+		return false
+	}
+	for _, key := range []string{"queryText", "outcome", "start", "finish"} {
+		if _, ok := record[key]; ok {
+			return true
+		}
+	}
+	return false
 }
 
 // isDremioProfileFile checks if content looks like a Dremio profile file
 func isDremioProfileFile(content []byte) bool {
-	// Try to parse as JSON and check for Dremio-specific fields
-	var data map[string]any
-	if err := json.Unmarshal(content, &data); err != nil {
+	trimmed := bytes.TrimSpace(content)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return false
+	}
+
+	// Check the top-level keys for Dremio-specific fields
+	keys, ok := topLevelJSONKeys(trimmed)
+	if !ok {
 		return false
 	}
 
-	// Check for Dremio-specific fields
-	contentStr := strings.ToLower(string(content[:min(2000, len(content))]))
-	return strings.Contains(contentStr, "dremio") ||
-		strings.Contains(contentStr, "fragment") ||
-		strings.Contains(contentStr, "operator") ||
-		(strings.Contains(contentStr, "profile") && strings.Contains(contentStr, "query"))
+	// Fields only present in exported query profiles
+	for _, key := range []string{"fragmentProfile", "planPhases", "jsonPlan", "dremioVersion"} {
+		if keys[key] {
+			return true
+		}
+	}
+
+	// Simplified profiles with a query and profile section
+	return keys["query"] && keys["profile"]
+}
+
+// topLevelJSONKeys returns the keys of the JSON object content starts with. Profiles are
+// usually larger than the detection prefix, so the keys read before content ends are
+// returned; ok is false only when content is not valid JSON up to that point
+func topLevelJSONKeys(content []byte) (map[string]bool, bool) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil, false
+	}
+
+	keys := map[string]bool{}
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return keys, isEndOfInput(err)
+		}
+		key, isKey := token.(string)
+		if !isKey {
+			// The closing brace of the object
+			return keys, true
+		}
+		keys[key] = true
+
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return keys, isEndOfInput(err)
+		}
+	}
+}
+
+// isEndOfInput reports whether err means the JSON ended early rather than being invalid
+func isEndOfInput(err error) bool {
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
 }
 
 // min returns the minimum of two integers
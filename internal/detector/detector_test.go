@@ -19,6 +19,7 @@ import (
 	"archive/zip"
 	"bytes"
 	"compress/gzip"
+	"strings"
 	"testing"
 
 	"github.com/rsvihladremio/ddd/internal/testutil"
@@ -51,12 +52,108 @@ func TestDetectFileType(t *testing.T) {
 			content:      testutil.SampleFiles["ttop"].Content,
 			expectedType: FileTypeTTop,
 		},
+		{
+			name:         "Top batch file by content",
+			filename:     "capture.txt",
+			content:      testutil.SampleFiles["top"].Content,
+			expectedType: FileTypeTop,
+		},
+		{
+			name:         "Top in thread mode is ttop",
+			filename:     "capture.txt",
+			content:      []byte("top - 12:02:03 up  3:07,  0 users,  load average: 3.18, 1.16, 0.41\nThreads: 262 total,   6 running, 256 sleeping,   0 stopped,   0 zombie\n\n  PID USER      PR  NI    VIRT    RES    SHR S  %CPU  %MEM     TIME+ COMMAND\n"),
+			expectedType: FileTypeTTop,
+		},
+		{
+			name:         "Top file by name",
+			filename:     "top-executor1.log",
+			content:      []byte("nothing recognizable"),
+			expectedType: FileTypeTop,
+		},
+		{
+			name:         "Names merely starting with top are not top",
+			filename:     "topology.txt",
+			content:      []byte("nothing recognizable"),
+			expectedType: FileTypeUnknown,
+		},
 		{
 			name:         "IOStat file by content",
 			filename:     "iostat_output.txt",
 			content:      testutil.SampleFiles["iostat"].Content,
 			expectedType: FileTypeIOStat,
 		},
+		{
+			name:         "VMStat file by content",
+			filename:     "capture.txt",
+			content:      testutil.SampleFiles["vmstat"].Content,
+			expectedType: FileTypeVMStat,
+		},
+		{
+			name:         "VMStat file by name",
+			filename:     "vmstat-executor1.log",
+			content:      []byte("nothing recognizable"),
+			expectedType: FileTypeVMStat,
+		},
+		{
+			name:         "Dremio profile by content",
+			filename:     "profile_attempt_0.json",
+			content:      testutil.SampleFiles["dremio_profile"].Content,
+			expectedType: FileTypeDremioProfile,
+		},
+		{
+			name:         "Thread dump by content",
+			filename:     "output.txt",
+			content:      testutil.SampleFiles["thread_dump"].Content,
+			expectedType: FileTypeThreadDump,
+		},
+		{
+			name:         "Thread dump without header",
+			filename:     "output.txt",
+			content:      []byte("\"main\" #1 prio=5\n   java.lang.Thread.State: RUNNABLE\n"),
+			expectedType: FileTypeThreadDump,
+		},
+		{
+			name:         "Queries JSON by content",
+			filename:     "queries.log",
+			content:      testutil.SampleFiles["queries_json"].Content,
+			expectedType: FileTypeQueriesJSON,
+		},
+		{
+			name:         "Rotated queries JSON by name",
+			filename:     "queries.2024-09-04.0.json",
+			content:      []byte("truncated"),
+			expectedType: FileTypeQueriesJSON,
+		},
+		{
+			name:         "Thread dump by name",
+			filename:     "jstack-1234.log",
+			content:      []byte("truncated"),
+			expectedType: FileTypeThreadDump,
+		},
+		{
+			name:         "dremio.conf by content",
+			filename:     "node1.conf",
+			content:      testutil.SampleFiles["dremio_config"].Content,
+			expectedType: FileTypeDremioConfig,
+		},
+		{
+			name:         "Hadoop site file by content",
+			filename:     "config.xml",
+			content:      []byte("<?xml version=\"1.0\"?>\n<configuration>\n  <property>\n    <name>fs.defaultFS</name>\n    <value>hdfs://nn:8020</value>\n  </property>\n</configuration>\n"),
+			expectedType: FileTypeDremioConfig,
+		},
+		{
+			name:         "dremio-env by content",
+			filename:     "env.sh",
+			content:      []byte("#!/bin/bash\nDREMIO_MAX_HEAP_MEMORY_SIZE_MB=8192\n"),
+			expectedType: FileTypeDremioConfig,
+		},
+		{
+			name:         "Site file by name",
+			filename:     "core-site.xml",
+			content:      []byte("<configuration/>"),
+			expectedType: FileTypeDremioConfig,
+		},
 		{
 			name:         "Unknown file type",
 			filename:     "unknown.txt",
@@ -79,6 +176,164 @@ func TestDetectFileType(t *testing.T) {
 	}
 }
 
+func TestDetectFileTypeWithConfidence(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		content  []byte
+		expected Detection
+	}{
+		{
+			name:     "IOStat matched on content",
+			filename: "output.txt",
+			content:  testutil.SampleFiles["iostat"].Content,
+			expected: Detection{FileType: FileTypeIOStat, Confidence: 0.9, Signal: SignalContent},
+		},
+		{
+			name:     "IOStat matched on filename only",
+			filename: "iostat.log",
+			content:  []byte("nothing recognizable"),
+			expected: Detection{FileType: FileTypeIOStat, Confidence: 0.4, Signal: SignalExtension},
+		},
+		{
+			name:     "JFR with magic bytes",
+			filename: "recording.jfr",
+			content:  []byte("FLR\x00\x00\x02"),
+			expected: Detection{FileType: FileTypeJFR, Confidence: 0.95, Signal: SignalContent},
+		},
+		{
+			name:     "JFR by extension only",
+			filename: "recording.jfr",
+			content:  []byte("not a recording"),
+			expected: Detection{FileType: FileTypeJFR, Confidence: 0.7, Signal: SignalExtension},
+		},
+		{
+			name:     "Unknown file",
+			filename: "notes.txt",
+			content:  []byte("random"),
+			expected: Detection{FileType: FileTypeUnknown, Confidence: 0, Signal: SignalNone},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := DetectFileTypeWithConfidence(tt.filename, tt.content)
+			assert.Equal(t, tt.expected, result)
+			assert.Equal(t, tt.expected.FileType, DetectFileType(tt.filename, tt.content))
+		})
+	}
+
+	t.Run("Archive matched on member names", func(t *testing.T) {
+		zipContent := createTestZip(t, map[string][]byte{
+			"iostat.txt": testutil.SampleFiles["iostat"].Content,
+		})
+
+		result := DetectFileTypeWithConfidence("bundle.zip", zipContent)
+		assert.Equal(t, Detection{FileType: FileTypeIOStat, Confidence: 0.6, Signal: SignalExtension}, result)
+	})
+
+	t.Run("Archive with unrecognized members", func(t *testing.T) {
+		zipContent := createTestZip(t, map[string][]byte{
+			"readme.md": []byte("hello"),
+		})
+
+		result := DetectFileTypeWithConfidence("bundle.zip", zipContent)
+		assert.Equal(t, Detection{FileType: FileTypeArchive, Confidence: 0.5, Signal: SignalExtension}, result)
+	})
+}
+
+func TestDetectByMagic(t *testing.T) {
+	// A JFR chunk header: magic, then major version 2 and minor version 1
+	jfrHeader := []byte{'F', 'L', 'R', 0x00, 0x00, 0x02, 0x00, 0x01}
+	zipContent := createTestZip(t, map[string][]byte{"iostat.txt": testutil.SampleFiles["iostat"].Content})
+	tarGzContent := createTestTarGz(t, map[string][]byte{"ttop.txt": testutil.SampleFiles["ttop"].Content})
+
+	require.Equal(t, []byte{0x1f, 0x8b}, tarGzContent[:2], "gzip output must start with its magic")
+	require.Equal(t, []byte{'P', 'K', 0x03, 0x04}, zipContent[:4], "zip output must start with its magic")
+
+	tests := []struct {
+		name     string
+		prefix   []byte
+		expected Detection
+		ok       bool
+	}{
+		{"JFR chunk magic", jfrHeader, Detection{FileType: FileTypeJFR, Confidence: 0.95, Signal: SignalContent}, true},
+		{"Zip local file header", zipContent, Detection{FileType: FileTypeIOStat, Confidence: 0.6, Signal: SignalContent}, true},
+		{"Gzipped tar", tarGzContent, Detection{FileType: FileTypeTTop, Confidence: 0.6, Signal: SignalContent}, true},
+		{"Zip of unrecognized files", createTestZip(t, map[string][]byte{"readme.md": []byte("hi")}),
+			Detection{FileType: FileTypeArchive, Confidence: 0.5, Signal: SignalContent}, true},
+		{"Truncated JFR magic", []byte("FLR"), Detection{}, false},
+		{"Text", testutil.SampleFiles["iostat"].Content, Detection{}, false},
+		{"Empty", nil, Detection{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detection, ok := detectByMagic(tt.prefix)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, detection)
+		})
+	}
+
+	t.Run("Renamed binary files detect by content", func(t *testing.T) {
+		assert.Equal(t, Detection{FileType: FileTypeJFR, Confidence: 0.95, Signal: SignalContent},
+			DetectFileTypeWithConfidence("recording.dat", jfrHeader))
+		assert.Equal(t, Detection{FileType: FileTypeJFR, Confidence: 0.95, Signal: SignalContent},
+			DetectFileTypeWithConfidence("recording", jfrHeader))
+		assert.Equal(t, FileTypeIOStat, DetectFileType("node1-capture.dat", zipContent))
+		assert.Equal(t, FileTypeTTop, DetectFileType("capture", tarGzContent))
+	})
+}
+
+func TestDetectGzippedFiles(t *testing.T) {
+	gzipped := func(t *testing.T, content []byte) []byte {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		_, err := gz.Write(content)
+		require.NoError(t, err)
+		require.NoError(t, gz.Close())
+		return buf.Bytes()
+	}
+
+	tests := []struct {
+		name     string
+		filename string
+		content  []byte
+		expected Detection
+	}{
+		{"Gzipped iostat", "iostat.log.gz", gzipped(t, testutil.SampleFiles["iostat"].Content),
+			Detection{FileType: FileTypeIOStat, Confidence: 0.9, Signal: SignalContent}},
+		{"Gzipped ttop under another name", "capture.dat", gzipped(t, testutil.SampleFiles["ttop"].Content),
+			Detection{FileType: FileTypeTTop, Confidence: 0.8, Signal: SignalContent}},
+		{"Gzipped JFR", "recording.jfr.gz", gzipped(t, []byte("FLR\x00\x00\x02\x00\x01")),
+			Detection{FileType: FileTypeJFR, Confidence: 0.95, Signal: SignalContent}},
+		{"Name of the decompressed file is a fallback", "ttop.txt.gz", gzipped(t, []byte("nothing recognizable")),
+			Detection{FileType: FileTypeTTop, Confidence: 0.4, Signal: SignalExtension}},
+		{"Gzipped tar is still an archive", "bundle.tar.gz", createTestTarGz(t, map[string][]byte{"iostat.txt": []byte("x")}),
+			Detection{FileType: FileTypeIOStat, Confidence: 0.6, Signal: SignalExtension}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, DetectFileTypeWithConfidence(tt.filename, tt.content))
+		})
+	}
+
+	t.Run("Prefix cut short mid-stream", func(t *testing.T) {
+		compressed := gzipped(t, testutil.SampleFiles["iostat"].Content)
+		inner, truncated, ok := gunzipPrefix(compressed[:len(compressed)/2], true)
+		require.True(t, ok)
+		assert.True(t, truncated)
+		assert.True(t, bytes.HasPrefix(testutil.SampleFiles["iostat"].Content, inner))
+	})
+
+	t.Run("Corrupt stream that is not a prefix", func(t *testing.T) {
+		compressed := gzipped(t, testutil.SampleFiles["iostat"].Content)
+		_, _, ok := gunzipPrefix(compressed[:len(compressed)/2], false)
+		assert.False(t, ok)
+	})
+}
+
 func TestDetectArchiveContent(t *testing.T) {
 	t.Run("ZIP archive with JFR files", func(t *testing.T) {
 		zipContent := createTestZip(t, map[string][]byte{
@@ -102,6 +357,55 @@ func TestDetectArchiveContent(t *testing.T) {
 		assert.Equal(t, FileTypeTTop, result)
 	})
 
+	t.Run("ZIP archive with top files", func(t *testing.T) {
+		zipContent := createTestZip(t, map[string][]byte{
+			"top.txt":    testutil.SampleFiles["top"].Content,
+			"readme.txt": []byte("This is a readme"),
+		})
+
+		assert.Equal(t, FileTypeTop, DetectFileType("archive.zip", zipContent))
+	})
+
+	t.Run("ZIP archive with vmstat files", func(t *testing.T) {
+		zipContent := createTestZip(t, map[string][]byte{
+			"vmstat.txt": testutil.SampleFiles["vmstat"].Content,
+			"readme.txt": []byte("This is a readme"),
+		})
+
+		assert.Equal(t, FileTypeVMStat, DetectFileType("archive.zip", zipContent))
+	})
+
+	t.Run("ZIP archive with Dremio profile", func(t *testing.T) {
+		zipContent := createTestZip(t, map[string][]byte{
+			"header.json":            []byte(`{"dremioVersion": "25.0.0"}`),
+			"profile_attempt_0.json": testutil.SampleFiles["dremio_profile"].Content,
+		})
+
+		result := DetectFileType("profile.zip", zipContent)
+		assert.Equal(t, FileTypeDremioProfile, result)
+	})
+
+	t.Run("Support bundle with only config files", func(t *testing.T) {
+		zipContent := createTestZip(t, map[string][]byte{
+			"conf/dremio.conf":    testutil.SampleFiles["dremio_config"].Content,
+			"conf/core-site.xml":  []byte("<configuration/>"),
+			"logs/server.log.txt": []byte("INFO started"),
+		})
+
+		result := DetectFileType("bundle.zip", zipContent)
+		assert.Equal(t, FileTypeDremioConfig, result)
+	})
+
+	t.Run("Support bundle config files do not hide other types", func(t *testing.T) {
+		zipContent := createTestZip(t, map[string][]byte{
+			"conf/dremio.conf": testutil.SampleFiles["dremio_config"].Content,
+			"jstack.txt":       testutil.SampleFiles["thread_dump"].Content,
+		})
+
+		result := DetectFileType("bundle.zip", zipContent)
+		assert.Equal(t, FileTypeThreadDump, result)
+	})
+
 	t.Run("Archive with unknown content", func(t *testing.T) {
 		zipContent := createTestZip(t, map[string][]byte{
 			"file1.txt": []byte("unknown content"),
@@ -113,6 +417,58 @@ func TestDetectArchiveContent(t *testing.T) {
 	})
 }
 
+func TestDetectFileTypeFromPrefix(t *testing.T) {
+	// Padding pushes everything but the start of each file past the detection prefix
+	padding := bytes.Repeat([]byte("x"), 2*PrefixBytes)
+
+	t.Run("Reader is only read up to the prefix", func(t *testing.T) {
+		for _, fileType := range []string{"ttop", "iostat"} {
+			content := append(append([]byte{}, testutil.SampleFiles[fileType].Content...), padding...)
+			reader := bytes.NewReader(content)
+
+			detection, err := DetectFileTypeFromReader("capture.txt", reader)
+			require.NoError(t, err)
+			assert.Equal(t, fileType, detection.FileType)
+			assert.Equal(t, SignalContent, detection.Signal)
+			assert.Equal(t, len(content)-PrefixBytes-1, reader.Len(), "Only the prefix should be read")
+		}
+
+		detection, err := DetectFileTypeFromReader("recording.jfr", bytes.NewReader(append([]byte("FLR\x00"), padding...)))
+		require.NoError(t, err)
+		assert.Equal(t, Detection{FileType: FileTypeJFR, Confidence: 0.95, Signal: SignalContent}, detection)
+	})
+
+	t.Run("Profile larger than the prefix", func(t *testing.T) {
+		profile := bytes.TrimSpace(testutil.SampleFiles["dremio_profile"].Content)
+		profile = append(profile[:len(profile)-1], []byte(`, "padding": "`+string(padding)+`"}`)...)
+
+		assert.Equal(t, FileTypeDremioProfile, DetectFileType("download.json", profile))
+	})
+
+	t.Run("Query log whose last record is cut off", func(t *testing.T) {
+		record := `{"queryId": "1", "queryText": "SELECT 1", "outcome": "COMPLETED", "start": 1, "finish": 2}` + "\n"
+		content := []byte(strings.Repeat(record, PrefixBytes/len(record)+10))
+
+		assert.Equal(t, FileTypeQueriesJSON, DetectFileType("log.json", content))
+	})
+
+	t.Run("ZIP archive whose file list is past the prefix", func(t *testing.T) {
+		var buf bytes.Buffer
+		zipWriter := zip.NewWriter(&buf)
+		writer, err := zipWriter.Create("ttop.txt")
+		require.NoError(t, err)
+		_, err = writer.Write(testutil.SampleFiles["ttop"].Content)
+		require.NoError(t, err)
+		writer, err = zipWriter.CreateHeader(&zip.FileHeader{Name: "padding.bin", Method: zip.Store})
+		require.NoError(t, err)
+		_, err = writer.Write(padding)
+		require.NoError(t, err)
+		require.NoError(t, zipWriter.Close())
+
+		assert.Equal(t, FileTypeTTop, DetectFileType("bundle.zip", buf.Bytes()))
+	})
+}
+
 func TestIsTTopFile(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -195,6 +551,41 @@ func TestIsIOStatFile(t *testing.T) {
 	}
 }
 
+func TestIsVMStatFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  []byte
+		expected bool
+	}{
+		{
+			name:     "Valid vmstat -t content",
+			content:  testutil.SampleFiles["vmstat"].Content,
+			expected: true,
+		},
+		{
+			name:     "Valid vmstat without the column groups",
+			content:  []byte(" r  b   swpd   free   buff  cache   si   so    bi    bo   in   cs us sy id wa st\n 1  0      0 812344  94512 2150876    0    0    12    30  210  380  6  2 91  1  0\n"),
+			expected: true,
+		},
+		{
+			name:     "Invalid content - r b without vmstat columns",
+			content:  []byte("r b c\n1 2 3\n"),
+			expected: false,
+		},
+		{
+			name:     "Empty content",
+			content:  []byte(""),
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, isVMStatFile(tt.content))
+		})
+	}
+}
+
 func TestIsQueriesJSONFile(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -207,18 +598,23 @@ func TestIsQueriesJSONFile(t *testing.T) {
 			expected: true,
 		},
 		{
-			name:     "Valid queries JSON with different structure",
-			content:  []byte(`{"queries": [{"id": "456", "sql": "SELECT COUNT(*) FROM users"}]}`),
+			name:     "Single record without trailing newline",
+			content:  []byte(`{"queryId": "456", "queryText": "SELECT COUNT(*) FROM users", "outcome": "COMPLETED"}`),
 			expected: true,
 		},
 		{
-			name:     "Invalid JSON",
-			content:  []byte(`{"queries": [`),
+			name:     "Truncated record",
+			content:  []byte("{\"queryId\": \"456\", \"outcome\": \"COMPLETED\"}\n{\"queryId\": "),
 			expected: false,
 		},
 		{
-			name:     "Valid JSON but no queries field",
-			content:  []byte(`{"data": [{"id": "123"}]}`),
+			name:     "JSON array of queries",
+			content:  []byte(`{"queries": [{"id": "456", "sql": "SELECT COUNT(*) FROM users"}]}`),
+			expected: false,
+		},
+		{
+			name:     "Newline-delimited JSON without query fields",
+			content:  []byte("{\"level\": \"INFO\", \"message\": \"started\"}\n{\"level\": \"INFO\", \"message\": \"query executed\"}\n"),
 			expected: false,
 		},
 		{
@@ -230,7 +626,7 @@ func TestIsQueriesJSONFile(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := isQueriesJSONFile(tt.content)
+			result := isQueriesJSONFile(tt.content, false)
 			assert.Equal(t, tt.expected, result)
 		})
 	}
@@ -252,11 +648,21 @@ func TestIsDremioProfileFile(t *testing.T) {
 			content:  []byte(`{"query": {"queryId": "123"}, "profile": {"nodes": []}}`),
 			expected: true,
 		},
+		{
+			name:     "Exported profile with fragment profiles",
+			content:  testutil.SampleFiles["dremio_profile"].Content,
+			expected: true,
+		},
 		{
 			name:     "Invalid JSON",
 			content:  []byte(`{"query": {`),
 			expected: false,
 		},
+		{
+			name:     "Plain text mentioning operators",
+			content:  []byte("operator fragment dremio query profile"),
+			expected: false,
+		},
 		{
 			name:     "Valid JSON but missing query field",
 			content:  []byte(`{"profile": {"duration": 1000}}`),
@@ -282,6 +688,14 @@ func TestIsDremioProfileFile(t *testing.T) {
 	}
 }
 
+func TestIsKnownFileType(t *testing.T) {
+	for _, fileType := range []string{FileTypeJFR, FileTypeTTop, FileTypeTop, FileTypeIOStat, FileTypeVMStat, FileTypeDremioProfile, FileTypeThreadDump, FileTypeQueriesJSON, FileTypeDremioConfig, FileTypeArchive, FileTypeUnknown} {
+		assert.True(t, IsKnownFileType(fileType), fileType)
+	}
+	assert.False(t, IsKnownFileType("spreadsheet"))
+	assert.False(t, IsKnownFileType(""))
+}
+
 func TestIsArchive(t *testing.T) {
 	tests := []struct {
 		name     string
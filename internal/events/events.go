@@ -0,0 +1,123 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package events provides a lightweight in-process pub/sub for report status changes
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Event types published for a report
+const (
+	TypeStatus   = "status"   // The report moved to a new status
+	TypeProgress = "progress" // A log line was written while generating the report
+)
+
+// subscriberBuffer is how many events a slow subscriber can fall behind before events are dropped
+const subscriberBuffer = 32
+
+// ReportEvent is a status transition or progress update for a report
+type ReportEvent struct {
+	Type     string    `json:"type"`
+	ReportID int       `json:"report_id"`
+	Status   string    `json:"status,omitempty"`
+	Level    string    `json:"level,omitempty"`
+	Message  string    `json:"message,omitempty"`
+	Time     time.Time `json:"time"`
+}
+
+// IsTerminalStatus reports whether a report status is final
+func IsTerminalStatus(status string) bool {
+	return status == "completed" || status == "failed" || status == "cancelled"
+}
+
+// Broker fans report events out to subscribers keyed by report ID.
+// A nil *Broker is valid and discards everything published to it.
+type Broker struct {
+	mu          sync.Mutex
+	subscribers map[int]map[chan ReportEvent]struct{}
+}
+
+// NewBroker creates an empty broker
+func NewBroker() *Broker {
+	return &Broker{subscribers: make(map[int]map[chan ReportEvent]struct{})}
+}
+
+// Subscribe registers for events about a report. The returned function unsubscribes
+// and closes the channel; it must be called once the subscriber is done.
+func (b *Broker) Subscribe(reportID int) (<-chan ReportEvent, func()) {
+	ch := make(chan ReportEvent, subscriberBuffer)
+
+	b.mu.Lock()
+	if b.subscribers[reportID] == nil {
+		b.subscribers[reportID] = make(map[chan ReportEvent]struct{})
+	}
+	b.subscribers[reportID][ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers[reportID], ch)
+			if len(b.subscribers[reportID]) == 0 {
+				delete(b.subscribers, reportID)
+			}
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// Publish delivers an event to every subscriber of its report without blocking.
+// Subscribers whose buffer is full miss the event.
+func (b *Broker) Publish(event ReportEvent) {
+	if b == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subscribers[event.ReportID] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// PublishStatus publishes a status transition for a report
+func (b *Broker) PublishStatus(reportID int, status, message string) {
+	b.Publish(ReportEvent{Type: TypeStatus, ReportID: reportID, Status: status, Message: message})
+}
+
+// PublishProgress publishes a progress log line for a report
+func (b *Broker) PublishProgress(reportID int, level, message string) {
+	b.Publish(ReportEvent{Type: TypeProgress, ReportID: reportID, Level: level, Message: message})
+}
+
+// SubscriberCount returns the number of subscribers for a report
+func (b *Broker) SubscriberCount(reportID int) int {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subscribers[reportID])
+}
@@ -0,0 +1,93 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBroker(t *testing.T) {
+	t.Run("Delivers events to subscribers of the report", func(t *testing.T) {
+		broker := NewBroker()
+		first, unsubscribeFirst := broker.Subscribe(1)
+		defer unsubscribeFirst()
+		second, unsubscribeSecond := broker.Subscribe(1)
+		defer unsubscribeSecond()
+		other, unsubscribeOther := broker.Subscribe(2)
+		defer unsubscribeOther()
+
+		broker.PublishStatus(1, "running", "")
+		broker.PublishProgress(1, "INFO", "Parsed 3 snapshots")
+
+		for _, ch := range []<-chan ReportEvent{first, second} {
+			event := <-ch
+			assert.Equal(t, TypeStatus, event.Type)
+			assert.Equal(t, 1, event.ReportID)
+			assert.Equal(t, "running", event.Status)
+			assert.False(t, event.Time.IsZero())
+
+			event = <-ch
+			assert.Equal(t, TypeProgress, event.Type)
+			assert.Equal(t, "INFO", event.Level)
+			assert.Equal(t, "Parsed 3 snapshots", event.Message)
+		}
+		assert.Empty(t, other)
+	})
+
+	t.Run("Unsubscribe closes the channel and removes the subscriber", func(t *testing.T) {
+		broker := NewBroker()
+		ch, unsubscribe := broker.Subscribe(1)
+		assert.Equal(t, 1, broker.SubscriberCount(1))
+
+		unsubscribe()
+		unsubscribe() // safe to call twice
+
+		_, ok := <-ch
+		assert.False(t, ok)
+		assert.Equal(t, 0, broker.SubscriberCount(1))
+		broker.PublishStatus(1, "completed", "")
+	})
+
+	t.Run("Slow subscribers do not block publishers", func(t *testing.T) {
+		broker := NewBroker()
+		ch, unsubscribe := broker.Subscribe(1)
+		defer unsubscribe()
+
+		for i := 0; i < subscriberBuffer*2; i++ {
+			broker.PublishProgress(1, "INFO", "line")
+		}
+		assert.Len(t, ch, subscriberBuffer)
+	})
+
+	t.Run("Nil broker discards events", func(t *testing.T) {
+		var broker *Broker
+		require.NotPanics(t, func() {
+			broker.PublishStatus(1, "running", "")
+			broker.PublishProgress(1, "INFO", "line")
+		})
+		assert.Equal(t, 0, broker.SubscriberCount(1))
+	})
+}
+
+func TestIsTerminalStatus(t *testing.T) {
+	assert.True(t, IsTerminalStatus("completed"))
+	assert.True(t, IsTerminalStatus("failed"))
+	assert.True(t, IsTerminalStatus("cancelled"))
+	assert.False(t, IsTerminalStatus("pending"))
+	assert.False(t, IsTerminalStatus("running"))
+}
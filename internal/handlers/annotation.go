@@ -0,0 +1,204 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rsvihladremio/ddd/internal/database"
+)
+
+// maxAnnotationLabelLength keeps annotation labels short enough to sit beside a chart line
+const maxAnnotationLabelLength = 200
+
+// HandleReportAnnotations manages the annotations marked on a report's charts.
+// GET /api/reports/{id}/annotations lists them, POST /api/reports/{id}/annotations with
+// {"timestamp": "2025-03-04T12:05:00Z", "label": "deploy started"} adds one, and
+// PUT or DELETE /api/reports/{id}/annotations/{annotationID} changes or removes one
+func (h *Handlers) HandleReportAnnotations(w http.ResponseWriter, r *http.Request) {
+	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if (len(pathParts) != 4 && len(pathParts) != 5) || pathParts[3] != "annotations" {
+		writeJSONError(w, http.StatusBadRequest, "Invalid URL", ErrCodeBadRequest)
+		return
+	}
+
+	reportID, err := strconv.Atoi(pathParts[2])
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid report ID", ErrCodeBadRequest)
+		return
+	}
+
+	if len(pathParts) == 5 {
+		annotationID, err := strconv.Atoi(pathParts[4])
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "Invalid annotation ID", ErrCodeBadRequest)
+			return
+		}
+		h.handleReportAnnotation(w, r, reportID, annotationID)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		if _, err := h.db.GetReportByID(reportID); err != nil {
+			writeJSONError(w, http.StatusNotFound, "Report not found", ErrCodeNotFound)
+			return
+		}
+
+		annotations, err := h.db.GetReportAnnotations(reportID)
+		if err != nil {
+			log.Printf("Error getting annotations of report %d: %v", reportID, err)
+			writeJSONError(w, http.StatusInternalServerError, "Failed to get annotations", ErrCodeInternal)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":     true,
+			"annotations": annotations,
+		}); err != nil {
+			log.Printf("Error encoding JSON response: %v", err)
+		}
+
+	case http.MethodPost:
+		annotation, ok := decodeReportAnnotation(w, r)
+		if !ok {
+			return
+		}
+		if _, err := h.db.GetReportByID(reportID); err != nil {
+			writeJSONError(w, http.StatusNotFound, "Report not found", ErrCodeNotFound)
+			return
+		}
+
+		annotation.ReportID = reportID
+		if err := h.db.InsertReportAnnotation(annotation); err != nil {
+			log.Printf("Error adding annotation to report %d: %v", reportID, err)
+			writeJSONError(w, http.StatusInternalServerError, "Failed to add annotation", ErrCodeInternal)
+			return
+		}
+		log.Printf("Annotation %d added to report %d", annotation.ID, reportID)
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":    true,
+			"annotation": annotation,
+			"message":    fmt.Sprintf("Annotation added to report %d", reportID),
+		}); err != nil {
+			log.Printf("Error encoding JSON response: %v", err)
+		}
+
+	default:
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed", ErrCodeMethodNotAllowed)
+	}
+}
+
+// handleReportAnnotation changes or removes one annotation of a report
+func (h *Handlers) handleReportAnnotation(w http.ResponseWriter, r *http.Request, reportID, annotationID int) {
+	switch r.Method {
+	case http.MethodPut:
+		annotation, ok := decodeReportAnnotation(w, r)
+		if !ok {
+			return
+		}
+		annotation.ID = annotationID
+		annotation.ReportID = reportID
+		if err := h.db.UpdateReportAnnotation(annotation); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				writeJSONError(w, http.StatusNotFound, fmt.Sprintf("Annotation %d not found on report %d", annotationID, reportID), ErrCodeNotFound)
+				return
+			}
+			log.Printf("Error updating annotation %d of report %d: %v", annotationID, reportID, err)
+			writeJSONError(w, http.StatusInternalServerError, "Failed to update annotation", ErrCodeInternal)
+			return
+		}
+
+		updated, err := h.db.GetReportAnnotation(reportID, annotationID)
+		if err != nil {
+			log.Printf("Error getting annotation %d of report %d: %v", annotationID, reportID, err)
+			writeJSONError(w, http.StatusInternalServerError, "Failed to get annotation", ErrCodeInternal)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":    true,
+			"annotation": updated,
+			"message":    fmt.Sprintf("Annotation %d updated", annotationID),
+		}); err != nil {
+			log.Printf("Error encoding JSON response: %v", err)
+		}
+
+	case http.MethodDelete:
+		if err := h.db.DeleteReportAnnotation(reportID, annotationID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				writeJSONError(w, http.StatusNotFound, fmt.Sprintf("Annotation %d not found on report %d", annotationID, reportID), ErrCodeNotFound)
+				return
+			}
+			log.Printf("Error deleting annotation %d of report %d: %v", annotationID, reportID, err)
+			writeJSONError(w, http.StatusInternalServerError, "Failed to delete annotation", ErrCodeInternal)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"message": fmt.Sprintf("Annotation %d deleted", annotationID),
+		}); err != nil {
+			log.Printf("Error encoding JSON response: %v", err)
+		}
+
+	default:
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed", ErrCodeMethodNotAllowed)
+	}
+}
+
+// decodeReportAnnotation reads and validates the timestamp and label of an annotation from a
+// request body, writing the error response when they aren't valid
+func decodeReportAnnotation(w http.ResponseWriter, r *http.Request) (*database.ReportAnnotation, bool) {
+	var request struct {
+		Timestamp string `json:"timestamp"`
+		Label     string `json:"label"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid JSON", ErrCodeBadRequest)
+		return nil, false
+	}
+
+	timestamp, err := time.Parse(time.RFC3339, request.Timestamp)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "timestamp must be an RFC 3339 time, e.g. 2025-03-04T12:05:00Z", ErrCodeBadRequest)
+		return nil, false
+	}
+
+	label := strings.TrimSpace(request.Label)
+	if label == "" {
+		writeJSONError(w, http.StatusBadRequest, "Annotation label is required", ErrCodeBadRequest)
+		return nil, false
+	}
+	if len(label) > maxAnnotationLabelLength {
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("Annotation label must be at most %d characters", maxAnnotationLabelLength), ErrCodeBadRequest)
+		return nil, false
+	}
+
+	return &database.ReportAnnotation{Timestamp: timestamp, Label: label}, true
+}
@@ -0,0 +1,128 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rsvihladremio/ddd/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlers_ReportAnnotations(t *testing.T) {
+	handler, db := setupTestHandler(t)
+
+	file := &database.File{Hash: "annotation-test-hash", OriginalName: "iostat.txt", FileType: "iostat", FileSize: 100, UploadTime: time.Now(), FilePath: "/uploads/annotation-test-hash"}
+	require.NoError(t, db.InsertFile(file))
+	report := &database.Report{FileID: file.ID, ReportType: "iostat", Status: "completed", CreatedTime: time.Now(), DDDVersion: DDDVersion,
+		ReportData: `{"type":"iostat","html_report":"<html><body><script src=\"/static/js/echarts.min.js\"></script></body></html>"}`}
+	require.NoError(t, db.InsertReport(report))
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.HandleReports(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return w
+	}
+	annotationsURL := fmt.Sprintf("/api/reports/%d/annotations", report.ID)
+	list := func(t *testing.T) []*database.ReportAnnotation {
+		w := serve("GET", annotationsURL, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var response struct {
+			Success     bool                         `json:"success"`
+			Annotations []*database.ReportAnnotation `json:"annotations"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.True(t, response.Success)
+		return response.Annotations
+	}
+
+	var created struct {
+		Annotation database.ReportAnnotation `json:"annotation"`
+	}
+	t.Run("Add and list annotations", func(t *testing.T) {
+		assert.Empty(t, list(t))
+
+		w := serve("POST", annotationsURL, `{"timestamp": "2025-03-04T12:10:00Z", "label": " deploy started "}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		assert.Equal(t, "deploy started", created.Annotation.Label)
+		assert.Equal(t, report.ID, created.Annotation.ReportID)
+
+		w = serve("POST", annotationsURL, `{"timestamp": "2025-03-04T07:05:00-05:00", "label": "incident declared"}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		annotations := list(t)
+		require.Len(t, annotations, 2)
+		assert.Equal(t, "incident declared", annotations[0].Label, "Annotations are listed in time order")
+		assert.True(t, time.Date(2025, 3, 4, 12, 5, 0, 0, time.UTC).Equal(annotations[0].Timestamp))
+	})
+
+	t.Run("Invalid annotations are rejected", func(t *testing.T) {
+		for name, body := range map[string]string{
+			"bad JSON":         `{`,
+			"no timestamp":     `{"label": "deploy"}`,
+			"not RFC 3339":     `{"timestamp": "2025-03-04 12:10:00", "label": "deploy"}`,
+			"empty label":      `{"timestamp": "2025-03-04T12:10:00Z", "label": "  "}`,
+			"too long a label": fmt.Sprintf(`{"timestamp": "2025-03-04T12:10:00Z", "label": "%s"}`, strings.Repeat("x", maxAnnotationLabelLength+1)),
+		} {
+			w := serve("POST", annotationsURL, body)
+			assert.Equal(t, http.StatusBadRequest, w.Code, name)
+		}
+
+		assert.Equal(t, http.StatusNotFound, serve("POST", "/api/reports/999/annotations", `{"timestamp": "2025-03-04T12:10:00Z", "label": "deploy"}`).Code)
+		assert.Equal(t, http.StatusNotFound, serve("GET", "/api/reports/999/annotations", "").Code)
+		assert.Equal(t, http.StatusBadRequest, serve("GET", annotationsURL+"/abc", "").Code)
+		assert.Equal(t, http.StatusMethodNotAllowed, serve("PATCH", annotationsURL, "").Code)
+	})
+
+	t.Run("Update an annotation", func(t *testing.T) {
+		url := fmt.Sprintf("%s/%d", annotationsURL, created.Annotation.ID)
+		w := serve("PUT", url, `{"timestamp": "2025-03-04T12:20:00Z", "label": "deploy finished"}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), `"label":"deploy finished"`)
+
+		annotations := list(t)
+		require.Len(t, annotations, 2)
+		assert.Equal(t, "deploy finished", annotations[1].Label)
+
+		w = serve("PUT", fmt.Sprintf("/api/reports/%d/annotations/%d", report.ID+1, created.Annotation.ID), `{"timestamp": "2025-03-04T12:20:00Z", "label": "elsewhere"}`)
+		assert.Equal(t, http.StatusNotFound, w.Code, "Annotations are only changed through their report")
+	})
+
+	t.Run("Standalone exports carry the annotations", func(t *testing.T) {
+		w := serve("GET", fmt.Sprintf("/api/reports/%d/standalone.html", report.ID), "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), `"label":"deploy finished"`)
+		assert.Contains(t, w.Body.String(), `"label":"incident declared"`)
+	})
+
+	t.Run("Delete an annotation", func(t *testing.T) {
+		url := fmt.Sprintf("%s/%d", annotationsURL, created.Annotation.ID)
+		w := serve("DELETE", url, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, http.StatusNotFound, serve("DELETE", url, "").Code)
+
+		annotations := list(t)
+		require.Len(t, annotations, 1)
+		assert.Equal(t, "incident declared", annotations[0].Label)
+	})
+}
@@ -0,0 +1,264 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/rsvihladremio/ddd/internal/database"
+	"github.com/rsvihladremio/ddd/internal/detector"
+	"github.com/rsvihladremio/ddd/internal/reporters"
+)
+
+// maxBaselineNameLength keeps baseline names short enough to show in the UI
+const maxBaselineNameLength = 100
+
+// HandleBaselines manages the named baselines reports are compared against.
+// GET /api/baselines lists them, POST /api/baselines with {"name": "r5.2xlarge", "report_id": 12}
+// marks a completed iostat report as a baseline and DELETE /api/baselines/{name} removes one
+func (h *Handlers) HandleBaselines(w http.ResponseWriter, r *http.Request) {
+	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(pathParts) == 3 && pathParts[2] != "" {
+		h.handleDeleteBaseline(w, r, pathParts[2])
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		baselines, err := h.db.GetBaselines()
+		if err != nil {
+			log.Printf("Error getting baselines: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "Failed to get baselines", ErrCodeInternal)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":   true,
+			"baselines": baselines,
+		}); err != nil {
+			log.Printf("Error encoding JSON response: %v", err)
+		}
+
+	case http.MethodPost:
+		var request struct {
+			Name     string `json:"name"`
+			ReportID int    `json:"report_id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "Invalid JSON", ErrCodeBadRequest)
+			return
+		}
+
+		name := strings.TrimSpace(request.Name)
+		if name == "" {
+			writeJSONError(w, http.StatusBadRequest, "Baseline name is required", ErrCodeBadRequest)
+			return
+		}
+		if len(name) > maxBaselineNameLength || strings.Contains(name, "/") {
+			writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("Baseline name must be at most %d characters without slashes", maxBaselineNameLength), ErrCodeBadRequest)
+			return
+		}
+
+		report, ok := h.comparableReport(w, request.ReportID)
+		if !ok {
+			return
+		}
+
+		baseline, err := h.db.SaveBaseline(name, report.ID)
+		if err != nil {
+			log.Printf("Error saving baseline %s: %v", name, err)
+			writeJSONError(w, http.StatusInternalServerError, "Failed to save baseline", ErrCodeInternal)
+			return
+		}
+		log.Printf("Report %d saved as baseline %s", report.ID, name)
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":  true,
+			"baseline": baseline,
+			"message":  fmt.Sprintf("Report %d saved as baseline %s", report.ID, name),
+		}); err != nil {
+			log.Printf("Error encoding JSON response: %v", err)
+		}
+
+	default:
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed", ErrCodeMethodNotAllowed)
+	}
+}
+
+// handleDeleteBaseline removes the baseline called name, the report itself is kept
+func (h *Handlers) handleDeleteBaseline(w http.ResponseWriter, r *http.Request, name string) {
+	if r.Method != http.MethodDelete {
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed", ErrCodeMethodNotAllowed)
+		return
+	}
+
+	if err := h.db.DeleteBaseline(name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeJSONError(w, http.StatusNotFound, fmt.Sprintf("Baseline %s not found", name), ErrCodeNotFound)
+			return
+		}
+		log.Printf("Error deleting baseline %s: %v", name, err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to delete baseline", ErrCodeInternal)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": fmt.Sprintf("Baseline %s deleted", name),
+	}); err != nil {
+		log.Printf("Error encoding JSON response: %v", err)
+	}
+}
+
+// HandleCompareToBaseline diffs the per-device p95 %util and await of an iostat report against a
+// named baseline and flags regressions beyond a tolerance, e.g.
+// GET /api/reports/{id}/compare?baseline=r5.2xlarge&tolerance=20. The comparison is rendered as
+// an HTML table unless format=json is given
+func (h *Handlers) HandleCompareToBaseline(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed", ErrCodeMethodNotAllowed)
+		return
+	}
+
+	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(pathParts) != 4 || pathParts[3] != "compare" {
+		writeJSONError(w, http.StatusBadRequest, "Invalid URL", ErrCodeBadRequest)
+		return
+	}
+
+	reportID, err := strconv.Atoi(pathParts[2])
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid report ID", ErrCodeBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	baselineName := query.Get("baseline")
+	if baselineName == "" {
+		writeJSONError(w, http.StatusBadRequest, "Missing baseline parameter", ErrCodeBadRequest)
+		return
+	}
+
+	tolerance := reporters.DefaultIOStatBaselineTolerancePct
+	if value := query.Get("tolerance"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed < 0 {
+			writeJSONError(w, http.StatusBadRequest, "tolerance must be a non-negative percentage", ErrCodeBadRequest)
+			return
+		}
+		tolerance = parsed
+	}
+
+	format := query.Get("format")
+	if format != "" && format != "html" && format != "json" {
+		writeJSONError(w, http.StatusBadRequest, "format must be html or json", ErrCodeBadRequest)
+		return
+	}
+
+	baseline, err := h.db.GetBaseline(baselineName)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeJSONError(w, http.StatusNotFound, fmt.Sprintf("Baseline %s not found", baselineName), ErrCodeNotFound)
+			return
+		}
+		log.Printf("Error getting baseline %s: %v", baselineName, err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to get baseline", ErrCodeInternal)
+		return
+	}
+
+	report, ok := h.comparableReport(w, reportID)
+	if !ok {
+		return
+	}
+	current, err := iostatDeviceSummaries(report)
+	if err != nil {
+		writeJSONError(w, http.StatusConflict, err.Error(), ErrCodeConflict)
+		return
+	}
+
+	baselineReport, err := h.db.GetReportByID(baseline.ReportID)
+	if err != nil {
+		log.Printf("Error getting report %d of baseline %s: %v", baseline.ReportID, baseline.Name, err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to get baseline report", ErrCodeInternal)
+		return
+	}
+	baselineSummaries, err := iostatDeviceSummaries(baselineReport)
+	if err != nil {
+		writeJSONError(w, http.StatusConflict, fmt.Sprintf("Baseline %s: %v", baseline.Name, err), ErrCodeConflict)
+		return
+	}
+
+	comparison := reporters.CompareIOStatToBaseline(baselineSummaries, current, tolerance)
+
+	if format == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":    true,
+			"baseline":   baseline,
+			"report_id":  report.ID,
+			"comparison": comparison,
+		}); err != nil {
+			log.Printf("Error encoding JSON response: %v", err)
+		}
+		return
+	}
+
+	comparisonHTML := reporters.GenerateIOStatBaselineComparisonHTML(comparison,
+		fmt.Sprintf("%s (report %d)", baseline.Name, baseline.ReportID), fmt.Sprintf("Report %d", report.ID))
+	w.Header().Set("Content-Type", "text/html")
+	if _, err := w.Write([]byte(comparisonHTML)); err != nil {
+		log.Printf("Error writing HTML response: %v", err)
+	}
+}
+
+// comparableReport looks up a report that can be saved as or compared to a baseline, writing
+// the error response and returning false when it can't
+func (h *Handlers) comparableReport(w http.ResponseWriter, reportID int) (*database.Report, bool) {
+	report, err := h.db.GetReportByID(reportID)
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, fmt.Sprintf("Report %d not found", reportID), ErrCodeNotFound)
+		return nil, false
+	}
+	if report.ReportType != detector.FileTypeIOStat {
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("Baselines are not supported for report type %s", report.ReportType), ErrCodeBadRequest)
+		return nil, false
+	}
+	if report.Status != "completed" {
+		writeJSONError(w, http.StatusConflict, fmt.Sprintf("Report %d is %s, only completed reports can be compared", reportID, report.Status), ErrCodeConflict)
+		return nil, false
+	}
+	return report, true
+}
+
+// iostatDeviceSummaries reads the per-device percentiles stored in an iostat report
+func iostatDeviceSummaries(report *database.Report) ([]reporters.DeviceSummary, error) {
+	var data struct {
+		DeviceSummaries *[]reporters.DeviceSummary `json:"device_summaries"`
+	}
+	if err := json.Unmarshal([]byte(report.ReportData), &data); err != nil || data.DeviceSummaries == nil {
+		return nil, fmt.Errorf("report %d has no device statistics, generate a new report to compare it", report.ID)
+	}
+	return *data.DeviceSummaries, nil
+}
@@ -0,0 +1,163 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rsvihladremio/ddd/internal/database"
+	"github.com/rsvihladremio/ddd/internal/reporters"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlers_Baselines(t *testing.T) {
+	handler, db := setupTestHandler(t)
+
+	file := &database.File{Hash: "baseline-test-hash", OriginalName: "iostat.txt", FileType: "iostat", FileSize: 100, UploadTime: time.Now(), FilePath: "/uploads/baseline-test-hash"}
+	require.NoError(t, db.InsertFile(file))
+
+	insertIOStatReport := func(t *testing.T, util, await float64) *database.Report {
+		data, err := json.Marshal(map[string]interface{}{
+			"type": "iostat",
+			"device_summaries": []reporters.DeviceSummary{{
+				Device:      "sda",
+				Utilization: reporters.PercentileStats{P95: util},
+				ReadAwait:   reporters.PercentileStats{P95: await},
+				WriteAwait:  reporters.PercentileStats{P95: await},
+			}},
+		})
+		require.NoError(t, err)
+		report := &database.Report{FileID: file.ID, ReportType: "iostat", Status: "completed", CreatedTime: time.Now(), DDDVersion: DDDVersion, ReportData: string(data)}
+		require.NoError(t, db.InsertReport(report))
+		return report
+	}
+	healthy := insertIOStatReport(t, 40, 5)
+	degraded := insertIOStatReport(t, 90, 5)
+
+	saveBaseline := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.HandleBaselines(w, httptest.NewRequest("POST", "/api/baselines", strings.NewReader(body)))
+		return w
+	}
+	compare := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.HandleReports(w, httptest.NewRequest("GET", fmt.Sprintf("/api/reports/%d/compare?%s", degraded.ID, query), nil))
+		return w
+	}
+
+	t.Run("Save and list baselines", func(t *testing.T) {
+		w := saveBaseline(fmt.Sprintf(`{"name": " r5.2xlarge ", "report_id": %d}`, healthy.ID))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), "saved as baseline r5.2xlarge")
+
+		w = httptest.NewRecorder()
+		handler.HandleBaselines(w, httptest.NewRequest("GET", "/api/baselines", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Success   bool                 `json:"success"`
+			Baselines []*database.Baseline `json:"baselines"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Baselines, 1)
+		assert.Equal(t, "r5.2xlarge", response.Baselines[0].Name)
+		assert.Equal(t, healthy.ID, response.Baselines[0].ReportID)
+	})
+
+	t.Run("Invalid baselines", func(t *testing.T) {
+		pending := &database.Report{FileID: file.ID, ReportType: "iostat", Status: "pending", CreatedTime: time.Now(), DDDVersion: DDDVersion}
+		require.NoError(t, db.InsertReport(pending))
+		ttop := &database.Report{FileID: file.ID, ReportType: "ttop", Status: "completed", CreatedTime: time.Now(), DDDVersion: DDDVersion, ReportData: "{}"}
+		require.NoError(t, db.InsertReport(ttop))
+
+		testCases := []struct {
+			name   string
+			body   string
+			status int
+		}{
+			{"Invalid JSON", `nope`, http.StatusBadRequest},
+			{"Missing name", fmt.Sprintf(`{"report_id": %d}`, healthy.ID), http.StatusBadRequest},
+			{"Name with a slash", fmt.Sprintf(`{"name": "a/b", "report_id": %d}`, healthy.ID), http.StatusBadRequest},
+			{"Unknown report", `{"name": "x", "report_id": 99999}`, http.StatusNotFound},
+			{"Report not completed", fmt.Sprintf(`{"name": "x", "report_id": %d}`, pending.ID), http.StatusConflict},
+			{"Not an iostat report", fmt.Sprintf(`{"name": "x", "report_id": %d}`, ttop.ID), http.StatusBadRequest},
+		}
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				assert.Equal(t, tc.status, saveBaseline(tc.body).Code)
+			})
+		}
+	})
+
+	t.Run("Compare to a baseline as HTML", func(t *testing.T) {
+		w := compare("baseline=r5.2xlarge")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "text/html", w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Body.String(), fmt.Sprintf("Report %d compared to baseline r5.2xlarge (report %d)", degraded.ID, healthy.ID))
+		assert.Contains(t, w.Body.String(), `<td class="fail">+125.0%</td>`)
+	})
+
+	t.Run("Compare to a baseline as JSON", func(t *testing.T) {
+		w := compare("baseline=r5.2xlarge&tolerance=200&format=json")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response struct {
+			Success    bool                                `json:"success"`
+			Comparison *reporters.IOStatBaselineComparison `json:"comparison"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.True(t, response.Success)
+		assert.Equal(t, 200.0, response.Comparison.TolerancePct)
+		assert.Equal(t, 0, response.Comparison.Regressions)
+		require.Len(t, response.Comparison.Devices, 1)
+		assert.InDelta(t, 125.0, response.Comparison.Devices[0].Utilization.DeltaPct, 0.001)
+	})
+
+	t.Run("Invalid comparisons", func(t *testing.T) {
+		old := &database.Report{FileID: file.ID, ReportType: "iostat", Status: "completed", CreatedTime: time.Now(), DDDVersion: "0.1.0", ReportData: `{"type":"iostat"}`}
+		require.NoError(t, db.InsertReport(old))
+
+		assert.Equal(t, http.StatusBadRequest, compare("").Code)
+		assert.Equal(t, http.StatusBadRequest, compare("baseline=r5.2xlarge&tolerance=-5").Code)
+		assert.Equal(t, http.StatusBadRequest, compare("baseline=r5.2xlarge&format=xml").Code)
+		assert.Equal(t, http.StatusNotFound, compare("baseline=m5.large").Code)
+
+		w := httptest.NewRecorder()
+		handler.HandleReports(w, httptest.NewRequest("GET", fmt.Sprintf("/api/reports/%d/compare?baseline=r5.2xlarge", old.ID), nil))
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), "has no device statistics")
+	})
+
+	t.Run("Delete a baseline", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.HandleBaselines(w, httptest.NewRequest("DELETE", "/api/baselines/r5.2xlarge", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = httptest.NewRecorder()
+		handler.HandleBaselines(w, httptest.NewRequest("DELETE", "/api/baselines/r5.2xlarge", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = httptest.NewRecorder()
+		handler.HandleBaselines(w, httptest.NewRequest("PUT", "/api/baselines", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
@@ -0,0 +1,66 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/rsvihladremio/ddd/internal/detector"
+	"github.com/rsvihladremio/ddd/internal/reporters"
+)
+
+// fileTypeCapability describes what DDD can do with one detected file type
+type fileTypeCapability struct {
+	FileType     string   `json:"file_type"`
+	AutoGenerate bool     `json:"auto_generate"` // Whether a report is queued on upload
+	ReportTypes  []string `json:"report_types"`  // Reports that can be requested for the file type
+}
+
+// HandleCapabilities lists the file types the detector recognises, which of them get a
+// report on upload, and the report types available for each, as registered with the
+// reporters package
+func (h *Handlers) HandleCapabilities(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed", ErrCodeMethodNotAllowed)
+		return
+	}
+
+	reportTypes := reporters.ReportTypes()
+	fileTypes := make([]fileTypeCapability, 0, len(detector.KnownFileTypes()))
+	for _, fileType := range detector.KnownFileTypes() {
+		capability := fileTypeCapability{
+			FileType:     fileType,
+			AutoGenerate: reporters.ShouldAutoGenerate(fileType),
+			ReportTypes:  make([]string, 0),
+		}
+		for _, reportType := range reportTypes {
+			if reporter, ok := reporters.Lookup(reportType); ok && reporters.AppliesTo(reporter, fileType) {
+				capability.ReportTypes = append(capability.ReportTypes, reportType)
+			}
+		}
+		fileTypes = append(fileTypes, capability)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":      true,
+		"file_types":   fileTypes,
+		"report_types": reportTypes,
+	}); err != nil {
+		log.Printf("Error encoding JSON response: %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlers_HandleCapabilities(t *testing.T) {
+	handler, _ := setupTestHandler(t)
+
+	t.Run("Lists file types and their report types", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/capabilities", nil)
+		w := httptest.NewRecorder()
+		handler.HandleCapabilities(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Success   bool `json:"success"`
+			FileTypes []struct {
+				FileType     string   `json:"file_type"`
+				AutoGenerate bool     `json:"auto_generate"`
+				ReportTypes  []string `json:"report_types"`
+			} `json:"file_types"`
+			ReportTypes []string `json:"report_types"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.True(t, response.Success)
+		assert.Equal(t, []string{"dremio_config", "dremio_profile", "iostat", "jfr", "queries_json", "thread_dump", "top", "ttop", "vmstat"}, response.ReportTypes)
+
+		byType := make(map[string][]string)
+		autoGenerate := make(map[string]bool)
+		for _, capability := range response.FileTypes {
+			byType[capability.FileType] = capability.ReportTypes
+			autoGenerate[capability.FileType] = capability.AutoGenerate
+		}
+		assert.Len(t, byType, 11)
+		assert.Equal(t, []string{"ttop"}, byType["ttop"])
+		assert.Equal(t, []string{"top"}, byType["top"])
+		assert.Equal(t, []string{"iostat"}, byType["iostat"])
+		assert.Equal(t, []string{"vmstat"}, byType["vmstat"])
+		assert.True(t, autoGenerate["ttop"])
+		assert.Empty(t, byType["unknown"])
+		assert.False(t, autoGenerate["unknown"])
+		assert.Empty(t, byType["archive"])
+	})
+
+	t.Run("Method not allowed", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/capabilities", nil)
+		w := httptest.NewRecorder()
+		handler.HandleCapabilities(w, req)
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
@@ -0,0 +1,157 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rsvihladremio/ddd/internal/database"
+)
+
+// orphanedBlobGracePeriod skips blobs written this recently when looking for orphans, since
+// an upload writes its blob just before it inserts the file row
+const orphanedBlobGracePeriod = time.Minute
+
+// missingBlob is a file row whose content is no longer on disk
+type missingBlob struct {
+	*database.File
+	Error string `json:"error"` // Why the content could not be found, e.g. the stat error
+}
+
+// orphanedBlob is a file in the uploads directory that no file row points to
+type orphanedBlob struct {
+	Path         string    `json:"path"`
+	Size         int64     `json:"size"`
+	ModifiedTime time.Time `json:"modified_time"`
+}
+
+// HandleConsistencyCheck reconciles the files table with the uploads directory. GET
+// /api/admin/consistency lists the files that are not deleted but whose content is gone
+// from disk, e.g. after a manual rm or a full disk, so their reports would fail; with
+// orphans=true it also lists blobs in the uploads directory that no file points to. POST
+// runs the same check and repairs it by marking the files with missing content as deleted,
+// which keeps their reports and lets a new upload restore them. Orphaned blobs are only
+// reported, never removed
+func (h *Handlers) HandleConsistencyCheck(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed", ErrCodeMethodNotAllowed)
+		return
+	}
+	repair := r.Method == http.MethodPost
+	includeOrphans := r.URL.Query().Get("orphans") == "true"
+
+	files, err := h.db.GetActiveFiles()
+	if err != nil {
+		log.Printf("Error getting files for consistency check: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to get files", ErrCodeInternal)
+		return
+	}
+
+	missing := make([]missingBlob, 0)
+	referenced := make(map[string]bool, len(files))
+	for _, file := range files {
+		referenced[filepath.Clean(file.FilePath)] = true
+		info, err := os.Stat(file.FilePath)
+		switch {
+		case err != nil:
+			missing = append(missing, missingBlob{File: file, Error: err.Error()})
+		case info.IsDir():
+			missing = append(missing, missingBlob{File: file, Error: fmt.Sprintf("%s is a directory", file.FilePath)})
+		}
+	}
+
+	repaired := 0
+	if repair {
+		for _, blob := range missing {
+			if err := h.db.MarkFileDeleted(blob.ID); err != nil {
+				log.Printf("Error marking file %d with missing content as deleted: %v", blob.ID, err)
+				writeJSONError(w, http.StatusInternalServerError,
+					fmt.Sprintf("Failed to mark file %d as deleted after repairing %d files", blob.ID, repaired), ErrCodeInternal)
+				return
+			}
+			log.Printf("Marked file %d (%s) as deleted, its content %s is missing", blob.ID, blob.OriginalName, blob.FilePath)
+			blob.Deleted = true
+			repaired++
+		}
+	}
+
+	response := map[string]interface{}{
+		"success": true,
+		"checked": len(files),
+		"missing": missing,
+	}
+	message := fmt.Sprintf("Checked %d files, %d are missing their content", len(files), len(missing))
+	if repair {
+		response["repaired"] = repaired
+		message = fmt.Sprintf("Checked %d files, marked %d with missing content as deleted", len(files), repaired)
+	}
+
+	if includeOrphans {
+		orphans, err := h.findOrphanedBlobs(referenced)
+		if err != nil {
+			log.Printf("Error looking for orphaned blobs in %s: %v", h.cfg.UploadsDir, err)
+			writeJSONError(w, http.StatusInternalServerError, "Failed to read the uploads directory", ErrCodeInternal)
+			return
+		}
+		response["orphaned_blobs"] = orphans
+		message += fmt.Sprintf("; %d blobs on disk have no file", len(orphans))
+	}
+	response["message"] = message
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Error encoding JSON response: %v", err)
+	}
+}
+
+// findOrphanedBlobs lists the files in the uploads directory that are not in referenced,
+// skipping directories and blobs an upload in progress may still be recording
+func (h *Handlers) findOrphanedBlobs(referenced map[string]bool) ([]orphanedBlob, error) {
+	orphans := make([]orphanedBlob, 0)
+	entries, err := os.ReadDir(h.cfg.UploadsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return orphans, nil
+		}
+		return nil, err
+	}
+
+	cutoff := time.Now().Add(-orphanedBlobGracePeriod)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(h.cfg.UploadsDir, entry.Name())
+		if referenced[filepath.Clean(path)] {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			// Removed since the directory was read
+			continue
+		}
+		if info.ModTime().After(cutoff) {
+			continue
+		}
+		orphans = append(orphans, orphanedBlob{Path: path, Size: info.Size(), ModifiedTime: info.ModTime()})
+	}
+	return orphans, nil
+}
@@ -0,0 +1,133 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rsvihladremio/ddd/internal/database"
+	"github.com/rsvihladremio/ddd/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlers_HandleConsistencyCheck(t *testing.T) {
+	handler, db := setupTestHandler(t)
+
+	hash, filePath := testutil.CreateSampleFile(t, handler.cfg.UploadsDir, "iostat")
+	present := &database.File{Hash: hash, OriginalName: "iostat.txt", FileType: "iostat", FileSize: 100, UploadTime: time.Now(), FilePath: filePath}
+	require.NoError(t, db.InsertFile(present))
+
+	missingPath := filepath.Join(handler.cfg.UploadsDir, "removed-out-of-band")
+	missing := &database.File{Hash: "removed-out-of-band", OriginalName: "ttop.txt", FileType: "ttop", FileSize: 100, UploadTime: time.Now(), FilePath: missingPath}
+	require.NoError(t, db.InsertFile(missing))
+	report := &database.Report{FileID: missing.ID, ReportType: "ttop", Status: "completed", CreatedTime: time.Now(), DDDVersion: "1.0.0"}
+	require.NoError(t, db.InsertReport(report))
+
+	orphanPath := filepath.Join(handler.cfg.UploadsDir, "orphaned-blob")
+	require.NoError(t, os.WriteFile(orphanPath, []byte("left behind"), 0o644))
+	old := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(orphanPath, old, old))
+	// Just written, so possibly an upload that hasn't recorded its file yet
+	require.NoError(t, os.WriteFile(filepath.Join(handler.cfg.UploadsDir, "in-flight-blob"), []byte("uploading"), 0o644))
+
+	type consistencyResponse struct {
+		Success bool `json:"success"`
+		Checked int  `json:"checked"`
+		Missing []struct {
+			ID       int    `json:"id"`
+			FilePath string `json:"file_path"`
+			Deleted  bool   `json:"deleted"`
+			Error    string `json:"error"`
+		} `json:"missing"`
+		Repaired      *int `json:"repaired"`
+		OrphanedBlobs []struct {
+			Path string `json:"path"`
+			Size int64  `json:"size"`
+		} `json:"orphaned_blobs"`
+		Message string `json:"message"`
+	}
+	check := func(t *testing.T, method, query string) consistencyResponse {
+		w := httptest.NewRecorder()
+		handler.HandleConsistencyCheck(w, httptest.NewRequest(method, "/api/admin/consistency"+query, nil))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response consistencyResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+
+	t.Run("List files whose content is missing", func(t *testing.T) {
+		response := check(t, "GET", "")
+		assert.True(t, response.Success)
+		assert.Equal(t, 2, response.Checked)
+		require.Len(t, response.Missing, 1)
+		assert.Equal(t, missing.ID, response.Missing[0].ID)
+		assert.Equal(t, missingPath, response.Missing[0].FilePath)
+		assert.NotEmpty(t, response.Missing[0].Error)
+		assert.Nil(t, response.Repaired)
+		assert.Nil(t, response.OrphanedBlobs)
+		assert.Equal(t, "Checked 2 files, 1 are missing their content", response.Message)
+
+		// Checking changes nothing
+		stored, err := db.GetFileByID(missing.ID)
+		require.NoError(t, err)
+		assert.False(t, stored.Deleted)
+	})
+
+	t.Run("List blobs no file points to", func(t *testing.T) {
+		response := check(t, "GET", "?orphans=true")
+		require.Len(t, response.OrphanedBlobs, 1)
+		assert.Equal(t, orphanPath, response.OrphanedBlobs[0].Path)
+		assert.Equal(t, int64(len("left behind")), response.OrphanedBlobs[0].Size)
+		assert.Contains(t, response.Message, "1 blobs on disk have no file")
+		assert.FileExists(t, orphanPath)
+	})
+
+	t.Run("Repair marks files with missing content as deleted", func(t *testing.T) {
+		response := check(t, "POST", "")
+		require.NotNil(t, response.Repaired)
+		assert.Equal(t, 1, *response.Repaired)
+		require.Len(t, response.Missing, 1)
+		assert.True(t, response.Missing[0].Deleted)
+
+		stored, err := db.GetFileByID(missing.ID)
+		require.NoError(t, err)
+		assert.True(t, stored.Deleted)
+		stored, err = db.GetFileByID(present.ID)
+		require.NoError(t, err)
+		assert.False(t, stored.Deleted)
+
+		// The reports are kept
+		_, err = db.GetReportByID(report.ID)
+		require.NoError(t, err)
+
+		response = check(t, "GET", "")
+		assert.Equal(t, 1, response.Checked)
+		assert.Empty(t, response.Missing)
+	})
+
+	t.Run("Method not allowed", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.HandleConsistencyCheck(w, httptest.NewRequest("DELETE", "/api/admin/consistency", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
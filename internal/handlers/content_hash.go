@@ -0,0 +1,197 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"slices"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/rsvihladremio/ddd/internal/database"
+)
+
+// Content hash algorithms uploads are deduplicated with. Deduplication doesn't need a
+// cryptographic hash, and xxhash is several times faster than sha256 on multi-GB captures
+const (
+	hashAlgorithmSHA256 = database.DefaultHashAlgorithm
+	hashAlgorithmXXHash = "xxhash"
+)
+
+// contentHashAlgorithm creates hashes of one algorithm and matches the hex digests it produces
+type contentHashAlgorithm struct {
+	label   string // Name shown in messages
+	newHash func() hash.Hash
+	pattern *regexp.Regexp
+}
+
+// contentHashAlgorithms are the supported algorithms by name. Their digests differ in length,
+// so a stored hash never matches content hashed with another algorithm
+var contentHashAlgorithms = map[string]contentHashAlgorithm{
+	hashAlgorithmSHA256: {label: "SHA-256", newHash: sha256.New, pattern: regexp.MustCompile(`^[0-9a-f]{64}$`)},
+	hashAlgorithmXXHash: {label: "xxHash64", newHash: func() hash.Hash { return xxhash.New() }, pattern: regexp.MustCompile(`^[0-9a-f]{16}$`)},
+}
+
+// contentHashAlgorithmNames returns the names of the supported algorithms, sorted
+func contentHashAlgorithmNames() []string {
+	names := make([]string, 0, len(contentHashAlgorithms))
+	for name := range contentHashAlgorithms {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// computeContentHash returns the hex digest of content with the named algorithm
+func computeContentHash(algorithm string, content []byte) (string, error) {
+	hashAlgorithm, ok := contentHashAlgorithms[algorithm]
+	if !ok {
+		return "", fmt.Errorf("unsupported hash algorithm %q", algorithm)
+	}
+	hasher := hashAlgorithm.newHash()
+	hasher.Write(content)
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// getHashAlgorithm returns the algorithm new uploads are hashed with
+func (h *Handlers) getHashAlgorithm() string {
+	if value := h.settings.HashAlgorithm(); value != "" {
+		if _, ok := contentHashAlgorithms[value]; ok {
+			return value
+		}
+	}
+	return hashAlgorithmSHA256
+}
+
+// errContentHashCollision is returned by findStoredContent when different content is stored
+// under the same digest of a non-cryptographic hash
+var errContentHashCollision = errors.New("different content is stored under the same hash")
+
+// findStoredContent returns the file content is already stored as. contentHash is the digest
+// of content with algorithm; files hashed with another algorithm before the hash_algorithm
+// setting changed are found by hashing content again with each algorithm used by files of
+// the same size. It returns sql.ErrNoRows when the content isn't stored, and
+// errContentHashCollision when other content is stored under contentHash
+func (h *Handlers) findStoredContent(content []byte, contentHash, algorithm string) (*database.File, error) {
+	file, err := h.db.GetFileByHash(contentHash)
+	if err == nil {
+		same, err := isStoredContent(file, content)
+		if err != nil {
+			return nil, err
+		}
+		if !same {
+			return nil, errContentHashCollision
+		}
+		return file, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	algorithms, err := h.db.GetHashAlgorithms(int64(len(content)))
+	if err != nil {
+		return nil, err
+	}
+	for _, other := range algorithms {
+		if other == algorithm {
+			continue
+		}
+		otherHash, err := computeContentHash(other, content)
+		if err != nil {
+			log.Printf("Skipping files hashed with %s: %v", other, err)
+			continue
+		}
+		file, err := h.db.GetFileByHash(otherHash)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if same, err := isStoredContent(file, content); err != nil || same {
+			return file, err
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+// lookupContent finds the file content is stored as like findStoredContent, returning the
+// hash and algorithm content is stored under. When other content already has its
+// contentHash, content is looked up and stored under its sha256 digest instead
+func (h *Handlers) lookupContent(content []byte, contentHash, algorithm string) (*database.File, string, string, error) {
+	file, err := h.findStoredContent(content, contentHash, algorithm)
+	if !errors.Is(err, errContentHashCollision) {
+		return file, contentHash, algorithm, err
+	}
+
+	log.Printf("Content with %s hash %s differs from the file stored under it, using %s instead", algorithm, contentHash, hashAlgorithmSHA256)
+	sha256Hash, err := computeContentHash(hashAlgorithmSHA256, content)
+	if err != nil {
+		return nil, "", "", err
+	}
+	file, err = h.findStoredContent(content, sha256Hash, hashAlgorithmSHA256)
+	return file, sha256Hash, hashAlgorithmSHA256, err
+}
+
+// isStoredContent reports whether file holds content. A sha256 digest match is trusted; a
+// match on a non-cryptographic digest is checked against the stored bytes. Such digests can
+// be forged, so when the file is no longer on disk it is treated as different content
+func isStoredContent(file *database.File, content []byte) (bool, error) {
+	if file.HashAlgorithm == hashAlgorithmSHA256 {
+		return true, nil
+	}
+	if file.FileSize != int64(len(content)) || file.Deleted {
+		return false, nil
+	}
+
+	stored, err := os.Open(file.FilePath)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if err := stored.Close(); err != nil {
+			log.Printf("Error closing %s: %v", file.FilePath, err)
+		}
+	}()
+
+	// Compare in chunks rather than reading a multi-GB capture into memory again
+	buf := make([]byte, 1<<20)
+	remaining := content
+	for {
+		n, err := io.ReadFull(stored, buf)
+		if n > len(remaining) || !bytes.Equal(buf[:n], remaining[:n]) {
+			return false, nil
+		}
+		remaining = remaining[n:]
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return len(remaining) == 0, nil
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+}
@@ -0,0 +1,190 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rsvihladremio/ddd/internal/database"
+	"github.com/rsvihladremio/ddd/internal/detector"
+	"github.com/rsvihladremio/ddd/internal/reporters"
+)
+
+// HandleCorrelate lines up an iostat and a ttop (or plain top) capture from the same node,
+// e.g. GET /api/reports/correlate?iostat=12&ttop=13, and renders %iowait against thread
+// activity on one page. Both parameters are report IDs; the captures are parsed again from
+// disk since reports only store their rendered output. max_skew (a duration such as 10s)
+// bounds how far apart paired snapshots may be, iowait sets the %iowait that counts as high,
+// and format=json returns the aligned samples instead of HTML
+func (h *Handlers) HandleCorrelate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed", ErrCodeMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	iostatID, err := strconv.Atoi(query.Get("iostat"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "iostat must be a report ID", ErrCodeBadRequest)
+		return
+	}
+	ttopID, err := strconv.Atoi(query.Get("ttop"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "ttop must be a report ID", ErrCodeBadRequest)
+		return
+	}
+
+	maxSkew := reporters.DefaultCorrelationMaxSkew
+	if value := query.Get("max_skew"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			writeJSONError(w, http.StatusBadRequest, "max_skew must be a positive duration such as 10s", ErrCodeBadRequest)
+			return
+		}
+		maxSkew = parsed
+	}
+
+	iowaitThreshold := reporters.DefaultCorrelationIOWaitPct
+	if value := query.Get("iowait"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed < 0 || parsed > 100 {
+			writeJSONError(w, http.StatusBadRequest, "iowait must be a percentage between 0 and 100", ErrCodeBadRequest)
+			return
+		}
+		iowaitThreshold = parsed
+	}
+
+	format := query.Get("format")
+	if format != "" && format != "html" && format != "json" {
+		writeJSONError(w, http.StatusBadRequest, "format must be html or json", ErrCodeBadRequest)
+		return
+	}
+
+	// Validate both reports before doing any parsing work
+	iostatReport, iostatFile, ok := h.correlatableReport(w, iostatID, detector.FileTypeIOStat)
+	if !ok {
+		return
+	}
+	ttopReport, ttopFile, ok := h.correlatableReport(w, ttopID, detector.FileTypeTTop, detector.FileTypeTop)
+	if !ok {
+		return
+	}
+
+	loc, err := h.settings.Location()
+	if err != nil {
+		log.Printf("Error loading timezone, using UTC: %v", err)
+		loc = time.UTC
+	}
+
+	iostatData, err := reporters.ParseIOStatFile(iostatFile.FilePath, loc)
+	if err != nil {
+		log.Printf("Error parsing file %d for correlation: %v", iostatFile.ID, err)
+		writeJSONError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to parse file for report %d", iostatReport.ID), ErrCodeInternal)
+		return
+	}
+	ttopData, err := reporters.ParseTTopFile(ttopFile.FilePath, loc)
+	if err != nil {
+		log.Printf("Error parsing file %d for correlation: %v", ttopFile.ID, err)
+		writeJSONError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to parse file for report %d", ttopReport.ID), ErrCodeInternal)
+		return
+	}
+
+	correlation := reporters.CorrelateIOStatTTop(iostatData, ttopData, maxSkew, iowaitThreshold)
+	if len(correlation.Points) == 0 {
+		writeJSONError(w, http.StatusConflict,
+			fmt.Sprintf("No snapshots of reports %d and %d are within %s of each other; correlate captures from the same time window",
+				iostatReport.ID, ttopReport.ID, maxSkew), ErrCodeConflict)
+		return
+	}
+
+	if format == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":          true,
+			"iostat_report_id": iostatReport.ID,
+			"ttop_report_id":   ttopReport.ID,
+			"correlation":      correlation,
+		}); err != nil {
+			log.Printf("Error encoding JSON response: %v", err)
+		}
+		return
+	}
+
+	correlationHTML, err := reporters.GenerateIOStatTTopCorrelationHTML(correlation,
+		fmt.Sprintf("%s (report %d)", iostatFile.OriginalName, iostatReport.ID),
+		fmt.Sprintf("%s (report %d)", ttopFile.OriginalName, ttopReport.ID))
+	if err != nil {
+		log.Printf("Error generating correlation report: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to generate correlation report", ErrCodeInternal)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	if _, err := w.Write([]byte(correlationHTML)); err != nil {
+		log.Printf("Error writing HTML response: %v", err)
+	}
+}
+
+// correlatableReport looks up a completed report of one of reportTypes whose file is still on
+// disk, writing the error response and returning false when it can't be correlated
+func (h *Handlers) correlatableReport(w http.ResponseWriter, reportID int, reportTypes ...string) (*database.Report, *database.File, bool) {
+	report, err := h.db.GetReportByID(reportID)
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, fmt.Sprintf("Report %d not found", reportID), ErrCodeNotFound)
+		return nil, nil, false
+	}
+
+	supported := false
+	for _, reportType := range reportTypes {
+		if report.ReportType == reportType {
+			supported = true
+			break
+		}
+	}
+	if !supported {
+		writeJSONError(w, http.StatusBadRequest,
+			fmt.Sprintf("Report %d is a %s report, expected %s", reportID, report.ReportType, joinOr(reportTypes)), ErrCodeBadRequest)
+		return nil, nil, false
+	}
+	if report.Status != "completed" {
+		writeJSONError(w, http.StatusConflict, fmt.Sprintf("Report %d is %s, only completed reports can be correlated", reportID, report.Status), ErrCodeConflict)
+		return nil, nil, false
+	}
+
+	file, err := h.db.GetFileByID(report.FileID)
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, fmt.Sprintf("File for report %d not found", reportID), ErrCodeNotFound)
+		return nil, nil, false
+	}
+	if file.Deleted {
+		writeJSONError(w, http.StatusGone, fmt.Sprintf("File for report %d has been deleted", reportID), ErrCodeGone)
+		return nil, nil, false
+	}
+	return report, file, true
+}
+
+// joinOr lists values as "a", "a or b" or "a, b or c"
+func joinOr(values []string) string {
+	if len(values) <= 1 {
+		return strings.Join(values, "")
+	}
+	return strings.Join(values[:len(values)-1], ", ") + " or " + values[len(values)-1]
+}
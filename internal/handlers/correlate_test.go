@@ -0,0 +1,149 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rsvihladremio/ddd/internal/database"
+	"github.com/rsvihladremio/ddd/internal/reporters"
+	"github.com/rsvihladremio/ddd/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlers_HandleCorrelate(t *testing.T) {
+	handler, db := setupTestHandler(t)
+
+	createReport := func(t *testing.T, name, reportType, status string, content []byte) *database.Report {
+		hash, filePath := testutil.CreateTestFile(t, handler.cfg.UploadsDir, testutil.TestFile{Name: name, Content: content})
+		file := &database.File{
+			Hash:         hash,
+			OriginalName: name,
+			FileType:     reportType,
+			FileSize:     int64(len(content)),
+			UploadTime:   time.Now(),
+			FilePath:     filePath,
+		}
+		require.NoError(t, db.InsertFile(file))
+
+		report := &database.Report{FileID: file.ID, ReportType: reportType, Status: status, CreatedTime: time.Now(), DDDVersion: "1.0.0"}
+		require.NoError(t, db.InsertReport(report))
+		return report
+	}
+
+	// The top sample retimed to the same minute as the iostat sample's two snapshots
+	topSample := string(testutil.SampleFiles["top"].Content)
+	overlapping := strings.Replace(topSample, "top - 12:02:03", "top - 12:07:20", 1) +
+		strings.Replace(topSample, "top - 12:02:03", "top - 12:07:21", 1)
+
+	iostat := createReport(t, "node1-iostat.txt", "iostat", "completed", testutil.SampleFiles["iostat"].Content)
+	top := createReport(t, "node1-top.txt", "top", "completed", []byte(overlapping))
+	laterTop := createReport(t, "node1-top-later.txt", "top", "completed", testutil.SampleFiles["top"].Content)
+	pending := createReport(t, "node2-iostat.txt", "iostat", "pending", []byte(testutil.SampleFiles["iostat"].Content[1:]))
+
+	correlate := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/reports/correlate?"+query, nil)
+		w := httptest.NewRecorder()
+		handler.HandleCorrelate(w, req)
+		return w
+	}
+
+	t.Run("Render the combined page", func(t *testing.T) {
+		w := correlate(fmt.Sprintf("iostat=%d&ttop=%d", iostat.ID, top.ID))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "text/html", w.Header().Get("Content-Type"))
+		body := w.Body.String()
+		assert.Contains(t, body, "I/O Wait and Process Correlation")
+		assert.Contains(t, body, fmt.Sprintf("node1-iostat.txt (report %d)", iostat.ID))
+		assert.Contains(t, body, fmt.Sprintf("node1-top.txt (report %d)", top.ID))
+		assert.Contains(t, body, "2 of 2 iostat and 2 ttop snapshots")
+	})
+
+	t.Run("Return the aligned samples as JSON", func(t *testing.T) {
+		w := correlate(fmt.Sprintf("iostat=%d&ttop=%d&format=json&iowait=2", iostat.ID, top.ID))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response struct {
+			Success     bool                            `json:"success"`
+			Correlation reporters.IOStatTTopCorrelation `json:"correlation"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.True(t, response.Success)
+		require.Len(t, response.Correlation.Points, 2)
+		assert.True(t, response.Correlation.Processes)
+		assert.Equal(t, 2.72, response.Correlation.Points[1].IOWait)
+		assert.Equal(t, 1, response.Correlation.Points[1].RunningThreads)
+		assert.Equal(t, "java", response.Correlation.Points[1].BusiestThreads[0].Command)
+		assert.Equal(t, 1, response.Correlation.HighIOWait)
+	})
+
+	t.Run("Reject captures that don't overlap", func(t *testing.T) {
+		w := correlate(fmt.Sprintf("iostat=%d&ttop=%d", iostat.ID, laterTop.ID))
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), "same time window")
+
+		// A generous enough skew pairs them anyway
+		w = correlate(fmt.Sprintf("iostat=%d&ttop=%d&max_skew=10m", iostat.ID, laterTop.ID))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Reject reports of the wrong type", func(t *testing.T) {
+		w := correlate(fmt.Sprintf("iostat=%d&ttop=%d", top.ID, iostat.ID))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "expected iostat")
+
+		w = correlate(fmt.Sprintf("iostat=%d&ttop=%d", iostat.ID, iostat.ID))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "expected ttop or top")
+	})
+
+	t.Run("Reject reports that are not completed", func(t *testing.T) {
+		w := correlate(fmt.Sprintf("iostat=%d&ttop=%d", pending.ID, top.ID))
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Unknown report", func(t *testing.T) {
+		w := correlate(fmt.Sprintf("iostat=99999&ttop=%d", top.ID))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Reject invalid parameters", func(t *testing.T) {
+		for _, query := range []string{
+			fmt.Sprintf("ttop=%d", top.ID),
+			fmt.Sprintf("iostat=%d", iostat.ID),
+			fmt.Sprintf("iostat=%d&ttop=%d&max_skew=soon", iostat.ID, top.ID),
+			fmt.Sprintf("iostat=%d&ttop=%d&max_skew=-1s", iostat.ID, top.ID),
+			fmt.Sprintf("iostat=%d&ttop=%d&iowait=101", iostat.ID, top.ID),
+			fmt.Sprintf("iostat=%d&ttop=%d&format=csv", iostat.ID, top.ID),
+		} {
+			w := correlate(query)
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		}
+	})
+
+	t.Run("Method not allowed", func(t *testing.T) {
+		req := httptest.NewRequest("POST", fmt.Sprintf("/api/reports/correlate?iostat=%d&ttop=%d", iostat.ID, top.ID), nil)
+		w := httptest.NewRecorder()
+		handler.HandleCorrelate(w, req)
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
@@ -0,0 +1,120 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCORS(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"success":true}`))
+	})
+
+	serve := func(handler http.Handler, method, path, origin string, preflight bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		if preflight {
+			req.Header.Set("Access-Control-Request-Method", "POST")
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	dashboard := "https://dashboard.example.com"
+	handler := CORS([]string{dashboard, "http://localhost:3000/"}, next)
+
+	t.Run("No origins configured leaves responses unchanged", func(t *testing.T) {
+		w := serve(CORS(nil, next), "GET", "/api/files", dashboard, false)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Empty(t, w.Header().Get("Vary"))
+	})
+
+	t.Run("Allowed origin", func(t *testing.T) {
+		w := serve(handler, "GET", "/api/files", dashboard, false)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, dashboard, w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "Origin", w.Header().Get("Vary"))
+		assert.Contains(t, w.Header().Get("Access-Control-Expose-Headers"), "Content-Disposition")
+		assert.Equal(t, `{"success":true}`, w.Body.String())
+	})
+
+	t.Run("Configured origins ignore a trailing slash", func(t *testing.T) {
+		w := serve(handler, "GET", "/api/files", "http://localhost:3000", false)
+
+		assert.Equal(t, "http://localhost:3000", w.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("Preflight from an allowed origin", func(t *testing.T) {
+		w := serve(handler, "OPTIONS", "/api/reports/1", dashboard, true)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, dashboard, w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, corsAllowMethods, w.Header().Get("Access-Control-Allow-Methods"))
+		assert.Equal(t, corsAllowHeaders, w.Header().Get("Access-Control-Allow-Headers"))
+		assert.Equal(t, corsMaxAge, w.Header().Get("Access-Control-Max-Age"))
+		assert.Empty(t, w.Body.String())
+	})
+
+	t.Run("Preflight from another origin is rejected", func(t *testing.T) {
+		w := serve(handler, "OPTIONS", "/api/reports/1", "https://evil.example.com", true)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, ErrCodeForbidden, response["error"].(map[string]interface{})["code"])
+	})
+
+	t.Run("Simple request from another origin gets no CORS headers", func(t *testing.T) {
+		w := serve(handler, "GET", "/api/files", "https://evil.example.com", false)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("Wildcard allows any origin", func(t *testing.T) {
+		w := serve(CORS([]string{"*"}, next), "GET", "/api/files", "https://anything.example.com", false)
+
+		assert.Equal(t, "https://anything.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("Non-API paths get no CORS headers", func(t *testing.T) {
+		w := serve(handler, "GET", "/static/js/app.js", dashboard, false)
+
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("Same-origin requests without an Origin header pass through", func(t *testing.T) {
+		w := serve(handler, "GET", "/api/files", "", false)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	})
+}
@@ -0,0 +1,157 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGzip(t *testing.T) {
+	largeJSON := `{"html_report":"` + strings.Repeat("<div>chart</div>", 200) + `"}`
+
+	serve := func(handler http.HandlerFunc, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/reports/content/1", nil)
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		w := httptest.NewRecorder()
+		Gzip(handler).ServeHTTP(w, req)
+		return w
+	}
+	acceptGzip := http.Header{"Accept-Encoding": []string{"gzip, deflate, br"}}
+
+	jsonHandler := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = io.WriteString(w, body)
+		}
+	}
+
+	t.Run("Compresses large JSON", func(t *testing.T) {
+		w := serve(jsonHandler(largeJSON), acceptGzip)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+		assert.Less(t, w.Body.Len(), len(largeJSON))
+
+		reader, err := gzip.NewReader(w.Body)
+		require.NoError(t, err)
+		decoded, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, largeJSON, string(decoded))
+	})
+
+	t.Run("Compresses across many small writes and sniffs content type", func(t *testing.T) {
+		page := "<!DOCTYPE html><html><body>" + strings.Repeat("<p>row</p>", 300) + "</body></html>"
+		w := serve(func(w http.ResponseWriter, r *http.Request) {
+			for i := 0; i < len(page); i += 100 {
+				_, _ = io.WriteString(w, page[i:min(i+100, len(page))])
+			}
+		}, acceptGzip)
+
+		assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+
+		reader, err := gzip.NewReader(w.Body)
+		require.NoError(t, err)
+		decoded, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, page, string(decoded))
+	})
+
+	t.Run("Client without gzip support", func(t *testing.T) {
+		w := serve(jsonHandler(largeJSON), nil)
+
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, largeJSON, w.Body.String())
+	})
+
+	t.Run("Client refusing gzip", func(t *testing.T) {
+		w := serve(jsonHandler(largeJSON), http.Header{"Accept-Encoding": []string{"gzip;q=0, identity"}})
+
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, largeJSON, w.Body.String())
+	})
+
+	t.Run("Small responses are not compressed", func(t *testing.T) {
+		w := serve(jsonHandler(`{"success":true}`), acceptGzip)
+
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, `{"success":true}`, w.Body.String())
+	})
+
+	t.Run("Error status is preserved", func(t *testing.T) {
+		w := serve(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, strings.Repeat("not found ", 200), http.StatusNotFound)
+		}, acceptGzip)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+	})
+
+	t.Run("Downloads are not compressed", func(t *testing.T) {
+		body := strings.Repeat("a", 4096)
+		w := serve(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Header().Set("Content-Disposition", `attachment; filename="data.txt"`)
+			_, _ = io.WriteString(w, body)
+		}, acceptGzip)
+
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, body, w.Body.String())
+	})
+
+	t.Run("Already encoded responses pass through", func(t *testing.T) {
+		w := serve(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/plain")
+			w.Header().Set("Content-Encoding", "gzip")
+			_, _ = io.WriteString(w, largeJSON)
+		}, acceptGzip)
+
+		assert.Equal(t, largeJSON, w.Body.String())
+	})
+
+	t.Run("Range requests pass through", func(t *testing.T) {
+		w := serve(jsonHandler(largeJSON), http.Header{
+			"Accept-Encoding": []string{"gzip"},
+			"Range":           []string{"bytes=0-10"},
+		})
+
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+	})
+
+	t.Run("Event streams are flushed uncompressed", func(t *testing.T) {
+		w := serve(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = io.WriteString(w, "event: status\ndata: {}\n\n")
+			require.NoError(t, http.NewResponseController(w).Flush())
+			assert.Equal(t, "event: status\ndata: {}\n\n", w.(*gzipResponseWriter).ResponseWriter.(*httptest.ResponseRecorder).Body.String())
+			_, _ = io.WriteString(w, "event: done\ndata: {}\n\n")
+		}, acceptGzip)
+
+		assert.True(t, w.Flushed)
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "event: status\ndata: {}\n\nevent: done\ndata: {}\n\n", w.Body.String())
+	})
+}
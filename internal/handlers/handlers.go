@@ -15,15 +15,27 @@
 package handlers
 
 import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/rand"
 	"crypto/sha256"
+	"database/sql"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"html"
+	"html/template"
 	"io"
+	"io/fs"
 	"log"
+	"mime/multipart"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"syscall"
@@ -32,13 +44,52 @@ import (
 	"github.com/rsvihladremio/ddd/internal/config"
 	"github.com/rsvihladremio/ddd/internal/database"
 	"github.com/rsvihladremio/ddd/internal/detector"
+	"github.com/rsvihladremio/ddd/internal/events"
+	"github.com/rsvihladremio/ddd/internal/metrics"
+	"github.com/rsvihladremio/ddd/internal/reporters"
+	"github.com/rsvihladremio/ddd/internal/settings"
+	"github.com/rsvihladremio/ddd/web"
 )
 
 const DDDVersion = "1.0.0"
 
+// isStaleVersion reports whether a report generated by version predates DDDVersion, in
+// which case regenerating it would pick up reporter improvements
+func isStaleVersion(version string) bool {
+	return compareVersions(version, DDDVersion) < 0
+}
+
+// compareVersions compares dotted numeric versions such as "1.2.0", returning -1, 0 or 1.
+// Missing or non-numeric parts count as 0, so "" sorts before every release
+func compareVersions(a, b string) int {
+	aParts := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	bParts := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		var aNum, bNum int
+		if i < len(aParts) {
+			aNum, _ = strconv.Atoi(aParts[i])
+		}
+		if i < len(bParts) {
+			bNum, _ = strconv.Atoi(bParts[i])
+		}
+		if aNum != bNum {
+			return cmp.Compare(aNum, bNum)
+		}
+	}
+	return 0
+}
+
 // CleanupWorker interface to avoid circular imports
 type CleanupWorker interface {
 	TriggerCleanup()
+	ResetInterval()
+}
+
+// ReportWorker interface to stop running reports and reschedule polling without importing
+// the report worker
+type ReportWorker interface {
+	CancelReport(reportID int) bool
+	ResetInterval()
 }
 
 // Handlers contains the HTTP handlers
@@ -46,44 +97,165 @@ type Handlers struct {
 	db            *database.DB
 	cfg           *config.Config
 	cleanupWorker CleanupWorker
+	reportWorker  ReportWorker
+	events        *events.Broker
+	settings      *settings.Settings
+	variants      *reportVariants
+	assets        fs.FS
 }
 
-// New creates a new Handlers instance
-func New(db *database.DB, cfg *config.Config, cleanupWorker CleanupWorker) *Handlers {
+// New creates a new Handlers instance. reportWorker stops running reports and may be nil,
+// in which case only pending reports can be cancelled. broker carries live report
+// updates and may be nil, in which case the report events endpoint is unavailable. Web
+// assets come from cfg.WebDir when set, otherwise from the copy embedded in the binary.
+func New(db *database.DB, cfg *config.Config, cleanupWorker CleanupWorker, reportWorker ReportWorker, broker *events.Broker) *Handlers {
 	return &Handlers{
 		db:            db,
 		cfg:           cfg,
 		cleanupWorker: cleanupWorker,
+		reportWorker:  reportWorker,
+		events:        broker,
+		settings:      settings.New(db, cfg),
+		variants:      newReportVariants(),
+		assets:        web.Assets(cfg.WebDir),
 	}
 }
 
-// getMaxDiskUsage retrieves max disk usage setting from database
-func (h *Handlers) getMaxDiskUsage() (float64, error) {
-	value, err := h.db.GetSetting("max_disk_usage")
-	if err != nil {
-		// Fall back to config if setting not found
-		return h.cfg.MaxDiskUsage, nil
+// checkAllowedFileType refuses content detected as a type missing from a non-empty
+// allowed_file_types setting with 415 Unsupported Media Type
+func (h *Handlers) checkAllowedFileType(fileType string) *uploadError {
+	allowed := h.settings.AllowedFileTypes()
+	if len(allowed) == 0 || slices.Contains(allowed, fileType) {
+		return nil
 	}
-	return strconv.ParseFloat(value, 64)
-}
-
-// getFileRetentionDays retrieves file retention days setting from database
-func (h *Handlers) getFileRetentionDays() (int, error) {
-	value, err := h.db.GetSetting("file_retention_days")
-	if err != nil {
-		// Fall back to config if setting not found
-		return h.cfg.FileRetentionDays, nil
+	return &uploadError{
+		http.StatusUnsupportedMediaType,
+		fmt.Sprintf("Uploads of %s files are not accepted; allowed file types: %s", fileType, strings.Join(allowed, ", ")),
+		ErrCodeUnsupportedType,
 	}
-	return strconv.Atoi(value)
 }
 
 // HandleIndex serves the main page
 func (h *Handlers) HandleIndex(w http.ResponseWriter, r *http.Request) {
 	if r.URL.Path != "/" {
-		http.NotFound(w, r)
+		h.notFound(w, r)
+		return
+	}
+	http.ServeFileFS(w, r, h.assets, "index.html")
+}
+
+// HandleStatic serves the static assets under /static/
+func (h *Handlers) HandleStatic() http.Handler {
+	// Request paths already match the layout of the assets, e.g. /static/js/app.js
+	return http.FileServerFS(h.assets)
+}
+
+// HandleFavicon serves the embedded favicon so browsers stop logging failed requests for it
+func (h *Handlers) HandleFavicon(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	http.ServeFileFS(w, r, h.assets, "static/favicon.ico")
+}
+
+// Error codes returned in API error bodies so clients can branch without parsing messages
+const (
+	ErrCodeBadRequest       = "bad_request"
+	ErrCodeForbidden        = "forbidden"
+	ErrCodeNotFound         = "not_found"
+	ErrCodeMethodNotAllowed = "method_not_allowed"
+	ErrCodeGone             = "gone"
+	ErrCodeConflict         = "conflict"
+	ErrCodeInternal         = "internal_error"
+	ErrCodeUnavailable      = "unavailable"
+	ErrCodeUnsupportedType  = "unsupported_file_type"
+)
+
+// writeJSONError writes an API error as {"success":false,"error":{"message":...,"code":...}}
+func writeJSONError(w http.ResponseWriter, status int, message, code string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": false,
+		"error": map[string]string{
+			"message": message,
+			"code":    code,
+		},
+	}); err != nil {
+		log.Printf("Error encoding JSON response: %v", err)
+	}
+}
+
+// statusErrorCode returns the generic error code for an HTTP status
+func statusErrorCode(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return ErrCodeBadRequest
+	case http.StatusForbidden:
+		return ErrCodeForbidden
+	case http.StatusNotFound:
+		return ErrCodeNotFound
+	case http.StatusMethodNotAllowed:
+		return ErrCodeMethodNotAllowed
+	case http.StatusGone:
+		return ErrCodeGone
+	case http.StatusConflict:
+		return ErrCodeConflict
+	case http.StatusServiceUnavailable:
+		return ErrCodeUnavailable
+	default:
+		return ErrCodeInternal
+	}
+}
+
+// errorPageData is passed to the error page template
+type errorPageData struct {
+	Status  int
+	Title   string
+	Message string
+}
+
+// writeError responds with an error status. API paths get a JSON body so /api/* never
+// returns HTML; pages get the friendly error page.
+func (h *Handlers) writeError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	if isAPIRequest(r) {
+		writeJSONError(w, status, message, statusErrorCode(status))
+		return
+	}
+
+	tmpl, err := template.ParseFS(h.assets, "templates/error.html")
+	if err != nil {
+		log.Printf("Error loading error page template: %v", err)
+		http.Error(w, message, status)
+		return
+	}
+
+	var page bytes.Buffer
+	data := errorPageData{Status: status, Title: http.StatusText(status), Message: message}
+	if err := tmpl.Execute(&page, data); err != nil {
+		log.Printf("Error rendering error page: %v", err)
+		http.Error(w, message, status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if _, err := page.WriteTo(w); err != nil {
+		log.Printf("Error writing HTML response: %v", err)
+	}
+}
+
+// notFound responds with a 404 in the form the path expects
+func (h *Handlers) notFound(w http.ResponseWriter, r *http.Request) {
+	if isAPIRequest(r) {
+		h.writeError(w, r, http.StatusNotFound, "Not found")
 		return
 	}
-	http.ServeFile(w, r, "./web/index.html")
+	h.writeError(w, r, http.StatusNotFound, "The page you are looking for does not exist.")
+}
+
+// isAPIRequest reports whether a request is for the JSON API rather than a page
+func isAPIRequest(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.Path, "/api/")
 }
 
 // HandleReportPage serves the report viewer page
@@ -91,153 +263,372 @@ func (h *Handlers) HandleReportPage(w http.ResponseWriter, r *http.Request) {
 	// Extract report ID from URL path
 	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
 	if len(pathParts) < 2 {
-		http.NotFound(w, r)
+		h.notFound(w, r)
 		return
 	}
 
 	reportIDStr := pathParts[1]
 	reportID, err := strconv.Atoi(reportIDStr)
 	if err != nil {
-		http.NotFound(w, r)
+		h.notFound(w, r)
 		return
 	}
 
 	// Verify report exists
 	report, err := h.db.GetReportByID(reportID)
 	if err != nil {
-		http.NotFound(w, r)
+		h.writeError(w, r, http.StatusNotFound, "That report does not exist. It may have been deleted.")
 		return
 	}
 
 	// Get file information
 	file, err := h.db.GetFileByID(report.FileID)
 	if err != nil {
-		http.NotFound(w, r)
+		h.notFound(w, r)
 		return
 	}
 
 	// Serve the report page with metadata
-	h.serveReportPage(w, report, file)
+	h.serveReportPage(w, r, report, file)
 }
 
-// HandleUpload handles file uploads
+// HandleUpload handles file uploads. Several files may be sent in the multipart "file"
+// field at once; each is stored independently in a new upload group and the response
+// has a result for every file, so one bad file doesn't fail the others. A single file may
+// be uploaded as the newer version of an earlier capture by sending its ID as "supersedes".
+// Files detected as a type missing from a non-empty allowed_file_types setting are refused.
+// Successful uploads report the space left in the uploads directory as "disk_space", so
+// users see disk pressure as they upload
 func (h *Handlers) HandleUpload(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed", ErrCodeMethodNotAllowed)
 		return
 	}
 
 	// Parse multipart form
 	err := r.ParseMultipartForm(100 << 20) // 100MB max
 	if err != nil {
-		http.Error(w, "Failed to parse form", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "Failed to parse form", ErrCodeBadRequest)
+		return
+	}
+
+	headers := r.MultipartForm.File["file"]
+	if len(headers) == 0 {
+		writeJSONError(w, http.StatusBadRequest, "Failed to get file", ErrCodeBadRequest)
+		return
+	}
+
+	superseded, uploadErr := h.supersededFile(r, len(headers))
+	if uploadErr != nil {
+		writeJSONError(w, uploadErr.status, uploadErr.message, uploadErr.code)
+		return
+	}
+
+	if len(headers) == 1 {
+		result, uploadErr := h.storeUpload(headers[0], "", superseded)
+		if uploadErr != nil {
+			writeJSONError(w, uploadErr.status, uploadErr.message, uploadErr.code)
+			return
+		}
+
+		response := map[string]interface{}{
+			"success": true,
+			"file":    result.file,
+			"message": result.message,
+		}
+		if result.uploads != nil {
+			response["uploads"] = result.uploads
+		}
+		if space := h.uploadsDiskSpace(); space != nil {
+			response["disk_space"] = space
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.Printf("Error encoding JSON response: %v", err)
+		}
 		return
 	}
 
-	file, header, err := r.FormFile("file")
+	// Files uploaded together, e.g. several dropped at once, share an upload group so
+	// the listing can show them as related
+	groupID, err := newUploadGroupID()
 	if err != nil {
-		http.Error(w, "Failed to get file", http.StatusBadRequest)
+		log.Printf("Error generating upload group id: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to create upload group", ErrCodeInternal)
 		return
 	}
+
+	// results has an entry for every file in the order they were sent; files and failures
+	// split the same outcomes for clients that only need one or the other
+	results := make([]map[string]interface{}, 0, len(headers))
+	files := make([]*database.File, 0, len(headers))
+	failures := make([]map[string]string, 0)
+	for _, header := range headers {
+		result, uploadErr := h.storeUpload(header, groupID, nil)
+		if uploadErr != nil {
+			results = append(results, map[string]interface{}{
+				"file_name": header.Filename,
+				"success":   false,
+				"error":     uploadErr.message,
+				"code":      uploadErr.code,
+			})
+			failures = append(failures, map[string]string{"file_name": header.Filename, "error": uploadErr.message})
+			continue
+		}
+		entry := map[string]interface{}{
+			"file_name": header.Filename,
+			"success":   true,
+			"file":      result.file,
+			"message":   result.message,
+		}
+		if result.uploads != nil {
+			entry["uploads"] = result.uploads
+		}
+		results = append(results, entry)
+		files = append(files, result.file)
+	}
+
+	message := fmt.Sprintf("Uploaded %d files", len(files))
+	if len(failures) > 0 {
+		message = fmt.Sprintf("Uploaded %d of %d files", len(files), len(headers))
+	}
+
+	response := map[string]interface{}{
+		"success":         len(failures) == 0,
+		"upload_group_id": groupID,
+		"results":         results,
+		"files":           files,
+		"failures":        failures,
+		"message":         message,
+	}
+	if space := h.uploadsDiskSpace(); space != nil {
+		response["disk_space"] = space
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Error encoding JSON response: %v", err)
+	}
+}
+
+// uploadsDiskSpace returns the space of the filesystem holding the uploads directory, nil
+// when it can't be read
+func (h *Handlers) uploadsDiskSpace() *DiskStats {
+	stats, _, err := statDisk(filepath.Clean(h.cfg.UploadsDir))
+	if err != nil {
+		log.Printf("Error getting uploads directory stats: %v", err)
+		return nil
+	}
+	return &stats
+}
+
+// uploadResult is a file stored by storeUpload
+type uploadResult struct {
+	file    *database.File
+	uploads []*database.FileUpload // Every upload of the content, set when it was already stored
+	message string
+}
+
+// uploadError is why storeUpload could not store a file, as it is reported to the client
+type uploadError struct {
+	status  int
+	message string
+	code    string
+}
+
+// newUploadGroupID returns a random version 4 UUID for a group of files uploaded together
+func newUploadGroupID() (string, error) {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", err
+	}
+	id[6] = (id[6] & 0x0f) | 0x40 // version 4
+	id[8] = (id[8] & 0x3f) | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:16]), nil
+}
+
+// storeUpload saves one uploaded file, records the upload against content that is already
+// stored or restores content that was deleted, and queues its automatic report. groupID
+// puts the file in an upload group; it is empty for files uploaded on their own.
+// superseded is the earlier capture the file is a newer version of, the content must
+// not be stored yet and the reports of the earlier capture are regenerated on it
+func (h *Handlers) storeUpload(header *multipart.FileHeader, groupID string, superseded *database.File) (*uploadResult, *uploadError) {
+	file, err := header.Open()
+	if err != nil {
+		return nil, &uploadError{http.StatusBadRequest, "Failed to get file", ErrCodeBadRequest}
+	}
 	defer func() {
 		if err := file.Close(); err != nil {
 			log.Printf("Error closing uploaded file: %v", err)
 		}
 	}()
 
-	// Calculate file hash
-	hasher := sha256.New()
 	fileContent, err := io.ReadAll(file)
 	if err != nil {
-		http.Error(w, "Failed to read file", http.StatusInternalServerError)
-		return
+		return nil, &uploadError{http.StatusInternalServerError, "Failed to read file", ErrCodeInternal}
+	}
+	// An empty capture can't be analyzed, usually because the collection failed or the
+	// copy was cut short, so it is refused before anything is stored
+	if len(fileContent) == 0 {
+		return nil, &uploadError{http.StatusBadRequest, fmt.Sprintf("%s is empty (0 bytes); check that the capture finished writing before uploading it", header.Filename), ErrCodeBadRequest}
+	}
+
+	// Calculate file hash
+	hashAlgorithm := h.getHashAlgorithm()
+	hash, err := computeContentHash(hashAlgorithm, fileContent)
+	if err != nil {
+		return nil, &uploadError{http.StatusInternalServerError, "Failed to hash file", ErrCodeInternal}
 	}
-	hasher.Write(fileContent)
-	hash := hex.EncodeToString(hasher.Sum(nil))
 
-	// Check if file already exists
-	existingFile, err := h.db.GetFileByHash(hash)
+	// Check if file already exists, possibly hashed with the algorithm in use before
+	existingFile, hash, hashAlgorithm, err := h.lookupContent(fileContent, hash, hashAlgorithm)
+	if err == nil && superseded != nil {
+		if existingFile.ID == superseded.ID {
+			return nil, &uploadError{http.StatusConflict, fmt.Sprintf("Upload is identical to file %d", superseded.ID), ErrCodeConflict}
+		}
+		return nil, &uploadError{http.StatusConflict, fmt.Sprintf("This content is already stored as file %d", existingFile.ID), ErrCodeConflict}
+	}
 	if err == nil {
 		if !existingFile.Deleted {
-			// File already exists and is not deleted, return existing file info
-			w.Header().Set("Content-Type", "application/json")
-			if err := json.NewEncoder(w).Encode(map[string]interface{}{
-				"success": true,
-				"file":    existingFile,
-				"message": "File already exists",
+			// File already exists and is not deleted. The content is stored once, but this
+			// upload is recorded so the name it came in under (e.g. another node) is kept
+			if err := h.db.InsertFileUpload(&database.FileUpload{
+				FileID:       existingFile.ID,
+				OriginalName: header.Filename,
+				UploadTime:   time.Now(),
 			}); err != nil {
-				log.Printf("Error encoding JSON response: %v", err)
+				log.Printf("Error recording upload of existing file %d: %v", existingFile.ID, err)
+				return nil, &uploadError{http.StatusInternalServerError, "Failed to record upload", ErrCodeInternal}
 			}
-			return
-		} else {
-			// File exists but is deleted - restore it
-			fileType := detector.DetectFileType(header.Filename, fileContent)
-			filePath := filepath.Join(h.cfg.UploadsDir, hash)
-
-			// Validate that the file path is within the uploads directory
-			if !strings.HasPrefix(filepath.Clean(filePath), filepath.Clean(h.cfg.UploadsDir)) {
-				http.Error(w, "Invalid file path", http.StatusBadRequest)
-				return
+			if existingFile, err = h.assignUploadGroup(existingFile, groupID); err != nil {
+				return nil, &uploadError{http.StatusInternalServerError, "Failed to record upload", ErrCodeInternal}
 			}
-
-			// Save file to disk
-			outFile, err := os.Create(filePath)
+			uploads, err := h.db.GetFileUploads(existingFile.ID)
 			if err != nil {
-				http.Error(w, "Failed to save file", http.StatusInternalServerError)
-				return
+				log.Printf("Error getting uploads for file %d: %v", existingFile.ID, err)
 			}
-			defer func() {
-				if err := outFile.Close(); err != nil {
-					log.Printf("Error closing output file: %v", err)
-				}
-			}()
-
-			_, err = outFile.Write(fileContent)
-			if err != nil {
-				http.Error(w, "Failed to write file", http.StatusInternalServerError)
-				return
+			if uploads == nil {
+				uploads = []*database.FileUpload{}
 			}
 
-			// Restore the file in database
-			err = h.db.RestoreFile(existingFile.ID, header.Filename, fileType, int64(len(fileContent)), filePath)
-			if err != nil {
-				http.Error(w, "Failed to restore file record", http.StatusInternalServerError)
-				return
-			}
+			return &uploadResult{file: existingFile, uploads: uploads, message: "File already exists; recorded this upload"}, nil
+		}
 
-			// Get updated file record
-			restoredFile, err := h.db.GetFileByHash(hash)
-			if err != nil {
-				http.Error(w, "Failed to get restored file", http.StatusInternalServerError)
-				return
-			}
+		// File exists but is deleted - restore it under the hash it was stored with
+		hash = existingFile.Hash
+		detection := detector.DetectFileTypeWithConfidence(header.Filename, fileContent)
+		fileType := detection.FileType
+		if uploadErr := h.checkAllowedFileType(fileType); uploadErr != nil {
+			return nil, uploadErr
+		}
+		filePath := filepath.Join(h.cfg.UploadsDir, hash)
 
-			w.Header().Set("Content-Type", "application/json")
-			if err := json.NewEncoder(w).Encode(map[string]interface{}{
-				"success": true,
-				"file":    restoredFile,
-				"message": "File restored successfully",
-			}); err != nil {
-				log.Printf("Error encoding JSON response: %v", err)
-			}
-			return
+		// Validate that the file path is within the uploads directory
+		if !strings.HasPrefix(filepath.Clean(filePath), filepath.Clean(h.cfg.UploadsDir)) {
+			return nil, &uploadError{http.StatusBadRequest, "Invalid file path", ErrCodeBadRequest}
+		}
+
+		// Save file to disk
+		if uploadErr := writeUploadedFile(filePath, fileContent); uploadErr != nil {
+			return nil, uploadErr
+		}
+
+		// Restore the file in database
+		err = h.db.RestoreFile(existingFile.ID, header.Filename, fileType, int64(len(fileContent)), filePath)
+		if err != nil {
+			return nil, &uploadError{http.StatusInternalServerError, "Failed to restore file record", ErrCodeInternal}
+		}
+		if err := h.db.UpdateFileDetection(existingFile.ID, detection.Confidence, detection.Signal); err != nil {
+			return nil, &uploadError{http.StatusInternalServerError, "Failed to restore file record", ErrCodeInternal}
+		}
+		if err := h.db.InsertFileUpload(&database.FileUpload{
+			FileID:       existingFile.ID,
+			OriginalName: header.Filename,
+			UploadTime:   time.Now(),
+		}); err != nil {
+			return nil, &uploadError{http.StatusInternalServerError, "Failed to record upload", ErrCodeInternal}
+		}
+		metrics.RecordUpload(fileType)
+
+		// Get updated file record
+		restoredFile, err := h.db.GetFileByHash(hash)
+		if err != nil {
+			return nil, &uploadError{http.StatusInternalServerError, "Failed to get restored file", ErrCodeInternal}
+		}
+		if restoredFile, err = h.assignUploadGroup(restoredFile, groupID); err != nil {
+			return nil, &uploadError{http.StatusInternalServerError, "Failed to get restored file", ErrCodeInternal}
 		}
+
+		return &uploadResult{file: restoredFile, message: "File restored successfully"}, nil
 	}
 
 	// Detect file type
-	fileType := detector.DetectFileType(header.Filename, fileContent)
+	detection := detector.DetectFileTypeWithConfidence(header.Filename, fileContent)
+	fileType := detection.FileType
+	if uploadErr := h.checkAllowedFileType(fileType); uploadErr != nil {
+		return nil, uploadErr
+	}
 
 	// Save file to disk
 	filePath := filepath.Join(h.cfg.UploadsDir, hash)
 	// Validate that the file path is within the uploads directory
 	if !strings.HasPrefix(filepath.Clean(filePath), filepath.Clean(h.cfg.UploadsDir)) {
-		http.Error(w, "Invalid file path", http.StatusBadRequest)
-		return
+		return nil, &uploadError{http.StatusBadRequest, "Invalid file path", ErrCodeBadRequest}
+	}
+	if uploadErr := writeUploadedFile(filePath, fileContent); uploadErr != nil {
+		return nil, uploadErr
+	}
+
+	// Save file record to database
+	dbFile := &database.File{
+		Hash:                hash,
+		HashAlgorithm:       hashAlgorithm,
+		OriginalName:        header.Filename,
+		FileType:            fileType,
+		FileSize:            int64(len(fileContent)),
+		UploadTime:          time.Now(),
+		FilePath:            filePath,
+		DetectionConfidence: detection.Confidence,
+		DetectionSignal:     detection.Signal,
+		UploadGroupID:       groupID,
+	}
+	if superseded != nil {
+		dbFile.SupersedesFileID = &superseded.ID
+	}
+
+	err = h.db.InsertFile(dbFile)
+	if errors.Is(err, database.ErrAlreadySuperseded) {
+		// Another upload superseded the same file since it was checked
+		if err := os.Remove(filePath); err != nil {
+			log.Printf("Error removing %s after a conflicting supersede: %v", filePath, err)
+		}
+		return nil, &uploadError{http.StatusConflict,
+			fmt.Sprintf("File %d is already superseded by another upload, supersede that instead", superseded.ID), ErrCodeConflict}
+	}
+	if err != nil {
+		return nil, &uploadError{http.StatusInternalServerError, "Failed to save file record", ErrCodeInternal}
+	}
+	metrics.RecordUpload(fileType)
+
+	// Automatically create a report for the uploaded file if we know how to handle it
+	if h.shouldAutoGenerateReport(fileType) {
+		if _, _, err := h.queueReport(dbFile.ID, fileType); err != nil {
+			// Log error but don't fail the upload
+			log.Printf("Failed to create automatic report for file %d: %v", dbFile.ID, err)
+		}
 	}
+	if superseded != nil {
+		h.queueSupersedingReports(dbFile, superseded)
+		return &uploadResult{file: dbFile, message: fmt.Sprintf("File uploaded as a newer version of file %d", superseded.ID)}, nil
+	}
+
+	return &uploadResult{file: dbFile, message: "File uploaded successfully"}, nil
+}
+
+// writeUploadedFile saves the content of an upload to filePath
+func writeUploadedFile(filePath string, content []byte) *uploadError {
 	outFile, err := os.Create(filePath)
 	if err != nil {
-		http.Error(w, "Failed to save file", http.StatusInternalServerError)
-		return
+		return &uploadError{http.StatusInternalServerError, "Failed to save file", ErrCodeInternal}
 	}
 	defer func() {
 		if err := outFile.Close(); err != nil {
@@ -245,58 +636,126 @@ func (h *Handlers) HandleUpload(w http.ResponseWriter, r *http.Request) {
 		}
 	}()
 
-	_, err = outFile.Write(fileContent)
-	if err != nil {
-		http.Error(w, "Failed to write file", http.StatusInternalServerError)
-		return
+	if _, err := outFile.Write(content); err != nil {
+		return &uploadError{http.StatusInternalServerError, "Failed to write file", ErrCodeInternal}
 	}
+	return nil
+}
 
-	// Save file record to database
-	dbFile := &database.File{
-		Hash:         hash,
-		OriginalName: header.Filename,
-		FileType:     fileType,
-		FileSize:     int64(len(fileContent)),
-		UploadTime:   time.Now(),
-		FilePath:     filePath,
+// assignUploadGroup puts file in the upload group when it is not already in one and
+// returns the updated record
+func (h *Handlers) assignUploadGroup(file *database.File, groupID string) (*database.File, error) {
+	if groupID == "" || file.UploadGroupID != "" {
+		return file, nil
 	}
+	if err := h.db.AssignFileUploadGroup(file.ID, groupID); err != nil {
+		log.Printf("Error assigning file %d to upload group %s: %v", file.ID, groupID, err)
+		return nil, err
+	}
+	file.UploadGroupID = groupID
+	return file, nil
+}
 
-	err = h.db.InsertFile(dbFile)
-	if err != nil {
-		http.Error(w, "Failed to save file record", http.StatusInternalServerError)
+// HandleFilesByGroup lists the files uploaded together in an upload group,
+// e.g. GET /api/groups/{id}
+func (h *Handlers) HandleFilesByGroup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed", ErrCodeMethodNotAllowed)
 		return
 	}
 
-	// Automatically create a report for the uploaded file if we know how to handle it
-	if h.shouldAutoGenerateReport(fileType) {
-		report := &database.Report{
-			FileID:      dbFile.ID,
-			ReportType:  fileType,
-			Status:      "pending",
-			CreatedTime: time.Now(),
-			DDDVersion:  DDDVersion,
-		}
+	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(pathParts) != 3 || pathParts[2] == "" { // expecting /api/groups/{id}
+		writeJSONError(w, http.StatusBadRequest, "Invalid upload group ID in path", ErrCodeBadRequest)
+		return
+	}
+	groupID := pathParts[2]
 
-		if err := h.db.InsertReport(report); err != nil {
-			// Log error but don't fail the upload
-			log.Printf("Failed to create automatic report for file %d: %v", dbFile.ID, err)
-		}
+	files, err := h.db.GetFilesByUploadGroup(groupID)
+	if err != nil {
+		log.Printf("Error getting files for upload group %s: %v", groupID, err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to get files", ErrCodeInternal)
+		return
+	}
+	if len(files) == 0 {
+		writeJSONError(w, http.StatusNotFound, "Upload group not found", ErrCodeNotFound)
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"file":    dbFile,
-		"message": "File uploaded successfully",
+		"success":         true,
+		"upload_group_id": groupID,
+		"files":           files,
 	}); err != nil {
 		log.Printf("Error encoding JSON response: %v", err)
 	}
 }
 
+// HandleDetectPreview reports how a file would be classified on upload without storing it,
+// e.g. POST /api/detect with the file, or only its first few KB, in the multipart "file"
+// field. Archives are listed from their end, so a partial archive may detect as unknown
+func (h *Handlers) HandleDetectPreview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed", ErrCodeMethodNotAllowed)
+		return
+	}
+
+	// Stream the form rather than parsing it, which would spool large files to disk
+	reader, err := r.MultipartReader()
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Failed to parse form", ErrCodeBadRequest)
+		return
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			writeJSONError(w, http.StatusBadRequest, "Failed to get file", ErrCodeBadRequest)
+			return
+		}
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "Failed to parse form", ErrCodeBadRequest)
+			return
+		}
+		if part.FormName() != "file" {
+			continue
+		}
+
+		// The detector only looks at a prefix, so the rest of the file is never read
+		content, err := io.ReadAll(io.LimitReader(part, detector.PrefixBytes+1))
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "Failed to read file", ErrCodeBadRequest)
+			return
+		}
+		detection := detector.DetectFileTypeWithConfidence(part.FileName(), content)
+		truncated := len(content) > detector.PrefixBytes
+		if truncated {
+			content = content[:detector.PrefixBytes]
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":        true,
+			"file_name":      part.FileName(),
+			"detection":      detection,
+			"bytes_examined": len(content),
+			"truncated":      truncated,
+			// Whether an upload of the file would pass the allowed_file_types setting
+			"allowed": h.checkAllowedFileType(detection.FileType) == nil,
+			// Detections at or below this confidence should be flagged for the user to check
+			"low_confidence_threshold": detector.LowConfidenceThreshold,
+		}); err != nil {
+			log.Printf("Error encoding JSON response: %v", err)
+		}
+		return
+	}
+}
+
 // HandleFiles handles file listing and searching
 func (h *Handlers) HandleFiles(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed", ErrCodeMethodNotAllowed)
 		return
 	}
 
@@ -322,16 +781,23 @@ func (h *Handlers) HandleFiles(w http.ResponseWriter, r *http.Request) {
 
 	includeDeleted := includeDeletedStr == "true"
 
-	files, err := h.db.GetFiles(limit, offset, includeDeleted, searchQuery)
+	// Sending cursor, empty for the first page, switches to cursor pagination which stays
+	// consistent while files are uploaded or deleted
+	if r.URL.Query().Has("cursor") {
+		h.handleFilesCursorPage(w, r.URL.Query().Get("cursor"), limit, includeDeleted, searchQuery)
+		return
+	}
+
+	files, err := h.db.GetFiles(limit, offset, includeDeleted, searchQuery, nil)
 	if err != nil {
-		http.Error(w, "Failed to get files", http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to get files", ErrCodeInternal)
 		return
 	}
 
 	// Get total count for pagination
 	totalCount, err := h.db.GetFilesCount(includeDeleted, searchQuery)
 	if err != nil {
-		http.Error(w, "Failed to get files count", http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to get files count", ErrCodeInternal)
 		return
 	}
 
@@ -343,46 +809,160 @@ func (h *Handlers) HandleFiles(w http.ResponseWriter, r *http.Request) {
 		"page":        (offset / limit) + 1,
 		"page_size":   limit,
 		"total_pages": (totalCount + limit - 1) / limit, // Ceiling division
+		// Detections at or below this confidence should be flagged for the user to check
+		"low_confidence_threshold": detector.LowConfidenceThreshold,
 	}); err != nil {
 		log.Printf("Error encoding JSON response: %v", err)
 	}
 }
 
-// HandleFileOperations handles individual file operations (delete, etc.)
-func (h *Handlers) HandleFileOperations(w http.ResponseWriter, r *http.Request) {
-	// Extract file ID from URL path
-	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
-	if len(pathParts) < 3 {
-		http.Error(w, "Invalid file ID", http.StatusBadRequest)
-		return
+// handleFilesCursorPage lists the page of files after token. next_cursor is empty on the last page
+func (h *Handlers) handleFilesCursorPage(w http.ResponseWriter, token string, limit int, includeDeleted bool, searchQuery string) {
+	var after *database.FileCursor
+	if token != "" {
+		cursor, err := database.ParseFileCursor(token)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, err.Error(), ErrCodeBadRequest)
+			return
+		}
+		after = cursor
 	}
 
-	fileIDStr := pathParts[2]
-	fileID, err := strconv.Atoi(fileIDStr)
+	files, err := h.db.GetFiles(limit, 0, includeDeleted, searchQuery, after)
 	if err != nil {
-		http.Error(w, "Invalid file ID", http.StatusBadRequest)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to get files", ErrCodeInternal)
 		return
 	}
 
-	switch r.Method {
-	case http.MethodDelete:
-		// Get file info first to get the file path
-		file, err := h.db.GetFileByID(fileID)
-		if err != nil {
-			http.Error(w, "File not found", http.StatusNotFound)
-			return
-		}
+	totalCount, err := h.db.GetFilesCount(includeDeleted, searchQuery)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Failed to get files count", ErrCodeInternal)
+		return
+	}
 
-		// Remove physical file from disk
-		if err := os.Remove(file.FilePath); err != nil && !os.IsNotExist(err) {
-			log.Printf("Warning: Failed to remove physical file %s: %v", file.FilePath, err)
-			// Continue with database update even if file removal fails
-		}
+	nextCursor := ""
+	if len(files) == limit {
+		nextCursor = database.NewFileCursor(files[len(files)-1]).String()
+	}
 
-		// Mark file as deleted in database
-		err = h.db.MarkFileDeleted(fileID)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":                  true,
+		"files":                    files,
+		"total":                    totalCount,
+		"page_size":                limit,
+		"next_cursor":              nextCursor,
+		"low_confidence_threshold": detector.LowConfidenceThreshold,
+	}); err != nil {
+		log.Printf("Error encoding JSON response: %v", err)
+	}
+}
+
+// streamFilesBatchSize is how many files HandleFilesStream reads from the database at a time
+const streamFilesBatchSize = 500
+
+// HandleFilesStream writes every file as newline-delimited JSON, one object per line in ID order,
+// flushing as it goes so integrations can sync the whole catalog without paging.
+// Deleted files are included with include_deleted=true
+func (h *Handlers) HandleFilesStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed", ErrCodeMethodNotAllowed)
+		return
+	}
+	includeDeleted := r.URL.Query().Get("include_deleted") == "true"
+
+	// Large catalogs can outlive the server write timeout, so lift it for this response
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Printf("Error clearing write deadline for file stream: %v", err)
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	encoder := json.NewEncoder(w)
+	err := h.db.StreamFiles(includeDeleted, streamFilesBatchSize, func(file *database.File) error {
+		if err := encoder.Encode(file); err != nil {
+			return err
+		}
+		if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		// The status line is already sent, the client sees a truncated stream
+		log.Printf("Error streaming files: %v", err)
+	}
+}
+
+// HandleFileOperations handles individual file operations (details with upload history, delete)
+func (h *Handlers) HandleFileOperations(w http.ResponseWriter, r *http.Request) {
+	// Extract file ID from URL path
+	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(pathParts) < 3 {
+		writeJSONError(w, http.StatusBadRequest, "Invalid file ID", ErrCodeBadRequest)
+		return
+	}
+
+	fileIDStr := pathParts[2]
+	fileID, err := strconv.Atoi(fileIDStr)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid file ID", ErrCodeBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		file, err := h.db.GetFileByID(fileID)
+		if err != nil {
+			writeJSONError(w, http.StatusNotFound, "File not found", ErrCodeNotFound)
+			return
+		}
+
+		uploads, err := h.db.GetFileUploads(fileID)
+		if err != nil {
+			log.Printf("Error getting uploads for file %d: %v", fileID, err)
+			writeJSONError(w, http.StatusInternalServerError, "Failed to get file uploads", ErrCodeInternal)
+			return
+		}
+
+		versions, err := h.db.GetFileVersions(fileID)
+		if err != nil {
+			log.Printf("Error getting versions of file %d: %v", fileID, err)
+			writeJSONError(w, http.StatusInternalServerError, "Failed to get file versions", ErrCodeInternal)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":  true,
+			"file":     file,
+			"uploads":  uploads,
+			"versions": versions, // Every version of the capture oldest first, including this file
+		}); err != nil {
+			log.Printf("Error encoding JSON response: %v", err)
+		}
+
+	case http.MethodDelete:
+		// Get file info first to get the file path
+		file, err := h.db.GetFileByID(fileID)
+		if err != nil {
+			writeJSONError(w, http.StatusNotFound, "File not found", ErrCodeNotFound)
+			return
+		}
+
+		// Remove physical file from disk
+		if err := os.Remove(file.FilePath); err != nil && !os.IsNotExist(err) {
+			log.Printf("Warning: Failed to remove physical file %s: %v", file.FilePath, err)
+			// Continue with database update even if file removal fails
+		}
+
+		// Mark file as deleted in database
+		err = h.db.MarkFileDeleted(fileID)
 		if err != nil {
-			http.Error(w, "Failed to delete file", http.StatusInternalServerError)
+			writeJSONError(w, http.StatusInternalServerError, "Failed to delete file", ErrCodeInternal)
 			return
 		}
 
@@ -395,8 +975,73 @@ func (h *Handlers) HandleFileOperations(w http.ResponseWriter, r *http.Request)
 		}
 
 	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed", ErrCodeMethodNotAllowed)
+	}
+}
+
+// HandleDownloadFile streams the original uploaded bytes of a file as an attachment.
+// Range requests are supported so large artifacts can be resumed.
+func (h *Handlers) HandleDownloadFile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed", ErrCodeMethodNotAllowed)
+		return
+	}
+
+	// Extract file ID from URL path
+	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(pathParts) < 4 { // expecting /api/files/{id}/download
+		writeJSONError(w, http.StatusBadRequest, "Invalid file ID in path", ErrCodeBadRequest)
+		return
+	}
+
+	fileID, err := strconv.Atoi(pathParts[2])
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid file ID", ErrCodeBadRequest)
+		return
+	}
+
+	file, err := h.db.GetFileByID(fileID)
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, "File not found", ErrCodeNotFound)
+		return
+	}
+	if file.Deleted {
+		writeJSONError(w, http.StatusGone, "File has been deleted", ErrCodeGone)
+		return
+	}
+
+	f, err := os.Open(file.FilePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			writeJSONError(w, http.StatusNotFound, "File content no longer available", ErrCodeNotFound)
+			return
+		}
+		log.Printf("Failed to open file %d for download: %v", fileID, err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to read file", ErrCodeInternal)
+		return
 	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			log.Printf("Error closing file %s: %v", file.FilePath, err)
+		}
+	}()
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", contentDisposition(file.OriginalName))
+	// ServeContent sets Content-Length and handles Range and If-Modified-Since
+	http.ServeContent(w, r, file.OriginalName, file.UploadTime, f)
+}
+
+// contentDisposition builds an attachment header for a file name. The quoted filename is
+// reduced to characters safe in a header, and filename* carries the exact UTF-8 name.
+func contentDisposition(name string) string {
+	safe := strings.Map(func(r rune) rune {
+		if r == '"' || r == '\\' || r < 0x20 || r > 0x7e {
+			return '_'
+		}
+		return r
+	}, name)
+	return fmt.Sprintf(`attachment; filename="%s"; filename*=UTF-8''%s`, safe, url.PathEscape(name))
 }
 
 // HandleReports handles report operations
@@ -404,30 +1049,112 @@ func (h *Handlers) HandleReports(w http.ResponseWriter, r *http.Request) {
 	// Extract ID from URL path (could be file ID or report ID depending on context)
 	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
 	if len(pathParts) < 3 {
-		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "Invalid ID", ErrCodeBadRequest)
+		return
+	}
+
+	// /api/reports/{id}/logs and /events can't be registered on the mux alongside /api/reports/content/
+	if len(pathParts) == 4 && pathParts[3] == "logs" {
+		h.HandleReportLogs(w, r)
+		return
+	}
+	if len(pathParts) == 4 && pathParts[3] == "events" {
+		h.HandleReportEvents(w, r)
+		return
+	}
+	if len(pathParts) == 4 && pathParts[3] == "priority" {
+		h.HandleReportPriority(w, r)
+		return
+	}
+	if len(pathParts) == 4 && pathParts[3] == "cancel" {
+		h.HandleCancelReport(w, r)
+		return
+	}
+	if len(pathParts) == 4 && pathParts[3] == "star" {
+		h.HandleStarReport(w, r)
+		return
+	}
+	if len(pathParts) == 4 && pathParts[3] == "standalone.html" {
+		h.HandleStandaloneReport(w, r)
+		return
+	}
+	if len(pathParts) == 5 && pathParts[3] == "chart" {
+		h.HandleReportChart(w, r)
+		return
+	}
+	if len(pathParts) == 4 && pathParts[3] == "bundle" {
+		h.HandleExportReport(w, r)
+		return
+	}
+	if len(pathParts) == 4 && pathParts[3] == "compare" {
+		h.HandleCompareToBaseline(w, r)
+		return
+	}
+	if (len(pathParts) == 4 || len(pathParts) == 5) && pathParts[3] == "annotations" {
+		h.HandleReportAnnotations(w, r)
 		return
 	}
 
 	idStr := pathParts[2]
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "Invalid ID", ErrCodeBadRequest)
 		return
 	}
 
 	switch r.Method {
 	case http.MethodGet:
 		// Get reports by file ID
-		reports, err := h.db.GetReportsByFileID(id)
+		limit := 20 // default
+		if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 {
+			limit = l
+		}
+
+		offset := 0 // default
+		if o, err := strconv.Atoi(r.URL.Query().Get("offset")); err == nil && o >= 0 {
+			offset = o
+		}
+
+		// starred=true narrows the listing to the reports the user marked as mattering
+		starredOnly := r.URL.Query().Get("starred") == "true"
+
+		var reports []*database.Report
+		if starredOnly {
+			reports, err = h.db.GetStarredReportsByFileIDPaged(id, limit, offset)
+		} else {
+			reports, err = h.db.GetReportsByFileIDPaged(id, limit, offset)
+		}
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, "Failed to get reports", ErrCodeInternal)
+			return
+		}
+
+		// Get total count for pagination
+		var totalCount int
+		if starredOnly {
+			totalCount, err = h.db.GetStarredReportCountByFileID(id)
+		} else {
+			totalCount, err = h.db.GetReportCountByFileID(id)
+		}
 		if err != nil {
-			http.Error(w, "Failed to get reports", http.StatusInternalServerError)
+			writeJSONError(w, http.StatusInternalServerError, "Failed to get reports count", ErrCodeInternal)
 			return
 		}
 
+		// Flag reports from older versions so the UI can offer to regenerate them
+		listed := make([]reportListItem, 0, len(reports))
+		for _, report := range reports {
+			listed = append(listed, reportListItem{Report: report, Stale: isStaleVersion(report.DDDVersion)})
+		}
+
 		w.Header().Set("Content-Type", "application/json")
 		if err := json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": true,
-			"reports": reports,
+			"success":     true,
+			"reports":     listed,
+			"total":       totalCount,
+			"page":        (offset / limit) + 1,
+			"page_size":   limit,
+			"total_pages": (totalCount + limit - 1) / limit, // Ceiling division
 		}); err != nil {
 			log.Printf("Error encoding JSON response: %v", err)
 		}
@@ -436,31 +1163,47 @@ func (h *Handlers) HandleReports(w http.ResponseWriter, r *http.Request) {
 		// Create new report for file ID
 		var req struct {
 			ReportType string `json:"report_type"`
+			Force      bool   `json:"force"` // Queue the report even if it doesn't apply to the file's type
 		}
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			writeJSONError(w, http.StatusBadRequest, "Invalid request body", ErrCodeBadRequest)
 			return
 		}
 
-		report := &database.Report{
-			FileID:      id,
-			ReportType:  req.ReportType,
-			Status:      "pending",
-			CreatedTime: time.Now(),
-			DDDVersion:  DDDVersion,
+		reporter, ok := reporters.Lookup(req.ReportType)
+		if !ok {
+			writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("Unknown report type: %s", req.ReportType), ErrCodeBadRequest)
+			return
+		}
+		file, err := h.db.GetFileByID(id)
+		if err != nil {
+			writeJSONError(w, http.StatusNotFound, "File not found", ErrCodeNotFound)
+			return
+		}
+		// Catch the common mistake of asking for the wrong report before it fails in the worker
+		if !req.Force && !reporters.AppliesTo(reporter, file.FileType) {
+			writeJSONError(w, http.StatusBadRequest,
+				fmt.Sprintf("A %s report can't be generated from a %s file; set force to queue it anyway", req.ReportType, file.FileType),
+				ErrCodeBadRequest)
+			return
 		}
 
-		err := h.db.InsertReport(report)
+		report, created, err := h.queueReport(id, req.ReportType)
 		if err != nil {
-			http.Error(w, "Failed to create report", http.StatusInternalServerError)
+			writeJSONError(w, http.StatusInternalServerError, "Failed to create report", ErrCodeInternal)
 			return
 		}
 
+		message := "Report queued for processing"
+		if !created {
+			message = "Report already queued for processing"
+		}
+
 		w.Header().Set("Content-Type", "application/json")
 		if err := json.NewEncoder(w).Encode(map[string]interface{}{
 			"success": true,
 			"report":  report,
-			"message": "Report queued for processing",
+			"message": message,
 		}); err != nil {
 			log.Printf("Error encoding JSON response: %v", err)
 		}
@@ -469,14 +1212,14 @@ func (h *Handlers) HandleReports(w http.ResponseWriter, r *http.Request) {
 		// Get the report first to find the associated file
 		report, err := h.db.GetReportByID(id)
 		if err != nil {
-			http.Error(w, "Report not found", http.StatusNotFound)
+			writeJSONError(w, http.StatusNotFound, "Report not found", ErrCodeNotFound)
 			return
 		}
 
 		// Delete the report
 		err = h.db.DeleteReport(id)
 		if err != nil {
-			http.Error(w, "Failed to delete report", http.StatusInternalServerError)
+			writeJSONError(w, http.StatusInternalServerError, "Failed to delete report", ErrCodeInternal)
 			return
 		}
 
@@ -524,296 +1267,1253 @@ func (h *Handlers) HandleReports(w http.ResponseWriter, r *http.Request) {
 		}
 
 	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed", ErrCodeMethodNotAllowed)
 	}
 }
 
-// HandleReportContent handles individual report content requests
-func (h *Handlers) HandleReportContent(w http.ResponseWriter, r *http.Request) {
+// reportListItem is a report as listed by HandleReports
+type reportListItem struct {
+	*database.Report
+	Stale bool `json:"stale"` // Generated by an older DDD version than the one running
+}
+
+// HandleReportMeta returns a report's status, type and timestamps without its report
+// data, for views that list reports and don't need the embedded HTML
+func (h *Handlers) HandleReportMeta(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed", ErrCodeMethodNotAllowed)
 		return
 	}
 
 	// Extract report ID from URL path
 	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
-	if len(pathParts) < 4 {
-		http.Error(w, "Invalid report ID", http.StatusBadRequest)
+	if len(pathParts) < 4 { // expecting /api/reports/meta/{id}
+		writeJSONError(w, http.StatusBadRequest, "Invalid report ID", ErrCodeBadRequest)
 		return
 	}
 
-	reportIDStr := pathParts[3]
-	reportID, err := strconv.Atoi(reportIDStr)
+	reportID, err := strconv.Atoi(pathParts[3])
 	if err != nil {
-		http.Error(w, "Invalid report ID", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "Invalid report ID", ErrCodeBadRequest)
 		return
 	}
 
-	// Get the specific report
-	report, err := h.db.GetReportByID(reportID)
+	report, err := h.db.GetReportMetaByID(reportID)
 	if err != nil {
-		http.Error(w, "Report not found", http.StatusNotFound)
+		writeJSONError(w, http.StatusNotFound, "Report not found", ErrCodeNotFound)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(map[string]interface{}{
-		"success":     true,
-		"report_data": report.ReportData,
+		"success": true,
+		"report":  reportListItem{Report: report, Stale: isStaleVersion(report.DDDVersion)},
 	}); err != nil {
 		log.Printf("Error encoding JSON response: %v", err)
 	}
 }
 
-// HandleDiskUsage returns disk usage information for uploads and database directories
-func (h *Handlers) HandleDiskUsage(w http.ResponseWriter, r *http.Request) {
+// HandleFailedReports lists failed reports across all files with their error messages, so
+// a bad batch can be triaged without opening each file. The optional search parameter
+// filters by file name, file type, report type or error message
+func (h *Handlers) HandleFailedReports(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	type diskStats struct {
-		Path      string  `json:"path"`
-		Total     uint64  `json:"total_bytes"`
-		Free      uint64  `json:"free_bytes"`
-		Used      uint64  `json:"used_bytes"`
-		Available uint64  `json:"available_bytes"`
-		Percent   float64 `json:"used_percent"`
-	}
-
-	var stat syscall.Statfs_t
-
-	// Get uploads directory stats
-	uploadsPath := filepath.Clean(h.cfg.UploadsDir)
-	if err := syscall.Statfs(uploadsPath, &stat); err != nil {
-		http.Error(w, "Failed to get uploads directory stats", http.StatusInternalServerError)
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed", ErrCodeMethodNotAllowed)
 		return
 	}
-	// Convert Bsize to uint64 - gosec G115 is acceptable here as Bsize represents block size
-	blockSize := uint64(stat.Bsize) // #nosec G115
 
-	uploadsStats := diskStats{
-		Path:      uploadsPath,
-		Total:     uint64(stat.Blocks) * blockSize,
-		Free:      uint64(stat.Bfree) * blockSize,
-		Available: uint64(stat.Bavail) * blockSize,
+	limit := 20 // default
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 {
+		limit = l
 	}
-	uploadsStats.Used = uploadsStats.Total - uploadsStats.Free
-	uploadsStats.Percent = float64(uploadsStats.Used) / float64(uploadsStats.Total) * 100
 
-	// Get database directory stats
-	dbPath := filepath.Clean(filepath.Dir(h.cfg.DBPath))
-	if err := syscall.Statfs(dbPath, &stat); err != nil {
-		http.Error(w, "Failed to get database directory stats", http.StatusInternalServerError)
-		return
-	}
-	dbStats := diskStats{
-		Path:      dbPath,
-		Total:     uint64(stat.Blocks) * blockSize,
-		Free:      uint64(stat.Bfree) * blockSize,
-		Available: uint64(stat.Bavail) * blockSize,
+	offset := 0 // default
+	if o, err := strconv.Atoi(r.URL.Query().Get("offset")); err == nil && o >= 0 {
+		offset = o
 	}
-	dbStats.Used = dbStats.Total - dbStats.Free
-	dbStats.Percent = float64(dbStats.Used) / float64(dbStats.Total) * 100
 
-	// Check if both paths are on same filesystem
-	sameFS := false
-	var stat1, stat2 syscall.Statfs_t
-	if err := syscall.Statfs(uploadsPath, &stat1); err == nil {
-		if err := syscall.Statfs(dbPath, &stat2); err == nil {
-			sameFS = stat1.Fsid == stat2.Fsid
-		}
-	}
+	searchQuery := r.URL.Query().Get("search")
 
-	// Get settings from database
-	maxDiskUsage, err := h.getMaxDiskUsage()
+	reports, err := h.db.SearchFailedReports(searchQuery, limit, offset)
 	if err != nil {
-		log.Printf("Error getting max disk usage setting: %v", err)
-		maxDiskUsage = h.cfg.MaxDiskUsage // fallback
+		writeJSONError(w, http.StatusInternalServerError, "Failed to get failed reports", ErrCodeInternal)
+		return
 	}
 
-	fileRetentionDays, err := h.getFileRetentionDays()
+	// Get total count for pagination
+	totalCount, err := h.db.CountFailedReports(searchQuery)
 	if err != nil {
-		log.Printf("Error getting file retention days setting: %v", err)
-		fileRetentionDays = h.cfg.FileRetentionDays // fallback
+		writeJSONError(w, http.StatusInternalServerError, "Failed to get failed reports count", ErrCodeInternal)
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(map[string]interface{}{
-		"success":             true,
-		"uploads":             uploadsStats,
-		"database":            dbStats,
-		"same_filesystem":     sameFS,
-		"max_disk_usage":      maxDiskUsage,
-		"file_retention_days": fileRetentionDays,
+		"success":     true,
+		"reports":     reports,
+		"total":       totalCount,
+		"page":        (offset / limit) + 1,
+		"page_size":   limit,
+		"total_pages": (totalCount + limit - 1) / limit, // Ceiling division
 	}); err != nil {
-		log.Printf("Error encoding disk usage JSON response: %v", err)
+		log.Printf("Error encoding JSON response: %v", err)
 	}
 }
 
-// HandleSettings handles getting and updating application settings
-func (h *Handlers) HandleSettings(w http.ResponseWriter, r *http.Request) {
-	switch r.Method {
-	case http.MethodGet:
-		// Get settings from database
-		maxDiskUsage, err := h.getMaxDiskUsage()
-		if err != nil {
-			log.Printf("Error getting max disk usage setting: %v", err)
-			maxDiskUsage = h.cfg.MaxDiskUsage // fallback
-		}
+// HandleRetryFailed re-queues every failed report, or only those of the type given in the
+// optional "type" query parameter, so a wave of failures from a since-fixed systemic issue
+// can be retried at once. Reports whose file content is no longer on disk are skipped, as
+// are reports whose file already has the same report type pending or running
+func (h *Handlers) HandleRetryFailed(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed", ErrCodeMethodNotAllowed)
+		return
+	}
 
-		fileRetentionDays, err := h.getFileRetentionDays()
-		if err != nil {
-			log.Printf("Error getting file retention days setting: %v", err)
-			fileRetentionDays = h.cfg.FileRetentionDays // fallback
+	reportType := r.URL.Query().Get("type")
+	if reportType != "" {
+		if _, ok := reporters.Lookup(reportType); !ok {
+			writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("Unknown report type: %s", reportType), ErrCodeBadRequest)
+			return
 		}
+	}
 
-		w.Header().Set("Content-Type", "application/json")
-		if err := json.NewEncoder(w).Encode(map[string]interface{}{
-			"success":             true,
-			"max_disk_usage":      maxDiskUsage,
-			"file_retention_days": fileRetentionDays,
-		}); err != nil {
-			log.Printf("Error encoding JSON response: %v", err)
+	// A negative limit lists every failed report
+	reports, err := h.db.GetFailedReports(-1, 0)
+	if err != nil {
+		log.Printf("Error getting failed reports: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to get failed reports", ErrCodeInternal)
+		return
+	}
+
+	queued, skipped, alreadyQueued := 0, 0, 0
+	for _, report := range reports {
+		if reportType != "" && report.ReportType != reportType {
+			continue
 		}
-	case http.MethodPost:
-		var req struct {
-			MaxDiskUsage      string `json:"max_disk_usage"`
-			FileRetentionDays string `json:"file_retention_days"`
+		file, err := h.db.GetFileByID(report.FileID)
+		if err != nil {
+			log.Printf("Skipping retry of report %d: %v", report.ID, err)
+			skipped++
+			continue
 		}
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Invalid request body", http.StatusBadRequest)
-			return
+		if _, err := os.Stat(file.FilePath); file.Deleted || err != nil {
+			log.Printf("Skipping retry of report %d: content of file %d is no longer on disk", report.ID, file.ID)
+			skipped++
+			continue
 		}
-
-		// Validate and update MaxDiskUsage
-		if maxUsage, err := strconv.ParseFloat(req.MaxDiskUsage, 64); err == nil {
-			if maxUsage >= 0 && maxUsage <= 100 {
-				// Get current threshold to check if it's being lowered
-				currentThreshold, err := h.getMaxDiskUsage()
-				if err != nil {
-					currentThreshold = h.cfg.MaxDiskUsage // fallback
-				}
-
-				// JS sends percentage, convert to decimal and save to database
-				maxUsageDecimal := maxUsage / 100.0
-				if err := h.db.SetSetting("max_disk_usage", fmt.Sprintf("%.6f", maxUsageDecimal)); err != nil {
-					log.Printf("Error saving max_disk_usage setting: %v", err)
-					http.Error(w, "Failed to save max_disk_usage setting", http.StatusInternalServerError)
-					return
-				}
-				// Also update config for backward compatibility
-				h.cfg.MaxDiskUsage = maxUsageDecimal
-
-				// If threshold was lowered, trigger immediate cleanup
-				if maxUsageDecimal < currentThreshold && h.cleanupWorker != nil {
-					log.Printf("Cleanup threshold lowered from %.1f%% to %.1f%%, triggering cleanup", currentThreshold*100, maxUsageDecimal*100)
-					go h.cleanupWorker.TriggerCleanup()
-				}
-			} else {
-				http.Error(w, "max_disk_usage must be between 0 and 100", http.StatusBadRequest)
-				return
-			}
-		} else {
-			http.Error(w, "Invalid max_disk_usage value", http.StatusBadRequest)
+		// Retrying earlier failures of the same report would only generate it twice
+		if _, err := h.db.GetActiveReport(report.FileID, report.ReportType); err == nil {
+			alreadyQueued++
+			continue
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Error checking for an active %s report for file %d: %v", report.ReportType, report.FileID, err)
+			writeJSONError(w, http.StatusInternalServerError, "Failed to re-queue reports", ErrCodeInternal)
 			return
 		}
-
-		// Validate and update FileRetentionDays
-		if retentionDays, err := strconv.Atoi(req.FileRetentionDays); err == nil {
-			if retentionDays >= 0 {
-				if err := h.db.SetSetting("file_retention_days", fmt.Sprintf("%d", retentionDays)); err != nil {
-					log.Printf("Error saving file_retention_days setting: %v", err)
-					http.Error(w, "Failed to save file_retention_days setting", http.StatusInternalServerError)
-					return
-				}
-				// Also update config for backward compatibility
-				h.cfg.FileRetentionDays = retentionDays
-			} else {
-				http.Error(w, "file_retention_days must be non-negative", http.StatusBadRequest)
-				return
+		if err := h.db.RequeueFailedReport(report.ID, DDDVersion); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				// Retried or removed since it was listed
+				continue
 			}
-		} else {
-			http.Error(w, "Invalid file_retention_days value", http.StatusBadRequest)
+			if errors.Is(err, database.ErrReportAlreadyActive) {
+				alreadyQueued++
+				continue
+			}
+			log.Printf("Error re-queueing report %d: %v", report.ID, err)
+			writeJSONError(w, http.StatusInternalServerError, "Failed to re-queue reports", ErrCodeInternal)
 			return
 		}
+		queued++
+	}
+	log.Printf("Re-queued %d failed reports, skipped %d with missing files", queued, skipped)
 
-		log.Printf("Updated settings: MaxDiskUsage=%.2f%%, FileRetentionDays=%d", h.cfg.MaxDiskUsage*100, h.cfg.FileRetentionDays)
-
-		w.Header().Set("Content-Type", "application/json")
-		if err := json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": true,
-			"message": "Settings updated successfully",
-		}); err != nil {
-			log.Printf("Error encoding JSON response: %v", err)
-		}
-	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":        true,
+		"report_type":    reportType,
+		"queued":         queued,
+		"skipped":        skipped,
+		"already_queued": alreadyQueued,
+		"message":        fmt.Sprintf("Re-queued %d failed reports", queued),
+	}); err != nil {
+		log.Printf("Error encoding JSON response: %v", err)
 	}
 }
 
-// HandleRedetectFileType re-detects the file type for an existing file
-func (h *Handlers) HandleRedetectFileType(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
+// HandleReportContent handles individual report content requests
+func (h *Handlers) HandleReportContent(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed", ErrCodeMethodNotAllowed)
+		return
+	}
+
+	// Extract report ID from URL path
+	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(pathParts) < 4 {
+		writeJSONError(w, http.StatusBadRequest, "Invalid report ID", ErrCodeBadRequest)
+		return
+	}
+
+	reportIDStr := pathParts[3]
+	reportID, err := strconv.Atoi(reportIDStr)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid report ID", ErrCodeBadRequest)
+		return
+	}
+
+	// An optional theme restyles the HTML report without regenerating it
+	theme := r.URL.Query().Get("theme")
+	if theme != "" && !reporters.IsKnownReportTheme(theme) {
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("Unknown theme %q, expected one of %s",
+			theme, strings.Join(reporters.ReportThemeNames(), ", ")), ErrCodeBadRequest)
+		return
+	}
+
+	// An optional top_threads regenerates a ttop or top report with that many threads in its CPU chart
+	topThreads := 0
+	if value := r.URL.Query().Get("top_threads"); value != "" {
+		topThreads, err = strconv.Atoi(value)
+		if err != nil || topThreads < 1 || topThreads > reporters.MaxTTopTopThreads {
+			writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("Invalid top_threads %q, expected a number from 1 to %d",
+				value, reporters.MaxTTopTopThreads), ErrCodeBadRequest)
+			return
+		}
+	}
+
+	// Get the specific report
+	report, err := h.db.GetReportByID(reportID)
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, "Report not found", ErrCodeNotFound)
+		return
+	}
+	if topThreads > 0 && !hasTopThreadsChart(report.ReportType) {
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("top_threads only applies to ttop and top reports, not %s", report.ReportType), ErrCodeBadRequest)
+		return
+	}
+
+	// Browsers revalidate report data with its ETag before every use: a completed report
+	// still changes when its data is trimmed or purged, and anything else once it finishes.
+	// Completed reports are private to the browser so shared caches don't keep them
+	etag := reportETag(report, theme, topThreads)
+	w.Header().Set("ETag", etag)
+	if report.Status == "completed" {
+		w.Header().Set("Cache-Control", "private, no-cache")
+	} else {
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	reportData := report.ReportData
+	if topThreads > 0 && report.Status == "completed" && reportData != "" {
+		file, err := h.db.GetFileByID(report.FileID)
+		if err != nil {
+			writeJSONError(w, http.StatusNotFound, "File not found", ErrCodeNotFound)
+			return
+		}
+		if file.Deleted {
+			writeJSONError(w, http.StatusGone, fmt.Sprintf("File for report %d has been deleted, the report can't be regenerated", report.ID), ErrCodeGone)
+			return
+		}
+		if reportData, err = h.regenerateWithTopThreads(r.Context(), report, file, topThreads); err != nil {
+			log.Printf("Error regenerating report %d with %d top threads: %v", report.ID, topThreads, err)
+			if errors.Is(err, errRegenerationBusy) {
+				w.Header().Set("Retry-After", "5")
+				writeJSONError(w, http.StatusServiceUnavailable, "Another report is being regenerated, try again shortly", ErrCodeUnavailable)
+				return
+			}
+			if errors.Is(err, context.DeadlineExceeded) {
+				writeJSONError(w, http.StatusServiceUnavailable, fmt.Sprintf("Regenerating the report timed out after %s; raise report_timeout_seconds and write_timeout_seconds for very large files",
+					h.regenerationTimeout()), ErrCodeUnavailable)
+				return
+			}
+			writeJSONError(w, http.StatusInternalServerError, "Failed to regenerate the report", ErrCodeInternal)
+			return
+		}
+	}
+	if report.Status == "completed" && reportData != "" {
+		// The page loads chart data one chart at a time from /api/reports/{id}/chart/{name}
+		if light, err := reporters.WithoutChartData(reportData); err != nil {
+			log.Printf("Error removing the chart data of report %d, serving it whole: %v", report.ID, err)
+		} else {
+			reportData = light
+		}
+	}
+	if theme != "" && report.Status == "completed" && reportData != "" {
+		themed, err := reporters.ThemeReportData(reportData, theme)
+		if err != nil {
+			log.Printf("Error applying theme %s to report %d: %v", theme, report.ID, err)
+			writeJSONError(w, http.StatusInternalServerError, "Failed to apply report theme", ErrCodeInternal)
+			return
+		}
+		reportData = themed
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":       true,
+		"report_data":   reportData,
+		"generation_ms": report.GenerationMs,
+	}); err != nil {
+		log.Printf("Error encoding JSON response: %v", err)
+	}
+}
+
+// reportETag returns an ETag for a report's content in the given theme, empty for the
+// theme it was generated with, and with topThreads threads charted, 0 for as generated.
+// It is weak because the gzip middleware may change the bytes on the wire without
+// changing the content.
+func reportETag(report *database.Report, theme string, topThreads int) string {
+	variant := theme
+	if topThreads > 0 {
+		variant += "\x00" + strconv.Itoa(topThreads)
+	}
+	sum := sha256.Sum256([]byte(report.Status + "\x00" + variant + "\x00" + report.ReportData))
+	return `W/"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+// etagMatches reports whether an If-None-Match header matches etag, using the weak
+// comparison that conditional GETs call for
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// HandleReportPriority changes the priority of a pending report, e.g. PUT
+// /api/reports/{id}/priority with {"priority": 10}. Pending reports with higher priorities
+// are generated first; every report starts at 0, which keeps the queue first in, first out
+func (h *Handlers) HandleReportPriority(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed", ErrCodeMethodNotAllowed)
+		return
+	}
+
+	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(pathParts) != 4 || pathParts[3] != "priority" {
+		writeJSONError(w, http.StatusBadRequest, "Invalid URL", ErrCodeBadRequest)
+		return
+	}
+
+	reportID, err := strconv.Atoi(pathParts[2])
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid report ID", ErrCodeBadRequest)
+		return
+	}
+
+	var request struct {
+		Priority *int `json:"priority"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Priority == nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid JSON: priority is required", ErrCodeBadRequest)
+		return
+	}
+
+	report, err := h.db.GetReportByID(reportID)
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, "Report not found", ErrCodeNotFound)
+		return
+	}
+	if err := h.db.SetReportPriority(reportID, *request.Priority); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// Only pending reports are waiting in the queue
+			writeJSONError(w, http.StatusConflict, fmt.Sprintf("Report is %s, only pending reports can be prioritized", report.Status), ErrCodeConflict)
+			return
+		}
+		log.Printf("Error setting priority of report %d: %v", reportID, err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to update report priority", ErrCodeInternal)
+		return
+	}
+	report.Priority = *request.Priority
+	report.ReportData = ""
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"report":  report,
+		"message": "Report priority updated",
+	}); err != nil {
+		log.Printf("Error encoding JSON response: %v", err)
+	}
+}
+
+// HandleStarReport stars or unstars a report, e.g. PUT /api/reports/{id}/star with
+// {"starred": true}, so the reports that mattered in an investigation are easy to find again
+func (h *Handlers) HandleStarReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed", ErrCodeMethodNotAllowed)
+		return
+	}
+
+	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(pathParts) != 4 || pathParts[3] != "star" {
+		writeJSONError(w, http.StatusBadRequest, "Invalid URL", ErrCodeBadRequest)
+		return
+	}
+
+	reportID, err := strconv.Atoi(pathParts[2])
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid report ID", ErrCodeBadRequest)
+		return
+	}
+
+	var request struct {
+		Starred *bool `json:"starred"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid JSON", ErrCodeBadRequest)
+		return
+	}
+	if request.Starred == nil {
+		writeJSONError(w, http.StatusBadRequest, "Missing starred field", ErrCodeBadRequest)
+		return
+	}
+
+	report, err := h.db.GetReportByID(reportID)
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, "Report not found", ErrCodeNotFound)
+		return
+	}
+	if err := h.db.SetReportStarred(reportID, *request.Starred); err != nil {
+		log.Printf("Error starring report %d: %v", reportID, err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to update star", ErrCodeInternal)
+		return
+	}
+	report.Starred = *request.Starred
+	report.ReportData = ""
+
+	message := "Report unstarred"
+	if report.Starred {
+		message = "Report starred"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"report":  report,
+		"message": message,
+	}); err != nil {
+		log.Printf("Error encoding JSON response: %v", err)
+	}
+}
+
+// HandleStandaloneReport downloads a completed report as a single HTML file with echarts
+// and the report data and annotations inlined, e.g. GET /api/reports/{id}/standalone.html?theme=dark.
+// The file opens with interactive charts without a network or a DDD server
+func (h *Handlers) HandleStandaloneReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed", ErrCodeMethodNotAllowed)
+		return
+	}
+
+	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(pathParts) != 4 || pathParts[3] != "standalone.html" {
+		writeJSONError(w, http.StatusBadRequest, "Invalid URL", ErrCodeBadRequest)
+		return
+	}
+
+	reportID, err := strconv.Atoi(pathParts[2])
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid report ID", ErrCodeBadRequest)
+		return
+	}
+
+	theme := r.URL.Query().Get("theme")
+	if theme != "" && !reporters.IsKnownReportTheme(theme) {
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("Unknown theme %q, expected one of %s",
+			theme, strings.Join(reporters.ReportThemeNames(), ", ")), ErrCodeBadRequest)
+		return
+	}
+
+	report, err := h.db.GetReportByID(reportID)
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, "Report not found", ErrCodeNotFound)
+		return
+	}
+	if report.Status != "completed" {
+		writeJSONError(w, http.StatusConflict, fmt.Sprintf("Report is %s, only completed reports can be downloaded", report.Status), ErrCodeConflict)
+		return
+	}
+
+	reportData := report.ReportData
+	if theme != "" {
+		if reportData, err = reporters.ThemeReportData(reportData, theme); err != nil {
+			log.Printf("Error applying theme %s to report %d: %v", theme, report.ID, err)
+			writeJSONError(w, http.StatusInternalServerError, "Failed to apply report theme", ErrCodeInternal)
+			return
+		}
+	}
+
+	annotations, err := h.db.GetReportAnnotations(report.ID)
+	if err != nil {
+		log.Printf("Error getting annotations of report %d: %v", report.ID, err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to get annotations", ErrCodeInternal)
+		return
+	}
+	if len(annotations) > 0 {
+		if reportData, err = reporters.WithAnnotations(reportData, annotations); err != nil {
+			log.Printf("Error adding annotations to report %d: %v", report.ID, err)
+			writeJSONError(w, http.StatusInternalServerError, "Failed to add annotations", ErrCodeInternal)
+			return
+		}
+	}
+
+	echartsJS, err := fs.ReadFile(h.assets, "static/js/echarts.min.js")
+	if err != nil {
+		log.Printf("Error reading echarts for standalone report %d: %v", report.ID, err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to load the chart library", ErrCodeInternal)
+		return
+	}
+
+	page, err := reporters.GenerateStandaloneHTML(reportData, echartsJS)
+	if err != nil {
+		log.Printf("Error generating standalone report %d: %v", report.ID, err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to generate standalone report", ErrCodeInternal)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Disposition", contentDisposition(fmt.Sprintf("ddd-%s-report-%d.html", report.ReportType, report.ID)))
+	if _, err := io.WriteString(w, page); err != nil {
+		log.Printf("Error writing standalone report: %v", err)
+	}
+}
+
+// HandleReportChart serves the data of one chart of a report, e.g. GET
+// /api/reports/{id}/chart/cpu. HTML reports that store their chart data apart from the page
+// fetch each chart as it scrolls into view, so a long capture doesn't load every series up front
+func (h *Handlers) HandleReportChart(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed", ErrCodeMethodNotAllowed)
+		return
+	}
+
+	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(pathParts) != 5 || pathParts[3] != "chart" || pathParts[4] == "" {
+		writeJSONError(w, http.StatusBadRequest, "Invalid URL", ErrCodeBadRequest)
+		return
+	}
+
+	reportID, err := strconv.Atoi(pathParts[2])
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid report ID", ErrCodeBadRequest)
+		return
+	}
+	name := pathParts[4]
+
+	report, err := h.db.GetReportByID(reportID)
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, "Report not found", ErrCodeNotFound)
+		return
+	}
+	if report.Status != "completed" {
+		writeJSONError(w, http.StatusConflict, fmt.Sprintf("Report is %s, charts are only available for completed reports", report.Status), ErrCodeConflict)
+		return
+	}
+
+	var stored struct {
+		ChartData map[string]json.RawMessage `json:"chart_data"`
+	}
+	if err := json.Unmarshal([]byte(report.ReportData), &stored); err != nil {
+		log.Printf("Error parsing report %d for chart %s: %v", report.ID, name, err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to read the report", ErrCodeInternal)
+		return
+	}
+	chart, ok := stored.ChartData[name]
+	if !ok {
+		writeJSONError(w, http.StatusNotFound, fmt.Sprintf("Report %d has no chart %q", report.ID, name), ErrCodeNotFound)
+		return
+	}
+
+	// Like the report content, charts are revalidated since trimming the report drops them
+	sum := sha256.Sum256(chart)
+	etag := `W/"` + hex.EncodeToString(sum[:]) + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"chart":   chart,
+	}); err != nil {
+		log.Printf("Error encoding JSON response: %v", err)
+	}
+}
+
+// cancelledPendingMessage is the error message stored on reports cancelled while queued
+const cancelledPendingMessage = "Cancelled before generation started"
+
+// HandleCancelReport cancels a report, e.g. POST /api/reports/{id}/cancel. Pending reports
+// are marked cancelled straight away; running reports are stopped by the report worker,
+// which marks them cancelled once the reporter gives up
+func (h *Handlers) HandleCancelReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed", ErrCodeMethodNotAllowed)
+		return
+	}
+
+	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(pathParts) != 4 || pathParts[3] != "cancel" {
+		writeJSONError(w, http.StatusBadRequest, "Invalid URL", ErrCodeBadRequest)
+		return
+	}
+
+	reportID, err := strconv.Atoi(pathParts[2])
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid report ID", ErrCodeBadRequest)
+		return
+	}
+
+	report, err := h.db.GetReportByID(reportID)
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, "Report not found", ErrCodeNotFound)
+		return
+	}
+
+	// A pending report is cancelled in the database unless the worker has just started it
+	if report.Status == "pending" {
+		err := h.db.CancelPendingReport(reportID, cancelledPendingMessage)
+		if err == nil {
+			h.events.PublishStatus(reportID, "cancelled", cancelledPendingMessage)
+			report.Status = "cancelled"
+			report.ErrorMessage = cancelledPendingMessage
+			writeCancelResponse(w, report, "Report cancelled")
+			return
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Error cancelling report %d: %v", reportID, err)
+			writeJSONError(w, http.StatusInternalServerError, "Failed to cancel report", ErrCodeInternal)
+			return
+		}
+	} else if report.Status != "running" {
+		writeJSONError(w, http.StatusConflict, fmt.Sprintf("Report is %s, only pending or running reports can be cancelled", report.Status), ErrCodeConflict)
+		return
+	}
+
+	// The worker marks the report cancelled once the reporter stops
+	if h.reportWorker == nil || !h.reportWorker.CancelReport(reportID) {
+		writeJSONError(w, http.StatusConflict, "Report is not being generated and cannot be cancelled", ErrCodeConflict)
+		return
+	}
+	writeCancelResponse(w, report, "Report cancellation requested")
+}
+
+// writeCancelResponse writes the response to a successful cancel request
+func writeCancelResponse(w http.ResponseWriter, report *database.Report, message string) {
+	report.ReportData = ""
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"report":  report,
+		"message": message,
+	}); err != nil {
+		log.Printf("Error encoding JSON response: %v", err)
+	}
+}
+
+// HandleReportLogs returns the logs written while generating a report, e.g.
+// /api/reports/{id}/logs?level=WARN&search=threshold
+func (h *Handlers) HandleReportLogs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed", ErrCodeMethodNotAllowed)
+		return
+	}
+
+	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(pathParts) != 4 || pathParts[3] != "logs" {
+		writeJSONError(w, http.StatusBadRequest, "Invalid URL", ErrCodeBadRequest)
+		return
+	}
+
+	reportID, err := strconv.Atoi(pathParts[2])
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid report ID", ErrCodeBadRequest)
+		return
+	}
+
+	if _, err := h.db.GetReportByID(reportID); err != nil {
+		writeJSONError(w, http.StatusNotFound, "Report not found", ErrCodeNotFound)
+		return
+	}
+
+	query := r.URL.Query()
+	logs, err := h.db.GetReportLogs(reportID, query.Get("level"), query.Get("search"))
+	if err != nil {
+		log.Printf("Error getting logs for report %d: %v", reportID, err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to get report logs", ErrCodeInternal)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":   true,
+		"report_id": reportID,
+		"logs":      logs,
+	}); err != nil {
+		log.Printf("Error encoding JSON response: %v", err)
+	}
+}
+
+// reportEventsHeartbeat is how often the events stream re-checks the report status and
+// sends a keep-alive comment, so a missed event can't leave the stream open forever
+var reportEventsHeartbeat = 15 * time.Second
+
+// HandleReportEvents streams status transitions and progress for a report as
+// Server-Sent Events. The current status is sent first and the stream ends with a
+// "done" event once the report is completed or failed.
+func (h *Handlers) HandleReportEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed", ErrCodeMethodNotAllowed)
+		return
+	}
+	if h.events == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, "Live report updates are not available", ErrCodeUnavailable)
+		return
+	}
+
+	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(pathParts) != 4 || pathParts[3] != "events" {
+		writeJSONError(w, http.StatusBadRequest, "Invalid URL", ErrCodeBadRequest)
+		return
+	}
+
+	reportID, err := strconv.Atoi(pathParts[2])
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid report ID", ErrCodeBadRequest)
+		return
+	}
+
+	// Subscribe before reading the current status so no transition is missed in between
+	updates, unsubscribe := h.events.Subscribe(reportID)
+	defer unsubscribe()
+
+	report, err := h.db.GetReportByID(reportID)
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, "Report not found", ErrCodeNotFound)
+		return
+	}
+
+	// Streams outlive the server write timeout, so lift it for this response
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Printf("Error clearing write deadline for report %d events: %v", reportID, err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	send := func(event events.ReportEvent) bool {
+		data, err := json.Marshal(event)
+		if err != nil {
+			log.Printf("Error encoding report event: %v", err)
+			return false
+		}
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+			return false
+		}
+		return rc.Flush() == nil
+	}
+	finish := func() {
+		if _, err := fmt.Fprint(w, "event: done\ndata: {}\n\n"); err == nil {
+			_ = rc.Flush()
+		}
+	}
+
+	if !send(events.ReportEvent{Type: events.TypeStatus, ReportID: reportID, Status: report.Status,
+		Message: report.ErrorMessage, Time: time.Now()}) {
+		return
+	}
+	if events.IsTerminalStatus(report.Status) {
+		finish()
+		return
+	}
+
+	heartbeat := time.NewTicker(reportEventsHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-updates:
+			if !ok {
+				return
+			}
+			if !send(event) {
+				return
+			}
+			if event.Type == events.TypeStatus && events.IsTerminalStatus(event.Status) {
+				finish()
+				return
+			}
+		case <-heartbeat.C:
+			current, err := h.db.GetReportByID(reportID)
+			if err != nil {
+				// The report was deleted while being watched
+				finish()
+				return
+			}
+			if events.IsTerminalStatus(current.Status) {
+				send(events.ReportEvent{Type: events.TypeStatus, ReportID: reportID, Status: current.Status,
+					Message: current.ErrorMessage, Time: time.Now()})
+				finish()
+				return
+			}
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil || rc.Flush() != nil {
+				return
+			}
+		}
+	}
+}
+
+// Limits for the number of results returned by report content search
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// HandleSearchReports searches the content of completed reports, e.g. /api/search?q=nvme0n1.
+// Every term must match; snippets are HTML-escaped with matches wrapped in <mark>.
+func (h *Handlers) HandleSearchReports(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed", ErrCodeMethodNotAllowed)
+		return
+	}
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		writeJSONError(w, http.StatusBadRequest, "Missing q parameter", ErrCodeBadRequest)
+		return
+	}
+
+	limit := defaultSearchLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 {
+			writeJSONError(w, http.StatusBadRequest, "Invalid limit parameter", ErrCodeBadRequest)
+			return
+		}
+		limit = min(parsed, maxSearchLimit)
+	}
+
+	results, err := h.db.SearchReports(query, limit)
+	if err != nil {
+		log.Printf("Error searching reports for %q: %v", query, err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to search reports", ErrCodeInternal)
+		return
+	}
+
+	for _, result := range results {
+		snippet := html.EscapeString(result.Snippet)
+		snippet = strings.ReplaceAll(snippet, database.SnippetMatchStart, "<mark>")
+		result.Snippet = strings.ReplaceAll(snippet, database.SnippetMatchEnd, "</mark>")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"query":   query,
+		"results": results,
+	}); err != nil {
+		log.Printf("Error encoding JSON response: %v", err)
+	}
+}
+
+// maxAggregateReports limits how many reports can be combined into a single aggregate page
+const maxAggregateReports = 50
+
+// HandleAggregateReport renders a combined HTML page for several completed reports of the same type,
+// e.g. /api/reports/aggregate?ids=1,2,3
+func (h *Handlers) HandleAggregateReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed", ErrCodeMethodNotAllowed)
+		return
+	}
+
+	idsParam := r.URL.Query().Get("ids")
+	if idsParam == "" {
+		writeJSONError(w, http.StatusBadRequest, "Missing ids parameter", ErrCodeBadRequest)
+		return
+	}
+
+	var reportIDs []int
+	seen := make(map[int]bool)
+	for _, idStr := range strings.Split(idsParam, ",") {
+		idStr = strings.TrimSpace(idStr)
+		if idStr == "" {
+			continue
+		}
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("Invalid report ID: %s", idStr), ErrCodeBadRequest)
+			return
+		}
+		if !seen[id] {
+			seen[id] = true
+			reportIDs = append(reportIDs, id)
+		}
+	}
+
+	if len(reportIDs) == 0 {
+		writeJSONError(w, http.StatusBadRequest, "Missing ids parameter", ErrCodeBadRequest)
+		return
+	}
+	if len(reportIDs) > maxAggregateReports {
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("Too many reports, at most %d can be aggregated", maxAggregateReports), ErrCodeBadRequest)
+		return
+	}
+
+	// Validate every report before doing any parsing work
+	var reportType string
+	reports := make([]*database.Report, 0, len(reportIDs))
+	for _, reportID := range reportIDs {
+		report, err := h.db.GetReportByID(reportID)
+		if err != nil {
+			writeJSONError(w, http.StatusNotFound, fmt.Sprintf("Report %d not found", reportID), ErrCodeNotFound)
+			return
+		}
+
+		if report.Status != "completed" {
+			writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("Report %d is not completed", reportID), ErrCodeBadRequest)
+			return
+		}
+
+		if reportType == "" {
+			reportType = report.ReportType
+		} else if report.ReportType != reportType {
+			writeJSONError(w, http.StatusBadRequest, "Cannot aggregate reports of different types", ErrCodeBadRequest)
+			return
+		}
+
+		reports = append(reports, report)
+	}
+
+	if reportType != detector.FileTypeIOStat {
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("Aggregate reports are not supported for report type %s", reportType), ErrCodeBadRequest)
+		return
+	}
+
+	loc, err := h.settings.Location()
+	if err != nil {
+		log.Printf("Error loading timezone, using UTC: %v", err)
+		loc = time.UTC
+	}
+
+	var datas []*reporters.IOStatReportData
+	var labels []string
+	for _, report := range reports {
+		file, err := h.db.GetFileByID(report.FileID)
+		if err != nil {
+			writeJSONError(w, http.StatusNotFound, fmt.Sprintf("File for report %d not found", report.ID), ErrCodeNotFound)
+			return
+		}
+		if file.Deleted {
+			writeJSONError(w, http.StatusGone, fmt.Sprintf("File for report %d has been deleted", report.ID), ErrCodeGone)
+			return
+		}
+
+		data, err := reporters.ParseIOStatFile(file.FilePath, loc)
+		if err != nil {
+			log.Printf("Error parsing file %d for aggregate report: %v", file.ID, err)
+			writeJSONError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to parse file for report %d", report.ID), ErrCodeInternal)
+			return
+		}
+
+		datas = append(datas, data)
+		labels = append(labels, fmt.Sprintf("%s (report %d)", file.OriginalName, report.ID))
+	}
+
+	aggregateHTML, err := reporters.GenerateIOStatAggregateHTML(datas, labels)
+	if err != nil {
+		log.Printf("Error generating aggregate report: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to generate aggregate report", ErrCodeInternal)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	if _, err := w.Write([]byte(aggregateHTML)); err != nil {
+		log.Printf("Error writing HTML response: %v", err)
+	}
+}
+
+const (
+	defaultThroughputWindow   = time.Hour
+	defaultThroughputInterval = time.Minute
+	maxThroughputBuckets      = 1440
+)
+
+// HandleWorkerThroughput returns the reports queued, completed and failed per interval so the
+// UI can chart reports per minute, e.g. /api/workers/throughput?window=6h&interval=5m
+func (h *Handlers) HandleWorkerThroughput(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed", ErrCodeMethodNotAllowed)
+		return
+	}
+
+	window := defaultThroughputWindow
+	if windowStr := r.URL.Query().Get("window"); windowStr != "" {
+		parsed, err := time.ParseDuration(windowStr)
+		if err != nil || parsed <= 0 {
+			writeJSONError(w, http.StatusBadRequest, "Invalid window parameter", ErrCodeBadRequest)
+			return
+		}
+		window = parsed
+	}
+
+	interval := defaultThroughputInterval
+	if intervalStr := r.URL.Query().Get("interval"); intervalStr != "" {
+		parsed, err := time.ParseDuration(intervalStr)
+		if err != nil || parsed <= 0 {
+			writeJSONError(w, http.StatusBadRequest, "Invalid interval parameter", ErrCodeBadRequest)
+			return
+		}
+		interval = parsed
+	}
+
+	if window/interval > maxThroughputBuckets {
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("Window covers more than %d intervals", maxThroughputBuckets), ErrCodeBadRequest)
+		return
+	}
+
+	// Align buckets to the interval so repeated polls line up
+	since := time.Now().Add(-window).Truncate(interval)
+	buckets, err := h.db.GetReportThroughput(since, interval)
+	if err != nil {
+		log.Printf("Error getting report throughput: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to get report throughput", ErrCodeInternal)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":          true,
+		"since":            since,
+		"interval_seconds": interval.Seconds(),
+		"buckets":          buckets,
+	}); err != nil {
+		log.Printf("Error encoding JSON response: %v", err)
+	}
+}
+
+// HandleStats returns the file and report totals summarized on the dashboard
+func (h *Handlers) HandleStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed", ErrCodeMethodNotAllowed)
+		return
+	}
+
+	stats, err := h.db.GetStats()
+	if err != nil {
+		log.Printf("Error getting stats: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to get stats", ErrCodeInternal)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"stats":   stats,
+	}); err != nil {
+		log.Printf("Error encoding JSON response: %v", err)
+	}
+}
+
+// HandleDiskUsage returns disk usage information for uploads and database directories
+func (h *Handlers) HandleDiskUsage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed", ErrCodeMethodNotAllowed)
+		return
+	}
+
+	uploadsStats, uploadsFS, err := statDisk(filepath.Clean(h.cfg.UploadsDir))
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Failed to get uploads directory stats", ErrCodeInternal)
+		return
+	}
+	dbStats, dbFS, err := statDisk(filepath.Clean(filepath.Dir(h.cfg.DBPath)))
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Failed to get database directory stats", ErrCodeInternal)
+		return
+	}
+
+	// Get settings from database
+	maxDiskUsage, err := h.settings.MaxDiskUsage()
+	if err != nil {
+		log.Printf("Error getting max disk usage setting: %v", err)
+		maxDiskUsage = h.cfg.MaxDiskUsage // fallback
+	}
+
+	fileRetentionDays, err := h.settings.FileRetentionDays()
+	if err != nil {
+		log.Printf("Error getting file retention days setting: %v", err)
+		fileRetentionDays = h.cfg.FileRetentionDays // fallback
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(DiskUsageResponse{
+		Success:           true,
+		Uploads:           uploadsStats,
+		Database:          dbStats,
+		SameFilesystem:    uploadsFS == dbFS,
+		MaxDiskUsage:      maxDiskUsage,
+		FileRetentionDays: fileRetentionDays,
+	}); err != nil {
+		log.Printf("Error encoding disk usage JSON response: %v", err)
+	}
+}
+
+// statDisk returns the space of the filesystem path is on and the ID of that filesystem
+func statDisk(path string) (DiskStats, syscall.Fsid, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return DiskStats{}, syscall.Fsid{}, err
+	}
+	// Convert Bsize to uint64 - gosec G115 is acceptable here as Bsize represents block size
+	blockSize := uint64(stat.Bsize) // #nosec G115
+
+	stats := DiskStats{
+		Path:      path,
+		Total:     uint64(stat.Blocks) * blockSize,
+		Free:      uint64(stat.Bfree) * blockSize,
+		Available: uint64(stat.Bavail) * blockSize,
+	}
+	stats.Used = stats.Total - stats.Free
+	stats.Percent = float64(stats.Used) / float64(stats.Total) * 100
+	return stats, stat.Fsid, nil
+}
+
+// HandleHealth reports whether the database is reachable and the uploads directory is writable.
+// It returns 200 when both checks pass and 503 otherwise, for use as a liveness/readiness probe.
+func (h *Handlers) HandleHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed", ErrCodeMethodNotAllowed)
+		return
+	}
+
+	status := "ok"
+	dbStatus := "ok"
+	uploadsStatus := "ok"
+
+	if err := h.db.Ping(); err != nil {
+		log.Printf("Health check: database ping failed: %v", err)
+		status = "unavailable"
+		dbStatus = "unreachable"
+	}
+
+	if err := checkDirWritable(h.cfg.UploadsDir); err != nil {
+		log.Printf("Health check: uploads directory not writable: %v", err)
+		status = "unavailable"
+		uploadsStatus = "not writable"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if status != "ok" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if r.Method == http.MethodHead {
+		return
+	}
+	if err := json.NewEncoder(w).Encode(HealthResponse{
+		Status:  status,
+		DB:      dbStatus,
+		Uploads: uploadsStatus,
+	}); err != nil {
+		log.Printf("Error encoding health JSON response: %v", err)
+	}
+}
+
+// checkDirWritable verifies a directory is writable by creating and removing a temporary file
+func checkDirWritable(dir string) error {
+	f, err := os.CreateTemp(dir, ".healthz-*")
+	if err != nil {
+		return err
+	}
+	name := f.Name()
+	if err := f.Close(); err != nil {
+		_ = os.Remove(name)
+		return err
+	}
+	return os.Remove(name)
+}
+
+// HandleRedetectFileType re-detects the file type for an existing file
+func (h *Handlers) HandleRedetectFileType(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed", ErrCodeMethodNotAllowed)
+		return
+	}
 
 	// Extract file ID from URL path
 	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
 	if len(pathParts) < 4 { // expecting /api/files/{id}/redetect
-		http.Error(w, "Invalid file ID in path", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "Invalid file ID in path", ErrCodeBadRequest)
 		return
 	}
 
 	fileIDStr := pathParts[2]
 	fileID, err := strconv.Atoi(fileIDStr)
 	if err != nil {
-		http.Error(w, "Invalid file ID", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "Invalid file ID", ErrCodeBadRequest)
 		return
 	}
 
 	// Get file info
 	file, err := h.db.GetFileByID(fileID)
 	if err != nil {
-		http.Error(w, "File not found", http.StatusNotFound)
+		writeJSONError(w, http.StatusNotFound, "File not found", ErrCodeNotFound)
 		return
 	}
 
-	// Read file content from disk
-	content, err := os.ReadFile(file.FilePath)
+	// Re-detect file type from the start of the file on disk
+	diskFile, err := os.Open(file.FilePath)
 	if err != nil {
-		http.Error(w, "Failed to read file", http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to read file", ErrCodeInternal)
 		return
 	}
-
-	// Re-detect file type
-	newFileType := detector.DetectFileType(file.OriginalName, content)
+	detection, err := detector.DetectFileTypeFromReader(file.OriginalName, diskFile)
+	if closeErr := diskFile.Close(); closeErr != nil {
+		log.Printf("Error closing file %d: %v", fileID, closeErr)
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Failed to read file", ErrCodeInternal)
+		return
+	}
+	newFileType := detection.FileType
 
 	// Update the file type in database
 	if err := h.db.UpdateFileFileType(fileID, newFileType); err != nil {
-		http.Error(w, "Failed to update file type", http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to update file type", ErrCodeInternal)
+		return
+	}
+	if err := h.db.UpdateFileDetection(fileID, detection.Confidence, detection.Signal); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Failed to update file type", ErrCodeInternal)
 		return
 	}
 
 	// Get updated file record to return
 	updatedFile, err := h.db.GetFileByID(fileID)
 	if err != nil {
-		http.Error(w, "Failed to retrieve updated file record", http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to retrieve updated file record", ErrCodeInternal)
 		return
 	}
 
 	// Automatically create a report for the updated file type if we know how to handle it
 	if h.shouldAutoGenerateReport(newFileType) {
-		report := &database.Report{
-			FileID:      updatedFile.ID,
-			ReportType:  newFileType,
-			Status:      "pending",
-			CreatedTime: time.Now(),
-			DDDVersion:  DDDVersion,
-		}
-
-		if err := h.db.InsertReport(report); err != nil {
+		if _, _, err := h.queueReport(updatedFile.ID, newFileType); err != nil {
 			// Log error but don't fail the re-detect
 			log.Printf("Failed to create automatic report for file %d after re-detection: %v", updatedFile.ID, err)
 		}
@@ -829,147 +2529,397 @@ func (h *Handlers) HandleRedetectFileType(w http.ResponseWriter, r *http.Request
 	}
 }
 
-// serveReportPage serves the report viewer HTML page
-func (h *Handlers) serveReportPage(w http.ResponseWriter, report *database.Report, file *database.File) {
-	html := `<!DOCTYPE html>
-<html lang="en">
-<head>
-    <meta charset="UTF-8">
-    <meta name="viewport" content="width=device-width, initial-scale=1.0">
-    <title>DDD Report: ` + report.ReportType + ` - ` + file.OriginalName + `</title>
-    <link rel="stylesheet" href="https://fonts.googleapis.com/css?family=Roboto:300,400,500,700&display=swap">
-    <link rel="stylesheet" href="https://fonts.googleapis.com/icon?family=Material+Icons">
-    <link rel="stylesheet" href="/static/css/material.min.css">
-    <link rel="stylesheet" href="/static/css/styles.css">
-    <style>
-        .report-page {
-            max-width: 1200px;
-            margin: 0 auto;
-            padding: 20px;
-        }
-        .report-header {
-            background: white;
-            padding: 24px;
-            border-radius: 4px;
-            box-shadow: 0 2px 4px rgba(0,0,0,0.1);
-            margin-bottom: 20px;
-        }
-        .report-content-page {
-            background: white;
-            padding: 24px;
-            border-radius: 4px;
-            box-shadow: 0 2px 4px rgba(0,0,0,0.1);
-            min-height: 400px;
-        }
-        .back-link {
-            margin-bottom: 20px;
-        }
-    </style>
-</head>
-<body>
-    <div class="report-page">
-        <div class="back-link">
-            <a href="/" class="mdl-button mdl-js-button mdl-button--icon">
-                <i class="material-icons">arrow_back</i>
-            </a>
-            <a href="/" class="mdl-button mdl-js-button">Back to Files</a>
-        </div>
-
-        <div class="report-header">
-            <h1>` + report.ReportType + ` Report</h1>
-            <p><strong>File:</strong> ` + file.OriginalName + `</p>
-            <p><strong>Status:</strong> <span class="status-badge status-` + report.Status + `">` + report.Status + `</span></p>
-            <p><strong>Created:</strong> ` + report.CreatedTime.Format("2006-01-02 15:04:05") + `</p>
-            <p><strong>DDD Version:</strong> ` + report.DDDVersion + `</p>
-            ` + func() string {
-		if report.CompletedTime != nil {
-			return `<p><strong>Completed:</strong> ` + report.CompletedTime.Format("2006-01-02 15:04:05") + `</p>`
-		}
-		return ""
-	}() + `
-            ` + func() string {
-		if report.ErrorMessage != "" {
-			return `<p><strong>Error:</strong> <span style="color: #d32f2f;">` + report.ErrorMessage + `</span></p>`
-		}
-		return ""
-	}() + `
-        </div>
-
-        <div class="report-content-page" id="report-content">
-            ` + func() string {
-		if report.Status == "completed" {
-			return `<div class="loading">Loading report content...</div>`
+// HandleSetFileType manually overrides the file type for an existing file, for when the
+// content is ambiguous and re-detection keeps choosing the wrong type
+func (h *Handlers) HandleSetFileType(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed", ErrCodeMethodNotAllowed)
+		return
+	}
+
+	// Extract file ID from URL path
+	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(pathParts) < 4 { // expecting /api/files/{id}/type
+		writeJSONError(w, http.StatusBadRequest, "Invalid file ID in path", ErrCodeBadRequest)
+		return
+	}
+
+	fileID, err := strconv.Atoi(pathParts[2])
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid file ID", ErrCodeBadRequest)
+		return
+	}
+
+	var request struct {
+		FileType string `json:"file_type"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid JSON", ErrCodeBadRequest)
+		return
+	}
+	if !detector.IsKnownFileType(request.FileType) {
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("Unknown file type: %s", request.FileType), ErrCodeBadRequest)
+		return
+	}
+
+	file, err := h.db.GetFileByID(fileID)
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, "File not found", ErrCodeNotFound)
+		return
+	}
+	if file.Deleted {
+		writeJSONError(w, http.StatusGone, "File has been deleted", ErrCodeGone)
+		return
+	}
+
+	if err := h.db.UpdateFileFileType(fileID, request.FileType); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Failed to update file type", ErrCodeInternal)
+		return
+	}
+	// A type chosen by a person is treated as certain
+	if err := h.db.UpdateFileDetection(fileID, 1.0, detector.SignalManual); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Failed to update file type", ErrCodeInternal)
+		return
+	}
+
+	updatedFile, err := h.db.GetFileByID(fileID)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Failed to retrieve updated file record", ErrCodeInternal)
+		return
+	}
+
+	// Queue a fresh report for the new file type if we know how to handle it
+	if h.shouldAutoGenerateReport(request.FileType) {
+		if _, _, err := h.queueReport(updatedFile.ID, request.FileType); err != nil {
+			// Log error but don't fail the type change
+			log.Printf("Failed to create report for file %d after manual type change: %v", updatedFile.ID, err)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"file":    updatedFile,
+		"message": "File type updated successfully",
+	}); err != nil {
+		log.Printf("Error encoding JSON response: %v", err)
+	}
+}
+
+// HandlePinFile pins or unpins a file. Pinned files are kept forever: both age-based and
+// disk-pressure cleanup skip them until they are unpinned
+func (h *Handlers) HandlePinFile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed", ErrCodeMethodNotAllowed)
+		return
+	}
+
+	// Extract file ID from URL path
+	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(pathParts) < 4 { // expecting /api/files/{id}/pin
+		writeJSONError(w, http.StatusBadRequest, "Invalid file ID in path", ErrCodeBadRequest)
+		return
+	}
+
+	fileID, err := strconv.Atoi(pathParts[2])
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid file ID", ErrCodeBadRequest)
+		return
+	}
+
+	var request struct {
+		Pinned *bool `json:"pinned"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid JSON", ErrCodeBadRequest)
+		return
+	}
+	if request.Pinned == nil {
+		writeJSONError(w, http.StatusBadRequest, "Missing pinned field", ErrCodeBadRequest)
+		return
+	}
+
+	file, err := h.db.GetFileByID(fileID)
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, "File not found", ErrCodeNotFound)
+		return
+	}
+	if file.Deleted {
+		writeJSONError(w, http.StatusGone, "File has been deleted", ErrCodeGone)
+		return
+	}
+
+	if err := h.db.SetFilePinned(fileID, *request.Pinned); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Failed to update pin", ErrCodeInternal)
+		return
+	}
+	file.Pinned = *request.Pinned
+
+	message := "File unpinned"
+	if file.Pinned {
+		message = "File pinned"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"file":    file,
+		"message": message,
+	}); err != nil {
+		log.Printf("Error encoding JSON response: %v", err)
+	}
+}
+
+// HandleFileReportHistory lists every report of a file oldest first, e.g. GET
+// /api/files/{id}/history, so the UI can show how the file's analysis evolved across
+// redetections and regenerations. Unlike the paged listing it includes everything: failed,
+// cancelled and purged reports, and reports a newer one of the same type superseded
+func (h *Handlers) HandleFileReportHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed", ErrCodeMethodNotAllowed)
+		return
+	}
+
+	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(pathParts) != 4 || pathParts[3] != "history" { // expecting /api/files/{id}/history
+		writeJSONError(w, http.StatusBadRequest, "Invalid URL", ErrCodeBadRequest)
+		return
+	}
+
+	fileID, err := strconv.Atoi(pathParts[2])
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid file ID", ErrCodeBadRequest)
+		return
+	}
+
+	if _, err := h.db.GetFileByID(fileID); err != nil {
+		writeJSONError(w, http.StatusNotFound, "File not found", ErrCodeNotFound)
+		return
+	}
+
+	reports, err := h.db.GetReportsByFileID(fileID)
+	if err != nil {
+		log.Printf("Error getting report history of file %d: %v", fileID, err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to get reports", ErrCodeInternal)
+		return
+	}
+
+	// Reports come newest first, so the first of each type seen is the one superseding the rest
+	history := make([]ReportHistoryEntry, len(reports))
+	latest := make(map[string]int)
+	for i, report := range reports {
+		entry := ReportHistoryEntry{Report: report, Stale: isStaleVersion(report.DDDVersion)}
+		if newer, ok := latest[report.ReportType]; ok {
+			entry.SupersededBy = newer
 		} else {
-			return `<div class="error-message">Report is not completed yet.</div>`
-		}
-	}() + `
-        </div>
-    </div>
-
-    <script src="/static/js/material.min.js"></script>
-    <script>
-        // Load report content if completed
-        if ('` + report.Status + `' === 'completed') {
-            fetch('/api/reports/content/` + strconv.Itoa(report.ID) + `')
-                .then(response => response.json())
-                .then(data => {
-                    if (data.success) {
-                        document.getElementById('report-content').innerHTML = renderReportData(data.report_data);
-                    } else {
-                        document.getElementById('report-content').innerHTML = '<div class="error-message">Failed to load report content</div>';
-                    }
-                })
-                .catch(error => {
-                    document.getElementById('report-content').innerHTML = '<div class="error-message">Error loading report: ' + error.message + '</div>';
-                });
-        }
-
-        function renderReportData(reportDataStr) {
-            try {
-                const reportData = JSON.parse(reportDataStr);
-
-                // If there's an HTML report, serve it as a complete page
-                if (reportData.html_report) {
-                    // Replace the entire page with the HTML report
-                    document.open();
-                    document.write(reportData.html_report);
-                    document.close();
-                    return; // Don't return anything since we've replaced the page
-                }
-
-                // Fallback to summary and analysis for other report types
-                return '<div class="report-content">' +
-                    '<h4>Report Summary</h4>' +
-                    '<p>' + (reportData.summary || 'No summary available') + '</p>' +
-                    '<h4>Analysis</h4>' +
-                    '<p>' + (reportData.analysis || 'No analysis available') + '</p>' +
-                    '</div>';
-            } catch (error) {
-                return '<pre class="report-raw-data">' + escapeHtml(reportDataStr) + '</pre>';
-            }
-        }
-
-        function escapeHtml(text) {
-            const div = document.createElement('div');
-            div.textContent = text;
-            return div.innerHTML;
-        }
-    </script>
-</body>
-</html>`
+			latest[report.ReportType] = report.ID
+		}
+		history[len(reports)-1-i] = entry
+	}
 
-	w.Header().Set("Content-Type", "text/html")
-	if _, err := w.Write([]byte(html)); err != nil {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(FileReportHistoryResponse{
+		Success: true,
+		FileID:  fileID,
+		Reports: history,
+	}); err != nil {
+		log.Printf("Error encoding JSON response: %v", err)
+	}
+}
+
+// HandleReprocessByType re-queues every completed report of the type given in the "type"
+// query parameter that was generated by a different DDD version, so reporter improvements
+// can be backfilled without re-uploading files. Files that already have a report from the
+// current version, or whose content is no longer on disk, are skipped.
+func (h *Handlers) HandleReprocessByType(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed", ErrCodeMethodNotAllowed)
+		return
+	}
+
+	reportType := r.URL.Query().Get("type")
+	if _, ok := reporters.Lookup(reportType); !ok {
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("Unknown report type: %s", reportType), ErrCodeBadRequest)
+		return
+	}
+
+	reports, err := h.db.GetCompletedReportsByType(reportType)
+	if err != nil {
+		log.Printf("Error getting completed %s reports: %v", reportType, err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to get reports", ErrCodeInternal)
+		return
+	}
+
+	// A file only needs one fresh report, however many stale ones it has
+	current := make(map[int]bool)
+	var staleFileIDs []int
+	for _, report := range reports {
+		if report.DDDVersion == DDDVersion {
+			current[report.FileID] = true
+		} else if !slices.Contains(staleFileIDs, report.FileID) {
+			staleFileIDs = append(staleFileIDs, report.FileID)
+		}
+	}
+
+	queued, skipped := 0, 0
+	for _, fileID := range staleFileIDs {
+		if current[fileID] {
+			continue
+		}
+		file, err := h.db.GetFileByID(fileID)
+		if err != nil {
+			log.Printf("Skipping reprocess of %s report for file %d: %v", reportType, fileID, err)
+			skipped++
+			continue
+		}
+		if _, err := os.Stat(file.FilePath); file.Deleted || err != nil {
+			log.Printf("Skipping reprocess of %s report for file %d: content is no longer on disk", reportType, fileID)
+			skipped++
+			continue
+		}
+		_, created, err := h.queueReport(fileID, reportType)
+		if err != nil {
+			log.Printf("Error queueing %s report for file %d: %v", reportType, fileID, err)
+			writeJSONError(w, http.StatusInternalServerError, "Failed to create report", ErrCodeInternal)
+			return
+		}
+		if created {
+			queued++
+		}
+	}
+	log.Printf("Queued %d %s reports for reprocessing, skipped %d files", queued, reportType, skipped)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":     true,
+		"report_type": reportType,
+		"queued":      queued,
+		"skipped":     skipped,
+		"message":     fmt.Sprintf("Queued %d %s reports for reprocessing", queued, reportType),
+	}); err != nil {
+		log.Printf("Error encoding JSON response: %v", err)
+	}
+}
+
+// HandleBackup writes a point-in-time copy of the database to the backup directory and
+// prunes the oldest backups beyond the retention count, returning the new backup's path
+// and size. Running reports are not paused while it is taken.
+func (h *Handlers) HandleBackup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed", ErrCodeMethodNotAllowed)
+		return
+	}
+
+	backup, err := h.db.Backup(h.cfg.BackupDir, h.cfg.BackupRetention)
+	if err != nil {
+		log.Printf("Error backing up database: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to back up database", ErrCodeInternal)
+		return
+	}
+	log.Printf("Backed up database to %s (%d bytes)", backup.Path, backup.Size)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"backup":  backup,
+		"message": fmt.Sprintf("Backed up database to %s", backup.Path),
+	}); err != nil {
+		log.Printf("Error encoding JSON response: %v", err)
+	}
+}
+
+// reportPageData is passed to the report page template
+type reportPageData struct {
+	Report         *database.Report
+	File           *database.File
+	ContentURL     string        // Where the page fetches the report data from once it is completed
+	CurrentVersion string        // DDD version now running, shown next to the version that generated the report
+	Stale          bool          // The report was generated by an older DDD version
+	GenerationTime time.Duration // How long the worker took to generate the report, 0 when unknown
+}
+
+// serveReportPage renders the report viewer page from the report template
+func (h *Handlers) serveReportPage(w http.ResponseWriter, r *http.Request, report *database.Report, file *database.File) {
+	// Parsed per request so a live web directory picks up template edits
+	tmpl, err := template.ParseFS(h.assets, "templates/report.html")
+	if err != nil {
+		log.Printf("Error loading report page template: %v", err)
+		h.writeError(w, r, http.StatusInternalServerError, "The report page could not be loaded.")
+		return
+	}
+
+	data := reportPageData{
+		Report:         report,
+		File:           file,
+		ContentURL:     "/api/reports/content/" + strconv.Itoa(report.ID),
+		CurrentVersion: DDDVersion,
+		Stale:          isStaleVersion(report.DDDVersion),
+		GenerationTime: time.Duration(report.GenerationMs) * time.Millisecond,
+	}
+	// ?theme= views the report in another theme; unknown names show the stored theme.
+	// ?top_threads= views a ttop or top report with another number of threads in its CPU
+	// chart; invalid counts show the stored chart
+	query := url.Values{}
+	if theme := r.URL.Query().Get("theme"); reporters.IsKnownReportTheme(theme) {
+		query.Set("theme", theme)
+	}
+	if topThreads, err := strconv.Atoi(r.URL.Query().Get("top_threads")); err == nil && hasTopThreadsChart(report.ReportType) &&
+		topThreads >= 1 && topThreads <= reporters.MaxTTopTopThreads {
+		query.Set("top_threads", strconv.Itoa(topThreads))
+	}
+	if len(query) > 0 {
+		data.ContentURL += "?" + query.Encode()
+	}
+
+	var page bytes.Buffer
+	if err := tmpl.Execute(&page, data); err != nil {
+		log.Printf("Error rendering report page: %v", err)
+		h.writeError(w, r, http.StatusInternalServerError, "The report page could not be rendered.")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if _, err := page.WriteTo(w); err != nil {
 		log.Printf("Error writing HTML response: %v", err)
 	}
 }
 
 // shouldAutoGenerateReport determines if we should automatically generate a report for a file type
 func (h *Handlers) shouldAutoGenerateReport(fileType string) bool {
-	switch fileType {
-	case detector.FileTypeJFR, detector.FileTypeTTop, detector.FileTypeIOStat:
-		return true
-	default:
-		return false
+	return reporters.ShouldAutoGenerate(fileType)
+}
+
+// queueReport creates a pending report of the given type for a file. If one is already
+// pending or running it is returned instead so re-uploads and re-detection don't pile up
+// duplicate work; created reports whether a new report was inserted.
+func (h *Handlers) queueReport(fileID int, reportType string) (*database.Report, bool, error) {
+	existing, err := h.db.GetActiveReport(fileID, reportType)
+	if err == nil {
+		log.Printf("Report %d of type %s is already %s for file %d, not queueing another", existing.ID, reportType, existing.Status, fileID)
+		return existing, false, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, false, err
+	}
+
+	report := &database.Report{
+		FileID:      fileID,
+		ReportType:  reportType,
+		Status:      "pending",
+		CreatedTime: time.Now(),
+		DDDVersion:  DDDVersion,
+	}
+	if err := h.db.InsertReport(report); err != nil {
+		if !errors.Is(err, database.ErrReportAlreadyActive) {
+			return nil, false, err
+		}
+		// Another request queued it since the check above
+		existing, err := h.db.GetActiveReport(fileID, reportType)
+		if err != nil {
+			return nil, false, err
+		}
+		log.Printf("Report %d of type %s was queued for file %d meanwhile, not queueing another", existing.ID, reportType, fileID)
+		return existing, false, nil
 	}
+	return report, true, nil
 }
@@ -16,17 +16,24 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/rsvihladremio/ddd/internal/database"
+	"github.com/rsvihladremio/ddd/internal/detector"
+	"github.com/rsvihladremio/ddd/internal/events"
+	"github.com/rsvihladremio/ddd/internal/reporters"
 	"github.com/rsvihladremio/ddd/internal/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -36,6 +43,7 @@ import (
 type mockCleanupWorker struct {
 	mu           sync.Mutex
 	triggerCount int
+	resetCount   int
 }
 
 func (m *mockCleanupWorker) TriggerCleanup() {
@@ -44,12 +52,44 @@ func (m *mockCleanupWorker) TriggerCleanup() {
 	m.triggerCount++
 }
 
+func (m *mockCleanupWorker) ResetInterval() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.resetCount++
+}
+
+func (m *mockCleanupWorker) getResetCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.resetCount
+}
+
 func (m *mockCleanupWorker) getTriggerCount() int {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	return m.triggerCount
 }
 
+// mockReportWorker implements ReportWorker for testing, treating the reports in running
+// as being generated
+type mockReportWorker struct {
+	running   map[int]bool
+	cancelled []int
+	resets    int
+}
+
+func (m *mockReportWorker) ResetInterval() {
+	m.resets++
+}
+
+func (m *mockReportWorker) CancelReport(reportID int) bool {
+	if !m.running[reportID] {
+		return false
+	}
+	m.cancelled = append(m.cancelled, reportID)
+	return true
+}
+
 // testDB creates a test database with clean schema
 func testDB(t *testing.T) *database.DB {
 	t.Helper()
@@ -74,7 +114,7 @@ func setupTestHandler(t *testing.T) (*Handlers, *database.DB) {
 	cfg := testutil.TestConfig(t)
 	mockWorker := &mockCleanupWorker{}
 
-	handler := New(db, cfg, mockWorker)
+	handler := New(db, cfg, mockWorker, nil, nil)
 	return handler, db
 }
 
@@ -87,8 +127,26 @@ func TestHandlers_HandleIndex(t *testing.T) {
 
 		handler.HandleIndex(w, req)
 
-		// Since index.html doesn't exist in test environment, expect 404
-		assert.Equal(t, http.StatusNotFound, w.Code)
+		// index.html is embedded in the binary, so it is served from any working directory
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "<title>DDD: Dandy Diagnostic Doctor</title>")
+	})
+
+	t.Run("Serve index page from a web directory", func(t *testing.T) {
+		webDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(webDir, "index.html"), []byte("<html>live copy</html>"), 0600))
+
+		cfg := testutil.TestConfig(t)
+		cfg.WebDir = webDir
+		liveHandler := New(handler.db, cfg, &mockCleanupWorker{}, nil, nil)
+
+		req := httptest.NewRequest("GET", "/", nil)
+		w := httptest.NewRecorder()
+
+		liveHandler.HandleIndex(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "<html>live copy</html>", w.Body.String())
 	})
 
 	t.Run("Return 404 for non-root paths", func(t *testing.T) {
@@ -98,9 +156,293 @@ func TestHandlers_HandleIndex(t *testing.T) {
 		handler.HandleIndex(w, req)
 
 		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Body.String(), "<h1>404 Not Found</h1>")
+	})
+
+	t.Run("Return JSON 404 for unknown API paths", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/nonexistent", nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleIndex(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+		assert.JSONEq(t, `{"success": false, "error": {"message": "Not found", "code": "not_found"}}`, w.Body.String())
+	})
+}
+
+func TestHandlers_HandleStatic(t *testing.T) {
+	handler, _ := setupTestHandler(t)
+
+	t.Run("Serve embedded asset", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/static/js/app.js", nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleStatic().ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Type"), "javascript")
+		assert.NotEmpty(t, w.Body.String())
+	})
+
+	t.Run("Missing asset", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/static/js/missing.js", nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleStatic().ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestHandlers_HandleFavicon(t *testing.T) {
+	handler, _ := setupTestHandler(t)
+
+	req := httptest.NewRequest("GET", "/favicon.ico", nil)
+	w := httptest.NewRecorder()
+
+	handler.HandleFavicon(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/vnd.microsoft.icon", w.Header().Get("Content-Type"))
+	assert.NotEmpty(t, w.Header().Get("Cache-Control"))
+	// ICO files start with a reserved zero word followed by type 1
+	assert.Equal(t, []byte{0, 0, 1, 0}, w.Body.Bytes()[:4])
+}
+
+func TestHandlers_WriteError(t *testing.T) {
+	handler, _ := setupTestHandler(t)
+
+	t.Run("Pages get the HTML error page", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/report/1", nil)
+		w := httptest.NewRecorder()
+
+		handler.writeError(w, req, http.StatusInternalServerError, "Something <broke>.")
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+		body := w.Body.String()
+		assert.Contains(t, body, "<h1>500 Internal Server Error</h1>")
+		assert.Contains(t, body, "Something &lt;broke&gt;.")
+	})
+
+	t.Run("API paths never get HTML", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/files/1", nil)
+		w := httptest.NewRecorder()
+
+		handler.writeError(w, req, http.StatusInternalServerError, "Something broke")
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{"success": false, "error": {"message": "Something broke", "code": "internal_error"}}`, w.Body.String())
+	})
+}
+
+func TestHandlers_JSONErrors(t *testing.T) {
+	handler, _ := setupTestHandler(t)
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		handle  http.HandlerFunc
+		status  int
+		code    string
+		message string
+	}{
+		{"Method not allowed", "POST", "/api/files", handler.HandleFiles, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Method not allowed"},
+		{"Bad request", "GET", "/api/reports/abc", handler.HandleReports, http.StatusBadRequest, ErrCodeBadRequest, "Invalid ID"},
+		{"Not found", "GET", "/api/files/99999/download", handler.HandleDownloadFile, http.StatusNotFound, ErrCodeNotFound, "File not found"},
+		{"Missing upload", "POST", "/api/upload", handler.HandleUpload, http.StatusBadRequest, ErrCodeBadRequest, "Failed to parse form"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			w := httptest.NewRecorder()
+
+			tt.handle(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+			var response struct {
+				Success bool `json:"success"`
+				Error   struct {
+					Message string `json:"message"`
+					Code    string `json:"code"`
+				} `json:"error"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.False(t, response.Success)
+			assert.Equal(t, tt.message, response.Error.Message)
+			assert.Equal(t, tt.code, response.Error.Code)
+		})
+	}
+}
+
+func TestHandlers_HandleReportPage(t *testing.T) {
+	handler, db := setupTestHandler(t)
+
+	file := &database.File{
+		Hash:         "report-page-hash",
+		OriginalName: `<script>alert("x")</script>.txt`,
+		FileType:     "ttop",
+		FileSize:     100,
+		UploadTime:   time.Now(),
+		FilePath:     "/uploads/report-page-hash",
+	}
+	require.NoError(t, db.InsertFile(file))
+
+	report := &database.Report{
+		FileID:      file.ID,
+		ReportType:  "ttop",
+		Status:      "failed",
+		CreatedTime: time.Now(),
+		DDDVersion:  "1.0.0",
+	}
+	require.NoError(t, db.InsertReport(report))
+	require.NoError(t, db.FailReport(report.ID, "parse error"))
+
+	t.Run("Render report page", func(t *testing.T) {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/report/%d", report.ID), nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleReportPage(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+		body := w.Body.String()
+		assert.Contains(t, body, "<h1>ttop Report</h1>")
+		assert.Contains(t, body, "parse error")
+		assert.Contains(t, body, "Report is not completed yet.")
+		assert.Contains(t, body, "&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt;.txt", "File names should be escaped")
+		assert.NotContains(t, body, `<script>alert("x")</script>`)
+		assert.NotContains(t, body, "Generated in:", "The generation time is only shown once it is known")
+	})
+
+	t.Run("Completed report page fetches its content", func(t *testing.T) {
+		completed := &database.Report{
+			FileID:      file.ID,
+			ReportType:  "ttop",
+			Status:      "completed",
+			CreatedTime: time.Now(),
+			DDDVersion:  "1.0.0",
+			ReportData:  `{"summary": "ok"}`,
+		}
+		require.NoError(t, db.InsertReport(completed))
+		require.NoError(t, db.SetReportGenerationTime(completed.ID, 1200*time.Millisecond))
+
+		req := httptest.NewRequest("GET", fmt.Sprintf("/report/%d", completed.ID), nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleReportPage(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		body := w.Body.String()
+		assert.Contains(t, body, "Loading report content...")
+		assert.Contains(t, body, fmt.Sprintf(`fetch("/api/reports/content/%d")`, completed.ID))
+		assert.NotContains(t, body, "Report is not completed yet.")
+		assert.Contains(t, body, "1.0.0 (current "+DDDVersion+")")
+		assert.Contains(t, body, "<strong>Generated in:</strong> 1.2s")
+		assert.NotContains(t, body, "generated by an older DDD version")
+	})
+
+	t.Run("Report page passes a theme through to its content", func(t *testing.T) {
+		completed := &database.Report{
+			FileID:      file.ID,
+			ReportType:  "ttop",
+			Status:      "completed",
+			CreatedTime: time.Now(),
+			DDDVersion:  "1.0.0",
+			ReportData:  `{"summary": "ok"}`,
+		}
+		require.NoError(t, db.InsertReport(completed))
+
+		req := httptest.NewRequest("GET", fmt.Sprintf("/report/%d?theme=dark", completed.ID), nil)
+		w := httptest.NewRecorder()
+		handler.HandleReportPage(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), fmt.Sprintf(`/api/reports/content/%d?theme=dark`, completed.ID))
+
+		req = httptest.NewRequest("GET", fmt.Sprintf("/report/%d?theme=neon", completed.ID), nil)
+		w = httptest.NewRecorder()
+		handler.HandleReportPage(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), "theme")
+	})
+
+	t.Run("Report page passes top threads through to its content", func(t *testing.T) {
+		completed := &database.Report{
+			FileID:      file.ID,
+			ReportType:  "ttop",
+			Status:      "completed",
+			CreatedTime: time.Now(),
+			DDDVersion:  "1.0.0",
+			ReportData:  `{"summary": "ok"}`,
+		}
+		require.NoError(t, db.InsertReport(completed))
+
+		req := httptest.NewRequest("GET", fmt.Sprintf("/report/%d?top_threads=20&theme=dark", completed.ID), nil)
+		w := httptest.NewRecorder()
+		handler.HandleReportPage(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), fmt.Sprintf(`/api/reports/content/%d?theme=dark\u0026top_threads=20`, completed.ID))
+
+		req = httptest.NewRequest("GET", fmt.Sprintf("/report/%d?top_threads=500", completed.ID), nil)
+		w = httptest.NewRecorder()
+		handler.HandleReportPage(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), "top_threads")
+	})
+
+	t.Run("Stale report page shows the version gap", func(t *testing.T) {
+		stale := &database.Report{
+			FileID:      file.ID,
+			ReportType:  "ttop",
+			Status:      "completed",
+			CreatedTime: time.Now(),
+			DDDVersion:  "0.9.0",
+			ReportData:  `{"summary": "ok"}`,
+		}
+		require.NoError(t, db.InsertReport(stale))
+
+		req := httptest.NewRequest("GET", fmt.Sprintf("/report/%d", stale.ID), nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleReportPage(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		body := w.Body.String()
+		assert.Contains(t, body, "0.9.0 (current "+DDDVersion+")")
+		assert.Contains(t, body, "generated by an older DDD version")
+	})
+
+	t.Run("Unknown report", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/report/99999", nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleReportPage(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), "That report does not exist.")
 	})
 }
 
+func TestCompareVersions(t *testing.T) {
+	assert.Equal(t, 0, compareVersions("1.0.0", "1.0.0"))
+	assert.Equal(t, 0, compareVersions("1.0", "1.0.0"))
+	assert.Equal(t, -1, compareVersions("0.9.9", "1.0.0"))
+	assert.Equal(t, -1, compareVersions("1.2.0", "1.10.0"), "Parts compare numerically")
+	assert.Equal(t, 1, compareVersions("v2.0.0", "1.9.0"))
+	assert.Equal(t, -1, compareVersions("", "1.0.0"))
+
+	assert.True(t, isStaleVersion("0.9.0"))
+	assert.False(t, isStaleVersion(DDDVersion))
+}
+
 func TestHandlers_HandleUpload(t *testing.T) {
 	handler, db := setupTestHandler(t)
 
@@ -139,11 +481,18 @@ func TestHandlers_HandleUpload(t *testing.T) {
 		assert.True(t, response["success"].(bool))
 		assert.Contains(t, response, "file")
 
+		// The space left for further uploads comes back with the file
+		require.Contains(t, response, "disk_space")
+		diskSpace := response["disk_space"].(map[string]interface{})
+		assert.Greater(t, diskSpace["total_bytes"].(float64), float64(0))
+		assert.Contains(t, diskSpace, "available_bytes")
+		assert.Contains(t, diskSpace, "used_percent")
+
 		// Verify file was saved to database
 		fileData := response["file"].(map[string]interface{})
 		fileID := int(fileData["id"].(float64))
 
-		files, err := db.GetFiles(10, 0, false, "")
+		files, err := db.GetFiles(10, 0, false, "", nil)
 		require.NoError(t, err)
 
 		found := false
@@ -152,6 +501,8 @@ func TestHandlers_HandleUpload(t *testing.T) {
 				found = true
 				assert.Equal(t, "test.txt", file.OriginalName)
 				assert.Equal(t, "ttop", file.FileType)
+				assert.Equal(t, "content", file.DetectionSignal)
+				assert.Greater(t, file.DetectionConfidence, 0.5)
 				break
 			}
 		}
@@ -181,7 +532,7 @@ func TestHandlers_HandleUpload(t *testing.T) {
 		// Second upload (duplicate)
 		body2 := &bytes.Buffer{}
 		writer2 := multipart.NewWriter(body2)
-		part2, err := writer2.CreateFormFile("file", "duplicate.txt")
+		part2, err := writer2.CreateFormFile("file", "duplicate-node2.txt")
 		require.NoError(t, err)
 
 		_, err = part2.Write(testContent)
@@ -204,6 +555,145 @@ func TestHandlers_HandleUpload(t *testing.T) {
 
 		assert.True(t, response["success"].(bool))
 		assert.Contains(t, response["message"], "already exists")
+
+		// Both uploads are kept as occurrences of the one stored file
+		uploads := response["uploads"].([]interface{})
+		require.Len(t, uploads, 2)
+		assert.Equal(t, "duplicate.txt", uploads[0].(map[string]interface{})["original_name"])
+		assert.Equal(t, "duplicate-node2.txt", uploads[1].(map[string]interface{})["original_name"])
+	})
+
+	t.Run("Upload several files as a group", func(t *testing.T) {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		for _, name := range []string{"ttop", "iostat"} {
+			part, err := writer.CreateFormFile("file", testutil.SampleFiles[name].Name)
+			require.NoError(t, err)
+			_, err = part.Write(testutil.SampleFiles[name].Content)
+			require.NoError(t, err)
+		}
+		// The same content as the earlier duplicate test, already stored without a group
+		part, err := writer.CreateFormFile("file", "duplicate-node3.txt")
+		require.NoError(t, err)
+		_, err = part.Write([]byte("duplicate content"))
+		require.NoError(t, err)
+		require.NoError(t, writer.Close())
+
+		req := httptest.NewRequest("POST", "/api/upload", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		w := httptest.NewRecorder()
+
+		handler.HandleUpload(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Success       bool   `json:"success"`
+			UploadGroupID string `json:"upload_group_id"`
+			Results       []struct {
+				FileName string         `json:"file_name"`
+				Success  bool           `json:"success"`
+				File     *database.File `json:"file"`
+				Message  string         `json:"message"`
+			} `json:"results"`
+			Files     []*database.File `json:"files"`
+			Failures  []interface{}    `json:"failures"`
+			Message   string           `json:"message"`
+			DiskSpace *DiskStats       `json:"disk_space"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+		assert.True(t, response.Success)
+		require.NotNil(t, response.DiskSpace)
+		assert.Greater(t, response.DiskSpace.Total, uint64(0))
+
+		// Every file has a result, in the order they were sent
+		require.Len(t, response.Results, 3)
+		assert.Equal(t, testutil.SampleFiles["ttop"].Name, response.Results[0].FileName)
+		assert.Equal(t, testutil.SampleFiles["iostat"].Name, response.Results[1].FileName)
+		assert.Equal(t, "duplicate-node3.txt", response.Results[2].FileName)
+		for _, result := range response.Results {
+			assert.True(t, result.Success)
+			require.NotNil(t, result.File)
+		}
+		assert.Equal(t, "File already exists; recorded this upload", response.Results[2].Message)
+
+		assert.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, response.UploadGroupID)
+		assert.Equal(t, "Uploaded 3 files", response.Message)
+		assert.Empty(t, response.Failures)
+		require.Len(t, response.Files, 3)
+
+		// Content stored earlier without a group joins it, keeping the name it was stored under
+		files, err := db.GetFilesByUploadGroup(response.UploadGroupID)
+		require.NoError(t, err)
+		var names []string
+		for _, file := range files {
+			names = append(names, file.OriginalName)
+			assert.Equal(t, response.UploadGroupID, file.UploadGroupID)
+		}
+		assert.ElementsMatch(t, []string{"test.txt", "iostat.txt", "duplicate.txt"}, names)
+
+		// A later upload on its own does not join a group
+		single := &bytes.Buffer{}
+		singleWriter := multipart.NewWriter(single)
+		singlePart, err := singleWriter.CreateFormFile("file", "single.txt")
+		require.NoError(t, err)
+		_, err = singlePart.Write([]byte("uploaded alone"))
+		require.NoError(t, err)
+		require.NoError(t, singleWriter.Close())
+
+		req = httptest.NewRequest("POST", "/api/upload", single)
+		req.Header.Set("Content-Type", singleWriter.FormDataContentType())
+		w = httptest.NewRecorder()
+		handler.HandleUpload(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var singleResponse map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &singleResponse))
+		assert.NotContains(t, singleResponse, "upload_group_id")
+		assert.Equal(t, "", singleResponse["file"].(map[string]interface{})["upload_group_id"])
+	})
+
+	t.Run("One failed file does not fail the others", func(t *testing.T) {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		for name, content := range map[string]string{"new.txt": "content that needs saving", "again.txt": "duplicate content"} {
+			part, err := writer.CreateFormFile("file", name)
+			require.NoError(t, err)
+			_, err = part.Write([]byte(content))
+			require.NoError(t, err)
+		}
+		require.NoError(t, writer.Close())
+
+		// New content can't be saved, but content that is already stored only needs recording
+		uploadsDir := handler.cfg.UploadsDir
+		handler.cfg.UploadsDir = filepath.Join(t.TempDir(), "missing")
+		defer func() { handler.cfg.UploadsDir = uploadsDir }()
+
+		req := httptest.NewRequest("POST", "/api/upload", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		w := httptest.NewRecorder()
+		handler.HandleUpload(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.False(t, response["success"].(bool))
+		assert.Equal(t, "Uploaded 1 of 2 files", response["message"])
+
+		results := map[string]map[string]interface{}{}
+		for _, result := range response["results"].([]interface{}) {
+			entry := result.(map[string]interface{})
+			results[entry["file_name"].(string)] = entry
+		}
+		require.Len(t, results, 2)
+		assert.False(t, results["new.txt"]["success"].(bool))
+		assert.Equal(t, "Failed to save file", results["new.txt"]["error"])
+		assert.Equal(t, ErrCodeInternal, results["new.txt"]["code"])
+		assert.True(t, results["again.txt"]["success"].(bool))
+		assert.Contains(t, results["again.txt"], "uploads")
+		assert.Len(t, response["failures"], 1)
 	})
 
 	t.Run("Upload with invalid method", func(t *testing.T) {
@@ -231,61 +721,464 @@ func TestHandlers_HandleUpload(t *testing.T) {
 	})
 }
 
-func TestHandlers_HandleFiles(t *testing.T) {
+func TestHandlers_HandleFilesByGroup(t *testing.T) {
 	handler, db := setupTestHandler(t)
 
-	// Create test files
-	testFiles := []*database.File{
-		{
-			Hash:         "hash1",
-			OriginalName: "file1.txt",
-			FileType:     "ttop",
-			FileSize:     100,
-			UploadTime:   time.Now().Add(-1 * time.Hour),
-			FilePath:     "/uploads/hash1",
-		},
-		{
-			Hash:         "hash2",
-			OriginalName: "file2.txt",
-			FileType:     "iostat",
-			FileSize:     200,
-			UploadTime:   time.Now(),
-			FilePath:     "/uploads/hash2",
-		},
-	}
-
-	for _, file := range testFiles {
-		err := db.InsertFile(file)
-		require.NoError(t, err)
+	for i, name := range []string{"ttop-node1.txt", "ttop-node2.txt"} {
+		require.NoError(t, db.InsertFile(&database.File{
+			Hash:          fmt.Sprintf("group-hash-%d", i),
+			OriginalName:  name,
+			FileType:      "ttop",
+			UploadTime:    time.Now(),
+			UploadGroupID: "1b4e28ba-2fa1-41d2-883f-0016d3cca427",
+		}))
 	}
 
-	t.Run("Get files list", func(t *testing.T) {
-		req := httptest.NewRequest("GET", "/api/files", nil)
+	t.Run("Lists the files in a group", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/groups/1b4e28ba-2fa1-41d2-883f-0016d3cca427", nil)
 		w := httptest.NewRecorder()
 
-		handler.HandleFiles(w, req)
+		handler.HandleFilesByGroup(w, req)
 
 		assert.Equal(t, http.StatusOK, w.Code)
-		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		var response struct {
+			Success       bool             `json:"success"`
+			UploadGroupID string           `json:"upload_group_id"`
+			Files         []*database.File `json:"files"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.True(t, response.Success)
+		assert.Equal(t, "1b4e28ba-2fa1-41d2-883f-0016d3cca427", response.UploadGroupID)
+		require.Len(t, response.Files, 2)
+		assert.Equal(t, "ttop-node1.txt", response.Files[0].OriginalName)
+		assert.Equal(t, "ttop-node2.txt", response.Files[1].OriginalName)
+	})
 
-		var response map[string]interface{}
-		err := json.Unmarshal(w.Body.Bytes(), &response)
-		require.NoError(t, err)
+	t.Run("Unknown group", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/groups/missing", nil)
+		w := httptest.NewRecorder()
 
-		assert.True(t, response["success"].(bool))
-		assert.Contains(t, response, "files")
+		handler.HandleFilesByGroup(w, req)
 
-		files := response["files"].([]interface{})
-		assert.GreaterOrEqual(t, len(files), 2)
+		assert.Equal(t, http.StatusNotFound, w.Code)
 	})
 
-	t.Run("Get files with pagination", func(t *testing.T) {
-		req := httptest.NewRequest("GET", "/api/files?limit=1&offset=0", nil)
+	t.Run("Invalid path", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/groups/a/b", nil)
 		w := httptest.NewRecorder()
 
-		handler.HandleFiles(w, req)
-
-		assert.Equal(t, http.StatusOK, w.Code)
+		handler.HandleFilesByGroup(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Invalid method", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/groups/1b4e28ba-2fa1-41d2-883f-0016d3cca427", nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleFilesByGroup(w, req)
+
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
+
+func TestHandlers_UploadEmptyFile(t *testing.T) {
+	handler, db := setupTestHandler(t)
+
+	upload := func(t *testing.T, files map[string][]byte, order ...string) *httptest.ResponseRecorder {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		for _, fileName := range order {
+			part, err := writer.CreateFormFile("file", fileName)
+			require.NoError(t, err)
+			_, err = part.Write(files[fileName])
+			require.NoError(t, err)
+		}
+		require.NoError(t, writer.Close())
+
+		req := httptest.NewRequest("POST", "/api/upload", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		w := httptest.NewRecorder()
+		handler.HandleUpload(w, req)
+		return w
+	}
+
+	t.Run("A zero byte file is refused before it is stored", func(t *testing.T) {
+		w := upload(t, map[string][]byte{"ttop.txt": {}}, "ttop.txt")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "ttop.txt is empty (0 bytes)")
+
+		count, err := db.GetFilesCount(true, "")
+		require.NoError(t, err)
+		assert.Zero(t, count)
+		entries, err := os.ReadDir(handler.cfg.UploadsDir)
+		if err == nil {
+			assert.Empty(t, entries)
+		}
+	})
+
+	t.Run("Empty files don't fail the rest of a batch", func(t *testing.T) {
+		w := upload(t, map[string][]byte{"empty.txt": {}, "iostat.txt": testutil.SampleFiles["iostat"].Content}, "empty.txt", "iostat.txt")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		results := response["results"].([]interface{})
+		require.Len(t, results, 2)
+		assert.False(t, results[0].(map[string]interface{})["success"].(bool))
+		assert.True(t, results[1].(map[string]interface{})["success"].(bool))
+	})
+}
+
+func TestHandlers_UploadHashAlgorithm(t *testing.T) {
+	handler, db := setupTestHandler(t)
+
+	upload := func(t *testing.T, sampleType string) *httptest.ResponseRecorder {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		part, err := writer.CreateFormFile("file", sampleType+".txt")
+		require.NoError(t, err)
+		_, err = part.Write(testutil.SampleFiles[sampleType].Content)
+		require.NoError(t, err)
+		require.NoError(t, writer.Close())
+
+		req := httptest.NewRequest("POST", "/api/upload", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		w := httptest.NewRecorder()
+		handler.HandleUpload(w, req)
+		return w
+	}
+	hashOf := func(t *testing.T, algorithm, sampleType string) string {
+		hash, err := computeContentHash(algorithm, testutil.SampleFiles[sampleType].Content)
+		require.NoError(t, err)
+		return hash
+	}
+
+	t.Run("Uploads are hashed with sha256 by default", func(t *testing.T) {
+		w := upload(t, "iostat")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		file, err := db.GetFileByHash(hashOf(t, hashAlgorithmSHA256, "iostat"))
+		require.NoError(t, err)
+		assert.Equal(t, hashAlgorithmSHA256, file.HashAlgorithm)
+	})
+
+	require.NoError(t, db.SetSetting("hash_algorithm", hashAlgorithmXXHash))
+
+	t.Run("Uploads are hashed with the configured algorithm", func(t *testing.T) {
+		w := upload(t, "ttop")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		hash := hashOf(t, hashAlgorithmXXHash, "ttop")
+		assert.Len(t, hash, 16)
+		file, err := db.GetFileByHash(hash)
+		require.NoError(t, err)
+		assert.Equal(t, hashAlgorithmXXHash, file.HashAlgorithm)
+		assert.Equal(t, filepath.Join(handler.cfg.UploadsDir, hash), file.FilePath)
+		assert.FileExists(t, file.FilePath)
+	})
+
+	t.Run("Content stored under the previous algorithm is still deduplicated", func(t *testing.T) {
+		w := upload(t, "iostat")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), "File already exists")
+
+		count, err := db.GetFilesCount(true, "iostat")
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+	})
+
+	require.NoError(t, db.SetSetting("hash_algorithm", hashAlgorithmSHA256))
+
+	t.Run("Switching back still finds content hashed with xxhash", func(t *testing.T) {
+		w := upload(t, "ttop")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), "File already exists")
+
+		count, err := db.GetFilesCount(true, "ttop")
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+	})
+
+	require.NoError(t, db.SetSetting("hash_algorithm", hashAlgorithmXXHash))
+
+	t.Run("Different content under the same xxhash is stored under its sha256", func(t *testing.T) {
+		// Stand in for an xxhash collision: other bytes of the same size stored under the vmstat sample's digest
+		content := testutil.SampleFiles["vmstat"].Content
+		collidingHash := hashOf(t, hashAlgorithmXXHash, "vmstat")
+		collidingPath := filepath.Join(handler.cfg.UploadsDir, collidingHash)
+		require.NoError(t, os.WriteFile(collidingPath, bytes.Repeat([]byte("x"), len(content)), 0600))
+		colliding := &database.File{Hash: collidingHash, HashAlgorithm: hashAlgorithmXXHash, OriginalName: "other.txt",
+			FileType: "unknown", FileSize: int64(len(content)), UploadTime: time.Now(), FilePath: collidingPath}
+		require.NoError(t, db.InsertFile(colliding))
+
+		w := upload(t, "vmstat")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), "File uploaded successfully")
+
+		file, err := db.GetFileByHash(hashOf(t, hashAlgorithmSHA256, "vmstat"))
+		require.NoError(t, err)
+		assert.NotEqual(t, colliding.ID, file.ID)
+		assert.Equal(t, hashAlgorithmSHA256, file.HashAlgorithm)
+		stored, err := os.ReadFile(file.FilePath)
+		require.NoError(t, err)
+		assert.Equal(t, content, stored)
+
+		// The same upload again is found under its sha256
+		w = upload(t, "vmstat")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), "File already exists")
+	})
+
+	t.Run("A deleted file with the same xxhash is not restored", func(t *testing.T) {
+		// Its bytes are gone, so nothing shows the upload is what its reports were generated from
+		content := testutil.SampleFiles["iostat"].Content
+		collidingHash := hashOf(t, hashAlgorithmXXHash, "iostat")
+		deleted := &database.File{Hash: collidingHash, HashAlgorithm: hashAlgorithmXXHash, OriginalName: "other.txt",
+			FileType: "unknown", FileSize: int64(len(content)), UploadTime: time.Now(), FilePath: filepath.Join(handler.cfg.UploadsDir, collidingHash)}
+		require.NoError(t, db.InsertFile(deleted))
+		require.NoError(t, db.MarkFileDeleted(deleted.ID))
+
+		w := upload(t, "iostat")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		file, err := db.GetFileByHash(hashOf(t, hashAlgorithmSHA256, "iostat"))
+		require.NoError(t, err)
+		assert.NotEqual(t, deleted.ID, file.ID)
+		still, err := db.GetFileByID(deleted.ID)
+		require.NoError(t, err)
+		assert.True(t, still.Deleted)
+	})
+}
+
+func TestHandlers_UploadAllowedFileTypes(t *testing.T) {
+	handler, db := setupTestHandler(t)
+
+	upload := func(fileNames ...string) *httptest.ResponseRecorder {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		for _, fileName := range fileNames {
+			part, err := writer.CreateFormFile("file", fileName)
+			require.NoError(t, err)
+			sampleType := strings.TrimSuffix(fileName, ".txt")
+			_, err = part.Write(testutil.SampleFiles[sampleType].Content)
+			require.NoError(t, err)
+		}
+		require.NoError(t, writer.Close())
+
+		req := httptest.NewRequest("POST", "/api/upload", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		w := httptest.NewRecorder()
+		handler.HandleUpload(w, req)
+		return w
+	}
+
+	t.Run("Every type is accepted by default", func(t *testing.T) {
+		w := upload("unknown.txt")
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	})
+
+	require.NoError(t, db.SetSetting("allowed_file_types", "ttop,iostat"))
+
+	t.Run("Types missing from the list are refused", func(t *testing.T) {
+		w := upload("thread_dump.txt")
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+		assert.Contains(t, w.Body.String(), ErrCodeUnsupportedType)
+		assert.Contains(t, w.Body.String(), "Uploads of thread_dump files are not accepted; allowed file types: ttop, iostat")
+
+		count, err := db.GetFilesCount(true, "thread_dump")
+		require.NoError(t, err)
+		assert.Zero(t, count)
+	})
+
+	t.Run("Listed types are accepted", func(t *testing.T) {
+		w := upload("iostat.txt")
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	})
+
+	t.Run("Refused files don't fail the rest of a batch", func(t *testing.T) {
+		w := upload("ttop.txt", "dremio_config.txt")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		results := response["results"].([]interface{})
+		require.Len(t, results, 2)
+		assert.True(t, results[0].(map[string]interface{})["success"].(bool))
+		refused := results[1].(map[string]interface{})
+		assert.False(t, refused["success"].(bool))
+		assert.Equal(t, ErrCodeUnsupportedType, refused["code"])
+	})
+}
+
+func TestHandlers_HandleDetectPreview(t *testing.T) {
+	handler, db := setupTestHandler(t)
+
+	detect := func(fileName string, content []byte) *httptest.ResponseRecorder {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		part, err := writer.CreateFormFile("file", fileName)
+		require.NoError(t, err)
+		_, err = part.Write(content)
+		require.NoError(t, err)
+		require.NoError(t, writer.Close())
+
+		req := httptest.NewRequest("POST", "/api/detect", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		w := httptest.NewRecorder()
+		handler.HandleDetectPreview(w, req)
+		return w
+	}
+
+	t.Run("Detect without storing the file", func(t *testing.T) {
+		w := detect("capture.txt", testutil.SampleFiles["iostat"].Content)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.True(t, response["success"].(bool))
+		assert.Equal(t, "capture.txt", response["file_name"])
+		assert.False(t, response["truncated"].(bool))
+
+		detection := response["detection"].(map[string]interface{})
+		assert.Equal(t, "iostat", detection["file_type"])
+		assert.Equal(t, 0.9, detection["confidence"])
+		assert.Equal(t, "content", detection["signal"])
+
+		count, err := db.GetFilesCount(true, "")
+		require.NoError(t, err)
+		assert.Zero(t, count)
+		entries, err := os.ReadDir(handler.cfg.UploadsDir)
+		if err == nil {
+			assert.Empty(t, entries)
+		}
+	})
+
+	t.Run("Report whether the upload would be allowed", func(t *testing.T) {
+		allowed := func(t *testing.T) bool {
+			w := detect("capture.txt", testutil.SampleFiles["iostat"].Content)
+			require.Equal(t, http.StatusOK, w.Code)
+			var response map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			return response["allowed"].(bool)
+		}
+
+		assert.True(t, allowed(t))
+		require.NoError(t, db.SetSetting("allowed_file_types", "jfr"))
+		assert.False(t, allowed(t))
+		require.NoError(t, db.SetSetting("allowed_file_types", ""))
+		assert.True(t, allowed(t))
+	})
+
+	t.Run("Only the first part of a large file is examined", func(t *testing.T) {
+		content := append([]byte{}, testutil.SampleFiles["ttop"].Content...)
+		content = append(content, bytes.Repeat([]byte("\n"), 2*detector.PrefixBytes)...)
+
+		w := detect("ttop.txt", content)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.True(t, response["truncated"].(bool))
+		assert.Equal(t, float64(detector.PrefixBytes), response["bytes_examined"])
+		assert.Equal(t, "ttop", response["detection"].(map[string]interface{})["file_type"])
+	})
+
+	t.Run("Unknown content falls back to the file name", func(t *testing.T) {
+		w := detect("recording.jfr", []byte("not really a recording"))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		detection := response["detection"].(map[string]interface{})
+		assert.Equal(t, "jfr", detection["file_type"])
+		assert.Equal(t, "extension", detection["signal"])
+	})
+
+	t.Run("Missing file", func(t *testing.T) {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		require.NoError(t, writer.WriteField("name", "capture.txt"))
+		require.NoError(t, writer.Close())
+
+		req := httptest.NewRequest("POST", "/api/detect", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		w := httptest.NewRecorder()
+		handler.HandleDetectPreview(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Method not allowed", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/detect", nil)
+		w := httptest.NewRecorder()
+		handler.HandleDetectPreview(w, req)
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
+
+func TestHandlers_HandleFiles(t *testing.T) {
+	handler, db := setupTestHandler(t)
+
+	// Create test files
+	testFiles := []*database.File{
+		{
+			Hash:         "hash1",
+			OriginalName: "file1.txt",
+			FileType:     "ttop",
+			FileSize:     100,
+			UploadTime:   time.Now().Add(-1 * time.Hour),
+			FilePath:     "/uploads/hash1",
+		},
+		{
+			Hash:                "hash2",
+			OriginalName:        "file2.txt",
+			FileType:            "iostat",
+			FileSize:            200,
+			UploadTime:          time.Now(),
+			FilePath:            "/uploads/hash2",
+			DetectionConfidence: 0.4,
+			DetectionSignal:     "extension",
+		},
+	}
+
+	for _, file := range testFiles {
+		err := db.InsertFile(file)
+		require.NoError(t, err)
+	}
+
+	t.Run("Get files list", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/files", nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleFiles(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+		var response map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		assert.True(t, response["success"].(bool))
+		assert.Contains(t, response, "files")
+
+		files := response["files"].([]interface{})
+		assert.GreaterOrEqual(t, len(files), 2)
+		assert.Equal(t, 0.5, response["low_confidence_threshold"])
+
+		// Files are ordered newest first, so the low confidence iostat file comes first
+		newest := files[0].(map[string]interface{})
+		assert.Equal(t, 0.4, newest["detection_confidence"])
+		assert.Equal(t, "extension", newest["detection_signal"])
+	})
+
+	t.Run("Get files with pagination", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/files?limit=1&offset=0", nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleFiles(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
 
 		var response map[string]interface{}
 		err := json.Unmarshal(w.Body.Bytes(), &response)
@@ -722,39 +1615,187 @@ func TestHandlers_HandleFiles(t *testing.T) {
 	})
 }
 
-func TestHandlers_HandleFileOperations(t *testing.T) {
+func TestHandlers_HandleFilesCursor(t *testing.T) {
 	handler, db := setupTestHandler(t)
 
-	// Create a test file
-	testFile := &database.File{
-		Hash:         "test-hash",
-		OriginalName: "test.txt",
-		FileType:     "ttop",
-		FileSize:     100,
-		UploadTime:   time.Now(),
-		FilePath:     "/uploads/test-hash",
+	base := time.Now().Add(-time.Hour)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, db.InsertFile(&database.File{
+			Hash:         fmt.Sprintf("cursor%d", i),
+			OriginalName: fmt.Sprintf("cursor%d.txt", i),
+			FileType:     "iostat",
+			FileSize:     100,
+			UploadTime:   base.Add(time.Duration(i) * time.Minute),
+			FilePath:     fmt.Sprintf("/uploads/cursor%d", i),
+		}))
 	}
 
-	err := db.InsertFile(testFile)
-	require.NoError(t, err)
-
-	t.Run("Delete file", func(t *testing.T) {
-		url := fmt.Sprintf("/api/files/%d", testFile.ID)
-		req := httptest.NewRequest("DELETE", url, nil)
+	type page struct {
+		Files []struct {
+			OriginalName string `json:"original_name"`
+		} `json:"files"`
+		Total      int    `json:"total"`
+		NextCursor string `json:"next_cursor"`
+	}
+	get := func(t *testing.T, cursor string) page {
 		w := httptest.NewRecorder()
+		handler.HandleFiles(w, httptest.NewRequest("GET", "/api/files?limit=2&cursor="+cursor, nil))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var response page
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
 
-		handler.HandleFileOperations(w, req)
+	t.Run("Pages stay consistent while files are uploaded", func(t *testing.T) {
+		first := get(t, "")
+		require.Len(t, first.Files, 2)
+		assert.Equal(t, "cursor4.txt", first.Files[0].OriginalName)
+		assert.Equal(t, "cursor3.txt", first.Files[1].OriginalName)
+		assert.Equal(t, 5, first.Total)
+		require.NotEmpty(t, first.NextCursor)
+
+		// A new upload lands on the first page and must not shift the next one
+		require.NoError(t, db.InsertFile(&database.File{
+			Hash: "cursor-new", OriginalName: "cursor-new.txt", FileType: "iostat",
+			FileSize: 100, UploadTime: time.Now(), FilePath: "/uploads/cursor-new",
+		}))
+
+		second := get(t, first.NextCursor)
+		require.Len(t, second.Files, 2)
+		assert.Equal(t, "cursor2.txt", second.Files[0].OriginalName)
+		assert.Equal(t, "cursor1.txt", second.Files[1].OriginalName)
+
+		last := get(t, second.NextCursor)
+		require.Len(t, last.Files, 1)
+		assert.Equal(t, "cursor0.txt", last.Files[0].OriginalName)
+		assert.Empty(t, last.NextCursor, "The last page has no next cursor")
+	})
 
-		assert.Equal(t, http.StatusOK, w.Code)
+	t.Run("Invalid cursor", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.HandleFiles(w, httptest.NewRequest("GET", "/api/files?cursor=not-a-cursor", nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
 
-		var response map[string]interface{}
-		err := json.Unmarshal(w.Body.Bytes(), &response)
-		require.NoError(t, err)
+func TestHandlers_HandleFilesStream(t *testing.T) {
+	handler, db := setupTestHandler(t)
 
-		assert.True(t, response["success"].(bool))
+	for i := 0; i < 3; i++ {
+		require.NoError(t, db.InsertFile(&database.File{
+			Hash:         fmt.Sprintf("stream%d", i),
+			OriginalName: fmt.Sprintf("stream%d.txt", i),
+			FileType:     "iostat",
+			FileSize:     int64(100 * (i + 1)),
+			UploadTime:   time.Now(),
+			FilePath:     fmt.Sprintf("/uploads/stream%d", i),
+		}))
+	}
+	require.NoError(t, db.MarkFileDeleted(2))
 
-		// Verify file was marked as deleted
-		updatedFile, err := db.GetFileByHash("test-hash")
+	stream := func(t *testing.T, query string) []database.File {
+		w := httptest.NewRecorder()
+		handler.HandleFilesStream(w, httptest.NewRequest("GET", "/api/files/stream"+query, nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+		assert.True(t, w.Flushed)
+
+		var files []database.File
+		for _, line := range strings.Split(strings.TrimSuffix(w.Body.String(), "\n"), "\n") {
+			var file database.File
+			require.NoError(t, json.Unmarshal([]byte(line), &file), line)
+			files = append(files, file)
+		}
+		return files
+	}
+
+	t.Run("One file per line", func(t *testing.T) {
+		files := stream(t, "")
+		require.Len(t, files, 2)
+		assert.Equal(t, "stream0.txt", files[0].OriginalName)
+		assert.Equal(t, "stream2.txt", files[1].OriginalName)
+	})
+
+	t.Run("Include deleted", func(t *testing.T) {
+		files := stream(t, "?include_deleted=true")
+		require.Len(t, files, 3)
+		assert.True(t, files[1].Deleted)
+	})
+
+	t.Run("Method not allowed", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.HandleFilesStream(w, httptest.NewRequest("POST", "/api/files/stream", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
+
+func TestHandlers_HandleFileOperations(t *testing.T) {
+	handler, db := setupTestHandler(t)
+
+	// Create a test file
+	testFile := &database.File{
+		Hash:         "test-hash",
+		OriginalName: "test.txt",
+		FileType:     "ttop",
+		FileSize:     100,
+		UploadTime:   time.Now(),
+		FilePath:     "/uploads/test-hash",
+	}
+
+	err := db.InsertFile(testFile)
+	require.NoError(t, err)
+
+	t.Run("Get file with its uploads", func(t *testing.T) {
+		require.NoError(t, db.InsertFileUpload(&database.FileUpload{
+			FileID:       testFile.ID,
+			OriginalName: "node2-test.txt",
+			UploadTime:   time.Now().Add(time.Minute),
+		}))
+
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/files/%d", testFile.ID), nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleFileOperations(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.True(t, response["success"].(bool))
+		assert.Equal(t, "test.txt", response["file"].(map[string]interface{})["original_name"])
+
+		uploads := response["uploads"].([]interface{})
+		require.Len(t, uploads, 2)
+		assert.Equal(t, "test.txt", uploads[0].(map[string]interface{})["original_name"])
+		assert.Equal(t, "node2-test.txt", uploads[1].(map[string]interface{})["original_name"])
+	})
+
+	t.Run("Get non-existent file", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/files/99999", nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleFileOperations(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Delete file", func(t *testing.T) {
+		url := fmt.Sprintf("/api/files/%d", testFile.ID)
+		req := httptest.NewRequest("DELETE", url, nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleFileOperations(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		assert.True(t, response["success"].(bool))
+
+		// Verify file was marked as deleted
+		updatedFile, err := db.GetFileByHash("test-hash")
 		require.NoError(t, err)
 		assert.True(t, updatedFile.Deleted)
 	})
@@ -807,115 +1848,1584 @@ func TestHandlers_HandleReports(t *testing.T) {
 	err = db.InsertReport(testReport)
 	require.NoError(t, err)
 
-	t.Run("Get reports for file", func(t *testing.T) {
-		url := fmt.Sprintf("/api/reports/%d", testFile.ID)
-		req := httptest.NewRequest("GET", url, nil)
+	t.Run("Get reports for file", func(t *testing.T) {
+		url := fmt.Sprintf("/api/reports/%d", testFile.ID)
+		req := httptest.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleReports(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		assert.True(t, response["success"].(bool))
+		assert.Contains(t, response, "reports")
+
+		reports := response["reports"].([]interface{})
+		assert.GreaterOrEqual(t, len(reports), 1)
+	})
+
+	t.Run("Reports from older versions are flagged stale", func(t *testing.T) {
+		staleFile := &database.File{
+			Hash:         "stale-report-hash",
+			OriginalName: "stale-report.txt",
+			FileType:     "ttop",
+			FileSize:     100,
+			UploadTime:   time.Now(),
+			FilePath:     "/uploads/stale-report-hash",
+		}
+		require.NoError(t, db.InsertFile(staleFile))
+		oldReport := &database.Report{
+			FileID:      staleFile.ID,
+			ReportType:  "ttop",
+			Status:      "completed",
+			CreatedTime: time.Now().Add(-time.Hour),
+			DDDVersion:  "0.9.0",
+		}
+		require.NoError(t, db.InsertReport(oldReport))
+		currentReport := &database.Report{
+			FileID:      staleFile.ID,
+			ReportType:  "ttop",
+			Status:      "completed",
+			CreatedTime: time.Now(),
+			DDDVersion:  DDDVersion,
+		}
+		require.NoError(t, db.InsertReport(currentReport))
+
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/reports/%d", staleFile.ID), nil)
+		w := httptest.NewRecorder()
+		handler.HandleReports(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		stale := map[float64]bool{}
+		for _, item := range response["reports"].([]interface{}) {
+			report := item.(map[string]interface{})
+			assert.Contains(t, report, "report_type", "Report fields should be listed alongside the stale flag")
+			stale[report["id"].(float64)] = report["stale"].(bool)
+		}
+		require.Len(t, stale, 2)
+		assert.True(t, stale[float64(oldReport.ID)])
+		assert.False(t, stale[float64(currentReport.ID)])
+	})
+
+	t.Run("Get reports for non-existent file", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/reports/99999", nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleReports(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		// Check if reports field exists and handle nil case
+		if reportsField, exists := response["reports"]; exists && reportsField != nil {
+			reports := reportsField.([]interface{})
+			assert.Equal(t, 0, len(reports))
+		} else {
+			// If reports field is nil or doesn't exist, that's also valid for no reports
+			assert.True(t, true) // Test passes
+		}
+	})
+
+	t.Run("Get reports for file with pagination", func(t *testing.T) {
+		for i := 0; i < 4; i++ {
+			require.NoError(t, db.InsertReport(&database.Report{
+				FileID:      testFile.ID,
+				ReportType:  "ttop",
+				Status:      "completed",
+				CreatedTime: time.Now().Add(time.Duration(i) * time.Second),
+				DDDVersion:  "1.0.0",
+			}))
+		}
+
+		url := fmt.Sprintf("/api/reports/%d?limit=2&offset=2", testFile.ID)
+		req := httptest.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleReports(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		assert.Len(t, response["reports"].([]interface{}), 2)
+		assert.Equal(t, float64(5), response["total"])
+		assert.Equal(t, float64(2), response["page"])
+		assert.Equal(t, float64(2), response["page_size"])
+		assert.Equal(t, float64(3), response["total_pages"])
+	})
+
+	t.Run("Get reports for file uses default page size", func(t *testing.T) {
+		url := fmt.Sprintf("/api/reports/%d?limit=bogus", testFile.ID)
+		req := httptest.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleReports(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		assert.Len(t, response["reports"].([]interface{}), 5)
+		assert.Equal(t, float64(20), response["page_size"])
+		assert.Equal(t, float64(1), response["total_pages"])
+	})
+
+	t.Run("Create report reuses an active report of the same type", func(t *testing.T) {
+		createReport := func(reportType string) map[string]interface{} {
+			url := fmt.Sprintf("/api/reports/%d", testFile.ID)
+			req := httptest.NewRequest("POST", url, strings.NewReader(`{"report_type":"`+reportType+`","force":true}`))
+			w := httptest.NewRecorder()
+			handler.HandleReports(w, req)
+			require.Equal(t, http.StatusOK, w.Code)
+
+			var response map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			return response
+		}
+
+		first := createReport("jfr")
+		assert.Equal(t, "Report queued for processing", first["message"])
+		second := createReport("jfr")
+		assert.Equal(t, "Report already queued for processing", second["message"])
+
+		firstID := first["report"].(map[string]interface{})["id"]
+		assert.Equal(t, firstID, second["report"].(map[string]interface{})["id"])
+
+		other := createReport("iostat")
+		assert.Equal(t, "Report queued for processing", other["message"])
+		assert.NotEqual(t, firstID, other["report"].(map[string]interface{})["id"])
+	})
+
+	t.Run("Create report validates the report type against the file", func(t *testing.T) {
+		createReport := func(fileID int, body string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("POST", fmt.Sprintf("/api/reports/%d", fileID), strings.NewReader(body))
+			w := httptest.NewRecorder()
+			handler.HandleReports(w, req)
+			return w
+		}
+
+		w := createReport(testFile.ID, `{"report_type":"thread_dump"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "A thread_dump report can't be generated from a ttop file")
+
+		w = createReport(testFile.ID, `{"report_type":"thread_dump","force":true}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = createReport(testFile.ID, `{"report_type":"pcap","force":true}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "Unknown report type: pcap")
+
+		w = createReport(99999, `{"report_type":"ttop"}`)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestHandlers_HandleReportContent(t *testing.T) {
+	handler, db := setupTestHandler(t)
+
+	// Create test report with content
+	testFile := &database.File{
+		Hash:         "content-test-hash",
+		OriginalName: "content-test.txt",
+		FileType:     "ttop",
+		FileSize:     100,
+		UploadTime:   time.Now(),
+		FilePath:     "/uploads/content-test-hash",
+	}
+
+	err := db.InsertFile(testFile)
+	require.NoError(t, err)
+
+	reportData := `{"type": "ttop", "summary": "detailed report content", "analysis": "comprehensive analysis"}`
+	testReport := &database.Report{
+		FileID:      testFile.ID,
+		ReportType:  "ttop",
+		Status:      "completed",
+		CreatedTime: time.Now(),
+		DDDVersion:  "1.0.0",
+		ReportData:  reportData,
+	}
+
+	err = db.InsertReport(testReport)
+	require.NoError(t, err)
+	require.NoError(t, db.SetReportGenerationTime(testReport.ID, 1200*time.Millisecond))
+
+	t.Run("Get report content", func(t *testing.T) {
+		url := fmt.Sprintf("/api/reports/content/%d", testReport.ID)
+		req := httptest.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleReportContent(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+		var response map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		assert.True(t, response["success"].(bool))
+		assert.Contains(t, response, "report_data")
+		assert.Equal(t, 1200.0, response["generation_ms"])
+
+		// The report_data should contain the JSON report data
+		reportDataStr := response["report_data"].(string)
+		assert.Equal(t, reportData, reportDataStr)
+
+		// Parse the report data to verify it's valid JSON
+		var parsedReportData map[string]interface{}
+		err = json.Unmarshal([]byte(reportDataStr), &parsedReportData)
+		require.NoError(t, err)
+		assert.Equal(t, "ttop", parsedReportData["type"])
+		assert.Equal(t, "detailed report content", parsedReportData["summary"])
+	})
+
+	t.Run("Conditional GET for completed report content", func(t *testing.T) {
+		url := fmt.Sprintf("/api/reports/content/%d", testReport.ID)
+		req := httptest.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleReportContent(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		etag := w.Header().Get("ETag")
+		require.NotEmpty(t, etag)
+		// Revalidated rather than kept, trimming or purging the report changes its data
+		assert.Equal(t, "private, no-cache", w.Header().Get("Cache-Control"))
+
+		req = httptest.NewRequest("GET", url, nil)
+		req.Header.Set("If-None-Match", `"other", `+etag)
+		w = httptest.NewRecorder()
+
+		handler.HandleReportContent(w, req)
+
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Body.String())
+		assert.Equal(t, etag, w.Header().Get("ETag"))
+
+		req = httptest.NewRequest("GET", url, nil)
+		req.Header.Set("If-None-Match", `W/"stale"`)
+		w = httptest.NewRecorder()
+
+		handler.HandleReportContent(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotEmpty(t, w.Body.String())
+	})
+
+	t.Run("Get report content in another theme", func(t *testing.T) {
+		html, err := reporters.GenerateTTopHTML(&reporters.TTopReportData{
+			Snapshots: []reporters.TTopSnapshot{{Timestamp: time.Now(), ThreadCounts: &reporters.ThreadCounts{Total: 1}}},
+		})
+		require.NoError(t, err)
+		data, err := json.Marshal(map[string]any{"type": "ttop", "html_report": html})
+		require.NoError(t, err)
+		themable := &database.Report{
+			FileID:      testFile.ID,
+			ReportType:  "ttop",
+			Status:      "completed",
+			CreatedTime: time.Now(),
+			DDDVersion:  "1.0.0",
+			ReportData:  string(data),
+		}
+		require.NoError(t, db.InsertReport(themable))
+
+		url := fmt.Sprintf("/api/reports/content/%d", themable.ID)
+		plain := httptest.NewRecorder()
+		handler.HandleReportContent(plain, httptest.NewRequest("GET", url, nil))
+		require.Equal(t, http.StatusOK, plain.Code)
+
+		w := httptest.NewRecorder()
+		handler.HandleReportContent(w, httptest.NewRequest("GET", url+"?theme=dark", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		var report map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(response["report_data"].(string)), &report))
+		assert.Equal(t, "dark", report["theme"])
+		assert.Contains(t, report["html_report"], "--report-bg: #0b0f14;")
+
+		// Each theme is cached separately
+		assert.NotEqual(t, plain.Header().Get("ETag"), w.Header().Get("ETag"))
+
+		w = httptest.NewRecorder()
+		handler.HandleReportContent(w, httptest.NewRequest("GET", url+"?theme=neon", nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "Unknown theme")
+	})
+
+	t.Run("Get top report content with another number of top threads", func(t *testing.T) {
+		hash, filePath := testutil.CreateSampleFile(t, handler.cfg.UploadsDir, "top")
+		topFile := &database.File{Hash: hash, OriginalName: "top.txt", FileType: "top", FileSize: 100, UploadTime: time.Now(), FilePath: filePath}
+		require.NoError(t, db.InsertFile(topFile))
+		reporter, ok := reporters.Lookup("top")
+		require.True(t, ok)
+		generated, err := reporter.Generate(context.Background(), filePath, reporters.Options{Location: time.UTC, Theme: "dark"})
+		require.NoError(t, err)
+		topReport := &database.Report{FileID: topFile.ID, ReportType: "top", Status: "completed", CreatedTime: time.Now(), DDDVersion: "1.0.0", ReportData: generated}
+		require.NoError(t, db.InsertReport(topReport))
+
+		url := fmt.Sprintf("/api/reports/content/%d", topReport.ID)
+		plain := httptest.NewRecorder()
+		handler.HandleReportContent(plain, httptest.NewRequest("GET", url, nil))
+		require.Equal(t, http.StatusOK, plain.Code)
+
+		w := httptest.NewRecorder()
+		handler.HandleReportContent(w, httptest.NewRequest("GET", url+"?top_threads=12", nil))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		var report map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(response["report_data"].(string)), &report))
+		assert.Equal(t, 12.0, report["top_threads"])
+		// Regenerated in the theme the report was stored with
+		assert.Equal(t, "dark", report["theme"])
+		assert.Contains(t, report["html_report"], "CPU Usage Over Time (Top 12)")
+		assert.NotEqual(t, plain.Header().Get("ETag"), w.Header().Get("ETag"))
+
+		for _, invalid := range []string{"0", "51", "many"} {
+			w = httptest.NewRecorder()
+			handler.HandleReportContent(w, httptest.NewRequest("GET", url+"?top_threads="+invalid, nil))
+			assert.Equal(t, http.StatusBadRequest, w.Code, invalid)
+		}
+
+		w = httptest.NewRecorder()
+		handler.HandleReportContent(w, httptest.NewRequest("GET", fmt.Sprintf("/api/reports/content/%d?top_threads=12", testReport.ID+1000000), nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		require.NoError(t, db.MarkFileDeleted(topFile.ID))
+		w = httptest.NewRecorder()
+		handler.HandleReportContent(w, httptest.NewRequest("GET", url+"?top_threads=20", nil))
+		assert.Equal(t, http.StatusGone, w.Code)
+	})
+
+	t.Run("Reports regenerated with another number of top threads are bounded and cached", func(t *testing.T) {
+		hash, filePath := testutil.CreateSampleFile(t, handler.cfg.UploadsDir, "top")
+		topFile := &database.File{Hash: "variants-" + hash, OriginalName: "top.txt", FileType: "top", FileSize: 100, UploadTime: time.Now(), FilePath: filePath}
+		require.NoError(t, db.InsertFile(topFile))
+		reporter, ok := reporters.Lookup("top")
+		require.True(t, ok)
+		generated, err := reporter.Generate(context.Background(), filePath, reporters.Options{Location: time.UTC})
+		require.NoError(t, err)
+		topReport := &database.Report{FileID: topFile.ID, ReportType: "top", Status: "completed", CreatedTime: time.Now(), DDDVersion: "1.0.0", ReportData: generated}
+		require.NoError(t, db.InsertReport(topReport))
+		url := fmt.Sprintf("/api/reports/content/%d", topReport.ID)
+
+		// Kept within max_report_bytes like reports generated by the worker
+		maxReportBytes := handler.cfg.MaxReportBytes
+		handler.cfg.MaxReportBytes = 4096
+		w := httptest.NewRecorder()
+		handler.HandleReportContent(w, httptest.NewRequest("GET", url+"?top_threads=7", nil))
+		handler.cfg.MaxReportBytes = maxReportBytes
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.LessOrEqual(t, len(response["report_data"].(string)), 4096)
+		var report map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(response["report_data"].(string)), &report))
+		assert.Equal(t, true, report["truncated"])
+
+		w = httptest.NewRecorder()
+		handler.HandleReportContent(w, httptest.NewRequest("GET", url+"?top_threads=9", nil))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		first := w.Body.String()
+
+		// Served from the cache without parsing the file again
+		require.NoError(t, os.Remove(filePath))
+		w = httptest.NewRecorder()
+		handler.HandleReportContent(w, httptest.NewRequest("GET", url+"?top_threads=9", nil))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, first, w.Body.String())
+
+		// A count that was never generated needs the file
+		w = httptest.NewRecorder()
+		handler.HandleReportContent(w, httptest.NewRequest("GET", url+"?top_threads=11", nil))
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+		// Changing the stored report drops its cached variants
+		require.NoError(t, db.CompleteReport(topReport.ID, generated+" "))
+		w = httptest.NewRecorder()
+		handler.HandleReportContent(w, httptest.NewRequest("GET", url+"?top_threads=9", nil))
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+
+	t.Run("Regenerating a report gives up after the report timeout", func(t *testing.T) {
+		ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+		defer cancel()
+		hash, filePath := testutil.CreateSampleFile(t, handler.cfg.UploadsDir, "ttop")
+		ttopFile := &database.File{Hash: hash, OriginalName: "ttop.txt", FileType: "ttop", FileSize: 100, UploadTime: time.Now(), FilePath: filePath}
+		require.NoError(t, db.InsertFile(ttopFile))
+		ttopReport := &database.Report{FileID: ttopFile.ID, ReportType: "ttop", Status: "completed", CreatedTime: time.Now(), DDDVersion: "1.0.0", ReportData: `{"type": "ttop"}`}
+		require.NoError(t, db.InsertReport(ttopReport))
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/reports/content/%d?top_threads=5", ttopReport.ID), nil).WithContext(ctx)
+		handler.HandleReportContent(w, req)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Contains(t, w.Body.String(), "report_timeout_seconds")
+
+		// Turned away rather than queued while another report is regenerated
+		handler.variants.slot <- struct{}{}
+		w = httptest.NewRecorder()
+		handler.HandleReportContent(w, httptest.NewRequest("GET", fmt.Sprintf("/api/reports/content/%d?top_threads=6", ttopReport.ID), nil))
+		<-handler.variants.slot
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, "5", w.Header().Get("Retry-After"))
+		assert.Contains(t, w.Body.String(), "Another report is being regenerated")
+	})
+
+	t.Run("Regenerating a report finishes well before the write timeout", func(t *testing.T) {
+		reportTimeout, writeTimeout := handler.cfg.ReportTimeoutSeconds, handler.cfg.WriteTimeoutSeconds
+		defer func() {
+			handler.cfg.ReportTimeoutSeconds, handler.cfg.WriteTimeoutSeconds = reportTimeout, writeTimeout
+		}()
+
+		handler.cfg.ReportTimeoutSeconds, handler.cfg.WriteTimeoutSeconds = 600, 15
+		assert.Equal(t, 7500*time.Millisecond, handler.regenerationTimeout())
+		handler.cfg.ReportTimeoutSeconds = 0
+		assert.Equal(t, 7500*time.Millisecond, handler.regenerationTimeout())
+		handler.cfg.ReportTimeoutSeconds, handler.cfg.WriteTimeoutSeconds = 5, 15
+		assert.Equal(t, 5*time.Second, handler.regenerationTimeout())
+		handler.cfg.ReportTimeoutSeconds, handler.cfg.WriteTimeoutSeconds = 600, 0
+		assert.Equal(t, 600*time.Second, handler.regenerationTimeout())
+	})
+
+	t.Run("Top threads only applies to ttop and top reports", func(t *testing.T) {
+		iostatReport := &database.Report{FileID: testFile.ID, ReportType: "iostat", Status: "completed", CreatedTime: time.Now(), DDDVersion: "1.0.0", ReportData: `{"type": "iostat"}`}
+		require.NoError(t, db.InsertReport(iostatReport))
+
+		w := httptest.NewRecorder()
+		handler.HandleReportContent(w, httptest.NewRequest("GET", fmt.Sprintf("/api/reports/content/%d?top_threads=12", iostatReport.ID), nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "only applies to ttop and top reports")
+	})
+
+	t.Run("Unfinished report content must be revalidated", func(t *testing.T) {
+		pending := &database.Report{
+			FileID:      testFile.ID,
+			ReportType:  "jfr",
+			Status:      "pending",
+			CreatedTime: time.Now(),
+			DDDVersion:  "1.0.0",
+		}
+		require.NoError(t, db.InsertReport(pending))
+
+		url := fmt.Sprintf("/api/reports/content/%d", pending.ID)
+		req := httptest.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleReportContent(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
+		pendingETag := w.Header().Get("ETag")
+
+		// Completing the report changes its ETag, so cached copies are replaced
+		require.NoError(t, db.CompleteReport(pending.ID, `{"type": "jfr"}`))
+		req = httptest.NewRequest("GET", url, nil)
+		req.Header.Set("If-None-Match", pendingETag)
+		w = httptest.NewRecorder()
+
+		handler.HandleReportContent(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotEqual(t, pendingETag, w.Header().Get("ETag"))
+	})
+
+	t.Run("Get content for non-existent report", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/reports/content/99999", nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleReportContent(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestHandlers_HandleReportMeta(t *testing.T) {
+	handler, db := setupTestHandler(t)
+
+	testFile := &database.File{
+		Hash:         "meta-test-hash",
+		OriginalName: "meta-test.txt",
+		FileType:     "ttop",
+		FileSize:     100,
+		UploadTime:   time.Now(),
+		FilePath:     "/uploads/meta-test-hash",
+	}
+	require.NoError(t, db.InsertFile(testFile))
+
+	testReport := &database.Report{
+		FileID:      testFile.ID,
+		ReportType:  "ttop",
+		Status:      "completed",
+		CreatedTime: time.Now(),
+		DDDVersion:  "1.0.0",
+		ReportData:  `{"type":"ttop","html_report":"<html>large</html>"}`,
+	}
+	require.NoError(t, db.InsertReport(testReport))
+	require.NoError(t, db.SetReportGenerationTime(testReport.ID, 1200*time.Millisecond))
+
+	t.Run("Get report metadata without its data", func(t *testing.T) {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/reports/meta/%d", testReport.ID), nil)
+		w := httptest.NewRecorder()
+		handler.HandleReportMeta(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), "report_data")
+		assert.NotContains(t, w.Body.String(), "large")
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.True(t, response["success"].(bool))
+		report := response["report"].(map[string]interface{})
+		assert.Equal(t, float64(testReport.ID), report["id"])
+		assert.Equal(t, float64(testFile.ID), report["file_id"])
+		assert.Equal(t, "ttop", report["report_type"])
+		assert.Equal(t, "completed", report["status"])
+		assert.Equal(t, 1200.0, report["generation_ms"])
+		assert.Contains(t, report, "created_time")
+		assert.Contains(t, report, "stale")
+	})
+
+	t.Run("Report not found", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/reports/meta/99999", nil)
+		w := httptest.NewRecorder()
+		handler.HandleReportMeta(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Invalid report ID", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/reports/meta/abc", nil)
+		w := httptest.NewRecorder()
+		handler.HandleReportMeta(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Method not allowed", func(t *testing.T) {
+		req := httptest.NewRequest("POST", fmt.Sprintf("/api/reports/meta/%d", testReport.ID), nil)
+		w := httptest.NewRecorder()
+		handler.HandleReportMeta(w, req)
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
+
+func TestHandlers_HandleFailedReports(t *testing.T) {
+	handler, db := setupTestHandler(t)
+
+	for i := 0; i < 3; i++ {
+		file := &database.File{
+			Hash:         fmt.Sprintf("failed-test-hash-%d", i),
+			OriginalName: fmt.Sprintf("failed-test-%d.txt", i),
+			FileType:     "iostat",
+			FileSize:     100,
+			UploadTime:   time.Now(),
+			FilePath:     fmt.Sprintf("/uploads/failed-test-hash-%d", i),
+		}
+		require.NoError(t, db.InsertFile(file))
+		report := &database.Report{FileID: file.ID, ReportType: "iostat", Status: "pending", CreatedTime: time.Now(), DDDVersion: "1.0.0"}
+		require.NoError(t, db.InsertReport(report))
+		require.NoError(t, db.UpdateReport(report.ID, "failed", "", fmt.Sprintf("parse error %d", i)))
+	}
+
+	t.Run("List failed reports a page at a time", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/reports/failed?limit=2&offset=0", nil)
+		w := httptest.NewRecorder()
+		handler.HandleFailedReports(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.True(t, response["success"].(bool))
+		assert.Equal(t, 3.0, response["total"])
+		assert.Equal(t, 2.0, response["total_pages"])
+		reports := response["reports"].([]interface{})
+		require.Len(t, reports, 2)
+		report := reports[0].(map[string]interface{})
+		assert.Equal(t, "failed", report["status"])
+		assert.Equal(t, "parse error 2", report["error_message"])
+		assert.Equal(t, "failed-test-2.txt", report["file_name"])
+		assert.Equal(t, "iostat", report["file_type"])
+	})
+
+	t.Run("Search failed reports", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/reports/failed?search=error+1", nil)
+		w := httptest.NewRecorder()
+		handler.HandleFailedReports(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 1.0, response["total"])
+		reports := response["reports"].([]interface{})
+		require.Len(t, reports, 1)
+		assert.Equal(t, "failed-test-1.txt", reports[0].(map[string]interface{})["file_name"])
+	})
+
+	t.Run("Method not allowed", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/reports/failed", nil)
+		w := httptest.NewRecorder()
+		handler.HandleFailedReports(w, req)
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
+
+func TestHandlers_HandleRetryFailed(t *testing.T) {
+	handler, db := setupTestHandler(t)
+
+	createFile := func(t *testing.T, sampleType string, node int) *database.File {
+		content := append(append([]byte{}, testutil.SampleFiles[sampleType].Content...), []byte(strings.Repeat("\n", node))...)
+		hash, filePath := testutil.CreateTestFile(t, handler.cfg.UploadsDir, testutil.TestFile{
+			Name:    sampleType + ".txt",
+			Content: content,
+		})
+		file := &database.File{
+			Hash:         hash,
+			OriginalName: fmt.Sprintf("node%d-%s.txt", node, sampleType),
+			FileType:     sampleType,
+			FileSize:     int64(len(content)),
+			UploadTime:   time.Now(),
+			FilePath:     filePath,
+		}
+		require.NoError(t, db.InsertFile(file))
+		return file
+	}
+	createFailedReport := func(t *testing.T, file *database.File) *database.Report {
+		report := &database.Report{FileID: file.ID, ReportType: file.FileType, Status: "pending", CreatedTime: time.Now(), DDDVersion: "0.9.0"}
+		require.NoError(t, db.InsertReport(report))
+		require.NoError(t, db.UpdateReport(report.ID, "failed", "", "missing dependency"))
+		return report
+	}
+	retry := func(method, query string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		req := httptest.NewRequest(method, "/api/reports/failed/retry"+query, nil)
+		w := httptest.NewRecorder()
+		handler.HandleRetryFailed(w, req)
+		var response map[string]interface{}
+		_ = json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response
+	}
+	status := func(t *testing.T, report *database.Report) string {
+		current, err := db.GetReportMetaByID(report.ID)
+		require.NoError(t, err)
+		return current.Status
+	}
+
+	iostatFile := createFile(t, "iostat", 1)
+	iostatFailed := createFailedReport(t, iostatFile)
+	// Two failures of the same report only need one retry
+	iostatFailedAgain := createFailedReport(t, iostatFile)
+	ttopFile := createFile(t, "ttop", 2)
+	ttopFailed := createFailedReport(t, ttopFile)
+	goneFile := createFile(t, "iostat", 3)
+	goneFailed := createFailedReport(t, goneFile)
+	require.NoError(t, os.Remove(goneFile.FilePath))
+
+	t.Run("Unknown report type", func(t *testing.T) {
+		w, _ := retry("POST", "?type=spreadsheet")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Retry only one report type", func(t *testing.T) {
+		w, response := retry("POST", "?type=ttop")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, 1.0, response["queued"])
+		assert.Equal(t, 0.0, response["skipped"])
+		assert.Equal(t, "pending", status(t, ttopFailed))
+		assert.Equal(t, "failed", status(t, iostatFailed))
+
+		requeued, err := db.GetReportMetaByID(ttopFailed.ID)
+		require.NoError(t, err)
+		assert.Empty(t, requeued.ErrorMessage)
+		assert.Nil(t, requeued.CompletedTime)
+		assert.Equal(t, DDDVersion, requeued.DDDVersion)
+	})
+
+	t.Run("Retry every failed report", func(t *testing.T) {
+		w, response := retry("POST", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, 1.0, response["queued"])
+		assert.Equal(t, 1.0, response["skipped"], "The file behind one failure is gone")
+		assert.Equal(t, 1.0, response["already_queued"])
+		assert.Equal(t, "failed", status(t, goneFailed))
+
+		pending := 0
+		for _, report := range []*database.Report{iostatFailed, iostatFailedAgain} {
+			if status(t, report) == "pending" {
+				pending++
+			}
+		}
+		assert.Equal(t, 1, pending)
+	})
+
+	t.Run("Method not allowed", func(t *testing.T) {
+		w, _ := retry("GET", "")
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
+
+func TestHandlers_HandleReportPriority(t *testing.T) {
+	handler, db := setupTestHandler(t)
+
+	file := &database.File{
+		Hash:         "priority-test-hash",
+		OriginalName: "priority-test.txt",
+		FileType:     "ttop",
+		FileSize:     100,
+		UploadTime:   time.Now(),
+		FilePath:     "/uploads/priority-test-hash",
+	}
+	require.NoError(t, db.InsertFile(file))
+
+	pending := &database.Report{FileID: file.ID, ReportType: "ttop", Status: "pending", CreatedTime: time.Now(), DDDVersion: "1.0.0"}
+	require.NoError(t, db.InsertReport(pending))
+	completed := &database.Report{FileID: file.ID, ReportType: "ttop", Status: "completed", CreatedTime: time.Now(), DDDVersion: "1.0.0"}
+	require.NoError(t, db.InsertReport(completed))
+
+	setPriority := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		// Routed through HandleReports like the /api/reports/ mux entry
+		handler.HandleReports(w, req)
+		return w
+	}
+
+	t.Run("Bump a pending report", func(t *testing.T) {
+		w := setPriority("PUT", fmt.Sprintf("/api/reports/%d/priority", pending.ID), `{"priority": 10}`)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.True(t, response["success"].(bool))
+		assert.Equal(t, 10.0, response["report"].(map[string]interface{})["priority"])
+
+		queue, err := db.GetPendingReports()
+		require.NoError(t, err)
+		require.NotEmpty(t, queue)
+		assert.Equal(t, pending.ID, queue[0].ID)
+		assert.Equal(t, 10, queue[0].Priority)
+	})
+
+	t.Run("Reject reports that are not pending", func(t *testing.T) {
+		w := setPriority("PUT", fmt.Sprintf("/api/reports/%d/priority", completed.ID), `{"priority": 10}`)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), ErrCodeConflict)
+		assert.Contains(t, w.Body.String(), "Report is completed")
+	})
+
+	t.Run("Reject missing priority", func(t *testing.T) {
+		w := setPriority("PUT", fmt.Sprintf("/api/reports/%d/priority", pending.ID), `{}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = setPriority("PUT", fmt.Sprintf("/api/reports/%d/priority", pending.ID), `{"priority": "high"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Unknown report", func(t *testing.T) {
+		w := setPriority("PUT", "/api/reports/99999/priority", `{"priority": 1}`)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Method not allowed", func(t *testing.T) {
+		w := setPriority("POST", fmt.Sprintf("/api/reports/%d/priority", pending.ID), `{"priority": 1}`)
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
+
+func TestHandlers_HandleStarReport(t *testing.T) {
+	handler, db := setupTestHandler(t)
+
+	file := &database.File{
+		Hash:         "star-test-hash",
+		OriginalName: "star-test.txt",
+		FileType:     "ttop",
+		FileSize:     100,
+		UploadTime:   time.Now(),
+		FilePath:     "/uploads/star-test-hash",
+	}
+	require.NoError(t, db.InsertFile(file))
+
+	important := &database.Report{FileID: file.ID, ReportType: "ttop", Status: "completed", CreatedTime: time.Now(), DDDVersion: "1.0.0"}
+	require.NoError(t, db.InsertReport(important))
+	other := &database.Report{FileID: file.ID, ReportType: "ttop", Status: "completed", CreatedTime: time.Now(), DDDVersion: "1.0.0"}
+	require.NoError(t, db.InsertReport(other))
+
+	star := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		// Routed through HandleReports like the /api/reports/ mux entry
+		handler.HandleReports(w, req)
+		return w
+	}
+
+	t.Run("Star and unstar a report", func(t *testing.T) {
+		w := star("PUT", fmt.Sprintf("/api/reports/%d/star", important.ID), `{"starred": true}`)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.True(t, response["success"].(bool))
+		assert.Equal(t, "Report starred", response["message"])
+		assert.Equal(t, true, response["report"].(map[string]interface{})["starred"])
+
+		stored, err := db.GetReportByID(important.ID)
+		require.NoError(t, err)
+		assert.True(t, stored.Starred)
+
+		w = star("PUT", fmt.Sprintf("/api/reports/%d/star", other.ID), `{"starred": false}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "Report unstarred")
+	})
+
+	t.Run("Filter the listing to starred reports", func(t *testing.T) {
+		require.NoError(t, db.SetReportStarred(important.ID, true))
+
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/reports/%d?starred=true", file.ID), nil)
+		w := httptest.NewRecorder()
+		handler.HandleReports(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Reports []database.Report `json:"reports"`
+			Total   int               `json:"total"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Reports, 1)
+		assert.Equal(t, important.ID, response.Reports[0].ID)
+		assert.True(t, response.Reports[0].Starred)
+		assert.Equal(t, 1, response.Total)
+
+		// Without the filter every report is listed
+		req = httptest.NewRequest("GET", fmt.Sprintf("/api/reports/%d", file.ID), nil)
+		w = httptest.NewRecorder()
+		handler.HandleReports(w, req)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Len(t, response.Reports, 2)
+		assert.Equal(t, 2, response.Total)
+	})
+
+	t.Run("Reject missing starred field", func(t *testing.T) {
+		w := star("PUT", fmt.Sprintf("/api/reports/%d/star", important.ID), `{}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = star("PUT", fmt.Sprintf("/api/reports/%d/star", important.ID), `not json`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Unknown report", func(t *testing.T) {
+		w := star("PUT", "/api/reports/99999/star", `{"starred": true}`)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Method not allowed", func(t *testing.T) {
+		w := star("POST", fmt.Sprintf("/api/reports/%d/star", important.ID), `{"starred": true}`)
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
+
+func TestHandlers_HandleCancelReport(t *testing.T) {
+	handler, db := setupTestHandler(t)
+
+	file := &database.File{
+		Hash:         "cancel-test-hash",
+		OriginalName: "cancel-test.jfr",
+		FileType:     "jfr",
+		FileSize:     100,
+		UploadTime:   time.Now(),
+		FilePath:     "/uploads/cancel-test-hash",
+	}
+	require.NoError(t, db.InsertFile(file))
+
+	// A file has one queued report of each type, so each report gets its own copy of the file
+	newReport := func(status string) *database.Report {
+		copied := *file
+		copied.Hash = fmt.Sprintf("%s-%d", file.Hash, time.Now().UnixNano())
+		require.NoError(t, db.InsertFile(&copied))
+		report := &database.Report{FileID: copied.ID, ReportType: "jfr", Status: status, CreatedTime: time.Now(), DDDVersion: "1.0.0"}
+		require.NoError(t, db.InsertReport(report))
+		return report
+	}
+
+	cancel := func(method string, reportID int) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, fmt.Sprintf("/api/reports/%d/cancel", reportID), nil)
+		w := httptest.NewRecorder()
+		// Routed through HandleReports like the /api/reports/ mux entry
+		handler.HandleReports(w, req)
+		return w
+	}
+
+	t.Run("Cancel a pending report", func(t *testing.T) {
+		pending := newReport("pending")
+
+		w := cancel("POST", pending.ID)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.True(t, response["success"].(bool))
+		assert.Equal(t, "cancelled", response["report"].(map[string]interface{})["status"])
+
+		cancelled, err := db.GetReportByID(pending.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "cancelled", cancelled.Status)
+		assert.Equal(t, cancelledPendingMessage, cancelled.ErrorMessage)
+
+		queue, err := db.GetPendingReports()
+		require.NoError(t, err)
+		for _, report := range queue {
+			assert.NotEqual(t, pending.ID, report.ID, "Cancelled reports leave the queue")
+		}
+	})
+
+	t.Run("Cancel a running report", func(t *testing.T) {
+		running := newReport("running")
+		canceller := &mockReportWorker{running: map[int]bool{running.ID: true}}
+		handler.reportWorker = canceller
+		defer func() { handler.reportWorker = nil }()
+
+		w := cancel("POST", running.ID)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, []int{running.ID}, canceller.cancelled)
+		assert.Contains(t, w.Body.String(), "Report cancellation requested")
+
+		// The worker records the cancellation once the reporter stops
+		report, err := db.GetReportByID(running.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "running", report.Status)
+	})
+
+	t.Run("Reject running reports the worker is not generating", func(t *testing.T) {
+		running := newReport("running")
+
+		w := cancel("POST", running.ID)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), ErrCodeConflict)
+	})
+
+	t.Run("Reject finished reports", func(t *testing.T) {
+		completed := newReport("completed")
+
+		w := cancel("POST", completed.ID)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), "Report is completed")
+	})
+
+	t.Run("Unknown report", func(t *testing.T) {
+		w := cancel("POST", 99999)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Method not allowed", func(t *testing.T) {
+		pending := newReport("pending")
+
+		w := cancel("GET", pending.ID)
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
+
+func TestHandlers_QueueReportConcurrently(t *testing.T) {
+	handler, db := setupTestHandler(t)
+
+	file := &database.File{Hash: "queue-race-hash", OriginalName: "ttop.txt", FileType: "ttop", FileSize: 100, UploadTime: time.Now(), FilePath: "/uploads/queue-race-hash"}
+	require.NoError(t, db.InsertFile(file))
+
+	// E.g. a double-clicked Generate, or a multi-file upload racing a redetect
+	const requests = 8
+	reports := make([]*database.Report, requests)
+	created := make([]bool, requests)
+	errs := make([]error, requests)
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			reports[i], created[i], errs[i] = handler.queueReport(file.ID, "ttop")
+		}(i)
+	}
+	wg.Wait()
+
+	createdCount := 0
+	for i := 0; i < requests; i++ {
+		require.NoError(t, errs[i])
+		assert.Equal(t, reports[0].ID, reports[i].ID, "Every request gets the same queued report")
+		if created[i] {
+			createdCount++
+		}
+	}
+	assert.Equal(t, 1, createdCount)
+
+	count, err := db.CountReportsByStatus("pending")
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestHandlers_HandleStandaloneReport(t *testing.T) {
+	handler, db := setupTestHandler(t)
+
+	file := &database.File{
+		Hash:         "standalone-test-hash",
+		OriginalName: "ttop.txt",
+		FileType:     "ttop",
+		FileSize:     100,
+		UploadTime:   time.Now(),
+		FilePath:     "/uploads/standalone-test-hash",
+	}
+	require.NoError(t, db.InsertFile(file))
+
+	html, err := reporters.GenerateTTopHTML(&reporters.TTopReportData{
+		Snapshots: []reporters.TTopSnapshot{{Timestamp: time.Now(), ThreadCounts: &reporters.ThreadCounts{Total: 1}}},
+	})
+	require.NoError(t, err)
+	data, err := json.Marshal(map[string]any{"type": "ttop", "summary": "standalone summary", "html_report": html})
+	require.NoError(t, err)
+
+	completed := &database.Report{FileID: file.ID, ReportType: "ttop", Status: "completed", CreatedTime: time.Now(), DDDVersion: "1.0.0", ReportData: string(data)}
+	require.NoError(t, db.InsertReport(completed))
+	pending := &database.Report{FileID: file.ID, ReportType: "ttop", Status: "pending", CreatedTime: time.Now(), DDDVersion: "1.0.0"}
+	require.NoError(t, db.InsertReport(pending))
+
+	download := func(method, url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.HandleReports(w, httptest.NewRequest(method, url, nil))
+		return w
+	}
+
+	t.Run("Download a completed report", func(t *testing.T) {
+		w := download("GET", fmt.Sprintf("/api/reports/%d/standalone.html", completed.ID))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Header().Get("Content-Disposition"), fmt.Sprintf(`filename="ddd-ttop-report-%d.html"`, completed.ID))
+
+		body := w.Body.String()
+		assert.NotContains(t, body, `src="/static/js/echarts.min.js"`)
+		assert.Contains(t, body, "echarts")
+		assert.Contains(t, body, "standalone summary")
+		assert.NoError(t, reporters.ValidateSelfContained(body))
+	})
+
+	t.Run("Download in another theme", func(t *testing.T) {
+		w := download("GET", fmt.Sprintf("/api/reports/%d/standalone.html?theme=dark", completed.ID))
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "--report-bg: #0b0f14;")
+
+		w = download("GET", fmt.Sprintf("/api/reports/%d/standalone.html?theme=neon", completed.ID))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Unfinished reports can't be downloaded", func(t *testing.T) {
+		w := download("GET", fmt.Sprintf("/api/reports/%d/standalone.html", pending.ID))
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), "only completed reports can be downloaded")
+	})
+
+	t.Run("Missing report", func(t *testing.T) {
+		w := download("GET", "/api/reports/99999/standalone.html")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Method not allowed", func(t *testing.T) {
+		w := download("POST", fmt.Sprintf("/api/reports/%d/standalone.html", completed.ID))
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
+
+func TestHandlers_HandleReportChart(t *testing.T) {
+	handler, db := setupTestHandler(t)
+
+	hash, filePath := testutil.CreateSampleFile(t, handler.cfg.UploadsDir, "iostat")
+	file := &database.File{Hash: hash, OriginalName: "iostat.txt", FileType: "iostat", FileSize: 100, UploadTime: time.Now(), FilePath: filePath}
+	require.NoError(t, db.InsertFile(file))
+
+	reporter, ok := reporters.Lookup("iostat")
+	require.True(t, ok)
+	data, err := reporter.Generate(context.Background(), filePath, reporters.Options{Location: time.UTC})
+	require.NoError(t, err)
+
+	completed := &database.Report{FileID: file.ID, ReportType: "iostat", Status: "completed", CreatedTime: time.Now(), DDDVersion: "1.0.0", ReportData: data}
+	require.NoError(t, db.InsertReport(completed))
+	pending := &database.Report{FileID: file.ID, ReportType: "iostat", Status: "pending", CreatedTime: time.Now(), DDDVersion: "1.0.0"}
+	require.NoError(t, db.InsertReport(pending))
+
+	get := func(method, url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.HandleReports(w, httptest.NewRequest(method, url, nil))
+		return w
+	}
+
+	t.Run("Get the data of one chart", func(t *testing.T) {
+		for _, name := range reporters.IOStatChartNames {
+			w := get("GET", fmt.Sprintf("/api/reports/%d/chart/%s", completed.ID, name))
+			require.Equal(t, http.StatusOK, w.Code, name)
+			assert.Equal(t, "private, no-cache", w.Header().Get("Cache-Control"))
+			etag := w.Header().Get("ETag")
+			require.NotEmpty(t, etag, name)
+
+			var response struct {
+				Success bool `json:"success"`
+				Chart   struct {
+					Labels []string          `json:"labels"`
+					Series []json.RawMessage `json:"series"`
+				} `json:"chart"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response), name)
+			assert.True(t, response.Success)
+			assert.NotEmpty(t, response.Chart.Labels, name)
+			assert.NotEmpty(t, response.Chart.Series, name)
+
+			req := httptest.NewRequest("GET", fmt.Sprintf("/api/reports/%d/chart/%s", completed.ID, name), nil)
+			req.Header.Set("If-None-Match", etag)
+			w = httptest.NewRecorder()
+			handler.HandleReports(w, req)
+			assert.Equal(t, http.StatusNotModified, w.Code, name)
+		}
+	})
+
+	t.Run("The report content leaves out the chart data", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.HandleReportContent(w, httptest.NewRequest("GET", fmt.Sprintf("/api/reports/content/%d", completed.ID), nil))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			ReportData string `json:"report_data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		var report map[string]any
+		require.NoError(t, json.Unmarshal([]byte(response.ReportData), &report))
+		assert.NotContains(t, report, "chart_data")
+		assert.Contains(t, report["html_report"], `data-chart="cpu"`)
+	})
+
+	t.Run("Standalone exports carry the chart data", func(t *testing.T) {
+		w := get("GET", fmt.Sprintf("/api/reports/%d/standalone.html", completed.ID))
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"chart_data":{`)
+		assert.NoError(t, reporters.ValidateSelfContained(w.Body.String()))
+	})
+
+	t.Run("Unknown chart", func(t *testing.T) {
+		w := get("GET", fmt.Sprintf("/api/reports/%d/chart/nope", completed.ID))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), `has no chart \"nope\"`)
+	})
+
+	t.Run("Unfinished reports have no charts", func(t *testing.T) {
+		w := get("GET", fmt.Sprintf("/api/reports/%d/chart/cpu", pending.ID))
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Missing report", func(t *testing.T) {
+		w := get("GET", "/api/reports/99999/chart/cpu")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Invalid report ID", func(t *testing.T) {
+		w := get("GET", "/api/reports/abc/chart/cpu")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Method not allowed", func(t *testing.T) {
+		w := get("POST", fmt.Sprintf("/api/reports/%d/chart/cpu", completed.ID))
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
+
+func TestHandlers_HandleReportLogs(t *testing.T) {
+	handler, db := setupTestHandler(t)
+
+	testFile := &database.File{
+		Hash:         "logs-test-hash",
+		OriginalName: "iostat.txt",
+		FileType:     "iostat",
+		FileSize:     100,
+		UploadTime:   time.Now(),
+		FilePath:     "/uploads/logs-test-hash",
+	}
+	require.NoError(t, db.InsertFile(testFile))
+
+	testReport := &database.Report{
+		FileID:      testFile.ID,
+		ReportType:  "iostat",
+		Status:      "completed",
+		CreatedTime: time.Now(),
+		DDDVersion:  "1.0.0",
+	}
+	require.NoError(t, db.InsertReport(testReport))
+
+	for _, entry := range []struct{ level, message string }{
+		{"INFO", "Parsed 3 snapshots"},
+		{"WARN", "Found 2 threshold breaches"},
+		{"INFO", "Report completed in 12ms"},
+	} {
+		require.NoError(t, db.InsertReportLog(&database.ReportLog{
+			ReportID:  testReport.ID,
+			Timestamp: time.Now(),
+			Level:     entry.level,
+			Message:   entry.message,
+		}))
+	}
+
+	getLogs := func(t *testing.T, url string) []interface{} {
+		t.Helper()
+		req := httptest.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleReports(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.True(t, response["success"].(bool))
+		assert.Equal(t, float64(testReport.ID), response["report_id"])
+		return response["logs"].([]interface{})
+	}
+
+	t.Run("All logs in order", func(t *testing.T) {
+		logs := getLogs(t, fmt.Sprintf("/api/reports/%d/logs", testReport.ID))
+		require.Len(t, logs, 3)
+		first := logs[0].(map[string]interface{})
+		assert.Equal(t, "INFO", first["level"])
+		assert.Equal(t, "Parsed 3 snapshots", first["message"])
+		assert.Contains(t, first, "timestamp")
+	})
+
+	t.Run("Filter by level", func(t *testing.T) {
+		logs := getLogs(t, fmt.Sprintf("/api/reports/%d/logs?level=warn", testReport.ID))
+		require.Len(t, logs, 1)
+		assert.Equal(t, "Found 2 threshold breaches", logs[0].(map[string]interface{})["message"])
+	})
+
+	t.Run("Search messages", func(t *testing.T) {
+		logs := getLogs(t, fmt.Sprintf("/api/reports/%d/logs?search=COMPLETED", testReport.ID))
+		require.Len(t, logs, 1)
+		assert.Equal(t, "Report completed in 12ms", logs[0].(map[string]interface{})["message"])
+	})
+
+	t.Run("Non-existent report", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/reports/99999/logs", nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleReports(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Invalid method", func(t *testing.T) {
+		req := httptest.NewRequest("POST", fmt.Sprintf("/api/reports/%d/logs", testReport.ID), nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleReports(w, req)
+
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+
+	t.Run("Logs are removed with the report", func(t *testing.T) {
+		require.NoError(t, db.DeleteReport(testReport.ID))
+
+		logs, err := db.GetReportLogs(testReport.ID, "", "")
+		require.NoError(t, err)
+		assert.Empty(t, logs)
+	})
+}
+
+func TestHandlers_HandleReportEvents(t *testing.T) {
+	db := testDB(t)
+	broker := events.NewBroker()
+	handler := New(db, testutil.TestConfig(t), &mockCleanupWorker{}, nil, broker)
+
+	testFile := &database.File{
+		Hash:         "events-test-hash",
+		OriginalName: "ttop.txt",
+		FileType:     "ttop",
+		FileSize:     100,
+		UploadTime:   time.Now(),
+		FilePath:     "/uploads/events-test-hash",
+	}
+	require.NoError(t, db.InsertFile(testFile))
+
+	insertReport := func(t *testing.T, status string) *database.Report {
+		t.Helper()
+		report := &database.Report{
+			FileID:      testFile.ID,
+			ReportType:  "ttop",
+			Status:      status,
+			CreatedTime: time.Now(),
+			DDDVersion:  "1.0.0",
+		}
+		require.NoError(t, db.InsertReport(report))
+		return report
+	}
+
+	t.Run("Terminal report sends status and closes", func(t *testing.T) {
+		report := insertReport(t, "completed")
+
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/reports/%d/events", report.ID), nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleReports(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+		body := w.Body.String()
+		assert.Contains(t, body, "event: status\ndata: {\"type\":\"status\",\"report_id\":"+strconv.Itoa(report.ID)+",\"status\":\"completed\"")
+		assert.True(t, strings.HasSuffix(body, "event: done\ndata: {}\n\n"))
+		assert.Equal(t, 0, broker.SubscriberCount(report.ID))
+	})
+
+	t.Run("Streams transitions until the report finishes", func(t *testing.T) {
+		report := insertReport(t, "pending")
+
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/reports/%d/events", report.ID), nil)
+		w := httptest.NewRecorder()
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			handler.HandleReports(w, req)
+		}()
+
+		require.Eventually(t, func() bool {
+			return broker.SubscriberCount(report.ID) == 1
+		}, time.Second, 10*time.Millisecond)
+
+		broker.PublishStatus(report.ID, "running", "")
+		broker.PublishProgress(report.ID, "INFO", "Parsed 2 snapshots")
+		broker.PublishStatus(report.ID, "completed", "")
+
+		select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+			t.Fatal("event stream did not close after the report completed")
+		}
+
+		body := w.Body.String()
+		pending := strings.Index(body, `"status":"pending"`)
+		running := strings.Index(body, `"status":"running"`)
+		progress := strings.Index(body, "event: progress\ndata: ")
+		completed := strings.Index(body, `"status":"completed"`)
+		assert.True(t, pending >= 0 && pending < running && running < progress && progress < completed,
+			"events out of order: %s", body)
+		assert.Contains(t, body, `"message":"Parsed 2 snapshots"`)
+		assert.True(t, strings.HasSuffix(body, "event: done\ndata: {}\n\n"))
+	})
+
+	t.Run("Non-existent report", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/reports/99999/events", nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleReports(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, 0, broker.SubscriberCount(99999))
+	})
+
+	t.Run("Unavailable without a broker", func(t *testing.T) {
+		noEvents, _ := setupTestHandler(t)
+		req := httptest.NewRequest("GET", "/api/reports/1/events", nil)
+		w := httptest.NewRecorder()
+
+		noEvents.HandleReports(w, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+}
+
+func TestHandlers_HandleSearchReports(t *testing.T) {
+	handler, db := setupTestHandler(t)
+
+	testFile := &database.File{
+		Hash:         "search-test-hash",
+		OriginalName: "jstack.txt",
+		FileType:     "thread_dump",
+		FileSize:     100,
+		UploadTime:   time.Now(),
+		FilePath:     "/uploads/search-test-hash",
+	}
+	require.NoError(t, db.InsertFile(testFile))
+
+	testReport := &database.Report{
+		FileID:      testFile.ID,
+		ReportType:  "thread_dump",
+		Status:      "completed",
+		CreatedTime: time.Now(),
+		DDDVersion:  "1.0.0",
+		ReportData:  `{"type":"thread_dump","analysis":"2 threads blocked on <lock> held by \"pool-7-worker\""}`,
+	}
+	require.NoError(t, db.InsertReport(testReport))
+
+	t.Run("Returns matching reports with snippets", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/search?q=pool-7-worker", nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleSearchReports(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.True(t, response["success"].(bool))
+		assert.Equal(t, "pool-7-worker", response["query"])
+
+		results := response["results"].([]interface{})
+		require.Len(t, results, 1)
+		result := results[0].(map[string]interface{})
+		assert.Equal(t, float64(testReport.ID), result["report_id"])
+		assert.Equal(t, "jstack.txt", result["file_name"])
+		// Snippet text is escaped while matches are highlighted
+		assert.Contains(t, result["snippet"], "&lt;lock&gt;")
+		assert.Contains(t, result["snippet"], "<mark>pool-7-worker</mark>")
+	})
+
+	t.Run("No matches", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/search?q=nvme0n1", nil)
 		w := httptest.NewRecorder()
 
-		handler.HandleReports(w, req)
+		handler.HandleSearchReports(w, req)
 
 		assert.Equal(t, http.StatusOK, w.Code)
-
 		var response map[string]interface{}
-		err := json.Unmarshal(w.Body.Bytes(), &response)
-		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Empty(t, response["results"])
+	})
 
-		assert.True(t, response["success"].(bool))
-		assert.Contains(t, response, "reports")
+	t.Run("Missing query", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/search?q=+", nil)
+		w := httptest.NewRecorder()
 
-		reports := response["reports"].([]interface{})
-		assert.GreaterOrEqual(t, len(reports), 1)
+		handler.HandleSearchReports(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
 	})
 
-	t.Run("Get reports for non-existent file", func(t *testing.T) {
-		req := httptest.NewRequest("GET", "/api/reports/99999", nil)
+	t.Run("Invalid limit", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/search?q=worker&limit=0", nil)
 		w := httptest.NewRecorder()
 
-		handler.HandleReports(w, req)
+		handler.HandleSearchReports(w, req)
 
-		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
 
-		var response map[string]interface{}
-		err := json.Unmarshal(w.Body.Bytes(), &response)
-		require.NoError(t, err)
+	t.Run("Invalid method", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/search?q=worker", nil)
+		w := httptest.NewRecorder()
 
-		// Check if reports field exists and handle nil case
-		if reportsField, exists := response["reports"]; exists && reportsField != nil {
-			reports := reportsField.([]interface{})
-			assert.Equal(t, 0, len(reports))
-		} else {
-			// If reports field is nil or doesn't exist, that's also valid for no reports
-			assert.True(t, true) // Test passes
-		}
+		handler.HandleSearchReports(w, req)
+
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
 	})
 }
 
-func TestHandlers_HandleReportContent(t *testing.T) {
+func TestHandlers_HandleAggregateReport(t *testing.T) {
 	handler, db := setupTestHandler(t)
 
-	// Create test report with content
-	testFile := &database.File{
-		Hash:         "content-test-hash",
-		OriginalName: "content-test.txt",
-		FileType:     "ttop",
-		FileSize:     100,
-		UploadTime:   time.Now(),
-		FilePath:     "/uploads/content-test-hash",
-	}
-
-	err := db.InsertFile(testFile)
-	require.NoError(t, err)
+	createReport := func(t *testing.T, sampleType, reportType, status string, node int) *database.Report {
+		// Trailing blank lines keep the hashes unique without changing the parsed content
+		content := append(append([]byte{}, testutil.SampleFiles[sampleType].Content...), []byte(strings.Repeat("\n", node))...)
+		hash, filePath := testutil.CreateTestFile(t, handler.cfg.UploadsDir, testutil.TestFile{
+			Name:    sampleType + ".txt",
+			Content: content,
+		})
+		file := &database.File{
+			Hash:         hash,
+			OriginalName: fmt.Sprintf("node%d-%s.txt", node, sampleType),
+			FileType:     reportType,
+			FileSize:     int64(len(content)),
+			UploadTime:   time.Now(),
+			FilePath:     filePath,
+		}
+		require.NoError(t, db.InsertFile(file))
 
-	reportData := `{"type": "ttop", "summary": "detailed report content", "analysis": "comprehensive analysis"}`
-	testReport := &database.Report{
-		FileID:      testFile.ID,
-		ReportType:  "ttop",
-		Status:      "completed",
-		CreatedTime: time.Now(),
-		DDDVersion:  "1.0.0",
-		ReportData:  reportData,
+		report := &database.Report{
+			FileID:      file.ID,
+			ReportType:  reportType,
+			Status:      status,
+			CreatedTime: time.Now(),
+			DDDVersion:  "1.0.0",
+		}
+		require.NoError(t, db.InsertReport(report))
+		return report
 	}
 
-	err = db.InsertReport(testReport)
-	require.NoError(t, err)
+	iostat1 := createReport(t, "iostat", "iostat", "completed", 1)
+	iostat2 := createReport(t, "iostat", "iostat", "completed", 2)
+	pending := createReport(t, "iostat", "iostat", "pending", 3)
+	ttop := createReport(t, "ttop", "ttop", "completed", 4)
 
-	t.Run("Get report content", func(t *testing.T) {
-		url := fmt.Sprintf("/api/reports/content/%d", testReport.ID)
+	t.Run("Aggregate iostat reports", func(t *testing.T) {
+		url := fmt.Sprintf("/api/reports/aggregate?ids=%d,%d", iostat1.ID, iostat2.ID)
 		req := httptest.NewRequest("GET", url, nil)
 		w := httptest.NewRecorder()
 
-		handler.HandleReportContent(w, req)
+		handler.HandleAggregateReport(w, req)
 
 		assert.Equal(t, http.StatusOK, w.Code)
-		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
-
-		var response map[string]interface{}
-		err := json.Unmarshal(w.Body.Bytes(), &response)
-		require.NoError(t, err)
+		assert.Equal(t, "text/html", w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Body.String(), "IOStat Aggregate Report")
+		assert.Contains(t, w.Body.String(), fmt.Sprintf("(report %d)", iostat1.ID))
+		assert.Contains(t, w.Body.String(), fmt.Sprintf("(report %d)", iostat2.ID))
+	})
 
-		assert.True(t, response["success"].(bool))
-		assert.Contains(t, response, "report_data")
+	t.Run("Reject mixed report types", func(t *testing.T) {
+		url := fmt.Sprintf("/api/reports/aggregate?ids=%d,%d", iostat1.ID, ttop.ID)
+		req := httptest.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
 
-		// The report_data should contain the JSON report data
-		reportDataStr := response["report_data"].(string)
-		assert.Equal(t, reportData, reportDataStr)
+		handler.HandleAggregateReport(w, req)
 
-		// Parse the report data to verify it's valid JSON
-		var parsedReportData map[string]interface{}
-		err = json.Unmarshal([]byte(reportDataStr), &parsedReportData)
-		require.NoError(t, err)
-		assert.Equal(t, "ttop", parsedReportData["type"])
-		assert.Equal(t, "detailed report content", parsedReportData["summary"])
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "different types")
 	})
 
-	t.Run("Get content for non-existent report", func(t *testing.T) {
-		req := httptest.NewRequest("GET", "/api/reports/content/99999", nil)
+	t.Run("Reject incomplete reports", func(t *testing.T) {
+		url := fmt.Sprintf("/api/reports/aggregate?ids=%d,%d", iostat1.ID, pending.ID)
+		req := httptest.NewRequest("GET", url, nil)
 		w := httptest.NewRecorder()
 
-		handler.HandleReportContent(w, req)
+		handler.HandleAggregateReport(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "not completed")
+	})
+
+	t.Run("Reject invalid and missing ids", func(t *testing.T) {
+		for _, url := range []string{"/api/reports/aggregate", "/api/reports/aggregate?ids=abc"} {
+			req := httptest.NewRequest("GET", url, nil)
+			w := httptest.NewRecorder()
+			handler.HandleAggregateReport(w, req)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		}
 
+		req := httptest.NewRequest("GET", "/api/reports/aggregate?ids=99999", nil)
+		w := httptest.NewRecorder()
+		handler.HandleAggregateReport(w, req)
 		assert.Equal(t, http.StatusNotFound, w.Code)
 	})
+
+	t.Run("Handles invalid method", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/reports/aggregate?ids=1", nil)
+		w := httptest.NewRecorder()
+		handler.HandleAggregateReport(w, req)
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
 }
 
 // Integration Tests - These replace the Playwright e2e tests with httptest-based tests
@@ -1764,11 +4274,331 @@ func TestHandlers_HandleDiskUsage(t *testing.T) {
 		assert.Contains(t, db, "used_percent")
 	})
 
-	t.Run("Handles invalid method", func(t *testing.T) {
-		req := httptest.NewRequest("POST", "/api/disk-usage", nil)
+	t.Run("Decodes as a DiskUsageResponse", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.HandleDiskUsage(w, httptest.NewRequest("GET", "/api/disk-usage", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response DiskUsageResponse
+		decoder := json.NewDecoder(w.Body)
+		decoder.DisallowUnknownFields()
+		require.NoError(t, decoder.Decode(&response), "Every field of the response is declared")
+		assert.True(t, response.Success)
+		assert.True(t, response.SameFilesystem, "The test uploads and database share a temp directory")
+		assert.Equal(t, response.Uploads.Total, response.Database.Total)
+		assert.Equal(t, response.Uploads.Total, response.Uploads.Used+response.Uploads.Free)
+	})
+
+	t.Run("Handles invalid method", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/disk-usage", nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleDiskUsage(w, req)
+
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
+
+func TestHandlers_HandleStats(t *testing.T) {
+	handler, db := setupTestHandler(t)
+
+	testFile := &database.File{
+		Hash:         "stats-test-hash",
+		OriginalName: "ttop.txt",
+		FileType:     "ttop",
+		FileSize:     2048,
+		UploadTime:   time.Now(),
+		FilePath:     "/uploads/stats-test-hash",
+	}
+	require.NoError(t, db.InsertFile(testFile))
+
+	completedTime := time.Now()
+	require.NoError(t, db.InsertReport(&database.Report{
+		FileID:        testFile.ID,
+		ReportType:    "ttop",
+		Status:        "completed",
+		CreatedTime:   completedTime.Add(-time.Second),
+		CompletedTime: &completedTime,
+		DDDVersion:    "1.0.0",
+	}))
+
+	t.Run("Returns the totals", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.HandleStats(w, httptest.NewRequest("GET", "/api/stats", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+		var response struct {
+			Success bool            `json:"success"`
+			Stats   *database.Stats `json:"stats"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.True(t, response.Success)
+		assert.Equal(t, 1, response.Stats.TotalFiles)
+		assert.Equal(t, int64(2048), response.Stats.TotalBytes)
+		assert.Equal(t, map[string]int{"ttop": 1}, response.Stats.FilesByType)
+		assert.Equal(t, map[string]int{"completed": 1}, response.Stats.ReportsByStatus)
+		assert.Equal(t, 1, response.Stats.ReportsLast24h)
+	})
+
+	t.Run("Only GET is allowed", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.HandleStats(w, httptest.NewRequest("POST", "/api/stats", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
+
+func TestHandlers_HandleWorkerThroughput(t *testing.T) {
+	handler, db := setupTestHandler(t)
+
+	testFile := &database.File{
+		Hash:         "throughput-test-hash",
+		OriginalName: "ttop.txt",
+		FileType:     "ttop",
+		FileSize:     100,
+		UploadTime:   time.Now(),
+		FilePath:     "/uploads/throughput-test-hash",
+	}
+	require.NoError(t, db.InsertFile(testFile))
+
+	completedTime := time.Now()
+	testReport := &database.Report{
+		FileID:        testFile.ID,
+		ReportType:    "ttop",
+		Status:        "completed",
+		CreatedTime:   completedTime.Add(-time.Second),
+		CompletedTime: &completedTime,
+		DDDVersion:    "1.0.0",
+	}
+	require.NoError(t, db.InsertReport(testReport))
+
+	t.Run("Returns buckets for the window", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/workers/throughput?window=10m&interval=5m", nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleWorkerThroughput(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.True(t, response["success"].(bool))
+		assert.Equal(t, 300.0, response["interval_seconds"])
+
+		buckets := response["buckets"].([]interface{})
+		require.NotEmpty(t, buckets)
+		completed := 0.0
+		for _, bucket := range buckets {
+			completed += bucket.(map[string]interface{})["completed"].(float64)
+		}
+		assert.Equal(t, 1.0, completed)
+	})
+
+	t.Run("Defaults to an hour by minute", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/workers/throughput", nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleWorkerThroughput(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 60.0, response["interval_seconds"])
+		assert.GreaterOrEqual(t, len(response["buckets"].([]interface{})), 60)
+	})
+
+	t.Run("Invalid parameters", func(t *testing.T) {
+		for _, query := range []string{"window=soon", "interval=-1m", "window=720h&interval=1s"} {
+			req := httptest.NewRequest("GET", "/api/workers/throughput?"+query, nil)
+			w := httptest.NewRecorder()
+
+			handler.HandleWorkerThroughput(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		}
+	})
+
+	t.Run("Invalid method", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/workers/throughput", nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleWorkerThroughput(w, req)
+
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
+
+func TestHandlers_HandleHealth(t *testing.T) {
+	t.Run("Healthy", func(t *testing.T) {
+		handler, _ := setupTestHandler(t)
+
+		req := httptest.NewRequest("GET", "/healthz", nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleHealth(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "ok", response["status"])
+		assert.Equal(t, "ok", response["db"])
+		assert.Equal(t, "ok", response["uploads"])
+
+		// The writability probe must not leave files behind
+		entries, err := os.ReadDir(handler.cfg.UploadsDir)
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("Database unreachable", func(t *testing.T) {
+		handler, db := setupTestHandler(t)
+		require.NoError(t, db.Close())
+
+		req := httptest.NewRequest("GET", "/healthz", nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleHealth(w, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "unavailable", response["status"])
+		assert.Equal(t, "unreachable", response["db"])
+	})
+
+	t.Run("Uploads directory missing", func(t *testing.T) {
+		handler, _ := setupTestHandler(t)
+		handler.cfg.UploadsDir = filepath.Join(t.TempDir(), "missing")
+
+		req := httptest.NewRequest("GET", "/healthz", nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleHealth(w, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "unavailable", response["status"])
+		assert.Equal(t, "ok", response["db"])
+		assert.Equal(t, "not writable", response["uploads"])
+	})
+
+	t.Run("Handles invalid method", func(t *testing.T) {
+		handler, _ := setupTestHandler(t)
+
+		req := httptest.NewRequest("POST", "/healthz", nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleHealth(w, req)
+
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
+
+func TestHandlers_HandleDownloadFile(t *testing.T) {
+	handler, db := setupTestHandler(t)
+
+	content := []byte("0123456789abcdef")
+	insertFile := func(hash, name string, onDisk bool) *database.File {
+		filePath := filepath.Join(t.TempDir(), hash)
+		if onDisk {
+			require.NoError(t, os.WriteFile(filePath, content, 0600))
+		}
+		file := &database.File{
+			Hash:         hash,
+			OriginalName: name,
+			FileType:     "ttop",
+			FileSize:     int64(len(content)),
+			UploadTime:   time.Now(),
+			FilePath:     filePath,
+		}
+		require.NoError(t, db.InsertFile(file))
+		return file
+	}
+
+	download := func(fileID int, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/files/%d/download", fileID), nil)
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		w := httptest.NewRecorder()
+		handler.HandleDownloadFile(w, req)
+		return w
+	}
+
+	t.Run("Download original bytes", func(t *testing.T) {
+		file := insertFile("download-hash", "top output.txt", true)
+
+		w := download(file.ID, nil)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, content, w.Body.Bytes())
+		assert.Equal(t, strconv.Itoa(len(content)), w.Header().Get("Content-Length"))
+		assert.Equal(t, "application/octet-stream", w.Header().Get("Content-Type"))
+		assert.Equal(t, `attachment; filename="top output.txt"; filename*=UTF-8''top%20output.txt`,
+			w.Header().Get("Content-Disposition"))
+	})
+
+	t.Run("Range request", func(t *testing.T) {
+		file := insertFile("download-range-hash", "range.txt", true)
+
+		w := download(file.ID, http.Header{"Range": []string{"bytes=4-7"}})
+
+		assert.Equal(t, http.StatusPartialContent, w.Code)
+		assert.Equal(t, "4567", w.Body.String())
+		assert.Equal(t, "bytes 4-7/16", w.Header().Get("Content-Range"))
+	})
+
+	t.Run("Unsafe characters in the file name", func(t *testing.T) {
+		file := insertFile("download-quote-hash", "a\"b\nc.txt", true)
+
+		w := download(file.ID, nil)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Disposition"), `filename="a_b_c.txt"`)
+	})
+
+	t.Run("Soft-deleted file", func(t *testing.T) {
+		file := insertFile("download-deleted-hash", "deleted.txt", true)
+		require.NoError(t, db.MarkFileDeleted(file.ID))
+
+		w := download(file.ID, nil)
+
+		assert.Equal(t, http.StatusGone, w.Code)
+	})
+
+	t.Run("File purged from disk", func(t *testing.T) {
+		file := insertFile("download-purged-hash", "purged.txt", false)
+
+		w := download(file.ID, nil)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Unknown file", func(t *testing.T) {
+		w := download(99999, nil)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Invalid file ID", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/files/abc/download", nil)
 		w := httptest.NewRecorder()
+		handler.HandleDownloadFile(w, req)
 
-		handler.HandleDiskUsage(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Method not allowed", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/files/1/download", nil)
+		w := httptest.NewRecorder()
+		handler.HandleDownloadFile(w, req)
 
 		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
 	})
@@ -1805,6 +4635,7 @@ func TestHandlers_HandleRedetectFileType(t *testing.T) {
 	// Use the new UpdateFileFileType directly for testing purposes
 	err = db.UpdateFileFileType(fileID, "unknown")
 	require.NoError(t, err)
+	require.NoError(t, db.UpdateFileDetection(fileID, 0, "none"))
 
 	// 3. Call the redetect endpoint.
 	redetectURL := fmt.Sprintf("/api/files/%d/redetect", fileID)
@@ -1821,19 +4652,411 @@ func TestHandlers_HandleRedetectFileType(t *testing.T) {
 
 	updatedFileData := redetectResponse["file"].(map[string]interface{})
 	assert.Equal(t, "ttop", updatedFileData["file_type"])
+	assert.Equal(t, "content", updatedFileData["detection_signal"])
+	assert.Greater(t, updatedFileData["detection_confidence"], 0.5)
 
 	// 5. Verify directly from the DB that the file type was updated using GetFileByID.
 	updatedFileFromDB, err := db.GetFileByID(fileID)
 	require.NoError(t, err)
 	assert.Equal(t, "ttop", updatedFileFromDB.FileType)
 
-	// 6. Verify that a new report was queued.
-	// The initial upload creates one report. Re-detection should create a second one.
+	// 6. Verify that no duplicate report was queued.
+	// The initial upload creates one report that is still pending, so re-detection reuses it.
 	reports, err := db.GetReportsByFileID(fileID)
 	require.NoError(t, err)
+	require.Len(t, reports, 1, "re-detection should not queue a duplicate of a pending report")
+
+	// 7. Once the first report has finished, re-detection queues a new one.
+	require.NoError(t, db.CompleteReport(reports[0].ID, `{"type": "ttop"}`))
+	req = httptest.NewRequest("POST", redetectURL, nil)
+	w = httptest.NewRecorder()
+	handler.HandleRedetectFileType(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	reports, err = db.GetReportsByFileID(fileID)
+	require.NoError(t, err)
 	assert.Len(t, reports, 2, "a new report should have been created on re-detection")
 }
 
+func TestHandlers_HandleSetFileType(t *testing.T) {
+	handler, db := setupTestHandler(t)
+
+	// Upload iostat content under a generic name, then override the detected type
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", "capture.data")
+	require.NoError(t, err)
+	_, err = part.Write(testutil.SampleFiles["iostat"].Content)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	req := httptest.NewRequest("POST", "/api/upload", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w := httptest.NewRecorder()
+	handler.HandleUpload(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var uploadResponse map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &uploadResponse))
+	fileID := int(uploadResponse["file"].(map[string]interface{})["id"].(float64))
+
+	setType := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PUT", path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		handler.HandleSetFileType(w, req)
+		return w
+	}
+
+	t.Run("Override file type and queue a report", func(t *testing.T) {
+		reportsBefore, err := db.GetReportsByFileID(fileID)
+		require.NoError(t, err)
+
+		w := setType(fmt.Sprintf("/api/files/%d/type", fileID), `{"file_type":"ttop"}`)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.True(t, response["success"].(bool))
+		fileData := response["file"].(map[string]interface{})
+		assert.Equal(t, "ttop", fileData["file_type"])
+		assert.Equal(t, 1.0, fileData["detection_confidence"])
+		assert.Equal(t, "manual", fileData["detection_signal"])
+
+		reports, err := db.GetReportsByFileID(fileID)
+		require.NoError(t, err)
+		require.Len(t, reports, len(reportsBefore)+1)
+		found := false
+		for _, report := range reports {
+			if report.ReportType == "ttop" && report.Status == "pending" {
+				found = true
+			}
+		}
+		assert.True(t, found, "a pending ttop report should have been queued")
+	})
+
+	t.Run("Non-reportable type does not queue a report", func(t *testing.T) {
+		reportsBefore, err := db.GetReportsByFileID(fileID)
+		require.NoError(t, err)
+
+		w := setType(fmt.Sprintf("/api/files/%d/type", fileID), `{"file_type":"unknown"}`)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		reports, err := db.GetReportsByFileID(fileID)
+		require.NoError(t, err)
+		assert.Len(t, reports, len(reportsBefore))
+	})
+
+	t.Run("Reject unknown file type", func(t *testing.T) {
+		w := setType(fmt.Sprintf("/api/files/%d/type", fileID), `{"file_type":"spreadsheet"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "Unknown file type: spreadsheet")
+
+		file, err := db.GetFileByID(fileID)
+		require.NoError(t, err)
+		assert.Equal(t, "unknown", file.FileType)
+	})
+
+	t.Run("Reject invalid JSON", func(t *testing.T) {
+		w := setType(fmt.Sprintf("/api/files/%d/type", fileID), `{"file_type":`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Missing file", func(t *testing.T) {
+		w := setType("/api/files/99999/type", `{"file_type":"iostat"}`)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Invalid file ID", func(t *testing.T) {
+		w := setType("/api/files/abc/type", `{"file_type":"iostat"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Method not allowed", func(t *testing.T) {
+		req := httptest.NewRequest("POST", fmt.Sprintf("/api/files/%d/type", fileID), strings.NewReader(`{"file_type":"iostat"}`))
+		w := httptest.NewRecorder()
+		handler.HandleSetFileType(w, req)
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
+
+func TestHandlers_HandlePinFile(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	cfg := testutil.TestConfig(t)
+
+	hash, filePath := testutil.CreateSampleFile(t, cfg.UploadsDir, "ttop")
+	file := &database.File{
+		Hash:         hash,
+		OriginalName: "ttop.txt",
+		FileType:     "ttop",
+		FileSize:     int64(len(testutil.SampleFiles["ttop"].Content)),
+		UploadTime:   time.Now(),
+		FilePath:     filePath,
+	}
+	require.NoError(t, db.InsertFile(file))
+
+	pin := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PUT", path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		handler.HandlePinFile(w, req)
+		return w
+	}
+
+	t.Run("Pin and unpin a file", func(t *testing.T) {
+		w := pin(fmt.Sprintf("/api/files/%d/pin", file.ID), `{"pinned":true}`)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.True(t, response["success"].(bool))
+		assert.Equal(t, true, response["file"].(map[string]interface{})["pinned"])
+
+		stored, err := db.GetFileByID(file.ID)
+		require.NoError(t, err)
+		assert.True(t, stored.Pinned)
+
+		w = pin(fmt.Sprintf("/api/files/%d/pin", file.ID), `{"pinned":false}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		stored, err = db.GetFileByID(file.ID)
+		require.NoError(t, err)
+		assert.False(t, stored.Pinned)
+	})
+
+	t.Run("Pin state is shown in the file listing", func(t *testing.T) {
+		require.NoError(t, db.SetFilePinned(file.ID, true))
+
+		req := httptest.NewRequest("GET", "/api/files", nil)
+		w := httptest.NewRecorder()
+		handler.HandleFiles(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"pinned":true`)
+	})
+
+	t.Run("Reject missing pinned field", func(t *testing.T) {
+		w := pin(fmt.Sprintf("/api/files/%d/pin", file.ID), `{}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Missing file", func(t *testing.T) {
+		w := pin("/api/files/99999/pin", `{"pinned":true}`)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Method not allowed", func(t *testing.T) {
+		req := httptest.NewRequest("POST", fmt.Sprintf("/api/files/%d/pin", file.ID), strings.NewReader(`{"pinned":true}`))
+		w := httptest.NewRecorder()
+		handler.HandlePinFile(w, req)
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
+
+func TestHandlers_HandleBackup(t *testing.T) {
+	handler, _ := setupTestHandler(t)
+
+	t.Run("Writes a backup and returns its path and size", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.HandleBackup(w, httptest.NewRequest("POST", "/api/admin/backup", nil))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response struct {
+			Success bool `json:"success"`
+			Backup  struct {
+				Path string `json:"path"`
+				Size int64  `json:"size"`
+			} `json:"backup"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.True(t, response.Success)
+		assert.Equal(t, handler.cfg.BackupDir, filepath.Dir(response.Backup.Path))
+		info, err := os.Stat(response.Backup.Path)
+		require.NoError(t, err)
+		assert.Equal(t, info.Size(), response.Backup.Size)
+	})
+
+	t.Run("Only POST is allowed", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.HandleBackup(w, httptest.NewRequest("GET", "/api/admin/backup", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+
+	t.Run("Unwritable backup directory", func(t *testing.T) {
+		blocker := filepath.Join(t.TempDir(), "file")
+		require.NoError(t, os.WriteFile(blocker, []byte("x"), 0600))
+		handler.cfg.BackupDir = filepath.Join(blocker, "backups")
+
+		w := httptest.NewRecorder()
+		handler.HandleBackup(w, httptest.NewRequest("POST", "/api/admin/backup", nil))
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Contains(t, w.Body.String(), "Failed to back up database")
+	})
+}
+
+func TestHandlers_HandleFileReportHistory(t *testing.T) {
+	handler, db := setupTestHandler(t)
+
+	file := &database.File{Hash: "history-hash", OriginalName: "capture.txt", FileType: "ttop", FileSize: 100, UploadTime: time.Now(), FilePath: "/uploads/history-hash"}
+	require.NoError(t, db.InsertFile(file))
+
+	start := time.Now().Add(-time.Hour)
+	insertReport := func(reportType, status, version string, age time.Duration) *database.Report {
+		report := &database.Report{FileID: file.ID, ReportType: reportType, Status: status, CreatedTime: start.Add(age), DDDVersion: version, ReportData: "{}"}
+		require.NoError(t, db.InsertReport(report))
+		return report
+	}
+	// The file was first detected as ttop, regenerated, then redetected as iostat
+	first := insertReport("ttop", "completed", "0.0.1", 0)
+	failed := insertReport("ttop", "failed", DDDVersion, time.Minute)
+	latestTTop := insertReport("ttop", "completed", DDDVersion, 2*time.Minute)
+	iostat := insertReport("iostat", "completed", DDDVersion, 3*time.Minute)
+
+	history := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.HandleFileReportHistory(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	t.Run("Every report oldest first", func(t *testing.T) {
+		w := history(fmt.Sprintf("/api/files/%d/history", file.ID))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response FileReportHistoryResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.True(t, response.Success)
+		assert.Equal(t, file.ID, response.FileID)
+		require.Len(t, response.Reports, 4)
+
+		ids := make([]int, len(response.Reports))
+		for i, entry := range response.Reports {
+			ids[i] = entry.ID
+		}
+		assert.Equal(t, []int{first.ID, failed.ID, latestTTop.ID, iostat.ID}, ids)
+
+		assert.True(t, response.Reports[0].Stale, "Generated by an older version")
+		assert.Equal(t, "0.0.1", response.Reports[0].DDDVersion)
+		assert.Equal(t, latestTTop.ID, response.Reports[0].SupersededBy)
+		assert.Equal(t, "failed", response.Reports[1].Status)
+		assert.Equal(t, latestTTop.ID, response.Reports[1].SupersededBy)
+		assert.Zero(t, response.Reports[2].SupersededBy, "The newest ttop report is current")
+		assert.Zero(t, response.Reports[3].SupersededBy, "Reports of another type don't supersede it")
+		assert.NotContains(t, w.Body.String(), `"superseded_by":0`)
+	})
+
+	t.Run("Unknown files and bad requests", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, history("/api/files/999/history").Code)
+		assert.Equal(t, http.StatusBadRequest, history("/api/files/abc/history").Code)
+
+		w := httptest.NewRecorder()
+		handler.HandleFileReportHistory(w, httptest.NewRequest("POST", fmt.Sprintf("/api/files/%d/history", file.ID), nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
+
+func TestHandlers_HandleReprocessByType(t *testing.T) {
+	handler, db := setupTestHandler(t)
+
+	createFile := func(t *testing.T, sampleType string, node int) *database.File {
+		content := append(append([]byte{}, testutil.SampleFiles[sampleType].Content...), []byte(strings.Repeat("\n", node))...)
+		hash, filePath := testutil.CreateTestFile(t, handler.cfg.UploadsDir, testutil.TestFile{
+			Name:    sampleType + ".txt",
+			Content: content,
+		})
+		file := &database.File{
+			Hash:         hash,
+			OriginalName: fmt.Sprintf("node%d-%s.txt", node, sampleType),
+			FileType:     sampleType,
+			FileSize:     int64(len(content)),
+			UploadTime:   time.Now(),
+			FilePath:     filePath,
+		}
+		require.NoError(t, db.InsertFile(file))
+		return file
+	}
+	createReport := func(t *testing.T, file *database.File, version string) {
+		require.NoError(t, db.InsertReport(&database.Report{
+			FileID:      file.ID,
+			ReportType:  file.FileType,
+			Status:      "completed",
+			CreatedTime: time.Now(),
+			DDDVersion:  version,
+		}))
+	}
+	reprocess := func(method, reportType string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/admin/reprocess?type="+reportType, nil)
+		w := httptest.NewRecorder()
+		handler.HandleReprocessByType(w, req)
+		return w
+	}
+	pendingReports := func(t *testing.T, file *database.File) int {
+		reports, err := db.GetReportsByFileID(file.ID)
+		require.NoError(t, err)
+		count := 0
+		for _, report := range reports {
+			if report.Status == "pending" {
+				count++
+			}
+		}
+		return count
+	}
+
+	// Two stale reports for one file should only queue one regeneration
+	stale := createFile(t, "iostat", 1)
+	createReport(t, stale, "0.9.0")
+	createReport(t, stale, "0.9.1")
+	// Already regenerated by the current version
+	current := createFile(t, "iostat", 2)
+	createReport(t, current, "0.9.0")
+	createReport(t, current, DDDVersion)
+	// Content cleaned up from disk
+	missing := createFile(t, "iostat", 3)
+	createReport(t, missing, "0.9.0")
+	require.NoError(t, os.Remove(missing.FilePath))
+	// Other report types are left alone
+	ttop := createFile(t, "ttop", 4)
+	createReport(t, ttop, "0.9.0")
+
+	t.Run("Queues stale reports of the type", func(t *testing.T) {
+		w := reprocess("POST", "iostat")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.True(t, response["success"].(bool))
+		assert.Equal(t, "iostat", response["report_type"])
+		assert.Equal(t, 1.0, response["queued"])
+		assert.Equal(t, 1.0, response["skipped"])
+
+		assert.Equal(t, 1, pendingReports(t, stale))
+		assert.Equal(t, 0, pendingReports(t, current))
+		assert.Equal(t, 0, pendingReports(t, missing))
+		assert.Equal(t, 0, pendingReports(t, ttop))
+	})
+
+	t.Run("Does not queue duplicates while reprocessing is pending", func(t *testing.T) {
+		w := reprocess("POST", "iostat")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 0.0, response["queued"])
+		assert.Equal(t, 1, pendingReports(t, stale))
+	})
+
+	t.Run("Reject unknown report type", func(t *testing.T) {
+		w := reprocess("POST", "spreadsheet")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "Unknown report type: spreadsheet")
+
+		w = reprocess("POST", "")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Method not allowed", func(t *testing.T) {
+		w := reprocess("GET", "iostat")
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
+
 func TestHandlers_HandleSettings(t *testing.T) {
 	handler, db := setupTestHandler(t)
 
@@ -1939,6 +5162,38 @@ func TestHandlers_HandleSettings(t *testing.T) {
 		}
 	})
 
+	t.Run("Report theme setting", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/settings", nil)
+		w := httptest.NewRecorder()
+		handler.HandleSettings(w, req)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "default", response["report_theme"])
+		assert.Equal(t, []interface{}{"dark", "default", "light"}, response["report_themes"])
+
+		body := `{"max_disk_usage": "50", "file_retention_days": "14", "report_theme": "dark"}`
+		req = httptest.NewRequest("POST", "/api/settings", strings.NewReader(body))
+		w = httptest.NewRecorder()
+		handler.HandleSettings(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		theme, err := db.GetSetting("report_theme")
+		require.NoError(t, err)
+		assert.Equal(t, "dark", theme)
+
+		body = `{"max_disk_usage": "50", "file_retention_days": "14", "report_theme": "neon"}`
+		req = httptest.NewRequest("POST", "/api/settings", strings.NewReader(body))
+		w = httptest.NewRecorder()
+		handler.HandleSettings(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "Unknown report_theme")
+
+		theme, err = db.GetSetting("report_theme")
+		require.NoError(t, err)
+		assert.Equal(t, "dark", theme)
+	})
+
 	t.Run("Settings persist across handler instances", func(t *testing.T) {
 		// Update settings
 		body := `{"max_disk_usage": "75.0", "file_retention_days": "21"}`
@@ -1950,7 +5205,7 @@ func TestHandlers_HandleSettings(t *testing.T) {
 
 		// Create a new handler instance with the same database
 		mockWorker := &mockCleanupWorker{}
-		newHandler := New(db, handler.cfg, mockWorker)
+		newHandler := New(db, handler.cfg, mockWorker, nil, nil)
 
 		// Get settings from new handler instance
 		req = httptest.NewRequest("GET", "/api/settings", nil)
@@ -1972,7 +5227,7 @@ func TestHandlers_HandleSettings(t *testing.T) {
 	t.Run("Cleanup triggered when threshold lowered", func(t *testing.T) {
 		// Create handler with mock cleanup worker
 		mockWorker := &mockCleanupWorker{}
-		testHandler := New(db, handler.cfg, mockWorker)
+		testHandler := New(db, handler.cfg, mockWorker, nil, nil)
 
 		// Set initial high threshold
 		body := `{"max_disk_usage": "90.0", "file_retention_days": "14"}`
@@ -2014,6 +5269,87 @@ func TestHandlers_HandleSettings(t *testing.T) {
 		assert.Equal(t, initialTriggerCount, mockWorker.getTriggerCount(), "Cleanup should NOT be triggered when threshold is raised")
 	})
 
+	t.Run("Changed worker intervals reset the workers", func(t *testing.T) {
+		cleanupWorker := &mockCleanupWorker{}
+		reportWorker := &mockReportWorker{}
+		testHandler := New(db, handler.cfg, cleanupWorker, reportWorker, nil)
+
+		save := func(body string) {
+			req := httptest.NewRequest("POST", "/api/settings", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			testHandler.HandleSettings(w, req)
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		}
+
+		save(`{"report_poll_interval_seconds": "5", "cleanup_interval_seconds": "600"}`)
+		assert.Equal(t, 1, reportWorker.resets)
+		assert.Equal(t, 1, cleanupWorker.getResetCount())
+
+		value, err := db.GetSetting("cleanup_interval_seconds")
+		require.NoError(t, err)
+		assert.Equal(t, "600", value)
+
+		// Saving the same values leaves the workers alone
+		save(`{"report_poll_interval_seconds": "5", "cleanup_interval_seconds": "600"}`)
+		assert.Equal(t, 1, reportWorker.resets)
+		assert.Equal(t, 1, cleanupWorker.getResetCount())
+
+		req := httptest.NewRequest("POST", "/api/settings", strings.NewReader(`{"report_poll_interval_seconds": "0"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		testHandler.HandleSettings(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Update iostat thresholds", func(t *testing.T) {
+		body := `{"max_disk_usage": "80.0", "file_retention_days": "14", "iostat_util_threshold": "75", "iostat_await_threshold_ms": "20.5"}`
+		req := httptest.NewRequest("POST", "/api/settings", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		handler.HandleSettings(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		utilThreshold, err := db.GetSetting("iostat_util_threshold")
+		require.NoError(t, err)
+		assert.Equal(t, "75", utilThreshold)
+
+		awaitThreshold, err := db.GetSetting("iostat_await_threshold_ms")
+		require.NoError(t, err)
+		assert.Equal(t, "20.5", awaitThreshold)
+
+		req = httptest.NewRequest("GET", "/api/settings", nil)
+		w = httptest.NewRecorder()
+		handler.HandleSettings(w, req)
+
+		var response map[string]interface{}
+		err = json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+		assert.Equal(t, 75.0, response["iostat_util_threshold"])
+		assert.Equal(t, 20.5, response["iostat_await_threshold_ms"])
+	})
+
+	t.Run("Reject invalid iostat thresholds", func(t *testing.T) {
+		testCases := []struct {
+			body      string
+			expectMsg string
+		}{
+			{`{"max_disk_usage": "80", "file_retention_days": "14", "iostat_util_threshold": "101"}`, "iostat_util_threshold must be between 0 and 100"},
+			{`{"max_disk_usage": "80", "file_retention_days": "14", "iostat_util_threshold": "abc"}`, "Invalid iostat_util_threshold value"},
+			{`{"max_disk_usage": "80", "file_retention_days": "14", "iostat_await_threshold_ms": "-5"}`, "iostat_await_threshold_ms must be non-negative"},
+		}
+
+		for _, tc := range testCases {
+			req := httptest.NewRequest("POST", "/api/settings", strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			handler.HandleSettings(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Contains(t, w.Body.String(), tc.expectMsg)
+		}
+	})
+
 	t.Run("Handles invalid method", func(t *testing.T) {
 		req := httptest.NewRequest("PUT", "/api/settings", nil)
 		w := httptest.NewRecorder()
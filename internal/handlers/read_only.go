@@ -0,0 +1,53 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"net/http"
+	"strings"
+)
+
+const readOnlyMessage = "DDD is in read-only mode for maintenance; existing reports can be viewed but nothing can be uploaded or changed"
+
+// readOnlyAllowedWrites are the API routes that keep accepting writes in read-only mode.
+// Detection previews store nothing, backups are what read-only mode is for, and the
+// settings endpoint checks itself so read_only can be switched off again
+var readOnlyAllowedWrites = map[string]bool{
+	"/api/detect":       true,
+	"/api/admin/backup": true,
+	"/api/settings":     true,
+}
+
+// writeReadOnlyError answers a refused write with 503 Service Unavailable
+func writeReadOnlyError(w http.ResponseWriter) {
+	writeJSONError(w, http.StatusServiceUnavailable, readOnlyMessage, ErrCodeUnavailable)
+}
+
+// ReadOnly refuses uploads, deletes and every other write to the /api/ routes with 503 while
+// DDD is in read-only mode. GET, HEAD and OPTIONS requests are always passed to next
+func (h *Handlers) ReadOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !strings.HasPrefix(r.URL.Path, "/api/") || readOnlyAllowedWrites[r.URL.Path] || !h.settings.ReadOnly() {
+			next.ServeHTTP(w, r)
+			return
+		}
+		writeReadOnlyError(w)
+	})
+}
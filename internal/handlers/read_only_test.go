@@ -0,0 +1,93 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadOnly(t *testing.T) {
+	handler, db := setupTestHandler(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/settings", handler.HandleSettings)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	server := handler.ReadOnly(mux)
+
+	request := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return w
+	}
+
+	t.Run("Writes are accepted by default", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, request("POST", "/api/upload", "").Code)
+		assert.Equal(t, http.StatusOK, request("DELETE", "/api/files/1", "").Code)
+	})
+
+	require.NoError(t, db.SetSetting("read_only", "true"))
+
+	t.Run("Writes are refused in read-only mode", func(t *testing.T) {
+		for _, tc := range []struct{ method, path string }{
+			{"POST", "/api/upload"},
+			{"DELETE", "/api/files/1"},
+			{"DELETE", "/api/reports/1"},
+			{"POST", "/api/reports/import"},
+			{"PUT", "/api/files/1/type"},
+		} {
+			w := request(tc.method, tc.path, "")
+			assert.Equal(t, http.StatusServiceUnavailable, w.Code, "%s %s", tc.method, tc.path)
+			assert.Contains(t, w.Body.String(), "read-only mode")
+			assert.Contains(t, w.Body.String(), ErrCodeUnavailable)
+		}
+	})
+
+	t.Run("Reads and allowed writes keep working", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, request("GET", "/api/files", "").Code)
+		assert.Equal(t, http.StatusOK, request("GET", "/report/1", "").Code)
+		assert.Equal(t, http.StatusOK, request("POST", "/api/detect", "").Code)
+		assert.Equal(t, http.StatusOK, request("POST", "/api/admin/backup", "").Code)
+
+		w := request("GET", "/api/settings", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"read_only_mode":true`)
+	})
+
+	t.Run("Only read_only can be changed", func(t *testing.T) {
+		w := request("POST", "/api/settings", `{"file_retention_days": "30", "read_only": false}`)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+		w = request("POST", "/api/settings", `{"read_only": false}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, http.StatusOK, request("POST", "/api/upload", "").Code)
+	})
+
+	t.Run("The read-only flag can't be switched off", func(t *testing.T) {
+		handler.cfg.ReadOnly = true
+		defer func() { handler.cfg.ReadOnly = false }()
+
+		assert.Equal(t, http.StatusServiceUnavailable, request("POST", "/api/upload", "").Code)
+		request("POST", "/api/settings", `{"read_only": "false"}`)
+		assert.Equal(t, http.StatusServiceUnavailable, request("POST", "/api/upload", "").Code)
+	})
+}
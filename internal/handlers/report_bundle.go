@@ -0,0 +1,391 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rsvihladremio/ddd/internal/database"
+	"github.com/rsvihladremio/ddd/internal/detector"
+	"github.com/rsvihladremio/ddd/internal/reporters"
+)
+
+// reportBundleVersion is the bundle format written by HandleExportReport. Bump it when
+// the format changes and upgrade older bundles in migrateReportBundle
+const reportBundleVersion = 1
+
+// maxReportBundleBytes caps an imported bundle; the original file is base64 encoded in
+// it, so this leaves room for a file at the 100MB upload limit
+const maxReportBundleBytes = 160 << 20
+
+// reportBundle moves a completed report between DDD instances. The original file is
+// optional so reports can leave a locked-down instance without the raw artifact
+type reportBundle struct {
+	Version      int                `json:"version"`
+	DDDVersion   string             `json:"ddd_version"` // DDD version that exported the bundle
+	ExportedTime time.Time          `json:"exported_time"`
+	Report       reportBundleReport `json:"report"`
+	File         reportBundleFile   `json:"file"`
+	Content      []byte             `json:"content,omitempty"` // The original file, base64 encoded
+}
+
+// reportBundleReport is the report metadata and data carried in a bundle
+type reportBundleReport struct {
+	ReportType    string          `json:"report_type"`
+	CreatedTime   time.Time       `json:"created_time"`
+	CompletedTime *time.Time      `json:"completed_time,omitempty"`
+	GenerationMs  int64           `json:"generation_ms,omitempty"`
+	DDDVersion    string          `json:"ddd_version"` // DDD version that generated the report
+	ReportData    json.RawMessage `json:"report_data"`
+}
+
+// reportBundleFile describes the file a bundled report was generated from
+type reportBundleFile struct {
+	Hash          string    `json:"hash"`
+	HashAlgorithm string    `json:"hash_algorithm,omitempty"` // Algorithm Hash was computed with, sha256 when empty
+	OriginalName  string    `json:"original_name"`
+	FileType      string    `json:"file_type"`
+	FileSize      int64     `json:"file_size"`
+	UploadTime    time.Time `json:"upload_time"`
+}
+
+// HandleExportReport downloads a completed report as a JSON bundle for
+// POST /api/reports/import on another DDD instance, e.g.
+// GET /api/reports/{id}/bundle?include_file=true. The original file is only included
+// when asked for and still on disk
+func (h *Handlers) HandleExportReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed", ErrCodeMethodNotAllowed)
+		return
+	}
+
+	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(pathParts) != 4 || pathParts[3] != "bundle" {
+		writeJSONError(w, http.StatusBadRequest, "Invalid URL", ErrCodeBadRequest)
+		return
+	}
+
+	reportID, err := strconv.Atoi(pathParts[2])
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid report ID", ErrCodeBadRequest)
+		return
+	}
+
+	includeFile := false
+	if value := r.URL.Query().Get("include_file"); value != "" {
+		if includeFile, err = strconv.ParseBool(value); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "include_file must be true or false", ErrCodeBadRequest)
+			return
+		}
+	}
+
+	report, err := h.db.GetReportByID(reportID)
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, "Report not found", ErrCodeNotFound)
+		return
+	}
+	if report.Status != "completed" {
+		writeJSONError(w, http.StatusConflict, fmt.Sprintf("Report is %s, only completed reports can be exported", report.Status), ErrCodeConflict)
+		return
+	}
+
+	file, err := h.db.GetFileByID(report.FileID)
+	if err != nil {
+		log.Printf("Error getting file %d for report %d export: %v", report.FileID, report.ID, err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to get report file", ErrCodeInternal)
+		return
+	}
+
+	bundle := reportBundle{
+		Version:      reportBundleVersion,
+		DDDVersion:   DDDVersion,
+		ExportedTime: time.Now(),
+		Report: reportBundleReport{
+			ReportType:    report.ReportType,
+			CreatedTime:   report.CreatedTime,
+			CompletedTime: report.CompletedTime,
+			GenerationMs:  report.GenerationMs,
+			DDDVersion:    report.DDDVersion,
+			ReportData:    json.RawMessage(report.ReportData),
+		},
+		File: reportBundleFile{
+			Hash:          file.Hash,
+			HashAlgorithm: file.HashAlgorithm,
+			OriginalName:  file.OriginalName,
+			FileType:      file.FileType,
+			FileSize:      file.FileSize,
+			UploadTime:    file.UploadTime,
+		},
+	}
+	if includeFile && !file.Deleted {
+		content, err := os.ReadFile(file.FilePath)
+		switch {
+		case err == nil:
+			bundle.Content = content
+		case os.IsNotExist(err):
+			log.Printf("File %d for report %d export is no longer on disk, exporting the report only", file.ID, report.ID)
+		default:
+			log.Printf("Error reading file %d for report %d export: %v", file.ID, report.ID, err)
+			writeJSONError(w, http.StatusInternalServerError, "Failed to read report file", ErrCodeInternal)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", contentDisposition(fmt.Sprintf("ddd-%s-report-%d.json", report.ReportType, report.ID)))
+	if err := json.NewEncoder(w).Encode(bundle); err != nil {
+		log.Printf("Error encoding report bundle: %v", err)
+	}
+}
+
+// HandleImportReport imports a report from a bundle written by HandleExportReport, e.g.
+// POST /api/reports/import. The report is attached to the file with the same content when
+// there is one. Otherwise the file is stored from the bundle, or recorded as deleted when
+// the bundle carries only its metadata. Bundles with the file queue the report to be
+// generated again; without it the report is stored completed but trimmed
+func (h *Handlers) HandleImportReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed", ErrCodeMethodNotAllowed)
+		return
+	}
+
+	var bundle reportBundle
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxReportBundleBytes)).Decode(&bundle); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeJSONError(w, http.StatusRequestEntityTooLarge, "Report bundle is too large", ErrCodeBadRequest)
+			return
+		}
+		writeJSONError(w, http.StatusBadRequest, "Invalid report bundle", ErrCodeBadRequest)
+		return
+	}
+	if err := h.validateReportBundle(&bundle); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error(), ErrCodeBadRequest)
+		return
+	}
+
+	file, err := h.importBundleFile(&bundle)
+	if err != nil {
+		log.Printf("Error importing file %s from report bundle: %v", bundle.File.Hash, err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to save report file", ErrCodeInternal)
+		return
+	}
+
+	// The rendered page and chart series in a bundle could carry script that would run on
+	// this instance, so they are never imported. With the original file the report is
+	// generated again; without it only the summary, analysis and findings are kept
+	var report *database.Report
+	message := "Report imported successfully"
+	if bundle.Content != nil {
+		report, _, err = h.queueReport(file.ID, bundle.Report.ReportType)
+		if err != nil {
+			log.Printf("Error queueing imported %s report for file %d: %v", bundle.Report.ReportType, file.ID, err)
+			writeJSONError(w, http.StatusInternalServerError, "Failed to save report", ErrCodeInternal)
+			return
+		}
+		message = "Report imported, it is being generated again from the bundled file"
+		log.Printf("Imported file %d (%s) from a report bundle, queued %s report %d", file.ID, file.OriginalName, report.ReportType, report.ID)
+	} else {
+		report, err = h.insertImportedReport(&bundle, file)
+		if err != nil {
+			log.Printf("Error inserting imported %s report for file %d: %v", bundle.Report.ReportType, file.ID, err)
+			writeJSONError(w, http.StatusInternalServerError, "Failed to save report", ErrCodeInternal)
+			return
+		}
+		log.Printf("Imported %s report %d for file %d (%s)", report.ReportType, report.ID, file.ID, file.OriginalName)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"report":  report,
+		"file":    file,
+		"message": message,
+	}); err != nil {
+		log.Printf("Error encoding JSON response: %v", err)
+	}
+}
+
+// insertImportedReport stores a bundled report as completed with only its summary,
+// analysis and findings, for bundles without the file to generate it again from
+func (h *Handlers) insertImportedReport(bundle *reportBundle, file *database.File) (*database.Report, error) {
+	reportData, err := database.TrimmedReportData(string(bundle.Report.ReportData))
+	if err != nil {
+		return nil, err
+	}
+	report := &database.Report{
+		FileID:        file.ID,
+		ReportType:    bundle.Report.ReportType,
+		Status:        "completed",
+		CreatedTime:   bundle.Report.CreatedTime,
+		CompletedTime: bundle.Report.CompletedTime,
+		DDDVersion:    bundle.Report.DDDVersion,
+		ReportData:    reportData,
+	}
+	if report.CompletedTime == nil {
+		report.CompletedTime = &bundle.ExportedTime
+	}
+	if err := h.db.InsertReport(report); err != nil {
+		return nil, err
+	}
+	if bundle.Report.GenerationMs > 0 {
+		if err := h.db.SetReportGenerationTime(report.ID, time.Duration(bundle.Report.GenerationMs)*time.Millisecond); err != nil {
+			log.Printf("Error recording generation time of imported report %d: %v", report.ID, err)
+		}
+		report.GenerationMs = bundle.Report.GenerationMs
+	}
+	if err := h.db.InsertReportLog(&database.ReportLog{
+		ReportID:  report.ID,
+		Timestamp: time.Now(),
+		Level:     "INFO",
+		Message:   fmt.Sprintf("Imported from a bundle exported by DDD %s at %s, without its rendered page and charts", bundle.DDDVersion, bundle.ExportedTime.Format(time.RFC3339)),
+	}); err != nil {
+		log.Printf("Error logging import of report %d: %v", report.ID, err)
+	}
+	return report, nil
+}
+
+// validateReportBundle checks that a bundle can be imported, filling in the file hash
+// and size from the content when the bundle carries the original file
+func (h *Handlers) validateReportBundle(bundle *reportBundle) error {
+	if err := migrateReportBundle(bundle); err != nil {
+		return err
+	}
+	if _, ok := reporters.Lookup(bundle.Report.ReportType); !ok {
+		return fmt.Errorf("Unknown report type: %s", bundle.Report.ReportType)
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal(bundle.Report.ReportData, &data); err != nil || data == nil {
+		return fmt.Errorf("Report data must be a JSON object")
+	}
+	if bundle.File.OriginalName == "" {
+		return fmt.Errorf("Report bundle is missing the file name")
+	}
+	if bundle.File.FileType == "" {
+		bundle.File.FileType = bundle.Report.ReportType
+	}
+	if !detector.IsKnownFileType(bundle.File.FileType) {
+		return fmt.Errorf("Unknown file type: %s", bundle.File.FileType)
+	}
+	if bundle.File.UploadTime.IsZero() {
+		bundle.File.UploadTime = time.Now()
+	}
+	if bundle.Report.CreatedTime.IsZero() {
+		bundle.Report.CreatedTime = bundle.ExportedTime
+	}
+
+	// Bundles from before the hash algorithm was configurable are always sha256
+	if bundle.File.HashAlgorithm == "" {
+		bundle.File.HashAlgorithm = hashAlgorithmSHA256
+	}
+	hashAlgorithm, ok := contentHashAlgorithms[bundle.File.HashAlgorithm]
+	if !ok {
+		return fmt.Errorf("Unsupported report bundle hash algorithm %q, expected one of %s",
+			bundle.File.HashAlgorithm, strings.Join(contentHashAlgorithmNames(), ", "))
+	}
+
+	if bundle.Content != nil {
+		hash, err := computeContentHash(bundle.File.HashAlgorithm, bundle.Content)
+		if err != nil {
+			return err
+		}
+		if bundle.File.Hash != "" && bundle.File.Hash != hash {
+			return fmt.Errorf("File content does not match its hash")
+		}
+		bundle.File.Hash = hash
+		bundle.File.FileSize = int64(len(bundle.Content))
+		return nil
+	}
+	if !hashAlgorithm.pattern.MatchString(bundle.File.Hash) {
+		return fmt.Errorf("Report bundle file hash must be a %s hex digest", hashAlgorithm.label)
+	}
+	return nil
+}
+
+// migrateReportBundle upgrades a bundle written by an older DDD to reportBundleVersion.
+// Bundles from a newer DDD are rejected since fields they rely on would be dropped
+func migrateReportBundle(bundle *reportBundle) error {
+	switch {
+	case bundle.Version > reportBundleVersion:
+		return fmt.Errorf("Report bundle version %d was exported by a newer DDD, this one reads up to version %d", bundle.Version, reportBundleVersion)
+	case bundle.Version < 1:
+		return fmt.Errorf("Unsupported report bundle version %d", bundle.Version)
+	}
+	// Version 1 is the first format, there is nothing older to upgrade yet
+	return nil
+}
+
+// importBundleFile returns the file an imported report belongs to. Content already stored
+// under the bundle's hash is reused, deleted content is restored when the bundle carries
+// it, and new content is stored like an upload, without queueing a report
+func (h *Handlers) importBundleFile(bundle *reportBundle) (*database.File, error) {
+	var existing *database.File
+	var err error
+	if bundle.Content != nil {
+		// The content may already be stored under the hash algorithm configured here
+		existing, bundle.File.Hash, bundle.File.HashAlgorithm, err = h.lookupContent(bundle.Content, bundle.File.Hash, bundle.File.HashAlgorithm)
+	} else {
+		existing, err = h.db.GetFileByHash(bundle.File.Hash)
+	}
+	filePath := filepath.Join(h.cfg.UploadsDir, bundle.File.Hash)
+	if err == nil {
+		filePath = filepath.Join(h.cfg.UploadsDir, existing.Hash)
+		if !existing.Deleted || bundle.Content == nil {
+			return existing, nil
+		}
+		if uploadErr := writeUploadedFile(filePath, bundle.Content); uploadErr != nil {
+			return nil, errors.New(uploadErr.message)
+		}
+		if err := h.db.RestoreFile(existing.ID, bundle.File.OriginalName, bundle.File.FileType, bundle.File.FileSize, filePath); err != nil {
+			return nil, err
+		}
+		return h.db.GetFileByID(existing.ID)
+	}
+
+	file := &database.File{
+		Hash:          bundle.File.Hash,
+		HashAlgorithm: bundle.File.HashAlgorithm,
+		OriginalName:  bundle.File.OriginalName,
+		FileType:      bundle.File.FileType,
+		FileSize:      bundle.File.FileSize,
+		UploadTime:    bundle.File.UploadTime,
+	}
+	if bundle.Content != nil {
+		if uploadErr := writeUploadedFile(filePath, bundle.Content); uploadErr != nil {
+			return nil, errors.New(uploadErr.message)
+		}
+		file.FilePath = filePath
+	}
+	if err := h.db.InsertFile(file); err != nil {
+		return nil, err
+	}
+	if bundle.Content == nil {
+		// Only the report came across; the file is kept as a record of where it came from
+		if err := h.db.MarkFileDeleted(file.ID); err != nil {
+			return nil, err
+		}
+		return h.db.GetFileByID(file.ID)
+	}
+	return file, nil
+}
@@ -0,0 +1,247 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rsvihladremio/ddd/internal/database"
+	"github.com/rsvihladremio/ddd/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlers_ReportBundleRoundTrip(t *testing.T) {
+	source, sourceDB := setupTestHandler(t)
+
+	content := testutil.SampleFiles["iostat"].Content
+	sum := sha256.Sum256(content)
+	hash := hex.EncodeToString(sum[:])
+	filePath := filepath.Join(source.cfg.UploadsDir, hash)
+	require.NoError(t, os.WriteFile(filePath, content, 0600))
+
+	file := &database.File{
+		Hash:         hash,
+		OriginalName: "node1-iostat.txt",
+		FileType:     "iostat",
+		FileSize:     int64(len(content)),
+		UploadTime:   time.Now().Add(-time.Hour),
+		FilePath:     filePath,
+	}
+	require.NoError(t, sourceDB.InsertFile(file))
+	report := &database.Report{FileID: file.ID, ReportType: "iostat", Status: "completed", CreatedTime: time.Now(),
+		DDDVersion: "0.9.0", ReportData: `{"type":"iostat","summary":"portable summary","html_report":"<html><script>alert(1)</script></html>"}`}
+	require.NoError(t, sourceDB.InsertReport(report))
+	require.NoError(t, sourceDB.SetReportGenerationTime(report.ID, 1500*time.Millisecond))
+
+	export := func(t *testing.T, query string) []byte {
+		w := httptest.NewRecorder()
+		source.HandleReports(w, httptest.NewRequest("GET", fmt.Sprintf("/api/reports/%d/bundle%s", report.ID, query), nil))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, w.Header().Get("Content-Disposition"), fmt.Sprintf(`filename="ddd-iostat-report-%d.json"`, report.ID))
+		return w.Body.Bytes()
+	}
+
+	type importResponse struct {
+		Success bool             `json:"success"`
+		Report  *database.Report `json:"report"`
+		File    *database.File   `json:"file"`
+	}
+	importBundle := func(t *testing.T, target *Handlers, bundle []byte) importResponse {
+		w := httptest.NewRecorder()
+		target.HandleImportReport(w, httptest.NewRequest("POST", "/api/reports/import", strings.NewReader(string(bundle))))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var response importResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+
+	t.Run("Import with the original file", func(t *testing.T) {
+		target, targetDB := setupTestHandler(t)
+
+		response := importBundle(t, target, export(t, "?include_file=true"))
+		assert.True(t, response.Success)
+		assert.False(t, response.File.Deleted)
+		assert.Equal(t, hash, response.File.Hash)
+		assert.Equal(t, "node1-iostat.txt", response.File.OriginalName)
+
+		stored, err := os.ReadFile(response.File.FilePath)
+		require.NoError(t, err)
+		assert.Equal(t, content, stored)
+
+		// The bundled report data is not trusted, the report is generated again from the file
+		imported, err := targetDB.GetReportByID(response.Report.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "pending", imported.Status)
+		assert.Equal(t, "iostat", imported.ReportType)
+		assert.Empty(t, imported.ReportData)
+
+		// Importing again attaches the report to the file already stored
+		again := importBundle(t, target, export(t, "?include_file=true"))
+		assert.Equal(t, response.File.ID, again.File.ID)
+	})
+
+	t.Run("Import without the original file", func(t *testing.T) {
+		target, targetDB := setupTestHandler(t)
+
+		bundle := export(t, "")
+		assert.NotContains(t, string(bundle), `"content"`)
+
+		response := importBundle(t, target, bundle)
+		assert.True(t, response.File.Deleted, "A file without content is kept as a deleted record")
+		assert.Equal(t, hash, response.File.Hash)
+
+		reports, err := targetDB.GetReportsByFileID(response.File.ID)
+		require.NoError(t, err)
+		require.Len(t, reports, 1)
+		assert.Equal(t, "completed", reports[0].Status)
+
+		imported, err := targetDB.GetReportByID(response.Report.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "0.9.0", imported.DDDVersion)
+		assert.Equal(t, int64(1500), imported.GenerationMs)
+		require.NotNil(t, imported.CompletedTime)
+		var data map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(imported.ReportData), &data))
+		assert.Equal(t, "portable summary", data["summary"])
+
+		logs, err := targetDB.GetReportLogs(imported.ID, "", "")
+		require.NoError(t, err)
+		require.Len(t, logs, 1)
+		assert.Contains(t, logs[0].Message, "Imported from a bundle exported by DDD")
+	})
+
+	t.Run("Files no longer on disk are left out", func(t *testing.T) {
+		require.NoError(t, os.Rename(filePath, filePath+".moved"))
+		defer func() {
+			require.NoError(t, os.Rename(filePath+".moved", filePath))
+		}()
+
+		var bundle reportBundle
+		require.NoError(t, json.Unmarshal(export(t, "?include_file=true"), &bundle))
+		assert.Equal(t, reportBundleVersion, bundle.Version)
+		assert.Nil(t, bundle.Content)
+		assert.Equal(t, hash, bundle.File.Hash)
+	})
+
+	t.Run("Only completed reports are exported", func(t *testing.T) {
+		pending := &database.Report{FileID: file.ID, ReportType: "iostat", Status: "pending", CreatedTime: time.Now(), DDDVersion: DDDVersion}
+		require.NoError(t, sourceDB.InsertReport(pending))
+
+		w := httptest.NewRecorder()
+		source.HandleReports(w, httptest.NewRequest("GET", fmt.Sprintf("/api/reports/%d/bundle", pending.ID), nil))
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		w = httptest.NewRecorder()
+		source.HandleReports(w, httptest.NewRequest("GET", "/api/reports/99999/bundle", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = httptest.NewRecorder()
+		source.HandleReports(w, httptest.NewRequest("GET", fmt.Sprintf("/api/reports/%d/bundle?include_file=maybe", report.ID), nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestHandlers_HandleImportReportDropsBundledHTML(t *testing.T) {
+	handler, db := setupTestHandler(t)
+
+	bundle := `{"version": 1, "exported_time": "2025-01-02T03:04:05Z",
+		"report": {"report_type": "iostat", "report_data": {
+			"summary": "sda saturated",
+			"html_report": "<html><body><script>fetch('/api/settings', {method: 'POST'})</script></body></html>",
+			"chart_data": {"util": "<script>alert(1)</script>"}}},
+		"file": {"hash": "` + strings.Repeat("b", 64) + `", "original_name": "crafted-iostat.txt"}}`
+	w := httptest.NewRecorder()
+	handler.HandleImportReport(w, httptest.NewRequest("POST", "/api/reports/import", strings.NewReader(bundle)))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var response struct {
+		Report *database.Report `json:"report"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+	stored, err := db.GetReportByID(response.Report.ID)
+	require.NoError(t, err)
+	assert.NotContains(t, stored.ReportData, "<script>")
+
+	// The report page renders what the content endpoint returns
+	w = httptest.NewRecorder()
+	handler.HandleReportContent(w, httptest.NewRequest("GET", fmt.Sprintf("/api/reports/content/%d", stored.ID), nil))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var content struct {
+		ReportData string `json:"report_data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &content))
+	assert.NotContains(t, content.ReportData, "<script>")
+	assert.NotContains(t, content.ReportData, "html_report")
+	assert.Contains(t, content.ReportData, "sda saturated")
+}
+
+func TestHandlers_HandleImportReportValidation(t *testing.T) {
+	handler, _ := setupTestHandler(t)
+
+	validHash := strings.Repeat("a", 64)
+	testCases := []struct {
+		name      string
+		body      string
+		expectMsg string
+	}{
+		{"Invalid JSON", `not json`, "Invalid report bundle"},
+		{"Unsupported version", `{"version": 2, "report": {"report_type": "ttop", "report_data": {}}, "file": {"hash": "` + validHash + `", "original_name": "a"}}`,
+			"Report bundle version 2 was exported by a newer DDD, this one reads up to version 1"},
+		{"Missing version", `{"report": {"report_type": "ttop", "report_data": {}}, "file": {"hash": "` + validHash + `", "original_name": "a"}}`,
+			"Unsupported report bundle version 0"},
+		{"Unknown report type", `{"version": 1, "report": {"report_type": "pcap", "report_data": {}}, "file": {"hash": "` + validHash + `", "original_name": "a"}}`,
+			"Unknown report type: pcap"},
+		{"Report data is not an object", `{"version": 1, "report": {"report_type": "ttop", "report_data": "text"}, "file": {"hash": "` + validHash + `", "original_name": "a"}}`,
+			"Report data must be a JSON object"},
+		{"Unknown file type", `{"version": 1, "report": {"report_type": "ttop", "report_data": {}}, "file": {"hash": "` + validHash + `", "original_name": "a", "file_type": "<img src=x>"}}`,
+			"Unknown file type"},
+		{"Missing file name", `{"version": 1, "report": {"report_type": "ttop", "report_data": {}}, "file": {"hash": "` + validHash + `"}}`,
+			"missing the file name"},
+		{"Invalid hash", `{"version": 1, "report": {"report_type": "ttop", "report_data": {}}, "file": {"hash": "../../etc/passwd", "original_name": "a"}}`,
+			"SHA-256 hex digest"},
+		{"Unsupported hash algorithm", `{"version": 1, "report": {"report_type": "ttop", "report_data": {}}, "file": {"hash": "` + validHash + `", "hash_algorithm": "md5", "original_name": "a"}}`,
+			"expected one of sha256, xxhash"},
+		{"Hash of the wrong algorithm", `{"version": 1, "report": {"report_type": "ttop", "report_data": {}}, "file": {"hash": "` + validHash + `", "hash_algorithm": "xxhash", "original_name": "a"}}`,
+			"xxHash64 hex digest"},
+		{"Content does not match hash", `{"version": 1, "report": {"report_type": "ttop", "report_data": {}}, "file": {"hash": "` + validHash + `", "original_name": "a"}, "content": "aGVsbG8="}`,
+			"does not match its hash"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.HandleImportReport(w, httptest.NewRequest("POST", "/api/reports/import", strings.NewReader(tc.body)))
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Contains(t, w.Body.String(), tc.expectMsg)
+		})
+	}
+
+	t.Run("Only POST is allowed", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.HandleImportReport(w, httptest.NewRequest("GET", "/api/reports/import", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
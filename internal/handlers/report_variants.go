@@ -0,0 +1,174 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/rsvihladremio/ddd/internal/database"
+	"github.com/rsvihladremio/ddd/internal/reporters"
+)
+
+// maxReportVariants caps the regenerated report variants kept in memory
+const maxReportVariants = 16
+
+// errRegenerationBusy is returned while another report variant is being generated
+var errRegenerationBusy = errors.New("another report is being regenerated")
+
+// reportVariantKey names a report regenerated with another number of top threads
+type reportVariantKey struct {
+	reportID   int
+	topThreads int
+}
+
+// reportVariant is a regenerated report and the ETag of the stored report it came from
+type reportVariant struct {
+	sourceETag string
+	reportData string
+}
+
+// reportVariants caches reports regenerated with another top_threads and runs one
+// regeneration at a time, so a client can't tie up every CPU by asking for each count.
+// Requests arriving while one runs are turned away rather than queued behind it
+type reportVariants struct {
+	mu       sync.Mutex
+	variants map[reportVariantKey]reportVariant
+	order    []reportVariantKey // Oldest first, for eviction
+
+	slot chan struct{} // Held while a variant is generated
+}
+
+// newReportVariants creates an empty variant cache
+func newReportVariants() *reportVariants {
+	return &reportVariants{
+		variants: make(map[reportVariantKey]reportVariant),
+		slot:     make(chan struct{}, 1),
+	}
+}
+
+// get returns a cached variant generated from the stored report with sourceETag
+func (v *reportVariants) get(key reportVariantKey, sourceETag string) (string, bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	variant, ok := v.variants[key]
+	if !ok || variant.sourceETag != sourceETag {
+		return "", false
+	}
+	return variant.reportData, true
+}
+
+// put caches a variant, evicting the oldest once maxReportVariants are cached
+func (v *reportVariants) put(key reportVariantKey, sourceETag, reportData string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if _, ok := v.variants[key]; !ok {
+		v.order = append(v.order, key)
+	}
+	v.variants[key] = reportVariant{sourceETag: sourceETag, reportData: reportData}
+	for len(v.order) > maxReportVariants {
+		delete(v.variants, v.order[0])
+		v.order = v.order[1:]
+	}
+}
+
+// hasTopThreadsChart reports whether reports of reportType chart their busiest threads
+// and so can be regenerated with another top_threads
+func hasTopThreadsChart(reportType string) bool {
+	return reportType == "ttop" || reportType == "top"
+}
+
+// regenerateWithTopThreads generates a ttop or top report again from its file with
+// topThreads threads in the CPU chart, in the theme the stored report was generated with.
+// It is generated while the request waits, so it gives up after regenerationTimeout and
+// fails with errRegenerationBusy while another variant is generated. Like the report
+// worker it keeps the report within max_report_bytes; the result is cached until the
+// stored report changes
+func (h *Handlers) regenerateWithTopThreads(ctx context.Context, report *database.Report, file *database.File, topThreads int) (string, error) {
+	key := reportVariantKey{reportID: report.ID, topThreads: topThreads}
+	sourceETag := reportETag(report, "", 0)
+	if reportData, ok := h.variants.get(key, sourceETag); ok {
+		return reportData, nil
+	}
+
+	select {
+	case h.variants.slot <- struct{}{}:
+		defer func() { <-h.variants.slot }()
+	default:
+		return "", errRegenerationBusy
+	}
+	// Another request may have generated it before this one took the slot
+	if reportData, ok := h.variants.get(key, sourceETag); ok {
+		return reportData, nil
+	}
+
+	reporter, ok := reporters.Lookup(report.ReportType)
+	if !ok {
+		return "", fmt.Errorf("unknown report type: %s", report.ReportType)
+	}
+
+	var stored struct {
+		Theme string `json:"theme"`
+	}
+	if err := json.Unmarshal([]byte(report.ReportData), &stored); err != nil {
+		return "", fmt.Errorf("failed to parse report: %w", err)
+	}
+
+	loc, err := h.settings.Location()
+	if err != nil {
+		log.Printf("Error loading timezone, using UTC: %v", err)
+		loc = time.UTC
+	}
+	if timeout := h.regenerationTimeout(); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	generate := func(ctx context.Context, maxPoints int) (string, error) {
+		return reporter.Generate(ctx, file.FilePath, reporters.Options{
+			Location:   loc,
+			MaxPoints:  maxPoints,
+			TopThreads: topThreads,
+			Theme:      stored.Theme,
+		})
+	}
+
+	reportData, err := generate(ctx, h.cfg.MaxChartPoints)
+	if err != nil {
+		return "", err
+	}
+	reportData, err = reporters.EnforceReportSize(ctx, report.ReportType, reportData, h.cfg.MaxReportBytes, h.cfg.MaxChartPoints, generate, nil)
+	if err != nil {
+		return "", err
+	}
+	h.variants.put(key, sourceETag, reportData)
+	return reportData, nil
+}
+
+// regenerationTimeout returns how long regenerating a report variant may take, 0 for no
+// limit: report_timeout_seconds, capped at half of write_timeout_seconds so the response
+// is written before the server gives up on the connection
+func (h *Handlers) regenerationTimeout() time.Duration {
+	timeout := h.cfg.ReportTimeout()
+	if writeTimeout := h.cfg.WriteTimeout(); writeTimeout > 0 && (timeout == 0 || timeout > writeTimeout/2) {
+		timeout = writeTimeout / 2
+	}
+	return timeout
+}
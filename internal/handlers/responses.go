@@ -0,0 +1,61 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import "github.com/rsvihladremio/ddd/internal/database"
+
+// Typed response bodies of the API. Handlers still answering with a map[string]interface{}
+// move here as they are changed, so each endpoint's contract is written down in one place
+
+// DiskStats is the space of the filesystem a directory is on
+type DiskStats struct {
+	Path      string  `json:"path"`
+	Total     uint64  `json:"total_bytes"`
+	Free      uint64  `json:"free_bytes"`
+	Used      uint64  `json:"used_bytes"`
+	Available uint64  `json:"available_bytes"` // Free space usable without root
+	Percent   float64 `json:"used_percent"`
+}
+
+// DiskUsageResponse is the body of GET /api/disk-usage
+type DiskUsageResponse struct {
+	Success           bool      `json:"success"`
+	Uploads           DiskStats `json:"uploads"`
+	Database          DiskStats `json:"database"`
+	SameFilesystem    bool      `json:"same_filesystem"` // Uploads and the database share the space
+	MaxDiskUsage      float64   `json:"max_disk_usage"`  // Fraction of the disk cleanup keeps usage under
+	FileRetentionDays int       `json:"file_retention_days"`
+}
+
+// HealthResponse is the body of GET /api/health, each check is "ok" or what went wrong
+type HealthResponse struct {
+	Status  string `json:"status"` // "ok" or "unavailable"
+	DB      string `json:"db"`
+	Uploads string `json:"uploads"`
+}
+
+// ReportHistoryEntry is one report in a file's history
+type ReportHistoryEntry struct {
+	*database.Report
+	Stale        bool `json:"stale"`                   // Generated by an older DDD version than the one running
+	SupersededBy int  `json:"superseded_by,omitempty"` // ID of the newest report of the same type, 0 when this is it
+}
+
+// FileReportHistoryResponse is the body of GET /api/files/{id}/history, oldest report first
+type FileReportHistoryResponse struct {
+	Success bool                 `json:"success"`
+	FileID  int                  `json:"file_id"`
+	Reports []ReportHistoryEntry `json:"reports"`
+}
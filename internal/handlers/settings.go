@@ -0,0 +1,610 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rsvihladremio/ddd/internal/config"
+	"github.com/rsvihladremio/ddd/internal/detector"
+	"github.com/rsvihladremio/ddd/internal/reporters"
+	"github.com/rsvihladremio/ddd/internal/settings"
+)
+
+// settingType is how a setting's value is parsed and validated
+type settingType string
+
+const (
+	settingTypeFloat  settingType = "float"
+	settingTypeInt    settingType = "int"
+	settingTypeChoice settingType = "choice"
+	settingTypeBool   settingType = "bool"
+	// settingTypeList values are any number of the choices, sent as a JSON array or a
+	// comma separated string and stored comma separated
+	settingTypeList settingType = "list"
+	// settingTypeTimezone values are IANA time zone names such as UTC or America/New_York
+	settingTypeTimezone settingType = "timezone"
+)
+
+// settingDefinition describes a setting that can be read and changed through /api/settings.
+// Values are sent and returned in the units described by Min and Max; Scale converts them
+// to how they are stored
+type settingDefinition struct {
+	Key         string      `json:"key"`
+	Type        settingType `json:"type"`
+	Description string      `json:"description"`
+	Min         *float64    `json:"min,omitempty"`
+	Max         *float64    `json:"max,omitempty"`
+	Choices     []string    `json:"choices,omitempty"`
+
+	// Optional settings have no default and are only returned once saved, e.g. overrides
+	// of another setting
+	Optional bool `json:"optional,omitempty"`
+
+	// Scale divides a sent value before it is stored, 0 to store it as sent
+	Scale float64 `json:"-"`
+	// defaultValue is the stored value to use when the setting has not been saved, nil
+	// for optional settings
+	defaultValue func(cfg *config.Config) string
+	// afterSave applies a newly stored value, e.g. to the running config
+	afterSave func(h *Handlers, previous, stored string)
+}
+
+// settingsRegistry lists every setting the settings endpoint knows, in the order they
+// are validated and saved
+var settingsRegistry = slices.Concat([]settingDefinition{
+	{
+		Key:         settings.KeyMaxDiskUsage,
+		Type:        settingTypeFloat,
+		Description: "Percentage of the uploads disk to use before the oldest files are cleaned up",
+		Min:         settingBound(0),
+		Max:         settingBound(100),
+		Scale:       100,
+		defaultValue: func(cfg *config.Config) string {
+			return fmt.Sprintf("%.6f", cfg.MaxDiskUsage)
+		},
+		afterSave: func(h *Handlers, previous, stored string) {
+			maxUsage, err := strconv.ParseFloat(stored, 64)
+			if err != nil {
+				return
+			}
+			// Also update config for backward compatibility
+			h.cfg.MaxDiskUsage = maxUsage
+
+			// If threshold was lowered, trigger immediate cleanup
+			if current, err := strconv.ParseFloat(previous, 64); err == nil && maxUsage < current && h.cleanupWorker != nil {
+				log.Printf("Cleanup threshold lowered from %.1f%% to %.1f%%, triggering cleanup", current*100, maxUsage*100)
+				go h.cleanupWorker.TriggerCleanup()
+			}
+		},
+	},
+	{
+		Key:         settings.KeyFileRetentionDays,
+		Type:        settingTypeInt,
+		Description: "Days to keep uploaded files before they are deleted",
+		Min:         settingBound(0),
+		defaultValue: func(cfg *config.Config) string {
+			return strconv.Itoa(cfg.FileRetentionDays)
+		},
+		afterSave: func(h *Handlers, previous, stored string) {
+			if days, err := strconv.Atoi(stored); err == nil {
+				// Also update config for backward compatibility
+				h.cfg.FileRetentionDays = days
+			}
+		},
+	},
+}, fileRetentionByTypeSettings(), []settingDefinition{
+	{
+		Key:         settings.KeyReportRetentionDays,
+		Type:        settingTypeInt,
+		Description: "Days report data is kept after its file is deleted before it is purged, 0 to keep it forever",
+		Min:         settingBound(0),
+		defaultValue: func(cfg *config.Config) string {
+			return strconv.Itoa(cfg.ReportRetentionDays)
+		},
+		afterSave: func(h *Handlers, previous, stored string) {
+			if days, err := strconv.Atoi(stored); err == nil {
+				h.cfg.ReportRetentionDays = days
+			}
+		},
+	},
+	{
+		Key:         settings.KeyReportDataRetentionDays,
+		Type:        settingTypeInt,
+		Description: "Days reports keep their charts and raw data before only their summary, analysis and findings are kept, 0 to keep it forever",
+		Min:         settingBound(0),
+		defaultValue: func(cfg *config.Config) string {
+			return strconv.Itoa(cfg.ReportDataRetentionDays)
+		},
+		afterSave: func(h *Handlers, previous, stored string) {
+			if days, err := strconv.Atoi(stored); err == nil {
+				h.cfg.ReportDataRetentionDays = days
+			}
+		},
+	},
+	{
+		Key:          settings.KeyReportPollIntervalSeconds,
+		Type:         settingTypeInt,
+		Description:  "Seconds between checks for pending reports",
+		Min:          settingBound(1),
+		defaultValue: staticSetting("10"),
+		afterSave: func(h *Handlers, previous, stored string) {
+			if stored != previous && h.reportWorker != nil {
+				h.reportWorker.ResetInterval()
+			}
+		},
+	},
+	{
+		Key:          settings.KeyCleanupIntervalSeconds,
+		Type:         settingTypeInt,
+		Description:  "Seconds between scheduled cleanups of old files and reports",
+		Min:          settingBound(1),
+		defaultValue: staticSetting("3600"),
+		afterSave: func(h *Handlers, previous, stored string) {
+			if stored != previous && h.cleanupWorker != nil {
+				h.cleanupWorker.ResetInterval()
+			}
+		},
+	},
+	{
+		Key:          settings.KeyIOStatUtilThreshold,
+		Type:         settingTypeFloat,
+		Description:  "Device %util above which iostat reports flag a finding",
+		Min:          settingBound(0),
+		Max:          settingBound(100),
+		defaultValue: staticSetting(strconv.FormatFloat(reporters.DefaultIOStatThresholds().UtilizationPct, 'f', -1, 64)),
+	},
+	{
+		Key:          settings.KeyIOStatAwaitThresholdMs,
+		Type:         settingTypeFloat,
+		Description:  "Read or write await in milliseconds above which iostat reports flag a finding",
+		Min:          settingBound(0),
+		defaultValue: staticSetting(strconv.FormatFloat(reporters.DefaultIOStatThresholds().AwaitMs, 'f', -1, 64)),
+	},
+	{
+		Key:          settings.KeyIOStatPartitions,
+		Type:         settingTypeChoice,
+		Description:  "What new iostat reports do with the partition rows of iostat -p captures: keep them as devices, roll them up into their parent device or filter them out",
+		Choices:      reporters.IOStatPartitionModes(),
+		defaultValue: staticSetting(reporters.IOStatPartitionsKeep),
+	},
+	{
+		Key:          settings.KeyTTopTopThreads,
+		Type:         settingTypeInt,
+		Description:  "Busiest threads, or processes for top captures, shown in the CPU chart of new ttop and top reports",
+		Min:          settingBound(1),
+		Max:          settingBound(reporters.MaxTTopTopThreads),
+		defaultValue: staticSetting(strconv.Itoa(reporters.DefaultTTopTopThreads)),
+	},
+	{
+		Key:         settings.KeyTimezone,
+		Type:        settingTypeTimezone,
+		Description: "IANA time zone that the timestamps of new iostat and ttop reports are read in",
+		defaultValue: func(cfg *config.Config) string {
+			return cfg.Timezone
+		},
+	},
+	{
+		Key:          settings.KeyReadOnly,
+		Type:         settingTypeBool,
+		Description:  "Refuse uploads, deletes and settings changes and pause report generation, e.g. during a backup or migration",
+		defaultValue: staticSetting("false"),
+		afterSave: func(h *Handlers, previous, stored string) {
+			if stored != previous {
+				log.Printf("Read-only mode set to %s", stored)
+			}
+		},
+	},
+	{
+		Key:          settings.KeyAllowedFileTypes,
+		Type:         settingTypeList,
+		Description:  "Detected file types uploads are accepted for, empty to accept every type",
+		Choices:      detector.KnownFileTypes(),
+		defaultValue: staticSetting(""),
+	},
+	{
+		Key:          settings.KeyHashAlgorithm,
+		Type:         settingTypeChoice,
+		Description:  "Hash new uploads are deduplicated by; xxhash is much faster than sha256 on large files, and files stored under either are still matched",
+		Choices:      contentHashAlgorithmNames(),
+		defaultValue: staticSetting(hashAlgorithmSHA256),
+	},
+	{
+		Key:          settings.KeyReportTheme,
+		Type:         settingTypeChoice,
+		Description:  "Theme new ttop and iostat HTML reports are generated with",
+		Choices:      reporters.ReportThemeNames(),
+		defaultValue: staticSetting(reporters.DefaultReportTheme),
+	},
+})
+
+// fileRetentionByTypeSettings returns an optional file_retention_days.{type} setting for every
+// file type, so e.g. JFR recordings can be kept longer than iostat samples
+func fileRetentionByTypeSettings() []settingDefinition {
+	fileTypes := detector.KnownFileTypes()
+	definitions := make([]settingDefinition, 0, len(fileTypes))
+	for _, fileType := range fileTypes {
+		definitions = append(definitions, settingDefinition{
+			Key:         settings.FileRetentionByTypePrefix + fileType,
+			Type:        settingTypeInt,
+			Description: fmt.Sprintf("Days to keep uploaded %s files, overriding file_retention_days", fileType),
+			Min:         settingBound(0),
+			Optional:    true,
+		})
+	}
+	return definitions
+}
+
+// settingBound returns a pointer to a setting's minimum or maximum
+func settingBound(value float64) *float64 {
+	return &value
+}
+
+// staticSetting returns a default that does not depend on the config
+func staticSetting(value string) func(cfg *config.Config) string {
+	return func(*config.Config) string {
+		return value
+	}
+}
+
+// lookupSetting returns the registered definition for key
+func lookupSetting(key string) (settingDefinition, bool) {
+	for _, definition := range settingsRegistry {
+		if definition.Key == key {
+			return definition, true
+		}
+	}
+	return settingDefinition{}, false
+}
+
+// DefaultSettings returns the stored value of every registered setting before it is
+// changed, seeded from cfg where the setting has a configuration option
+func DefaultSettings(cfg *config.Config) map[string]string {
+	defaults := make(map[string]string, len(settingsRegistry))
+	for _, definition := range settingsRegistry {
+		if !definition.Optional {
+			defaults[definition.Key] = definition.defaultValue(cfg)
+		}
+	}
+	return defaults
+}
+
+// parse validates a value sent for the setting and returns it as it is stored
+func (d settingDefinition) parse(value string) (string, error) {
+	switch d.Type {
+	case settingTypeChoice:
+		if !slices.Contains(d.Choices, value) {
+			return "", fmt.Errorf("Unknown %s %q, expected one of %s", d.Key, value, strings.Join(d.Choices, ", "))
+		}
+		return value, nil
+	case settingTypeBool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", fmt.Errorf("%s must be true or false", d.Key)
+		}
+		return strconv.FormatBool(parsed), nil
+	case settingTypeList:
+		items := settings.SplitList(value)
+		for _, item := range items {
+			if !slices.Contains(d.Choices, item) {
+				return "", fmt.Errorf("Unknown %s %q, expected any of %s", d.Key, item, strings.Join(d.Choices, ", "))
+			}
+		}
+		return strings.Join(items, ","), nil
+	case settingTypeTimezone:
+		if _, err := time.LoadLocation(value); err != nil {
+			return "", fmt.Errorf("%s must be an IANA zone name such as UTC or America/New_York", d.Key)
+		}
+		return value, nil
+	case settingTypeInt:
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return "", fmt.Errorf("Invalid %s value", d.Key)
+		}
+		if err := d.checkRange(float64(parsed)); err != nil {
+			return "", err
+		}
+		return strconv.Itoa(parsed), nil
+	default:
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return "", fmt.Errorf("Invalid %s value", d.Key)
+		}
+		if err := d.checkRange(parsed); err != nil {
+			return "", err
+		}
+		if d.Scale != 0 {
+			return fmt.Sprintf("%.6f", parsed/d.Scale), nil
+		}
+		return strconv.FormatFloat(parsed, 'f', -1, 64), nil
+	}
+}
+
+// checkRange reports whether value is outside the setting's minimum or maximum
+func (d settingDefinition) checkRange(value float64) error {
+	tooLow := d.Min != nil && value < *d.Min
+	tooHigh := d.Max != nil && value > *d.Max
+	switch {
+	case !tooLow && !tooHigh:
+		return nil
+	case d.Min != nil && d.Max != nil:
+		return fmt.Errorf("%s must be between %g and %g", d.Key, *d.Min, *d.Max)
+	case tooLow && *d.Min == 0:
+		return fmt.Errorf("%s must be non-negative", d.Key)
+	case tooLow:
+		return fmt.Errorf("%s must be at least %g", d.Key, *d.Min)
+	default:
+		return fmt.Errorf("%s must be at most %g", d.Key, *d.Max)
+	}
+}
+
+// value converts a stored value to the value the endpoint returns, in the units it is sent
+func (d settingDefinition) value(stored string) (any, error) {
+	switch d.Type {
+	case settingTypeChoice:
+		if !slices.Contains(d.Choices, stored) {
+			return nil, fmt.Errorf("unknown %s %q", d.Key, stored)
+		}
+		return stored, nil
+	case settingTypeBool:
+		return strconv.ParseBool(stored)
+	case settingTypeList:
+		return settings.SplitList(stored), nil
+	case settingTypeTimezone:
+		if _, err := time.LoadLocation(stored); err != nil {
+			return nil, err
+		}
+		return stored, nil
+	case settingTypeInt:
+		return strconv.Atoi(stored)
+	default:
+		parsed, err := strconv.ParseFloat(stored, 64)
+		if err != nil {
+			return nil, err
+		}
+		if d.Scale != 0 {
+			// Round away the error from dividing when it was stored
+			return strconv.ParseFloat(strconv.FormatFloat(parsed*d.Scale, 'f', 4, 64), 64)
+		}
+		return parsed, nil
+	}
+}
+
+// currentSettings returns the value of every registered setting, falling back to its
+// default when it is missing or invalid, and any other stored settings as they are stored
+func (h *Handlers) currentSettings() (map[string]any, error) {
+	stored, err := h.db.GetAllSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	settings := make(map[string]any, len(stored)+len(settingsRegistry))
+	for key, value := range stored {
+		if _, ok := lookupSetting(key); !ok {
+			settings[key] = value
+		}
+	}
+	for _, definition := range settingsRegistry {
+		raw, ok := stored[definition.Key]
+		if !ok && definition.Optional {
+			continue
+		}
+		if !ok {
+			raw = definition.defaultValue(h.cfg)
+		}
+		value, err := definition.value(raw)
+		if err != nil && definition.Optional {
+			log.Printf("Invalid %s setting %q, ignoring it: %v", definition.Key, raw, err)
+			continue
+		}
+		if err != nil {
+			log.Printf("Invalid %s setting %q, using the default: %v", definition.Key, raw, err)
+			if value, err = definition.value(definition.defaultValue(h.cfg)); err != nil {
+				return nil, err
+			}
+		}
+		settings[definition.Key] = value
+	}
+	return settings, nil
+}
+
+// HandleSettings handles settings operations. GET returns every setting and the
+// definitions of those that can be changed; POST changes any subset of the registered
+// settings, validating all of them before any is saved
+func (h *Handlers) HandleSettings(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		settings, err := h.currentSettings()
+		if err != nil {
+			log.Printf("Error getting settings: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "Failed to get settings", ErrCodeInternal)
+			return
+		}
+
+		// Get settings from database
+		maxDiskUsage, err := h.settings.MaxDiskUsage()
+		if err != nil {
+			log.Printf("Error getting max disk usage setting: %v", err)
+			maxDiskUsage = h.cfg.MaxDiskUsage // fallback
+		}
+
+		fileRetentionDays, err := h.settings.FileRetentionDays()
+		if err != nil {
+			log.Printf("Error getting file retention days setting: %v", err)
+			fileRetentionDays = h.cfg.FileRetentionDays // fallback
+		}
+
+		thresholds := h.settings.IOStatThresholds()
+
+		// The top level fields predate the settings map and are kept for older clients;
+		// max_disk_usage there is a fraction rather than a percentage
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":                   true,
+			"settings":                  settings,
+			"definitions":               settingsRegistry,
+			"max_disk_usage":            maxDiskUsage,
+			"file_retention_days":       fileRetentionDays,
+			"iostat_util_threshold":     thresholds.UtilizationPct,
+			"iostat_await_threshold_ms": thresholds.AwaitMs,
+			"report_theme":              h.settings.ReportTheme(),
+			"report_themes":             reporters.ReportThemeNames(),
+			"read_only_mode":            h.settings.ReadOnly(),
+		}); err != nil {
+			log.Printf("Error encoding JSON response: %v", err)
+		}
+	case http.MethodPost:
+		// Values may be sent as strings, as the settings page does, or as JSON numbers
+		var req map[string]interface{}
+		decoder := json.NewDecoder(r.Body)
+		decoder.UseNumber()
+		if err := decoder.Decode(&req); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "Invalid request body", ErrCodeBadRequest)
+			return
+		}
+
+		for key := range req {
+			if _, ok := lookupSetting(key); !ok {
+				writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("Unknown setting %q", key), ErrCodeBadRequest)
+				return
+			}
+		}
+
+		// Only read_only itself can be changed in read-only mode, so it can be switched off again
+		if h.settings.ReadOnly() {
+			for key := range req {
+				if key != settings.KeyReadOnly {
+					writeReadOnlyError(w)
+					return
+				}
+			}
+		}
+
+		// Validate everything first so a bad value doesn't leave the settings half updated
+		updates := make(map[string]string)
+		for _, definition := range settingsRegistry {
+			sent, ok := req[definition.Key]
+			if !ok || sent == nil || sent == "" {
+				continue
+			}
+			var value string
+			switch sent := sent.(type) {
+			case string:
+				value = sent
+			case json.Number:
+				value = sent.String()
+			case bool:
+				value = strconv.FormatBool(sent)
+			case []interface{}:
+				// Only list settings take arrays; an empty array clears one
+				items := make([]string, 0, len(sent))
+				for _, item := range sent {
+					if item, ok := item.(string); ok {
+						items = append(items, item)
+					}
+				}
+				if definition.Type != settingTypeList || len(items) != len(sent) {
+					writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("Invalid %s value", definition.Key), ErrCodeBadRequest)
+					return
+				}
+				value = strings.Join(items, ",")
+			default:
+				writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("Invalid %s value", definition.Key), ErrCodeBadRequest)
+				return
+			}
+			stored, err := definition.parse(value)
+			if err != nil {
+				writeJSONError(w, http.StatusBadRequest, err.Error(), ErrCodeBadRequest)
+				return
+			}
+			updates[definition.Key] = stored
+		}
+
+		var updated []string
+		for _, definition := range settingsRegistry {
+			stored, ok := updates[definition.Key]
+			if !ok {
+				continue
+			}
+			previous, err := h.db.GetSetting(definition.Key)
+			if err != nil && !definition.Optional {
+				previous = definition.defaultValue(h.cfg)
+			}
+			if err := h.db.SetSetting(definition.Key, stored); err != nil {
+				log.Printf("Error saving %s setting: %v", definition.Key, err)
+				writeJSONError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to save %s setting", definition.Key), ErrCodeInternal)
+				return
+			}
+			if definition.afterSave != nil {
+				definition.afterSave(h, previous, stored)
+			}
+			updated = append(updated, definition.Key+"="+stored)
+		}
+
+		log.Printf("Updated settings: %s", strings.Join(updated, ", "))
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"message": "Settings updated successfully",
+		}); err != nil {
+			log.Printf("Error encoding JSON response: %v", err)
+		}
+	default:
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed", ErrCodeMethodNotAllowed)
+	}
+}
+
+// HandleSettingsHistory returns the settings audit log, most recent change first, e.g.
+// GET /api/settings/history?key=max_disk_usage&limit=20
+func (h *Handlers) HandleSettingsHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed", ErrCodeMethodNotAllowed)
+		return
+	}
+
+	limit := 100 // default
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			writeJSONError(w, http.StatusBadRequest, "limit must be a positive integer", ErrCodeBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	key := r.URL.Query().Get("key")
+	changes, err := h.db.GetSettingsHistory(key, limit)
+	if err != nil {
+		log.Printf("Error getting settings history: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to get settings history", ErrCodeInternal)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"changes": changes,
+	}); err != nil {
+		log.Printf("Error encoding JSON response: %v", err)
+	}
+}
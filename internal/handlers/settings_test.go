@@ -0,0 +1,256 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultSettings(t *testing.T) {
+	handler, _ := setupTestHandler(t)
+
+	defaults := DefaultSettings(handler.cfg)
+
+	// Every default must pass its own validation, optional settings have none
+	for _, definition := range settingsRegistry {
+		if definition.Optional {
+			assert.NotContains(t, defaults, definition.Key)
+			continue
+		}
+		_, err := definition.value(defaults[definition.Key])
+		assert.NoError(t, err, definition.Key)
+	}
+	assert.Equal(t, "90", defaults["iostat_util_threshold"])
+	assert.Equal(t, "default", defaults["report_theme"])
+	assert.Equal(t, "0", defaults["report_retention_days"], "Report data is kept forever unless configured")
+	assert.Equal(t, "false", defaults["read_only"])
+}
+
+func TestHandlers_HandleSettingsRegistry(t *testing.T) {
+	handler, db := setupTestHandler(t)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/settings", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		handler.HandleSettings(w, req)
+		return w
+	}
+	get := func(t *testing.T) map[string]interface{} {
+		w := httptest.NewRecorder()
+		handler.HandleSettings(w, httptest.NewRequest("GET", "/api/settings", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+
+	t.Run("Get every setting with its definition", func(t *testing.T) {
+		require.NoError(t, db.SetSetting("last_seen_version", "1.2.3"))
+
+		response := get(t)
+		settings := response["settings"].(map[string]interface{})
+		assert.Equal(t, handler.cfg.MaxDiskUsage*100, settings["max_disk_usage"])
+		assert.Equal(t, float64(handler.cfg.FileRetentionDays), settings["file_retention_days"])
+		assert.Equal(t, 90.0, settings["iostat_util_threshold"])
+		assert.Equal(t, "default", settings["report_theme"])
+		assert.Equal(t, false, settings["read_only"])
+		assert.Equal(t, "1.2.3", settings["last_seen_version"], "Unregistered settings are returned as stored")
+
+		definitions := response["definitions"].([]interface{})
+		require.Len(t, definitions, len(settingsRegistry))
+		first := definitions[0].(map[string]interface{})
+		assert.Equal(t, "max_disk_usage", first["key"])
+		assert.Equal(t, "float", first["type"])
+		assert.Equal(t, 0.0, first["min"])
+		assert.Equal(t, 100.0, first["max"])
+		theme := definitions[len(definitions)-1].(map[string]interface{})
+		assert.Equal(t, "choice", theme["type"])
+		assert.Equal(t, []interface{}{"dark", "default", "light"}, theme["choices"])
+	})
+
+	t.Run("Update a subset with numbers", func(t *testing.T) {
+		w := post(`{"iostat_await_threshold_ms": 42.5, "file_retention_days": 9}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		value, err := db.GetSetting("iostat_await_threshold_ms")
+		require.NoError(t, err)
+		assert.Equal(t, "42.5", value)
+		assert.Equal(t, 9, handler.cfg.FileRetentionDays)
+
+		settings := get(t)["settings"].(map[string]interface{})
+		assert.Equal(t, 42.5, settings["iostat_await_threshold_ms"])
+		assert.Equal(t, 9.0, settings["file_retention_days"])
+	})
+
+	t.Run("Max disk usage round trips as a percentage", func(t *testing.T) {
+		w := post(`{"max_disk_usage": "62.5"}`)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		value, err := db.GetSetting("max_disk_usage")
+		require.NoError(t, err)
+		assert.Equal(t, "0.625000", value)
+		assert.Equal(t, 62.5, get(t)["settings"].(map[string]interface{})["max_disk_usage"])
+	})
+
+	t.Run("Reject invalid values without saving any", func(t *testing.T) {
+		testCases := []struct {
+			body      string
+			expectMsg string
+		}{
+			{`{"report_theme": "dark", "retention": "5"}`, `Unknown setting \"retention\"`},
+			{`{"report_theme": "dark", "file_retention_days": "1.5"}`, "Invalid file_retention_days value"},
+			{`{"report_theme": "dark", "file_retention_days": true}`, "Invalid file_retention_days value"},
+			{`{"report_theme": "dark", "iostat_util_threshold": -1}`, "iostat_util_threshold must be between 0 and 100"},
+			{`{"report_theme": "neon"}`, `Unknown report_theme \"neon\", expected one of dark, default, light`},
+			{`["report_theme"]`, "Invalid request body"},
+		}
+
+		for _, tc := range testCases {
+			w := post(tc.body)
+			assert.Equal(t, http.StatusBadRequest, w.Code, tc.body)
+			assert.Contains(t, w.Body.String(), tc.expectMsg, tc.body)
+		}
+
+		assert.Equal(t, "default", get(t)["settings"].(map[string]interface{})["report_theme"])
+	})
+
+	t.Run("Timezone", func(t *testing.T) {
+		assert.Equal(t, handler.cfg.Timezone, get(t)["settings"].(map[string]interface{})["timezone"])
+
+		w := post(`{"timezone": "America/New_York"}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "America/New_York", get(t)["settings"].(map[string]interface{})["timezone"])
+		loc, err := handler.settings.Location()
+		require.NoError(t, err)
+		assert.Equal(t, "America/New_York", loc.String())
+
+		w = post(`{"timezone": "Mars/Olympus_Mons"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "timezone must be an IANA zone name")
+	})
+
+	t.Run("Per file type retention overrides", func(t *testing.T) {
+		settings := get(t)["settings"].(map[string]interface{})
+		assert.NotContains(t, settings, "file_retention_days.jfr", "Overrides are only returned once set")
+
+		w := post(`{"file_retention_days.jfr": 90}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		value, err := db.GetSetting("file_retention_days.jfr")
+		require.NoError(t, err)
+		assert.Equal(t, "90", value)
+		assert.Equal(t, 90.0, get(t)["settings"].(map[string]interface{})["file_retention_days.jfr"])
+
+		w = post(`{"file_retention_days.iostat": -1}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "file_retention_days.iostat must be non-negative")
+
+		w = post(`{"file_retention_days.pcap": 5}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `Unknown setting \"file_retention_days.pcap\"`)
+	})
+
+	t.Run("List settings", func(t *testing.T) {
+		assert.Equal(t, []interface{}{}, get(t)["settings"].(map[string]interface{})["allowed_file_types"])
+
+		w := post(`{"allowed_file_types": ["ttop", "iostat", "ttop"]}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		value, err := db.GetSetting("allowed_file_types")
+		require.NoError(t, err)
+		assert.Equal(t, "ttop,iostat", value)
+		assert.Equal(t, []interface{}{"ttop", "iostat"}, get(t)["settings"].(map[string]interface{})["allowed_file_types"])
+
+		w = post(`{"allowed_file_types": "jfr, thread_dump"}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, []interface{}{"jfr", "thread_dump"}, get(t)["settings"].(map[string]interface{})["allowed_file_types"])
+
+		for _, body := range []string{`{"allowed_file_types": ["pcap"]}`, `{"allowed_file_types": [1]}`, `{"report_theme": ["dark"]}`} {
+			w = post(body)
+			assert.Equal(t, http.StatusBadRequest, w.Code, body)
+		}
+
+		// An empty array clears the list
+		w = post(`{"allowed_file_types": []}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, []interface{}{}, get(t)["settings"].(map[string]interface{})["allowed_file_types"])
+	})
+
+	t.Run("Invalid stored values fall back to the default", func(t *testing.T) {
+		require.NoError(t, db.SetSetting("iostat_util_threshold", "not-a-number"))
+		assert.Equal(t, 90.0, get(t)["settings"].(map[string]interface{})["iostat_util_threshold"])
+	})
+}
+
+func TestHandlers_HandleSettingsHistory(t *testing.T) {
+	handler, _ := setupTestHandler(t)
+
+	body := `{"max_disk_usage": 70, "report_theme": "dark"}`
+	req := httptest.NewRequest("POST", "/api/settings", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	handler.HandleSettings(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	get := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.HandleSettingsHistory(w, httptest.NewRequest("GET", "/api/settings/history"+query, nil))
+		return w
+	}
+
+	t.Run("Filter by key", func(t *testing.T) {
+		w := get("?key=max_disk_usage")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Success bool `json:"success"`
+			Changes []struct {
+				Key      string  `json:"key"`
+				OldValue *string `json:"old_value"`
+				NewValue string  `json:"new_value"`
+			} `json:"changes"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.True(t, response.Success)
+		require.NotEmpty(t, response.Changes)
+		assert.Equal(t, "max_disk_usage", response.Changes[0].Key)
+		assert.Equal(t, "0.700000", response.Changes[0].NewValue)
+		for _, change := range response.Changes {
+			assert.Equal(t, "max_disk_usage", change.Key)
+		}
+	})
+
+	t.Run("Limit", func(t *testing.T) {
+		w := get("?limit=1")
+		require.Equal(t, http.StatusOK, w.Code)
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Len(t, response["changes"], 1)
+	})
+
+	t.Run("Invalid requests", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, get("?limit=zero").Code)
+		assert.Equal(t, http.StatusBadRequest, get("?limit=-1").Code)
+
+		w := httptest.NewRecorder()
+		handler.HandleSettingsHistory(w, httptest.NewRequest("POST", "/api/settings/history", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
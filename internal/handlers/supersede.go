@@ -0,0 +1,81 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/rsvihladremio/ddd/internal/database"
+)
+
+// supersededFile looks up the file named by the "supersedes" form field of an upload, nil when
+// the upload is not a newer version of an earlier capture. Only a single file can supersede
+// another and every file has at most one newer version, so versions form a chain
+func (h *Handlers) supersededFile(r *http.Request, uploadCount int) (*database.File, *uploadError) {
+	value := r.FormValue("supersedes")
+	if value == "" {
+		return nil, nil
+	}
+
+	fileID, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, &uploadError{http.StatusBadRequest, "Invalid supersedes file ID", ErrCodeBadRequest}
+	}
+	if uploadCount != 1 {
+		return nil, &uploadError{http.StatusBadRequest, "supersedes can only be used when uploading a single file", ErrCodeBadRequest}
+	}
+
+	file, err := h.db.GetFileByID(fileID)
+	if err != nil {
+		return nil, &uploadError{http.StatusNotFound, fmt.Sprintf("File %d not found", fileID), ErrCodeNotFound}
+	}
+
+	newer, err := h.db.GetFileSupersededBy(fileID)
+	if err == nil {
+		return nil, &uploadError{http.StatusConflict,
+			fmt.Sprintf("File %d is already superseded by file %d, supersede that instead", fileID, newer.ID), ErrCodeConflict}
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("Error checking newer versions of file %d: %v", fileID, err)
+		return nil, &uploadError{http.StatusInternalServerError, "Failed to check file versions", ErrCodeInternal}
+	}
+	return file, nil
+}
+
+// queueSupersedingReports regenerates the reports of the superseded file on the content of the
+// newer version, one for each report type the earlier capture had
+func (h *Handlers) queueSupersedingReports(file, superseded *database.File) {
+	reports, err := h.db.GetReportsByFileID(superseded.ID)
+	if err != nil {
+		log.Printf("Error getting reports of superseded file %d: %v", superseded.ID, err)
+		return
+	}
+
+	queued := make(map[string]bool)
+	for _, report := range reports {
+		if queued[report.ReportType] {
+			continue
+		}
+		queued[report.ReportType] = true
+		if _, _, err := h.queueReport(file.ID, report.ReportType); err != nil {
+			log.Printf("Failed to queue %s report for file %d superseding file %d: %v", report.ReportType, file.ID, superseded.ID, err)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rsvihladremio/ddd/internal/database"
+	"github.com/rsvihladremio/ddd/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlers_UploadSupersedes(t *testing.T) {
+	handler, db := setupTestHandler(t)
+
+	upload := func(t *testing.T, supersedes string, contents ...[]byte) *httptest.ResponseRecorder {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		for _, content := range contents {
+			part, err := writer.CreateFormFile("file", "iostat.txt")
+			require.NoError(t, err)
+			_, err = part.Write(content)
+			require.NoError(t, err)
+		}
+		if supersedes != "" {
+			require.NoError(t, writer.WriteField("supersedes", supersedes))
+		}
+		require.NoError(t, writer.Close())
+
+		req := httptest.NewRequest("POST", "/api/upload", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		w := httptest.NewRecorder()
+		handler.HandleUpload(w, req)
+		return w
+	}
+	fileID := func(t *testing.T, w *httptest.ResponseRecorder) int {
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var response struct {
+			File database.File `json:"file"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response.File.ID
+	}
+
+	original := testutil.SampleFiles["iostat"].Content
+	grown := append(append([]byte{}, original...), original[bytes.IndexByte(original, '\n'):]...)
+	grownAgain := append(append([]byte{}, grown...), original[bytes.IndexByte(original, '\n'):]...)
+
+	firstID := fileID(t, upload(t, "", original))
+	// The earlier capture had a report, it is regenerated on the newer content
+	require.NoError(t, db.InsertReport(&database.Report{FileID: firstID, ReportType: "iostat", Status: "completed",
+		CreatedTime: time.Now(), DDDVersion: DDDVersion, ReportData: "{}"}))
+
+	var secondID, thirdID int
+	t.Run("Upload a newer version", func(t *testing.T) {
+		w := upload(t, fmt.Sprint(firstID), grown)
+		secondID = fileID(t, w)
+		assert.Contains(t, w.Body.String(), fmt.Sprintf("newer version of file %d", firstID))
+
+		second, err := db.GetFileByID(secondID)
+		require.NoError(t, err)
+		require.NotNil(t, second.SupersedesFileID)
+		assert.Equal(t, firstID, *second.SupersedesFileID)
+
+		reports, err := db.GetReportsByFileID(secondID)
+		require.NoError(t, err)
+		require.Len(t, reports, 1)
+		assert.Equal(t, "iostat", reports[0].ReportType)
+		assert.Equal(t, "pending", reports[0].Status)
+	})
+
+	t.Run("Only the newest version can be superseded", func(t *testing.T) {
+		w := upload(t, fmt.Sprint(firstID), grownAgain)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), fmt.Sprintf("already superseded by file %d", secondID))
+
+		thirdID = fileID(t, upload(t, fmt.Sprint(secondID), grownAgain))
+
+		w = httptest.NewRecorder()
+		handler.HandleFileOperations(w, httptest.NewRequest("GET", fmt.Sprintf("/api/files/%d", secondID), nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			Versions []database.File `json:"versions"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		ids := make([]int, 0, len(response.Versions))
+		for _, version := range response.Versions {
+			ids = append(ids, version.ID)
+		}
+		assert.Equal(t, []int{firstID, secondID, thirdID}, ids)
+	})
+
+	t.Run("Invalid requests", func(t *testing.T) {
+		testCases := []struct {
+			name       string
+			supersedes string
+			contents   [][]byte
+			expectCode int
+			expectMsg  string
+		}{
+			{"Not a file ID", "abc", [][]byte{[]byte("new content")}, http.StatusBadRequest, "Invalid supersedes file ID"},
+			{"Unknown file", "9999", [][]byte{[]byte("new content")}, http.StatusNotFound, "File 9999 not found"},
+			{"Several files", fmt.Sprint(firstID), [][]byte{[]byte("a"), []byte("b")}, http.StatusBadRequest, "single file"},
+			{"Content already stored", fmt.Sprint(thirdID), [][]byte{original}, http.StatusConflict, fmt.Sprintf("already stored as file %d", firstID)},
+			{"Identical content", fmt.Sprint(thirdID), [][]byte{grownAgain}, http.StatusConflict, "Upload is identical"},
+		}
+		for _, tc := range testCases {
+			w := upload(t, tc.supersedes, tc.contents...)
+			assert.Equal(t, tc.expectCode, w.Code, tc.name)
+			assert.Contains(t, w.Body.String(), tc.expectMsg, tc.name)
+		}
+	})
+}
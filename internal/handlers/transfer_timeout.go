@@ -0,0 +1,63 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// isTransferRequest reports whether r uploads or downloads a whole file or report bundle,
+// which can legitimately take far longer than other requests on a slow link
+func isTransferRequest(r *http.Request) bool {
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	switch {
+	case path == "/api/upload", path == "/api/reports/import":
+		return true
+	case strings.HasPrefix(path, "/api/files/") && strings.HasSuffix(path, "/download"):
+		return true
+	case strings.HasPrefix(path, "/api/reports/") && strings.HasSuffix(path, "/bundle"):
+		return true
+	}
+	return false
+}
+
+// TransferTimeout gives uploads and downloads timeout to be read and written in place of
+// the server's read and write timeouts, which are sized for ordinary API calls. A timeout
+// of 0 lets them take as long as they need. Other requests are passed to next unchanged
+func TransferTimeout(timeout time.Duration, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isTransferRequest(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var deadline time.Time
+		if timeout > 0 {
+			deadline = time.Now().Add(timeout)
+		}
+		rc := http.NewResponseController(w)
+		if err := rc.SetReadDeadline(deadline); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			log.Printf("Error extending the read deadline of %s %s: %v", r.Method, r.URL.Path, err)
+		}
+		if err := rc.SetWriteDeadline(deadline); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			log.Printf("Error extending the write deadline of %s %s: %v", r.Method, r.URL.Path, err)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,92 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransferTimeout(t *testing.T) {
+	t.Run("Only uploads and downloads are transfers", func(t *testing.T) {
+		for path, expected := range map[string]bool{
+			"/api/upload":              true,
+			"/api/reports/import":      true,
+			"/api/files/3/download":    true,
+			"/api/reports/7/bundle":    true,
+			"/api/files":               false,
+			"/api/files/3":             false,
+			"/api/reports/7/content":   false,
+			"/api/reports/7":           false,
+			"/api/settings":            false,
+			"/reports/7/bundle/readme": false,
+		} {
+			assert.Equal(t, expected, isTransferRequest(httptest.NewRequest("GET", path, nil)), path)
+		}
+	})
+
+	// A real server, since the deadlines only exist on a real connection
+	server := httptest.NewUnstartedServer(TransferTimeout(time.Minute, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write(body)
+	})))
+	server.Config.ReadTimeout = 100 * time.Millisecond
+	server.Config.WriteTimeout = 100 * time.Millisecond
+	server.Start()
+	t.Cleanup(server.Close)
+
+	// slowPost sends its body a chunk at a time over well past the server timeouts
+	slowPost := func(path string) (*http.Response, error) {
+		pr, pw := io.Pipe()
+		go func() {
+			for i := 0; i < 6; i++ {
+				time.Sleep(50 * time.Millisecond)
+				if _, err := pw.Write([]byte("chunk")); err != nil {
+					return
+				}
+			}
+			_ = pw.Close()
+		}()
+		return server.Client().Post(server.URL+path, "application/octet-stream", pr)
+	}
+
+	t.Run("Slow uploads outlast the server timeouts", func(t *testing.T) {
+		resp, err := slowPost("/api/upload")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "chunkchunkchunkchunkchunkchunk", string(body))
+	})
+
+	t.Run("Other slow requests still time out", func(t *testing.T) {
+		resp, err := slowPost("/api/settings")
+		if err == nil {
+			defer resp.Body.Close()
+			assert.NotEqual(t, http.StatusOK, resp.StatusCode)
+		}
+	})
+}
@@ -0,0 +1,137 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metrics exposes operational Prometheus metrics for DDD.
+// Recording is always cheap and safe; the metrics are only served when
+// Handler is mounted, which main does when the -metrics flag is set.
+package metrics
+
+import (
+	"log"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/rsvihladremio/ddd/internal/database"
+)
+
+const namespace = "ddd"
+
+var (
+	uploadsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "uploads_total",
+		Help:      "Number of files uploaded, by detected file type.",
+	}, []string{"file_type"})
+
+	reportsGeneratedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reports_generated_total",
+		Help:      "Number of reports generated successfully, by report type.",
+	}, []string{"report_type"})
+
+	reportsFailedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reports_failed_total",
+		Help:      "Number of reports that failed to generate, by report type.",
+	}, []string{"report_type"})
+
+	reportDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "report_generation_duration_seconds",
+		Help:      "Time taken to generate a report, by report type.",
+		Buckets:   []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300},
+	}, []string{"report_type"})
+)
+
+// RecordUpload counts a newly stored upload
+func RecordUpload(fileType string) {
+	uploadsTotal.WithLabelValues(fileType).Inc()
+}
+
+// RecordReportGenerated counts a successful report and observes how long it took
+func RecordReportGenerated(reportType string, duration time.Duration) {
+	reportsGeneratedTotal.WithLabelValues(reportType).Inc()
+	reportDuration.WithLabelValues(reportType).Observe(duration.Seconds())
+}
+
+// RecordReportFailed counts a report that failed to generate
+func RecordReportFailed(reportType string) {
+	reportsFailedTotal.WithLabelValues(reportType).Inc()
+}
+
+// NewRegistry creates a registry with the DDD metrics, gauges for the pending report
+// queue depth and uploads disk usage, and the standard Go and process collectors
+func NewRegistry(db *database.DB, uploadsDir string) *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		uploadsTotal,
+		reportsGeneratedTotal,
+		reportsFailedTotal,
+		reportDuration,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "pending_reports",
+			Help:      "Number of reports waiting to be generated.",
+		}, func() float64 {
+			count, err := db.CountReportsByStatus("pending")
+			if err != nil {
+				log.Printf("Error counting pending reports for metrics: %v", err)
+				return 0
+			}
+			return float64(count)
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "uploads_disk_usage_percent",
+			Help:      "Used space on the filesystem holding the uploads directory, as a percentage.",
+		}, func() float64 {
+			percent, err := diskUsagePercent(uploadsDir)
+			if err != nil {
+				log.Printf("Error getting uploads disk usage for metrics: %v", err)
+				return 0
+			}
+			return percent
+		}),
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return registry
+}
+
+// Handler returns an HTTP handler serving the metrics in the Prometheus exposition format
+func Handler(db *database.DB, uploadsDir string) http.Handler {
+	return promhttp.HandlerFor(NewRegistry(db, uploadsDir), promhttp.HandlerOpts{})
+}
+
+// diskUsagePercent returns the used percentage of the filesystem containing path
+func diskUsagePercent(path string) (float64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+
+	// Convert Bsize to uint64 - gosec G115 is acceptable here as Bsize represents block size
+	blockSize := uint64(stat.Bsize) // #nosec G115
+	total := stat.Blocks * blockSize
+	if total == 0 {
+		return 0, nil
+	}
+	used := total - stat.Bavail*blockSize
+	return float64(used) / float64(total) * 100, nil
+}
@@ -0,0 +1,89 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rsvihladremio/ddd/internal/database"
+)
+
+func TestRecorders(t *testing.T) {
+	before := testutil.ToFloat64(uploadsTotal.WithLabelValues("iostat"))
+	RecordUpload("iostat")
+	assert.Equal(t, before+1, testutil.ToFloat64(uploadsTotal.WithLabelValues("iostat")))
+
+	before = testutil.ToFloat64(reportsGeneratedTotal.WithLabelValues("ttop"))
+	RecordReportGenerated("ttop", 1500*time.Millisecond)
+	assert.Equal(t, before+1, testutil.ToFloat64(reportsGeneratedTotal.WithLabelValues("ttop")))
+
+	before = testutil.ToFloat64(reportsFailedTotal.WithLabelValues("jfr"))
+	RecordReportFailed("jfr")
+	assert.Equal(t, before+1, testutil.ToFloat64(reportsFailedTotal.WithLabelValues("jfr")))
+}
+
+func TestHandler(t *testing.T) {
+	tempDir := t.TempDir()
+	db, err := database.Initialize(filepath.Join(tempDir, "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("Error closing database: %v", err)
+		}
+	})
+
+	file := &database.File{
+		Hash:         "metrics-hash",
+		OriginalName: "iostat.txt",
+		FileType:     "iostat",
+		FileSize:     10,
+		UploadTime:   time.Now(),
+		FilePath:     filepath.Join(tempDir, "metrics-hash"),
+	}
+	require.NoError(t, db.InsertFile(file))
+	for _, reportType := range []string{"iostat", "vmstat"} {
+		require.NoError(t, db.InsertReport(&database.Report{
+			FileID:      file.ID,
+			ReportType:  reportType,
+			Status:      "pending",
+			CreatedTime: time.Now(),
+			DDDVersion:  "1.0.0",
+		}))
+	}
+
+	RecordUpload("iostat")
+	RecordReportGenerated("iostat", 2*time.Second)
+
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	w := httptest.NewRecorder()
+	Handler(db, tempDir).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, `ddd_uploads_total{file_type="iostat"}`)
+	assert.Contains(t, body, `ddd_reports_generated_total{report_type="iostat"}`)
+	assert.Contains(t, body, `ddd_report_generation_duration_seconds_bucket{report_type="iostat",le="2.5"}`)
+	assert.Contains(t, body, "ddd_pending_reports 2\n")
+	assert.Contains(t, body, "ddd_uploads_disk_usage_percent")
+	assert.Contains(t, body, "go_goroutines")
+}
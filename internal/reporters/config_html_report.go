@@ -0,0 +1,314 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporters
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// configFormatLabels are the names of the configuration formats shown in the report header
+var configFormatLabels = map[string]string{
+	ConfigFormatHOCON: "dremio.conf (HOCON)",
+	ConfigFormatXML:   "Hadoop site XML",
+	ConfigFormatEnv:   "dremio-env",
+}
+
+// GenerateConfigHTML generates an HTML report for a parsed Dremio configuration file.
+// The report lists the risky values that were flagged, the known-interesting settings
+// and every setting in the file, highlighting values that differ from Dremio's defaults.
+func GenerateConfigHTML(data *ConfigReportData) (string, error) {
+	if data == nil || len(data.Settings) == 0 {
+		return generateEmptyConfigHTML(), nil
+	}
+
+	warnings := 0
+	var interesting []ConfigSetting
+	for _, finding := range data.Findings {
+		if finding.Severity == ConfigSeverityWarning {
+			warnings++
+		}
+	}
+	for _, setting := range data.Settings {
+		if setting.Interesting {
+			interesting = append(interesting, setting)
+		}
+	}
+
+	formatLabel, ok := configFormatLabels[data.Format]
+	if !ok {
+		formatLabel = data.Format
+	}
+
+	report := fmt.Sprintf(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Dremio Configuration Report</title>
+    <style>
+        body {
+            font-family: 'Segoe UI', Tahoma, Geneva, Verdana, sans-serif;
+            margin: 0;
+            padding: 20px;
+            background-color: #f5f5f5;
+        }
+        .container {
+            max-width: 1400px;
+            margin: 0 auto;
+            background-color: white;
+            border-radius: 8px;
+            box-shadow: 0 2px 10px rgba(0,0,0,0.1);
+            overflow: hidden;
+        }
+        .header {
+            background: linear-gradient(135deg, #64748b 0%%, #475569 100%%);
+            color: white;
+            padding: 30px;
+            text-align: center;
+        }
+        .header h1 {
+            margin: 0 0 10px 0;
+            font-size: 2.5em;
+            font-weight: 300;
+        }
+        .header p {
+            margin: 0;
+            font-size: 1.1em;
+            opacity: 0.9;
+        }
+        .stats-grid {
+            display: grid;
+            grid-template-columns: repeat(auto-fit, minmax(200px, 1fr));
+            gap: 20px;
+            padding: 30px;
+            background-color: #f8f9fa;
+        }
+        .stat-card {
+            background: white;
+            padding: 20px;
+            border-radius: 8px;
+            text-align: center;
+            box-shadow: 0 2px 4px rgba(0,0,0,0.1);
+        }
+        .stat-value {
+            font-size: 2em;
+            font-weight: bold;
+            color: #475569;
+            margin-bottom: 5px;
+        }
+        .stat-label {
+            color: #666;
+            font-size: 0.9em;
+        }
+        .chart-container {
+            padding: 30px;
+            border-bottom: 1px solid #eee;
+        }
+        .chart-container:last-child {
+            border-bottom: none;
+        }
+        .chart-title {
+            font-size: 1.5em;
+            margin-bottom: 20px;
+            color: #333;
+            text-align: center;
+        }
+        .summary-table {
+            width: 100%%;
+            border-collapse: collapse;
+            font-size: 0.9em;
+        }
+        .summary-table th,
+        .summary-table td {
+            padding: 8px 12px;
+            border-bottom: 1px solid #eee;
+            text-align: left;
+        }
+        .summary-table thead th {
+            background-color: #f8f9fa;
+            color: #333;
+        }
+        .value-cell {
+            font-family: monospace;
+            white-space: pre-wrap;
+            word-break: break-word;
+        }
+        .interesting-row {
+            background-color: #fffbea;
+        }
+        .non-default {
+            color: #b45309;
+            font-weight: bold;
+        }
+        .severity-warning {
+            color: #dc2626;
+            font-weight: bold;
+        }
+        .severity-info {
+            color: #2563eb;
+        }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>Dremio Configuration Report</h1>
+            <p>%s</p>
+        </div>
+
+        <div class="stats-grid">
+            <div class="stat-card">
+                <div class="stat-value">%d</div>
+                <div class="stat-label">Settings</div>
+            </div>
+            <div class="stat-card">
+                <div class="stat-value">%d</div>
+                <div class="stat-label">Non-default</div>
+            </div>
+            <div class="stat-card">
+                <div class="stat-value">%d</div>
+                <div class="stat-label">Warnings</div>
+            </div>
+            <div class="stat-card">
+                <div class="stat-value">%d</div>
+                <div class="stat-label">Findings</div>
+            </div>
+        </div>
+
+        <div class="chart-container">
+            <div class="chart-title">Findings</div>
+            %s
+        </div>
+
+        <div class="chart-container">
+            <div class="chart-title">Key Settings</div>
+            %s
+        </div>
+
+        <div class="chart-container">
+            <div class="chart-title">All Settings</div>
+            %s
+        </div>
+    </div>
+</body>
+</html>`,
+		html.EscapeString(formatLabel),
+		len(data.Settings),
+		data.NonDefaultCount,
+		warnings,
+		len(data.Findings),
+		generateConfigFindingsTableHTML(data.Findings),
+		generateConfigSettingsTableHTML(interesting, "None of the known key settings are set in this file."),
+		generateConfigSettingsTableHTML(data.Settings, "No settings found."))
+
+	return report, nil
+}
+
+// generateEmptyConfigHTML returns HTML for when the file contains no settings
+func generateEmptyConfigHTML() string {
+	return `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Dremio Configuration Report</title>
+</head>
+<body>
+    <h1>Dremio Configuration Report</h1>
+    <p>No settings found in the configuration file.</p>
+</body>
+</html>`
+}
+
+// generateConfigFindingsTableHTML renders the flagged settings as an HTML table
+func generateConfigFindingsTableHTML(findings []ConfigFinding) string {
+	if len(findings) == 0 {
+		return `<p>No risky settings found.</p>`
+	}
+
+	var rows []string
+	for _, finding := range findings {
+		rows = append(rows, fmt.Sprintf(`                <tr>
+                    <td class="severity-%s">%s</td>
+                    <td>%s</td>
+                    <td class="value-cell">%s</td>
+                    <td>%s</td>
+                </tr>`,
+			html.EscapeString(finding.Severity),
+			html.EscapeString(strings.ToUpper(finding.Severity)),
+			html.EscapeString(finding.Key),
+			html.EscapeString(finding.Value),
+			html.EscapeString(finding.Message)))
+	}
+
+	return fmt.Sprintf(`<table class="summary-table">
+            <thead>
+                <tr>
+                    <th>Severity</th>
+                    <th>Setting</th>
+                    <th>Value</th>
+                    <th>Finding</th>
+                </tr>
+            </thead>
+            <tbody>
+%s
+            </tbody>
+        </table>`, strings.Join(rows, "\n"))
+}
+
+// generateConfigSettingsTableHTML renders settings as a key/value HTML table, highlighting
+// the known-interesting keys and the values that differ from the default
+func generateConfigSettingsTableHTML(settings []ConfigSetting, emptyMessage string) string {
+	if len(settings) == 0 {
+		return fmt.Sprintf(`<p>%s</p>`, html.EscapeString(emptyMessage))
+	}
+
+	var rows []string
+	for _, setting := range settings {
+		rowClass := ""
+		if setting.Interesting {
+			rowClass = ` class="interesting-row"`
+		}
+		valueClass := "value-cell"
+		if setting.NonDefault {
+			valueClass += " non-default"
+		}
+		rows = append(rows, fmt.Sprintf(`                <tr%s>
+                    <td>%s</td>
+                    <td class="%s">%s</td>
+                    <td class="value-cell">%s</td>
+                </tr>`,
+			rowClass,
+			html.EscapeString(setting.Key),
+			valueClass,
+			html.EscapeString(setting.Value),
+			html.EscapeString(valueOrNA(setting.Default))))
+	}
+
+	return fmt.Sprintf(`<table class="summary-table">
+            <thead>
+                <tr>
+                    <th>Setting</th>
+                    <th>Value</th>
+                    <th>Default</th>
+                </tr>
+            </thead>
+            <tbody>
+%s
+            </tbody>
+        </table>`, strings.Join(rows, "\n"))
+}
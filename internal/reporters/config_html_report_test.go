@@ -0,0 +1,64 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporters
+
+import (
+	"testing"
+
+	"github.com/rsvihladremio/ddd/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateConfigHTML(t *testing.T) {
+	t.Run("Sample dremio.conf", func(t *testing.T) {
+		data, err := ParseDremioConfig(testutil.SampleFiles["dremio_config"].Content)
+		require.NoError(t, err)
+
+		html, err := GenerateConfigHTML(data)
+		require.NoError(t, err)
+
+		assert.Contains(t, html, "<title>Dremio Configuration Report</title>")
+		assert.Contains(t, html, "dremio.conf (HOCON)")
+		assert.Contains(t, html, `<td class="severity-warning">WARNING</td>`)
+		assert.Contains(t, html, "Spilling is disabled")
+		assert.Contains(t, html, `<tr class="interesting-row">`)
+		assert.Contains(t, html, `<td class="value-cell non-default">zk1:2181,zk2:2181</td>`)
+		assert.Contains(t, html, "${DREMIO_HOME}/data")
+		assert.Contains(t, html, "100%")
+	})
+
+	t.Run("No interesting keys or findings", func(t *testing.T) {
+		data := &ConfigReportData{
+			Format:   ConfigFormatXML,
+			Settings: []ConfigSetting{{Key: "dfs.replication", Value: "<3>"}},
+			Findings: []ConfigFinding{},
+		}
+
+		html, err := GenerateConfigHTML(data)
+		require.NoError(t, err)
+
+		assert.Contains(t, html, "No risky settings found.")
+		assert.Contains(t, html, "None of the known key settings are set in this file.")
+		assert.Contains(t, html, "&lt;3&gt;")
+		assert.Contains(t, html, "<td class=\"value-cell\">N/A</td>")
+	})
+
+	t.Run("No settings", func(t *testing.T) {
+		html, err := GenerateConfigHTML(&ConfigReportData{})
+		require.NoError(t, err)
+		assert.Contains(t, html, "No settings found in the configuration file.")
+	})
+}
@@ -0,0 +1,519 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporters
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Configuration file formats recognised by ParseDremioConfig
+const (
+	ConfigFormatHOCON = "hocon" // dremio.conf
+	ConfigFormatXML   = "xml"   // Hadoop core-site.xml, hdfs-site.xml, hive-site.xml and friends
+	ConfigFormatEnv   = "env"   // dremio-env shell variables
+)
+
+// Severities of a configuration finding
+const (
+	ConfigSeverityWarning = "warning"
+	ConfigSeverityInfo    = "info"
+)
+
+// minRecommendedHeapMB is the smallest coordinator or executor heap that is not flagged
+const minRecommendedHeapMB = 4096
+
+// maskedConfigValue replaces the value of settings that hold credentials
+const maskedConfigValue = "********"
+
+// ConfigSetting is a single key and value read from a configuration file. HOCON keys are
+// flattened to their dotted path, so services { executor { enabled: true } } becomes
+// services.executor.enabled
+type ConfigSetting struct {
+	Key         string `json:"key"`
+	Value       string `json:"value"`
+	Default     string `json:"default,omitempty"` // Dremio's default, when known
+	NonDefault  bool   `json:"non_default"`       // Set when the value differs from a known default
+	Interesting bool   `json:"interesting"`       // Set for keys support engineers usually check first
+	Masked      bool   `json:"masked"`            // Set when the value was hidden because it holds a credential
+}
+
+// ConfigFinding is a setting whose value is risky or worth a second look
+type ConfigFinding struct {
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// ConfigReportData represents the parsed configuration file used to build the report
+type ConfigReportData struct {
+	Format          string          `json:"format"`
+	Settings        []ConfigSetting `json:"settings"`
+	Findings        []ConfigFinding `json:"findings"`
+	NonDefaultCount int             `json:"non_default_count"`
+}
+
+// dremioConfigDefaults are the defaults of commonly changed dremio.conf and dremio-env settings
+var dremioConfigDefaults = map[string]string{
+	"paths.local":                         "/var/lib/dremio",
+	"paths.dist":                          "pdfs://${paths.local}/pdfs",
+	"services.coordinator.enabled":        "true",
+	"services.coordinator.master.enabled": "true",
+	"services.coordinator.master.embedded-zookeeper.enabled": "true",
+	"services.coordinator.web.port":                          "9047",
+	"services.coordinator.web.ssl.enabled":                   "false",
+	"services.coordinator.client-endpoint.port":              "31010",
+	"services.executor.enabled":                              "true",
+	"services.fabric.port":                                   "45678",
+	"services.flight.port":                                   "32010",
+	"zookeeper":                                              "localhost:2181",
+	"debug.enabled":                                          "false",
+}
+
+// interestingConfigKeys are the settings support engineers look at first. Keys ending in
+// "." match every setting beneath that prefix
+var interestingConfigKeys = []string{
+	"paths.local",
+	"paths.dist",
+	"paths.spilling",
+	"paths.results",
+	"paths.accelerator",
+	"paths.uploads",
+	"services.coordinator.enabled",
+	"services.coordinator.master.enabled",
+	"services.coordinator.master.embedded-zookeeper.enabled",
+	"services.coordinator.web.ssl.enabled",
+	"services.executor.enabled",
+	"services.executor.cache.",
+	"services.fabric.memory.reservation",
+	"registration.publish-host",
+	"zookeeper",
+	"debug.",
+	"DREMIO_MAX_HEAP_MEMORY_SIZE_MB",
+	"DREMIO_MAX_DIRECT_MEMORY_SIZE_MB",
+	"DREMIO_MAX_MEMORY_SIZE_MB",
+	"DREMIO_JAVA_SERVER_EXTRA_OPTS",
+	"DREMIO_GC_OPTS",
+	"fs.defaultFS",
+	"fs.s3a.",
+	"fs.azure.",
+	"dfs.client.",
+	"hive.metastore.uris",
+}
+
+// sensitiveConfigKeyParts mark keys whose values hold credentials and are masked in the report
+var sensitiveConfigKeyParts = []string{"password", "secret", "access.key", "accesskey", "token", "credential"}
+
+// envAssignmentRegex matches a dremio-env variable assignment, with or without export
+var envAssignmentRegex = regexp.MustCompile(`^(?:export\s+)?([A-Za-z_][A-Za-z0-9_]*)=(.*)$`)
+
+// xmxRegex extracts a -Xmx heap size from JVM options
+var xmxRegex = regexp.MustCompile(`-Xmx(\d+)([kKmMgG]?)`)
+
+// ParseDremioConfig parses a Dremio configuration file. The format is detected from the
+// content, since uploaded files are stored without their names: Hadoop site files are XML,
+// dremio-env is a list of shell variables and anything else is read as dremio.conf HOCON.
+// Credentials are masked and the settings are checked for risky values
+func ParseDremioConfig(content []byte) (*ConfigReportData, error) {
+	data := &ConfigReportData{
+		Format:   detectConfigFormat(content),
+		Findings: []ConfigFinding{},
+	}
+
+	var settings []ConfigSetting
+	var err error
+	switch data.Format {
+	case ConfigFormatXML:
+		settings, err = parseSiteXML(content)
+	case ConfigFormatEnv:
+		settings = parseDremioEnv(content)
+	default:
+		settings, err = parseHOCON(content)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range settings {
+		setting := &settings[i]
+		if defaultValue, ok := dremioConfigDefaults[setting.Key]; ok {
+			setting.Default = defaultValue
+			setting.NonDefault = !strings.EqualFold(setting.Value, defaultValue)
+			if setting.NonDefault {
+				data.NonDefaultCount++
+			}
+		}
+		setting.Interesting = isInterestingConfigKey(setting.Key)
+		if isSensitiveConfigKey(setting.Key) && setting.Value != "" {
+			setting.Value = maskedConfigValue
+			setting.Masked = true
+		}
+	}
+	data.Settings = settings
+	data.Findings = checkConfigSettings(settings)
+	return data, nil
+}
+
+// detectConfigFormat works out which kind of configuration file content is
+func detectConfigFormat(content []byte) string {
+	trimmed := bytes.TrimSpace(content)
+	if bytes.HasPrefix(trimmed, []byte("<")) {
+		return ConfigFormatXML
+	}
+
+	assignments, other := 0, 0
+	for _, line := range strings.Split(string(trimmed), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if envAssignmentRegex.MatchString(line) {
+			assignments++
+		} else {
+			other++
+		}
+	}
+	if assignments > 0 && assignments >= other {
+		return ConfigFormatEnv
+	}
+	return ConfigFormatHOCON
+}
+
+// siteConfiguration is the <configuration> document of a Hadoop site file
+type siteConfiguration struct {
+	Properties []struct {
+		Name  string `xml:"name"`
+		Value string `xml:"value"`
+	} `xml:"property"`
+}
+
+// parseSiteXML reads the name and value of every property in a Hadoop site file
+func parseSiteXML(content []byte) ([]ConfigSetting, error) {
+	var config siteConfiguration
+	if err := xml.Unmarshal(content, &config); err != nil {
+		return nil, fmt.Errorf("error parsing XML configuration: %w", err)
+	}
+
+	settings := make([]ConfigSetting, 0, len(config.Properties))
+	for _, property := range config.Properties {
+		name := strings.TrimSpace(property.Name)
+		if name == "" {
+			continue
+		}
+		settings = append(settings, ConfigSetting{Key: name, Value: strings.TrimSpace(property.Value)})
+	}
+	return settings, nil
+}
+
+// parseDremioEnv reads the variables set in dremio-env, ignoring comments and other shell lines
+func parseDremioEnv(content []byte) []ConfigSetting {
+	var settings []ConfigSetting
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		match := envAssignmentRegex.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		settings = append(settings, ConfigSetting{Key: match[1], Value: unquoteConfigValue(match[2])})
+	}
+	return settings
+}
+
+// parseHOCON flattens the subset of HOCON used by dremio.conf into dotted keys. It handles
+// nested and inline objects, multi-line arrays, comments and quoted strings; includes are
+// skipped and substitutions such as ${paths.local} are kept as written
+func parseHOCON(content []byte) ([]ConfigSetting, error) {
+	lines := strings.Split(string(content), "\n")
+	var settings []ConfigSetting
+	var path []string
+
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(stripHOCONComment(lines[i]))
+		if line == "" || strings.HasPrefix(line, "include ") {
+			continue
+		}
+
+		// Closing braces, possibly several on one line
+		for strings.HasPrefix(line, "}") {
+			if len(path) == 0 {
+				return nil, fmt.Errorf("unbalanced closing brace on line %d", i+1)
+			}
+			path = path[:len(path)-1]
+			line = strings.TrimSpace(strings.TrimLeft(line[1:], ","))
+		}
+		if line == "" {
+			continue
+		}
+
+		key, value := splitHOCONLine(line)
+		if key == "" {
+			continue
+		}
+		fullKey := strings.Join(append(append([]string{}, path...), key), ".")
+
+		switch {
+		case value == "{":
+			path = append(path, key)
+		case strings.HasPrefix(value, "{"):
+			inline, err := parseHOCON([]byte(splitHOCONInline(strings.TrimSuffix(strings.TrimPrefix(value, "{"), "}"))))
+			if err != nil {
+				return nil, err
+			}
+			for _, setting := range inline {
+				setting.Key = fullKey + "." + setting.Key
+				settings = append(settings, setting)
+			}
+		case strings.HasPrefix(value, "["):
+			// Arrays may span lines, so keep reading until the brackets balance
+			for strings.Count(value, "[") > strings.Count(value, "]") && i+1 < len(lines) {
+				i++
+				next := strings.TrimSpace(stripHOCONComment(lines[i]))
+				if next == "" {
+					continue
+				}
+				if !strings.HasSuffix(value, "[") && !strings.HasPrefix(next, "]") && !strings.HasSuffix(value, ",") {
+					value += ","
+				}
+				value += " " + next
+			}
+			settings = append(settings, ConfigSetting{Key: fullKey, Value: normalizeHOCONArray(value)})
+		default:
+			settings = append(settings, ConfigSetting{Key: fullKey, Value: joinHOCONValue(value)})
+		}
+	}
+
+	if len(path) > 0 {
+		return nil, fmt.Errorf("unclosed object %q", strings.Join(path, "."))
+	}
+	return settings, nil
+}
+
+// splitHOCONLine splits a HOCON line into its key and value. An object opened with
+// "key {" has the value "{"
+func splitHOCONLine(line string) (string, string) {
+	line = strings.TrimSuffix(line, ",")
+	var key, rest string
+	if strings.HasPrefix(line, `"`) {
+		end := strings.Index(line[1:], `"`)
+		if end < 0 {
+			return "", ""
+		}
+		key, rest = line[1:end+1], line[end+2:]
+	} else {
+		idx := strings.IndexAny(line, ":={ \t")
+		if idx < 0 {
+			return line, ""
+		}
+		key, rest = line[:idx], line[idx:]
+	}
+
+	rest = strings.TrimSpace(rest)
+	if strings.HasPrefix(rest, ":") || strings.HasPrefix(rest, "=") {
+		rest = strings.TrimSpace(rest[1:])
+	}
+	return strings.TrimSpace(key), rest
+}
+
+// splitHOCONInline puts each field of an inline object on its own line by replacing the
+// commas between fields with newlines, leaving commas inside strings and arrays alone
+func splitHOCONInline(fields string) string {
+	var b strings.Builder
+	inQuotes, depth := false, 0
+	for _, r := range fields {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+		case inQuotes:
+		case r == '[' || r == '{':
+			depth++
+		case r == ']' || r == '}':
+			depth--
+		case r == ',' && depth == 0:
+			r = '\n'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// stripHOCONComment removes a # or // comment that is not inside a quoted string
+func stripHOCONComment(line string) string {
+	inQuotes := false
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '"':
+			inQuotes = !inQuotes
+		case inQuotes:
+		case line[i] == '#':
+			return line[:i]
+		case line[i] == '/' && i+1 < len(line) && line[i+1] == '/':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// normalizeHOCONArray rewrites an array value on a single line with its elements unquoted
+func normalizeHOCONArray(value string) string {
+	inner := strings.TrimSpace(value)
+	inner = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(inner, "["), "]"))
+	if inner == "" {
+		return "[]"
+	}
+	var elements []string
+	for _, element := range strings.Split(inner, ",") {
+		if element = strings.TrimSpace(element); element != "" {
+			elements = append(elements, joinHOCONValue(element))
+		}
+	}
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+// joinHOCONValue joins a HOCON value concatenated from quoted and unquoted parts, such as
+// "pdfs://"${paths.local}"/pdfs", by dropping the quotes around each part
+func joinHOCONValue(value string) string {
+	var b strings.Builder
+	escaped := false
+	for _, r := range strings.TrimSpace(value) {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '"':
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// unquoteConfigValue removes the quotes around a fully quoted value
+func unquoteConfigValue(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// isInterestingConfigKey checks if key is one of the settings highlighted in the report
+func isInterestingConfigKey(key string) bool {
+	for _, interesting := range interestingConfigKeys {
+		if key == interesting || (strings.HasSuffix(interesting, ".") && strings.HasPrefix(key, interesting)) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSensitiveConfigKey checks if the value of key is a credential
+func isSensitiveConfigKey(key string) bool {
+	lower := strings.ToLower(key)
+	for _, part := range sensitiveConfigKeyParts {
+		if strings.Contains(lower, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkConfigSettings flags risky values, warnings first and then by key
+func checkConfigSettings(settings []ConfigSetting) []ConfigFinding {
+	findings := []ConfigFinding{}
+	values := make(map[string]string, len(settings))
+	for _, setting := range settings {
+		values[setting.Key] = setting.Value
+	}
+	add := func(key, severity, message string) {
+		findings = append(findings, ConfigFinding{Key: key, Value: values[key], Severity: severity, Message: message})
+	}
+
+	if value, ok := values["paths.spilling"]; ok && value == "[]" {
+		add("paths.spilling", ConfigSeverityWarning,
+			"Spilling is disabled; queries that run out of memory will fail instead of spilling to disk")
+	}
+	if strings.HasPrefix(values["paths.dist"], "pdfs://") {
+		add("paths.dist", ConfigSeverityWarning,
+			"Distributed storage uses PDFS, which is not supported for production; use a shared store such as S3, ADLS, HDFS or NAS")
+	}
+	if strings.EqualFold(values["services.coordinator.enabled"], "true") &&
+		strings.EqualFold(values["services.executor.enabled"], "true") {
+		add("services.executor.enabled", ConfigSeverityInfo,
+			"This node is both coordinator and executor; separate the roles for production clusters")
+	}
+	if strings.EqualFold(values["services.coordinator.web.ssl.enabled"], "false") {
+		add("services.coordinator.web.ssl.enabled", ConfigSeverityInfo, "The web UI is served without TLS")
+	}
+	if value, ok := values["DREMIO_MAX_HEAP_MEMORY_SIZE_MB"]; ok {
+		if heap, err := strconv.Atoi(value); err == nil && heap < minRecommendedHeapMB {
+			add("DREMIO_MAX_HEAP_MEMORY_SIZE_MB", ConfigSeverityWarning,
+				fmt.Sprintf("Heap of %d MB is below the recommended minimum of %d MB", heap, minRecommendedHeapMB))
+		}
+	}
+	for _, key := range []string{"DREMIO_JAVA_SERVER_EXTRA_OPTS", "DREMIO_JAVA_EXTRA_OPTS"} {
+		if heap, ok := xmxMegabytes(values[key]); ok && heap < minRecommendedHeapMB {
+			add(key, ConfigSeverityWarning,
+				fmt.Sprintf("-Xmx heap of %d MB is below the recommended minimum of %d MB", heap, minRecommendedHeapMB))
+		}
+	}
+	for _, setting := range settings {
+		if strings.HasPrefix(setting.Key, "debug.") && strings.EqualFold(setting.Value, "true") {
+			add(setting.Key, ConfigSeverityWarning, "Debug settings should not be enabled in production")
+		}
+		if setting.Masked {
+			add(setting.Key, ConfigSeverityInfo, "Credential stored in plain text; consider a credential provider or secret store")
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Severity != findings[j].Severity {
+			return findings[i].Severity == ConfigSeverityWarning
+		}
+		return findings[i].Key < findings[j].Key
+	})
+	return findings
+}
+
+// xmxMegabytes returns the -Xmx heap size in opts in megabytes
+func xmxMegabytes(opts string) (int, bool) {
+	match := xmxRegex.FindStringSubmatch(opts)
+	if match == nil {
+		return 0, false
+	}
+	size, err := strconv.Atoi(match[1])
+	if err != nil {
+		return 0, false
+	}
+	switch strings.ToLower(match[2]) {
+	case "g":
+		return size * 1024, true
+	case "m":
+		return size, true
+	case "k":
+		return size / 1024, true
+	default:
+		return size / (1024 * 1024), true
+	}
+}
@@ -0,0 +1,191 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporters
+
+import (
+	"testing"
+
+	"github.com/rsvihladremio/ddd/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// configSettingValues maps each parsed setting to its value
+func configSettingValues(data *ConfigReportData) map[string]string {
+	values := map[string]string{}
+	for _, setting := range data.Settings {
+		values[setting.Key] = setting.Value
+	}
+	return values
+}
+
+// configFindingKeys lists the keys of the findings in order
+func configFindingKeys(data *ConfigReportData) []string {
+	keys := []string{}
+	for _, finding := range data.Findings {
+		keys = append(keys, finding.Key)
+	}
+	return keys
+}
+
+func TestParseDremioConfig(t *testing.T) {
+	t.Run("Sample dremio.conf", func(t *testing.T) {
+		data, err := ParseDremioConfig(testutil.SampleFiles["dremio_config"].Content)
+		require.NoError(t, err)
+
+		assert.Equal(t, ConfigFormatHOCON, data.Format)
+		assert.Equal(t, map[string]string{
+			"paths.local":                          "${DREMIO_HOME}/data",
+			"paths.dist":                           "pdfs://${paths.local}/pdfs",
+			"paths.spilling":                       "[]",
+			"services.coordinator.enabled":         "true",
+			"services.coordinator.master.enabled":  "true",
+			"services.executor.enabled":            "true",
+			"services.coordinator.web.ssl.enabled": "false",
+			"debug.enabled":                        "true",
+			"zookeeper":                            "zk1:2181,zk2:2181",
+		}, configSettingValues(data))
+
+		// paths.local, debug.enabled and zookeeper differ from the defaults
+		assert.Equal(t, 3, data.NonDefaultCount)
+		assert.Equal(t, []string{"debug.enabled", "paths.dist", "paths.spilling", "services.coordinator.web.ssl.enabled", "services.executor.enabled"}, configFindingKeys(data))
+		assert.Equal(t, ConfigSeverityWarning, data.Findings[0].Severity)
+		assert.Equal(t, ConfigSeverityInfo, data.Findings[4].Severity)
+
+		for _, setting := range data.Settings {
+			assert.True(t, setting.Interesting, setting.Key)
+		}
+	})
+
+	t.Run("Nested objects and multi-line arrays", func(t *testing.T) {
+		content := []byte(`include "other.conf"
+paths {
+  local = "/opt/dremio/data" // data directory
+  spilling: [
+    "/disk1/spill",
+    "/disk2/spill"
+  ]
+}
+services {
+  coordinator {
+    enabled: false
+  }
+  executor.cache.path.db: "/ssd/cache"
+}
+registration.publish-host: "10.0.0.1"
+`)
+		data, err := ParseDremioConfig(content)
+		require.NoError(t, err)
+
+		assert.Equal(t, map[string]string{
+			"paths.local":                     "/opt/dremio/data",
+			"paths.spilling":                  "[/disk1/spill, /disk2/spill]",
+			"services.coordinator.enabled":    "false",
+			"services.executor.cache.path.db": "/ssd/cache",
+			"registration.publish-host":       "10.0.0.1",
+		}, configSettingValues(data))
+		assert.Empty(t, data.Findings)
+	})
+
+	t.Run("Unbalanced braces", func(t *testing.T) {
+		_, err := ParseDremioConfig([]byte("paths {\n  local: /data\n"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unclosed object")
+
+		_, err = ParseDremioConfig([]byte("paths.local: /data\n}\n"))
+		require.Error(t, err)
+	})
+
+	t.Run("Hadoop site XML masks credentials", func(t *testing.T) {
+		content := []byte(`<?xml version="1.0"?>
+<configuration>
+  <property>
+    <name>fs.s3a.access.key</name>
+    <value>AKIAEXAMPLE</value>
+  </property>
+  <property>
+    <name>fs.s3a.secret.key</name>
+    <value>topsecret</value>
+  </property>
+  <property>
+    <name>fs.s3a.connection.maximum</name>
+    <value>1000</value>
+  </property>
+  <property>
+    <name>dfs.replication</name>
+    <value>3</value>
+  </property>
+</configuration>`)
+		data, err := ParseDremioConfig(content)
+		require.NoError(t, err)
+
+		assert.Equal(t, ConfigFormatXML, data.Format)
+		assert.Equal(t, map[string]string{
+			"fs.s3a.access.key":         maskedConfigValue,
+			"fs.s3a.secret.key":         maskedConfigValue,
+			"fs.s3a.connection.maximum": "1000",
+			"dfs.replication":           "3",
+		}, configSettingValues(data))
+		assert.Equal(t, []string{"fs.s3a.access.key", "fs.s3a.secret.key"}, configFindingKeys(data))
+		assert.NotContains(t, data.Findings[0].Value, "AKIAEXAMPLE")
+		assert.False(t, data.Settings[3].Interesting)
+	})
+
+	t.Run("Invalid XML", func(t *testing.T) {
+		_, err := ParseDremioConfig([]byte("<configuration><property>"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "error parsing XML configuration")
+	})
+
+	t.Run("dremio-env with a small heap", func(t *testing.T) {
+		content := []byte(`#!/bin/bash
+# Dremio environment
+DREMIO_MAX_HEAP_MEMORY_SIZE_MB=2048
+export DREMIO_MAX_DIRECT_MEMORY_SIZE_MB=8192
+DREMIO_JAVA_SERVER_EXTRA_OPTS="-Xmx1g -XX:+UseG1GC"
+`)
+		data, err := ParseDremioConfig(content)
+		require.NoError(t, err)
+
+		assert.Equal(t, ConfigFormatEnv, data.Format)
+		assert.Equal(t, map[string]string{
+			"DREMIO_MAX_HEAP_MEMORY_SIZE_MB":   "2048",
+			"DREMIO_MAX_DIRECT_MEMORY_SIZE_MB": "8192",
+			"DREMIO_JAVA_SERVER_EXTRA_OPTS":    "-Xmx1g -XX:+UseG1GC",
+		}, configSettingValues(data))
+		assert.Equal(t, []string{"DREMIO_JAVA_SERVER_EXTRA_OPTS", "DREMIO_MAX_HEAP_MEMORY_SIZE_MB"}, configFindingKeys(data))
+		assert.Contains(t, data.Findings[1].Message, "Heap of 2048 MB is below the recommended minimum")
+	})
+}
+
+func TestXmxMegabytes(t *testing.T) {
+	tests := []struct {
+		opts     string
+		expected int
+		ok       bool
+	}{
+		{"-Xmx8g", 8192, true},
+		{"-XX:+UseG1GC -Xmx2048m", 2048, true},
+		{"-Xmx1048576k", 1024, true},
+		{"-Xmx1073741824", 1024, true},
+		{"-XX:+UseG1GC", 0, false},
+	}
+
+	for _, tt := range tests {
+		heap, ok := xmxMegabytes(tt.opts)
+		assert.Equal(t, tt.ok, ok, tt.opts)
+		assert.Equal(t, tt.expected, heap, tt.opts)
+	}
+}
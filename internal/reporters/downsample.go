@@ -0,0 +1,305 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporters
+
+// downsampleSnapshots splits snapshots into at most maxPoints buckets of adjacent
+// snapshots and merges each bucket into one point with average. Snapshots are returned
+// unchanged when maxPoints is zero or already large enough
+func downsampleSnapshots[T any](snapshots []T, maxPoints int, average func(bucket []T) T) []T {
+	if maxPoints <= 0 || len(snapshots) <= maxPoints {
+		return snapshots
+	}
+
+	starts := downsampleBucketStarts(len(snapshots), maxPoints)
+	downsampled := make([]T, 0, len(starts))
+	for i, start := range starts {
+		end := len(snapshots)
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		}
+		downsampled = append(downsampled, average(snapshots[start:end]))
+	}
+	return downsampled
+}
+
+// downsampleBucketStarts returns the index of the first snapshot in each point
+// downsampleSnapshots produces from count snapshots
+func downsampleBucketStarts(count, maxPoints int) []int {
+	if maxPoints <= 0 || count <= maxPoints {
+		maxPoints = count
+	}
+	starts := make([]int, maxPoints)
+	for i := range starts {
+		starts[i] = i * count / maxPoints
+	}
+	return starts
+}
+
+// downsampleTTopData returns data with its snapshots averaged into at most maxPoints buckets
+func downsampleTTopData(data *TTopReportData, maxPoints int) *TTopReportData {
+	return &TTopReportData{Snapshots: downsampleSnapshots(data.Snapshots, maxPoints, averageTTopSnapshots), CPUCores: data.CPUCores, Processes: data.Processes}
+}
+
+// downsampleIOStatData returns data with its snapshots averaged into at most maxPoints buckets
+func downsampleIOStatData(data *IOStatReportData, maxPoints int) *IOStatReportData {
+	downsampled := *data
+	downsampled.Snapshots = downsampleSnapshots(data.Snapshots, maxPoints, averageIOStatSnapshots)
+	return &downsampled
+}
+
+// averageTTopSnapshots merges a bucket of ttop snapshots into one stamped with the first
+// snapshot's time. Thread counts and memory are averaged over the snapshots reporting them;
+// per-thread CPU and memory are averaged over the whole bucket, so a thread seen only
+// briefly does not look busier than it was
+func averageTTopSnapshots(bucket []TTopSnapshot) TTopSnapshot {
+	merged := TTopSnapshot{Timestamp: bucket[0].Timestamp, Threads: []ThreadInfo{}}
+
+	var counts ThreadCounts
+	var memory SystemMemory
+	countSamples, memorySamples := 0, 0
+	threads := map[int]*ThreadInfo{}
+	var order []int
+
+	for _, snapshot := range bucket {
+		if snapshot.ThreadCounts != nil {
+			counts.Total += snapshot.ThreadCounts.Total
+			counts.Running += snapshot.ThreadCounts.Running
+			counts.Sleeping += snapshot.ThreadCounts.Sleeping
+			counts.Stopped += snapshot.ThreadCounts.Stopped
+			counts.Zombie += snapshot.ThreadCounts.Zombie
+			countSamples++
+		}
+		if snapshot.SystemMemory != nil {
+			memory.MemTotal += snapshot.SystemMemory.MemTotal
+			memory.MemFree += snapshot.SystemMemory.MemFree
+			memory.MemUsed += snapshot.SystemMemory.MemUsed
+			memory.MemBuffCache += snapshot.SystemMemory.MemBuffCache
+			memory.SwapTotal += snapshot.SystemMemory.SwapTotal
+			memory.SwapFree += snapshot.SystemMemory.SwapFree
+			memory.SwapUsed += snapshot.SystemMemory.SwapUsed
+			memory.MemAvail += snapshot.SystemMemory.MemAvail
+			memorySamples++
+		}
+		for _, thread := range snapshot.Threads {
+			total, ok := threads[thread.PID]
+			if !ok {
+				total = &ThreadInfo{PID: thread.PID, User: thread.User, Command: thread.Command}
+				threads[thread.PID] = total
+				order = append(order, thread.PID)
+			}
+			total.CPU += thread.CPU
+			total.MEM += thread.MEM
+			total.VIRT += thread.VIRT
+			total.RES += thread.RES
+			total.SHR += thread.SHR
+		}
+	}
+
+	if countSamples > 0 {
+		merged.ThreadCounts = &ThreadCounts{
+			Total:    roundedAverage(counts.Total, countSamples),
+			Running:  roundedAverage(counts.Running, countSamples),
+			Sleeping: roundedAverage(counts.Sleeping, countSamples),
+			Stopped:  roundedAverage(counts.Stopped, countSamples),
+			Zombie:   roundedAverage(counts.Zombie, countSamples),
+		}
+	}
+	if memorySamples > 0 {
+		n := float64(memorySamples)
+		merged.SystemMemory = &SystemMemory{
+			MemTotal:     memory.MemTotal / n,
+			MemFree:      memory.MemFree / n,
+			MemUsed:      memory.MemUsed / n,
+			MemBuffCache: memory.MemBuffCache / n,
+			SwapTotal:    memory.SwapTotal / n,
+			SwapFree:     memory.SwapFree / n,
+			SwapUsed:     memory.SwapUsed / n,
+			MemAvail:     memory.MemAvail / n,
+		}
+	}
+	for _, pid := range order {
+		thread := *threads[pid]
+		thread.CPU /= float64(len(bucket))
+		thread.MEM /= float64(len(bucket))
+		thread.VIRT /= float64(len(bucket))
+		thread.RES /= float64(len(bucket))
+		thread.SHR /= float64(len(bucket))
+		merged.Threads = append(merged.Threads, thread)
+	}
+	return merged
+}
+
+// averageIOStatSnapshots merges a bucket of iostat snapshots into one stamped with the
+// first snapshot's time. CPU and each device are averaged over the snapshots reporting them
+func averageIOStatSnapshots(bucket []IOStatSnapshot) IOStatSnapshot {
+	merged := IOStatSnapshot{Timestamp: bucket[0].Timestamp, Devices: []DeviceStats{}}
+
+	var cpu CPUStats
+	cpuSamples := 0
+	devices := map[string]*DeviceStats{}
+	deviceSamples := map[string]int{}
+	var order []string
+
+	for _, snapshot := range bucket {
+		if snapshot.CPUStats != nil {
+			cpu.User += snapshot.CPUStats.User
+			cpu.Nice += snapshot.CPUStats.Nice
+			cpu.System += snapshot.CPUStats.System
+			cpu.IOWait += snapshot.CPUStats.IOWait
+			cpu.Steal += snapshot.CPUStats.Steal
+			cpu.Idle += snapshot.CPUStats.Idle
+			cpuSamples++
+		}
+		for _, device := range snapshot.Devices {
+			total, ok := devices[device.Device]
+			if !ok {
+				total = &DeviceStats{Device: device.Device}
+				devices[device.Device] = total
+				order = append(order, device.Device)
+			}
+			addDeviceStats(total, device)
+			deviceSamples[device.Device]++
+		}
+	}
+
+	if cpuSamples > 0 {
+		n := float64(cpuSamples)
+		merged.CPUStats = &CPUStats{
+			User:   cpu.User / n,
+			Nice:   cpu.Nice / n,
+			System: cpu.System / n,
+			IOWait: cpu.IOWait / n,
+			Steal:  cpu.Steal / n,
+			Idle:   cpu.Idle / n,
+		}
+	}
+	for _, name := range order {
+		device := *devices[name]
+		scaleDeviceStats(&device, 1/float64(deviceSamples[name]))
+		merged.Devices = append(merged.Devices, device)
+	}
+	return merged
+}
+
+// addDeviceStats adds every statistic of stats to total
+func addDeviceStats(total *DeviceStats, stats DeviceStats) {
+	total.ReadsPerS += stats.ReadsPerS
+	total.ReadKBPerS += stats.ReadKBPerS
+	total.ReadReqMergedPerS += stats.ReadReqMergedPerS
+	total.ReadReqMergedPct += stats.ReadReqMergedPct
+	total.ReadAwait += stats.ReadAwait
+	total.ReadReqSize += stats.ReadReqSize
+	total.WritesPerS += stats.WritesPerS
+	total.WriteKBPerS += stats.WriteKBPerS
+	total.WriteReqMergedPerS += stats.WriteReqMergedPerS
+	total.WriteReqMergedPct += stats.WriteReqMergedPct
+	total.WriteAwait += stats.WriteAwait
+	total.WriteReqSize += stats.WriteReqSize
+	total.DiscardsPerS += stats.DiscardsPerS
+	total.DiscardKBPerS += stats.DiscardKBPerS
+	total.DiscardReqMergedPerS += stats.DiscardReqMergedPerS
+	total.DiscardReqMergedPct += stats.DiscardReqMergedPct
+	total.DiscardAwait += stats.DiscardAwait
+	total.DiscardReqSize += stats.DiscardReqSize
+	total.FlushesPerS += stats.FlushesPerS
+	total.FlushAwait += stats.FlushAwait
+	total.AvgQueueSize += stats.AvgQueueSize
+	total.Utilization += stats.Utilization
+}
+
+// scaleDeviceStats multiplies every statistic of stats by factor
+func scaleDeviceStats(stats *DeviceStats, factor float64) {
+	stats.ReadsPerS *= factor
+	stats.ReadKBPerS *= factor
+	stats.ReadReqMergedPerS *= factor
+	stats.ReadReqMergedPct *= factor
+	stats.ReadAwait *= factor
+	stats.ReadReqSize *= factor
+	stats.WritesPerS *= factor
+	stats.WriteKBPerS *= factor
+	stats.WriteReqMergedPerS *= factor
+	stats.WriteReqMergedPct *= factor
+	stats.WriteAwait *= factor
+	stats.WriteReqSize *= factor
+	stats.DiscardsPerS *= factor
+	stats.DiscardKBPerS *= factor
+	stats.DiscardReqMergedPerS *= factor
+	stats.DiscardReqMergedPct *= factor
+	stats.DiscardAwait *= factor
+	stats.DiscardReqSize *= factor
+	stats.FlushesPerS *= factor
+	stats.FlushAwait *= factor
+	stats.AvgQueueSize *= factor
+	stats.Utilization *= factor
+}
+
+// downsampleVMStatData returns data with its snapshots averaged into at most maxPoints buckets
+func downsampleVMStatData(data *VMStatReportData, maxPoints int) *VMStatReportData {
+	return &VMStatReportData{Snapshots: downsampleSnapshots(data.Snapshots, maxPoints, averageVMStatSnapshots), Timestamped: data.Timestamped}
+}
+
+// averageVMStatSnapshots merges a bucket of vmstat snapshots into one stamped with the
+// first snapshot's time, with every statistic averaged over the bucket
+func averageVMStatSnapshots(bucket []VMStatSnapshot) VMStatSnapshot {
+	var total VMStatSnapshot
+	for _, snapshot := range bucket {
+		total.Runnable += snapshot.Runnable
+		total.Blocked += snapshot.Blocked
+		total.SwapUsed += snapshot.SwapUsed
+		total.Free += snapshot.Free
+		total.Buffers += snapshot.Buffers
+		total.Cache += snapshot.Cache
+		total.SwapInPerS += snapshot.SwapInPerS
+		total.SwapOutPerS += snapshot.SwapOutPerS
+		total.BlocksInPerS += snapshot.BlocksInPerS
+		total.BlocksOutPerS += snapshot.BlocksOutPerS
+		total.InterruptsPerS += snapshot.InterruptsPerS
+		total.ContextSwitchesPerS += snapshot.ContextSwitchesPerS
+		total.User += snapshot.User
+		total.System += snapshot.System
+		total.Idle += snapshot.Idle
+		total.IOWait += snapshot.IOWait
+		total.Steal += snapshot.Steal
+		total.Guest += snapshot.Guest
+	}
+
+	n := float64(len(bucket))
+	return VMStatSnapshot{
+		Timestamp:           bucket[0].Timestamp,
+		Runnable:            total.Runnable / n,
+		Blocked:             total.Blocked / n,
+		SwapUsed:            total.SwapUsed / n,
+		Free:                total.Free / n,
+		Buffers:             total.Buffers / n,
+		Cache:               total.Cache / n,
+		SwapInPerS:          total.SwapInPerS / n,
+		SwapOutPerS:         total.SwapOutPerS / n,
+		BlocksInPerS:        total.BlocksInPerS / n,
+		BlocksOutPerS:       total.BlocksOutPerS / n,
+		InterruptsPerS:      total.InterruptsPerS / n,
+		ContextSwitchesPerS: total.ContextSwitchesPerS / n,
+		User:                total.User / n,
+		System:              total.System / n,
+		Idle:                total.Idle / n,
+		IOWait:              total.IOWait / n,
+		Steal:               total.Steal / n,
+		Guest:               total.Guest / n,
+	}
+}
+
+// roundedAverage returns total divided by samples rounded to the nearest whole number
+func roundedAverage(total, samples int) int {
+	return (total + samples/2) / samples
+}
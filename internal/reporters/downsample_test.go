@@ -0,0 +1,182 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporters
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownsampleSnapshots(t *testing.T) {
+	snapshots := make([]int, 10)
+	for i := range snapshots {
+		snapshots[i] = i
+	}
+	sum := func(bucket []int) int {
+		total := 0
+		for _, value := range bucket {
+			total += value
+		}
+		return total
+	}
+
+	t.Run("Merges adjacent snapshots into buckets", func(t *testing.T) {
+		// Buckets are [0 1] [2 3 4] [5 6] [7 8 9]
+		assert.Equal(t, []int{1, 9, 11, 24}, downsampleSnapshots(snapshots, 4, sum))
+		assert.Equal(t, []int{10, 35}, downsampleSnapshots(snapshots, 2, sum))
+		assert.Equal(t, []int{45}, downsampleSnapshots(snapshots, 1, sum))
+	})
+
+	t.Run("Unchanged when under the limit or unlimited", func(t *testing.T) {
+		assert.Equal(t, snapshots, downsampleSnapshots(snapshots, 10, sum))
+		assert.Equal(t, snapshots, downsampleSnapshots(snapshots, 0, sum))
+	})
+
+	t.Run("Bucket starts match the merged buckets", func(t *testing.T) {
+		assert.Equal(t, []int{0, 2, 5, 7}, downsampleBucketStarts(10, 4))
+		assert.Equal(t, []int{0, 1, 2}, downsampleBucketStarts(3, 10))
+		assert.Equal(t, []int{0, 1, 2}, downsampleBucketStarts(3, 0))
+		assert.Empty(t, downsampleBucketStarts(0, 4))
+	})
+}
+
+func TestAverageTTopSnapshots(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	bucket := []TTopSnapshot{
+		{
+			Timestamp:    start,
+			ThreadCounts: &ThreadCounts{Total: 100, Running: 3},
+			SystemMemory: &SystemMemory{MemTotal: 1000, MemUsed: 400},
+			Threads:      []ThreadInfo{{PID: 1, User: "dremio", CPU: 80, MEM: 10, Command: "worker"}},
+		},
+		{
+			Timestamp:    start.Add(time.Second),
+			ThreadCounts: &ThreadCounts{Total: 103, Running: 4},
+			SystemMemory: &SystemMemory{MemTotal: 1000, MemUsed: 600},
+			Threads: []ThreadInfo{
+				{PID: 1, User: "dremio", CPU: 40, MEM: 10, Command: "worker"},
+				{PID: 2, User: "dremio", CPU: 50, MEM: 2, Command: "gc"},
+			},
+		},
+	}
+
+	merged := averageTTopSnapshots(bucket)
+
+	assert.Equal(t, start, merged.Timestamp)
+	require.NotNil(t, merged.ThreadCounts)
+	assert.Equal(t, 102, merged.ThreadCounts.Total)
+	assert.Equal(t, 4, merged.ThreadCounts.Running)
+	require.NotNil(t, merged.SystemMemory)
+	assert.Equal(t, 1000.0, merged.SystemMemory.MemTotal)
+	assert.Equal(t, 500.0, merged.SystemMemory.MemUsed)
+	// Threads missing from a snapshot count as idle for it
+	assert.Equal(t, []ThreadInfo{
+		{PID: 1, User: "dremio", CPU: 60, MEM: 10, Command: "worker"},
+		{PID: 2, User: "dremio", CPU: 25, MEM: 1, Command: "gc"},
+	}, merged.Threads)
+}
+
+func TestAverageIOStatSnapshots(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	bucket := []IOStatSnapshot{
+		{
+			Timestamp: start,
+			CPUStats:  &CPUStats{User: 10, IOWait: 4, Idle: 86},
+			Devices: []DeviceStats{
+				{Device: "sda", ReadsPerS: 100, WriteAwait: 2, Utilization: 20},
+				{Device: "sdb", Utilization: 90},
+			},
+		},
+		{
+			Timestamp: start.Add(time.Second),
+			Devices:   []DeviceStats{{Device: "sda", ReadsPerS: 300, WriteAwait: 6, Utilization: 40}},
+		},
+	}
+
+	merged := averageIOStatSnapshots(bucket)
+
+	assert.Equal(t, start, merged.Timestamp)
+	require.NotNil(t, merged.CPUStats)
+	assert.Equal(t, CPUStats{User: 10, IOWait: 4, Idle: 86}, *merged.CPUStats)
+	assert.Equal(t, []DeviceStats{
+		{Device: "sda", ReadsPerS: 200, WriteAwait: 4, Utilization: 30},
+		{Device: "sdb", Utilization: 90},
+	}, merged.Devices)
+}
+
+func TestAverageVMStatSnapshots(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	bucket := []VMStatSnapshot{
+		{Timestamp: start, Runnable: 2, ContextSwitchesPerS: 1000, SwapOutPerS: 8, User: 20, Idle: 80},
+		{Timestamp: start.Add(time.Second), Runnable: 5, ContextSwitchesPerS: 3000, User: 60, Idle: 40},
+	}
+
+	assert.Equal(t, VMStatSnapshot{
+		Timestamp:           start,
+		Runnable:            3.5,
+		ContextSwitchesPerS: 2000,
+		SwapOutPerS:         4,
+		User:                40,
+		Idle:                60,
+	}, averageVMStatSnapshots(bucket))
+}
+
+func TestGenerateHTMLWithMaxPoints(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("TTop charts average adjacent snapshots but cards use every snapshot", func(t *testing.T) {
+		data := &TTopReportData{}
+		for i := 0; i < 10; i++ {
+			data.Snapshots = append(data.Snapshots, TTopSnapshot{
+				Timestamp:    start.Add(time.Duration(i) * time.Second),
+				ThreadCounts: &ThreadCounts{Total: 100 + i},
+				Threads:      []ThreadInfo{{PID: i, User: "dremio", Command: "worker"}},
+			})
+		}
+
+		html, err := GenerateTTopHTMLWithMaxPoints(data, 2)
+		require.NoError(t, err)
+
+		assert.Contains(t, html, `["12:00:00", "12:00:05"]`)
+		assert.NotContains(t, html, `"12:00:09"`)
+		// Unique threads are counted across all ten snapshots
+		assert.Contains(t, html, `<div class="stat-value">10</div>`)
+	})
+
+	t.Run("IOStat charts average adjacent snapshots", func(t *testing.T) {
+		data := &IOStatReportData{}
+		for i := 0; i < 10; i++ {
+			data.Snapshots = append(data.Snapshots, IOStatSnapshot{
+				Timestamp: start.Add(time.Duration(i) * time.Second),
+				CPUStats:  &CPUStats{Idle: 90},
+				Devices:   []DeviceStats{{Device: "sda", ReadsPerS: float64(i)}},
+			})
+		}
+
+		html, err := GenerateIOStatHTMLWithMaxPoints(data, DefaultIOStatThresholds(), 2)
+		require.NoError(t, err)
+
+		assert.NotContains(t, html, `"12:00:09"`)
+		assert.Contains(t, html, `<div class="stat-value">10</div>`)
+
+		charts := GenerateIOStatChartData(data, DefaultIOStatThresholds(), 2)
+		assert.JSONEq(t, `["12:00:00", "12:00:05"]`, string(charts["device_requests"].Labels))
+		// Reads/sec 0-4 and 5-9 average to 2 and 7
+		assert.Contains(t, string(charts["device_requests"].Series), `"data": [2.00, 7.00]`)
+	})
+}
@@ -0,0 +1,404 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporters
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"strings"
+)
+
+// GenerateDremioProfileHTML generates an HTML report for a parsed Dremio query profile.
+// The report shows a summary, planning phase timings, operator timings, non-default
+// support options, the query text and any error output.
+func GenerateDremioProfileHTML(data *DremioProfileReportData) (string, error) {
+	if data == nil {
+		return generateEmptyDremioProfileHTML(), nil
+	}
+
+	phaseNames := []string{}
+	phaseDurations := []int64{}
+	for _, phase := range data.PlanPhases {
+		phaseNames = append(phaseNames, phase.PhaseName)
+		phaseDurations = append(phaseDurations, phase.DurationMillis)
+	}
+	phaseNamesJSON, err := json.Marshal(phaseNames)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal phase names: %w", err)
+	}
+	phaseDurationsJSON, err := json.Marshal(phaseDurations)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal phase durations: %w", err)
+	}
+
+	report := fmt.Sprintf(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Dremio Profile Report</title>
+    <script src="/static/js/echarts.min.js"></script>
+    <style>
+        body {
+            font-family: 'Segoe UI', Tahoma, Geneva, Verdana, sans-serif;
+            margin: 0;
+            padding: 20px;
+            background-color: #f5f5f5;
+        }
+        .container {
+            max-width: 1400px;
+            margin: 0 auto;
+            background-color: white;
+            border-radius: 8px;
+            box-shadow: 0 2px 10px rgba(0,0,0,0.1);
+            overflow: hidden;
+        }
+        .header {
+            background: linear-gradient(135deg, #06b6d4 0%%, #0891b2 100%%);
+            color: white;
+            padding: 30px;
+            text-align: center;
+        }
+        .header h1 {
+            margin: 0 0 10px 0;
+            font-size: 2.5em;
+            font-weight: 300;
+        }
+        .header p {
+            margin: 0;
+            font-size: 1.1em;
+            opacity: 0.9;
+        }
+        .stats-grid {
+            display: grid;
+            grid-template-columns: repeat(auto-fit, minmax(200px, 1fr));
+            gap: 20px;
+            padding: 30px;
+            background-color: #f8f9fa;
+        }
+        .stat-card {
+            background: white;
+            padding: 20px;
+            border-radius: 8px;
+            text-align: center;
+            box-shadow: 0 2px 4px rgba(0,0,0,0.1);
+        }
+        .stat-value {
+            font-size: 2em;
+            font-weight: bold;
+            color: #06b6d4;
+            margin-bottom: 5px;
+        }
+        .stat-label {
+            color: #666;
+            font-size: 0.9em;
+        }
+        .chart-container {
+            padding: 30px;
+            border-bottom: 1px solid #eee;
+        }
+        .chart-container:last-child {
+            border-bottom: none;
+        }
+        .chart-title {
+            font-size: 1.5em;
+            margin-bottom: 20px;
+            color: #333;
+            text-align: center;
+        }
+        .chart {
+            width: 100%%;
+            height: 400px;
+        }
+        .summary-table {
+            width: 100%%;
+            border-collapse: collapse;
+            font-size: 0.9em;
+        }
+        .summary-table th,
+        .summary-table td {
+            padding: 8px 12px;
+            border-bottom: 1px solid #eee;
+            text-align: right;
+        }
+        .summary-table th:first-child,
+        .summary-table td:first-child,
+        .summary-table .text-cell {
+            text-align: left;
+        }
+        .summary-table thead th {
+            background-color: #f8f9fa;
+            color: #333;
+        }
+        .query-text,
+        .error-text {
+            white-space: pre-wrap;
+            word-break: break-word;
+            background-color: #f8f9fa;
+            padding: 15px;
+            border-radius: 4px;
+            font-size: 0.9em;
+        }
+        .error-text {
+            background-color: #ffe6e6;
+            color: #b91c1c;
+        }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>Dremio Profile Report</h1>
+            <p>Query %s</p>
+        </div>
+
+        <div class="stats-grid">
+            <div class="stat-card">
+                <div class="stat-value">%s</div>
+                <div class="stat-label">State</div>
+            </div>
+            <div class="stat-card">
+                <div class="stat-value">%s</div>
+                <div class="stat-label">User</div>
+            </div>
+            <div class="stat-card">
+                <div class="stat-value">%d ms</div>
+                <div class="stat-label">Total Duration</div>
+            </div>
+            <div class="stat-card">
+                <div class="stat-value">%d ms</div>
+                <div class="stat-label">Planning Duration</div>
+            </div>
+            <div class="stat-card">
+                <div class="stat-value">%s</div>
+                <div class="stat-label">Dremio Version</div>
+            </div>
+        </div>
+%s
+        <div class="chart-container">
+            <div class="chart-title">Query</div>
+            <div class="query-text">%s</div>
+        </div>
+
+        <div class="chart-container">
+            <div class="chart-title">Planning Phase Timing</div>
+            <div id="phaseChart" class="chart"></div>
+            %s
+        </div>
+
+        <div class="chart-container">
+            <div class="chart-title">Operators</div>
+            %s
+        </div>
+
+        <div class="chart-container">
+            <div class="chart-title">Non-Default Support Options</div>
+            %s
+        </div>
+    </div>
+
+    <script>
+        try {
+            const phaseChart = echarts.init(document.getElementById('phaseChart'));
+            phaseChart.setOption({
+                tooltip: { trigger: 'axis', axisPointer: { type: 'shadow' } },
+                grid: { left: '3%%', right: '4%%', bottom: '3%%', containLabel: true },
+                xAxis: { type: 'value', name: 'ms' },
+                yAxis: { type: 'category', inverse: true, data: %s },
+                series: [
+                    { name: 'Duration (ms)', type: 'bar', data: %s }
+                ]
+            });
+
+            // Handle window resize
+            window.addEventListener('resize', function() {
+                phaseChart.resize();
+            });
+
+        } catch (error) {
+            console.error('Error initializing charts:', error);
+            document.body.innerHTML += '<div style="color: red; padding: 20px; background: #ffe6e6; border: 1px solid red; margin: 20px;">Error initializing charts: ' + error.message + '</div>';
+        }
+    </script>
+</body>
+</html>`,
+		html.EscapeString(data.QueryID),
+		html.EscapeString(data.State),
+		html.EscapeString(valueOrNA(data.User)),
+		data.TotalMillis,
+		data.PlanningMillis,
+		html.EscapeString(valueOrNA(data.DremioVersion)),
+		generateProfileErrorHTML(data),
+		html.EscapeString(data.Query),
+		generatePlanPhaseTableHTML(data.PlanPhases),
+		generateOperatorTableHTML(data.Operators),
+		generateOptionsTableHTML(data.NonDefaultOptions),
+		phaseNamesJSON,
+		phaseDurationsJSON)
+
+	return report, nil
+}
+
+// generateEmptyDremioProfileHTML returns HTML for when no profile data is available
+func generateEmptyDremioProfileHTML() string {
+	return `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Dremio Profile Report</title>
+</head>
+<body>
+    <h1>Dremio Profile Report</h1>
+    <p>No data available for analysis.</p>
+</body>
+</html>`
+}
+
+// valueOrNA returns "N/A" for empty values shown in the summary cards
+func valueOrNA(value string) string {
+	if value == "" {
+		return "N/A"
+	}
+	return value
+}
+
+// generateProfileErrorHTML renders the error section, or nothing when the query did not fail
+func generateProfileErrorHTML(data *DremioProfileReportData) string {
+	if data.Error == "" && data.VerboseError == "" {
+		return ""
+	}
+
+	errorText := data.Error
+	if data.VerboseError != "" {
+		errorText = data.VerboseError
+	}
+	return fmt.Sprintf(`
+        <div class="chart-container">
+            <div class="chart-title">Error</div>
+            <div class="error-text">%s</div>
+        </div>
+`, html.EscapeString(errorText))
+}
+
+// generatePlanPhaseTableHTML renders the planning phases as an HTML table
+func generatePlanPhaseTableHTML(phases []DremioPlanPhase) string {
+	if len(phases) == 0 {
+		return `<p>No planning phases recorded.</p>`
+	}
+
+	var rows []string
+	for _, phase := range phases {
+		rows = append(rows, fmt.Sprintf(`                <tr>
+                    <td>%s</td>
+                    <td>%d</td>
+                </tr>`, html.EscapeString(phase.PhaseName), phase.DurationMillis))
+	}
+
+	return fmt.Sprintf(`<table class="summary-table">
+            <thead>
+                <tr>
+                    <th>Phase</th>
+                    <th>Duration (ms)</th>
+                </tr>
+            </thead>
+            <tbody>
+%s
+            </tbody>
+        </table>`, strings.Join(rows, "\n"))
+}
+
+// generateOperatorTableHTML renders the aggregated operator timings as an HTML table
+func generateOperatorTableHTML(operators []DremioOperatorSummary) string {
+	if len(operators) == 0 {
+		return `<p>No operator statistics recorded.</p>`
+	}
+
+	var rows []string
+	for _, op := range operators {
+		rows = append(rows, fmt.Sprintf(`                <tr>
+                    <td>%s</td>
+                    <td class="text-cell">%s</td>
+                    <td>%d</td>
+                    <td>%.3f</td>
+                    <td>%.3f</td>
+                    <td>%.3f</td>
+                    <td>%.2f</td>
+                    <td>%d</td>
+                </tr>`,
+			html.EscapeString(op.ID),
+			html.EscapeString(op.Name),
+			op.Threads,
+			float64(op.SetupNanos)/1e9,
+			float64(op.ProcessNanos)/1e9,
+			float64(op.WaitNanos)/1e9,
+			float64(op.MaxPeakMemory)/(1024*1024),
+			op.RecordsReceived))
+	}
+
+	return fmt.Sprintf(`<table class="summary-table">
+            <thead>
+                <tr>
+                    <th>Operator</th>
+                    <th class="text-cell">Name</th>
+                    <th>Threads</th>
+                    <th>Setup (s)</th>
+                    <th>Process (s)</th>
+                    <th>Wait (s)</th>
+                    <th>Max Peak Memory (MiB)</th>
+                    <th>Records</th>
+                </tr>
+            </thead>
+            <tbody>
+%s
+            </tbody>
+        </table>`, strings.Join(rows, "\n"))
+}
+
+// generateOptionsTableHTML renders the non-default support options as an HTML table
+func generateOptionsTableHTML(options []DremioOption) string {
+	if len(options) == 0 {
+		return `<p>All support options are at their default values.</p>`
+	}
+
+	var rows []string
+	for _, option := range options {
+		rows = append(rows, fmt.Sprintf(`                <tr>
+                    <td>%s</td>
+                    <td class="text-cell">%s</td>
+                    <td class="text-cell">%s</td>
+                    <td class="text-cell">%s</td>
+                </tr>`,
+			html.EscapeString(option.Name),
+			html.EscapeString(option.Type),
+			html.EscapeString(option.Kind),
+			html.EscapeString(option.Value)))
+	}
+
+	return fmt.Sprintf(`<table class="summary-table">
+            <thead>
+                <tr>
+                    <th>Option</th>
+                    <th class="text-cell">Type</th>
+                    <th class="text-cell">Kind</th>
+                    <th class="text-cell">Value</th>
+                </tr>
+            </thead>
+            <tbody>
+%s
+            </tbody>
+        </table>`, strings.Join(rows, "\n"))
+}
@@ -0,0 +1,67 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporters
+
+import (
+	"testing"
+
+	"github.com/rsvihladremio/ddd/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateDremioProfileHTML(t *testing.T) {
+	t.Run("Completed profile", func(t *testing.T) {
+		data, err := ParseDremioProfile(testutil.SampleFiles["dremio_profile"].Content)
+		require.NoError(t, err)
+
+		html, err := GenerateDremioProfileHTML(data)
+		require.NoError(t, err)
+
+		assert.Contains(t, html, "<title>Dremio Profile Report</title>")
+		assert.Contains(t, html, "COMPLETED")
+		assert.Contains(t, html, "2500 ms")
+		assert.Contains(t, html, "SELECT * FROM sales WHERE region = &#39;EMEA&#39;")
+		assert.Contains(t, html, "Planning Phase Timing")
+		assert.Contains(t, html, `["Validation","Logical Planning","Physical Planning"]`)
+		assert.Contains(t, html, "<td>00-01</td>")
+		assert.Contains(t, html, "planner.slice_target")
+		assert.NotContains(t, html, `class="error-text"`)
+	})
+
+	t.Run("Failed profile shows the error", func(t *testing.T) {
+		data := &DremioProfileReportData{
+			Query:        "SELECT <x>",
+			State:        "FAILED",
+			Error:        "Column x not found",
+			VerboseError: "VALIDATION ERROR: Column <x> not found",
+		}
+
+		html, err := GenerateDremioProfileHTML(data)
+		require.NoError(t, err)
+
+		assert.Contains(t, html, `<div class="error-text">VALIDATION ERROR: Column &lt;x&gt; not found</div>`)
+		assert.Contains(t, html, "SELECT &lt;x&gt;")
+		assert.Contains(t, html, "No planning phases recorded.")
+		assert.Contains(t, html, "No operator statistics recorded.")
+		assert.Contains(t, html, "All support options are at their default values.")
+	})
+
+	t.Run("Nil data", func(t *testing.T) {
+		html, err := GenerateDremioProfileHTML(nil)
+		require.NoError(t, err)
+		assert.Contains(t, html, "No data available for analysis.")
+	})
+}
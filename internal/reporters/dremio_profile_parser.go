@@ -0,0 +1,358 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporters
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DremioQueryID is the two-part identifier Dremio assigns to every query
+type DremioQueryID struct {
+	Part1 int64 `json:"part1"`
+	Part2 int64 `json:"part2"`
+}
+
+// String formats the query id the same way the Dremio UI displays it
+func (id DremioQueryID) String() string {
+	return fmt.Sprintf("%08x-%08x-%08x-%08x",
+		uint32(uint64(id.Part1)>>32), uint32(id.Part1),
+		uint32(uint64(id.Part2)>>32), uint32(id.Part2))
+}
+
+// DremioPlanPhase is a single planning phase with its duration
+type DremioPlanPhase struct {
+	PhaseName      string `json:"phaseName"`      // Name of the planning phase
+	DurationMillis int64  `json:"durationMillis"` // Time spent in the phase in milliseconds
+}
+
+// DremioStreamProfile holds the input statistics of an operator
+type DremioStreamProfile struct {
+	Records int64 `json:"records"` // Records received on this input
+	Batches int64 `json:"batches"` // Batches received on this input
+}
+
+// DremioOperatorProfile holds the timings of one operator in one minor fragment
+type DremioOperatorProfile struct {
+	OperatorID               int                   `json:"operatorId"`               // Operator id within the major fragment
+	OperatorType             int                   `json:"operatorType"`             // Core operator type enum value
+	SetupNanos               int64                 `json:"setupNanos"`               // Time spent in setup
+	ProcessNanos             int64                 `json:"processNanos"`             // Time spent processing
+	WaitNanos                int64                 `json:"waitNanos"`                // Time spent waiting
+	PeakLocalMemoryAllocated int64                 `json:"peakLocalMemoryAllocated"` // Peak memory in bytes
+	InputProfile             []DremioStreamProfile `json:"inputProfile"`             // Per-input statistics
+}
+
+// DremioMinorFragmentProfile is a single thread executing part of a major fragment
+type DremioMinorFragmentProfile struct {
+	MinorFragmentID int                     `json:"minorFragmentId"`
+	StartTime       int64                   `json:"startTime"`
+	EndTime         int64                   `json:"endTime"`
+	OperatorProfile []DremioOperatorProfile `json:"operatorProfile"`
+}
+
+// DremioMajorFragmentProfile groups the minor fragments of a major fragment
+type DremioMajorFragmentProfile struct {
+	MajorFragmentID      int                          `json:"majorFragmentId"`
+	MinorFragmentProfile []DremioMinorFragmentProfile `json:"minorFragmentProfile"`
+}
+
+// DremioProfile represents the parts of an exported Dremio query profile used by the report
+type DremioProfile struct {
+	ID                    DremioQueryID                `json:"id"`
+	Query                 string                       `json:"query"`
+	User                  string                       `json:"user"`
+	State                 json.RawMessage              `json:"state"`
+	Start                 int64                        `json:"start"`
+	End                   int64                        `json:"end"`
+	PlanningStart         int64                        `json:"planningStart"`
+	PlanningEnd           int64                        `json:"planningEnd"`
+	Error                 string                       `json:"error"`
+	VerboseError          string                       `json:"verboseError"`
+	JSONPlan              string                       `json:"jsonPlan"`
+	NonDefaultOptionsJSON string                       `json:"nonDefaultOptionsJSON"`
+	DremioVersion         string                       `json:"dremioVersion"`
+	TotalFragments        int                          `json:"totalFragments"`
+	FinishedFragments     int                          `json:"finishedFragments"`
+	PlanPhases            []DremioPlanPhase            `json:"planPhases"`
+	FragmentProfile       []DremioMajorFragmentProfile `json:"fragmentProfile"`
+}
+
+// DremioOption is a support option that was changed from its default value
+type DremioOption struct {
+	Name  string `json:"name"`  // Option name
+	Kind  string `json:"kind"`  // Option kind (BOOLEAN, LONG, DOUBLE, STRING)
+	Type  string `json:"type"`  // Where the option was set (SYSTEM, SESSION, QUERY)
+	Value string `json:"value"` // Option value formatted for display
+}
+
+// DremioOperatorSummary aggregates one operator across all of its minor fragments
+type DremioOperatorSummary struct {
+	ID              string `json:"id"`               // Operator id in "MM-OO" format
+	Name            string `json:"name"`             // Operator name from the JSON plan
+	Threads         int    `json:"threads"`          // Number of minor fragments running the operator
+	SetupNanos      int64  `json:"setup_nanos"`      // Total setup time
+	ProcessNanos    int64  `json:"process_nanos"`    // Total process time
+	WaitNanos       int64  `json:"wait_nanos"`       // Total wait time
+	MaxPeakMemory   int64  `json:"max_peak_memory"`  // Highest peak memory of any minor fragment
+	RecordsReceived int64  `json:"records_received"` // Total records received across all inputs
+}
+
+// DremioProfileReportData represents the parsed profile data used to build the report
+type DremioProfileReportData struct {
+	QueryID           string                  `json:"query_id"`
+	Query             string                  `json:"query"`
+	User              string                  `json:"user"`
+	State             string                  `json:"state"`
+	DremioVersion     string                  `json:"dremio_version"`
+	TotalMillis       int64                   `json:"total_millis"`
+	PlanningMillis    int64                   `json:"planning_millis"`
+	Error             string                  `json:"error"`
+	VerboseError      string                  `json:"verbose_error"`
+	PlanPhases        []DremioPlanPhase       `json:"plan_phases"`
+	NonDefaultOptions []DremioOption          `json:"non_default_options"`
+	Operators         []DremioOperatorSummary `json:"operators"`
+}
+
+// dremioQueryStates maps the QueryState enum values used in exported profiles to their names
+var dremioQueryStates = map[int]string{
+	0: "STARTING",
+	1: "RUNNING",
+	2: "COMPLETED",
+	3: "CANCELED",
+	4: "FAILED",
+	5: "CANCELLATION_REQUESTED",
+	6: "ENQUEUED",
+}
+
+// ParseDremioProfile parses a Dremio query profile, either the raw profile JSON or
+// the zip downloaded from the Dremio UI containing profile_attempt_N.json entries
+func ParseDremioProfile(content []byte) (*DremioProfileReportData, error) {
+	profileJSON := content
+	if bytes.HasPrefix(content, []byte("PK\x03\x04")) {
+		extracted, err := extractProfileFromZip(content)
+		if err != nil {
+			return nil, err
+		}
+		profileJSON = extracted
+	}
+
+	var profile DremioProfile
+	if err := json.Unmarshal(profileJSON, &profile); err != nil {
+		return nil, fmt.Errorf("failed to parse profile JSON: %w", err)
+	}
+
+	data := &DremioProfileReportData{
+		QueryID:       profile.ID.String(),
+		Query:         profile.Query,
+		User:          profile.User,
+		State:         parseDremioQueryState(profile.State),
+		DremioVersion: profile.DremioVersion,
+		Error:         profile.Error,
+		VerboseError:  profile.VerboseError,
+		PlanPhases:    profile.PlanPhases,
+	}
+	if data.PlanPhases == nil {
+		data.PlanPhases = []DremioPlanPhase{}
+	}
+	if profile.End > profile.Start && profile.Start > 0 {
+		data.TotalMillis = profile.End - profile.Start
+	}
+	if profile.PlanningEnd > profile.PlanningStart && profile.PlanningStart > 0 {
+		data.PlanningMillis = profile.PlanningEnd - profile.PlanningStart
+	}
+
+	options, err := parseNonDefaultOptions(profile.NonDefaultOptionsJSON)
+	if err != nil {
+		return nil, err
+	}
+	data.NonDefaultOptions = options
+	data.Operators = summarizeOperators(profile.FragmentProfile, parseJSONPlanOperatorNames(profile.JSONPlan))
+
+	return data, nil
+}
+
+// extractProfileFromZip returns the last profile attempt stored in a profile zip
+func extractProfileFromZip(content []byte) ([]byte, error) {
+	zipReader, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open profile zip: %w", err)
+	}
+
+	var latest *zip.File
+	latestAttempt := -1
+	for _, file := range zipReader.File {
+		name := strings.ToLower(filepath.Base(file.Name))
+		if !strings.HasPrefix(name, "profile_attempt_") || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		attempt, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, "profile_attempt_"), ".json"))
+		if err != nil {
+			continue
+		}
+		if attempt > latestAttempt {
+			latest = file
+			latestAttempt = attempt
+		}
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("no profile_attempt_N.json found in zip")
+	}
+
+	reader, err := latest.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", latest.Name, err)
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+	return io.ReadAll(newDecompressedLimitReader(reader))
+}
+
+// parseDremioQueryState handles both the numeric and string forms of the query state
+func parseDremioQueryState(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return "UNKNOWN"
+	}
+	var name string
+	if err := json.Unmarshal(raw, &name); err == nil {
+		return name
+	}
+	var value int
+	if err := json.Unmarshal(raw, &value); err == nil {
+		if name, ok := dremioQueryStates[value]; ok {
+			return name
+		}
+		return fmt.Sprintf("STATE_%d", value)
+	}
+	return "UNKNOWN"
+}
+
+// parseNonDefaultOptions decodes the embedded nonDefaultOptionsJSON string
+func parseNonDefaultOptions(optionsJSON string) ([]DremioOption, error) {
+	options := []DremioOption{}
+	if strings.TrimSpace(optionsJSON) == "" {
+		return options, nil
+	}
+
+	var raw []struct {
+		Name      string   `json:"name"`
+		Kind      string   `json:"kind"`
+		Type      string   `json:"type"`
+		BoolVal   *bool    `json:"bool_val"`
+		NumVal    *int64   `json:"num_val"`
+		FloatVal  *float64 `json:"float_val"`
+		StringVal *string  `json:"string_val"`
+	}
+	if err := json.Unmarshal([]byte(optionsJSON), &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse non-default options: %w", err)
+	}
+
+	for _, option := range raw {
+		var value string
+		switch {
+		case option.BoolVal != nil:
+			value = strconv.FormatBool(*option.BoolVal)
+		case option.NumVal != nil:
+			value = strconv.FormatInt(*option.NumVal, 10)
+		case option.FloatVal != nil:
+			value = strconv.FormatFloat(*option.FloatVal, 'f', -1, 64)
+		case option.StringVal != nil:
+			value = *option.StringVal
+		}
+		options = append(options, DremioOption{
+			Name:  option.Name,
+			Kind:  option.Kind,
+			Type:  option.Type,
+			Value: value,
+		})
+	}
+
+	sort.Slice(options, func(i, j int) bool {
+		return options[i].Name < options[j].Name
+	})
+	return options, nil
+}
+
+// parseJSONPlanOperatorNames maps "MM-OO" operator ids to short operator names using the JSON plan
+func parseJSONPlanOperatorNames(jsonPlan string) map[string]string {
+	names := map[string]string{}
+	if strings.TrimSpace(jsonPlan) == "" {
+		return names
+	}
+
+	var plan map[string]struct {
+		Op string `json:"op"`
+	}
+	if err := json.Unmarshal([]byte(jsonPlan), &plan); err != nil {
+		return names
+	}
+
+	for id, node := range plan {
+		name := node.Op
+		if idx := strings.LastIndex(name, "."); idx >= 0 {
+			name = name[idx+1:]
+		}
+		names[id] = strings.TrimSuffix(name, "Prel")
+	}
+	return names
+}
+
+// summarizeOperators aggregates operator timings across minor fragments, ordered by operator id
+func summarizeOperators(fragments []DremioMajorFragmentProfile, names map[string]string) []DremioOperatorSummary {
+	byID := map[string]*DremioOperatorSummary{}
+	for _, major := range fragments {
+		for _, minor := range major.MinorFragmentProfile {
+			for _, op := range minor.OperatorProfile {
+				id := fmt.Sprintf("%02d-%02d", major.MajorFragmentID, op.OperatorID)
+				summary, ok := byID[id]
+				if !ok {
+					name, found := names[id]
+					if !found {
+						name = fmt.Sprintf("Operator type %d", op.OperatorType)
+					}
+					summary = &DremioOperatorSummary{ID: id, Name: name}
+					byID[id] = summary
+				}
+				summary.Threads++
+				summary.SetupNanos += op.SetupNanos
+				summary.ProcessNanos += op.ProcessNanos
+				summary.WaitNanos += op.WaitNanos
+				if op.PeakLocalMemoryAllocated > summary.MaxPeakMemory {
+					summary.MaxPeakMemory = op.PeakLocalMemoryAllocated
+				}
+				for _, input := range op.InputProfile {
+					summary.RecordsReceived += input.Records
+				}
+			}
+		}
+	}
+
+	operators := make([]DremioOperatorSummary, 0, len(byID))
+	for _, summary := range byID {
+		operators = append(operators, *summary)
+	}
+	sort.Slice(operators, func(i, j int) bool {
+		return operators[i].ID < operators[j].ID
+	})
+	return operators
+}
@@ -0,0 +1,148 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporters
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/rsvihladremio/ddd/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDremioProfile(t *testing.T) {
+	t.Run("Profile JSON", func(t *testing.T) {
+		data, err := ParseDremioProfile(testutil.SampleFiles["dremio_profile"].Content)
+		require.NoError(t, err)
+
+		assert.Equal(t, "SELECT * FROM sales WHERE region = 'EMEA'", data.Query)
+		assert.Equal(t, "dremio", data.User)
+		assert.Equal(t, "COMPLETED", data.State)
+		assert.Equal(t, "25.0.0", data.DremioVersion)
+		assert.Equal(t, int64(2500), data.TotalMillis)
+		assert.Equal(t, int64(300), data.PlanningMillis)
+		require.Len(t, data.PlanPhases, 3)
+		assert.Equal(t, "Logical Planning", data.PlanPhases[1].PhaseName)
+		assert.Equal(t, int64(180), data.PlanPhases[1].DurationMillis)
+
+		// Options are sorted by name
+		require.Len(t, data.NonDefaultOptions, 2)
+		assert.Equal(t, DremioOption{Name: "exec.queue.enable", Kind: "BOOLEAN", Type: "SESSION", Value: "false"}, data.NonDefaultOptions[0])
+		assert.Equal(t, DremioOption{Name: "planner.slice_target", Kind: "LONG", Type: "SYSTEM", Value: "1000"}, data.NonDefaultOptions[1])
+
+		// Operator 00-01 runs in two minor fragments
+		require.Len(t, data.Operators, 2)
+		assert.Equal(t, "00-00", data.Operators[0].ID)
+		assert.Equal(t, "Screen", data.Operators[0].Name)
+		filter := data.Operators[1]
+		assert.Equal(t, "00-01", filter.ID)
+		assert.Equal(t, "Filter", filter.Name)
+		assert.Equal(t, 2, filter.Threads)
+		assert.Equal(t, int64(500000000), filter.ProcessNanos)
+		assert.Equal(t, int64(150000000), filter.WaitNanos)
+		assert.Equal(t, int64(8388608), filter.MaxPeakMemory)
+		assert.Equal(t, int64(1500), filter.RecordsReceived)
+	})
+
+	t.Run("Profile zip uses the latest attempt", func(t *testing.T) {
+		var buf bytes.Buffer
+		zipWriter := zip.NewWriter(&buf)
+		files := map[string]string{
+			"header.json":            `{}`,
+			"profile_attempt_0.json": `{"query": "SELECT 0", "state": "FAILED"}`,
+			"profile_attempt_1.json": `{"query": "SELECT 1", "state": "COMPLETED"}`,
+		}
+		for name, content := range files {
+			writer, err := zipWriter.Create(name)
+			require.NoError(t, err)
+			_, err = writer.Write([]byte(content))
+			require.NoError(t, err)
+		}
+		require.NoError(t, zipWriter.Close())
+
+		data, err := ParseDremioProfile(buf.Bytes())
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT 1", data.Query)
+		assert.Equal(t, "COMPLETED", data.State)
+	})
+
+	t.Run("Failed query keeps the error output", func(t *testing.T) {
+		data, err := ParseDremioProfile([]byte(`{"query": "SELECT x", "state": 4, "error": "Column x not found", "verboseError": "VALIDATION ERROR: Column x not found"}`))
+		require.NoError(t, err)
+		assert.Equal(t, "FAILED", data.State)
+		assert.Equal(t, "Column x not found", data.Error)
+		assert.Equal(t, "VALIDATION ERROR: Column x not found", data.VerboseError)
+		assert.Empty(t, data.Operators)
+		assert.Empty(t, data.NonDefaultOptions)
+	})
+
+	t.Run("Invalid JSON", func(t *testing.T) {
+		_, err := ParseDremioProfile([]byte(`{"query": `))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to parse profile JSON")
+	})
+
+	t.Run("Zip bombs are refused", func(t *testing.T) {
+		defer func(limit int64) { maxDecompressedCaptureBytes = limit }(maxDecompressedCaptureBytes)
+		maxDecompressedCaptureBytes = 1024 * 1024
+
+		var buf bytes.Buffer
+		zipWriter := zip.NewWriter(&buf)
+		writer, err := zipWriter.Create("profile_attempt_0.json")
+		require.NoError(t, err)
+		_, err = writer.Write(make([]byte, 16*1024*1024))
+		require.NoError(t, err)
+		require.NoError(t, zipWriter.Close())
+		require.Less(t, buf.Len(), 64*1024)
+
+		_, err = ParseDremioProfile(buf.Bytes())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "decompressed capture exceeds 1048576 bytes")
+	})
+
+	t.Run("Zip without profile", func(t *testing.T) {
+		var buf bytes.Buffer
+		zipWriter := zip.NewWriter(&buf)
+		_, err := zipWriter.Create("readme.txt")
+		require.NoError(t, err)
+		require.NoError(t, zipWriter.Close())
+
+		_, err = ParseDremioProfile(buf.Bytes())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no profile_attempt_N.json found")
+	})
+}
+
+func TestParseDremioQueryState(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      json.RawMessage
+		expected string
+	}{
+		{"Numeric state", json.RawMessage(`3`), "CANCELED"},
+		{"String state", json.RawMessage(`"RUNNING"`), "RUNNING"},
+		{"Unknown numeric state", json.RawMessage(`42`), "STATE_42"},
+		{"Missing state", nil, "UNKNOWN"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, parseDremioQueryState(tt.raw))
+		})
+	}
+}
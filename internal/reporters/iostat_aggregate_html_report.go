@@ -0,0 +1,326 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporters
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+)
+
+// IOStatNodeSummary holds the cross-node summary row for a single iostat capture
+type IOStatNodeSummary struct {
+	Label               string  `json:"label"`                  // Node label shown in the aggregate report
+	SnapshotCount       int     `json:"snapshot_count"`         // Number of snapshots in the capture
+	DeviceCount         int     `json:"device_count"`           // Number of unique devices monitored
+	PeakCPUUsage        float64 `json:"peak_cpu_usage"`         // Peak CPU usage (100 - idle)
+	PeakDeviceQueueSize float64 `json:"peak_device_queue_size"` // Peak aqu-sz across all devices
+	MaxP95Utilization   float64 `json:"max_p95_utilization"`    // Highest per-device p95 %util
+	BusiestDevice       string  `json:"busiest_device"`         // Device with the highest p95 %util
+}
+
+// ParseIOStatFile streams and parses an iostat capture from disk, interpreting its timestamps in loc
+func ParseIOStatFile(filePath string, loc *time.Location) (*IOStatReportData, error) {
+	file, _, err := openCapture(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	return ParseIOStatReader(file, loc)
+}
+
+// summarizeIOStatNode builds the cross-node summary row for a single capture
+func summarizeIOStatNode(label string, data *IOStatReportData) IOStatNodeSummary {
+	summary := IOStatNodeSummary{Label: label}
+	if data == nil {
+		return summary
+	}
+
+	summary.SnapshotCount = len(data.Snapshots)
+	summary.DeviceCount = countUniqueDevices(data)
+	summary.PeakCPUUsage = findPeakCPUUsage(data)
+	summary.PeakDeviceQueueSize = findPeakDeviceQueueSize(data)
+
+	for _, device := range summarizeDevices(data) {
+		if summary.BusiestDevice == "" || device.Utilization.P95 > summary.MaxP95Utilization {
+			summary.MaxP95Utilization = device.Utilization.P95
+			summary.BusiestDevice = device.Device
+		}
+	}
+	return summary
+}
+
+// GenerateIOStatAggregateHTML generates a single HTML page comparing several iostat captures,
+// typically one per node. It renders a cross-node summary table followed by one small chart
+// per node showing CPU usage and the highest device %util over time.
+func GenerateIOStatAggregateHTML(datas []*IOStatReportData, labels []string) (string, error) {
+	if len(datas) == 0 {
+		return "", fmt.Errorf("no iostat data to aggregate")
+	}
+	if len(datas) != len(labels) {
+		return "", fmt.Errorf("expected %d labels, got %d", len(datas), len(labels))
+	}
+
+	var summaryRows []string
+	var chartContainers []string
+	var chartScripts []string
+
+	for i, data := range datas {
+		summary := summarizeIOStatNode(labels[i], data)
+		summaryRows = append(summaryRows, fmt.Sprintf(`                <tr>
+                    <td>%s</td>
+                    <td>%d</td>
+                    <td>%d</td>
+                    <td>%.1f%%</td>
+                    <td>%.2f</td>
+                    <td>%.2f%%</td>
+                    <td>%s</td>
+                </tr>`,
+			html.EscapeString(summary.Label),
+			summary.SnapshotCount,
+			summary.DeviceCount,
+			summary.PeakCPUUsage,
+			summary.PeakDeviceQueueSize,
+			summary.MaxP95Utilization,
+			html.EscapeString(summary.BusiestDevice)))
+
+		chartID := fmt.Sprintf("nodeChart%d", i)
+		chartContainers = append(chartContainers, fmt.Sprintf(`            <div class="node-chart">
+                <div class="node-title">%s</div>
+                <div id="%s" class="chart"></div>
+            </div>`, html.EscapeString(labels[i]), chartID))
+
+		chartScript, err := extractNodeChartScript(chartID, data)
+		if err != nil {
+			return "", err
+		}
+		chartScripts = append(chartScripts, chartScript)
+	}
+
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>IOStat Aggregate Report</title>
+    <script src="/static/js/echarts.min.js"></script>
+    <style>
+        body {
+            font-family: 'Segoe UI', Tahoma, Geneva, Verdana, sans-serif;
+            margin: 0;
+            padding: 20px;
+            background-color: #f5f5f5;
+        }
+        .container {
+            max-width: 1400px;
+            margin: 0 auto;
+            background-color: white;
+            border-radius: 8px;
+            box-shadow: 0 2px 10px rgba(0,0,0,0.1);
+            overflow: hidden;
+        }
+        .header {
+            background: linear-gradient(135deg, #06b6d4 0%%, #0891b2 100%%);
+            color: white;
+            padding: 30px;
+            text-align: center;
+        }
+        .header h1 {
+            margin: 0 0 10px 0;
+            font-size: 2.5em;
+            font-weight: 300;
+        }
+        .header p {
+            margin: 0;
+            font-size: 1.1em;
+            opacity: 0.9;
+        }
+        .chart-container {
+            padding: 30px;
+            border-bottom: 1px solid #eee;
+        }
+        .chart-container:last-child {
+            border-bottom: none;
+        }
+        .chart-title {
+            font-size: 1.5em;
+            margin-bottom: 20px;
+            color: #333;
+            text-align: center;
+        }
+        .summary-table {
+            width: 100%%;
+            border-collapse: collapse;
+            font-size: 0.9em;
+        }
+        .summary-table th,
+        .summary-table td {
+            padding: 8px 12px;
+            border-bottom: 1px solid #eee;
+            text-align: right;
+        }
+        .summary-table th:first-child,
+        .summary-table td:first-child {
+            text-align: left;
+        }
+        .summary-table thead th {
+            background-color: #f8f9fa;
+            color: #333;
+        }
+        .small-multiples {
+            display: grid;
+            grid-template-columns: repeat(auto-fit, minmax(420px, 1fr));
+            gap: 20px;
+        }
+        .node-chart {
+            border: 1px solid #eee;
+            border-radius: 8px;
+            padding: 10px;
+        }
+        .node-title {
+            font-size: 1.1em;
+            color: #333;
+            text-align: center;
+            margin-bottom: 10px;
+        }
+        .chart {
+            width: 100%%;
+            height: 260px;
+        }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>IOStat Aggregate Report</h1>
+            <p>Comparing %d iostat captures</p>
+        </div>
+
+        <div class="chart-container">
+            <div class="chart-title">Cross-Node Summary</div>
+            <table class="summary-table">
+                <thead>
+                    <tr>
+                        <th>Node</th>
+                        <th>Snapshots</th>
+                        <th>Devices</th>
+                        <th>Peak CPU Usage</th>
+                        <th>Peak Avg. Queue Size</th>
+                        <th>Max p95 %%util</th>
+                        <th>Busiest Device</th>
+                    </tr>
+                </thead>
+                <tbody>
+%s
+                </tbody>
+            </table>
+        </div>
+
+        <div class="chart-container">
+            <div class="chart-title">CPU Usage and Peak Device Utilization by Node</div>
+            <div class="small-multiples">
+%s
+            </div>
+        </div>
+    </div>
+
+    <script>
+        try {
+            const charts = [];
+%s
+
+            // Handle window resize
+            window.addEventListener('resize', function() {
+                charts.forEach(function(chart) {
+                    chart.resize();
+                });
+            });
+
+        } catch (error) {
+            console.error('Error initializing charts:', error);
+            document.body.innerHTML += '<div style="color: red; padding: 20px; background: #ffe6e6; border: 1px solid red; margin: 20px;">Error initializing charts: ' + error.message + '</div>';
+        }
+    </script>
+</body>
+</html>`,
+		len(datas),
+		strings.Join(summaryRows, "\n"),
+		strings.Join(chartContainers, "\n"),
+		strings.Join(chartScripts, "\n")), nil
+}
+
+// extractNodeChartScript builds the echarts initialization script for a single node's small chart
+func extractNodeChartScript(chartID string, data *IOStatReportData) (string, error) {
+	labels := []string{}
+	cpuUsage := []float64{}
+	maxUtil := []float64{}
+	axisName := timeAxisName(time.Time{})
+
+	if data != nil {
+		if len(data.Snapshots) > 0 {
+			axisName = timeAxisName(data.Snapshots[0].Timestamp)
+		}
+		for _, snapshot := range data.Snapshots {
+			labels = append(labels, snapshot.Timestamp.Format("15:04:05"))
+
+			usage := 0.0
+			if snapshot.CPUStats != nil {
+				usage = 100.0 - snapshot.CPUStats.Idle
+			}
+			cpuUsage = append(cpuUsage, usage)
+
+			peak := 0.0
+			for _, device := range snapshot.Devices {
+				if device.Utilization > peak {
+					peak = device.Utilization
+				}
+			}
+			maxUtil = append(maxUtil, peak)
+		}
+	}
+
+	labelsJSON, err := json.Marshal(labels)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal labels: %w", err)
+	}
+	cpuJSON, err := json.Marshal(cpuUsage)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal CPU usage: %w", err)
+	}
+	utilJSON, err := json.Marshal(maxUtil)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal utilization: %w", err)
+	}
+
+	return fmt.Sprintf(`            charts.push((function() {
+                const chart = echarts.init(document.getElementById('%s'));
+                chart.setOption({
+                    tooltip: { trigger: 'axis' },
+                    legend: { data: ['CPU Usage %%', 'Max Device %%util'] },
+                    grid: { left: '3%%', right: '4%%', bottom: '3%%', containLabel: true },
+                    xAxis: { type: 'category', name: '%s', nameLocation: 'middle', nameGap: 30, boundaryGap: false, data: %s },
+                    yAxis: { type: 'value', min: 0, max: 100 },
+                    series: [
+                        { name: 'CPU Usage %%', type: 'line', smooth: true, data: %s },
+                        { name: 'Max Device %%util', type: 'line', smooth: true, data: %s }
+                    ]
+                });
+                return chart;
+            })());`, chartID, axisName, labelsJSON, cpuJSON, utilJSON), nil
+}
@@ -0,0 +1,98 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporters
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateIOStatAggregateHTML(t *testing.T) {
+	node := func(idle, util float64) *IOStatReportData {
+		return &IOStatReportData{
+			Snapshots: []IOStatSnapshot{
+				{
+					Timestamp: time.Date(2024, 9, 4, 12, 7, 20, 0, time.UTC),
+					CPUStats:  &CPUStats{Idle: idle},
+					Devices: []DeviceStats{
+						{Device: "sda", Utilization: util, AvgQueueSize: 1.5},
+						{Device: "sdb", Utilization: util / 2},
+					},
+				},
+			},
+		}
+	}
+
+	t.Run("Render summary table and one chart per node", func(t *testing.T) {
+		datas := []*IOStatReportData{node(80.0, 40.0), node(20.0, 95.0)}
+		labels := []string{"node-1 <iostat>", "node-2"}
+
+		html, err := GenerateIOStatAggregateHTML(datas, labels)
+		require.NoError(t, err)
+
+		assert.Contains(t, html, "IOStat Aggregate Report")
+		assert.Contains(t, html, "Comparing 2 iostat captures")
+		assert.Contains(t, html, "Cross-Node Summary")
+		assert.Contains(t, html, `id="nodeChart0"`)
+		assert.Contains(t, html, `id="nodeChart1"`)
+		assert.NotContains(t, html, `id="nodeChart2"`)
+
+		// Labels are escaped
+		assert.Contains(t, html, "node-1 &lt;iostat&gt;")
+		assert.NotContains(t, html, "<iostat>")
+
+		// Peak CPU and utilization values for each node
+		assert.Contains(t, html, "20.0%")
+		assert.Contains(t, html, "80.0%")
+		assert.Contains(t, html, "95.00%")
+		assert.Contains(t, html, `["12:07:20"]`)
+	})
+
+	t.Run("Reject empty input", func(t *testing.T) {
+		_, err := GenerateIOStatAggregateHTML(nil, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("Reject mismatched labels", func(t *testing.T) {
+		_, err := GenerateIOStatAggregateHTML([]*IOStatReportData{node(50, 50)}, []string{"a", "b"})
+		assert.Error(t, err)
+	})
+}
+
+func TestSummarizeIOStatNode(t *testing.T) {
+	data := &IOStatReportData{
+		Snapshots: []IOStatSnapshot{
+			{
+				CPUStats: &CPUStats{Idle: 70.0},
+				Devices: []DeviceStats{
+					{Device: "sda", Utilization: 10.0, AvgQueueSize: 0.5},
+					{Device: "nvme0n1", Utilization: 60.0, AvgQueueSize: 2.5},
+				},
+			},
+		},
+	}
+
+	summary := summarizeIOStatNode("node-1", data)
+	assert.Equal(t, "node-1", summary.Label)
+	assert.Equal(t, 1, summary.SnapshotCount)
+	assert.Equal(t, 2, summary.DeviceCount)
+	assert.InDelta(t, 30.0, summary.PeakCPUUsage, 0.001)
+	assert.Equal(t, 2.5, summary.PeakDeviceQueueSize)
+	assert.Equal(t, 60.0, summary.MaxP95Utilization)
+	assert.Equal(t, "nvme0n1", summary.BusiestDevice)
+}
@@ -0,0 +1,280 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporters
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+)
+
+// DefaultIOStatBaselineTolerancePct is how far above the baseline a p95 may rise before it counts as a regression
+const DefaultIOStatBaselineTolerancePct = 20.0
+
+// IOStatMetricDelta compares one p95 metric of a device against the baseline
+type IOStatMetricDelta struct {
+	Baseline  float64 `json:"baseline"`
+	Current   float64 `json:"current"`
+	DeltaPct  float64 `json:"delta_pct"` // Change relative to the baseline, 0 when the baseline is 0
+	Regressed bool    `json:"regressed"` // Current is above the baseline by more than the tolerance
+}
+
+// IOStatDeviceComparison holds the p95 deltas for a single device. Devices found in only one of
+// the captures are listed but cannot regress
+type IOStatDeviceComparison struct {
+	Device      string            `json:"device"`
+	InBaseline  bool              `json:"in_baseline"`
+	InCurrent   bool              `json:"in_current"`
+	Utilization IOStatMetricDelta `json:"utilization"` // p95 %util
+	ReadAwait   IOStatMetricDelta `json:"read_await"`  // p95 r_await
+	WriteAwait  IOStatMetricDelta `json:"write_await"` // p95 w_await
+}
+
+// IOStatBaselineComparison is the result of checking an iostat capture against a baseline
+type IOStatBaselineComparison struct {
+	TolerancePct float64                  `json:"tolerance_pct"`
+	Devices      []IOStatDeviceComparison `json:"devices"`
+	Regressions  int                      `json:"regressions"` // Number of regressed metrics across all devices
+}
+
+// CompareIOStatToBaseline diffs the per-device p95 %util, r_await and w_await of current against
+// baseline and flags every metric that rose by more than tolerancePct percent of its baseline value.
+// A metric with a baseline of 0 regresses as soon as it is above 0
+func CompareIOStatToBaseline(baseline, current []DeviceSummary, tolerancePct float64) *IOStatBaselineComparison {
+	comparison := &IOStatBaselineComparison{
+		TolerancePct: tolerancePct,
+		Devices:      make([]IOStatDeviceComparison, 0),
+	}
+
+	baselineByDevice := make(map[string]DeviceSummary, len(baseline))
+	for _, summary := range baseline {
+		baselineByDevice[summary.Device] = summary
+	}
+	currentByDevice := make(map[string]DeviceSummary, len(current))
+	for _, summary := range current {
+		currentByDevice[summary.Device] = summary
+	}
+
+	devices := make([]string, 0, len(baselineByDevice))
+	for device := range baselineByDevice {
+		devices = append(devices, device)
+	}
+	for device := range currentByDevice {
+		if _, ok := baselineByDevice[device]; !ok {
+			devices = append(devices, device)
+		}
+	}
+	sort.Strings(devices)
+
+	for _, device := range devices {
+		base, inBaseline := baselineByDevice[device]
+		cur, inCurrent := currentByDevice[device]
+		compared := inBaseline && inCurrent
+		deviceComparison := IOStatDeviceComparison{
+			Device:      device,
+			InBaseline:  inBaseline,
+			InCurrent:   inCurrent,
+			Utilization: compareMetric(base.Utilization.P95, cur.Utilization.P95, tolerancePct, compared),
+			ReadAwait:   compareMetric(base.ReadAwait.P95, cur.ReadAwait.P95, tolerancePct, compared),
+			WriteAwait:  compareMetric(base.WriteAwait.P95, cur.WriteAwait.P95, tolerancePct, compared),
+		}
+		for _, delta := range []IOStatMetricDelta{deviceComparison.Utilization, deviceComparison.ReadAwait, deviceComparison.WriteAwait} {
+			if delta.Regressed {
+				comparison.Regressions++
+			}
+		}
+		comparison.Devices = append(comparison.Devices, deviceComparison)
+	}
+	return comparison
+}
+
+// compareMetric builds the delta between a baseline and current value. Values of a device
+// missing from either capture are kept for display but not compared
+func compareMetric(baseline, current, tolerancePct float64, compared bool) IOStatMetricDelta {
+	delta := IOStatMetricDelta{Baseline: baseline, Current: current}
+	if !compared {
+		return delta
+	}
+	if baseline == 0 {
+		delta.Regressed = current > 0
+		return delta
+	}
+	delta.DeltaPct = (current - baseline) / baseline * 100
+	delta.Regressed = delta.DeltaPct > tolerancePct
+	return delta
+}
+
+// GenerateIOStatBaselineComparisonHTML renders a comparison as a page with one row per device,
+// regressions in red and improvements in green
+func GenerateIOStatBaselineComparisonHTML(comparison *IOStatBaselineComparison, baselineLabel, currentLabel string) string {
+	var rows []string
+	for _, device := range comparison.Devices {
+		note := ""
+		switch {
+		case !device.InBaseline:
+			note = "not in baseline"
+		case !device.InCurrent:
+			note = "missing from this capture"
+		}
+
+		cells := []string{fmt.Sprintf(`<td>%s</td>`, html.EscapeString(device.Device))}
+		for _, delta := range []IOStatMetricDelta{device.Utilization, device.ReadAwait, device.WriteAwait} {
+			cells = append(cells, fmt.Sprintf(`<td>%.2f</td><td>%.2f</td>%s`,
+				delta.Baseline, delta.Current, deltaCellHTML(delta, note == "")))
+		}
+		cells = append(cells, fmt.Sprintf(`<td>%s</td>`, note))
+		rows = append(rows, "                    <tr>"+strings.Join(cells, "")+"</tr>")
+	}
+	if len(rows) == 0 {
+		rows = append(rows, `                    <tr><td colspan="11">No device statistics available.</td></tr>`)
+	}
+
+	verdict := `<p class="verdict pass">No regressions beyond the tolerance</p>`
+	if comparison.Regressions > 0 {
+		verdict = fmt.Sprintf(`<p class="verdict fail">%d regressed metrics beyond the tolerance</p>`, comparison.Regressions)
+	}
+
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>IOStat Baseline Comparison</title>
+    <style>
+        body {
+            font-family: 'Segoe UI', Tahoma, Geneva, Verdana, sans-serif;
+            margin: 0;
+            padding: 20px;
+            background-color: #f5f5f5;
+        }
+        .container {
+            max-width: 1400px;
+            margin: 0 auto;
+            background-color: white;
+            border-radius: 8px;
+            box-shadow: 0 2px 10px rgba(0,0,0,0.1);
+            overflow: hidden;
+        }
+        .header {
+            background: linear-gradient(135deg, #06b6d4 0%%, #0891b2 100%%);
+            color: white;
+            padding: 30px;
+            text-align: center;
+        }
+        .header h1 {
+            margin: 0 0 10px 0;
+            font-size: 2.5em;
+            font-weight: 300;
+        }
+        .header p {
+            margin: 0;
+            font-size: 1.1em;
+            opacity: 0.9;
+        }
+        .chart-container {
+            padding: 30px;
+        }
+        .verdict {
+            font-size: 1.2em;
+            margin: 0 0 20px 0;
+        }
+        .summary-table {
+            width: 100%%;
+            border-collapse: collapse;
+            font-size: 0.9em;
+        }
+        .summary-table th,
+        .summary-table td {
+            padding: 8px 12px;
+            border-bottom: 1px solid #eee;
+            text-align: right;
+        }
+        .summary-table th:first-child,
+        .summary-table td:first-child,
+        .summary-table td:last-child {
+            text-align: left;
+        }
+        .summary-table thead th {
+            background-color: #f8f9fa;
+            color: #333;
+        }
+        .pass {
+            color: #2e7d32;
+        }
+        .fail {
+            color: #d32f2f;
+            font-weight: bold;
+        }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>IOStat Baseline Comparison</h1>
+            <p>%s compared to baseline %s, tolerance %.0f%%</p>
+        </div>
+
+        <div class="chart-container">
+            %s
+            <table class="summary-table">
+                <thead>
+                    <tr>
+                        <th rowspan="2">Device</th>
+                        <th colspan="3">p95 %%util</th>
+                        <th colspan="3">p95 r_await (ms)</th>
+                        <th colspan="3">p95 w_await (ms)</th>
+                        <th rowspan="2">Note</th>
+                    </tr>
+                    <tr>
+                        <th>Baseline</th><th>Current</th><th>Delta</th>
+                        <th>Baseline</th><th>Current</th><th>Delta</th>
+                        <th>Baseline</th><th>Current</th><th>Delta</th>
+                    </tr>
+                </thead>
+                <tbody>
+%s
+                </tbody>
+            </table>
+        </div>
+    </div>
+</body>
+</html>`,
+		html.EscapeString(currentLabel),
+		html.EscapeString(baselineLabel),
+		comparison.TolerancePct,
+		verdict,
+		strings.Join(rows, "\n"))
+}
+
+// deltaCellHTML renders a delta red when it regressed and green when it improved
+func deltaCellHTML(delta IOStatMetricDelta, compared bool) string {
+	if !compared {
+		return `<td>-</td>`
+	}
+
+	text := fmt.Sprintf("%+.1f%%", delta.DeltaPct)
+	if delta.Baseline == 0 {
+		text = fmt.Sprintf("%+.2f", delta.Current-delta.Baseline)
+	}
+	switch {
+	case delta.Regressed:
+		return fmt.Sprintf(`<td class="fail">%s</td>`, text)
+	case delta.Current < delta.Baseline:
+		return fmt.Sprintf(`<td class="pass">%s</td>`, text)
+	default:
+		return fmt.Sprintf(`<td>%s</td>`, text)
+	}
+}
@@ -0,0 +1,113 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporters
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareIOStatToBaseline(t *testing.T) {
+	device := func(name string, util, readAwait, writeAwait float64) DeviceSummary {
+		return DeviceSummary{
+			Device:      name,
+			Utilization: PercentileStats{P95: util},
+			ReadAwait:   PercentileStats{P95: readAwait},
+			WriteAwait:  PercentileStats{P95: writeAwait},
+		}
+	}
+
+	baseline := []DeviceSummary{
+		device("sda", 50, 10, 0),
+		device("sdb", 40, 5, 8),
+		device("sdc", 10, 1, 1),
+	}
+	current := []DeviceSummary{
+		device("sda", 59, 20, 0.5),
+		device("sdb", 30, 5.5, 8),
+		device("nvme0n1", 99, 50, 50),
+	}
+
+	comparison := CompareIOStatToBaseline(baseline, current, 20)
+	assert.Equal(t, 20.0, comparison.TolerancePct)
+	require.Len(t, comparison.Devices, 4)
+
+	byDevice := map[string]IOStatDeviceComparison{}
+	var order []string
+	for _, device := range comparison.Devices {
+		byDevice[device.Device] = device
+		order = append(order, device.Device)
+	}
+	assert.Equal(t, []string{"nvme0n1", "sda", "sdb", "sdc"}, order, "Devices are sorted by name")
+
+	sda := byDevice["sda"]
+	assert.False(t, sda.Utilization.Regressed, "An 18% rise is within the tolerance")
+	assert.InDelta(t, 18.0, sda.Utilization.DeltaPct, 0.001)
+	assert.True(t, sda.ReadAwait.Regressed)
+	assert.InDelta(t, 100.0, sda.ReadAwait.DeltaPct, 0.001)
+	assert.True(t, sda.WriteAwait.Regressed, "Any rise from a baseline of 0 regresses")
+
+	sdb := byDevice["sdb"]
+	assert.False(t, sdb.Utilization.Regressed)
+	assert.InDelta(t, -25.0, sdb.Utilization.DeltaPct, 0.001)
+	assert.False(t, sdb.ReadAwait.Regressed)
+	assert.False(t, sdb.WriteAwait.Regressed)
+
+	assert.False(t, byDevice["nvme0n1"].InBaseline)
+	assert.False(t, byDevice["nvme0n1"].Utilization.Regressed, "Devices missing from the baseline cannot regress")
+	assert.False(t, byDevice["sdc"].InCurrent)
+
+	assert.Equal(t, 2, comparison.Regressions)
+
+	t.Run("A higher tolerance accepts the rise", func(t *testing.T) {
+		assert.Equal(t, 1, CompareIOStatToBaseline(baseline, current, 150).Regressions)
+	})
+}
+
+func TestGenerateIOStatBaselineComparisonHTML(t *testing.T) {
+	baseline := []DeviceSummary{
+		{Device: "sda", Utilization: PercentileStats{P95: 50}, ReadAwait: PercentileStats{P95: 10}, WriteAwait: PercentileStats{P95: 10}},
+		{Device: "sdb", Utilization: PercentileStats{P95: 40}},
+	}
+	current := []DeviceSummary{
+		{Device: "sda", Utilization: PercentileStats{P95: 80}, ReadAwait: PercentileStats{P95: 5}, WriteAwait: PercentileStats{P95: 10}},
+		{Device: "<nvme>", Utilization: PercentileStats{P95: 10}},
+	}
+
+	html := GenerateIOStatBaselineComparisonHTML(CompareIOStatToBaseline(baseline, current, 20), "r5 <healthy>", "Report 7")
+
+	assert.Contains(t, html, "IOStat Baseline Comparison")
+	assert.Contains(t, html, "Report 7 compared to baseline r5 &lt;healthy&gt;, tolerance 20%")
+	assert.Contains(t, html, `<p class="verdict fail">1 regressed metrics beyond the tolerance</p>`)
+	assert.Contains(t, html, `<td class="fail">+60.0%</td>`, "Regressions are red")
+	assert.Contains(t, html, `<td class="pass">-50.0%</td>`, "Improvements are green")
+	assert.Contains(t, html, `<td>+0.0%</td>`)
+	assert.Contains(t, html, "&lt;nvme&gt;")
+	assert.NotContains(t, html, "<nvme>")
+	assert.Contains(t, html, "not in baseline")
+	assert.Contains(t, html, "missing from this capture")
+
+	t.Run("No regressions", func(t *testing.T) {
+		html := GenerateIOStatBaselineComparisonHTML(CompareIOStatToBaseline(baseline, baseline, 20), "r5", "Report 8")
+		assert.Contains(t, html, `<p class="verdict pass">No regressions beyond the tolerance</p>`)
+	})
+
+	t.Run("No devices", func(t *testing.T) {
+		html := GenerateIOStatBaselineComparisonHTML(CompareIOStatToBaseline(nil, nil, 20), "r5", "Report 9")
+		assert.Contains(t, html, "No device statistics available.")
+	})
+}
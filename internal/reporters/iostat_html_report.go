@@ -15,105 +15,134 @@
 package reporters
 
 import (
+	"encoding/json"
 	"fmt"
+	"html"
+	"math"
+	"sort"
 	"strings"
+	"time"
 )
 
-// GenerateIOStatHTML generates a self-contained HTML report with three charts:
+// PercentileStats holds the p50/p95/p99 values of a metric
+type PercentileStats struct {
+	P50 float64 `json:"p50"`
+	P95 float64 `json:"p95"`
+	P99 float64 `json:"p99"`
+}
+
+// DeviceSummary holds percentile statistics for a single device across all snapshots
+type DeviceSummary struct {
+	Device       string          `json:"device"`         // Device name (e.g., sda)
+	Samples      int             `json:"samples"`        // Number of snapshots the device appeared in
+	Utilization  PercentileStats `json:"utilization"`    // %util percentiles
+	ReadAwait    PercentileStats `json:"read_await"`     // r_await percentiles
+	WriteAwait   PercentileStats `json:"write_await"`    // w_await percentiles
+	AvgQueueSize PercentileStats `json:"avg_queue_size"` // aqu-sz percentiles
+}
+
+// IOStatThresholds holds the limits above which a device sample is reported as a finding
+type IOStatThresholds struct {
+	UtilizationPct float64 `json:"utilization_pct"` // %util above this value is flagged
+	AwaitMs        float64 `json:"await_ms"`        // r_await or w_await above this value is flagged
+}
+
+// DefaultIOStatThresholds returns the thresholds used when none are configured
+func DefaultIOStatThresholds() IOStatThresholds {
+	return IOStatThresholds{
+		UtilizationPct: 90,
+		AwaitMs:        100,
+	}
+}
+
+// IOStatFinding describes a single device sample that crossed a threshold
+type IOStatFinding struct {
+	Timestamp     time.Time `json:"timestamp"`      // When the breach was observed
+	SnapshotIndex int       `json:"snapshot_index"` // Index of the snapshot in the report data
+	Device        string    `json:"device"`         // Device name (e.g., sda)
+	Metric        string    `json:"metric"`         // Metric that crossed the threshold (%util, r_await, w_await)
+	Value         float64   `json:"value"`          // Observed value
+	Threshold     float64   `json:"threshold"`      // Threshold that was crossed
+}
+
+// GenerateIOStatHTML generates an HTML report using the default thresholds
+func GenerateIOStatHTML(data *IOStatReportData) (string, error) {
+	return GenerateIOStatHTMLWithThresholds(data, DefaultIOStatThresholds())
+}
+
+// GenerateIOStatHTMLWithThresholds generates an HTML report with three charts:
 // 1. CPU Utilization Over Time
 // 2. Device I/O Throughput Over Time
 // 3. Device Utilization Over Time
-func GenerateIOStatHTML(data *IOStatReportData) (string, error) {
+// Samples crossing the given thresholds are annotated on the charts and listed in a findings section
+func GenerateIOStatHTMLWithThresholds(data *IOStatReportData, thresholds IOStatThresholds) (string, error) {
+	return GenerateIOStatHTMLWithMaxPoints(data, thresholds, 0)
+}
+
+// GenerateIOStatHTMLWithMaxPoints generates the same report as GenerateIOStatHTMLWithThresholds
+// with the charts limited to at most maxPoints points, 0 for every snapshot. Adjacent snapshots
+// are averaged into each point; the summary cards, device percentiles and findings are always
+// computed from every snapshot
+func GenerateIOStatHTMLWithMaxPoints(data *IOStatReportData, thresholds IOStatThresholds, maxPoints int) (string, error) {
+	return GenerateIOStatHTMLWithTheme(data, thresholds, maxPoints, DefaultReportTheme)
+}
+
+// GenerateIOStatHTMLWithTheme generates the same report as GenerateIOStatHTMLWithMaxPoints
+// styled with the named report theme, falling back to the default theme for unknown names.
+// The charts hold no data of their own: each loads its entry of GenerateIOStatChartData when
+// it scrolls into view, from /api/reports/{id}/chart/{name} or, in a standalone export, from
+// the report data embedded in the page
+func GenerateIOStatHTMLWithTheme(data *IOStatReportData, thresholds IOStatThresholds, maxPoints int, themeName string) (string, error) {
 	if data == nil || len(data.Snapshots) == 0 {
 		return generateEmptyIOStatHTML(), nil
 	}
 
-	// Prepare data for charts
-	labels := extractIOStatTimeLabels(data)
-	cpuData := extractCPUSeriesData(data)
-	ioThroughputData := extractIOThroughputSeriesData(data)
+	theme := lookupReportTheme(themeName)
 
-	// Generate HTML with embedded charts
+	// The chart series aren't in the page, only the snapshot and time each chart point starts at
+	chartSnapshots := downsampleIOStatData(data, maxPoints).Snapshots
+	findings := findIOStatThresholdBreaches(data, thresholds)
+	snapshotData, err := extractIOStatSnapshotDetails(data, thresholds, len(chartSnapshots))
+	if err != nil {
+		return "", err
+	}
+	chartTimes := make([]time.Time, len(chartSnapshots))
+	for i, snapshot := range chartSnapshots {
+		chartTimes[i] = snapshot.Timestamp
+	}
+	chartTimesData, err := chartTimesScript(chartTimes)
+	if err != nil {
+		return "", err
+	}
+
+	// Generate HTML with empty charts that load their data from GenerateIOStatChartData
 	html := fmt.Sprintf(`<!DOCTYPE html>
 <html lang="en">
 <head>
     <meta charset="UTF-8">
     <meta name="viewport" content="width=device-width, initial-scale=1.0">
     <title>IOStat Analysis Report</title>
-    <script src="https://cdn.jsdelivr.net/npm/echarts@5.4.3/dist/echarts.min.js"></script>
+    <script src="/static/js/echarts.min.js"></script>
+    %s
     <style>
-        body {
-            font-family: 'Segoe UI', Tahoma, Geneva, Verdana, sans-serif;
-            margin: 0;
-            padding: 20px;
-            background-color: #f5f5f5;
-        }
-        .container {
-            max-width: 1400px;
-            margin: 0 auto;
-            background-color: white;
-            border-radius: 8px;
-            box-shadow: 0 2px 10px rgba(0,0,0,0.1);
-            overflow: hidden;
-        }
-        .header {
-            background: linear-gradient(135deg, #06b6d4 0%%, #0891b2 100%%);
-            color: white;
-            padding: 30px;
-            text-align: center;
-        }
-        .header h1 {
-            margin: 0 0 10px 0;
-            font-size: 2.5em;
-            font-weight: 300;
-        }
-        .header p {
-            margin: 0;
-            font-size: 1.1em;
-            opacity: 0.9;
-        }
-        .stats-grid {
-            display: grid;
-            grid-template-columns: repeat(auto-fit, minmax(200px, 1fr));
-            gap: 20px;
-            padding: 30px;
-            background-color: #f8f9fa;
-        }
-        .stat-card {
-            background: white;
-            padding: 20px;
-            border-radius: 8px;
+        .findings-ok {
+            color: var(--report-good);
             text-align: center;
-            box-shadow: 0 2px 4px rgba(0,0,0,0.1);
         }
-        .stat-value {
-            font-size: 2em;
+        .findings-table td.breach,
+        .snapshot-table td.breach {
+            color: var(--report-bad);
             font-weight: bold;
-            color: #06b6d4;
-            margin-bottom: 5px;
-        }
-        .stat-label {
-            color: #666;
-            font-size: 0.9em;
-        }
-        .chart-container {
-            padding: 30px;
-            border-bottom: 1px solid #eee;
         }
-        .chart-container:last-child {
-            border-bottom: none;
-        }
-        .chart-title {
-            font-size: 1.5em;
-            margin-bottom: 20px;
-            color: #333;
-            text-align: center;
+        .snapshot-nav {
+            display: flex;
+            gap: 8px;
+            align-items: center;
+            margin-bottom: 12px;
         }
-        .chart {
-            width: 100%%;
-            height: 400px;
+        .snapshot-cpu {
+            margin-bottom: 12px;
         }
-
     </style>
 </head>
 <body>
@@ -142,45 +171,89 @@ func GenerateIOStatHTML(data *IOStatReportData) (string, error) {
             </div>
         </div>
 
+        <div class="chart-container">
+            <div class="chart-title">Device Percentile Summary</div>
+            %s
+        </div>
+
+        <div class="chart-container">
+            <div class="chart-title">Findings</div>
+            %s
+        </div>
+
         <div class="chart-container">
             <div class="chart-title">CPU Utilization Over Time</div>
-            <div id="cpuChart" class="chart"></div>
+            <div id="cpuChart" class="chart" data-chart="cpu"></div>
         </div>
 
         <div class="chart-container">
             <div class="chart-title">Device I/O Throughput Over Time</div>
-            <div id="ioThroughputChart" class="chart"></div>
+            <div id="ioThroughputChart" class="chart" data-chart="io_throughput"></div>
         </div>
 
+        <div class="chart-container">
+            <div class="chart-title">Per-Device I/O Throughput (Stacked)</div>
+            <div id="perDeviceThroughputChart" class="chart" data-chart="per_device_throughput"></div>
+        </div>
+
+        <div class="chart-container">
+            <div class="chart-title">Device %%util Heatmap</div>
+            <div id="utilHeatmapChart" class="chart" data-chart="util_heatmap" style="height: %dpx"></div>
+        </div>
 
 
         <div class="chart-container">
             <div class="chart-title">Device I/O Await Times</div>
-            <div id="deviceAwaitChart" class="chart"></div>
+            <div id="deviceAwaitChart" class="chart" data-chart="device_await"></div>
         </div>
 
         <div class="chart-container">
             <div class="chart-title">Device Average Queue Size</div>
-            <div id="deviceQueueChart" class="chart"></div>
+            <div id="deviceQueueChart" class="chart" data-chart="device_queue"></div>
         </div>
 
         <div class="chart-container">
             <div class="chart-title">Device I/O Requests Per Second</div>
-            <div id="deviceRequestsChart" class="chart"></div>
+            <div id="deviceRequestsChart" class="chart" data-chart="device_requests"></div>
         </div>
 
         <div class="chart-container">
             <div class="chart-title">Device I/O Request Sizes</div>
-            <div id="deviceRequestSizeChart" class="chart"></div>
+            <div id="deviceRequestSizeChart" class="chart" data-chart="device_request_size"></div>
         </div>
 
-
+        <div id="snapshotDetails" class="chart-container" style="display: none">
+            <div class="chart-title">Snapshot Details</div>
+            <div class="snapshot-nav">
+                <button type="button" id="snapshotPrev">&larr;</button>
+                <select id="snapshotSelect"></select>
+                <button type="button" id="snapshotNext">&rarr;</button>
+            </div>
+            <div id="snapshotCPU" class="snapshot-cpu"></div>
+            <table class="summary-table snapshot-table">
+                <thead>
+                    <tr>
+                        <th>Device</th>
+                        <th>r/s</th><th>rkB/s</th><th>r_await (ms)</th><th>rareq-sz</th>
+                        <th>w/s</th><th>wkB/s</th><th>w_await (ms)</th><th>wareq-sz</th>
+                        <th>aqu-sz</th><th>%%util</th>
+                    </tr>
+                </thead>
+                <tbody id="snapshotDevices"></tbody>
+            </table>
+        </div>
     </div>
 
+    <script type="application/json" id="iostatSnapshotData">%s</script>
+    %s
+    %s
+
     <script>
         try {
+            const timeAxisName = '%s';
+
             // CPU Utilization Chart
-            const cpuChart = echarts.init(document.getElementById('cpuChart'));
+            const cpuChart = echarts.init(document.getElementById('cpuChart'), 'ddd');
             const cpuOption = {
                 tooltip: {
                     trigger: 'axis',
@@ -199,8 +272,11 @@ func GenerateIOStatHTML(data *IOStatReportData) (string, error) {
                 },
                 xAxis: {
                     type: 'category',
-                    boundaryGap: false,
-                    data: %s
+                    name: timeAxisName,
+                    nameLocation: 'middle',
+                    nameGap: 30,
+                    triggerEvent: true,
+                    boundaryGap: false
                 },
                 yAxis: {
                     type: 'value',
@@ -208,12 +284,12 @@ func GenerateIOStatHTML(data *IOStatReportData) (string, error) {
                     min: 0,
                     max: 100
                 },
-                series: %s
+                series: []
             };
             cpuChart.setOption(cpuOption);
 
             // I/O Throughput Chart
-            const ioThroughputChart = echarts.init(document.getElementById('ioThroughputChart'));
+            const ioThroughputChart = echarts.init(document.getElementById('ioThroughputChart'), 'ddd');
             const ioThroughputOption = {
                 tooltip: {
                     trigger: 'axis',
@@ -232,21 +308,120 @@ func GenerateIOStatHTML(data *IOStatReportData) (string, error) {
                 },
                 xAxis: {
                     type: 'category',
-                    boundaryGap: false,
-                    data: %s
+                    name: timeAxisName,
+                    nameLocation: 'middle',
+                    nameGap: 30,
+                    triggerEvent: true,
+                    boundaryGap: false
                 },
                 yAxis: {
                     type: 'value',
                     name: 'KB/s'
                 },
-                series: %s
+                series: []
             };
             ioThroughputChart.setOption(ioThroughputOption);
 
+            // Per-Device I/O Throughput Chart, reads and writes stacked separately by device
+            const perDeviceThroughputChart = echarts.init(document.getElementById('perDeviceThroughputChart'), 'ddd');
+            const perDeviceThroughputOption = {
+                tooltip: {
+                    trigger: 'axis',
+                    axisPointer: {
+                        type: 'cross'
+                    }
+                },
+                legend: {
+                    type: 'scroll'
+                },
+                grid: {
+                    left: '3%%',
+                    right: '4%%',
+                    bottom: '3%%',
+                    containLabel: true
+                },
+                xAxis: {
+                    type: 'category',
+                    name: timeAxisName,
+                    nameLocation: 'middle',
+                    nameGap: 30,
+                    triggerEvent: true,
+                    boundaryGap: false
+                },
+                yAxis: {
+                    type: 'value',
+                    name: 'KB/s'
+                },
+                series: []
+            };
+            perDeviceThroughputChart.setOption(perDeviceThroughputOption);
+
+            // Device %%util Heatmap, one row per device so saturation across many disks shows at a glance.
+            // The colors come from the report theme so re-theming a stored report restyles it
+            const themeStyle = getComputedStyle(document.documentElement);
+            const utilHeatmapChart = echarts.init(document.getElementById('utilHeatmapChart'), 'ddd');
+            const utilHeatmapOption = {
+                tooltip: {
+                    position: 'top',
+                    formatter: function(params) {
+                        return params.value[1] === undefined ? '' :
+                            utilHeatmapChart.getOption().yAxis[0].data[params.value[1]] + ' at ' +
+                            utilHeatmapChart.getOption().xAxis[0].data[params.value[0]] + ': ' + params.value[2] + '%%';
+                    }
+                },
+                grid: {
+                    left: '3%%',
+                    right: '4%%',
+                    bottom: 70,
+                    containLabel: true
+                },
+                xAxis: {
+                    type: 'category',
+                    name: timeAxisName,
+                    nameLocation: 'middle',
+                    nameGap: 30,
+                    triggerEvent: true,
+                    splitArea: {
+                        show: true
+                    }
+                },
+                yAxis: {
+                    type: 'category',
+                    splitArea: {
+                        show: true
+                    }
+                },
+                visualMap: {
+                    min: 0,
+                    max: 100,
+                    calculable: true,
+                    orient: 'horizontal',
+                    left: 'center',
+                    bottom: 0,
+                    text: ['100%%', '0%%'],
+                    inRange: {
+                        color: [themeStyle.getPropertyValue('--report-good').trim() || '#2e7d32', '#fac858',
+                            themeStyle.getPropertyValue('--report-bad').trim() || '#d32f2f']
+                    }
+                },
+                series: [{
+                    name: '%%util',
+                    type: 'heatmap',
+                    data: [],
+                    emphasis: {
+                        itemStyle: {
+                            shadowBlur: 10,
+                            shadowColor: 'rgba(0, 0, 0, 0.5)'
+                        }
+                    }
+                }]
+            };
+            utilHeatmapChart.setOption(utilHeatmapOption);
+
 
 
             // Device I/O Await Chart
-            const deviceAwaitChart = echarts.init(document.getElementById('deviceAwaitChart'));
+            const deviceAwaitChart = echarts.init(document.getElementById('deviceAwaitChart'), 'ddd');
             const deviceAwaitOption = {
                 tooltip: {
                     trigger: 'axis',
@@ -254,9 +429,7 @@ func GenerateIOStatHTML(data *IOStatReportData) (string, error) {
                         type: 'cross'
                     }
                 },
-                legend: {
-                    data: %s
-                },
+                legend: {},
                 grid: {
                     left: '3%%',
                     right: '4%%',
@@ -265,19 +438,22 @@ func GenerateIOStatHTML(data *IOStatReportData) (string, error) {
                 },
                 xAxis: {
                     type: 'category',
-                    boundaryGap: false,
-                    data: %s
+                    name: timeAxisName,
+                    nameLocation: 'middle',
+                    nameGap: 30,
+                    triggerEvent: true,
+                    boundaryGap: false
                 },
                 yAxis: {
                     type: 'value',
                     name: 'Await Time (ms)'
                 },
-                series: %s
+                series: []
             };
             deviceAwaitChart.setOption(deviceAwaitOption);
 
             // Device Queue Size Chart
-            const deviceQueueChart = echarts.init(document.getElementById('deviceQueueChart'));
+            const deviceQueueChart = echarts.init(document.getElementById('deviceQueueChart'), 'ddd');
             const deviceQueueOption = {
                 tooltip: {
                     trigger: 'axis',
@@ -285,9 +461,7 @@ func GenerateIOStatHTML(data *IOStatReportData) (string, error) {
                         type: 'cross'
                     }
                 },
-                legend: {
-                    data: %s
-                },
+                legend: {},
                 grid: {
                     left: '3%%',
                     right: '4%%',
@@ -296,19 +470,22 @@ func GenerateIOStatHTML(data *IOStatReportData) (string, error) {
                 },
                 xAxis: {
                     type: 'category',
-                    boundaryGap: false,
-                    data: %s
+                    name: timeAxisName,
+                    nameLocation: 'middle',
+                    nameGap: 30,
+                    triggerEvent: true,
+                    boundaryGap: false
                 },
                 yAxis: {
                     type: 'value',
                     name: 'Queue Size'
                 },
-                series: %s
+                series: []
             };
             deviceQueueChart.setOption(deviceQueueOption);
 
             // Device Requests Chart
-            const deviceRequestsChart = echarts.init(document.getElementById('deviceRequestsChart'));
+            const deviceRequestsChart = echarts.init(document.getElementById('deviceRequestsChart'), 'ddd');
             const deviceRequestsOption = {
                 tooltip: {
                     trigger: 'axis',
@@ -316,9 +493,7 @@ func GenerateIOStatHTML(data *IOStatReportData) (string, error) {
                         type: 'cross'
                     }
                 },
-                legend: {
-                    data: %s
-                },
+                legend: {},
                 grid: {
                     left: '3%%',
                     right: '4%%',
@@ -327,19 +502,22 @@ func GenerateIOStatHTML(data *IOStatReportData) (string, error) {
                 },
                 xAxis: {
                     type: 'category',
-                    boundaryGap: false,
-                    data: %s
+                    name: timeAxisName,
+                    nameLocation: 'middle',
+                    nameGap: 30,
+                    triggerEvent: true,
+                    boundaryGap: false
                 },
                 yAxis: {
                     type: 'value',
                     name: 'Requests/sec'
                 },
-                series: %s
+                series: []
             };
             deviceRequestsChart.setOption(deviceRequestsOption);
 
             // Device Request Size Chart
-            const deviceRequestSizeChart = echarts.init(document.getElementById('deviceRequestSizeChart'));
+            const deviceRequestSizeChart = echarts.init(document.getElementById('deviceRequestSizeChart'), 'ddd');
             const deviceRequestSizeOption = {
                 tooltip: {
                     trigger: 'axis',
@@ -347,9 +525,7 @@ func GenerateIOStatHTML(data *IOStatReportData) (string, error) {
                         type: 'cross'
                     }
                 },
-                legend: {
-                    data: %s
-                },
+                legend: {},
                 grid: {
                     left: '3%%',
                     right: '4%%',
@@ -358,21 +534,174 @@ func GenerateIOStatHTML(data *IOStatReportData) (string, error) {
                 },
                 xAxis: {
                     type: 'category',
-                    boundaryGap: false,
-                    data: %s
+                    name: timeAxisName,
+                    nameLocation: 'middle',
+                    nameGap: 30,
+                    triggerEvent: true,
+                    boundaryGap: false
                 },
                 yAxis: {
                     type: 'value',
                     name: 'Request Size (KB)'
                 },
-                series: %s
+                series: []
             };
             deviceRequestSizeChart.setOption(deviceRequestSizeOption);
 
+            // Chart data is loaded as each chart scrolls into view rather than carried in the page,
+            // from the DDD server or, in a standalone export, the report data embedded at its end
+            let embeddedChartData;
+
+            function fetchChartData(name) {
+                const embedded = document.getElementById('ddd-report-data');
+                if (embedded) {
+                    embeddedChartData = embeddedChartData || JSON.parse(embedded.textContent).chart_data || {};
+                    return embeddedChartData[name] ? Promise.resolve(embeddedChartData[name]) :
+                        Promise.reject(new Error('the report has no data for this chart'));
+                }
+                if (!dddReportID) {
+                    return Promise.reject(new Error('open the report in DDD to load this chart'));
+                }
+                return fetch('/api/reports/' + dddReportID + '/chart/' + name).then(function(response) {
+                    return response.json().then(function(result) {
+                        if (!result.success) {
+                            throw new Error(result.error ? result.error.message : 'HTTP ' + response.status);
+                        }
+                        return result.chart;
+                    });
+                });
+            }
+
+            function loadChart(chart) {
+                chart.showLoading();
+                fetchChartData(chart.getDom().dataset.chart).then(function(data) {
+                    const option = { xAxis: { data: data.labels }, series: data.series };
+                    if (data.legend) {
+                        option.legend = { data: data.legend };
+                    }
+                    if (data.devices) {
+                        option.yAxis = { data: data.devices };
+                    }
+                    chart.hideLoading();
+                    chart.setOption(option);
+                    dddAnnotate(chart);
+                }).catch(function(error) {
+                    chart.showLoading({ text: 'Failed to load chart: ' + error.message, showSpinner: false });
+                });
+            }
+
+            const lazyCharts = [cpuChart, ioThroughputChart, perDeviceThroughputChart, utilHeatmapChart,
+                deviceAwaitChart, deviceQueueChart, deviceRequestsChart, deviceRequestSizeChart];
+            function observeCharts() {
+                if (!('IntersectionObserver' in window)) {
+                    lazyCharts.forEach(loadChart);
+                    return;
+                }
+                const observer = new IntersectionObserver(function(entries) {
+                    entries.forEach(function(entry) {
+                        if (entry.isIntersecting) {
+                            observer.unobserve(entry.target);
+                            loadChart(echarts.getInstanceByDom(entry.target));
+                        }
+                    });
+                }, { rootMargin: '200px' });
+                lazyCharts.forEach(function(chart) {
+                    observer.observe(chart.getDom());
+                });
+            }
+            // A standalone export embeds its data after this script
+            if (document.readyState === 'loading') {
+                document.addEventListener('DOMContentLoaded', observeCharts);
+            } else {
+                observeCharts();
+            }
+
+            // Snapshot drill-down, clicking a time on any chart shows every device of the first
+            // snapshot behind that point. Built with textContent since device names come from the capture
+            const snapshotData = JSON.parse(document.getElementById('iostatSnapshotData').textContent);
+            const snapshotDetails = document.getElementById('snapshotDetails');
+            const snapshotSelect = document.getElementById('snapshotSelect');
+            snapshotData.snapshots.forEach(function(snapshot, index) {
+                snapshotSelect.add(new Option(snapshot.time, index));
+            });
+
+            function showSnapshot(index) {
+                const snapshot = snapshotData.snapshots[index];
+                if (!snapshot) {
+                    return;
+                }
+                snapshotSelect.value = index;
+                const cpu = snapshot.cpu_stats;
+                document.getElementById('snapshotCPU').textContent = cpu ?
+                    'CPU: user ' + cpu.user.toFixed(2) + '%%, system ' + cpu.system.toFixed(2) + '%%, iowait ' +
+                    cpu.iowait.toFixed(2) + '%%, steal ' + cpu.steal.toFixed(2) + '%%, idle ' + cpu.idle.toFixed(2) + '%%' :
+                    'No CPU statistics in this snapshot';
+
+                const tbody = document.getElementById('snapshotDevices');
+                tbody.replaceChildren();
+                snapshot.devices.forEach(function(device) {
+                    const row = tbody.insertRow();
+                    row.insertCell().textContent = device.device;
+                    [
+                        [device.reads_per_s], [device.read_kb_per_s], [device.read_await, snapshotData.await_ms], [device.read_req_size],
+                        [device.writes_per_s], [device.write_kb_per_s], [device.write_await, snapshotData.await_ms], [device.write_req_size],
+                        [device.avg_queue_size], [device.utilization, snapshotData.utilization_pct]
+                    ].forEach(function(column) {
+                        const cell = row.insertCell();
+                        cell.textContent = column[0].toFixed(2);
+                        if (column[1] !== undefined && column[0] > column[1]) {
+                            cell.className = 'breach';
+                        }
+                    });
+                });
+                if (snapshot.devices.length === 0) {
+                    const cell = tbody.insertRow().insertCell();
+                    cell.colSpan = 11;
+                    cell.textContent = 'No device statistics in this snapshot';
+                }
+                snapshotDetails.style.display = '';
+            }
+
+            function showChartPoint(point) {
+                if (point < 0 || point >= snapshotData.points.length) {
+                    return;
+                }
+                showSnapshot(snapshotData.points[point]);
+                snapshotDetails.scrollIntoView({ behavior: 'smooth', block: 'nearest' });
+            }
+
+            snapshotSelect.addEventListener('change', function() {
+                showSnapshot(Number(snapshotSelect.value));
+            });
+            document.getElementById('snapshotPrev').addEventListener('click', function() {
+                showSnapshot(Number(snapshotSelect.value) - 1);
+            });
+            document.getElementById('snapshotNext').addEventListener('click', function() {
+                showSnapshot(Number(snapshotSelect.value) + 1);
+            });
+
+            lazyCharts.forEach(function(chart) {
+                // A click anywhere in the plot selects the time under the cursor
+                chart.getZr().on('click', function(event) {
+                    const pixel = [event.offsetX, event.offsetY];
+                    if (chart.containPixel('grid', pixel)) {
+                        showChartPoint(Math.round(chart.convertFromPixel('grid', pixel)[0]));
+                    }
+                });
+                // and so does a click on a time label of the x-axis
+                chart.on('click', function(params) {
+                    if (params.componentType === 'xAxis') {
+                        showChartPoint(chart.getOption().xAxis[0].data.indexOf(params.value));
+                    }
+                });
+            });
+
             // Handle window resize
             window.addEventListener('resize', function() {
                 cpuChart.resize();
                 ioThroughputChart.resize();
+                perDeviceThroughputChart.resize();
+                utilHeatmapChart.resize();
                 deviceAwaitChart.resize();
                 deviceQueueChart.resize();
                 deviceRequestsChart.resize();
@@ -386,30 +715,89 @@ func GenerateIOStatHTML(data *IOStatReportData) (string, error) {
     </script>
 </body>
 </html>`,
+		theme.headHTML(),
 		len(data.Snapshots),
 		countUniqueDevices(data),
 		findPeakCPUUsage(data),
 		findPeakDeviceQueueSize(data),
-		labels,
-		cpuData,
-		labels,
-		ioThroughputData,
-		extractDeviceAwaitLegendData(data),
-		labels,
-		extractDeviceAwaitSeriesData(data),
-		extractDeviceQueueLegendData(data),
-		labels,
-		extractDeviceQueueSeriesData(data),
-		extractDeviceRequestsLegendData(data),
-		labels,
-		extractDeviceRequestsSeriesData(data),
-		extractDeviceRequestSizeLegendData(data),
-		labels,
-		extractDeviceRequestSizeSeriesData(data))
+		generateDeviceSummaryTableHTML(summarizeDevices(data)),
+		generateFindingsHTML(findings),
+		utilHeatmapHeight(countUniqueDevices(data)),
+		snapshotData,
+		chartTimesData,
+		reportAnnotationsScript,
+		timeAxisName(data.Snapshots[0].Timestamp))
 
 	return html, nil
 }
 
+// IOStatChart is the data one chart of an iostat report loads when it scrolls into view
+type IOStatChart struct {
+	Labels  json.RawMessage `json:"labels"`            // Time of each chart point
+	Legend  json.RawMessage `json:"legend,omitempty"`  // Series names, for charts whose legend depends on the devices
+	Devices json.RawMessage `json:"devices,omitempty"` // Heatmap rows
+	Series  json.RawMessage `json:"series"`            // echarts series, merged into the chart's own
+}
+
+// IOStatChartNames names the charts of an iostat report in page order
+var IOStatChartNames = []string{
+	"cpu",
+	"io_throughput",
+	"per_device_throughput",
+	"util_heatmap",
+	"device_await",
+	"device_queue",
+	"device_requests",
+	"device_request_size",
+}
+
+// GenerateIOStatChartData generates the data of every chart of the report that
+// GenerateIOStatHTMLWithMaxPoints generates for the same arguments, keyed by the names in
+// IOStatChartNames. Reports store it next to the HTML report so each chart can be fetched
+// on its own rather than the page carrying every series of a long capture
+func GenerateIOStatChartData(data *IOStatReportData, thresholds IOStatThresholds, maxPoints int) map[string]IOStatChart {
+	if data == nil || len(data.Snapshots) == 0 {
+		return map[string]IOStatChart{}
+	}
+
+	chartData := downsampleIOStatData(data, maxPoints)
+	labels := json.RawMessage(extractIOStatTimeLabels(chartData))
+	return map[string]IOStatChart{
+		"cpu":           {Labels: labels, Series: json.RawMessage(extractCPUSeriesData(chartData))},
+		"io_throughput": {Labels: labels, Series: json.RawMessage(extractIOThroughputSeriesData(chartData))},
+		"per_device_throughput": {
+			Labels: labels,
+			Legend: json.RawMessage(extractPerDeviceThroughputLegendData(chartData)),
+			Series: json.RawMessage(extractPerDeviceThroughputSeriesData(chartData)),
+		},
+		"util_heatmap": {
+			Labels:  labels,
+			Devices: json.RawMessage(extractUtilHeatmapDeviceData(chartData)),
+			Series:  json.RawMessage(`[{"data": ` + extractUtilHeatmapData(chartData) + `}]`),
+		},
+		"device_await": {
+			Labels: labels,
+			Legend: json.RawMessage(extractDeviceAwaitLegendData(chartData)),
+			Series: json.RawMessage(extractDeviceAwaitSeriesData(chartData, thresholds)),
+		},
+		"device_queue": {
+			Labels: labels,
+			Legend: json.RawMessage(extractDeviceQueueLegendData(chartData)),
+			Series: json.RawMessage(extractDeviceQueueSeriesData(chartData, thresholds)),
+		},
+		"device_requests": {
+			Labels: labels,
+			Legend: json.RawMessage(extractDeviceRequestsLegendData(chartData)),
+			Series: json.RawMessage(extractDeviceRequestsSeriesData(chartData)),
+		},
+		"device_request_size": {
+			Labels: labels,
+			Legend: json.RawMessage(extractDeviceRequestSizeLegendData(chartData)),
+			Series: json.RawMessage(extractDeviceRequestSizeSeriesData(chartData)),
+		},
+	}
+}
+
 // generateEmptyIOStatHTML generates HTML for empty iostat data
 func generateEmptyIOStatHTML() string {
 	return `<!DOCTYPE html>
@@ -454,6 +842,42 @@ func generateEmptyIOStatHTML() string {
 </html>`
 }
 
+// ioStatSnapshotDetail is one snapshot as embedded in the report for the drill-down table
+type ioStatSnapshotDetail struct {
+	Time     string        `json:"time"`
+	CPUStats *CPUStats     `json:"cpu_stats"`
+	Devices  []DeviceStats `json:"devices"`
+}
+
+// extractIOStatSnapshotDetails returns every snapshot of data as JSON for the drill-down
+// table, with the index of the first snapshot behind each of the chartPoints chart points
+// and the thresholds breaching cells are highlighted with
+func extractIOStatSnapshotDetails(data *IOStatReportData, thresholds IOStatThresholds, chartPoints int) (string, error) {
+	snapshots := make([]ioStatSnapshotDetail, len(data.Snapshots))
+	for i, snapshot := range data.Snapshots {
+		devices := snapshot.Devices
+		if devices == nil {
+			devices = []DeviceStats{}
+		}
+		snapshots[i] = ioStatSnapshotDetail{
+			Time:     snapshot.Timestamp.Format("2006-01-02 15:04:05"),
+			CPUStats: snapshot.CPUStats,
+			Devices:  devices,
+		}
+	}
+
+	// json.Marshal escapes <, > and & so device names can't close the script the data is embedded in
+	details, err := json.Marshal(struct {
+		IOStatThresholds
+		Snapshots []ioStatSnapshotDetail `json:"snapshots"`
+		Points    []int                  `json:"points"`
+	}{thresholds, snapshots, downsampleBucketStarts(len(data.Snapshots), chartPoints)})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal iostat snapshots: %w", err)
+	}
+	return string(details), nil
+}
+
 // extractIOStatTimeLabels extracts time labels for chart x-axis
 func extractIOStatTimeLabels(data *IOStatReportData) string {
 	var labels []string
@@ -486,28 +910,28 @@ func extractCPUSeriesData(data *IOStatReportData) string {
 
 	series := []string{
 		fmt.Sprintf(`{
-			name: "User",
-			type: "line",
-			data: [%s],
-			smooth: true
+			"name": "User",
+			"type": "line",
+			"data": [%s],
+			"smooth": true
 		}`, strings.Join(userData, ", ")),
 		fmt.Sprintf(`{
-			name: "System",
-			type: "line",
-			data: [%s],
-			smooth: true
+			"name": "System",
+			"type": "line",
+			"data": [%s],
+			"smooth": true
 		}`, strings.Join(systemData, ", ")),
 		fmt.Sprintf(`{
-			name: "IOWait",
-			type: "line",
-			data: [%s],
-			smooth: true
+			"name": "IOWait",
+			"type": "line",
+			"data": [%s],
+			"smooth": true
 		}`, strings.Join(iowaitData, ", ")),
 		fmt.Sprintf(`{
-			name: "Idle",
-			type: "line",
-			data: [%s],
-			smooth: true
+			"name": "Idle",
+			"type": "line",
+			"data": [%s],
+			"smooth": true
 		}`, strings.Join(idleData, ", ")),
 	}
 
@@ -535,31 +959,133 @@ func extractIOThroughputSeriesData(data *IOStatReportData) string {
 
 	series := []string{
 		fmt.Sprintf(`{
-			name: "Read KB/s",
-			type: "line",
-			data: [%s],
-			smooth: true
+			"name": "Read KB/s",
+			"type": "line",
+			"data": [%s],
+			"smooth": true
 		}`, strings.Join(readData, ", ")),
 		fmt.Sprintf(`{
-			name: "Write KB/s",
-			type: "line",
-			data: [%s],
-			smooth: true
+			"name": "Write KB/s",
+			"type": "line",
+			"data": [%s],
+			"smooth": true
 		}`, strings.Join(writeData, ", ")),
 	}
 
 	return fmt.Sprintf("[%s]", strings.Join(series, ", "))
 }
 
+// extractPerDeviceThroughputLegendData extracts legend data for the per-device throughput chart
+func extractPerDeviceThroughputLegendData(data *IOStatReportData) string {
+	var legends []string
+	for _, device := range ioStatDeviceNames(data) {
+		legends = append(legends, jsonString(device+" Read KB/s"))
+		legends = append(legends, jsonString(device+" Write KB/s"))
+	}
+
+	return fmt.Sprintf("[%s]", strings.Join(legends, ", "))
+}
+
+// extractPerDeviceThroughputSeriesData extracts read and write throughput per device as
+// stacked areas, so each device is a band and the top of each stack is the aggregate total
+func extractPerDeviceThroughputSeriesData(data *IOStatReportData) string {
+	var series []string
+	for _, device := range ioStatDeviceNames(data) {
+		readData := make([]string, len(data.Snapshots))
+		writeData := make([]string, len(data.Snapshots))
+
+		for i, snapshot := range data.Snapshots {
+			read := 0.0
+			write := 0.0
+
+			for _, d := range snapshot.Devices {
+				if d.Device == device {
+					read = d.ReadKBPerS
+					write = d.WriteKBPerS
+					break
+				}
+			}
+
+			readData[i] = fmt.Sprintf("%.1f", read)
+			writeData[i] = fmt.Sprintf("%.1f", write)
+		}
+
+		series = append(series, fmt.Sprintf(`{
+			"name": %s,
+			"type": "line",
+			"stack": "read",
+			"areaStyle": { "opacity": 0.4 },
+			"emphasis": { "focus": "series" },
+			"data": [%s]
+		}`, jsonString(device+" Read KB/s"), strings.Join(readData, ", ")))
+
+		series = append(series, fmt.Sprintf(`{
+			"name": %s,
+			"type": "line",
+			"stack": "write",
+			"areaStyle": { "opacity": 0.4 },
+			"emphasis": { "focus": "series" },
+			"data": [%s]
+		}`, jsonString(device+" Write KB/s"), strings.Join(writeData, ", ")))
+	}
+
+	return fmt.Sprintf("[%s]", strings.Join(series, ", "))
+}
+
+// utilHeatmapHeight sizes the %util heatmap so each device row stays readable on boxes with
+// dozens of disks, never shorter than the other charts
+func utilHeatmapHeight(deviceCount int) int {
+	return max(400, 120+deviceCount*24)
+}
+
+// extractUtilHeatmapDeviceData extracts the device names for the rows of the %util heatmap
+func extractUtilHeatmapDeviceData(data *IOStatReportData) string {
+	var devices []string
+	for _, device := range ioStatDeviceNames(data) {
+		devices = append(devices, jsonString(device))
+	}
+	return fmt.Sprintf("[%s]", strings.Join(devices, ", "))
+}
+
+// extractUtilHeatmapData extracts the %util of every device in every snapshot as
+// [time index, device index, %util] heatmap cells. Snapshots missing a device leave its
+// cell empty rather than showing it idle
+func extractUtilHeatmapData(data *IOStatReportData) string {
+	var cells []string
+	for y, device := range ioStatDeviceNames(data) {
+		for x, snapshot := range data.Snapshots {
+			for _, d := range snapshot.Devices {
+				if d.Device == device {
+					cells = append(cells, fmt.Sprintf("[%d, %d, %.1f]", x, y, d.Utilization))
+					break
+				}
+			}
+		}
+	}
+	return fmt.Sprintf("[%s]", strings.Join(cells, ", "))
+}
+
 // countUniqueDevices counts the number of unique devices across all snapshots
 func countUniqueDevices(data *IOStatReportData) int {
+	return len(ioStatDeviceNames(data))
+}
+
+// ioStatDeviceNames returns the names of every device seen in any snapshot, sorted so
+// chart series and stacking order are stable between runs
+func ioStatDeviceNames(data *IOStatReportData) []string {
 	deviceSet := make(map[string]bool)
 	for _, snapshot := range data.Snapshots {
 		for _, device := range snapshot.Devices {
 			deviceSet[device.Device] = true
 		}
 	}
-	return len(deviceSet)
+
+	devices := make([]string, 0, len(deviceSet))
+	for device := range deviceSet {
+		devices = append(devices, device)
+	}
+	sort.Strings(devices)
+	return devices
 }
 
 // findPeakCPUUsage finds the peak CPU usage (100 - idle) across all snapshots
@@ -589,37 +1115,236 @@ func findPeakDeviceQueueSize(data *IOStatReportData) float64 {
 	return peak
 }
 
-// extractDeviceAwaitLegendData extracts legend data for device await charts
-func extractDeviceAwaitLegendData(data *IOStatReportData) string {
-	deviceSet := make(map[string]bool)
+// summarizeDevices computes p50/p95/p99 of %util, r_await, w_await and aqu-sz for each device
+// across all snapshots, so a briefly busy device can be told apart from a chronically saturated one
+func summarizeDevices(data *IOStatReportData) []DeviceSummary {
+	if data == nil {
+		return []DeviceSummary{}
+	}
+
+	type deviceSamples struct {
+		utilization  []float64
+		readAwait    []float64
+		writeAwait   []float64
+		avgQueueSize []float64
+	}
+
+	samples := make(map[string]*deviceSamples)
 	for _, snapshot := range data.Snapshots {
 		for _, device := range snapshot.Devices {
-			deviceSet[device.Device] = true
+			s, exists := samples[device.Device]
+			if !exists {
+				s = &deviceSamples{}
+				samples[device.Device] = s
+			}
+			s.utilization = append(s.utilization, device.Utilization)
+			s.readAwait = append(s.readAwait, device.ReadAwait)
+			s.writeAwait = append(s.writeAwait, device.WriteAwait)
+			s.avgQueueSize = append(s.avgQueueSize, device.AvgQueueSize)
 		}
 	}
 
-	var legends []string
-	for device := range deviceSet {
-		legends = append(legends, fmt.Sprintf(`"%s Read Await"`, device))
-		legends = append(legends, fmt.Sprintf(`"%s Write Await"`, device))
+	// Sort device names so the table order is stable between runs
+	devices := make([]string, 0, len(samples))
+	for device := range samples {
+		devices = append(devices, device)
+	}
+	sort.Strings(devices)
+
+	summaries := make([]DeviceSummary, 0, len(devices))
+	for _, device := range devices {
+		s := samples[device]
+		summaries = append(summaries, DeviceSummary{
+			Device:       device,
+			Samples:      len(s.utilization),
+			Utilization:  computePercentiles(s.utilization),
+			ReadAwait:    computePercentiles(s.readAwait),
+			WriteAwait:   computePercentiles(s.writeAwait),
+			AvgQueueSize: computePercentiles(s.avgQueueSize),
+		})
 	}
+	return summaries
+}
 
-	return fmt.Sprintf("[%s]", strings.Join(legends, ", "))
+// computePercentiles returns the p50/p95/p99 values of the given samples
+func computePercentiles(values []float64) PercentileStats {
+	if len(values) == 0 {
+		return PercentileStats{}
+	}
+
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	return PercentileStats{
+		P50: percentile(sorted, 50),
+		P95: percentile(sorted, 95),
+		P99: percentile(sorted, 99),
+	}
 }
 
-// extractDeviceAwaitSeriesData extracts device await time data for charts
-func extractDeviceAwaitSeriesData(data *IOStatReportData) string {
-	deviceSet := make(map[string]bool)
-	for _, snapshot := range data.Snapshots {
+// percentile returns the p-th percentile of an already sorted slice using linear interpolation
+// between the closest ranks
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	if len(sorted) == 1 {
+		return sorted[0]
+	}
+
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	if lower == upper {
+		return sorted[lower]
+	}
+
+	weight := rank - float64(lower)
+	return sorted[lower] + (sorted[upper]-sorted[lower])*weight
+}
+
+// generateDeviceSummaryTableHTML renders the device percentile summaries as an HTML table
+func generateDeviceSummaryTableHTML(summaries []DeviceSummary) string {
+	if len(summaries) == 0 {
+		return `<p>No device statistics available.</p>`
+	}
+
+	var rows []string
+	for _, summary := range summaries {
+		rows = append(rows, fmt.Sprintf(`                <tr>
+                    <td>%s</td>
+                    <td>%d</td>
+                    <td>%.2f</td><td>%.2f</td><td>%.2f</td>
+                    <td>%.2f</td><td>%.2f</td><td>%.2f</td>
+                    <td>%.2f</td><td>%.2f</td><td>%.2f</td>
+                    <td>%.2f</td><td>%.2f</td><td>%.2f</td>
+                </tr>`,
+			html.EscapeString(summary.Device),
+			summary.Samples,
+			summary.Utilization.P50, summary.Utilization.P95, summary.Utilization.P99,
+			summary.ReadAwait.P50, summary.ReadAwait.P95, summary.ReadAwait.P99,
+			summary.WriteAwait.P50, summary.WriteAwait.P95, summary.WriteAwait.P99,
+			summary.AvgQueueSize.P50, summary.AvgQueueSize.P95, summary.AvgQueueSize.P99))
+	}
+
+	return fmt.Sprintf(`<table class="summary-table">
+            <thead>
+                <tr>
+                    <th rowspan="2">Device</th>
+                    <th rowspan="2">Samples</th>
+                    <th colspan="3">%%util</th>
+                    <th colspan="3">r_await (ms)</th>
+                    <th colspan="3">w_await (ms)</th>
+                    <th colspan="3">aqu-sz</th>
+                </tr>
+                <tr>
+                    <th>p50</th><th>p95</th><th>p99</th>
+                    <th>p50</th><th>p95</th><th>p99</th>
+                    <th>p50</th><th>p95</th><th>p99</th>
+                    <th>p50</th><th>p95</th><th>p99</th>
+                </tr>
+            </thead>
+            <tbody>
+%s
+            </tbody>
+        </table>`, strings.Join(rows, "\n"))
+}
+
+// findIOStatThresholdBreaches returns every device sample whose %util, r_await or w_await
+// is above the configured thresholds, in snapshot order
+func findIOStatThresholdBreaches(data *IOStatReportData, thresholds IOStatThresholds) []IOStatFinding {
+	findings := make([]IOStatFinding, 0)
+	if data == nil {
+		return findings
+	}
+
+	for i, snapshot := range data.Snapshots {
 		for _, device := range snapshot.Devices {
-			deviceSet[device.Device] = true
+			checks := []struct {
+				metric    string
+				value     float64
+				threshold float64
+			}{
+				{"%util", device.Utilization, thresholds.UtilizationPct},
+				{"r_await", device.ReadAwait, thresholds.AwaitMs},
+				{"w_await", device.WriteAwait, thresholds.AwaitMs},
+			}
+
+			for _, check := range checks {
+				if check.value > check.threshold {
+					findings = append(findings, IOStatFinding{
+						Timestamp:     snapshot.Timestamp,
+						SnapshotIndex: i,
+						Device:        device.Device,
+						Metric:        check.metric,
+						Value:         check.value,
+						Threshold:     check.threshold,
+					})
+				}
+			}
 		}
 	}
+	return findings
+}
+
+// generateFindingsHTML renders the threshold breaches as an HTML table
+func generateFindingsHTML(findings []IOStatFinding) string {
+	if len(findings) == 0 {
+		return `<p class="findings-ok">No threshold breaches detected.</p>`
+	}
+
+	var rows []string
+	for _, finding := range findings {
+		rows = append(rows, fmt.Sprintf(`                <tr>
+                    <td>%s</td>
+                    <td>%s</td>
+                    <td>%s</td>
+                    <td class="breach">%.2f</td>
+                    <td>%.2f</td>
+                </tr>`,
+			finding.Timestamp.Format("2006-01-02 15:04:05"),
+			html.EscapeString(finding.Device),
+			finding.Metric,
+			finding.Value,
+			finding.Threshold))
+	}
+
+	return fmt.Sprintf(`<table class="summary-table findings-table">
+            <thead>
+                <tr>
+                    <th>Timestamp</th>
+                    <th>Device</th>
+                    <th>Metric</th>
+                    <th>Value</th>
+                    <th>Threshold</th>
+                </tr>
+            </thead>
+            <tbody>
+%s
+            </tbody>
+        </table>`, strings.Join(rows, "\n"))
+}
+
+// extractDeviceAwaitLegendData extracts legend data for device await charts
+func extractDeviceAwaitLegendData(data *IOStatReportData) string {
+	var legends []string
+	for _, device := range ioStatDeviceNames(data) {
+		legends = append(legends, jsonString(device+" Read Await"))
+		legends = append(legends, jsonString(device+" Write Await"))
+	}
 
+	return fmt.Sprintf("[%s]", strings.Join(legends, ", "))
+}
+
+// extractDeviceAwaitSeriesData extracts device await time data for charts, marking samples
+// above the await threshold and drawing the threshold as a line
+func extractDeviceAwaitSeriesData(data *IOStatReportData, thresholds IOStatThresholds) string {
 	var series []string
-	for device := range deviceSet {
+	for _, device := range ioStatDeviceNames(data) {
 		readAwaitData := make([]string, len(data.Snapshots))
 		writeAwaitData := make([]string, len(data.Snapshots))
+		var readBreaches, writeBreaches []string
 
 		for i, snapshot := range data.Snapshots {
 			readAwait := 0.0
@@ -635,21 +1360,31 @@ func extractDeviceAwaitSeriesData(data *IOStatReportData) string {
 
 			readAwaitData[i] = fmt.Sprintf("%.2f", readAwait)
 			writeAwaitData[i] = fmt.Sprintf("%.2f", writeAwait)
+
+			if readAwait > thresholds.AwaitMs {
+				readBreaches = append(readBreaches, fmt.Sprintf(`{ "coord": [%d, %.2f], "value": "%.2f" }`, i, readAwait, readAwait))
+			}
+			if writeAwait > thresholds.AwaitMs {
+				writeBreaches = append(writeBreaches, fmt.Sprintf(`{ "coord": [%d, %.2f], "value": "%.2f" }`, i, writeAwait, writeAwait))
+			}
 		}
 
 		series = append(series, fmt.Sprintf(`{
-			name: "%s Read Await",
-			type: "line",
-			data: [%s],
-			smooth: true
-		}`, device, strings.Join(readAwaitData, ", ")))
+			"name": %s,
+			"type": "line",
+			"data": [%s],
+			"smooth": true,
+			"markPoint": { "itemStyle": { "color": "#d32f2f" }, "data": [%s] },
+			"markLine": { "silent": true, "symbol": "none", "lineStyle": { "color": "#d32f2f", "type": "dashed" }, "data": [{ "yAxis": %.2f, "name": "Await threshold" }] }
+		}`, jsonString(device+" Read Await"), strings.Join(readAwaitData, ", "), strings.Join(readBreaches, ", "), thresholds.AwaitMs))
 
 		series = append(series, fmt.Sprintf(`{
-			name: "%s Write Await",
-			type: "line",
-			data: [%s],
-			smooth: true
-		}`, device, strings.Join(writeAwaitData, ", ")))
+			"name": %s,
+			"type": "line",
+			"data": [%s],
+			"smooth": true,
+			"markPoint": { "itemStyle": { "color": "#d32f2f" }, "data": [%s] }
+		}`, jsonString(device+" Write Await"), strings.Join(writeAwaitData, ", "), strings.Join(writeBreaches, ", ")))
 	}
 
 	return fmt.Sprintf("[%s]", strings.Join(series, ", "))
@@ -657,33 +1392,21 @@ func extractDeviceAwaitSeriesData(data *IOStatReportData) string {
 
 // extractDeviceQueueLegendData extracts legend data for device queue charts
 func extractDeviceQueueLegendData(data *IOStatReportData) string {
-	deviceSet := make(map[string]bool)
-	for _, snapshot := range data.Snapshots {
-		for _, device := range snapshot.Devices {
-			deviceSet[device.Device] = true
-		}
-	}
-
 	var legends []string
-	for device := range deviceSet {
-		legends = append(legends, fmt.Sprintf(`"%s Queue Size"`, device))
+	for _, device := range ioStatDeviceNames(data) {
+		legends = append(legends, jsonString(device+" Queue Size"))
 	}
 
 	return fmt.Sprintf("[%s]", strings.Join(legends, ", "))
 }
 
-// extractDeviceQueueSeriesData extracts device queue size data for charts
-func extractDeviceQueueSeriesData(data *IOStatReportData) string {
-	deviceSet := make(map[string]bool)
-	for _, snapshot := range data.Snapshots {
-		for _, device := range snapshot.Devices {
-			deviceSet[device.Device] = true
-		}
-	}
-
+// extractDeviceQueueSeriesData extracts device queue size data for charts, shading the time
+// ranges where the device's %util was above the utilization threshold
+func extractDeviceQueueSeriesData(data *IOStatReportData, thresholds IOStatThresholds) string {
 	var series []string
-	for device := range deviceSet {
+	for _, device := range ioStatDeviceNames(data) {
 		queueData := make([]string, len(data.Snapshots))
+		overUtil := make([]bool, len(data.Snapshots))
 
 		for i, snapshot := range data.Snapshots {
 			queueSize := 0.0
@@ -691,6 +1414,7 @@ func extractDeviceQueueSeriesData(data *IOStatReportData) string {
 			for _, d := range snapshot.Devices {
 				if d.Device == device {
 					queueSize = d.AvgQueueSize
+					overUtil[i] = d.Utilization > thresholds.UtilizationPct
 					break
 				}
 			}
@@ -698,13 +1422,28 @@ func extractDeviceQueueSeriesData(data *IOStatReportData) string {
 			queueData[i] = fmt.Sprintf("%.2f", queueSize)
 		}
 
+		// Collapse consecutive saturated snapshots into a single shaded area
+		var areas []string
+		for i := 0; i < len(overUtil); i++ {
+			if !overUtil[i] {
+				continue
+			}
+			start := i
+			for i+1 < len(overUtil) && overUtil[i+1] {
+				i++
+			}
+			areas = append(areas, fmt.Sprintf(`[{ "name": "%%util > %.0f%%", "xAxis": %d }, { "xAxis": %d }]`,
+				thresholds.UtilizationPct, start, i))
+		}
+
 		series = append(series, fmt.Sprintf(`{
-			name: "%s Queue Size",
-			type: "line",
-			data: [%s],
-			smooth: true,
-			areaStyle: {}
-		}`, device, strings.Join(queueData, ", ")))
+			"name": %s,
+			"type": "line",
+			"data": [%s],
+			"smooth": true,
+			"areaStyle": {},
+			"markArea": { "itemStyle": { "color": "rgba(211, 47, 47, 0.15)" }, "data": [%s] }
+		}`, jsonString(device+" Queue Size"), strings.Join(queueData, ", "), strings.Join(areas, ", ")))
 	}
 
 	return fmt.Sprintf("[%s]", strings.Join(series, ", "))
@@ -712,17 +1451,10 @@ func extractDeviceQueueSeriesData(data *IOStatReportData) string {
 
 // extractDeviceRequestsLegendData extracts legend data for device requests charts
 func extractDeviceRequestsLegendData(data *IOStatReportData) string {
-	deviceSet := make(map[string]bool)
-	for _, snapshot := range data.Snapshots {
-		for _, device := range snapshot.Devices {
-			deviceSet[device.Device] = true
-		}
-	}
-
 	var legends []string
-	for device := range deviceSet {
-		legends = append(legends, fmt.Sprintf(`"%s Reads/sec"`, device))
-		legends = append(legends, fmt.Sprintf(`"%s Writes/sec"`, device))
+	for _, device := range ioStatDeviceNames(data) {
+		legends = append(legends, jsonString(device+" Reads/sec"))
+		legends = append(legends, jsonString(device+" Writes/sec"))
 	}
 
 	return fmt.Sprintf("[%s]", strings.Join(legends, ", "))
@@ -730,15 +1462,8 @@ func extractDeviceRequestsLegendData(data *IOStatReportData) string {
 
 // extractDeviceRequestsSeriesData extracts device requests per second data for charts
 func extractDeviceRequestsSeriesData(data *IOStatReportData) string {
-	deviceSet := make(map[string]bool)
-	for _, snapshot := range data.Snapshots {
-		for _, device := range snapshot.Devices {
-			deviceSet[device.Device] = true
-		}
-	}
-
 	var series []string
-	for device := range deviceSet {
+	for _, device := range ioStatDeviceNames(data) {
 		readsData := make([]string, len(data.Snapshots))
 		writesData := make([]string, len(data.Snapshots))
 
@@ -759,18 +1484,18 @@ func extractDeviceRequestsSeriesData(data *IOStatReportData) string {
 		}
 
 		series = append(series, fmt.Sprintf(`{
-			name: "%s Reads/sec",
-			type: "line",
-			data: [%s],
-			smooth: true
-		}`, device, strings.Join(readsData, ", ")))
+			"name": %s,
+			"type": "line",
+			"data": [%s],
+			"smooth": true
+		}`, jsonString(device+" Reads/sec"), strings.Join(readsData, ", ")))
 
 		series = append(series, fmt.Sprintf(`{
-			name: "%s Writes/sec",
-			type: "line",
-			data: [%s],
-			smooth: true
-		}`, device, strings.Join(writesData, ", ")))
+			"name": %s,
+			"type": "line",
+			"data": [%s],
+			"smooth": true
+		}`, jsonString(device+" Writes/sec"), strings.Join(writesData, ", ")))
 	}
 
 	return fmt.Sprintf("[%s]", strings.Join(series, ", "))
@@ -778,17 +1503,10 @@ func extractDeviceRequestsSeriesData(data *IOStatReportData) string {
 
 // extractDeviceRequestSizeLegendData extracts legend data for device request size charts
 func extractDeviceRequestSizeLegendData(data *IOStatReportData) string {
-	deviceSet := make(map[string]bool)
-	for _, snapshot := range data.Snapshots {
-		for _, device := range snapshot.Devices {
-			deviceSet[device.Device] = true
-		}
-	}
-
 	var legends []string
-	for device := range deviceSet {
-		legends = append(legends, fmt.Sprintf(`"%s Read Size"`, device))
-		legends = append(legends, fmt.Sprintf(`"%s Write Size"`, device))
+	for _, device := range ioStatDeviceNames(data) {
+		legends = append(legends, jsonString(device+" Read Size"))
+		legends = append(legends, jsonString(device+" Write Size"))
 	}
 
 	return fmt.Sprintf("[%s]", strings.Join(legends, ", "))
@@ -796,15 +1514,8 @@ func extractDeviceRequestSizeLegendData(data *IOStatReportData) string {
 
 // extractDeviceRequestSizeSeriesData extracts device request size data for charts
 func extractDeviceRequestSizeSeriesData(data *IOStatReportData) string {
-	deviceSet := make(map[string]bool)
-	for _, snapshot := range data.Snapshots {
-		for _, device := range snapshot.Devices {
-			deviceSet[device.Device] = true
-		}
-	}
-
 	var series []string
-	for device := range deviceSet {
+	for _, device := range ioStatDeviceNames(data) {
 		readSizeData := make([]string, len(data.Snapshots))
 		writeSizeData := make([]string, len(data.Snapshots))
 
@@ -825,19 +1536,25 @@ func extractDeviceRequestSizeSeriesData(data *IOStatReportData) string {
 		}
 
 		series = append(series, fmt.Sprintf(`{
-			name: "%s Read Size",
-			type: "line",
-			data: [%s],
-			smooth: true
-		}`, device, strings.Join(readSizeData, ", ")))
+			"name": %s,
+			"type": "line",
+			"data": [%s],
+			"smooth": true
+		}`, jsonString(device+" Read Size"), strings.Join(readSizeData, ", ")))
 
 		series = append(series, fmt.Sprintf(`{
-			name: "%s Write Size",
-			type: "line",
-			data: [%s],
-			smooth: true
-		}`, device, strings.Join(writeSizeData, ", ")))
+			"name": %s,
+			"type": "line",
+			"data": [%s],
+			"smooth": true
+		}`, jsonString(device+" Write Size"), strings.Join(writeSizeData, ", ")))
 	}
 
 	return fmt.Sprintf("[%s]", strings.Join(series, ", "))
 }
+
+// jsonString quotes s as a JSON string, so device names are safe inside chart data
+func jsonString(s string) string {
+	quoted, _ := json.Marshal(s)
+	return string(quoted)
+}
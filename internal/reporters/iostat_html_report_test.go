@@ -15,6 +15,8 @@
 package reporters
 
 import (
+	"encoding/json"
+	"strings"
 	"testing"
 	"time"
 
@@ -100,19 +102,27 @@ func TestGenerateIOStatHTML(t *testing.T) {
 		// Verify all chart containers are present
 		assert.Contains(t, html, `id="cpuChart"`)
 		assert.Contains(t, html, `id="ioThroughputChart"`)
+		assert.Contains(t, html, `id="perDeviceThroughputChart"`)
 		assert.Contains(t, html, `id="deviceAwaitChart"`)
 		assert.Contains(t, html, `id="deviceQueueChart"`)
 		assert.Contains(t, html, `id="deviceRequestsChart"`)
 		assert.Contains(t, html, `id="deviceRequestSizeChart"`)
+		assert.Contains(t, html, `id="utilHeatmapChart"`)
+		assert.Contains(t, html, "type: 'heatmap'")
 
 		// Verify chart titles
 		assert.Contains(t, html, "CPU Utilization Over Time")
 		assert.Contains(t, html, "Device I/O Throughput Over Time")
+		assert.Contains(t, html, "Per-Device I/O Throughput (Stacked)")
 		assert.Contains(t, html, "Device I/O Await Times")
 		assert.Contains(t, html, "Device Average Queue Size")
 		assert.Contains(t, html, "Device I/O Requests Per Second")
 		assert.Contains(t, html, "Device I/O Request Sizes")
 
+		// The time axis names the zone the timestamps are in
+		assert.Contains(t, html, "const timeAxisName = 'Time (UTC)';")
+		assert.Equal(t, 8, strings.Count(html, "name: timeAxisName"))
+
 		// Verify device utilization chart is NOT present
 		assert.NotContains(t, html, `id="deviceUtilChart"`)
 		assert.NotContains(t, html, "Device Utilization Over Time")
@@ -127,11 +137,58 @@ func TestGenerateIOStatHTML(t *testing.T) {
 		assert.Contains(t, html, "2") // snapshot count
 		assert.Contains(t, html, "2") // device count (sda, sdb)
 
-		// Verify data is embedded in charts
-		assert.Contains(t, html, "25.5")  // CPU user value
-		assert.Contains(t, html, "10.2")  // CPU system value
-		assert.Contains(t, html, "350.0") // Read KB/s aggregated
-		assert.Contains(t, html, "700.0") // Write KB/s aggregated
+		// The charts load their data when they scroll into view rather than carrying it
+		assert.Equal(t, 8, strings.Count(html, `data-chart="`))
+		assert.Contains(t, html, "'/api/reports/' + dddReportID + '/chart/' + name")
+		assert.Contains(t, html, "getElementById('ddd-report-data')")
+		assert.Contains(t, html, "new IntersectionObserver(")
+		assert.Contains(t, html, "dddAnnotate(chart);", "Annotations are drawn once a chart has its data")
+		assert.Contains(t, html, `id="ddd-chart-times">["`)
+		assert.NotContains(t, html, "350.0")
+		assert.NotContains(t, html, "700.0")
+	})
+
+	t.Run("Generate the data of every chart", func(t *testing.T) {
+		data := &IOStatReportData{
+			Snapshots: []IOStatSnapshot{
+				{
+					Timestamp: time.Date(2024, 9, 4, 12, 7, 20, 0, time.UTC),
+					CPUStats:  &CPUStats{User: 25.5, System: 10.2, Idle: 64.3},
+					Devices: []DeviceStats{
+						{Device: "sda", ReadKBPerS: 250.0, WriteKBPerS: 500.0, Utilization: 15.5},
+						{Device: `dm"0`, ReadKBPerS: 100.0, WriteKBPerS: 200.0, Utilization: 8.2},
+					},
+				},
+			},
+		}
+
+		charts := GenerateIOStatChartData(data, DefaultIOStatThresholds(), 0)
+		require.Len(t, charts, len(IOStatChartNames))
+		for _, name := range IOStatChartNames {
+			require.Contains(t, charts, name)
+		}
+
+		// Every chart is valid JSON, even with a device name that needs escaping
+		encoded, err := json.Marshal(charts)
+		require.NoError(t, err)
+		var decoded map[string]struct {
+			Labels  []string         `json:"labels"`
+			Legend  []string         `json:"legend"`
+			Devices []string         `json:"devices"`
+			Series  []map[string]any `json:"series"`
+		}
+		require.NoError(t, json.Unmarshal(encoded, &decoded))
+
+		assert.Equal(t, []string{"12:07:20"}, decoded["cpu"].Labels)
+		assert.Equal(t, "User", decoded["cpu"].Series[0]["name"])
+		assert.Equal(t, []any{25.5}, decoded["cpu"].Series[0]["data"])
+		assert.Equal(t, []any{350.0}, decoded["io_throughput"].Series[0]["data"])
+		assert.Equal(t, []string{`dm"0`, "sda"}, decoded["util_heatmap"].Devices)
+		assert.Equal(t, []string{`dm"0 Read KB/s`, `dm"0 Write KB/s`, "sda Read KB/s", "sda Write KB/s"},
+			decoded["per_device_throughput"].Legend)
+		assert.Len(t, decoded["device_await"].Series, 4)
+
+		assert.Empty(t, GenerateIOStatChartData(nil, DefaultIOStatThresholds(), 0))
 	})
 
 	t.Run("Generate HTML with empty data", func(t *testing.T) {
@@ -192,6 +249,64 @@ func TestGenerateIOStatHTML(t *testing.T) {
 		assert.Contains(t, html, "1") // snapshot count
 		assert.Contains(t, html, "1") // device count
 	})
+
+	t.Run("Generate HTML with a theme", func(t *testing.T) {
+		data := &IOStatReportData{
+			Snapshots: []IOStatSnapshot{
+				{
+					Timestamp: time.Date(2024, 9, 4, 12, 7, 20, 0, time.UTC),
+					CPUStats:  &CPUStats{User: 15.5, System: 5.2, Idle: 79.3},
+					Devices:   []DeviceStats{{Device: "sda", ReadKBPerS: 150.0, Utilization: 10.5}},
+				},
+			},
+		}
+
+		html, err := GenerateIOStatHTMLWithTheme(data, DefaultIOStatThresholds(), 0, "light")
+		require.NoError(t, err)
+
+		assert.Contains(t, html, "--report-bg: #ffffff;")
+		assert.Contains(t, html, "color: var(--report-bad);")
+		assert.Contains(t, html, `"#2563eb"`)
+		assert.Equal(t, 8, strings.Count(html, "'ddd');"))
+
+		// Unknown themes fall back to the default
+		html, err = GenerateIOStatHTMLWithTheme(data, DefaultIOStatThresholds(), 0, "neon")
+		require.NoError(t, err)
+		assert.Contains(t, html, "--report-accent: #06b6d4;")
+	})
+}
+
+// The reports are used in air-gapped environments, so scripts must come from DDD itself
+func TestTimeSeriesReportsServeEChartsLocally(t *testing.T) {
+	timestamp := time.Date(2024, 9, 4, 12, 7, 20, 0, time.UTC)
+
+	iostatHTML, err := GenerateIOStatHTML(&IOStatReportData{
+		Snapshots: []IOStatSnapshot{
+			{
+				Timestamp: timestamp,
+				CPUStats:  &CPUStats{User: 15.5, Idle: 84.5},
+				Devices:   []DeviceStats{{Device: "sda", Utilization: 10.5}},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	ttopHTML, err := GenerateTTopHTML(&TTopReportData{
+		Snapshots: []TTopSnapshot{
+			{
+				Timestamp:    timestamp,
+				ThreadCounts: &ThreadCounts{Total: 1, Running: 1},
+				Threads:      []ThreadInfo{{PID: 1234, User: "dremio", CPU: 25.5, Command: "java"}},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	for name, html := range map[string]string{"iostat": iostatHTML, "ttop": ttopHTML} {
+		assert.Contains(t, html, `<script src="/static/js/echarts.min.js"></script>`, name)
+		assert.NotContains(t, html, `src="http`, name)
+		assert.NotContains(t, html, "cdn.jsdelivr.net", name)
+	}
 }
 
 func TestExtractIOStatTimeLabels(t *testing.T) {
@@ -314,6 +429,87 @@ func TestExtractIOThroughputSeriesData(t *testing.T) {
 	})
 }
 
+func TestExtractPerDeviceThroughputSeriesData(t *testing.T) {
+	data := &IOStatReportData{
+		Snapshots: []IOStatSnapshot{
+			{
+				Devices: []DeviceStats{
+					{Device: "sdb", ReadKBPerS: 50.0, WriteKBPerS: 900.0},
+					{Device: "sda", ReadKBPerS: 100.0, WriteKBPerS: 10.0},
+				},
+			},
+			{
+				// sdb is missing from this snapshot and contributes nothing
+				Devices: []DeviceStats{
+					{Device: "sda", ReadKBPerS: 150.0, WriteKBPerS: 20.0},
+				},
+			},
+		},
+	}
+
+	t.Run("Each device is its own stacked band", func(t *testing.T) {
+		result := extractPerDeviceThroughputSeriesData(data)
+
+		assert.Contains(t, result, `"name": "sda Read KB/s"`)
+		assert.Contains(t, result, `"name": "sdb Write KB/s"`)
+		assert.Equal(t, 2, strings.Count(result, `"stack": "read"`))
+		assert.Equal(t, 2, strings.Count(result, `"stack": "write"`))
+		assert.Contains(t, result, `"data": [100.0, 150.0]`) // sda reads
+		assert.Contains(t, result, `"data": [10.0, 20.0]`)   // sda writes
+		assert.Contains(t, result, `"data": [50.0, 0.0]`)    // sdb reads
+		assert.Contains(t, result, `"data": [900.0, 0.0]`)   // sdb writes
+
+		// Devices are stacked in a stable, sorted order
+		assert.Less(t, strings.Index(result, "sda Read KB/s"), strings.Index(result, "sdb Read KB/s"))
+	})
+
+	t.Run("Legend lists every series", func(t *testing.T) {
+		assert.Equal(t, `["sda Read KB/s", "sda Write KB/s", "sdb Read KB/s", "sdb Write KB/s"]`,
+			extractPerDeviceThroughputLegendData(data))
+	})
+
+	t.Run("No devices", func(t *testing.T) {
+		empty := &IOStatReportData{Snapshots: []IOStatSnapshot{{Devices: []DeviceStats{}}}}
+		assert.Equal(t, "[]", extractPerDeviceThroughputSeriesData(empty))
+		assert.Equal(t, "[]", extractPerDeviceThroughputLegendData(empty))
+	})
+}
+
+func TestExtractUtilHeatmapData(t *testing.T) {
+	data := &IOStatReportData{
+		Snapshots: []IOStatSnapshot{
+			{
+				Devices: []DeviceStats{
+					{Device: "sdb", Utilization: 97.25},
+					{Device: "sda", Utilization: 12.0},
+				},
+			},
+			{
+				// sdb is missing from this snapshot, its cell is left empty
+				Devices: []DeviceStats{
+					{Device: "sda", Utilization: 15.5},
+				},
+			},
+		},
+	}
+
+	t.Run("One cell per device and snapshot", func(t *testing.T) {
+		assert.Equal(t, `[[0, 0, 12.0], [1, 0, 15.5], [0, 1, 97.2]]`, extractUtilHeatmapData(data))
+		assert.Equal(t, `["sda", "sdb"]`, extractUtilHeatmapDeviceData(data))
+	})
+
+	t.Run("No devices", func(t *testing.T) {
+		empty := &IOStatReportData{Snapshots: []IOStatSnapshot{{Devices: []DeviceStats{}}}}
+		assert.Equal(t, "[]", extractUtilHeatmapData(empty))
+		assert.Equal(t, "[]", extractUtilHeatmapDeviceData(empty))
+	})
+
+	t.Run("Height grows with the device count", func(t *testing.T) {
+		assert.Equal(t, 400, utilHeatmapHeight(2))
+		assert.Equal(t, 1080, utilHeatmapHeight(40))
+	})
+}
+
 func TestCountUniqueDevices(t *testing.T) {
 	t.Run("Count unique devices across snapshots", func(t *testing.T) {
 		data := &IOStatReportData{
@@ -473,6 +669,63 @@ func TestGenerateEmptyIOStatHTML(t *testing.T) {
 	})
 }
 
+func TestExtractIOStatSnapshotDetails(t *testing.T) {
+	start := time.Date(2024, 9, 4, 12, 7, 20, 0, time.UTC)
+	data := &IOStatReportData{}
+	for i := 0; i < 5; i++ {
+		data.Snapshots = append(data.Snapshots, IOStatSnapshot{
+			Timestamp: start.Add(time.Duration(i) * time.Second),
+			CPUStats:  &CPUStats{IOWait: float64(i)},
+			Devices:   []DeviceStats{{Device: "sda", Utilization: float64(i * 20)}, {Device: "</script>", ReadAwait: 5}},
+		})
+	}
+	data.Snapshots[4].CPUStats = nil
+	data.Snapshots[4].Devices = nil
+
+	type details struct {
+		UtilizationPct float64 `json:"utilization_pct"`
+		AwaitMs        float64 `json:"await_ms"`
+		Snapshots      []struct {
+			Time     string        `json:"time"`
+			CPUStats *CPUStats     `json:"cpu_stats"`
+			Devices  []DeviceStats `json:"devices"`
+		} `json:"snapshots"`
+		Points []int `json:"points"`
+	}
+
+	t.Run("Every snapshot with the first snapshot behind each chart point", func(t *testing.T) {
+		embedded, err := extractIOStatSnapshotDetails(data, DefaultIOStatThresholds(), 2)
+		require.NoError(t, err)
+		assert.NotContains(t, embedded, "</script>")
+
+		var parsed details
+		require.NoError(t, json.Unmarshal([]byte(embedded), &parsed))
+		assert.Equal(t, 90.0, parsed.UtilizationPct)
+		assert.Equal(t, 100.0, parsed.AwaitMs)
+		require.Len(t, parsed.Snapshots, 5)
+		assert.Equal(t, "2024-09-04 12:07:23", parsed.Snapshots[3].Time)
+		assert.Equal(t, 3.0, parsed.Snapshots[3].CPUStats.IOWait)
+		require.Len(t, parsed.Snapshots[3].Devices, 2)
+		assert.Equal(t, 60.0, parsed.Snapshots[3].Devices[0].Utilization)
+		assert.Equal(t, "</script>", parsed.Snapshots[3].Devices[1].Device)
+		assert.Nil(t, parsed.Snapshots[4].CPUStats)
+		assert.NotNil(t, parsed.Snapshots[4].Devices)
+		assert.Equal(t, []int{0, 2}, parsed.Points)
+	})
+
+	t.Run("Report embeds the snapshots and the click handlers", func(t *testing.T) {
+		html, err := GenerateIOStatHTMLWithMaxPoints(data, DefaultIOStatThresholds(), 2)
+		require.NoError(t, err)
+		assert.Contains(t, html, `<script type="application/json" id="iostatSnapshotData">{"utilization_pct":90,`)
+		assert.Contains(t, html, `"points":[0,2]}</script>`)
+		assert.Contains(t, html, `id="snapshotDetails"`)
+		assert.Contains(t, html, "triggerEvent: true")
+		assert.Contains(t, html, "chart.getZr().on('click'")
+		assert.Contains(t, html, "<th>aqu-sz</th><th>%util</th>")
+		assert.NotContains(t, html, "%!")
+	})
+}
+
 func TestExtractDeviceAwaitData(t *testing.T) {
 	t.Run("Extract device await legend and series data", func(t *testing.T) {
 		data := &IOStatReportData{
@@ -499,7 +752,7 @@ func TestExtractDeviceAwaitData(t *testing.T) {
 		assert.Contains(t, legend, "sda")
 		assert.Contains(t, legend, "sdb")
 
-		series := extractDeviceAwaitSeriesData(data)
+		series := extractDeviceAwaitSeriesData(data, DefaultIOStatThresholds())
 		assert.NotEmpty(t, series)
 		assert.Contains(t, series, "2.50, 3.10") // sda read await values
 		assert.Contains(t, series, "3.20, 4.00") // sda write await values
@@ -533,7 +786,7 @@ func TestExtractDeviceQueueData(t *testing.T) {
 		assert.Contains(t, legend, "sda")
 		assert.Contains(t, legend, "sdb")
 
-		series := extractDeviceQueueSeriesData(data)
+		series := extractDeviceQueueSeriesData(data, DefaultIOStatThresholds())
 		assert.NotEmpty(t, series)
 		assert.Contains(t, series, "1.50, 2.10") // sda queue size values
 		assert.Contains(t, series, "0.80, 1.20") // sdb queue size values
@@ -610,3 +863,164 @@ func TestExtractDeviceRequestSizeData(t *testing.T) {
 		assert.Contains(t, series, "96.10, 104.40")  // sdb write size values
 	})
 }
+
+func TestSummarizeDevices(t *testing.T) {
+	t.Run("Compute percentiles per device", func(t *testing.T) {
+		var snapshots []IOStatSnapshot
+		for i := 1; i <= 100; i++ {
+			snapshots = append(snapshots, IOStatSnapshot{
+				Devices: []DeviceStats{
+					{
+						Device:       "sdb",
+						Utilization:  float64(i),
+						ReadAwait:    float64(i) / 10,
+						WriteAwait:   float64(i) * 2,
+						AvgQueueSize: 1.0,
+					},
+					{
+						Device:      "sda",
+						Utilization: 5.0,
+					},
+				},
+			})
+		}
+		data := &IOStatReportData{Snapshots: snapshots}
+
+		summaries := summarizeDevices(data)
+		require.Len(t, summaries, 2)
+
+		// Devices are sorted by name
+		assert.Equal(t, "sda", summaries[0].Device)
+		assert.Equal(t, "sdb", summaries[1].Device)
+
+		sda := summaries[0]
+		assert.Equal(t, 100, sda.Samples)
+		assert.Equal(t, PercentileStats{P50: 5.0, P95: 5.0, P99: 5.0}, sda.Utilization)
+
+		sdb := summaries[1]
+		assert.Equal(t, 100, sdb.Samples)
+		assert.InDelta(t, 50.5, sdb.Utilization.P50, 0.001)
+		assert.InDelta(t, 95.05, sdb.Utilization.P95, 0.001)
+		assert.InDelta(t, 99.01, sdb.Utilization.P99, 0.001)
+		assert.InDelta(t, 5.05, sdb.ReadAwait.P50, 0.001)
+		assert.InDelta(t, 190.1, sdb.WriteAwait.P95, 0.001)
+		assert.Equal(t, PercentileStats{P50: 1.0, P95: 1.0, P99: 1.0}, sdb.AvgQueueSize)
+	})
+
+	t.Run("Summarize with no snapshots", func(t *testing.T) {
+		summaries := summarizeDevices(&IOStatReportData{Snapshots: []IOStatSnapshot{}})
+		assert.Empty(t, summaries)
+
+		summaries = summarizeDevices(nil)
+		assert.Empty(t, summaries)
+	})
+
+	t.Run("Summary table rendered above charts", func(t *testing.T) {
+		data := &IOStatReportData{
+			Snapshots: []IOStatSnapshot{
+				{
+					Timestamp: time.Date(2024, 9, 4, 12, 7, 20, 0, time.UTC),
+					CPUStats:  &CPUStats{Idle: 90.0},
+					Devices: []DeviceStats{
+						{Device: "nvme0n1", Utilization: 42.5, AvgQueueSize: 1.25},
+					},
+				},
+			},
+		}
+
+		html, err := GenerateIOStatHTML(data)
+		require.NoError(t, err)
+
+		assert.Contains(t, html, "Device Percentile Summary")
+		assert.Contains(t, html, `class="summary-table"`)
+		assert.Contains(t, html, "<td>nvme0n1</td>")
+		assert.Contains(t, html, "42.50")
+		assert.Less(t, strings.Index(html, "Device Percentile Summary"), strings.Index(html, `id="cpuChart"`))
+	})
+}
+
+func TestPercentile(t *testing.T) {
+	t.Run("Interpolates between ranks", func(t *testing.T) {
+		sorted := []float64{10, 20, 30, 40}
+		assert.Equal(t, 10.0, percentile(sorted, 0))
+		assert.Equal(t, 25.0, percentile(sorted, 50))
+		assert.Equal(t, 40.0, percentile(sorted, 100))
+	})
+
+	t.Run("Single and empty samples", func(t *testing.T) {
+		assert.Equal(t, 7.0, percentile([]float64{7}, 99))
+		assert.Equal(t, 0.0, percentile([]float64{}, 50))
+	})
+}
+
+func TestFindIOStatThresholdBreaches(t *testing.T) {
+	data := &IOStatReportData{
+		Snapshots: []IOStatSnapshot{
+			{
+				Timestamp: time.Date(2024, 9, 4, 12, 7, 20, 0, time.UTC),
+				Devices: []DeviceStats{
+					{Device: "sda", Utilization: 95.0, ReadAwait: 1.0, WriteAwait: 2.0},
+					{Device: "sdb", Utilization: 10.0, ReadAwait: 150.0, WriteAwait: 2.0},
+				},
+			},
+			{
+				Timestamp: time.Date(2024, 9, 4, 12, 7, 21, 0, time.UTC),
+				Devices: []DeviceStats{
+					{Device: "sda", Utilization: 50.0, ReadAwait: 1.0, WriteAwait: 250.0},
+					{Device: "sdb", Utilization: 10.0, ReadAwait: 1.0, WriteAwait: 2.0},
+				},
+			},
+		},
+	}
+
+	t.Run("Find breaches with default thresholds", func(t *testing.T) {
+		findings := findIOStatThresholdBreaches(data, DefaultIOStatThresholds())
+		require.Len(t, findings, 3)
+
+		assert.Equal(t, "sda", findings[0].Device)
+		assert.Equal(t, "%util", findings[0].Metric)
+		assert.Equal(t, 95.0, findings[0].Value)
+		assert.Equal(t, 90.0, findings[0].Threshold)
+		assert.Equal(t, 0, findings[0].SnapshotIndex)
+
+		assert.Equal(t, "sdb", findings[1].Device)
+		assert.Equal(t, "r_await", findings[1].Metric)
+
+		assert.Equal(t, "sda", findings[2].Device)
+		assert.Equal(t, "w_await", findings[2].Metric)
+		assert.Equal(t, 1, findings[2].SnapshotIndex)
+		assert.Equal(t, data.Snapshots[1].Timestamp, findings[2].Timestamp)
+	})
+
+	t.Run("Find breaches with custom thresholds", func(t *testing.T) {
+		findings := findIOStatThresholdBreaches(data, IOStatThresholds{UtilizationPct: 40, AwaitMs: 1000})
+		require.Len(t, findings, 2)
+		assert.Equal(t, "%util", findings[0].Metric)
+		assert.Equal(t, "%util", findings[1].Metric)
+	})
+
+	t.Run("Annotate charts and list findings", func(t *testing.T) {
+		for i := range data.Snapshots {
+			data.Snapshots[i].CPUStats = &CPUStats{Idle: 90.0}
+		}
+
+		html, err := GenerateIOStatHTML(data)
+		require.NoError(t, err)
+
+		assert.Contains(t, html, "Findings")
+		assert.Contains(t, html, `class="summary-table findings-table"`)
+		assert.Contains(t, html, "2024-09-04 12:07:20")
+
+		charts := GenerateIOStatChartData(data, DefaultIOStatThresholds(), 0)
+		assert.Contains(t, string(charts["device_await"].Series), `"markPoint"`)
+		assert.Contains(t, string(charts["device_await"].Series), `{ "coord": [0, 150.00]`)
+		assert.Contains(t, string(charts["device_queue"].Series), `"markArea"`)
+		assert.Contains(t, string(charts["device_queue"].Series), `[{ "name": "%util > 90%", "xAxis": 0 }, { "xAxis": 0 }]`)
+	})
+
+	t.Run("No findings", func(t *testing.T) {
+		findings := findIOStatThresholdBreaches(data, IOStatThresholds{UtilizationPct: 100, AwaitMs: 1000})
+		assert.Empty(t, findings)
+		assert.Contains(t, generateFindingsHTML(findings), "No threshold breaches detected")
+	})
+}
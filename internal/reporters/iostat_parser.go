@@ -15,8 +15,9 @@
 package reporters
 
 import (
-	"bufio"
+	"bytes"
 	"fmt"
+	"io"
 	"strconv"
 	"strings"
 	"time"
@@ -35,6 +36,7 @@ type CPUStats struct {
 // DeviceStats represents I/O statistics for a single device
 type DeviceStats struct {
 	Device               string  `json:"device"`                   // Device name (e.g., sda)
+	Parent               string  `json:"parent,omitempty"`         // Whole device of a partition from iostat -p (e.g., sda for sda1), empty for devices
 	ReadsPerS            float64 `json:"reads_per_s"`              // r/s - reads per second
 	ReadKBPerS           float64 `json:"read_kb_per_s"`            // rkB/s - kilobytes read per second
 	ReadReqMergedPerS    float64 `json:"read_req_merged_per_s"`    // rrqm/s - read requests merged per second
@@ -69,22 +71,42 @@ type IOStatSnapshot struct {
 // IOStatReportData represents the complete parsed iostat report data
 type IOStatReportData struct {
 	SystemInfo string           `json:"system_info"` // System information from header
+	SourceUnit string           `json:"source_unit"` // Throughput unit of the capture, IOStatUnitKB or IOStatUnitMB
 	Snapshots  []IOStatSnapshot `json:"snapshots"`   // All snapshots from the iostat output
 }
 
-// ParseIOStat parses iostat output content and extracts I/O statistics over time
+// Throughput units iostat reports in. Captures taken with iostat -x -m use megabytes;
+// the parser normalizes them so DeviceStats throughput is always in kilobytes per second
+const (
+	IOStatUnitKB = "kB"
+	IOStatUnitMB = "MB"
+)
+
+// ParseIOStat parses iostat output content and extracts I/O statistics over time,
+// treating the capture's timestamps as UTC
 func ParseIOStat(content []byte) (*IOStatReportData, error) {
-	if len(content) == 0 {
-		return &IOStatReportData{Snapshots: []IOStatSnapshot{}}, nil
-	}
+	return ParseIOStatInLocation(content, time.UTC)
+}
 
-	scanner := bufio.NewScanner(strings.NewReader(string(content)))
-	var snapshots []IOStatSnapshot
+// ParseIOStatInLocation parses iostat output content and extracts I/O statistics over time.
+// iostat prints timestamps without a zone, so they are interpreted in loc
+func ParseIOStatInLocation(content []byte, loc *time.Location) (*IOStatReportData, error) {
+	return ParseIOStatReader(bytes.NewReader(content), loc)
+}
+
+// ParseIOStatReader parses iostat output line by line from r, interpreting timestamps in
+// loc, so large captures are never held in memory as raw text
+func ParseIOStatReader(r io.Reader, loc *time.Location) (*IOStatReportData, error) {
+	scanner := newLineScanner(r)
+	unit := IOStatUnitKB
+	columns := defaultIOStatDeviceColumns
+	snapshots := []IOStatSnapshot{}
 	var currentSnapshot *IOStatSnapshot
 	var systemInfo string
 	var inDeviceSection bool
 	var lineNumber int
 	var expectingCPUStats bool
+	var previousTimestamp time.Time
 
 	for scanner.Scan() {
 		lineNumber++
@@ -101,7 +123,7 @@ func ParseIOStat(content []byte) (*IOStatReportData, error) {
 			continue
 		}
 
-		// Check if this line contains a timestamp (MM/DD/YY format)
+		// Check if this line contains a timestamp (MM/DD/YY format, or only a time of day)
 		if isTimestampLine(line) {
 			// Validate previous snapshot if it exists
 			if currentSnapshot != nil {
@@ -111,12 +133,14 @@ func ParseIOStat(content []byte) (*IOStatReportData, error) {
 				snapshots = append(snapshots, *currentSnapshot)
 			}
 
-			// Parse timestamp
-			timestamp, err := parseIOStatTimestamp(line)
+			// Parse timestamp, carrying the date forward from the previous snapshot
+			timestamp, err := resolveIOStatTimestamp(line, previousTimestamp, loc)
 			if err != nil {
 				return nil, fmt.Errorf("line %d: failed to parse timestamp: %w", lineNumber, err)
 			}
 
+			previousTimestamp = timestamp
+
 			// Start new snapshot
 			currentSnapshot = &IOStatSnapshot{
 				Timestamp: timestamp,
@@ -155,22 +179,28 @@ func ParseIOStat(content []byte) (*IOStatReportData, error) {
 			continue
 		}
 
-		// Check if this line is the device header
+		// Check if this line is the device header, which also tells us the throughput unit
 		if strings.HasPrefix(line, "Device") {
 			inDeviceSection = true
+			unit = detectIOStatUnit(line)
+			columns = parseDeviceHeader(line)
 			continue
 		}
 
 		// Parse device statistics
 		if inDeviceSection && currentSnapshot != nil {
-			deviceStats, err := parseDeviceStatsLine(line)
+			deviceStats, err := parseDeviceStatsLine(line, columns)
 			if err != nil {
 				return nil, fmt.Errorf("line %d: failed to parse device statistics: %w", lineNumber, err)
 			}
-			currentSnapshot.Devices = append(currentSnapshot.Devices, deviceStats)
+			currentSnapshot.Devices = append(currentSnapshot.Devices, normalizeDeviceThroughput(deviceStats, unit))
 		}
 	}
 
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading iostat content: %w", err)
+	}
+
 	// Validate the last snapshot
 	if currentSnapshot != nil {
 		if expectingCPUStats {
@@ -181,12 +211,41 @@ func ParseIOStat(content []byte) (*IOStatReportData, error) {
 
 	return &IOStatReportData{
 		SystemInfo: systemInfo,
+		SourceUnit: unit,
 		Snapshots:  snapshots,
 	}, nil
 }
 
-// isTimestampLine checks if a line contains a timestamp in MM/DD/YY format
+// detectIOStatUnit reads the throughput unit from a device header line, which has
+// rMB/s and wMB/s columns for iostat -m captures and rkB/s and wkB/s otherwise
+func detectIOStatUnit(header string) string {
+	for _, column := range strings.Fields(header) {
+		if column == "rMB/s" || column == "wMB/s" {
+			return IOStatUnitMB
+		}
+	}
+	return IOStatUnitKB
+}
+
+// normalizeDeviceThroughput converts the read, write and discard throughput of stats from
+// unit to kilobytes per second. Request sizes are printed in kilobytes in every mode
+func normalizeDeviceThroughput(stats DeviceStats, unit string) DeviceStats {
+	if unit != IOStatUnitMB {
+		return stats
+	}
+	stats.ReadKBPerS *= 1024
+	stats.WriteKBPerS *= 1024
+	stats.DiscardKBPerS *= 1024
+	return stats
+}
+
+// isTimestampLine checks if a line contains a timestamp in MM/DD/YY format or,
+// as some iostat variants print after the first snapshot, only a time of day
 func isTimestampLine(line string) bool {
+	if isTimeOnlyLine(line) {
+		return true
+	}
+
 	// Look for pattern like "09/04/24 12:07:20"
 	parts := strings.Fields(line)
 	if len(parts) < 2 {
@@ -202,8 +261,76 @@ func isTimestampLine(line string) bool {
 	return false
 }
 
-// parseIOStatTimestamp parses timestamp from a line like "09/04/24 12:07:20"
-func parseIOStatTimestamp(line string) (time.Time, error) {
+// isTimeOnlyLine checks if a line holds only a time of day like "12:07:20" or "12:07:20 PM"
+func isTimeOnlyLine(line string) bool {
+	parts := strings.Fields(line)
+	if len(parts) == 2 && parts[1] != "AM" && parts[1] != "PM" {
+		return false
+	}
+	if len(parts) != 1 && len(parts) != 2 {
+		return false
+	}
+
+	_, err := time.Parse("15:04:05", parts[0])
+	return err == nil
+}
+
+// resolveIOStatTimestamp parses the timestamp line of a snapshot. A line with only a time
+// of day takes its date from the previous snapshot, and a time earlier than the previous
+// snapshot on the same date is a capture that ran past midnight, so it moves to the next day
+func resolveIOStatTimestamp(line string, previous time.Time, loc *time.Location) (time.Time, error) {
+	var timestamp time.Time
+	if isTimeOnlyLine(line) {
+		if previous.IsZero() {
+			return time.Time{}, fmt.Errorf("time %q has no date and no earlier snapshot to take one from", line)
+		}
+		timeOfDay, err := parseIOStatTimeOfDay(line)
+		if err != nil {
+			return time.Time{}, err
+		}
+		timestamp = time.Date(previous.Year(), previous.Month(), previous.Day(),
+			timeOfDay.Hour(), timeOfDay.Minute(), timeOfDay.Second(), 0, loc)
+	} else {
+		parsed, err := parseIOStatTimestamp(line, loc)
+		if err != nil {
+			return time.Time{}, err
+		}
+		timestamp = parsed
+	}
+
+	if !previous.IsZero() && timestamp.Before(previous) {
+		prevYear, prevMonth, prevDay := previous.Date()
+		year, month, day := timestamp.Date()
+		if year == prevYear && month == prevMonth && day == prevDay {
+			timestamp = timestamp.AddDate(0, 0, 1)
+		}
+	}
+
+	return timestamp, nil
+}
+
+// parseIOStatTimeOfDay parses a time-only line in 24 hour ("23:59:58") or 12 hour ("11:59:58 PM") form
+func parseIOStatTimeOfDay(line string) (time.Time, error) {
+	layout := "15:04:05"
+	if strings.HasSuffix(line, "AM") || strings.HasSuffix(line, "PM") {
+		layout = "03:04:05 PM"
+	}
+
+	timeStr := strings.Join(strings.Fields(line), " ")
+	parsedTime, err := time.Parse(layout, timeStr)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse time %s: %w", timeStr, err)
+	}
+	return parsedTime, nil
+}
+
+// ioStatCentury is added to the two-digit year iostat prints. Go's "06" layout maps
+// 69-99 to the 1900s, which would put a capture labelled "70" half a century in the past
+const ioStatCentury = 2000
+
+// parseIOStatTimestamp parses timestamp from a line like "09/04/24 12:07:20" in loc.
+// The year is always taken to be in ioStatCentury
+func parseIOStatTimestamp(line string, loc *time.Location) (time.Time, error) {
 	parts := strings.Fields(line)
 	if len(parts) < 2 {
 		return time.Time{}, fmt.Errorf("invalid timestamp line format")
@@ -212,12 +339,13 @@ func parseIOStatTimestamp(line string) (time.Time, error) {
 	dateTimeStr := parts[0] + " " + parts[1]
 
 	// Parse MM/DD/YY HH:MM:SS format
-	parsedTime, err := time.Parse("01/02/06 15:04:05", dateTimeStr)
+	parsedTime, err := time.ParseInLocation("01/02/06 15:04:05", dateTimeStr, loc)
 	if err != nil {
 		return time.Time{}, fmt.Errorf("failed to parse timestamp %s: %w", dateTimeStr, err)
 	}
 
-	return parsedTime, nil
+	return time.Date(ioStatCentury+parsedTime.Year()%100, parsedTime.Month(), parsedTime.Day(),
+		parsedTime.Hour(), parsedTime.Minute(), parsedTime.Second(), 0, loc), nil
 }
 
 // parseCPUStatsLine parses a line with CPU statistics
@@ -267,48 +395,76 @@ func parseCPUStatsLine(line string) (*CPUStats, error) {
 	}, nil
 }
 
-// parseDeviceStatsLine parses a line with device I/O statistics
-func parseDeviceStatsLine(line string) (DeviceStats, error) {
+// defaultIOStatDeviceColumns is the device header of sysstat 12 and later (iostat -x),
+// used until a capture's own header line is seen
+var defaultIOStatDeviceColumns = []string{
+	"r/s", "rkB/s", "rrqm/s", "%rrqm", "r_await", "rareq-sz",
+	"w/s", "wkB/s", "wrqm/s", "%wrqm", "w_await", "wareq-sz",
+	"d/s", "dkB/s", "drqm/s", "%drqm", "d_await", "dareq-sz",
+	"f/s", "f_await", "aqu-sz", "%util",
+}
+
+// ioStatDeviceFields maps device header columns to the DeviceStats field they fill.
+// Older sysstat releases print fewer columns, in a different order and with some renamed
+// (avgqu-sz for aqu-sz); columns without a field here, such as svctm, are ignored
+var ioStatDeviceFields = map[string]func(*DeviceStats, float64){
+	"r/s":      func(d *DeviceStats, v float64) { d.ReadsPerS = v },
+	"rkB/s":    func(d *DeviceStats, v float64) { d.ReadKBPerS = v },
+	"rMB/s":    func(d *DeviceStats, v float64) { d.ReadKBPerS = v },
+	"rrqm/s":   func(d *DeviceStats, v float64) { d.ReadReqMergedPerS = v },
+	"%rrqm":    func(d *DeviceStats, v float64) { d.ReadReqMergedPct = v },
+	"r_await":  func(d *DeviceStats, v float64) { d.ReadAwait = v },
+	"rareq-sz": func(d *DeviceStats, v float64) { d.ReadReqSize = v },
+	"w/s":      func(d *DeviceStats, v float64) { d.WritesPerS = v },
+	"wkB/s":    func(d *DeviceStats, v float64) { d.WriteKBPerS = v },
+	"wMB/s":    func(d *DeviceStats, v float64) { d.WriteKBPerS = v },
+	"wrqm/s":   func(d *DeviceStats, v float64) { d.WriteReqMergedPerS = v },
+	"%wrqm":    func(d *DeviceStats, v float64) { d.WriteReqMergedPct = v },
+	"w_await":  func(d *DeviceStats, v float64) { d.WriteAwait = v },
+	"wareq-sz": func(d *DeviceStats, v float64) { d.WriteReqSize = v },
+	"d/s":      func(d *DeviceStats, v float64) { d.DiscardsPerS = v },
+	"dkB/s":    func(d *DeviceStats, v float64) { d.DiscardKBPerS = v },
+	"dMB/s":    func(d *DeviceStats, v float64) { d.DiscardKBPerS = v },
+	"drqm/s":   func(d *DeviceStats, v float64) { d.DiscardReqMergedPerS = v },
+	"%drqm":    func(d *DeviceStats, v float64) { d.DiscardReqMergedPct = v },
+	"d_await":  func(d *DeviceStats, v float64) { d.DiscardAwait = v },
+	"dareq-sz": func(d *DeviceStats, v float64) { d.DiscardReqSize = v },
+	"f/s":      func(d *DeviceStats, v float64) { d.FlushesPerS = v },
+	"f_await":  func(d *DeviceStats, v float64) { d.FlushAwait = v },
+	"aqu-sz":   func(d *DeviceStats, v float64) { d.AvgQueueSize = v },
+	"avgqu-sz": func(d *DeviceStats, v float64) { d.AvgQueueSize = v },
+	"%util":    func(d *DeviceStats, v float64) { d.Utilization = v },
+}
+
+// parseDeviceHeader returns the statistic column names of a device header line such as
+// "Device            r/s     rkB/s ..." or the older "Device:         rrqm/s   wrqm/s ..."
+func parseDeviceHeader(line string) []string {
 	fields := strings.Fields(line)
-	if len(fields) != 23 {
-		return DeviceStats{}, fmt.Errorf("expected 23 device stat fields, got %d", len(fields))
+	if len(fields) == 0 {
+		return nil
 	}
+	return fields[1:]
+}
 
-	device := fields[0]
+// parseDeviceStatsLine parses a line with device I/O statistics laid out as columns,
+// the statistic columns of the preceding device header. Fields the header does not
+// have are left zero
+func parseDeviceStatsLine(line string, columns []string) (DeviceStats, error) {
+	fields := strings.Fields(line)
+	if len(fields) != len(columns)+1 {
+		return DeviceStats{}, fmt.Errorf("expected %d device stat fields, got %d", len(columns)+1, len(fields))
+	}
 
-	// Parse all numeric fields
-	values := make([]float64, 22)
-	for i := 1; i < 23; i++ {
-		val, err := strconv.ParseFloat(fields[i], 64)
+	stats := DeviceStats{Device: fields[0], Parent: ioStatPartitionParent(fields[0])}
+	for i, column := range columns {
+		val, err := strconv.ParseFloat(fields[i+1], 64)
 		if err != nil {
-			return DeviceStats{}, fmt.Errorf("failed to parse field %d: %w", i, err)
+			return DeviceStats{}, fmt.Errorf("failed to parse %s field: %w", column, err)
+		}
+		if set, ok := ioStatDeviceFields[column]; ok {
+			set(&stats, val)
 		}
-		values[i-1] = val
 	}
 
-	return DeviceStats{
-		Device:               device,
-		ReadsPerS:            values[0],
-		ReadKBPerS:           values[1],
-		ReadReqMergedPerS:    values[2],
-		ReadReqMergedPct:     values[3],
-		ReadAwait:            values[4],
-		ReadReqSize:          values[5],
-		WritesPerS:           values[6],
-		WriteKBPerS:          values[7],
-		WriteReqMergedPerS:   values[8],
-		WriteReqMergedPct:    values[9],
-		WriteAwait:           values[10],
-		WriteReqSize:         values[11],
-		DiscardsPerS:         values[12],
-		DiscardKBPerS:        values[13],
-		DiscardReqMergedPerS: values[14],
-		DiscardReqMergedPct:  values[15],
-		DiscardAwait:         values[16],
-		DiscardReqSize:       values[17],
-		FlushesPerS:          values[18],
-		FlushAwait:           values[19],
-		AvgQueueSize:         values[20],
-		Utilization:          values[21],
-	}, nil
+	return stats, nil
 }
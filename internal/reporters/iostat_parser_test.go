@@ -187,7 +187,7 @@ sda              5.00    250.00     1.00  20.00    2.00    50.00   10.00    500.
 func TestParseIOStatTimestamp(t *testing.T) {
 	t.Run("Valid timestamp parsing", func(t *testing.T) {
 		line := "09/04/24 12:07:20"
-		timestamp, err := parseIOStatTimestamp(line)
+		timestamp, err := parseIOStatTimestamp(line, time.UTC)
 		require.NoError(t, err)
 
 		assert.Equal(t, 2024, timestamp.Year())
@@ -196,17 +196,35 @@ func TestParseIOStatTimestamp(t *testing.T) {
 		assert.Equal(t, 12, timestamp.Hour())
 		assert.Equal(t, 7, timestamp.Minute())
 		assert.Equal(t, 20, timestamp.Second())
+		assert.Equal(t, time.UTC, timestamp.Location())
+	})
+
+	t.Run("Two-digit years are in the 2000s", func(t *testing.T) {
+		timestamp, err := parseIOStatTimestamp("01/02/70 00:00:01", time.UTC)
+		require.NoError(t, err)
+		assert.Equal(t, 2070, timestamp.Year())
+	})
+
+	t.Run("Timestamp in a configured zone", func(t *testing.T) {
+		loc, err := time.LoadLocation("America/New_York")
+		require.NoError(t, err)
+
+		timestamp, err := parseIOStatTimestamp("09/04/24 12:07:20", loc)
+		require.NoError(t, err)
+		assert.Equal(t, loc, timestamp.Location())
+		assert.Equal(t, 12, timestamp.Hour())
+		assert.Equal(t, 16, timestamp.UTC().Hour())
 	})
 
 	t.Run("Invalid timestamp format", func(t *testing.T) {
 		line := "invalid timestamp"
-		_, err := parseIOStatTimestamp(line)
+		_, err := parseIOStatTimestamp(line, time.UTC)
 		require.Error(t, err)
 	})
 
 	t.Run("Missing timestamp parts", func(t *testing.T) {
 		line := "09/04/24"
-		_, err := parseIOStatTimestamp(line)
+		_, err := parseIOStatTimestamp(line, time.UTC)
 		require.Error(t, err)
 	})
 }
@@ -231,6 +249,126 @@ func TestIsTimestampLine(t *testing.T) {
 		line := "avg-cpu:  %user   %nice %system %iowait  %steal   %idle"
 		assert.False(t, isTimestampLine(line))
 	})
+
+	t.Run("Time-only timestamp lines", func(t *testing.T) {
+		assert.True(t, isTimestampLine("23:59:59"))
+		assert.True(t, isTimestampLine("11:59:59 PM"))
+		assert.False(t, isTimestampLine("23:59:59 sda"))
+	})
+}
+
+// ioStatMidnightSnapshot is a snapshot body with one device, appended after a timestamp line
+const ioStatMidnightSnapshot = `
+avg-cpu:  %user   %nice %system %iowait  %steal   %idle
+           2.36    0.00    0.40    0.04    0.01   97.20
+
+Device            r/s     rkB/s   rrqm/s  %rrqm r_await rareq-sz     w/s     wkB/s   wrqm/s  %wrqm w_await wareq-sz     d/s     dkB/s   drqm/s  %drqm d_await dareq-sz     f/s f_await  aqu-sz  %util
+sda              2.08     94.38     0.31  13.07    0.89    45.47    9.58    210.39     5.55  36.68    2.74    21.96    0.09    377.20     0.00   0.00    0.95  4151.86    3.94    0.06    0.03   1.39
+
+`
+
+func TestParseIOStat_Megabytes(t *testing.T) {
+	t.Run("iostat -x -m values are scaled to KB/s", func(t *testing.T) {
+		sampleContent := `Linux 5.10.0-32-cloud-amd64 (ddc-test-dremio-master) 	09/04/24 	_x86_64_	(4 CPU)
+
+09/04/24 12:07:20
+avg-cpu:  %user   %nice %system %iowait  %steal   %idle
+           2.36    0.00    0.40    0.04    0.01   97.20
+
+Device            r/s     rMB/s   rrqm/s  %rrqm r_await rareq-sz     w/s     wMB/s   wrqm/s  %wrqm w_await wareq-sz     d/s     dMB/s   drqm/s  %drqm d_await dareq-sz     f/s f_await  aqu-sz  %util
+sda              2.08      1.50     0.31  13.07    0.89    45.47    9.58     37.22     5.55  36.68    2.74    21.96    0.09      0.25     0.00   0.00    0.95  4151.86    3.94    0.06    0.03   1.39`
+
+		data, err := ParseIOStat([]byte(sampleContent))
+		require.NoError(t, err)
+		require.Len(t, data.Snapshots, 1)
+		assert.Equal(t, IOStatUnitMB, data.SourceUnit)
+
+		device := data.Snapshots[0].Devices[0]
+		assert.InDelta(t, 1.50*1024, device.ReadKBPerS, 0.001)
+		assert.InDelta(t, 37.22*1024, device.WriteKBPerS, 0.001)
+		assert.InDelta(t, 0.25*1024, device.DiscardKBPerS, 0.001)
+		// Request sizes and rates are not throughput and stay as printed
+		assert.Equal(t, 45.47, device.ReadReqSize)
+		assert.Equal(t, 2.08, device.ReadsPerS)
+	})
+
+	t.Run("Default kilobyte captures are left alone", func(t *testing.T) {
+		sampleContent := `09/04/24 12:07:20
+avg-cpu:  %user   %nice %system %iowait  %steal   %idle
+           2.36    0.00    0.40    0.04    0.01   97.20
+
+Device            r/s     rkB/s   rrqm/s  %rrqm r_await rareq-sz     w/s     wkB/s   wrqm/s  %wrqm w_await wareq-sz     d/s     dkB/s   drqm/s  %drqm d_await dareq-sz     f/s f_await  aqu-sz  %util
+sda              2.08     94.38     0.31  13.07    0.89    45.47    9.58    210.39     5.55  36.68    2.74    21.96    0.09    377.20     0.00   0.00    0.95  4151.86    3.94    0.06    0.03   1.39`
+
+		data, err := ParseIOStat([]byte(sampleContent))
+		require.NoError(t, err)
+		assert.Equal(t, IOStatUnitKB, data.SourceUnit)
+		assert.Equal(t, 94.38, data.Snapshots[0].Devices[0].ReadKBPerS)
+	})
+}
+
+func TestParseIOStat_MidnightRollover(t *testing.T) {
+	header := "Linux 5.10.0-32-cloud-amd64 (ddc-test-dremio-master) \t12/31/24 \t_x86_64_\t(4 CPU)\n\n"
+
+	t.Run("Time-only lines carry the date forward across midnight", func(t *testing.T) {
+		content := header +
+			"12/31/24 23:59:58" + ioStatMidnightSnapshot +
+			"23:59:59" + ioStatMidnightSnapshot +
+			"00:00:00" + ioStatMidnightSnapshot +
+			"00:00:01" + ioStatMidnightSnapshot
+
+		data, err := ParseIOStat([]byte(content))
+		require.NoError(t, err)
+		require.Len(t, data.Snapshots, 4)
+
+		assert.Equal(t, time.Date(2024, 12, 31, 23, 59, 58, 0, time.UTC), data.Snapshots[0].Timestamp)
+		assert.Equal(t, time.Date(2024, 12, 31, 23, 59, 59, 0, time.UTC), data.Snapshots[1].Timestamp)
+		assert.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), data.Snapshots[2].Timestamp)
+		assert.Equal(t, time.Date(2025, 1, 1, 0, 0, 1, 0, time.UTC), data.Snapshots[3].Timestamp)
+	})
+
+	t.Run("Twelve hour times roll over", func(t *testing.T) {
+		content := header +
+			"12/31/24 23:59:59" + ioStatMidnightSnapshot +
+			"12:00:00 AM" + ioStatMidnightSnapshot
+
+		data, err := ParseIOStat([]byte(content))
+		require.NoError(t, err)
+		require.Len(t, data.Snapshots, 2)
+
+		assert.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), data.Snapshots[1].Timestamp)
+	})
+
+	t.Run("Stale date with an earlier time moves to the next day", func(t *testing.T) {
+		content := header +
+			"12/31/24 23:59:59" + ioStatMidnightSnapshot +
+			"12/31/24 00:00:00" + ioStatMidnightSnapshot
+
+		data, err := ParseIOStat([]byte(content))
+		require.NoError(t, err)
+		require.Len(t, data.Snapshots, 2)
+
+		assert.True(t, data.Snapshots[1].Timestamp.After(data.Snapshots[0].Timestamp))
+		assert.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), data.Snapshots[1].Timestamp)
+	})
+
+	t.Run("Dated lines crossing midnight are trusted", func(t *testing.T) {
+		content := header +
+			"12/31/24 23:59:59" + ioStatMidnightSnapshot +
+			"01/01/25 00:00:00" + ioStatMidnightSnapshot
+
+		data, err := ParseIOStat([]byte(content))
+		require.NoError(t, err)
+		require.Len(t, data.Snapshots, 2)
+
+		assert.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), data.Snapshots[1].Timestamp)
+	})
+
+	t.Run("Time-only line without an earlier date", func(t *testing.T) {
+		_, err := ParseIOStat([]byte(header + "23:59:59" + ioStatMidnightSnapshot))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "has no date")
+	})
 }
 
 func TestParseCPUStatsLine(t *testing.T) {
@@ -266,7 +404,7 @@ func TestParseDeviceStatsLine(t *testing.T) {
 	t.Run("Valid device stats line", func(t *testing.T) {
 		line := "sda              2.08     94.38     0.31  13.07    0.89    45.47    9.58    210.39     5.55  36.68    2.74    21.96    0.09    377.20     0.00   0.00    0.95  4151.86    3.94    0.06    0.03   1.39"
 
-		device, err := parseDeviceStatsLine(line)
+		device, err := parseDeviceStatsLine(line, defaultIOStatDeviceColumns)
 		require.NoError(t, err)
 
 		assert.Equal(t, "sda", device.Device)
@@ -296,20 +434,20 @@ func TestParseDeviceStatsLine(t *testing.T) {
 
 	t.Run("Invalid device stats line - insufficient fields", func(t *testing.T) {
 		line := "sda 2.08 94.38"
-		_, err := parseDeviceStatsLine(line)
+		_, err := parseDeviceStatsLine(line, defaultIOStatDeviceColumns)
 		require.Error(t, err)
 	})
 
 	t.Run("Invalid device stats line - non-numeric values", func(t *testing.T) {
 		line := "sda invalid 94.38 0.31 13.07 0.89 45.47 9.58 210.39 5.55 36.68 2.74 21.96 0.09 377.20 0.00 0.00 0.95 4151.86 3.94 0.06 0.03 1.39"
-		_, err := parseDeviceStatsLine(line)
+		_, err := parseDeviceStatsLine(line, defaultIOStatDeviceColumns)
 		require.Error(t, err)
 	})
 
 	t.Run("Device stats with zero values", func(t *testing.T) {
 		line := "sdb              0.00      0.00     0.00   0.00    0.00     0.00    0.00      0.00     0.00   0.00    0.00     0.00    0.00      0.00     0.00   0.00    0.00     0.00    0.00    0.00    0.00   0.00"
 
-		device, err := parseDeviceStatsLine(line)
+		device, err := parseDeviceStatsLine(line, defaultIOStatDeviceColumns)
 		require.NoError(t, err)
 
 		assert.Equal(t, "sdb", device.Device)
@@ -321,6 +459,72 @@ func TestParseDeviceStatsLine(t *testing.T) {
 	})
 }
 
+func TestParseIOStat_OlderSysstatColumns(t *testing.T) {
+	t.Run("RHEL 7 sysstat 10 layout", func(t *testing.T) {
+		sampleContent := `Linux 3.10.0-1160.el7.x86_64 (rhel7-node) 	09/04/24 	_x86_64_	(4 CPU)
+
+09/04/24 12:07:20
+avg-cpu:  %user   %nice %system %iowait  %steal   %idle
+           2.36    0.00    0.40    0.04    0.01   97.20
+
+Device:         rrqm/s   wrqm/s     r/s     w/s    rkB/s    wkB/s avgrq-sz avgqu-sz   await r_await w_await  svctm  %util
+sda               0.31     5.55    2.08    9.58    94.38   210.39    52.26     0.03    2.41    0.89    2.74   0.45   1.39`
+
+		data, err := ParseIOStat([]byte(sampleContent))
+		require.NoError(t, err)
+		require.Len(t, data.Snapshots, 1)
+		require.Len(t, data.Snapshots[0].Devices, 1)
+
+		device := data.Snapshots[0].Devices[0]
+		assert.Equal(t, "sda", device.Device)
+		assert.Equal(t, 0.31, device.ReadReqMergedPerS)
+		assert.Equal(t, 5.55, device.WriteReqMergedPerS)
+		assert.Equal(t, 2.08, device.ReadsPerS)
+		assert.Equal(t, 9.58, device.WritesPerS)
+		assert.Equal(t, 94.38, device.ReadKBPerS)
+		assert.Equal(t, 210.39, device.WriteKBPerS)
+		assert.Equal(t, 0.03, device.AvgQueueSize)
+		assert.Equal(t, 0.89, device.ReadAwait)
+		assert.Equal(t, 2.74, device.WriteAwait)
+		assert.Equal(t, 1.39, device.Utilization)
+		// Columns this version does not print stay zero
+		assert.Equal(t, 0.0, device.DiscardsPerS)
+		assert.Equal(t, 0.0, device.FlushesPerS)
+		assert.Equal(t, 0.0, device.ReadReqSize)
+	})
+
+	t.Run("RHEL 8 sysstat 11.7 layout", func(t *testing.T) {
+		sampleContent := `09/04/24 12:07:20
+avg-cpu:  %user   %nice %system %iowait  %steal   %idle
+           2.36    0.00    0.40    0.04    0.01   97.20
+
+Device            r/s     w/s     rkB/s     wkB/s   rrqm/s   wrqm/s  %rrqm  %wrqm r_await w_await aqu-sz rareq-sz wareq-sz  svctm  %util
+nvme0n1          2.08    9.58     94.38    210.39     0.31     5.55  13.07  36.68    0.89    2.74   0.03    45.47    21.96   0.45   1.39`
+
+		data, err := ParseIOStat([]byte(sampleContent))
+		require.NoError(t, err)
+		require.Len(t, data.Snapshots, 1)
+
+		device := data.Snapshots[0].Devices[0]
+		assert.Equal(t, "nvme0n1", device.Device)
+		assert.Equal(t, 94.38, device.ReadKBPerS)
+		assert.Equal(t, 210.39, device.WriteKBPerS)
+		assert.Equal(t, 13.07, device.ReadReqMergedPct)
+		assert.Equal(t, 36.68, device.WriteReqMergedPct)
+		assert.Equal(t, 45.47, device.ReadReqSize)
+		assert.Equal(t, 21.96, device.WriteReqSize)
+		assert.Equal(t, 0.03, device.AvgQueueSize)
+		assert.Equal(t, 1.39, device.Utilization)
+		assert.Equal(t, 0.0, device.DiscardKBPerS)
+	})
+
+	t.Run("Row not matching its header", func(t *testing.T) {
+		_, err := parseDeviceStatsLine("sda 1.0 2.0", []string{"r/s", "w/s", "%util"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "expected 4 device stat fields, got 3")
+	})
+}
+
 func TestIOStatReportDataStructure(t *testing.T) {
 	t.Run("Data structure creation and access", func(t *testing.T) {
 		// Create test data
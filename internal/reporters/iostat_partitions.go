@@ -0,0 +1,157 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporters
+
+import (
+	"regexp"
+)
+
+// What iostat reports do with the partition rows iostat -p prints beside whole devices
+const (
+	IOStatPartitionsKeep   = "keep"   // Chart each partition as a device of its own
+	IOStatPartitionsRollup = "rollup" // Fold partitions into their parent device
+	IOStatPartitionsFilter = "filter" // Drop partitions, keeping only whole devices
+)
+
+// IOStatPartitionModes returns the valid iostat partition modes
+func IOStatPartitionModes() []string {
+	return []string{IOStatPartitionsKeep, IOStatPartitionsRollup, IOStatPartitionsFilter}
+}
+
+// ioStatPartitionPatterns match the partition device names of the common Linux block
+// devices, capturing the name of the whole device: sda1 of sda, vdb2 of vdb, xvda1 of xvda,
+// nvme0n1p1 of nvme0n1, mmcblk0p2 of mmcblk0, and the p-suffixed partitions of loop, md and
+// nbd devices. Device mapper and other virtual devices have no partitions by name
+var ioStatPartitionPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`^((?:s|h|v|xv)d[a-z]+)\d+$`),
+	regexp.MustCompile(`^((?:nvme\d+n\d+)|(?:mmcblk\d+)|(?:loop\d+)|(?:md\d+)|(?:nbd\d+))p\d+$`),
+}
+
+// ioStatPartitionParent returns the whole device a partition belongs to, empty when device
+// isn't a partition
+func ioStatPartitionParent(device string) string {
+	for _, pattern := range ioStatPartitionPatterns {
+		if match := pattern.FindStringSubmatch(device); match != nil {
+			return match[1]
+		}
+	}
+	return ""
+}
+
+// ApplyIOStatPartitions returns data with its partition rows handled as mode says, and how
+// many partition rows were rolled up or filtered out. iostat -p counts a partition's I/O in
+// its parent's row too, so rolling up drops partitions whose parent is in the snapshot and
+// only sums the partitions of parents iostat didn't print. Unknown modes keep every row
+func ApplyIOStatPartitions(data *IOStatReportData, mode string) (*IOStatReportData, int) {
+	if data == nil || (mode != IOStatPartitionsRollup && mode != IOStatPartitionsFilter) {
+		return data, 0
+	}
+
+	result := *data
+	result.Snapshots = make([]IOStatSnapshot, len(data.Snapshots))
+	changed := 0
+	for i, snapshot := range data.Snapshots {
+		listed := make(map[string]bool, len(snapshot.Devices))
+		for _, device := range snapshot.Devices {
+			listed[device.Device] = true
+		}
+
+		devices := make([]DeviceStats, 0, len(snapshot.Devices))
+		rollups := make(map[string]*ioStatPartitionRollup)
+		for _, device := range snapshot.Devices {
+			if device.Parent == "" {
+				devices = append(devices, device)
+				continue
+			}
+			changed++
+			if mode == IOStatPartitionsFilter || listed[device.Parent] {
+				continue
+			}
+			rollup, ok := rollups[device.Parent]
+			if !ok {
+				// The parent takes the place of its first partition
+				rollup = &ioStatPartitionRollup{index: len(devices)}
+				rollups[device.Parent] = rollup
+				devices = append(devices, DeviceStats{Device: device.Parent})
+			}
+			rollup.add(device)
+		}
+		for _, rollup := range rollups {
+			rollup.fill(&devices[rollup.index])
+		}
+
+		snapshot.Devices = devices
+		result.Snapshots[i] = snapshot
+	}
+	return &result, changed
+}
+
+// ioStatPartitionRollup sums the partitions of a device iostat printed no row for
+type ioStatPartitionRollup struct {
+	index int // Position of the parent in the snapshot's devices
+	sum   DeviceStats
+	// Await times are weighted by each partition's requests
+	readAwait, writeAwait, discardAwait, flushAwait float64
+}
+
+// add counts one partition in the rollup
+func (r *ioStatPartitionRollup) add(partition DeviceStats) {
+	r.sum.ReadsPerS += partition.ReadsPerS
+	r.sum.ReadKBPerS += partition.ReadKBPerS
+	r.sum.ReadReqMergedPerS += partition.ReadReqMergedPerS
+	r.sum.WritesPerS += partition.WritesPerS
+	r.sum.WriteKBPerS += partition.WriteKBPerS
+	r.sum.WriteReqMergedPerS += partition.WriteReqMergedPerS
+	r.sum.DiscardsPerS += partition.DiscardsPerS
+	r.sum.DiscardKBPerS += partition.DiscardKBPerS
+	r.sum.DiscardReqMergedPerS += partition.DiscardReqMergedPerS
+	r.sum.FlushesPerS += partition.FlushesPerS
+	r.sum.AvgQueueSize += partition.AvgQueueSize
+	// Partitions share the disk, so it was busy at least as long as the busiest of them
+	r.sum.Utilization = max(r.sum.Utilization, partition.Utilization)
+
+	r.readAwait += partition.ReadAwait * partition.ReadsPerS
+	r.writeAwait += partition.WriteAwait * partition.WritesPerS
+	r.discardAwait += partition.DiscardAwait * partition.DiscardsPerS
+	r.flushAwait += partition.FlushAwait * partition.FlushesPerS
+}
+
+// fill sets the statistics of parent from the partitions added to the rollup
+func (r *ioStatPartitionRollup) fill(parent *DeviceStats) {
+	device := parent.Device
+	*parent = r.sum
+	parent.Device = device
+
+	parent.ReadAwait = ratio(r.readAwait, r.sum.ReadsPerS)
+	parent.WriteAwait = ratio(r.writeAwait, r.sum.WritesPerS)
+	parent.DiscardAwait = ratio(r.discardAwait, r.sum.DiscardsPerS)
+	parent.FlushAwait = ratio(r.flushAwait, r.sum.FlushesPerS)
+
+	parent.ReadReqSize = ratio(r.sum.ReadKBPerS, r.sum.ReadsPerS)
+	parent.WriteReqSize = ratio(r.sum.WriteKBPerS, r.sum.WritesPerS)
+	parent.DiscardReqSize = ratio(r.sum.DiscardKBPerS, r.sum.DiscardsPerS)
+
+	parent.ReadReqMergedPct = 100 * ratio(r.sum.ReadReqMergedPerS, r.sum.ReadReqMergedPerS+r.sum.ReadsPerS)
+	parent.WriteReqMergedPct = 100 * ratio(r.sum.WriteReqMergedPerS, r.sum.WriteReqMergedPerS+r.sum.WritesPerS)
+	parent.DiscardReqMergedPct = 100 * ratio(r.sum.DiscardReqMergedPerS, r.sum.DiscardReqMergedPerS+r.sum.DiscardsPerS)
+}
+
+// ratio returns a/b, 0 when b is 0
+func ratio(a, b float64) float64 {
+	if b == 0 {
+		return 0
+	}
+	return a / b
+}
@@ -0,0 +1,174 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporters
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ioStatPartitionCapture is iostat -x -p ALL output with sda and its partitions
+const ioStatPartitionCapture = `Linux 5.10.0-32-cloud-amd64 (test-system) 	09/04/24 	_x86_64_	(4 CPU)
+
+09/04/24 12:07:20
+avg-cpu:  %user   %nice %system %iowait  %steal   %idle
+           2.36    0.00    0.40    0.04    0.01   97.20
+
+Device            r/s     rkB/s   rrqm/s  %rrqm r_await rareq-sz     w/s     wkB/s   wrqm/s  %wrqm w_await wareq-sz     d/s     dkB/s   drqm/s  %drqm d_await dareq-sz     f/s f_await  aqu-sz  %util
+sda             10.00    400.00     0.00   0.00    1.00    40.00   20.00    800.00     0.00   0.00    2.00    40.00    0.00      0.00     0.00   0.00    0.00     0.00    0.00    0.00    0.10  12.00
+sda1             4.00    100.00     0.00   0.00    1.00    25.00    5.00    200.00     0.00   0.00    2.00    40.00    0.00      0.00     0.00   0.00    0.00     0.00    0.00    0.00    0.05   5.00
+sda2             6.00    300.00     0.00   0.00    1.00    50.00   15.00    600.00     0.00   0.00    2.00    40.00    0.00      0.00     0.00   0.00    0.00     0.00    0.00    0.00    0.05   9.00
+nvme0n1p1        1.00     10.00     0.00   0.00    3.00    10.00    0.00      0.00     0.00   0.00    0.00     0.00    0.00      0.00     0.00   0.00    0.00     0.00    0.00    0.00    0.01   1.00
+dm-0             2.00     20.00     0.00   0.00    1.00    10.00    2.00     20.00     0.00   0.00    1.00    10.00    0.00      0.00     0.00   0.00    0.00     0.00    0.00    0.00    0.01   1.00
+`
+
+func TestIOStatPartitionParent(t *testing.T) {
+	for device, parent := range map[string]string{
+		"sda1":      "sda",
+		"sdab12":    "sdab",
+		"vdb2":      "vdb",
+		"xvda1":     "xvda",
+		"hda3":      "hda",
+		"nvme0n1p1": "nvme0n1",
+		"mmcblk0p2": "mmcblk0",
+		"loop0p1":   "loop0",
+		"md127p1":   "md127",
+		"sda":       "",
+		"nvme0n1":   "",
+		"mmcblk0":   "",
+		"dm-0":      "",
+		"loop0":     "",
+		"md0":       "",
+	} {
+		assert.Equal(t, parent, ioStatPartitionParent(device), device)
+	}
+}
+
+func TestParseIOStat_Partitions(t *testing.T) {
+	data, err := ParseIOStat([]byte(ioStatPartitionCapture))
+	require.NoError(t, err)
+	require.Len(t, data.Snapshots, 1)
+
+	parents := map[string]string{}
+	for _, device := range data.Snapshots[0].Devices {
+		parents[device.Device] = device.Parent
+	}
+	assert.Equal(t, map[string]string{"sda": "", "sda1": "sda", "sda2": "sda", "nvme0n1p1": "nvme0n1", "dm-0": ""}, parents)
+}
+
+func TestApplyIOStatPartitions(t *testing.T) {
+	data, err := ParseIOStat([]byte(ioStatPartitionCapture))
+	require.NoError(t, err)
+
+	deviceNames := func(data *IOStatReportData) []string {
+		var names []string
+		for _, device := range data.Snapshots[0].Devices {
+			names = append(names, device.Device)
+		}
+		return names
+	}
+
+	t.Run("Keep every row", func(t *testing.T) {
+		kept, changed := ApplyIOStatPartitions(data, IOStatPartitionsKeep)
+		assert.Zero(t, changed)
+		assert.Equal(t, []string{"sda", "sda1", "sda2", "nvme0n1p1", "dm-0"}, deviceNames(kept))
+	})
+
+	t.Run("Filter out partitions", func(t *testing.T) {
+		filtered, changed := ApplyIOStatPartitions(data, IOStatPartitionsFilter)
+		assert.Equal(t, 3, changed)
+		assert.Equal(t, []string{"sda", "dm-0"}, deviceNames(filtered))
+
+		// The parsed data is left as it was
+		assert.Len(t, data.Snapshots[0].Devices, 5)
+	})
+
+	t.Run("Roll partitions up into their parents", func(t *testing.T) {
+		rolled, changed := ApplyIOStatPartitions(data, IOStatPartitionsRollup)
+		assert.Equal(t, 3, changed)
+		assert.Equal(t, []string{"sda", "nvme0n1", "dm-0"}, deviceNames(rolled))
+
+		// sda was printed, and already counts its partitions
+		assert.Equal(t, data.Snapshots[0].Devices[0], rolled.Snapshots[0].Devices[0])
+		// nvme0n1 wasn't, so it is built from its partition
+		nvme := rolled.Snapshots[0].Devices[1]
+		assert.Empty(t, nvme.Parent)
+		assert.Equal(t, 1.0, nvme.ReadsPerS)
+		assert.Equal(t, 3.0, nvme.ReadAwait)
+		assert.Equal(t, 10.0, nvme.ReadReqSize)
+	})
+
+	t.Run("Partitions of a device iostat didn't print are summed", func(t *testing.T) {
+		rolled, changed := ApplyIOStatPartitions(&IOStatReportData{Snapshots: []IOStatSnapshot{{
+			Devices: []DeviceStats{
+				{Device: "sdb1", Parent: "sdb", ReadsPerS: 10, ReadKBPerS: 100, ReadAwait: 1, ReadReqMergedPerS: 10, AvgQueueSize: 0.5, Utilization: 30},
+				{Device: "sdb2", Parent: "sdb", ReadsPerS: 30, ReadKBPerS: 900, ReadAwait: 5, ReadReqMergedPerS: 30, AvgQueueSize: 1.5, Utilization: 60},
+			},
+		}}}, IOStatPartitionsRollup)
+		assert.Equal(t, 2, changed)
+		require.Len(t, rolled.Snapshots[0].Devices, 1)
+
+		sdb := rolled.Snapshots[0].Devices[0]
+		assert.Equal(t, "sdb", sdb.Device)
+		assert.Equal(t, 40.0, sdb.ReadsPerS)
+		assert.Equal(t, 1000.0, sdb.ReadKBPerS)
+		assert.Equal(t, 25.0, sdb.ReadReqSize)
+		assert.Equal(t, 4.0, sdb.ReadAwait) // (10*1 + 30*5) / 40
+		assert.Equal(t, 50.0, sdb.ReadReqMergedPct)
+		assert.Equal(t, 2.0, sdb.AvgQueueSize)
+		assert.Equal(t, 60.0, sdb.Utilization)
+		assert.Zero(t, sdb.WriteAwait)
+	})
+
+	t.Run("Nil data", func(t *testing.T) {
+		result, changed := ApplyIOStatPartitions(nil, IOStatPartitionsFilter)
+		assert.Nil(t, result)
+		assert.Zero(t, changed)
+	})
+}
+
+func TestGenerateIOStatReportWithPartitions(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "iostat-p.txt")
+	require.NoError(t, os.WriteFile(filePath, []byte(ioStatPartitionCapture), 0o644))
+
+	for mode, devices := range map[string]float64{
+		"":                     5,
+		IOStatPartitionsKeep:   5,
+		IOStatPartitionsRollup: 3,
+		IOStatPartitionsFilter: 2,
+	} {
+		reportJSON, err := IOStatReporter{}.Generate(context.Background(), filePath, Options{
+			Location:         time.UTC,
+			IOStatThresholds: DefaultIOStatThresholds(),
+			IOStatPartitions: mode,
+		})
+		require.NoError(t, err, mode)
+
+		var report map[string]any
+		require.NoError(t, json.Unmarshal([]byte(reportJSON), &report))
+		assert.Equal(t, devices, report["unique_devices"], mode)
+		if mode == "" {
+			mode = IOStatPartitionsKeep
+		}
+		assert.Equal(t, mode, report["partitions"])
+	}
+}
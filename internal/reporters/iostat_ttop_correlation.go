@@ -0,0 +1,520 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporters
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultCorrelationMaxSkew is how far apart an iostat and a ttop snapshot may be taken
+	// and still be paired
+	DefaultCorrelationMaxSkew = 30 * time.Second
+	// DefaultCorrelationIOWaitPct is the %iowait at or above which a paired sample is listed
+	// as a high I/O wait moment
+	DefaultCorrelationIOWaitPct = 10.0
+	// correlationBusiestThreads is how many of the busiest threads are kept for each sample
+	correlationBusiestThreads = 3
+)
+
+// CorrelationPoint pairs one iostat snapshot with the closest ttop snapshot from the same node
+type CorrelationPoint struct {
+	Timestamp      time.Time    `json:"timestamp"`       // When the iostat snapshot was taken
+	TTopTimestamp  time.Time    `json:"ttop_timestamp"`  // When the paired ttop snapshot was taken, aligned to the iostat dates
+	IOWait         float64      `json:"iowait"`          // %iowait from iostat
+	CPUUsage       float64      `json:"cpu_usage"`       // 100 - %idle from iostat
+	RunningThreads int          `json:"running_threads"` // Running threads (or processes) from ttop
+	TotalThreads   int          `json:"total_threads"`   // Total threads (or processes) from ttop
+	ThreadCPU      float64      `json:"thread_cpu"`      // Summed %CPU of the rows ttop listed
+	BusiestThreads []ThreadInfo `json:"busiest_threads"` // Rows with the highest %CPU, busiest first
+}
+
+// IOStatTTopCorrelation is the time-aligned view of an iostat and a ttop capture
+type IOStatTTopCorrelation struct {
+	Processes         bool               `json:"processes,omitempty"`            // The ttop side is a plain top -b capture of processes
+	MaxSkewSeconds    float64            `json:"max_skew_seconds"`               // Largest gap allowed between paired snapshots
+	IOWaitThreshold   float64            `json:"iowait_threshold"`               // %iowait at or above which a sample counts as high I/O wait
+	IOStatSnapshots   int                `json:"iostat_snapshots"`               // Snapshots in the iostat capture
+	TTopSnapshots     int                `json:"ttop_snapshots"`                 // Snapshots in the ttop capture
+	Points            []CorrelationPoint `json:"points"`                         // Paired samples, oldest first
+	HighIOWait        int                `json:"high_iowait"`                    // Paired samples at or above the %iowait threshold
+	IOWaitVsRunning   *float64           `json:"iowait_vs_running,omitempty"`    // Pearson r of %iowait and running threads, unset when undefined
+	IOWaitVsThreadCPU *float64           `json:"iowait_vs_thread_cpu,omitempty"` // Pearson r of %iowait and summed thread %CPU, unset when undefined
+}
+
+// ParseTTopFile streams and parses a ttop or top capture from disk, interpreting its timestamps in loc
+func ParseTTopFile(filePath string, loc *time.Location) (*TTopReportData, error) {
+	file, _, err := openCapture(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	return ParseTTopReader(file, loc)
+}
+
+// CorrelateIOStatTTop time-aligns an iostat and a ttop capture from the same node. Each iostat
+// snapshot with CPU statistics is paired with the closest ttop snapshot at most maxSkew away;
+// snapshots without a partner, e.g. outside the window both captures cover, are left out
+func CorrelateIOStatTTop(iostat *IOStatReportData, ttop *TTopReportData, maxSkew time.Duration, iowaitThreshold float64) *IOStatTTopCorrelation {
+	correlation := &IOStatTTopCorrelation{
+		MaxSkewSeconds:  maxSkew.Seconds(),
+		IOWaitThreshold: iowaitThreshold,
+		Points:          make([]CorrelationPoint, 0),
+	}
+	if iostat == nil || ttop == nil {
+		return correlation
+	}
+	correlation.Processes = ttop.Processes
+	correlation.IOStatSnapshots = len(iostat.Snapshots)
+	correlation.TTopSnapshots = len(ttop.Snapshots)
+	if len(iostat.Snapshots) == 0 || len(ttop.Snapshots) == 0 {
+		return correlation
+	}
+
+	aligned := alignTTopTimestamps(ttop.Snapshots, iostat.Snapshots[0].Timestamp)
+	for _, snapshot := range iostat.Snapshots {
+		if snapshot.CPUStats == nil {
+			continue
+		}
+		index, ok := closestTimestamp(aligned, snapshot.Timestamp, maxSkew)
+		if !ok {
+			continue
+		}
+		threads := ttop.Snapshots[index]
+		point := CorrelationPoint{
+			Timestamp:      snapshot.Timestamp,
+			TTopTimestamp:  aligned[index],
+			IOWait:         snapshot.CPUStats.IOWait,
+			CPUUsage:       100.0 - snapshot.CPUStats.Idle,
+			BusiestThreads: busiestThreads(threads.Threads, correlationBusiestThreads),
+		}
+		if threads.ThreadCounts != nil {
+			point.RunningThreads = threads.ThreadCounts.Running
+			point.TotalThreads = threads.ThreadCounts.Total
+		}
+		for _, thread := range threads.Threads {
+			point.ThreadCPU += thread.CPU
+		}
+		if point.IOWait >= iowaitThreshold {
+			correlation.HighIOWait++
+		}
+		correlation.Points = append(correlation.Points, point)
+	}
+
+	iowait := make([]float64, len(correlation.Points))
+	running := make([]float64, len(correlation.Points))
+	threadCPU := make([]float64, len(correlation.Points))
+	for i, point := range correlation.Points {
+		iowait[i] = point.IOWait
+		running[i] = float64(point.RunningThreads)
+		threadCPU[i] = point.ThreadCPU
+	}
+	if r, ok := pearsonCorrelation(iowait, running); ok {
+		correlation.IOWaitVsRunning = &r
+	}
+	if r, ok := pearsonCorrelation(iowait, threadCPU); ok {
+		correlation.IOWaitVsThreadCPU = &r
+	}
+	return correlation
+}
+
+// alignTTopTimestamps moves ttop snapshots onto the dates of the iostat capture. top only prints
+// the time of day, so the parser dates every snapshot on the day it was parsed. The first snapshot
+// goes on whichever day around anchor is closest, and every later snapshot that goes back in time
+// has crossed midnight
+func alignTTopTimestamps(snapshots []TTopSnapshot, anchor time.Time) []time.Time {
+	aligned := make([]time.Time, len(snapshots))
+	if len(snapshots) == 0 {
+		return aligned
+	}
+
+	atTimeOfDay := func(day, timestamp time.Time) time.Time {
+		return time.Date(day.Year(), day.Month(), day.Day(),
+			timestamp.Hour(), timestamp.Minute(), timestamp.Second(), timestamp.Nanosecond(), anchor.Location())
+	}
+
+	day := anchor
+	best := atTimeOfDay(day, snapshots[0].Timestamp)
+	for _, offset := range []int{-1, 1} {
+		candidateDay := anchor.AddDate(0, 0, offset)
+		candidate := atTimeOfDay(candidateDay, snapshots[0].Timestamp)
+		if absDuration(candidate.Sub(anchor)) < absDuration(best.Sub(anchor)) {
+			day, best = candidateDay, candidate
+		}
+	}
+	aligned[0] = best
+
+	for i := 1; i < len(snapshots); i++ {
+		timestamp := atTimeOfDay(day, snapshots[i].Timestamp)
+		if timestamp.Before(aligned[i-1]) {
+			day = day.AddDate(0, 0, 1)
+			timestamp = atTimeOfDay(day, snapshots[i].Timestamp)
+		}
+		aligned[i] = timestamp
+	}
+	return aligned
+}
+
+// closestTimestamp returns the index of the timestamp in sorted closest to target, and false
+// when none is within maxSkew
+func closestTimestamp(sorted []time.Time, target time.Time, maxSkew time.Duration) (int, bool) {
+	next := sort.Search(len(sorted), func(i int) bool {
+		return !sorted[i].Before(target)
+	})
+	best, bestGap := -1, time.Duration(0)
+	for _, i := range []int{next - 1, next} {
+		if i < 0 || i >= len(sorted) {
+			continue
+		}
+		gap := absDuration(sorted[i].Sub(target))
+		if best == -1 || gap < bestGap {
+			best, bestGap = i, gap
+		}
+	}
+	if best == -1 || bestGap > maxSkew {
+		return 0, false
+	}
+	return best, true
+}
+
+// busiestThreads returns up to n of the threads with the highest %CPU, busiest first
+func busiestThreads(threads []ThreadInfo, n int) []ThreadInfo {
+	busiest := make([]ThreadInfo, len(threads))
+	copy(busiest, threads)
+	sort.SliceStable(busiest, func(i, j int) bool {
+		return busiest[i].CPU > busiest[j].CPU
+	})
+	if len(busiest) > n {
+		busiest = busiest[:n]
+	}
+	return busiest
+}
+
+// pearsonCorrelation returns the Pearson correlation coefficient of xs and ys, and false when
+// it is undefined: fewer than two samples or a series that never changes
+func pearsonCorrelation(xs, ys []float64) (float64, bool) {
+	n := len(xs)
+	if n < 2 || n != len(ys) {
+		return 0, false
+	}
+	var meanX, meanY float64
+	for i := range xs {
+		meanX += xs[i]
+		meanY += ys[i]
+	}
+	meanX /= float64(n)
+	meanY /= float64(n)
+
+	var covariance, varianceX, varianceY float64
+	for i := range xs {
+		dx, dy := xs[i]-meanX, ys[i]-meanY
+		covariance += dx * dy
+		varianceX += dx * dx
+		varianceY += dy * dy
+	}
+	if varianceX == 0 || varianceY == 0 {
+		return 0, false
+	}
+	return covariance / math.Sqrt(varianceX*varianceY), true
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// describeCorrelation puts a Pearson coefficient into words for the summary table
+func describeCorrelation(r *float64) string {
+	if r == nil {
+		return "n/a (not enough variation)"
+	}
+	strength := "no clear"
+	switch abs := math.Abs(*r); {
+	case abs >= 0.7:
+		strength = "strong"
+	case abs >= 0.4:
+		strength = "moderate"
+	case abs >= 0.2:
+		strength = "weak"
+	}
+	direction := ""
+	if strength != "no clear" {
+		direction = " positive"
+		if *r < 0 {
+			direction = " negative"
+		}
+	}
+	return fmt.Sprintf("%.2f (%s%s correlation)", *r, strength, direction)
+}
+
+// GenerateIOStatTTopCorrelationHTML renders the correlation as a single HTML page: a summary
+// table, %iowait charted against running threads and against thread %CPU, and the busiest
+// threads at every high I/O wait moment
+func GenerateIOStatTTopCorrelationHTML(correlation *IOStatTTopCorrelation, iostatLabel, ttopLabel string) (string, error) {
+	row, title, rows := "Thread", "Threads", "threads"
+	if correlation.Processes {
+		row, title, rows = "Process", "Processes", "processes"
+	}
+
+	labels := make([]string, 0, len(correlation.Points))
+	iowait := make([]float64, 0, len(correlation.Points))
+	running := make([]int, 0, len(correlation.Points))
+	threadCPU := make([]float64, 0, len(correlation.Points))
+	axisName := timeAxisName(time.Time{})
+	if len(correlation.Points) > 0 {
+		axisName = timeAxisName(correlation.Points[0].Timestamp)
+	}
+	var momentRows []string
+	for _, point := range correlation.Points {
+		labels = append(labels, point.Timestamp.Format("15:04:05"))
+		iowait = append(iowait, point.IOWait)
+		running = append(running, point.RunningThreads)
+		threadCPU = append(threadCPU, point.ThreadCPU)
+
+		if point.IOWait < correlation.IOWaitThreshold {
+			continue
+		}
+		var busiest []string
+		for _, thread := range point.BusiestThreads {
+			busiest = append(busiest, fmt.Sprintf("%s (%d) %.1f%%",
+				html.EscapeString(thread.Command), thread.PID, thread.CPU))
+		}
+		momentRows = append(momentRows, fmt.Sprintf(`                    <tr><td>%s</td><td>%.2f%%</td><td>%d</td><td>%.1f%%</td><td>%s</td></tr>`,
+			point.Timestamp.Format("2006-01-02 15:04:05"), point.IOWait, point.RunningThreads, point.ThreadCPU,
+			strings.Join(busiest, "<br>")))
+	}
+	if len(momentRows) == 0 {
+		momentRows = append(momentRows, fmt.Sprintf(`                    <tr><td colspan="5">No paired samples at or above %.1f%% iowait.</td></tr>`, correlation.IOWaitThreshold))
+	}
+
+	window := "none, the captures don't overlap"
+	if len(correlation.Points) > 0 {
+		window = fmt.Sprintf("%s to %s", correlation.Points[0].Timestamp.Format("2006-01-02 15:04:05"),
+			correlation.Points[len(correlation.Points)-1].Timestamp.Format("2006-01-02 15:04:05"))
+	}
+
+	labelsJSON, err := json.Marshal(labels)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal labels: %w", err)
+	}
+	iowaitJSON, err := json.Marshal(iowait)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal iowait: %w", err)
+	}
+	runningJSON, err := json.Marshal(running)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal running %s: %w", rows, err)
+	}
+	threadCPUJSON, err := json.Marshal(threadCPU)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal %s CPU: %w", rows, err)
+	}
+
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>I/O Wait and %[1]s Correlation</title>
+    <script src="/static/js/echarts.min.js"></script>
+    <style>
+        body {
+            font-family: 'Segoe UI', Tahoma, Geneva, Verdana, sans-serif;
+            margin: 0;
+            padding: 20px;
+            background-color: #f5f5f5;
+        }
+        .container {
+            max-width: 1400px;
+            margin: 0 auto;
+            background-color: white;
+            border-radius: 8px;
+            box-shadow: 0 2px 10px rgba(0,0,0,0.1);
+            overflow: hidden;
+        }
+        .header {
+            background: linear-gradient(135deg, #06b6d4 0%%, #0891b2 100%%);
+            color: white;
+            padding: 30px;
+            text-align: center;
+        }
+        .header h1 {
+            margin: 0 0 10px 0;
+            font-size: 2.5em;
+            font-weight: 300;
+        }
+        .header p {
+            margin: 0;
+            font-size: 1.1em;
+            opacity: 0.9;
+        }
+        .chart-container {
+            padding: 30px;
+            border-bottom: 1px solid #eee;
+        }
+        .chart-container:last-child {
+            border-bottom: none;
+        }
+        .chart-title {
+            font-size: 1.5em;
+            margin-bottom: 20px;
+            color: #333;
+            text-align: center;
+        }
+        .summary-table {
+            width: 100%%;
+            border-collapse: collapse;
+            font-size: 0.9em;
+        }
+        .summary-table th,
+        .summary-table td {
+            padding: 8px 12px;
+            border-bottom: 1px solid #eee;
+            text-align: left;
+            vertical-align: top;
+        }
+        .summary-table thead th {
+            background-color: #f8f9fa;
+            color: #333;
+        }
+        .chart {
+            width: 100%%;
+            height: 400px;
+        }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>I/O Wait and %[1]s Correlation</h1>
+            <p>%[2]s with %[3]s</p>
+        </div>
+
+        <div class="chart-container">
+            <div class="chart-title">Summary</div>
+            <table class="summary-table">
+                <tbody>
+                    <tr><th>Paired samples</th><td>%[4]d of %[5]d iostat and %[6]d ttop snapshots, at most %[7]gs apart</td></tr>
+                    <tr><th>Overlapping window</th><td>%[8]s</td></tr>
+                    <tr><th>High I/O wait samples</th><td>%[9]d at or above %.1[10]f%% iowait</td></tr>
+                    <tr><th>%%iowait vs running %[11]s</th><td>%[12]s</td></tr>
+                    <tr><th>%%iowait vs %[13]s %%CPU</th><td>%[14]s</td></tr>
+                </tbody>
+            </table>
+        </div>
+
+        <div class="chart-container">
+            <div class="chart-title">I/O Wait vs Running %[21]s</div>
+            <div id="runningChart" class="chart"></div>
+        </div>
+
+        <div class="chart-container">
+            <div class="chart-title">I/O Wait vs %[1]s CPU</div>
+            <div id="cpuChart" class="chart"></div>
+        </div>
+
+        <div class="chart-container">
+            <div class="chart-title">Busiest %[21]s at High I/O Wait</div>
+            <table class="summary-table">
+                <thead>
+                    <tr>
+                        <th>Time</th>
+                        <th>%%iowait</th>
+                        <th>Running %[11]s</th>
+                        <th>%[1]s %%CPU</th>
+                        <th>Busiest %[11]s</th>
+                    </tr>
+                </thead>
+                <tbody>
+%[15]s
+                </tbody>
+            </table>
+        </div>
+    </div>
+
+    <script>
+        try {
+            const labels = %[16]s;
+            const iowait = %[17]s;
+            const charts = [];
+
+            function dualAxisChart(id, name, data) {
+                const chart = echarts.init(document.getElementById(id));
+                chart.setOption({
+                    tooltip: { trigger: 'axis' },
+                    legend: { data: ['%%iowait', name] },
+                    grid: { left: '3%%', right: '4%%', bottom: '3%%', containLabel: true },
+                    xAxis: { type: 'category', name: '%[18]s', nameLocation: 'middle', nameGap: 30, boundaryGap: false, data: labels },
+                    yAxis: [
+                        { type: 'value', name: '%%iowait', min: 0, max: 100 },
+                        { type: 'value', name: name, min: 0 }
+                    ],
+                    series: [
+                        { name: '%%iowait', type: 'line', smooth: true, data: iowait },
+                        { name: name, type: 'line', smooth: true, yAxisIndex: 1, data: data }
+                    ]
+                });
+                charts.push(chart);
+            }
+
+            dualAxisChart('runningChart', 'Running %[11]s', %[19]s);
+            dualAxisChart('cpuChart', '%[1]s %%CPU', %[20]s);
+
+            // Handle window resize
+            window.addEventListener('resize', function() {
+                charts.forEach(function(chart) {
+                    chart.resize();
+                });
+            });
+
+        } catch (error) {
+            console.error('Error initializing charts:', error);
+            document.body.innerHTML += '<div style="color: red; padding: 20px; background: #ffe6e6; border: 1px solid red; margin: 20px;">Error initializing charts: ' + error.message + '</div>';
+        }
+    </script>
+</body>
+</html>`,
+		row,
+		html.EscapeString(iostatLabel),
+		html.EscapeString(ttopLabel),
+		len(correlation.Points),
+		correlation.IOStatSnapshots,
+		correlation.TTopSnapshots,
+		correlation.MaxSkewSeconds,
+		window,
+		correlation.HighIOWait,
+		correlation.IOWaitThreshold,
+		rows,
+		describeCorrelation(correlation.IOWaitVsRunning),
+		strings.ToLower(row),
+		describeCorrelation(correlation.IOWaitVsThreadCPU),
+		strings.Join(momentRows, "\n"),
+		labelsJSON,
+		iowaitJSON,
+		axisName,
+		runningJSON,
+		threadCPUJSON,
+		title), nil
+}
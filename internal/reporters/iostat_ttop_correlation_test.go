@@ -0,0 +1,158 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporters
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCorrelateIOStatTTop(t *testing.T) {
+	iostatAt := func(start time.Time, iowaits ...float64) *IOStatReportData {
+		data := &IOStatReportData{}
+		for i, iowait := range iowaits {
+			data.Snapshots = append(data.Snapshots, IOStatSnapshot{
+				Timestamp: start.Add(time.Duration(i) * 10 * time.Second),
+				CPUStats:  &CPUStats{IOWait: iowait, Idle: 50},
+			})
+		}
+		return data
+	}
+	// ttop snapshots are dated on the day they were parsed, which is never the capture's day
+	parsedDay := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	ttopAt := func(start time.Time, running ...int) *TTopReportData {
+		data := &TTopReportData{}
+		for i, count := range running {
+			at := start.Add(time.Duration(i) * 10 * time.Second)
+			data.Snapshots = append(data.Snapshots, TTopSnapshot{
+				Timestamp:    time.Date(parsedDay.Year(), parsedDay.Month(), parsedDay.Day(), at.Hour(), at.Minute(), at.Second(), 0, time.UTC),
+				ThreadCounts: &ThreadCounts{Total: 100, Running: count},
+				Threads: []ThreadInfo{
+					{PID: 1, Command: "idle-thread", CPU: 1},
+					{PID: 2, Command: "flush-thread", CPU: float64(count * 10)},
+				},
+			})
+		}
+		return data
+	}
+
+	t.Run("Pair snapshots by time of day", func(t *testing.T) {
+		start := time.Date(2024, 9, 4, 12, 0, 0, 0, time.UTC)
+		// ttop started 3s after iostat and took one snapshot fewer
+		correlation := CorrelateIOStatTTop(iostatAt(start, 1, 20, 40, 5), ttopAt(start.Add(3*time.Second), 1, 4, 8), DefaultCorrelationMaxSkew, 10)
+
+		require.Len(t, correlation.Points, 4)
+		assert.Equal(t, 4, correlation.IOStatSnapshots)
+		assert.Equal(t, 3, correlation.TTopSnapshots)
+		assert.Equal(t, start.Add(23*time.Second), correlation.Points[2].TTopTimestamp)
+		assert.Equal(t, 40.0, correlation.Points[2].IOWait)
+		assert.Equal(t, 50.0, correlation.Points[2].CPUUsage)
+		assert.Equal(t, 8, correlation.Points[2].RunningThreads)
+		assert.Equal(t, 81.0, correlation.Points[2].ThreadCPU)
+		assert.Equal(t, "flush-thread", correlation.Points[2].BusiestThreads[0].Command)
+		assert.Equal(t, 2, correlation.HighIOWait)
+
+		require.NotNil(t, correlation.IOWaitVsRunning)
+		assert.Greater(t, *correlation.IOWaitVsRunning, 0.5)
+		require.NotNil(t, correlation.IOWaitVsThreadCPU)
+	})
+
+	t.Run("Leave out snapshots further apart than the max skew", func(t *testing.T) {
+		start := time.Date(2024, 9, 4, 12, 0, 0, 0, time.UTC)
+		correlation := CorrelateIOStatTTop(iostatAt(start, 1, 2, 3), ttopAt(start.Add(25*time.Second), 1, 2), 10*time.Second, 10)
+		require.Len(t, correlation.Points, 1)
+		assert.Equal(t, start.Add(20*time.Second), correlation.Points[0].Timestamp)
+
+		correlation = CorrelateIOStatTTop(iostatAt(start, 1, 2), ttopAt(start.Add(time.Hour), 1, 2), 10*time.Second, 10)
+		assert.Empty(t, correlation.Points)
+		assert.Nil(t, correlation.IOWaitVsRunning)
+	})
+
+	t.Run("Follow captures across midnight", func(t *testing.T) {
+		start := time.Date(2024, 9, 4, 23, 59, 50, 0, time.UTC)
+		correlation := CorrelateIOStatTTop(iostatAt(start, 1, 2, 3), ttopAt(start, 1, 2, 3), DefaultCorrelationMaxSkew, 10)
+		require.Len(t, correlation.Points, 3)
+		assert.Equal(t, time.Date(2024, 9, 5, 0, 0, 10, 0, time.UTC), correlation.Points[2].TTopTimestamp)
+		assert.Equal(t, 3, correlation.Points[2].RunningThreads)
+
+		// ttop started just before midnight, iostat just after
+		correlation = CorrelateIOStatTTop(iostatAt(start.Add(15*time.Second), 1), ttopAt(start, 1, 2), DefaultCorrelationMaxSkew, 10)
+		require.Len(t, correlation.Points, 1)
+		assert.Equal(t, time.Date(2024, 9, 5, 0, 0, 0, 0, time.UTC), correlation.Points[0].TTopTimestamp)
+	})
+
+	t.Run("Constant series have no correlation", func(t *testing.T) {
+		start := time.Date(2024, 9, 4, 12, 0, 0, 0, time.UTC)
+		correlation := CorrelateIOStatTTop(iostatAt(start, 5, 5, 5), ttopAt(start, 1, 2, 3), DefaultCorrelationMaxSkew, 10)
+		require.Len(t, correlation.Points, 3)
+		assert.Nil(t, correlation.IOWaitVsRunning)
+		assert.Nil(t, correlation.IOWaitVsThreadCPU)
+	})
+}
+
+func TestGenerateIOStatTTopCorrelationHTML(t *testing.T) {
+	r := 0.85
+	correlation := &IOStatTTopCorrelation{
+		MaxSkewSeconds:  30,
+		IOWaitThreshold: 10,
+		IOStatSnapshots: 2,
+		TTopSnapshots:   2,
+		HighIOWait:      1,
+		IOWaitVsRunning: &r,
+		Points: []CorrelationPoint{
+			{Timestamp: time.Date(2024, 9, 4, 12, 7, 20, 0, time.UTC), IOWait: 2, RunningThreads: 1},
+			{
+				Timestamp:      time.Date(2024, 9, 4, 12, 7, 30, 0, time.UTC),
+				IOWait:         35,
+				RunningThreads: 9,
+				ThreadCPU:      120,
+				BusiestThreads: []ThreadInfo{{PID: 42, Command: "<flusher>", CPU: 95.5}},
+			},
+		},
+	}
+
+	t.Run("Render summary, charts and high I/O wait moments", func(t *testing.T) {
+		html, err := GenerateIOStatTTopCorrelationHTML(correlation, "iostat.txt (report 1)", "ttop <node1> (report 2)")
+		require.NoError(t, err)
+
+		assert.Contains(t, html, "I/O Wait and Thread Correlation")
+		assert.Contains(t, html, "ttop &lt;node1&gt; (report 2)")
+		assert.Contains(t, html, "2 of 2 iostat and 2 ttop snapshots, at most 30s apart")
+		assert.Contains(t, html, "2024-09-04 12:07:20 to 2024-09-04 12:07:30")
+		assert.Contains(t, html, "1 at or above 10.0% iowait")
+		assert.Contains(t, html, "0.85 (strong positive correlation)")
+		assert.Contains(t, html, "n/a (not enough variation)")
+		assert.Contains(t, html, "&lt;flusher&gt; (42) 95.5%")
+		assert.Contains(t, html, `["12:07:20","12:07:30"]`)
+		assert.Contains(t, html, "Busiest Threads at High I/O Wait")
+		assert.Contains(t, html, `id="runningChart"`)
+		assert.Contains(t, html, `id="cpuChart"`)
+		assert.NotContains(t, html, "%!")
+	})
+
+	t.Run("Name processes for plain top captures", func(t *testing.T) {
+		processes := *correlation
+		processes.Processes = true
+		html, err := GenerateIOStatTTopCorrelationHTML(&processes, "iostat", "top")
+		require.NoError(t, err)
+		assert.Contains(t, html, "I/O Wait and Process Correlation")
+		assert.Contains(t, html, "I/O Wait vs Running Processes")
+		assert.Contains(t, html, "Running processes")
+		assert.NotContains(t, html, "Processs")
+	})
+}
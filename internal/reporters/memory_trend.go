@@ -0,0 +1,142 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporters
+
+import (
+	"fmt"
+	"math"
+)
+
+// Thresholds for reporting a rising memory series as possible growth. The fit has to explain
+// most of the variation and add a meaningful share of the starting value, so a sawtooth heap or
+// a small drift isn't flagged
+const (
+	memoryGrowthMinSamples  = 5
+	memoryGrowthMinRSquared = 0.8
+	memoryGrowthMinPct      = 10.0
+)
+
+// TrendResult is the least squares line through a series of evenly spaced samples
+type TrendResult struct {
+	Samples   int     `json:"samples"`
+	Slope     float64 `json:"slope"`     // Change per sample
+	Intercept float64 `json:"intercept"` // Fitted value of the first sample
+	RSquared  float64 `json:"r_squared"` // How much of the variation the line explains, 0 to 1
+}
+
+// Growth is the change the line predicts from the first to the last sample
+func (t TrendResult) Growth() float64 {
+	if t.Samples < 2 {
+		return 0
+	}
+	return t.Slope * float64(t.Samples-1)
+}
+
+// MemoryGrowthFinding flags resident memory that rose steadily across a capture, the
+// signature of a leak
+type MemoryGrowthFinding struct {
+	GrowthMiB float64 `json:"growth_mib"`
+	Minutes   float64 `json:"minutes"`
+	RSquared  float64 `json:"r_squared"`
+	Message   string  `json:"message"`
+}
+
+// detectMemoryTrend fits a line through points by linear regression, taking each point as one
+// sample interval after the previous. A flat series has an R² of 0
+func detectMemoryTrend(points []float64) TrendResult {
+	result := TrendResult{Samples: len(points)}
+	if len(points) == 0 {
+		return result
+	}
+	if len(points) == 1 {
+		result.Intercept = points[0]
+		return result
+	}
+
+	n := float64(len(points))
+	var sumX, sumY float64
+	for i, y := range points {
+		sumX += float64(i)
+		sumY += y
+	}
+	meanX, meanY := sumX/n, sumY/n
+
+	var covariance, varianceX, varianceY float64
+	for i, y := range points {
+		dx, dy := float64(i)-meanX, y-meanY
+		covariance += dx * dy
+		varianceX += dx * dx
+		varianceY += dy * dy
+	}
+
+	result.Slope = covariance / varianceX
+	result.Intercept = meanY - result.Slope*meanX
+	if varianceY > 0 {
+		result.RSquared = covariance * covariance / (varianceX * varianceY)
+	}
+	return result
+}
+
+// findTTopMemoryGrowth checks the resident memory of the captured process for steady growth,
+// nil when there is none. Threads of a process share its resident memory, so the largest RES
+// of each snapshot is the process's
+func findTTopMemoryGrowth(data *TTopReportData) *MemoryGrowthFinding {
+	var points []float64
+	var first, last int
+	for i, snapshot := range data.Snapshots {
+		if len(snapshot.Threads) == 0 {
+			continue
+		}
+		res := 0.0
+		for _, thread := range snapshot.Threads {
+			res = math.Max(res, thread.RES)
+		}
+		if len(points) == 0 {
+			first = i
+		}
+		last = i
+		points = append(points, res)
+	}
+	if len(points) < memoryGrowthMinSamples {
+		return nil
+	}
+
+	trend := detectMemoryTrend(points)
+	start := trend.Intercept
+	if start <= 0 {
+		start = points[0]
+	}
+	growth := trend.Growth()
+	if trend.RSquared < memoryGrowthMinRSquared || growth <= 0 || start <= 0 || growth/start*100 < memoryGrowthMinPct {
+		return nil
+	}
+
+	finding := &MemoryGrowthFinding{
+		GrowthMiB: growth / (1024 * 1024),
+		Minutes:   data.Snapshots[last].Timestamp.Sub(data.Snapshots[first].Timestamp).Minutes(),
+		RSquared:  trend.RSquared,
+	}
+	finding.Message = fmt.Sprintf("Possible memory growth: +%.1f MiB over %.0f minutes (R²=%.2f)",
+		finding.GrowthMiB, finding.Minutes, finding.RSquared)
+	return finding
+}
+
+// generateMemoryGrowthHTML renders the memory growth finding of a ttop report
+func generateMemoryGrowthHTML(finding *MemoryGrowthFinding) string {
+	if finding == nil {
+		return `<p class="findings-ok">No steady memory growth detected.</p>`
+	}
+	return fmt.Sprintf(`<p class="findings-warning">%s</p>`, finding.Message)
+}
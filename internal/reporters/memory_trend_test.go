@@ -0,0 +1,99 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporters
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectMemoryTrend(t *testing.T) {
+	t.Run("Straight line", func(t *testing.T) {
+		trend := detectMemoryTrend([]float64{100, 110, 120, 130, 140})
+		assert.Equal(t, 5, trend.Samples)
+		assert.InDelta(t, 10, trend.Slope, 1e-9)
+		assert.InDelta(t, 100, trend.Intercept, 1e-9)
+		assert.InDelta(t, 1, trend.RSquared, 1e-9)
+		assert.InDelta(t, 40, trend.Growth(), 1e-9)
+	})
+
+	t.Run("Flat series", func(t *testing.T) {
+		trend := detectMemoryTrend([]float64{50, 50, 50, 50})
+		assert.Zero(t, trend.Slope)
+		assert.Zero(t, trend.RSquared)
+		assert.InDelta(t, 50, trend.Intercept, 1e-9)
+	})
+
+	t.Run("Sawtooth fits poorly", func(t *testing.T) {
+		trend := detectMemoryTrend([]float64{100, 200, 100, 200, 100, 200, 100})
+		assert.Less(t, trend.RSquared, 0.1)
+	})
+
+	t.Run("Too few points", func(t *testing.T) {
+		assert.Equal(t, TrendResult{}, detectMemoryTrend(nil))
+		trend := detectMemoryTrend([]float64{42})
+		assert.Equal(t, 42.0, trend.Intercept)
+		assert.Zero(t, trend.Growth())
+	})
+}
+
+func TestFindTTopMemoryGrowth(t *testing.T) {
+	const mib = 1024 * 1024
+	start := time.Date(2024, 9, 4, 12, 0, 0, 0, time.UTC)
+	capture := func(resMiB ...float64) *TTopReportData {
+		data := &TTopReportData{}
+		for i, res := range resMiB {
+			data.Snapshots = append(data.Snapshots, TTopSnapshot{
+				Timestamp: start.Add(time.Duration(i) * 5 * time.Minute),
+				Threads: []ThreadInfo{
+					{PID: 1, Command: "java", RES: res * mib},
+					{PID: 2, Command: "java", RES: res * mib},
+				},
+			})
+		}
+		return data
+	}
+
+	t.Run("Steady growth", func(t *testing.T) {
+		finding := findTTopMemoryGrowth(capture(1000, 1050, 1100, 1160, 1200, 1250, 1300))
+		require.NotNil(t, finding)
+		assert.InDelta(t, 300, finding.GrowthMiB, 5)
+		assert.Equal(t, 30.0, finding.Minutes)
+		assert.Greater(t, finding.RSquared, 0.99)
+		assert.Contains(t, finding.Message, "Possible memory growth: +")
+		assert.Contains(t, finding.Message, "MiB over 30 minutes (R²=1.00)")
+
+		html := generateMemoryGrowthHTML(finding)
+		assert.Contains(t, html, `class="findings-warning"`)
+	})
+
+	t.Run("No finding", func(t *testing.T) {
+		testCases := map[string]*TTopReportData{
+			"Stable":        capture(1000, 1001, 999, 1000, 1002, 1000),
+			"Small drift":   capture(1000, 1010, 1020, 1030, 1040, 1050),
+			"Shrinking":     capture(1300, 1250, 1200, 1150, 1100),
+			"Noisy":         capture(1000, 1600, 1050, 1550, 1100, 1500, 1200),
+			"Too few":       capture(1000, 1500, 2000),
+			"No thread RES": {Snapshots: []TTopSnapshot{{Timestamp: start}}},
+		}
+		for name, data := range testCases {
+			assert.Nil(t, findTTopMemoryGrowth(data), name)
+		}
+		assert.Contains(t, generateMemoryGrowthHTML(nil), "No steady memory growth detected")
+	})
+}
@@ -0,0 +1,414 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporters
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"strings"
+)
+
+// maxQueryTextLength is how much of each query's SQL the slowest queries table shows
+const maxQueryTextLength = 300
+
+// queryOutcomeChartItem is a slice of the outcome pie chart
+type queryOutcomeChartItem struct {
+	Name  string `json:"name"`
+	Value int    `json:"value"`
+}
+
+// GenerateQueriesHTML generates an HTML report for an aggregated queries.json log.
+// The report shows summary statistics, the outcome breakdown, the duration distribution,
+// the most common failure reasons and the slowest queries.
+func GenerateQueriesHTML(data *QueriesReportData) (string, error) {
+	if data == nil || data.TotalQueries == 0 {
+		return generateEmptyQueriesHTML(), nil
+	}
+
+	outcomes := make([]queryOutcomeChartItem, 0, len(data.Outcomes))
+	for _, outcome := range data.Outcomes {
+		outcomes = append(outcomes, queryOutcomeChartItem{Name: outcome.Outcome, Value: outcome.Count})
+	}
+	outcomesJSON, err := json.Marshal(outcomes)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal outcomes: %w", err)
+	}
+
+	bucketLabels := make([]string, 0, len(data.DurationBuckets))
+	bucketCounts := make([]int, 0, len(data.DurationBuckets))
+	for _, bucket := range data.DurationBuckets {
+		bucketLabels = append(bucketLabels, bucket.Label)
+		bucketCounts = append(bucketCounts, bucket.Count)
+	}
+	bucketLabelsJSON, err := json.Marshal(bucketLabels)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal duration buckets: %w", err)
+	}
+	bucketCountsJSON, err := json.Marshal(bucketCounts)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal duration counts: %w", err)
+	}
+
+	timeRange := "N/A"
+	if !data.FirstStart.IsZero() && !data.LastFinish.IsZero() {
+		timeRange = fmt.Sprintf("%s to %s UTC",
+			data.FirstStart.Format("2006-01-02 15:04:05"), data.LastFinish.Format("2006-01-02 15:04:05"))
+	}
+
+	report := fmt.Sprintf(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Dremio Queries Report</title>
+    <script src="/static/js/echarts.min.js"></script>
+    <style>
+        body {
+            font-family: 'Segoe UI', Tahoma, Geneva, Verdana, sans-serif;
+            margin: 0;
+            padding: 20px;
+            background-color: #f5f5f5;
+        }
+        .container {
+            max-width: 1400px;
+            margin: 0 auto;
+            background-color: white;
+            border-radius: 8px;
+            box-shadow: 0 2px 10px rgba(0,0,0,0.1);
+            overflow: hidden;
+        }
+        .header {
+            background: linear-gradient(135deg, #06b6d4 0%%, #0891b2 100%%);
+            color: white;
+            padding: 30px;
+            text-align: center;
+        }
+        .header h1 {
+            margin: 0 0 10px 0;
+            font-size: 2.5em;
+            font-weight: 300;
+        }
+        .header p {
+            margin: 0;
+            font-size: 1.1em;
+            opacity: 0.9;
+        }
+        .stats-grid {
+            display: grid;
+            grid-template-columns: repeat(auto-fit, minmax(200px, 1fr));
+            gap: 20px;
+            padding: 30px;
+            background-color: #f8f9fa;
+        }
+        .stat-card {
+            background: white;
+            padding: 20px;
+            border-radius: 8px;
+            text-align: center;
+            box-shadow: 0 2px 4px rgba(0,0,0,0.1);
+        }
+        .stat-value {
+            font-size: 2em;
+            font-weight: bold;
+            color: #06b6d4;
+            margin-bottom: 5px;
+        }
+        .stat-label {
+            color: #666;
+            font-size: 0.9em;
+        }
+        .chart-container {
+            padding: 30px;
+            border-bottom: 1px solid #eee;
+        }
+        .chart-container:last-child {
+            border-bottom: none;
+        }
+        .chart-title {
+            font-size: 1.5em;
+            margin-bottom: 20px;
+            color: #333;
+            text-align: center;
+        }
+        .chart {
+            width: 100%%;
+            height: 400px;
+        }
+        .summary-table {
+            width: 100%%;
+            border-collapse: collapse;
+            font-size: 0.9em;
+        }
+        .summary-table th,
+        .summary-table td {
+            padding: 8px 12px;
+            border-bottom: 1px solid #eee;
+            text-align: right;
+        }
+        .summary-table th:first-child,
+        .summary-table td:first-child,
+        .summary-table .text-cell {
+            text-align: left;
+        }
+        .summary-table thead th {
+            background-color: #f8f9fa;
+            color: #333;
+        }
+        .query-cell {
+            font-family: monospace;
+            white-space: pre-wrap;
+            word-break: break-word;
+            text-align: left;
+        }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>Dremio Queries Report</h1>
+            <p>%s</p>
+        </div>
+
+        <div class="stats-grid">
+            <div class="stat-card">
+                <div class="stat-value">%d</div>
+                <div class="stat-label">Queries</div>
+            </div>
+            <div class="stat-card">
+                <div class="stat-value">%d</div>
+                <div class="stat-label">Failed</div>
+            </div>
+            <div class="stat-card">
+                <div class="stat-value">%.0f ms</div>
+                <div class="stat-label">p50 Duration</div>
+            </div>
+            <div class="stat-card">
+                <div class="stat-value">%.0f ms</div>
+                <div class="stat-label">p95 Duration</div>
+            </div>
+            <div class="stat-card">
+                <div class="stat-value">%d ms</div>
+                <div class="stat-label">Max Duration</div>
+            </div>
+            <div class="stat-card">
+                <div class="stat-value">%.2f MiB</div>
+                <div class="stat-label">Max Memory</div>
+            </div>
+        </div>
+
+        <div class="chart-container">
+            <div class="chart-title">Query Outcomes</div>
+            <div id="outcomeChart" class="chart"></div>
+        </div>
+
+        <div class="chart-container">
+            <div class="chart-title">Duration Distribution</div>
+            <div id="durationChart" class="chart"></div>
+            %s
+        </div>
+
+        <div class="chart-container">
+            <div class="chart-title">Failure Reasons</div>
+            %s
+        </div>
+
+        <div class="chart-container">
+            <div class="chart-title">Slowest Queries</div>
+            %s
+        </div>
+    </div>
+
+    <script>
+        try {
+            const outcomeChart = echarts.init(document.getElementById('outcomeChart'));
+            outcomeChart.setOption({
+                tooltip: { trigger: 'item', formatter: '{b}: {c} ({d}%%)' },
+                legend: { orient: 'vertical', left: 'left' },
+                series: [
+                    { name: 'Outcome', type: 'pie', radius: '60%%', data: %s }
+                ]
+            });
+
+            const durationChart = echarts.init(document.getElementById('durationChart'));
+            durationChart.setOption({
+                tooltip: { trigger: 'axis', axisPointer: { type: 'shadow' } },
+                grid: { left: '3%%', right: '4%%', bottom: '3%%', containLabel: true },
+                xAxis: { type: 'category', name: 'Duration', data: %s },
+                yAxis: { type: 'value', name: 'Queries', minInterval: 1 },
+                series: [
+                    { name: 'Queries', type: 'bar', data: %s }
+                ]
+            });
+
+            // Handle window resize
+            window.addEventListener('resize', function() {
+                outcomeChart.resize();
+                durationChart.resize();
+            });
+
+        } catch (error) {
+            console.error('Error initializing charts:', error);
+            document.body.innerHTML += '<div style="color: red; padding: 20px; background: #ffe6e6; border: 1px solid red; margin: 20px;">Error initializing charts: ' + error.message + '</div>';
+        }
+    </script>
+</body>
+</html>`,
+		html.EscapeString(timeRange),
+		data.TotalQueries,
+		data.FailedQueries,
+		data.DurationMs.P50,
+		data.DurationMs.P95,
+		data.MaxDurationMs,
+		float64(data.MaxMemoryBytes)/(1024*1024),
+		generateQueryPercentileTableHTML(data),
+		generateFailureReasonTableHTML(data.FailureReasons),
+		generateSlowestQueriesTableHTML(data.SlowestQueries),
+		outcomesJSON,
+		bucketLabelsJSON,
+		bucketCountsJSON)
+
+	return report, nil
+}
+
+// generateEmptyQueriesHTML returns HTML for when the log contains no queries
+func generateEmptyQueriesHTML() string {
+	return `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Dremio Queries Report</title>
+</head>
+<body>
+    <h1>Dremio Queries Report</h1>
+    <p>No data available for analysis.</p>
+</body>
+</html>`
+}
+
+// generateQueryPercentileTableHTML renders the duration and memory percentiles as an HTML table
+func generateQueryPercentileTableHTML(data *QueriesReportData) string {
+	return fmt.Sprintf(`<table class="summary-table">
+            <thead>
+                <tr>
+                    <th>Metric</th>
+                    <th>p50</th>
+                    <th>p95</th>
+                    <th>p99</th>
+                    <th>Max</th>
+                </tr>
+            </thead>
+            <tbody>
+                <tr>
+                    <td>Duration (ms)</td>
+                    <td>%.0f</td>
+                    <td>%.0f</td>
+                    <td>%.0f</td>
+                    <td>%d</td>
+                </tr>
+                <tr>
+                    <td>Memory Allocated (MiB)</td>
+                    <td>%.2f</td>
+                    <td>%.2f</td>
+                    <td>%.2f</td>
+                    <td>%.2f</td>
+                </tr>
+            </tbody>
+        </table>`,
+		data.DurationMs.P50, data.DurationMs.P95, data.DurationMs.P99, data.MaxDurationMs,
+		data.MemoryBytes.P50/(1024*1024), data.MemoryBytes.P95/(1024*1024), data.MemoryBytes.P99/(1024*1024),
+		float64(data.MaxMemoryBytes)/(1024*1024))
+}
+
+// generateFailureReasonTableHTML renders the most common failure reasons as an HTML table
+func generateFailureReasonTableHTML(reasons []QueryFailureReason) string {
+	if len(reasons) == 0 {
+		return `<p>No failed queries.</p>`
+	}
+
+	var rows []string
+	for _, reason := range reasons {
+		rows = append(rows, fmt.Sprintf(`                <tr>
+                    <td>%s</td>
+                    <td>%d</td>
+                </tr>`, html.EscapeString(reason.Reason), reason.Count))
+	}
+
+	return fmt.Sprintf(`<table class="summary-table">
+            <thead>
+                <tr>
+                    <th>Reason</th>
+                    <th>Queries</th>
+                </tr>
+            </thead>
+            <tbody>
+%s
+            </tbody>
+        </table>`, strings.Join(rows, "\n"))
+}
+
+// generateSlowestQueriesTableHTML renders the slowest queries as an HTML table
+func generateSlowestQueriesTableHTML(queries []QuerySummary) string {
+	if len(queries) == 0 {
+		return `<p>No queries recorded.</p>`
+	}
+
+	var rows []string
+	for _, query := range queries {
+		rows = append(rows, fmt.Sprintf(`                <tr>
+                    <td>%s</td>
+                    <td class="text-cell">%s</td>
+                    <td class="text-cell">%s</td>
+                    <td class="text-cell">%s</td>
+                    <td>%d</td>
+                    <td>%.2f</td>
+                    <td class="query-cell">%s</td>
+                </tr>`,
+			html.EscapeString(query.QueryID),
+			html.EscapeString(valueOrNA(query.Username)),
+			html.EscapeString(query.Outcome),
+			query.Start.Format("2006-01-02 15:04:05"),
+			query.DurationMs,
+			float64(query.MemoryAllocated)/(1024*1024),
+			html.EscapeString(truncateQueryText(query.QueryText))))
+	}
+
+	return fmt.Sprintf(`<table class="summary-table">
+            <thead>
+                <tr>
+                    <th>Query ID</th>
+                    <th class="text-cell">User</th>
+                    <th class="text-cell">Outcome</th>
+                    <th class="text-cell">Start (UTC)</th>
+                    <th>Duration (ms)</th>
+                    <th>Memory (MiB)</th>
+                    <th class="text-cell">Query</th>
+                </tr>
+            </thead>
+            <tbody>
+%s
+            </tbody>
+        </table>`, strings.Join(rows, "\n"))
+}
+
+// truncateQueryText shortens long SQL so a single query doesn't dominate the table
+func truncateQueryText(text string) string {
+	runes := []rune(text)
+	if len(runes) <= maxQueryTextLength {
+		return text
+	}
+	return string(runes[:maxQueryTextLength]) + "..."
+}
@@ -0,0 +1,66 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporters
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rsvihladremio/ddd/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateQueriesHTML(t *testing.T) {
+	t.Run("Sample query log", func(t *testing.T) {
+		data, err := ParseQueriesJSON(testutil.SampleFiles["queries_json"].Content)
+		require.NoError(t, err)
+
+		html, err := GenerateQueriesHTML(data)
+		require.NoError(t, err)
+
+		assert.Contains(t, html, "<title>Dremio Queries Report</title>")
+		assert.Contains(t, html, "2024-09-04 12:07:20 to 2024-09-04 12:08:32 UTC")
+		assert.Contains(t, html, "45000 ms")
+		assert.Contains(t, html, "512.00 MiB")
+		assert.Contains(t, html, `{"name":"COMPLETED","value":2}`)
+		assert.Contains(t, html, `"1-5s","5-30s","30s-1m","1-5m","5-30m"`)
+		assert.Contains(t, html, "data: [1,2,0,1,0,0,0]")
+		assert.Contains(t, html, "VALIDATION ERROR: Object &#39;missing&#39; not found")
+		assert.Contains(t, html, "<td>1a2b3c4d-0002</td>")
+	})
+
+	t.Run("Long query text is truncated", func(t *testing.T) {
+		data := &QueriesReportData{
+			TotalQueries: 1,
+			SlowestQueries: []QuerySummary{
+				{QueryID: "q1", Outcome: "COMPLETED", QueryText: "SELECT " + strings.Repeat("x", maxQueryTextLength)},
+			},
+		}
+
+		html, err := GenerateQueriesHTML(data)
+		require.NoError(t, err)
+
+		assert.Contains(t, html, strings.Repeat("x", maxQueryTextLength-len("SELECT "))+"...")
+		assert.NotContains(t, html, strings.Repeat("x", maxQueryTextLength))
+		assert.Contains(t, html, "No failed queries.")
+	})
+
+	t.Run("No queries", func(t *testing.T) {
+		html, err := GenerateQueriesHTML(&QueriesReportData{})
+		require.NoError(t, err)
+		assert.Contains(t, html, "No data available for analysis.")
+	})
+}
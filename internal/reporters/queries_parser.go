@@ -0,0 +1,277 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporters
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxSlowestQueries is how many queries the slowest queries table lists
+const maxSlowestQueries = 20
+
+// maxFailureReasons is how many distinct failure reasons the report lists
+const maxFailureReasons = 10
+
+// DremioQueryRecord is one line of the queries.json log Dremio writes when a query finishes
+type DremioQueryRecord struct {
+	QueryID         string `json:"queryId"`
+	QueryText       string `json:"queryText"`
+	Username        string `json:"username"`
+	Outcome         string `json:"outcome"`
+	OutcomeReason   string `json:"outcomeReason"`
+	QueueName       string `json:"queueName"`
+	Start           int64  `json:"start"`           // Query start as epoch milliseconds
+	Finish          int64  `json:"finish"`          // Query finish as epoch milliseconds
+	MemoryAllocated int64  `json:"memoryAllocated"` // Memory allocated by the query in bytes
+}
+
+// QuerySummary is a single query as listed in the slowest queries table
+type QuerySummary struct {
+	QueryID         string    `json:"query_id"`
+	Username        string    `json:"username"`
+	Outcome         string    `json:"outcome"`
+	Start           time.Time `json:"start"`
+	DurationMs      int64     `json:"duration_ms"`
+	MemoryAllocated int64     `json:"memory_allocated"`
+	QueryText       string    `json:"query_text"`
+}
+
+// QueryOutcomeCount is the number of queries that finished with an outcome
+type QueryOutcomeCount struct {
+	Outcome string `json:"outcome"`
+	Count   int    `json:"count"`
+}
+
+// QueryFailureReason is a failure reason and how many failed queries reported it
+type QueryFailureReason struct {
+	Reason string `json:"reason"`
+	Count  int    `json:"count"`
+}
+
+// QueryDurationBucket is the number of queries whose duration fell in a range
+type QueryDurationBucket struct {
+	Label string `json:"label"`
+	Count int    `json:"count"`
+}
+
+// queryDurationBuckets are the upper bounds in milliseconds of the duration distribution
+// buckets; queries slower than the last bound land in a final open-ended bucket
+var queryDurationBuckets = []struct {
+	label string
+	maxMs int64
+}{
+	{"< 1s", 1000},
+	{"1-5s", 5000},
+	{"5-30s", 30000},
+	{"30s-1m", 60000},
+	{"1-5m", 300000},
+	{"5-30m", 1800000},
+}
+
+// QueriesReportData represents the aggregated queries.json data used to build the report
+type QueriesReportData struct {
+	TotalQueries    int                   `json:"total_queries"`
+	FailedQueries   int                   `json:"failed_queries"`
+	SkippedLines    int                   `json:"skipped_lines"` // Lines that were not valid query records
+	FirstStart      time.Time             `json:"first_start"`
+	LastFinish      time.Time             `json:"last_finish"`
+	DurationMs      PercentileStats       `json:"duration_ms"`
+	MaxDurationMs   int64                 `json:"max_duration_ms"`
+	MemoryBytes     PercentileStats       `json:"memory_bytes"`
+	MaxMemoryBytes  int64                 `json:"max_memory_bytes"`
+	DurationBuckets []QueryDurationBucket `json:"duration_buckets"`
+	Outcomes        []QueryOutcomeCount   `json:"outcomes"`
+	FailureReasons  []QueryFailureReason  `json:"failure_reasons"`
+	SlowestQueries  []QuerySummary        `json:"slowest_queries"`
+}
+
+// ParseQueriesJSON parses the newline-delimited queries.json log and aggregates query
+// counts, durations, memory and failures. Lines that are not query records are counted
+// in SkippedLines rather than failing the whole file.
+func ParseQueriesJSON(content []byte) (*QueriesReportData, error) {
+	return ParseQueriesJSONReader(bytes.NewReader(content))
+}
+
+// ParseQueriesJSONReader parses queries.json records line by line from r like
+// ParseQueriesJSON. Records are aggregated as they are read and only the slowest
+// queries are kept, so memory does not grow with the query text in the log
+func ParseQueriesJSONReader(r io.Reader) (*QueriesReportData, error) {
+	data := &QueriesReportData{
+		DurationBuckets: newQueryDurationBuckets(),
+		Outcomes:        []QueryOutcomeCount{},
+		FailureReasons:  []QueryFailureReason{},
+		SlowestQueries:  []QuerySummary{},
+	}
+	summary := &queriesSummary{outcomes: map[string]int{}, reasons: map[string]int{}}
+
+	// Query text is stored inline, so a single record can be very long
+	scanner := newLineScanner(r)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var record DremioQueryRecord
+		if err := json.Unmarshal(line, &record); err != nil || record.QueryID == "" {
+			data.SkippedLines++
+			continue
+		}
+		summary.add(data, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	summary.finish(data)
+	return data, nil
+}
+
+// queriesSummary holds the running aggregates while a query log is streamed
+type queriesSummary struct {
+	outcomes  map[string]int
+	reasons   map[string]int
+	durations []float64
+	memory    []float64
+}
+
+// add folds a single query record into data and the running aggregates
+func (s *queriesSummary) add(data *QueriesReportData, record DremioQueryRecord) {
+	data.TotalQueries++
+
+	outcome := strings.ToUpper(record.Outcome)
+	if outcome == "" {
+		outcome = "UNKNOWN"
+	}
+	s.outcomes[outcome]++
+	if outcome == "FAILED" {
+		data.FailedQueries++
+		reason := strings.TrimSpace(record.OutcomeReason)
+		if reason == "" {
+			reason = "No reason recorded"
+		}
+		s.reasons[firstLine(reason)]++
+	}
+
+	var durationMs int64
+	if record.Finish >= record.Start && record.Start > 0 {
+		durationMs = record.Finish - record.Start
+	}
+	s.durations = append(s.durations, float64(durationMs))
+	s.memory = append(s.memory, float64(record.MemoryAllocated))
+	data.DurationBuckets[queryDurationBucketIndex(durationMs)].Count++
+	if durationMs > data.MaxDurationMs {
+		data.MaxDurationMs = durationMs
+	}
+	if record.MemoryAllocated > data.MaxMemoryBytes {
+		data.MaxMemoryBytes = record.MemoryAllocated
+	}
+
+	start := time.UnixMilli(record.Start).UTC()
+	if record.Start > 0 && (data.FirstStart.IsZero() || start.Before(data.FirstStart)) {
+		data.FirstStart = start
+	}
+	if finish := time.UnixMilli(record.Finish).UTC(); record.Finish > 0 && finish.After(data.LastFinish) {
+		data.LastFinish = finish
+	}
+
+	data.SlowestQueries = insertSlowQuery(data.SlowestQueries, QuerySummary{
+		QueryID:         record.QueryID,
+		Username:        record.Username,
+		Outcome:         outcome,
+		Start:           start,
+		DurationMs:      durationMs,
+		MemoryAllocated: record.MemoryAllocated,
+		QueryText:       record.QueryText,
+	})
+}
+
+// finish computes the percentiles and ordered breakdowns once every record has been added
+func (s *queriesSummary) finish(data *QueriesReportData) {
+	data.DurationMs = computePercentiles(s.durations)
+	data.MemoryBytes = computePercentiles(s.memory)
+
+	for outcome, count := range s.outcomes {
+		data.Outcomes = append(data.Outcomes, QueryOutcomeCount{Outcome: outcome, Count: count})
+	}
+	sort.Slice(data.Outcomes, func(i, j int) bool {
+		if data.Outcomes[i].Count != data.Outcomes[j].Count {
+			return data.Outcomes[i].Count > data.Outcomes[j].Count
+		}
+		return data.Outcomes[i].Outcome < data.Outcomes[j].Outcome
+	})
+
+	for reason, count := range s.reasons {
+		data.FailureReasons = append(data.FailureReasons, QueryFailureReason{Reason: reason, Count: count})
+	}
+	sort.Slice(data.FailureReasons, func(i, j int) bool {
+		if data.FailureReasons[i].Count != data.FailureReasons[j].Count {
+			return data.FailureReasons[i].Count > data.FailureReasons[j].Count
+		}
+		return data.FailureReasons[i].Reason < data.FailureReasons[j].Reason
+	})
+	if len(data.FailureReasons) > maxFailureReasons {
+		data.FailureReasons = data.FailureReasons[:maxFailureReasons]
+	}
+}
+
+// insertSlowQuery adds query to the slowest-first list, keeping at most maxSlowestQueries.
+// Queries with equal durations keep the order they were read in
+func insertSlowQuery(queries []QuerySummary, query QuerySummary) []QuerySummary {
+	idx := sort.Search(len(queries), func(i int) bool {
+		return queries[i].DurationMs < query.DurationMs
+	})
+	if idx >= maxSlowestQueries {
+		return queries
+	}
+	queries = append(queries, QuerySummary{})
+	copy(queries[idx+1:], queries[idx:])
+	queries[idx] = query
+	if len(queries) > maxSlowestQueries {
+		queries = queries[:maxSlowestQueries]
+	}
+	return queries
+}
+
+// newQueryDurationBuckets returns the empty duration distribution buckets
+func newQueryDurationBuckets() []QueryDurationBucket {
+	buckets := make([]QueryDurationBucket, 0, len(queryDurationBuckets)+1)
+	for _, bucket := range queryDurationBuckets {
+		buckets = append(buckets, QueryDurationBucket{Label: bucket.label})
+	}
+	return append(buckets, QueryDurationBucket{Label: "> 30m"})
+}
+
+// queryDurationBucketIndex returns the index of the duration bucket a query falls in
+func queryDurationBucketIndex(durationMs int64) int {
+	for i, bucket := range queryDurationBuckets {
+		if durationMs < bucket.maxMs {
+			return i
+		}
+	}
+	return len(queryDurationBuckets)
+}
+
+// firstLine returns the first line of s, which is enough to group multi-line error messages
+func firstLine(s string) string {
+	if idx := strings.IndexByte(s, '\n'); idx >= 0 {
+		return strings.TrimSpace(s[:idx])
+	}
+	return s
+}
@@ -0,0 +1,118 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporters
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rsvihladremio/ddd/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQueriesJSON(t *testing.T) {
+	t.Run("Sample query log", func(t *testing.T) {
+		data, err := ParseQueriesJSON(testutil.SampleFiles["queries_json"].Content)
+		require.NoError(t, err)
+
+		assert.Equal(t, 4, data.TotalQueries)
+		assert.Equal(t, 1, data.FailedQueries)
+		assert.Equal(t, 0, data.SkippedLines)
+		assert.Equal(t, time.UnixMilli(1725451640000).UTC(), data.FirstStart)
+		assert.Equal(t, time.UnixMilli(1725451712500).UTC(), data.LastFinish)
+
+		assert.InDelta(t, 1850, data.DurationMs.P50, 0.001)
+		assert.InDelta(t, 38625, data.DurationMs.P95, 0.001)
+		assert.Equal(t, int64(45000), data.MaxDurationMs)
+		assert.Equal(t, int64(536870912), data.MaxMemoryBytes)
+
+		assert.Equal(t, []QueryOutcomeCount{
+			{Outcome: "COMPLETED", Count: 2},
+			{Outcome: "CANCELED", Count: 1},
+			{Outcome: "FAILED", Count: 1},
+		}, data.Outcomes)
+		assert.Equal(t, []QueryFailureReason{
+			{Reason: "VALIDATION ERROR: Object 'missing' not found", Count: 1},
+		}, data.FailureReasons)
+
+		counts := map[string]int{}
+		for _, bucket := range data.DurationBuckets {
+			counts[bucket.Label] = bucket.Count
+		}
+		assert.Equal(t, 1, counts["< 1s"])
+		assert.Equal(t, 2, counts["1-5s"])
+		assert.Equal(t, 1, counts["30s-1m"])
+		assert.Equal(t, 0, counts["> 30m"])
+
+		require.Len(t, data.SlowestQueries, 4)
+		assert.Equal(t, "1a2b3c4d-0002", data.SlowestQueries[0].QueryID)
+		assert.Equal(t, "bob", data.SlowestQueries[0].Username)
+		assert.Equal(t, int64(45000), data.SlowestQueries[0].DurationMs)
+		assert.Equal(t, "1a2b3c4d-0003", data.SlowestQueries[3].QueryID)
+	})
+
+	t.Run("Invalid lines are skipped", func(t *testing.T) {
+		content := `{"queryId":"q1","start":1000,"finish":3000,"outcome":"COMPLETED"}
+not json
+{"message":"no query id"}
+
+{"queryId":"q2","start":1000,"finish":2000,"outcome":"FAILED","outcomeReason":""}
+`
+		data, err := ParseQueriesJSON([]byte(content))
+		require.NoError(t, err)
+
+		assert.Equal(t, 2, data.TotalQueries)
+		assert.Equal(t, 2, data.SkippedLines)
+		assert.Equal(t, []QueryFailureReason{{Reason: "No reason recorded", Count: 1}}, data.FailureReasons)
+	})
+
+	t.Run("Slowest queries are capped", func(t *testing.T) {
+		var content []byte
+		for i := 0; i < maxSlowestQueries+5; i++ {
+			content = append(content, []byte(`{"queryId":"q","start":1000,"finish":2000,"outcome":"COMPLETED"}`+"\n")...)
+		}
+		data, err := ParseQueriesJSON(content)
+		require.NoError(t, err)
+
+		assert.Equal(t, maxSlowestQueries+5, data.TotalQueries)
+		assert.Len(t, data.SlowestQueries, maxSlowestQueries)
+	})
+
+	t.Run("Slowest queries keep only the top durations while streaming", func(t *testing.T) {
+		var content strings.Builder
+		for i := 1; i <= maxSlowestQueries*2; i++ {
+			fmt.Fprintf(&content, `{"queryId":"q%d","start":1000,"finish":%d,"outcome":"COMPLETED","queryText":"%s"}`+"\n",
+				i, 1000+i*10, strings.Repeat("x", 80*1024))
+		}
+
+		data, err := ParseQueriesJSONReader(strings.NewReader(content.String()))
+		require.NoError(t, err)
+
+		assert.Equal(t, maxSlowestQueries*2, data.TotalQueries)
+		require.Len(t, data.SlowestQueries, maxSlowestQueries)
+		assert.Equal(t, fmt.Sprintf("q%d", maxSlowestQueries*2), data.SlowestQueries[0].QueryID)
+		assert.Equal(t, fmt.Sprintf("q%d", maxSlowestQueries+1), data.SlowestQueries[maxSlowestQueries-1].QueryID)
+	})
+
+	t.Run("Empty content", func(t *testing.T) {
+		data, err := ParseQueriesJSON([]byte(""))
+		require.NoError(t, err)
+		assert.Equal(t, 0, data.TotalQueries)
+		assert.Empty(t, data.SlowestQueries)
+	})
+}
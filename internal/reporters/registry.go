@@ -0,0 +1,180 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporters
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Options are the per-run settings passed to a reporter. Reporters ignore the ones that
+// don't apply to them
+type Options struct {
+	Location         *time.Location   // Zone for timestamps that carry none
+	MaxPoints        int              // Charted points for time series reports, 0 to chart every snapshot
+	TopThreads       int              // Busiest threads in the ttop and top CPU charts, 0 for DefaultTTopTopThreads
+	Theme            string           // Report colour theme
+	IOStatThresholds IOStatThresholds // Finding thresholds for iostat reports
+	IOStatPartitions string           // What iostat reports do with partition rows, IOStatPartitionsKeep when empty
+	Logger           ReportLogger     // Receives the report's log, the standard logger when nil
+}
+
+// Reporter generates the report for one report type
+type Reporter interface {
+	// Type is the report type, which matches the file type it is generated from
+	Type() string
+	// Generate builds the report JSON for the file at filePath
+	Generate(ctx context.Context, filePath string, opts Options) (string, error)
+	// AutoGenerate reports whether a report is queued automatically for files of this type
+	AutoGenerate() bool
+}
+
+// FileTypeMatcher is implemented by reporters that can run on files other than their own
+// type, such as generic reporters that accept any file
+type FileTypeMatcher interface {
+	AppliesTo(fileType string) bool
+}
+
+// AppliesTo reports whether reporter can generate a report from a file of fileType
+func AppliesTo(reporter Reporter, fileType string) bool {
+	if matcher, ok := reporter.(FileTypeMatcher); ok {
+		return matcher.AppliesTo(fileType)
+	}
+	return reporter.Type() == fileType
+}
+
+// GenerateFunc is the signature of Reporter.Generate
+type GenerateFunc func(ctx context.Context, filePath string, opts Options) (string, error)
+
+type funcReporter struct {
+	reportType   string
+	generate     GenerateFunc
+	autoGenerate bool
+}
+
+func (r funcReporter) Type() string {
+	return r.reportType
+}
+
+func (r funcReporter) Generate(ctx context.Context, filePath string, opts Options) (string, error) {
+	return r.generate(ctx, filePath, opts)
+}
+
+func (r funcReporter) AutoGenerate() bool {
+	return r.autoGenerate
+}
+
+// NewReporter adapts a generate function to the Reporter interface
+func NewReporter(reportType string, generate GenerateFunc, autoGenerate bool) Reporter {
+	return funcReporter{reportType: reportType, generate: generate, autoGenerate: autoGenerate}
+}
+
+// Registry maps report types to their reporters
+type Registry struct {
+	mu        sync.RWMutex
+	reporters map[string]Reporter
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{reporters: make(map[string]Reporter)}
+}
+
+// Register adds a reporter under its report type. Registering a type twice is a
+// programming error and panics
+func (r *Registry) Register(reporter Reporter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	reportType := reporter.Type()
+	if _, exists := r.reporters[reportType]; exists {
+		panic(fmt.Sprintf("reporters: report type %s registered twice", reportType))
+	}
+	r.reporters[reportType] = reporter
+}
+
+// Lookup returns the reporter for a report type
+func (r *Registry) Lookup(reportType string) (Reporter, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	reporter, ok := r.reporters[reportType]
+	return reporter, ok
+}
+
+// ShouldAutoGenerate reports whether a report is queued automatically for files of the type
+func (r *Registry) ShouldAutoGenerate(fileType string) bool {
+	reporter, ok := r.Lookup(fileType)
+	return ok && reporter.AutoGenerate()
+}
+
+// Types lists the registered report types, sorted
+func (r *Registry) Types() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	types := make([]string, 0, len(r.reporters))
+	for reportType := range r.reporters {
+		types = append(types, reportType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// defaultRegistry holds the built-in reporters
+var defaultRegistry = NewRegistry()
+
+// Register adds a reporter to the default registry
+func Register(reporter Reporter) {
+	defaultRegistry.Register(reporter)
+}
+
+// Lookup returns the reporter for a report type from the default registry
+func Lookup(reportType string) (Reporter, bool) {
+	return defaultRegistry.Lookup(reportType)
+}
+
+// ShouldAutoGenerate reports whether the default registry queues a report automatically
+// for files of the type
+func ShouldAutoGenerate(fileType string) bool {
+	return defaultRegistry.ShouldAutoGenerate(fileType)
+}
+
+// ReportTypes lists the report types in the default registry, sorted
+func ReportTypes() []string {
+	return defaultRegistry.Types()
+}
+
+func init() {
+	Register(TTopReporter{})
+	Register(TopReporter{})
+	Register(IOStatReporter{})
+	Register(VMStatReporter{})
+	Register(NewReporter("jfr", func(ctx context.Context, filePath string, opts Options) (string, error) {
+		return GenerateJFRReport(ctx, filePath, opts.Logger)
+	}, true))
+	Register(NewReporter("dremio_profile", func(ctx context.Context, filePath string, opts Options) (string, error) {
+		return GenerateDremioProfileReport(ctx, filePath, opts.Logger)
+	}, true))
+	Register(NewReporter("thread_dump", func(ctx context.Context, filePath string, opts Options) (string, error) {
+		return GenerateThreadDumpReport(ctx, filePath, opts.Logger)
+	}, true))
+	Register(NewReporter("queries_json", func(ctx context.Context, filePath string, opts Options) (string, error) {
+		return GenerateQueriesReport(ctx, filePath, opts.Logger)
+	}, true))
+	Register(NewReporter("dremio_config", func(ctx context.Context, filePath string, opts Options) (string, error) {
+		return GenerateConfigReport(ctx, filePath, opts.Logger)
+	}, true))
+}
@@ -0,0 +1,121 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporters
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rsvihladremio/ddd/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultRegistry(t *testing.T) {
+	assert.Equal(t, []string{"dremio_config", "dremio_profile", "iostat", "jfr", "queries_json", "thread_dump", "top", "ttop", "vmstat"}, ReportTypes())
+
+	for _, reportType := range ReportTypes() {
+		assert.True(t, ShouldAutoGenerate(reportType), reportType)
+	}
+	assert.False(t, ShouldAutoGenerate("unknown"))
+	assert.False(t, ShouldAutoGenerate("archive"))
+
+	_, ok := Lookup("spreadsheet")
+	assert.False(t, ok)
+}
+
+func TestRegisteredReportersRoundTripSamples(t *testing.T) {
+	for _, reportType := range ReportTypes() {
+		t.Run(reportType, func(t *testing.T) {
+			reporter, ok := Lookup(reportType)
+			require.True(t, ok)
+			assert.Equal(t, reportType, reporter.Type())
+
+			// JFR reports don't parse the recording yet, so any content will do
+			content := []byte("FLR\x00 recording")
+			if sample, ok := testutil.SampleFiles[reportType]; ok {
+				content = sample.Content
+			}
+			filePath := filepath.Join(t.TempDir(), "capture")
+			require.NoError(t, os.WriteFile(filePath, content, 0644))
+
+			reportJSON, err := reporter.Generate(context.Background(), filePath, Options{
+				Location:         time.UTC,
+				Theme:            DefaultReportTheme,
+				IOStatThresholds: DefaultIOStatThresholds(),
+			})
+			require.NoError(t, err)
+
+			var report map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(reportJSON), &report))
+			assert.Equal(t, reportType, report["type"])
+			assert.Equal(t, float64(len(content)), report["file_size"])
+		})
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry()
+	var gotPath string
+	var gotOpts Options
+	registry.Register(NewReporter("csv", func(ctx context.Context, filePath string, opts Options) (string, error) {
+		gotPath, gotOpts = filePath, opts
+		return `{"ok":true}`, nil
+	}, false))
+
+	t.Run("Lookup dispatches to the registered reporter", func(t *testing.T) {
+		reporter, ok := registry.Lookup("csv")
+		require.True(t, ok)
+		assert.Equal(t, "csv", reporter.Type())
+		report, err := reporter.Generate(context.Background(), "data.csv", Options{MaxPoints: 10, Theme: "dark"})
+		require.NoError(t, err)
+		assert.Equal(t, `{"ok":true}`, report)
+		assert.Equal(t, "data.csv", gotPath)
+		assert.Equal(t, 10, gotOpts.MaxPoints)
+		assert.Equal(t, "dark", gotOpts.Theme)
+	})
+
+	t.Run("Registered reporters can opt out of automatic generation", func(t *testing.T) {
+		assert.False(t, registry.ShouldAutoGenerate("csv"))
+		assert.Equal(t, []string{"csv"}, registry.Types())
+	})
+
+	t.Run("Reporters apply to their own file type unless they match others", func(t *testing.T) {
+		reporter, ok := registry.Lookup("csv")
+		require.True(t, ok)
+		assert.True(t, AppliesTo(reporter, "csv"))
+		assert.False(t, AppliesTo(reporter, "ttop"))
+		assert.True(t, AppliesTo(anyFileReporter{reporter}, "ttop"))
+	})
+
+	t.Run("Registering a type twice panics", func(t *testing.T) {
+		assert.Panics(t, func() {
+			registry.Register(NewReporter("csv", nil, true))
+		})
+	})
+}
+
+// anyFileReporter is a generic reporter that accepts every file type
+type anyFileReporter struct {
+	Reporter
+}
+
+func (anyFileReporter) AppliesTo(string) bool {
+	return true
+}
@@ -0,0 +1,127 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporters
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Annotations are added after a report is generated, so rather than being written into the
+// report they are drawn by the page as it opens. The page fetches them from the DDD server or,
+// in a standalone export, reads them from the report data embedded at its end.
+// dddAnnotate(chart) marks each annotation on a chart at the chart point it falls in, using
+// the start time of every chart point listed by chartTimesScript
+const reportAnnotationsScript = `<script>
+        const dddReportID = (location.pathname.match(/^\/report\/(\d+)/) || [])[1];
+        const dddAnnotations = new Promise(function(resolve) {
+            if (document.readyState === 'loading') {
+                document.addEventListener('DOMContentLoaded', resolve);
+            } else {
+                resolve();
+            }
+        }).then(function() {
+            const embedded = document.getElementById('ddd-report-data');
+            if (embedded) {
+                return JSON.parse(embedded.textContent).annotations || [];
+            }
+            if (!dddReportID) {
+                return [];
+            }
+            return fetch('/api/reports/' + dddReportID + '/annotations').then(function(response) {
+                return response.json();
+            }).then(function(result) {
+                return result.success ? result.annotations : [];
+            });
+        }).catch(function(error) {
+            console.error('Error loading annotations:', error);
+            return [];
+        });
+
+        function dddAnnotate(chart) {
+            const times = JSON.parse(document.getElementById('ddd-chart-times').textContent).map(Date.parse);
+            dddAnnotations.then(function(annotations) {
+                if (times.length === 0) {
+                    return;
+                }
+                // The last point covers as long as the one before it
+                const end = times[times.length - 1] + (times.length > 1 ? times[times.length - 1] - times[times.length - 2] : 0);
+                const lines = [];
+                annotations.forEach(function(annotation) {
+                    const time = Date.parse(annotation.timestamp);
+                    if (time < times[0] || time > end) {
+                        return;
+                    }
+                    let point = 0;
+                    while (point + 1 < times.length && times[point + 1] <= time) {
+                        point++;
+                    }
+                    lines.push({ xAxis: point, name: annotation.label });
+                });
+                if (lines.length === 0) {
+                    return;
+                }
+                const color = getComputedStyle(document.documentElement).getPropertyValue('--report-accent').trim();
+                chart.setOption({ series: [{
+                    id: 'ddd-annotations',
+                    name: 'Annotations',
+                    type: 'line',
+                    data: [],
+                    markLine: {
+                        symbol: 'none',
+                        silent: true,
+                        label: { formatter: '{b}', position: 'insideEndTop' },
+                        lineStyle: { type: 'dashed', color: color || undefined },
+                        data: lines
+                    }
+                }] });
+            });
+        }
+    </script>`
+
+// chartTimesScript returns the script element listing the start time of each chart point,
+// which dddAnnotate places annotations by
+func chartTimesScript(times []time.Time) (string, error) {
+	formatted := make([]string, len(times))
+	for i, t := range times {
+		formatted[i] = t.Format(time.RFC3339Nano)
+	}
+	data, err := json.Marshal(formatted)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal chart times: %w", err)
+	}
+	return `<script type="application/json" id="ddd-chart-times">` + string(data) + `</script>`, nil
+}
+
+// WithAnnotations returns report JSON carrying annotations, so a standalone export shows them
+// without a DDD server to fetch them from
+func WithAnnotations(reportJSON string, annotations any) (string, error) {
+	var report map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(reportJSON), &report); err != nil {
+		return "", fmt.Errorf("failed to parse report: %w", err)
+	}
+	data, err := json.Marshal(annotations)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal annotations: %w", err)
+	}
+	report["annotations"] = data
+
+	result, err := json.Marshal(report)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal report: %w", err)
+	}
+	return string(result), nil
+}
@@ -0,0 +1,62 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporters
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChartTimesScript(t *testing.T) {
+	est := time.FixedZone("EST", -5*3600)
+	script, err := chartTimesScript([]time.Time{
+		time.Date(2025, 3, 4, 12, 0, 0, 0, time.UTC),
+		time.Date(2025, 3, 4, 7, 0, 30, 500000000, est),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `<script type="application/json" id="ddd-chart-times">["2025-03-04T12:00:00Z","2025-03-04T07:00:30.5-05:00"]</script>`, script)
+
+	script, err = chartTimesScript(nil)
+	require.NoError(t, err)
+	assert.Contains(t, script, ">[]</script>")
+}
+
+func TestWithAnnotations(t *testing.T) {
+	annotations := []map[string]any{{"timestamp": "2025-03-04T12:05:00Z", "label": "deploy </script> started"}}
+	result, err := WithAnnotations(`{"type":"iostat","html_report":"<html></html>"}`, annotations)
+	require.NoError(t, err)
+
+	var report map[string]any
+	require.NoError(t, json.Unmarshal([]byte(result), &report))
+	assert.Equal(t, "iostat", report["type"])
+	assert.Equal(t, "<html></html>", report["html_report"])
+	require.Len(t, report["annotations"], 1)
+	assert.Equal(t, "deploy </script> started", report["annotations"].([]any)[0].(map[string]any)["label"])
+
+	t.Run("Standalone exports carry their annotations", func(t *testing.T) {
+		page, err := GenerateStandaloneHTML(result, []byte("/* echarts */"))
+		require.NoError(t, err)
+		assert.Contains(t, page, `"annotations":[{"label":"deploy \u003c/script\u003e started"`)
+		assert.False(t, strings.Contains(page, "deploy </script>"), "Labels can't close the embedded data")
+	})
+
+	_, err = WithAnnotations("not json", annotations)
+	assert.Error(t, err)
+}
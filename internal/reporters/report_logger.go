@@ -0,0 +1,54 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporters
+
+import (
+	"fmt"
+	"log"
+)
+
+// Log levels used for report generation logs
+const (
+	LogLevelInfo  = "INFO"
+	LogLevelWarn  = "WARN"
+	LogLevelError = "ERROR"
+)
+
+// ReportLogger receives progress and decision messages while a report is generated
+type ReportLogger interface {
+	Infof(format string, args ...any)
+	Warnf(format string, args ...any)
+	Errorf(format string, args ...any)
+}
+
+// stdoutLogger writes report logs to the standard logger only
+type stdoutLogger struct{}
+
+func (stdoutLogger) Infof(format string, args ...any)  { logLine(LogLevelInfo, format, args...) }
+func (stdoutLogger) Warnf(format string, args ...any)  { logLine(LogLevelWarn, format, args...) }
+func (stdoutLogger) Errorf(format string, args ...any) { logLine(LogLevelError, format, args...) }
+
+// logLine prefixes a formatted message with its level and writes it to the standard logger
+func logLine(level, format string, args ...any) {
+	log.Printf("%s %s", level, fmt.Sprintf(format, args...))
+}
+
+// loggerOrDefault returns the given logger, or one writing to stdout when it is nil
+func loggerOrDefault(logger ReportLogger) ReportLogger {
+	if logger == nil {
+		return stdoutLogger{}
+	}
+	return logger
+}
@@ -0,0 +1,353 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporters
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultReportTheme is the theme reports are generated with when none is chosen
+const DefaultReportTheme = "default"
+
+// reportChartTheme is the name the report theme is registered under with echarts
+const reportChartTheme = "ddd"
+
+// Markers around the themed parts of a report, so a stored report can be re-themed
+// without regenerating it
+const (
+	reportThemeStyleStart  = `<style id="ddd-report-theme">`
+	reportThemeScriptStart = `<script id="ddd-report-chart-theme">`
+)
+
+// reportTheme is a named look for the HTML reports. The colors and spacing are set as
+// CSS custom properties that reportBaseCSS uses, and the palette is registered as the
+// echarts theme every chart is created with
+type reportTheme struct {
+	Name        string
+	Background  string // Page behind the report
+	Surface     string // Report container and stat cards
+	SurfaceAlt  string // Stats strip and table headers
+	Text        string
+	MutedText   string
+	Border      string
+	Accent      string // Stat values
+	HeaderStart string // Header gradient start
+	HeaderEnd   string // Header gradient end
+	HeaderText  string
+	Shadow      string
+	Good        string // Values within thresholds
+	Bad         string // Values breaching thresholds
+	Spacing     string // Padding around each section
+	ChartHeight string
+	Palette     []string // Series colors, in order
+}
+
+// reportThemes are the themes a report can be generated with
+var reportThemes = map[string]reportTheme{
+	// The original report look
+	"default": {
+		Name:        "default",
+		Background:  "#f5f5f5",
+		Surface:     "white",
+		SurfaceAlt:  "#f8f9fa",
+		Text:        "#333",
+		MutedText:   "#666",
+		Border:      "#eee",
+		Accent:      "#06b6d4",
+		HeaderStart: "#06b6d4",
+		HeaderEnd:   "#0891b2",
+		HeaderText:  "white",
+		Shadow:      "0 2px 10px rgba(0,0,0,0.1)",
+		Good:        "#2e7d32",
+		Bad:         "#d32f2f",
+		Spacing:     "30px",
+		ChartHeight: "400px",
+		Palette:     []string{"#5470c6", "#91cc75", "#fac858", "#ee6666", "#73c0de", "#3ba272", "#fc8452", "#9a60b4", "#ea7ccc"},
+	},
+	// A flat, airy look with a muted palette for sharing with a wider audience
+	"light": {
+		Name:        "light",
+		Background:  "#ffffff",
+		Surface:     "#ffffff",
+		SurfaceAlt:  "#ffffff",
+		Text:        "#1f2937",
+		MutedText:   "#6b7280",
+		Border:      "#e5e7eb",
+		Accent:      "#2563eb",
+		HeaderStart: "#ffffff",
+		HeaderEnd:   "#ffffff",
+		HeaderText:  "#111827",
+		Shadow:      "none",
+		Good:        "#15803d",
+		Bad:         "#b91c1c",
+		Spacing:     "40px",
+		ChartHeight: "420px",
+		Palette:     []string{"#2563eb", "#64748b", "#0ea5e9", "#94a3b8", "#1e40af", "#38bdf8", "#475569", "#7dd3fc", "#0f172a"},
+	},
+	// A dense dark look that fits more charts on screen
+	"dark": {
+		Name:        "dark",
+		Background:  "#0b0f14",
+		Surface:     "#111827",
+		SurfaceAlt:  "#1f2937",
+		Text:        "#e5e7eb",
+		MutedText:   "#9ca3af",
+		Border:      "#374151",
+		Accent:      "#22d3ee",
+		HeaderStart: "#1f2937",
+		HeaderEnd:   "#111827",
+		HeaderText:  "#f9fafb",
+		Shadow:      "0 2px 10px rgba(0,0,0,0.5)",
+		Good:        "#4ade80",
+		Bad:         "#f87171",
+		Spacing:     "16px",
+		ChartHeight: "320px",
+		Palette:     []string{"#22d3ee", "#a3e635", "#fbbf24", "#f87171", "#c084fc", "#34d399", "#fb923c", "#60a5fa", "#f472b6"},
+	},
+}
+
+// reportBaseCSS is the layout shared by the themed reports. Colours and spacing come from
+// the custom properties in the theme's style block
+const reportBaseCSS = `
+        body {
+            font-family: 'Segoe UI', Tahoma, Geneva, Verdana, sans-serif;
+            margin: 0;
+            padding: 20px;
+            background-color: var(--report-bg);
+            color: var(--report-text);
+        }
+        .container {
+            max-width: 1400px;
+            margin: 0 auto;
+            background-color: var(--report-surface);
+            border-radius: 8px;
+            box-shadow: var(--report-shadow);
+            overflow: hidden;
+        }
+        .header {
+            background: linear-gradient(135deg, var(--report-header-start) 0%, var(--report-header-end) 100%);
+            color: var(--report-header-text);
+            padding: var(--report-spacing);
+            text-align: center;
+            border-bottom: 1px solid var(--report-border);
+        }
+        .header h1 {
+            margin: 0 0 10px 0;
+            font-size: 2.5em;
+            font-weight: 300;
+        }
+        .header p {
+            margin: 0;
+            font-size: 1.1em;
+            opacity: 0.9;
+        }
+        .stats-grid {
+            display: grid;
+            grid-template-columns: repeat(auto-fit, minmax(200px, 1fr));
+            gap: 20px;
+            padding: var(--report-spacing);
+            background-color: var(--report-surface-alt);
+        }
+        .stat-card {
+            background: var(--report-surface);
+            padding: 20px;
+            border-radius: 8px;
+            text-align: center;
+            box-shadow: var(--report-shadow);
+            border: 1px solid var(--report-border);
+        }
+        .stat-value {
+            font-size: 2em;
+            font-weight: bold;
+            color: var(--report-accent);
+            margin-bottom: 5px;
+        }
+        .stat-label {
+            color: var(--report-muted);
+            font-size: 0.9em;
+        }
+        .chart-container {
+            padding: var(--report-spacing);
+            border-bottom: 1px solid var(--report-border);
+        }
+        .chart-container:last-child {
+            border-bottom: none;
+        }
+        .chart-title {
+            font-size: 1.5em;
+            margin-bottom: 20px;
+            color: var(--report-text);
+            text-align: center;
+        }
+        .chart {
+            width: 100%;
+            height: var(--report-chart-height);
+        }
+        .summary-table {
+            width: 100%;
+            border-collapse: collapse;
+            font-size: 0.9em;
+        }
+        .summary-table th,
+        .summary-table td {
+            padding: 8px 12px;
+            border-bottom: 1px solid var(--report-border);
+            text-align: right;
+        }
+        .summary-table th:first-child,
+        .summary-table td:first-child {
+            text-align: left;
+        }
+        .summary-table thead th {
+            background-color: var(--report-surface-alt);
+            color: var(--report-text);
+        }
+`
+
+// ReportThemeNames returns the names of the report themes, sorted
+func ReportThemeNames() []string {
+	names := make([]string, 0, len(reportThemes))
+	for name := range reportThemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsKnownReportTheme reports whether name is one of the report themes
+func IsKnownReportTheme(name string) bool {
+	_, ok := reportThemes[name]
+	return ok
+}
+
+// lookupReportTheme returns the named theme, or the default theme when name is unknown
+func lookupReportTheme(name string) reportTheme {
+	if theme, ok := reportThemes[name]; ok {
+		return theme
+	}
+	return reportThemes[DefaultReportTheme]
+}
+
+// headHTML returns the theme's style block, the shared report styles and the echarts
+// theme registration, to be placed in the report head after echarts is loaded
+func (t reportTheme) headHTML() string {
+	return t.styleBlock() + "\n    <style>" + reportBaseCSS + "    </style>\n    " + t.chartThemeScript()
+}
+
+// styleBlock returns the marked style block that sets the theme's custom properties
+func (t reportTheme) styleBlock() string {
+	return fmt.Sprintf(`%s
+        :root {
+            --report-bg: %s;
+            --report-surface: %s;
+            --report-surface-alt: %s;
+            --report-text: %s;
+            --report-muted: %s;
+            --report-border: %s;
+            --report-accent: %s;
+            --report-header-start: %s;
+            --report-header-end: %s;
+            --report-header-text: %s;
+            --report-shadow: %s;
+            --report-good: %s;
+            --report-bad: %s;
+            --report-spacing: %s;
+            --report-chart-height: %s;
+        }
+    </style>`,
+		reportThemeStyleStart,
+		t.Background, t.Surface, t.SurfaceAlt, t.Text, t.MutedText, t.Border, t.Accent,
+		t.HeaderStart, t.HeaderEnd, t.HeaderText, t.Shadow, t.Good, t.Bad, t.Spacing, t.ChartHeight)
+}
+
+// chartThemeScript returns the marked script that registers the theme's palette and
+// text colors with echarts. Charts pick it up by passing reportChartTheme to echarts.init
+func (t reportTheme) chartThemeScript() string {
+	axis := map[string]any{
+		"axisLine":  map[string]any{"lineStyle": map[string]any{"color": t.Border}},
+		"axisLabel": map[string]any{"color": t.MutedText},
+		"nameTextStyle": map[string]any{
+			"color": t.MutedText,
+		},
+		"splitLine": map[string]any{"lineStyle": map[string]any{"color": []string{t.Border}}},
+	}
+	chartTheme := map[string]any{
+		"color":           t.Palette,
+		"backgroundColor": "transparent",
+		"textStyle":       map[string]any{"color": t.Text},
+		"title":           map[string]any{"textStyle": map[string]any{"color": t.Text}},
+		"legend":          map[string]any{"textStyle": map[string]any{"color": t.Text}},
+		"categoryAxis":    axis,
+		"valueAxis":       axis,
+		"toolbox":         map[string]any{"iconStyle": map[string]any{"borderColor": t.MutedText}},
+		"dataZoom":        map[string]any{"textStyle": map[string]any{"color": t.MutedText}},
+	}
+	// The theme only holds color strings, which always marshal
+	themeJSON, _ := json.Marshal(chartTheme)
+
+	return fmt.Sprintf(`%s
+        if (window.echarts) {
+            echarts.registerTheme('%s', %s);
+        }
+    </script>`, reportThemeScriptStart, reportChartTheme, themeJSON)
+}
+
+// ApplyReportTheme swaps the theme of a generated HTML report for the named theme.
+// Reports without theme markers, such as those generated before themes existed, are
+// returned unchanged
+func ApplyReportTheme(reportHTML, name string) string {
+	theme := lookupReportTheme(name)
+	reportHTML = replaceMarkedBlock(reportHTML, reportThemeStyleStart, "</style>", theme.styleBlock())
+	return replaceMarkedBlock(reportHTML, reportThemeScriptStart, "</script>", theme.chartThemeScript())
+}
+
+// ThemeReportData re-themes the HTML report inside stored report JSON, recording the
+// theme it now uses. Reports without an HTML report are returned unchanged
+func ThemeReportData(reportJSON, name string) (string, error) {
+	var report map[string]any
+	if err := json.Unmarshal([]byte(reportJSON), &report); err != nil {
+		return "", fmt.Errorf("failed to parse report: %w", err)
+	}
+	htmlReport, ok := report["html_report"].(string)
+	if !ok || !strings.Contains(htmlReport, reportThemeStyleStart) {
+		return reportJSON, nil
+	}
+
+	report["html_report"] = ApplyReportTheme(htmlReport, name)
+	report["theme"] = lookupReportTheme(name).Name
+
+	themed, err := json.Marshal(report)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal report: %w", err)
+	}
+	return string(themed), nil
+}
+
+// replaceMarkedBlock replaces the block from start up to and including the first end
+// after it with replacement, leaving s unchanged when start is not found
+func replaceMarkedBlock(s, start, end, replacement string) string {
+	from := strings.Index(s, start)
+	if from < 0 {
+		return s
+	}
+	to := strings.Index(s[from:], end)
+	if to < 0 {
+		return s
+	}
+	return s[:from] + replacement + s[from+to+len(end):]
+}
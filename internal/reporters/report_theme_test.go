@@ -0,0 +1,95 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporters
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReportThemeNames(t *testing.T) {
+	assert.Equal(t, []string{"dark", "default", "light"}, ReportThemeNames())
+	assert.True(t, IsKnownReportTheme(DefaultReportTheme))
+	assert.False(t, IsKnownReportTheme(""))
+	assert.False(t, IsKnownReportTheme("neon"))
+
+	// Every theme sets every value the shared styles and charts rely on
+	for _, name := range ReportThemeNames() {
+		theme := lookupReportTheme(name)
+		assert.Equal(t, name, theme.Name)
+		assert.NotContains(t, theme.styleBlock(), ": ;", name)
+		assert.NotEmpty(t, theme.Palette, name)
+	}
+}
+
+func TestApplyReportTheme(t *testing.T) {
+	data := &TTopReportData{
+		Snapshots: []TTopSnapshot{
+			{
+				Timestamp:    time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+				ThreadCounts: &ThreadCounts{Total: 50, Running: 1, Sleeping: 49},
+				Threads:      []ThreadInfo{{PID: 1234, User: "dremio", CPU: 25.5, Command: "java"}},
+			},
+		},
+	}
+
+	t.Run("Matches generating with the theme", func(t *testing.T) {
+		original, err := GenerateTTopHTML(data)
+		require.NoError(t, err)
+		dark, err := GenerateTTopHTMLWithTheme(data, 0, "dark")
+		require.NoError(t, err)
+
+		assert.Equal(t, dark, ApplyReportTheme(original, "dark"))
+		assert.Equal(t, original, ApplyReportTheme(dark, DefaultReportTheme))
+	})
+
+	t.Run("Leaves reports without theme markers alone", func(t *testing.T) {
+		html := "<html><style>body { color: red; }</style></html>"
+		assert.Equal(t, html, ApplyReportTheme(html, "dark"))
+	})
+}
+
+func TestThemeReportData(t *testing.T) {
+	t.Run("Re-themes the HTML report", func(t *testing.T) {
+		html := "<html>" + lookupReportTheme(DefaultReportTheme).headHTML() + "</html>"
+		reportJSON, err := json.Marshal(map[string]any{"type": "ttop", "theme": DefaultReportTheme, "html_report": html})
+		require.NoError(t, err)
+
+		themed, err := ThemeReportData(string(reportJSON), "light")
+		require.NoError(t, err)
+
+		var report map[string]any
+		require.NoError(t, json.Unmarshal([]byte(themed), &report))
+		assert.Equal(t, "ttop", report["type"])
+		assert.Equal(t, "light", report["theme"])
+		assert.Contains(t, report["html_report"], "--report-bg: #ffffff;")
+	})
+
+	t.Run("Leaves reports without an HTML report alone", func(t *testing.T) {
+		reportJSON := `{"type":"jfr","summary":"no charts"}`
+		themed, err := ThemeReportData(reportJSON, "dark")
+		require.NoError(t, err)
+		assert.Equal(t, reportJSON, themed)
+	})
+
+	t.Run("Rejects invalid report JSON", func(t *testing.T) {
+		_, err := ThemeReportData("not json", "dark")
+		assert.Error(t, err)
+	})
+}
@@ -15,71 +15,327 @@
 package reporters
 
 import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
 )
 
-// secureReadFile safely reads a file with path validation to prevent directory traversal
-func secureReadFile(filePath string) ([]byte, error) {
+// maxScanLineBytes is the longest line the streaming parsers accept. Query text and some
+// stack frames put very long single lines in otherwise ordinary logs
+const maxScanLineBytes = 16 * 1024 * 1024
+
+// gzipMagic starts every gzip stream
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// maxDecompressedCaptureBytes caps how large a gzipped capture or zipped profile may grow
+// once decompressed, so a small compression bomb can't exhaust the server's memory
+var maxDecompressedCaptureBytes int64 = 1024 * 1024 * 1024
+
+// decompressedLimitReader fails once more than limit bytes have been read from r, which
+// must be wrapped in an io.LimitReader of limit+1 bytes
+type decompressedLimitReader struct {
+	r     io.Reader
+	read  int64
+	limit int64
+}
+
+// newDecompressedLimitReader returns a reader over decompressed content that fails with
+// a "decompressed capture exceeds" error past maxDecompressedCaptureBytes
+func newDecompressedLimitReader(r io.Reader) io.Reader {
+	limit := maxDecompressedCaptureBytes
+	return &decompressedLimitReader{r: io.LimitReader(r, limit+1), limit: limit}
+}
+
+// Read implements io.Reader
+func (l *decompressedLimitReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	if l.read > l.limit {
+		return n - int(l.read-l.limit), fmt.Errorf("decompressed capture exceeds %d bytes", l.limit)
+	}
+	return n, err
+}
+
+// secureOpenFile opens a regular file for streaming, rejecting directory traversal, and
+// returns its size. The caller is responsible for closing the file
+func secureOpenFile(filePath string) (*os.File, int64, error) {
+	cleanPath, err := secureCleanPath(filePath)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	file, err := os.Open(cleanPath)
+	if err != nil {
+		return nil, 0, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, 0, err
+	}
+	if info.IsDir() {
+		_ = file.Close()
+		return nil, 0, fmt.Errorf("%s is a directory", cleanPath)
+	}
+	return file, info.Size(), nil
+}
+
+// captureReader reads a capture opened by openCapture, closing the decompressor with the file
+type captureReader struct {
+	io.Reader
+	file *os.File
+	gzip *gzip.Reader
+}
+
+// Close implements io.Closer
+func (c *captureReader) Close() error {
+	if c.gzip != nil {
+		_ = c.gzip.Close()
+	}
+	return c.file.Close()
+}
+
+// openCapture opens a capture for streaming like secureOpenFile. Collection scripts gzip
+// their output, so gzipped content is decompressed as it is read and an iostat.log.gz
+// parses like the original, up to maxDecompressedCaptureBytes. The size is that of the
+// file on disk
+func openCapture(filePath string) (io.ReadCloser, int64, error) {
+	file, size, err := secureOpenFile(filePath)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	buffered := bufio.NewReader(file)
+	if magic, _ := buffered.Peek(len(gzipMagic)); !bytes.Equal(magic, gzipMagic) {
+		return &captureReader{Reader: buffered, file: file}, size, nil
+	}
+	gz, err := gzip.NewReader(buffered)
+	if err != nil {
+		_ = file.Close()
+		return nil, 0, fmt.Errorf("failed to decompress %s: %w", filepath.Base(filePath), err)
+	}
+	return &captureReader{Reader: newDecompressedLimitReader(gz), file: file, gzip: gz}, size, nil
+}
+
+// readCapture reads a whole capture, decompressing it when it is gzipped like openCapture
+func readCapture(filePath string) ([]byte, error) {
+	file, _, err := openCapture(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	return io.ReadAll(file)
+}
+
+// secureCleanPath cleans filePath and rejects directory traversal attempts
+func secureCleanPath(filePath string) (string, error) {
 	// Clean the path to resolve any .. or . components
 	cleanPath := filepath.Clean(filePath)
 
 	// Check for directory traversal attempts
 	if strings.Contains(cleanPath, "..") {
-		return nil, fmt.Errorf("invalid file path: directory traversal detected")
+		return "", fmt.Errorf("invalid file path: directory traversal detected")
 	}
 
 	// Ensure the path is absolute or relative to current directory
 	if !filepath.IsAbs(cleanPath) && strings.HasPrefix(cleanPath, "/") {
-		return nil, fmt.Errorf("invalid file path: absolute path not allowed")
+		return "", fmt.Errorf("invalid file path: absolute path not allowed")
+	}
+
+	return cleanPath, nil
+}
+
+// newLineScanner returns a line scanner over r that accepts lines up to maxScanLineBytes,
+// so the parsers only hold the current line rather than the whole file in memory
+func newLineScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxScanLineBytes)
+	return scanner
+}
+
+// contextReader stops reading once ctx is done, so a streaming parser gives up at its next
+// read when the report it belongs to is cancelled rather than scanning to the end of the file
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+// newContextReader returns a reader over r that fails with ctx.Err() once ctx is done
+func newContextReader(ctx context.Context, r io.Reader) io.Reader {
+	return &contextReader{ctx: ctx, r: r}
+}
+
+// Read implements io.Reader
+func (c *contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
 	}
+	return c.r.Read(p)
+}
+
+// timeAxisName returns the x-axis title for time series charts. Captures only record
+// wall-clock times, so the zone they were interpreted in is named to avoid confusion
+// when correlating with logs from elsewhere
+func timeAxisName(timestamp time.Time) string {
+	return fmt.Sprintf("Time (%s)", timestamp.Location())
+}
+
+// TTopReporter generates ttop reports
+type TTopReporter struct{}
+
+// Type implements Reporter
+func (TTopReporter) Type() string {
+	return "ttop"
+}
+
+// AutoGenerate implements Reporter
+func (TTopReporter) AutoGenerate() bool {
+	return true
+}
+
+// Generate implements Reporter, charting the thread snapshots in opts.Location with at
+// most opts.MaxPoints points and the opts.TopThreads busiest threads
+func (TTopReporter) Generate(ctx context.Context, filePath string, opts Options) (string, error) {
+	return GenerateTTopReportWithTopThreads(ctx, filePath, opts.Location, opts.MaxPoints, opts.Theme, opts.TopThreads, opts.Logger)
+}
+
+// TopReporter generates reports for plain top -b captures, which share the ttop parser
+// and charts with the rows labelled as processes
+type TopReporter struct{}
+
+// Type implements Reporter
+func (TopReporter) Type() string {
+	return "top"
+}
 
-	return os.ReadFile(cleanPath)
+// AutoGenerate implements Reporter
+func (TopReporter) AutoGenerate() bool {
+	return true
 }
 
-// GenerateTTopReport generates a comprehensive report for ttop.txt files
-// This function parses ttop output to extract thread information over time
-// and generates both a JSON summary and an HTML report with interactive charts
-func GenerateTTopReport(filePath string) (string, error) {
-	content, err := secureReadFile(filePath)
+// Generate implements Reporter, charting the process snapshots in opts.Location with at
+// most opts.MaxPoints points and the opts.TopThreads busiest processes
+func (TopReporter) Generate(ctx context.Context, filePath string, opts Options) (string, error) {
+	return generateTopFamilyReport(ctx, "top", filePath, opts.Location, opts.MaxPoints, opts.Theme, opts.TopThreads, opts.Logger)
+}
+
+// GenerateTTopReport generates a comprehensive report for ttop.txt files with timestamps in UTC
+func GenerateTTopReport(ctx context.Context, filePath string, logger ReportLogger) (string, error) {
+	return GenerateTTopReportInLocation(ctx, filePath, time.UTC, logger)
+}
+
+// GenerateTTopReportInLocation generates a comprehensive report for ttop.txt files
+// This function parses ttop output to extract thread information over time, with
+// timestamps interpreted in loc, and generates both a JSON summary and an HTML report
+// with interactive charts.
+// Progress is written to logger, or stdout when it is nil
+func GenerateTTopReportInLocation(ctx context.Context, filePath string, loc *time.Location, logger ReportLogger) (string, error) {
+	return GenerateTTopReportWithMaxPoints(ctx, filePath, loc, 0, logger)
+}
+
+// GenerateTTopReportWithMaxPoints generates the same report as GenerateTTopReportInLocation
+// with the HTML charts averaged into at most maxPoints points, 0 for every snapshot
+func GenerateTTopReportWithMaxPoints(ctx context.Context, filePath string, loc *time.Location, maxPoints int, logger ReportLogger) (string, error) {
+	return GenerateTTopReportWithTheme(ctx, filePath, loc, maxPoints, DefaultReportTheme, logger)
+}
+
+// GenerateTTopReportWithTheme generates the same report as GenerateTTopReportWithMaxPoints
+// with the HTML report styled by the named report theme
+func GenerateTTopReportWithTheme(ctx context.Context, filePath string, loc *time.Location, maxPoints int, themeName string, logger ReportLogger) (string, error) {
+	return GenerateTTopReportWithTopThreads(ctx, filePath, loc, maxPoints, themeName, DefaultTTopTopThreads, logger)
+}
+
+// GenerateTTopReportWithTopThreads generates the same report as GenerateTTopReportWithTheme
+// with the CPU chart showing the topThreads busiest threads
+func GenerateTTopReportWithTopThreads(ctx context.Context, filePath string, loc *time.Location, maxPoints int, themeName string, topThreads int, logger ReportLogger) (string, error) {
+	return generateTopFamilyReport(ctx, "ttop", filePath, loc, maxPoints, themeName, topThreads, logger)
+}
+
+// generateTopFamilyReport generates a reportType report from ttop or top -b output. The
+// parser tells the two apart, so the summary names threads or processes to match the rows
+func generateTopFamilyReport(ctx context.Context, reportType, filePath string, loc *time.Location, maxPoints int, themeName string, topThreads int, logger ReportLogger) (string, error) {
+	logger = loggerOrDefault(logger)
+
+	file, fileSize, err := openCapture(filePath)
 	if err != nil {
+		logger.Errorf("Failed to read %s: %v", filePath, err)
 		return "", fmt.Errorf("failed to read file: %w", err)
 	}
+	defer func() {
+		_ = file.Close()
+	}()
 
-	// Parse ttop content to extract structured data
-	parsedData, err := ParseTTop(content)
+	// Stream ttop or top content to extract structured data
+	parsedData, err := ParseTTopReader(newContextReader(ctx, file), loc)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse ttop content: %w", err)
+		logger.Errorf("Failed to parse %s content: %v", reportType, err)
+		return "", fmt.Errorf("failed to parse %s content: %w", reportType, err)
+	}
+	logger.Infof("Read %d bytes of %s output", fileSize, reportType)
+	logger.Infof("Parsed %d snapshots", len(parsedData.Snapshots))
+	if len(parsedData.Snapshots) == 0 {
+		logger.Warnf("No %s snapshots found; the file may be truncated or not %s output", reportType, reportType)
+	}
+
+	// Stop before the HTML report if the report was cancelled while parsing
+	if err := ctx.Err(); err != nil {
+		return "", err
 	}
 
 	// Generate HTML report with charts
-	htmlReport, err := GenerateTTopHTML(parsedData)
+	if maxPoints > 0 && len(parsedData.Snapshots) > maxPoints {
+		logger.Infof("Averaging %d snapshots into %d chart points", len(parsedData.Snapshots), maxPoints)
+	}
+	topThreads = ClampTTopTopThreads(topThreads)
+	htmlReport, err := GenerateTTopHTMLWithTopThreads(parsedData, maxPoints, themeName, topThreads)
 	if err != nil {
+		logger.Errorf("Failed to generate HTML report: %v", err)
 		return "", fmt.Errorf("failed to generate HTML report: %w", err)
 	}
+	logger.Infof("Generated HTML report (%d bytes)", len(htmlReport))
 
 	// Calculate summary statistics
 	snapshotCount := len(parsedData.Snapshots)
 	uniqueThreads := countUniqueThreads(parsedData)
 	peakThreadCount := findPeakThreadCount(parsedData)
+	peakRES := findPeakRES(parsedData)
+	title, row, rows := "TTop", "thread", "threads"
+	if parsedData.Processes {
+		title, row, rows = "Top", "process", "processes"
+	}
+	logger.Infof("Observed %d unique %s with a peak of %d %s in one snapshot", uniqueThreads, rows, peakThreadCount, rows)
+	memoryGrowth := findTTopMemoryGrowth(parsedData)
+	if memoryGrowth != nil {
+		logger.Warnf("%s", memoryGrowth.Message)
+	}
 
 	// Generate summary and analysis text
-	summary := fmt.Sprintf("TTop analysis report covering %d snapshots with %d unique threads observed",
-		snapshotCount, uniqueThreads)
+	summary := fmt.Sprintf("%s analysis report covering %d snapshots with %d unique %s observed",
+		title, snapshotCount, uniqueThreads, rows)
 
-	analysis := fmt.Sprintf("Peak thread count: %d. Analysis includes thread count over time, "+
-		"CPU usage patterns for top 5 busiest threads, and memory usage distribution by user. "+
+	analysis := fmt.Sprintf("Peak %s count: %d. Peak resident memory: %.1f MiB. Analysis includes %s count over time, "+
+		"CPU usage patterns for top %d busiest %s, resident memory of the top 5 memory consumers, "+
+		"and memory usage distribution by user. "+
 		"Interactive charts provide detailed visualization of system performance metrics.",
-		peakThreadCount)
+		row, peakThreadCount, peakRES/(1024*1024), row, topThreads, rows)
+	if memoryGrowth != nil {
+		analysis = memoryGrowth.Message + ". " + analysis
+	}
 
 	// Build comprehensive report structure
 	report := map[string]any{
-		"type":           "ttop",
-		"file_size":      len(content),
+		"type":           reportType,
+		"file_size":      fileSize,
 		"summary":        summary,
 		"analysis":       analysis,
 		"generated_at":   time.Now().Format(time.RFC3339),
@@ -87,6 +343,12 @@ func GenerateTTopReport(filePath string) (string, error) {
 		"snapshot_count": snapshotCount,
 		"unique_threads": uniqueThreads,
 		"peak_threads":   peakThreadCount,
+		"peak_res_bytes": peakRES,
+		"top_threads":    topThreads,
+		"cpu_cores":      parsedData.CPUCores,
+		"memory_growth":  memoryGrowth,
+		"timezone":       loc.String(),
+		"theme":          lookupReportTheme(themeName).Name,
 	}
 
 	reportJSON, err := json.Marshal(report)
@@ -97,55 +359,429 @@ func GenerateTTopReport(filePath string) (string, error) {
 	return string(reportJSON), nil
 }
 
-// GenerateIOStatReport generates a comprehensive report for iostat files
-// This function parses iostat output to extract I/O statistics over time
-// and generates both a JSON summary and an HTML report with interactive charts
-func GenerateIOStatReport(filePath string) (string, error) {
-	content, err := secureReadFile(filePath)
+// IOStatReporter generates iostat reports
+type IOStatReporter struct{}
+
+// Type implements Reporter
+func (IOStatReporter) Type() string {
+	return "iostat"
+}
+
+// AutoGenerate implements Reporter
+func (IOStatReporter) AutoGenerate() bool {
+	return true
+}
+
+// Generate implements Reporter, flagging devices against opts.IOStatThresholds
+func (IOStatReporter) Generate(ctx context.Context, filePath string, opts Options) (string, error) {
+	return GenerateIOStatReportWithPartitions(ctx, filePath, opts.IOStatThresholds, opts.Location, opts.MaxPoints, opts.Theme, opts.IOStatPartitions, opts.Logger)
+}
+
+// GenerateIOStatReport generates a comprehensive report for iostat files using the default thresholds
+func GenerateIOStatReport(ctx context.Context, filePath string, logger ReportLogger) (string, error) {
+	return GenerateIOStatReportWithThresholds(ctx, filePath, DefaultIOStatThresholds(), logger)
+}
+
+// GenerateIOStatReportWithThresholds generates a comprehensive report for iostat files
+// with timestamps in UTC
+func GenerateIOStatReportWithThresholds(ctx context.Context, filePath string, thresholds IOStatThresholds, logger ReportLogger) (string, error) {
+	return GenerateIOStatReportInLocation(ctx, filePath, thresholds, time.UTC, logger)
+}
+
+// GenerateIOStatReportInLocation generates a comprehensive report for iostat files
+// This function parses iostat output to extract I/O statistics over time, with
+// timestamps interpreted in loc, and generates both a JSON summary and an HTML report
+// with interactive charts.
+// Device samples crossing the given thresholds are reported as findings.
+// Progress is written to logger, or stdout when it is nil
+func GenerateIOStatReportInLocation(ctx context.Context, filePath string, thresholds IOStatThresholds, loc *time.Location, logger ReportLogger) (string, error) {
+	return GenerateIOStatReportWithMaxPoints(ctx, filePath, thresholds, loc, 0, logger)
+}
+
+// GenerateIOStatReportWithMaxPoints generates the same report as GenerateIOStatReportInLocation
+// with the HTML charts averaged into at most maxPoints points, 0 for every snapshot
+func GenerateIOStatReportWithMaxPoints(ctx context.Context, filePath string, thresholds IOStatThresholds, loc *time.Location, maxPoints int, logger ReportLogger) (string, error) {
+	return GenerateIOStatReportWithTheme(ctx, filePath, thresholds, loc, maxPoints, DefaultReportTheme, logger)
+}
+
+// GenerateIOStatReportWithTheme generates the same report as GenerateIOStatReportWithMaxPoints
+// with the HTML report styled by the named report theme
+func GenerateIOStatReportWithTheme(ctx context.Context, filePath string, thresholds IOStatThresholds, loc *time.Location, maxPoints int, themeName string, logger ReportLogger) (string, error) {
+	return GenerateIOStatReportWithPartitions(ctx, filePath, thresholds, loc, maxPoints, themeName, IOStatPartitionsKeep, logger)
+}
+
+// GenerateIOStatReportWithPartitions generates the same report as GenerateIOStatReportWithTheme
+// with the partition rows of iostat -p captures kept, rolled up into their parent devices
+// or filtered out as partitions, one of IOStatPartitionModes, says
+func GenerateIOStatReportWithPartitions(ctx context.Context, filePath string, thresholds IOStatThresholds, loc *time.Location, maxPoints int, themeName, partitions string, logger ReportLogger) (string, error) {
+	logger = loggerOrDefault(logger)
+
+	file, fileSize, err := openCapture(filePath)
 	if err != nil {
+		logger.Errorf("Failed to read %s: %v", filePath, err)
 		return "", fmt.Errorf("failed to read file: %w", err)
 	}
+	defer func() {
+		_ = file.Close()
+	}()
 
-	// Parse iostat content to extract structured data
-	parsedData, err := ParseIOStat(content)
+	// Stream iostat content to extract structured data
+	parsedData, err := ParseIOStatReader(newContextReader(ctx, file), loc)
 	if err != nil {
+		logger.Errorf("Failed to parse iostat content: %v", err)
 		return "", fmt.Errorf("failed to parse iostat content: %w", err)
 	}
+	logger.Infof("Read %d bytes of iostat output", fileSize)
+	logger.Infof("Parsed %d snapshots", len(parsedData.Snapshots))
+	if len(parsedData.Snapshots) == 0 {
+		logger.Warnf("No iostat snapshots found; expected output from iostat -x with timestamps")
+	}
+	if parsedData.SourceUnit == IOStatUnitMB {
+		logger.Infof("Capture reports throughput in MB/s; converted to KB/s")
+	}
+	if parsedData.SystemInfo == "" {
+		logger.Warnf("No system header line found; host details will be missing from the report")
+	}
+	if partitions == "" {
+		partitions = IOStatPartitionsKeep
+	}
+	parsedData, changed := ApplyIOStatPartitions(parsedData, partitions)
+	switch {
+	case changed > 0 && partitions == IOStatPartitionsRollup:
+		logger.Infof("Rolled %d partition rows up into their devices", changed)
+	case changed > 0:
+		logger.Infof("Filtered out %d partition rows", changed)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
 
 	// Generate HTML report with charts
-	htmlReport, err := GenerateIOStatHTML(parsedData)
+	logger.Infof("Using thresholds %%util > %.0f%% and await > %.0fms", thresholds.UtilizationPct, thresholds.AwaitMs)
+	if maxPoints > 0 && len(parsedData.Snapshots) > maxPoints {
+		logger.Infof("Averaging %d snapshots into %d chart points", len(parsedData.Snapshots), maxPoints)
+	}
+	htmlReport, err := GenerateIOStatHTMLWithTheme(parsedData, thresholds, maxPoints, themeName)
 	if err != nil {
+		logger.Errorf("Failed to generate HTML report: %v", err)
 		return "", fmt.Errorf("failed to generate HTML report: %w", err)
 	}
+	logger.Infof("Generated HTML report (%d bytes)", len(htmlReport))
 
 	// Calculate summary statistics
 	snapshotCount := len(parsedData.Snapshots)
 	uniqueDevices := countUniqueDevices(parsedData)
 	peakCPUUsage := findPeakCPUUsage(parsedData)
 	peakDeviceQueueSize := findPeakDeviceQueueSize(parsedData)
+	findings := findIOStatThresholdBreaches(parsedData, thresholds)
+	logger.Infof("Monitored %d devices; peak CPU usage %.1f%%, peak device queue size %.1f",
+		uniqueDevices, peakCPUUsage, peakDeviceQueueSize)
+	if len(findings) > 0 {
+		logger.Warnf("Found %d threshold breaches", len(findings))
+	} else {
+		logger.Infof("No device samples crossed the thresholds")
+	}
 
 	// Generate summary and analysis text
 	summary := fmt.Sprintf("IOStat analysis report covering %d snapshots with %d devices monitored",
 		snapshotCount, uniqueDevices)
 
 	analysis := fmt.Sprintf("Peak CPU usage: %.1f%%, Peak device queue size: %.1f. "+
+		"%d threshold breaches found (%%util > %.0f%%, await > %.0fms). "+
 		"Analysis includes CPU utilization over time, I/O throughput patterns, await times, "+
 		"queue sizes, and request patterns. Interactive charts provide detailed visualization of system I/O performance.",
-		peakCPUUsage, peakDeviceQueueSize)
+		peakCPUUsage, peakDeviceQueueSize, len(findings), thresholds.UtilizationPct, thresholds.AwaitMs)
 
 	// Build comprehensive report structure
 	report := map[string]any{
 		"type":                   "iostat",
-		"file_size":              len(content),
+		"file_size":              fileSize,
 		"summary":                summary,
 		"analysis":               analysis,
 		"generated_at":           time.Now().Format(time.RFC3339),
 		"html_report":            htmlReport,
+		"chart_data":             GenerateIOStatChartData(parsedData, thresholds, maxPoints),
 		"snapshot_count":         snapshotCount,
 		"unique_devices":         uniqueDevices,
 		"peak_cpu_usage":         peakCPUUsage,
 		"peak_device_queue_size": peakDeviceQueueSize,
 		"system_info":            parsedData.SystemInfo,
+		"source_unit":            parsedData.SourceUnit,
+		"device_summaries":       summarizeDevices(parsedData),
+		"thresholds":             thresholds,
+		"findings":               findings,
+		"timezone":               loc.String(),
+		"theme":                  lookupReportTheme(themeName).Name,
+		"partitions":             partitions,
+	}
+
+	reportJSON, err := json.Marshal(report)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal report: %w", err)
+	}
+
+	return string(reportJSON), nil
+}
+
+// VMStatReporter generates vmstat reports
+type VMStatReporter struct{}
+
+// Type implements Reporter
+func (VMStatReporter) Type() string {
+	return "vmstat"
+}
+
+// AutoGenerate implements Reporter
+func (VMStatReporter) AutoGenerate() bool {
+	return true
+}
+
+// Generate implements Reporter, charting the samples in opts.Location with at most
+// opts.MaxPoints points
+func (VMStatReporter) Generate(ctx context.Context, filePath string, opts Options) (string, error) {
+	return GenerateVMStatReportWithTheme(ctx, filePath, opts.Location, opts.MaxPoints, opts.Theme, opts.Logger)
+}
+
+// GenerateVMStatReport generates a report for vmstat output with timestamps in UTC
+func GenerateVMStatReport(ctx context.Context, filePath string, logger ReportLogger) (string, error) {
+	return GenerateVMStatReportWithTheme(ctx, filePath, time.UTC, 0, DefaultReportTheme, logger)
+}
+
+// GenerateVMStatReportWithTheme generates a report for vmstat output
+// This function parses vmstat samples, with timestamps interpreted in loc, and generates
+// both a JSON summary and an HTML report charting the run queue, context switches, CPU
+// breakdown and swap activity in at most maxPoints points, styled by the named report theme.
+// Progress is written to logger, or stdout when it is nil
+func GenerateVMStatReportWithTheme(ctx context.Context, filePath string, loc *time.Location, maxPoints int, themeName string, logger ReportLogger) (string, error) {
+	logger = loggerOrDefault(logger)
+
+	file, fileSize, err := openCapture(filePath)
+	if err != nil {
+		logger.Errorf("Failed to read %s: %v", filePath, err)
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	// Stream vmstat content to extract structured data
+	parsedData, err := ParseVMStatReader(newContextReader(ctx, file), loc)
+	if err != nil {
+		logger.Errorf("Failed to parse vmstat content: %v", err)
+		return "", fmt.Errorf("failed to parse vmstat content: %w", err)
+	}
+	logger.Infof("Read %d bytes of vmstat output", fileSize)
+	logger.Infof("Parsed %d snapshots, skipping the first sample's averages since boot", len(parsedData.Snapshots))
+	if len(parsedData.Snapshots) == 0 {
+		logger.Warnf("No vmstat snapshots found; expected output from vmstat with an interval, e.g. vmstat -t 1")
+	} else if !parsedData.Timestamped {
+		logger.Warnf("No timestamps in the capture; charts are labelled by sample number, run vmstat -t to record times")
+	}
+
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	// Generate HTML report with charts
+	if maxPoints > 0 && len(parsedData.Snapshots) > maxPoints {
+		logger.Infof("Averaging %d snapshots into %d chart points", len(parsedData.Snapshots), maxPoints)
+	}
+	htmlReport, err := GenerateVMStatHTML(parsedData, maxPoints, themeName)
+	if err != nil {
+		logger.Errorf("Failed to generate HTML report: %v", err)
+		return "", fmt.Errorf("failed to generate HTML report: %w", err)
+	}
+	logger.Infof("Generated HTML report (%d bytes)", len(htmlReport))
+
+	// Calculate summary statistics
+	snapshotCount := len(parsedData.Snapshots)
+	peakRunQueue := findPeakRunQueue(parsedData)
+	peakContextSwitches := findPeakContextSwitches(parsedData)
+	swappingSnapshots := countSwappingSnapshots(parsedData)
+	logger.Infof("Peak run queue %.0f, peak context switches %.0f/s", peakRunQueue, peakContextSwitches)
+	if swappingSnapshots > 0 {
+		logger.Warnf("Memory was swapped in or out in %d snapshots", swappingSnapshots)
+	}
+
+	// Generate summary and analysis text
+	summary := fmt.Sprintf("VMStat analysis report covering %d snapshots", snapshotCount)
+
+	analysis := fmt.Sprintf("Peak run queue: %.0f, Peak context switches: %.0f/s. "+
+		"Memory was swapped in or out in %d of %d snapshots. "+
+		"Analysis includes the run queue, context switches and interrupts, CPU breakdown and swap activity over time.",
+		peakRunQueue, peakContextSwitches, swappingSnapshots, snapshotCount)
+
+	// Build comprehensive report structure
+	report := map[string]any{
+		"type":                  "vmstat",
+		"file_size":             fileSize,
+		"summary":               summary,
+		"analysis":              analysis,
+		"generated_at":          time.Now().Format(time.RFC3339),
+		"html_report":           htmlReport,
+		"snapshot_count":        snapshotCount,
+		"timestamped":           parsedData.Timestamped,
+		"peak_run_queue":        peakRunQueue,
+		"peak_context_switches": peakContextSwitches,
+		"swapping_snapshots":    swappingSnapshots,
+		"timezone":              loc.String(),
+		"theme":                 lookupReportTheme(themeName).Name,
+	}
+
+	reportJSON, err := json.Marshal(report)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal report: %w", err)
+	}
+
+	return string(reportJSON), nil
+}
+
+// GenerateDremioProfileReport generates a report for Dremio query profiles
+// This function accepts either the profile JSON or the profile zip downloaded from
+// the Dremio UI and generates both a JSON summary and an HTML report.
+// Progress is written to logger, or stdout when it is nil
+func GenerateDremioProfileReport(ctx context.Context, filePath string, logger ReportLogger) (string, error) {
+	logger = loggerOrDefault(logger)
+
+	content, err := readCapture(filePath)
+	if err != nil {
+		logger.Errorf("Failed to read %s: %v", filePath, err)
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	// Parse the profile to extract structured data
+	parsedData, err := ParseDremioProfile(content)
+	if err != nil {
+		logger.Errorf("Failed to parse profile: %v", err)
+		return "", fmt.Errorf("failed to parse profile: %w", err)
+	}
+	logger.Infof("Parsed profile for query %s with %d operators", parsedData.QueryID, len(parsedData.Operators))
+
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	// Generate HTML report with charts
+	htmlReport, err := GenerateDremioProfileHTML(parsedData)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate HTML report: %w", err)
+	}
+
+	// Generate summary and analysis text
+	summary := fmt.Sprintf("Dremio profile report for query %s (%s) taking %d ms",
+		parsedData.QueryID, parsedData.State, parsedData.TotalMillis)
+
+	analysis := fmt.Sprintf("Planning took %d ms across %d phases. %d operators and %d non-default support options recorded.",
+		parsedData.PlanningMillis, len(parsedData.PlanPhases), len(parsedData.Operators), len(parsedData.NonDefaultOptions))
+	if parsedData.Error != "" {
+		analysis += " The query failed: " + parsedData.Error
+	}
+
+	// Build comprehensive report structure
+	report := map[string]any{
+		"type":                "dremio_profile",
+		"file_size":           len(content),
+		"summary":             summary,
+		"analysis":            analysis,
+		"generated_at":        time.Now().Format(time.RFC3339),
+		"html_report":         htmlReport,
+		"query_id":            parsedData.QueryID,
+		"state":               parsedData.State,
+		"total_millis":        parsedData.TotalMillis,
+		"planning_millis":     parsedData.PlanningMillis,
+		"plan_phases":         parsedData.PlanPhases,
+		"operators":           parsedData.Operators,
+		"non_default_options": parsedData.NonDefaultOptions,
+	}
+
+	reportJSON, err := json.Marshal(report)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal report: %w", err)
+	}
+
+	return string(reportJSON), nil
+}
+
+// GenerateThreadDumpReport generates a report for JVM thread dumps (jstack or kill -3 output)
+// This function groups threads by state and stack signature, lists the locks threads are
+// blocked on and, for files with several appended dumps, tracks thread states over time.
+// It generates both a JSON summary and an HTML report.
+// Progress is written to logger, or stdout when it is nil
+func GenerateThreadDumpReport(ctx context.Context, filePath string, logger ReportLogger) (string, error) {
+	logger = loggerOrDefault(logger)
+
+	file, fileSize, err := openCapture(filePath)
+	if err != nil {
+		logger.Errorf("Failed to read %s: %v", filePath, err)
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	// Stream the thread dump to extract structured data
+	parsedData, err := ParseThreadDumpReader(newContextReader(ctx, file))
+	if err != nil {
+		logger.Errorf("Failed to parse thread dump: %v", err)
+		return "", fmt.Errorf("failed to parse thread dump: %w", err)
+	}
+	logger.Infof("Parsed %d thread dump snapshots", len(parsedData.Snapshots))
+
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	// Generate HTML report with charts
+	htmlReport, err := GenerateThreadDumpHTML(parsedData)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate HTML report: %w", err)
+	}
+
+	// Thread details come from the latest snapshot, contention trends from all of them
+	latest := latestThreadDump(parsedData)
+	if latest == nil {
+		latest = &ThreadDump{Threads: []ThreadDumpThread{}}
+	}
+	states := summarizeThreadStates(latest)
+	groups := groupThreadsByStack(latest)
+	locks := summarizeThreadLocks(latest)
+	streaks := findPersistentlyBlockedThreads(parsedData)
+
+	// Generate summary and analysis text
+	summary := fmt.Sprintf("Thread dump report covering %d snapshots, latest with %d threads in %d distinct stacks",
+		len(parsedData.Snapshots), len(latest.Threads), len(groups))
+
+	analysis := "No threads are blocked on a lock."
+	if len(locks) > 0 && locks[0].Blocked > 0 {
+		analysis = fmt.Sprintf("%d threads blocked on lock <%s> (a %s)", locks[0].Blocked, locks[0].Address, locks[0].Class)
+		if locks[0].Owner != "" {
+			analysis += fmt.Sprintf(" held by \"%s\"", locks[0].Owner)
+		}
+		analysis += "."
+	}
+	if len(streaks) > 0 {
+		analysis += fmt.Sprintf(" %d threads stayed BLOCKED across consecutive snapshots, a sign of contention or deadlock.", len(streaks))
+	}
+
+	// Build comprehensive report structure
+	report := map[string]any{
+		"type":                 "thread_dump",
+		"file_size":            fileSize,
+		"summary":              summary,
+		"analysis":             analysis,
+		"generated_at":         time.Now().Format(time.RFC3339),
+		"html_report":          htmlReport,
+		"snapshot_count":       len(parsedData.Snapshots),
+		"thread_count":         len(latest.Threads),
+		"thread_states":        states,
+		"stack_groups":         len(groups),
+		"locks":                locks,
+		"persistently_blocked": streaks,
 	}
 
 	reportJSON, err := json.Marshal(report)
@@ -156,12 +792,278 @@ func GenerateIOStatReport(filePath string) (string, error) {
 	return string(reportJSON), nil
 }
 
-// GenerateJFRReport generates a report for JFR files
-func GenerateJFRReport(filePath string) (string, error) {
-	content, err := secureReadFile(filePath)
+// GenerateQueriesReport generates a report for the queries.json log written by Dremio
+// This function aggregates query counts, durations, memory and failures and lists the
+// slowest queries. It generates both a JSON summary and an HTML report.
+// Progress is written to logger, or stdout when it is nil
+func GenerateQueriesReport(ctx context.Context, filePath string, logger ReportLogger) (string, error) {
+	logger = loggerOrDefault(logger)
+
+	file, fileSize, err := openCapture(filePath)
 	if err != nil {
+		logger.Errorf("Failed to read %s: %v", filePath, err)
 		return "", fmt.Errorf("failed to read file: %w", err)
 	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	// Stream the query log to extract structured data
+	parsedData, err := ParseQueriesJSONReader(newContextReader(ctx, file))
+	if err != nil {
+		logger.Errorf("Failed to parse queries.json: %v", err)
+		return "", fmt.Errorf("failed to parse queries.json: %w", err)
+	}
+	logger.Infof("Parsed %d queries", parsedData.TotalQueries)
+	if parsedData.SkippedLines > 0 {
+		logger.Warnf("Skipped %d lines that were not query records", parsedData.SkippedLines)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	// Generate HTML report with charts
+	htmlReport, err := GenerateQueriesHTML(parsedData)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate HTML report: %w", err)
+	}
+
+	// Generate summary and analysis text
+	summary := fmt.Sprintf("Dremio queries report covering %d queries, %d failed",
+		parsedData.TotalQueries, parsedData.FailedQueries)
+
+	analysis := fmt.Sprintf("Query duration p50 %.0f ms, p95 %.0f ms, max %d ms.",
+		parsedData.DurationMs.P50, parsedData.DurationMs.P95, parsedData.MaxDurationMs)
+	if len(parsedData.FailureReasons) > 0 {
+		analysis += fmt.Sprintf(" Most common failure (%d queries): %s",
+			parsedData.FailureReasons[0].Count, parsedData.FailureReasons[0].Reason)
+	}
+
+	// Build comprehensive report structure
+	report := map[string]any{
+		"type":             "queries_json",
+		"file_size":        fileSize,
+		"summary":          summary,
+		"analysis":         analysis,
+		"generated_at":     time.Now().Format(time.RFC3339),
+		"html_report":      htmlReport,
+		"total_queries":    parsedData.TotalQueries,
+		"failed_queries":   parsedData.FailedQueries,
+		"skipped_lines":    parsedData.SkippedLines,
+		"duration_ms":      parsedData.DurationMs,
+		"max_duration_ms":  parsedData.MaxDurationMs,
+		"memory_bytes":     parsedData.MemoryBytes,
+		"max_memory_bytes": parsedData.MaxMemoryBytes,
+		"outcomes":         parsedData.Outcomes,
+		"failure_reasons":  parsedData.FailureReasons,
+		"slowest_queries":  parsedData.SlowestQueries,
+	}
+
+	reportJSON, err := json.Marshal(report)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal report: %w", err)
+	}
+
+	return string(reportJSON), nil
+}
+
+// GenerateConfigReport generates a report for a Dremio configuration file from a support
+// bundle: dremio.conf, dremio-env or a Hadoop site XML file
+func GenerateConfigReport(ctx context.Context, filePath string, logger ReportLogger) (string, error) {
+	logger = loggerOrDefault(logger)
+
+	file, fileSize, err := openCapture(filePath)
+	if err != nil {
+		logger.Errorf("Failed to read %s: %v", filePath, err)
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	// Configuration files are small, so they are parsed in one piece
+	content, err := io.ReadAll(newContextReader(ctx, file))
+	if err != nil {
+		logger.Errorf("Failed to read %s: %v", filePath, err)
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+
+	parsedData, err := ParseDremioConfig(content)
+	if err != nil {
+		logger.Errorf("Failed to parse configuration: %v", err)
+		return "", fmt.Errorf("failed to parse configuration: %w", err)
+	}
+	logger.Infof("Parsed %d %s settings", len(parsedData.Settings), parsedData.Format)
+
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	// Generate HTML report with the settings tables
+	htmlReport, err := GenerateConfigHTML(parsedData)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate HTML report: %w", err)
+	}
+
+	// Generate summary and analysis text
+	warnings := 0
+	for _, finding := range parsedData.Findings {
+		if finding.Severity == ConfigSeverityWarning {
+			warnings++
+		}
+	}
+	summary := fmt.Sprintf("Dremio configuration report (%s) with %d settings, %d non-default",
+		parsedData.Format, len(parsedData.Settings), parsedData.NonDefaultCount)
+
+	analysis := fmt.Sprintf("%d findings, %d warnings.", len(parsedData.Findings), warnings)
+	if len(parsedData.Findings) > 0 {
+		analysis += fmt.Sprintf(" %s: %s", parsedData.Findings[0].Key, parsedData.Findings[0].Message)
+	}
+
+	// Build comprehensive report structure
+	report := map[string]any{
+		"type":              "dremio_config",
+		"file_size":         fileSize,
+		"summary":           summary,
+		"analysis":          analysis,
+		"generated_at":      time.Now().Format(time.RFC3339),
+		"html_report":       htmlReport,
+		"format":            parsedData.Format,
+		"settings_count":    len(parsedData.Settings),
+		"non_default_count": parsedData.NonDefaultCount,
+		"findings":          parsedData.Findings,
+		"settings":          parsedData.Settings,
+	}
+
+	reportJSON, err := json.Marshal(report)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal report: %w", err)
+	}
+
+	return string(reportJSON), nil
+}
+
+// TruncateReport shrinks a report that is larger than maxBytes by dropping its HTML report
+// and any list or object fields, keeping the type, summary, analysis and other scalar
+// values. The stored report is marked "truncated" and records its original size
+func TruncateReport(reportJSON string, maxBytes int64) (string, error) {
+	var report map[string]any
+	if err := json.Unmarshal([]byte(reportJSON), &report); err != nil {
+		return "", fmt.Errorf("failed to parse report: %w", err)
+	}
+
+	truncated := map[string]any{}
+	for key, value := range report {
+		switch value.(type) {
+		case string, float64, bool, nil:
+			truncated[key] = value
+		}
+	}
+	truncated["html_report"] = truncatedReportHTML(len(reportJSON), maxBytes)
+	truncated["truncated"] = true
+	truncated["original_bytes"] = len(reportJSON)
+
+	result, err := json.Marshal(truncated)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal report: %w", err)
+	}
+	return string(result), nil
+}
+
+// Chart point limits tried, halving each time, when a time series report is over its size limit
+const (
+	initialDownsamplePoints = 1000
+	minDownsamplePoints     = 50
+)
+
+// RegenerateFunc generates a report again with at most maxPoints charted points
+type RegenerateFunc func(ctx context.Context, maxPoints int) (string, error)
+
+// EnforceReportSize keeps reportData within maxBytes, 0 for no limit. ttop and iostat reports
+// are generated again by regenerate with fewer chart points than maxChartPoints until they
+// fit; anything still too large is truncated to its summary with a warning
+func EnforceReportSize(ctx context.Context, reportType, reportData string, maxBytes int64, maxChartPoints int, regenerate RegenerateFunc, logger ReportLogger) (string, error) {
+	if maxBytes <= 0 || int64(len(reportData)) <= maxBytes {
+		return reportData, nil
+	}
+	logger = loggerOrDefault(logger)
+	logger.Warnf("Report is %d bytes, over the %d byte limit", len(reportData), maxBytes)
+
+	if reportType == "ttop" || reportType == "iostat" {
+		start := initialDownsamplePoints
+		if maxChartPoints > 0 && maxChartPoints/2 < start {
+			start = maxChartPoints / 2
+		}
+		for maxPoints := start; maxPoints >= minDownsamplePoints; maxPoints /= 2 {
+			downsampled, err := regenerate(ctx, maxPoints)
+			if err != nil {
+				return "", err
+			}
+			if int64(len(downsampled)) <= maxBytes {
+				logger.Warnf("Downsampled charts to %d points to fit the report in %d bytes", maxPoints, len(downsampled))
+				return downsampled, nil
+			}
+		}
+	}
+
+	truncated, err := TruncateReport(reportData, maxBytes)
+	if err != nil {
+		return "", err
+	}
+	logger.Warnf("Stored a truncated report with only the summary; raise max_report_bytes to keep the charts")
+	return truncated, nil
+}
+
+// WithoutChartData removes the chart data that HTML reports load one chart at a time, for
+// serving a report to the page that displays it. Reports without chart data are unchanged
+func WithoutChartData(reportJSON string) (string, error) {
+	var report map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(reportJSON), &report); err != nil {
+		return "", fmt.Errorf("failed to parse report: %w", err)
+	}
+	if _, ok := report["chart_data"]; !ok {
+		return reportJSON, nil
+	}
+	delete(report, "chart_data")
+
+	result, err := json.Marshal(report)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal report: %w", err)
+	}
+	return string(result), nil
+}
+
+// truncatedReportHTML returns the HTML shown in place of a report that was too large to store
+func truncatedReportHTML(originalBytes int, maxBytes int64) string {
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Report Truncated</title>
+</head>
+<body>
+    <h1>Report Truncated</h1>
+    <p>The generated report was %d bytes, over the %d byte limit set by max_report_bytes, so only its summary was kept.</p>
+</body>
+</html>`, originalBytes, maxBytes)
+}
+
+// GenerateJFRReport generates a report for JFR files.
+// Progress is written to logger, or stdout when it is nil
+func GenerateJFRReport(ctx context.Context, filePath string, logger ReportLogger) (string, error) {
+	logger = loggerOrDefault(logger)
+
+	content, err := readCapture(filePath)
+	if err != nil {
+		logger.Errorf("Failed to read %s: %v", filePath, err)
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	logger.Warnf("JFR analysis is not implemented yet; producing a basic report")
 
 	// Parse JFR content and generate report
 	report := map[string]any{
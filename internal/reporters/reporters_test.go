@@ -15,9 +15,15 @@
 package reporters
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/rsvihladremio/ddd/internal/testutil"
@@ -36,7 +42,7 @@ func TestGenerateTTopReport(t *testing.T) {
 		require.NoError(t, err)
 
 		// Generate report
-		reportJSON, err := GenerateTTopReport(filePath)
+		reportJSON, err := GenerateTTopReport(context.Background(), filePath, nil)
 		require.NoError(t, err)
 		assert.NotEmpty(t, reportJSON)
 
@@ -53,7 +59,7 @@ func TestGenerateTTopReport(t *testing.T) {
 	})
 
 	t.Run("Non-existent file", func(t *testing.T) {
-		_, err := GenerateTTopReport("/non/existent/file.txt")
+		_, err := GenerateTTopReport(context.Background(), "/non/existent/file.txt", nil)
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "failed to read file")
 	})
@@ -65,7 +71,7 @@ func TestGenerateTTopReport(t *testing.T) {
 		err := os.WriteFile(filePath, []byte(""), 0644)
 		require.NoError(t, err)
 
-		reportJSON, err := GenerateTTopReport(filePath)
+		reportJSON, err := GenerateTTopReport(context.Background(), filePath, nil)
 		require.NoError(t, err)
 
 		var report map[string]interface{}
@@ -88,7 +94,7 @@ func TestGenerateTTopReport(t *testing.T) {
 		err := os.WriteFile(filePath, []byte(largeContent), 0644)
 		require.NoError(t, err)
 
-		reportJSON, err := GenerateTTopReport(filePath)
+		reportJSON, err := GenerateTTopReport(context.Background(), filePath, nil)
 		require.NoError(t, err)
 
 		var report map[string]interface{}
@@ -127,7 +133,7 @@ sda              0.00      0.00     0.00   0.00    0.00     0.00  395.00  38116.
 		require.NoError(t, err)
 
 		// Generate report
-		reportJSON, err := GenerateIOStatReport(filePath)
+		reportJSON, err := GenerateIOStatReport(context.Background(), filePath, nil)
 		require.NoError(t, err)
 		assert.NotEmpty(t, reportJSON)
 
@@ -162,6 +168,12 @@ sda              0.00      0.00     0.00   0.00    0.00     0.00  395.00  38116.
 		assert.Contains(t, htmlReport, "<!DOCTYPE html>")
 		assert.Contains(t, htmlReport, "IOStat Analysis Report")
 		assert.Contains(t, htmlReport, "echarts.min.js")
+
+		// The charts load their data from beside the HTML report
+		chartData, ok := report["chart_data"].(map[string]interface{})
+		require.True(t, ok)
+		assert.Len(t, chartData, len(IOStatChartNames))
+		assert.Contains(t, chartData, "cpu")
 	})
 
 	t.Run("Empty iostat file", func(t *testing.T) {
@@ -171,7 +183,7 @@ sda              0.00      0.00     0.00   0.00    0.00     0.00  395.00  38116.
 		err := os.WriteFile(filePath, []byte(""), 0644)
 		require.NoError(t, err)
 
-		reportJSON, err := GenerateIOStatReport(filePath)
+		reportJSON, err := GenerateIOStatReport(context.Background(), filePath, nil)
 		require.NoError(t, err)
 
 		var report map[string]interface{}
@@ -195,7 +207,7 @@ That should not cause the parser to crash`
 		err := os.WriteFile(filePath, []byte(malformedContent), 0644)
 		require.NoError(t, err)
 
-		reportJSON, err := GenerateIOStatReport(filePath)
+		reportJSON, err := GenerateIOStatReport(context.Background(), filePath, nil)
 		require.NoError(t, err)
 
 		var report map[string]interface{}
@@ -235,7 +247,7 @@ nvme0n1         10.00    500.00     2.50  25.00    1.10    50.00   20.00   1000.
 		err := os.WriteFile(filePath, []byte(largeContent), 0644)
 		require.NoError(t, err)
 
-		reportJSON, err := GenerateIOStatReport(filePath)
+		reportJSON, err := GenerateIOStatReport(context.Background(), filePath, nil)
 		require.NoError(t, err)
 
 		var report map[string]interface{}
@@ -250,6 +262,197 @@ nvme0n1         10.00    500.00     2.50  25.00    1.10    50.00   20.00   1000.
 	})
 }
 
+func TestGenerateDremioProfileReport(t *testing.T) {
+	t.Run("Valid profile", func(t *testing.T) {
+		tempDir := t.TempDir()
+		filePath := filepath.Join(tempDir, "profile_attempt_0.json")
+
+		profileContent := testutil.SampleFiles["dremio_profile"].Content
+		err := os.WriteFile(filePath, profileContent, 0644)
+		require.NoError(t, err)
+
+		reportJSON, err := GenerateDremioProfileReport(context.Background(), filePath, nil)
+		require.NoError(t, err)
+
+		var report map[string]interface{}
+		err = json.Unmarshal([]byte(reportJSON), &report)
+		require.NoError(t, err)
+
+		assert.Equal(t, "dremio_profile", report["type"])
+		assert.Equal(t, float64(len(profileContent)), report["file_size"])
+		assert.Equal(t, "COMPLETED", report["state"])
+		assert.Equal(t, float64(2500), report["total_millis"])
+		assert.Contains(t, report["summary"], "COMPLETED")
+		assert.Contains(t, report["html_report"], "Dremio Profile Report")
+		assert.Len(t, report["plan_phases"], 3)
+		assert.Len(t, report["operators"], 2)
+	})
+
+	t.Run("Non-existent file", func(t *testing.T) {
+		_, err := GenerateDremioProfileReport(context.Background(), "/non/existent/profile.json", nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to read file")
+	})
+
+	t.Run("Invalid profile", func(t *testing.T) {
+		tempDir := t.TempDir()
+		filePath := filepath.Join(tempDir, "profile.json")
+		err := os.WriteFile(filePath, []byte("not a profile"), 0644)
+		require.NoError(t, err)
+
+		_, err = GenerateDremioProfileReport(context.Background(), filePath, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to parse profile")
+	})
+}
+
+func TestGenerateThreadDumpReport(t *testing.T) {
+	t.Run("Valid thread dump", func(t *testing.T) {
+		tempDir := t.TempDir()
+		filePath := filepath.Join(tempDir, "jstack.txt")
+
+		dumpContent := testutil.SampleFiles["thread_dump"].Content
+		err := os.WriteFile(filePath, dumpContent, 0644)
+		require.NoError(t, err)
+
+		reportJSON, err := GenerateThreadDumpReport(context.Background(), filePath, nil)
+		require.NoError(t, err)
+
+		var report map[string]interface{}
+		err = json.Unmarshal([]byte(reportJSON), &report)
+		require.NoError(t, err)
+
+		assert.Equal(t, "thread_dump", report["type"])
+		assert.Equal(t, float64(5), report["thread_count"])
+		assert.Equal(t, float64(1), report["snapshot_count"])
+		assert.Equal(t, `2 threads blocked on lock <0x000000008c0a1a30> (a java.lang.Object) held by "worker-1".`, report["analysis"])
+		assert.Contains(t, report["html_report"], "Thread Dump Report")
+	})
+
+	t.Run("Non-existent file", func(t *testing.T) {
+		_, err := GenerateThreadDumpReport(context.Background(), "/non/existent/jstack.txt", nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to read file")
+	})
+}
+
+func TestGenerateQueriesReport(t *testing.T) {
+	t.Run("Valid queries.json", func(t *testing.T) {
+		tempDir := t.TempDir()
+		filePath := filepath.Join(tempDir, "queries.json")
+
+		err := os.WriteFile(filePath, testutil.SampleFiles["queries_json"].Content, 0644)
+		require.NoError(t, err)
+
+		reportJSON, err := GenerateQueriesReport(context.Background(), filePath, nil)
+		require.NoError(t, err)
+
+		var report map[string]interface{}
+		err = json.Unmarshal([]byte(reportJSON), &report)
+		require.NoError(t, err)
+
+		assert.Equal(t, "queries_json", report["type"])
+		assert.Equal(t, float64(4), report["total_queries"])
+		assert.Equal(t, float64(1), report["failed_queries"])
+		assert.Equal(t, "Dremio queries report covering 4 queries, 1 failed", report["summary"])
+		assert.Equal(t, "Query duration p50 1850 ms, p95 38625 ms, max 45000 ms. Most common failure (1 queries): VALIDATION ERROR: Object 'missing' not found", report["analysis"])
+		assert.Len(t, report["slowest_queries"], 4)
+		assert.Contains(t, report["html_report"], "Dremio Queries Report")
+	})
+
+	t.Run("Non-existent file", func(t *testing.T) {
+		_, err := GenerateQueriesReport(context.Background(), "/non/existent/queries.json", nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to read file")
+	})
+}
+
+func TestGenerateConfigReport(t *testing.T) {
+	t.Run("Valid dremio.conf", func(t *testing.T) {
+		tempDir := t.TempDir()
+		filePath := filepath.Join(tempDir, "dremio.conf")
+
+		err := os.WriteFile(filePath, testutil.SampleFiles["dremio_config"].Content, 0644)
+		require.NoError(t, err)
+
+		reportJSON, err := GenerateConfigReport(context.Background(), filePath, nil)
+		require.NoError(t, err)
+
+		var report map[string]interface{}
+		err = json.Unmarshal([]byte(reportJSON), &report)
+		require.NoError(t, err)
+
+		assert.Equal(t, "dremio_config", report["type"])
+		assert.Equal(t, "hocon", report["format"])
+		assert.Equal(t, float64(9), report["settings_count"])
+		assert.Equal(t, float64(3), report["non_default_count"])
+		assert.Equal(t, "Dremio configuration report (hocon) with 9 settings, 3 non-default", report["summary"])
+		assert.Equal(t, "5 findings, 3 warnings. debug.enabled: Debug settings should not be enabled in production", report["analysis"])
+		assert.Len(t, report["findings"], 5)
+		assert.Len(t, report["settings"], 9)
+		assert.Contains(t, report["html_report"], "Dremio Configuration Report")
+	})
+
+	t.Run("Invalid configuration", func(t *testing.T) {
+		tempDir := t.TempDir()
+		filePath := filepath.Join(tempDir, "dremio.conf")
+		require.NoError(t, os.WriteFile(filePath, []byte("paths {\n"), 0644))
+
+		_, err := GenerateConfigReport(context.Background(), filePath, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to parse configuration")
+	})
+
+	t.Run("Non-existent file", func(t *testing.T) {
+		_, err := GenerateConfigReport(context.Background(), "/non/existent/dremio.conf", nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to read file")
+	})
+}
+
+func TestGenerateVMStatReport(t *testing.T) {
+	t.Run("Valid vmstat output", func(t *testing.T) {
+		tempDir := t.TempDir()
+		filePath := filepath.Join(tempDir, "vmstat.txt")
+
+		err := os.WriteFile(filePath, testutil.SampleFiles["vmstat"].Content, 0644)
+		require.NoError(t, err)
+
+		reportJSON, err := GenerateVMStatReport(context.Background(), filePath, nil)
+		require.NoError(t, err)
+
+		var report map[string]interface{}
+		err = json.Unmarshal([]byte(reportJSON), &report)
+		require.NoError(t, err)
+
+		assert.Equal(t, "vmstat", report["type"])
+		assert.Equal(t, float64(2), report["snapshot_count"])
+		assert.Equal(t, true, report["timestamped"])
+		assert.Equal(t, float64(9), report["peak_run_queue"])
+		assert.Equal(t, float64(2410), report["peak_context_switches"])
+		assert.Equal(t, float64(1), report["swapping_snapshots"])
+		assert.Equal(t, "VMStat analysis report covering 2 snapshots", report["summary"])
+		assert.Contains(t, report["analysis"], "Memory was swapped in or out in 1 of 2 snapshots")
+		assert.Contains(t, report["html_report"], "VMStat Analysis Report")
+	})
+
+	t.Run("Invalid vmstat output", func(t *testing.T) {
+		tempDir := t.TempDir()
+		filePath := filepath.Join(tempDir, "vmstat.txt")
+		require.NoError(t, os.WriteFile(filePath, []byte(" r  b   swpd   free   buff  cache   si   so    bi    bo   in   cs us sy id wa st\n 1 0\n"), 0644))
+
+		_, err := GenerateVMStatReport(context.Background(), filePath, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to parse vmstat content")
+	})
+
+	t.Run("Non-existent file", func(t *testing.T) {
+		_, err := GenerateVMStatReport(context.Background(), "/non/existent/vmstat.txt", nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to read file")
+	})
+}
+
 func TestReportGeneration_Integration(t *testing.T) {
 	t.Run("Generate reports for all sample file types", func(t *testing.T) {
 		tempDir := t.TempDir()
@@ -259,7 +462,7 @@ func TestReportGeneration_Integration(t *testing.T) {
 		err := os.WriteFile(ttopPath, testutil.SampleFiles["ttop"].Content, 0644)
 		require.NoError(t, err)
 
-		ttopReport, err := GenerateTTopReport(ttopPath)
+		ttopReport, err := GenerateTTopReport(context.Background(), ttopPath, nil)
 		require.NoError(t, err)
 		assert.NotEmpty(t, ttopReport)
 
@@ -295,7 +498,7 @@ sda              0.00      0.00     0.00   0.00    0.00     0.00  395.00  38116.
 		err = os.WriteFile(iostatPath, []byte(iostatContent), 0644)
 		require.NoError(t, err)
 
-		iostatReport, err := GenerateIOStatReport(iostatPath)
+		iostatReport, err := GenerateIOStatReport(context.Background(), iostatPath, nil)
 		require.NoError(t, err)
 		assert.NotEmpty(t, iostatReport)
 
@@ -336,7 +539,7 @@ sda              0.00      0.00     0.00   0.00    0.00     0.00  395.00  38116.
 		err := os.WriteFile(filePath, []byte(specialContent), 0644)
 		require.NoError(t, err)
 
-		reportJSON, err := GenerateTTopReport(filePath)
+		reportJSON, err := GenerateTTopReport(context.Background(), filePath, nil)
 		require.NoError(t, err)
 
 		// Should be valid JSON despite special characters
@@ -365,7 +568,7 @@ sda              0.00      0.00     0.00   0.00    0.00     0.00  395.00  38116.
 
 		for _, filePath := range filePaths {
 			go func(path string) {
-				report, err := GenerateTTopReport(path)
+				report, err := GenerateTTopReport(context.Background(), path, nil)
 				if err != nil {
 					errors <- err
 					return
@@ -400,7 +603,7 @@ func TestReportValidation(t *testing.T) {
 		err := os.WriteFile(filePath, testutil.SampleFiles["ttop"].Content, 0644)
 		require.NoError(t, err)
 
-		reportJSON, err := GenerateTTopReport(filePath)
+		reportJSON, err := GenerateTTopReport(context.Background(), filePath, nil)
 		require.NoError(t, err)
 
 		// Parse the JSON
@@ -435,7 +638,7 @@ func TestReportValidation(t *testing.T) {
 		reports := make([]map[string]interface{}, 3)
 
 		for i := 0; i < 3; i++ {
-			reportJSON, err := GenerateTTopReport(filePath)
+			reportJSON, err := GenerateTTopReport(context.Background(), filePath, nil)
 			require.NoError(t, err)
 
 			err = json.Unmarshal([]byte(reportJSON), &reports[i])
@@ -475,7 +678,7 @@ func TestReportErrorHandling(t *testing.T) {
 			}
 		})
 
-		_, err = GenerateTTopReport(filePath)
+		_, err = GenerateTTopReport(context.Background(), filePath, nil)
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "failed to read file")
 	})
@@ -483,12 +686,170 @@ func TestReportErrorHandling(t *testing.T) {
 	t.Run("Directory instead of file", func(t *testing.T) {
 		tempDir := t.TempDir()
 
-		_, err := GenerateTTopReport(tempDir)
+		_, err := GenerateTTopReport(context.Background(), tempDir, nil)
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "failed to read file")
 	})
 }
 
+func TestSecureOpenFile(t *testing.T) {
+	t.Run("Returns the file and its size", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "ttop.txt")
+		require.NoError(t, os.WriteFile(filePath, testutil.SampleFiles["ttop"].Content, 0600))
+
+		file, size, err := secureOpenFile(filePath)
+		require.NoError(t, err)
+		defer func() {
+			_ = file.Close()
+		}()
+		assert.Equal(t, int64(len(testutil.SampleFiles["ttop"].Content)), size)
+	})
+
+	t.Run("Rejects directories", func(t *testing.T) {
+		_, _, err := secureOpenFile(t.TempDir())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "is a directory")
+	})
+
+	t.Run("Rejects directory traversal", func(t *testing.T) {
+		_, _, err := secureOpenFile("../../etc/passwd")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "directory traversal")
+	})
+}
+
+func TestOpenCapture(t *testing.T) {
+	gzipped := func(t *testing.T, content []byte) []byte {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		_, err := gz.Write(content)
+		require.NoError(t, err)
+		require.NoError(t, gz.Close())
+		return buf.Bytes()
+	}
+	write := func(t *testing.T, name string, content []byte) string {
+		filePath := filepath.Join(t.TempDir(), name)
+		require.NoError(t, os.WriteFile(filePath, content, 0600))
+		return filePath
+	}
+
+	t.Run("Plain captures are read as they are", func(t *testing.T) {
+		content, err := readCapture(write(t, "ttop.txt", testutil.SampleFiles["ttop"].Content))
+		require.NoError(t, err)
+		assert.Equal(t, testutil.SampleFiles["ttop"].Content, content)
+	})
+
+	t.Run("Gzipped captures are decompressed", func(t *testing.T) {
+		compressed := gzipped(t, testutil.SampleFiles["iostat"].Content)
+		file, size, err := openCapture(write(t, "iostat.log.gz", compressed))
+		require.NoError(t, err)
+		defer func() {
+			_ = file.Close()
+		}()
+
+		assert.Equal(t, int64(len(compressed)), size, "The size is of the file on disk")
+		content, err := io.ReadAll(file)
+		require.NoError(t, err)
+		assert.Equal(t, testutil.SampleFiles["iostat"].Content, content)
+	})
+
+	t.Run("Gzipped reports match the plain ones", func(t *testing.T) {
+		for _, fileType := range []string{"iostat", "ttop"} {
+			content := testutil.SampleFiles[fileType].Content
+			generate := GenerateIOStatReport
+			if fileType == "ttop" {
+				generate = GenerateTTopReport
+			}
+
+			plainJSON, err := generate(context.Background(), write(t, fileType+".txt", content), nil)
+			require.NoError(t, err)
+			gzippedJSON, err := generate(context.Background(), write(t, fileType+".txt.gz", gzipped(t, content)), nil)
+			require.NoError(t, err)
+
+			var plain, fromGzip map[string]any
+			require.NoError(t, json.Unmarshal([]byte(plainJSON), &plain))
+			require.NoError(t, json.Unmarshal([]byte(gzippedJSON), &fromGzip))
+			assert.Equal(t, plain["snapshot_count"], fromGzip["snapshot_count"], fileType)
+			assert.Equal(t, plain["summary"], fromGzip["summary"], fileType)
+		}
+	})
+
+	t.Run("Gzipped iostat is parsed", func(t *testing.T) {
+		reportJSON, err := GenerateIOStatReport(context.Background(), write(t, "iostat.log.gz", gzipped(t, testutil.SampleFiles["iostat"].Content)), nil)
+		require.NoError(t, err)
+		var report map[string]any
+		require.NoError(t, json.Unmarshal([]byte(reportJSON), &report))
+		assert.Positive(t, report["snapshot_count"])
+	})
+
+	t.Run("Compression bombs are refused", func(t *testing.T) {
+		defer func(limit int64) { maxDecompressedCaptureBytes = limit }(maxDecompressedCaptureBytes)
+		maxDecompressedCaptureBytes = 1024 * 1024
+
+		bomb := gzipped(t, make([]byte, 16*1024*1024))
+		require.Less(t, len(bomb), 64*1024)
+		_, err := readCapture(write(t, "profile.json.gz", bomb))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "decompressed capture exceeds 1048576 bytes")
+
+		// Captures right at the limit are still read
+		content, err := readCapture(write(t, "profile.json.gz", gzipped(t, make([]byte, 1024*1024))))
+		require.NoError(t, err)
+		assert.Len(t, content, 1024*1024)
+	})
+
+	t.Run("Corrupt gzip header", func(t *testing.T) {
+		_, _, err := openCapture(write(t, "iostat.log.gz", []byte{0x1f, 0x8b, 0x00}))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to decompress iostat.log.gz")
+	})
+}
+
+func TestTruncateReport(t *testing.T) {
+	t.Run("Keeps scalar fields and replaces the HTML", func(t *testing.T) {
+		original := `{"type":"iostat","summary":"IOStat analysis","snapshot_count":5000,"html_report":"<html>big</html>","device_summaries":[{"device":"sda"}],"thresholds":{"utilization_pct":90}}`
+
+		truncatedJSON, err := TruncateReport(original, 64)
+		require.NoError(t, err)
+
+		var report map[string]any
+		require.NoError(t, json.Unmarshal([]byte(truncatedJSON), &report))
+		assert.Equal(t, "iostat", report["type"])
+		assert.Equal(t, "IOStat analysis", report["summary"])
+		assert.Equal(t, float64(5000), report["snapshot_count"])
+		assert.Equal(t, true, report["truncated"])
+		assert.Equal(t, float64(len(original)), report["original_bytes"])
+		assert.Contains(t, report["html_report"], "over the 64 byte limit")
+		assert.NotContains(t, report, "device_summaries")
+		assert.NotContains(t, report, "thresholds")
+	})
+
+	t.Run("Invalid report JSON", func(t *testing.T) {
+		_, err := TruncateReport("not json", 64)
+		assert.ErrorContains(t, err, "failed to parse report")
+	})
+}
+
+func TestWithoutChartData(t *testing.T) {
+	t.Run("Removes the chart data", func(t *testing.T) {
+		light, err := WithoutChartData(`{"type":"iostat","html_report":"<html></html>","chart_data":{"cpu":{"labels":["12:00:00"],"series":[]}}}`)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"type":"iostat","html_report":"<html></html>"}`, light)
+	})
+
+	t.Run("Reports without chart data are unchanged", func(t *testing.T) {
+		original := `{"type":"ttop", "html_report":"<html></html>"}`
+		light, err := WithoutChartData(original)
+		require.NoError(t, err)
+		assert.Equal(t, original, light)
+	})
+
+	t.Run("Invalid report JSON", func(t *testing.T) {
+		_, err := WithoutChartData("not json")
+		assert.ErrorContains(t, err, "failed to parse report")
+	})
+}
+
 func TestGenerateTTopReport_HTMLReport(t *testing.T) {
 	t.Run("HTML report included in generated report", func(t *testing.T) {
 		tempDir := t.TempDir()
@@ -521,7 +882,7 @@ MiB Swap:      0.0 total,      0.0 free,      0.0 used.  12032.0 avail Mem
 		require.NoError(t, err)
 
 		// Generate report
-		reportJSON, err := GenerateTTopReport(filePath)
+		reportJSON, err := GenerateTTopReport(context.Background(), filePath, nil)
 		require.NoError(t, err)
 		assert.NotEmpty(t, reportJSON)
 
@@ -575,7 +936,7 @@ MiB Swap:      0.0 total,      0.0 free,      0.0 used.  12032.0 avail Mem
 		err := os.WriteFile(filePath, []byte(""), 0644)
 		require.NoError(t, err)
 
-		reportJSON, err := GenerateTTopReport(filePath)
+		reportJSON, err := GenerateTTopReport(context.Background(), filePath, nil)
 		require.NoError(t, err)
 
 		var report map[string]interface{}
@@ -606,7 +967,7 @@ Threads: 100 total,   2 running, 98 sleeping,   0 stopped,   0 zombie
 		err := os.WriteFile(filePath, []byte(sampleContent), 0644)
 		require.NoError(t, err)
 
-		reportJSON, err := GenerateTTopReport(filePath)
+		reportJSON, err := GenerateTTopReport(context.Background(), filePath, nil)
 		require.NoError(t, err)
 
 		var report map[string]interface{}
@@ -637,3 +998,102 @@ Threads: 100 total,   2 running, 98 sleeping,   0 stopped,   0 zombie
 		assert.Contains(t, htmlReport, "<div class=\"stat-value\">2</div>") // Should mention 2 unique threads
 	})
 }
+
+// recordingLogger captures report logs for assertions
+type recordingLogger struct {
+	lines []string
+}
+
+func (l *recordingLogger) Infof(format string, args ...any) {
+	l.lines = append(l.lines, LogLevelInfo+" "+fmt.Sprintf(format, args...))
+}
+
+func (l *recordingLogger) Warnf(format string, args ...any) {
+	l.lines = append(l.lines, LogLevelWarn+" "+fmt.Sprintf(format, args...))
+}
+
+func (l *recordingLogger) Errorf(format string, args ...any) {
+	l.lines = append(l.lines, LogLevelError+" "+fmt.Sprintf(format, args...))
+}
+
+func TestReportLogging(t *testing.T) {
+	t.Run("TTop report logs progress", func(t *testing.T) {
+		tempDir := t.TempDir()
+		filePath := filepath.Join(tempDir, "ttop.txt")
+		require.NoError(t, os.WriteFile(filePath, testutil.SampleFiles["ttop"].Content, 0600))
+
+		logger := &recordingLogger{}
+		_, err := GenerateTTopReport(context.Background(), filePath, logger)
+		require.NoError(t, err)
+
+		require.NotEmpty(t, logger.lines)
+		assert.Contains(t, logger.lines[0], "INFO Read ")
+		assert.Contains(t, strings.Join(logger.lines, "\n"), "INFO Parsed ")
+	})
+
+	t.Run("IOStat report logs thresholds and breaches", func(t *testing.T) {
+		tempDir := t.TempDir()
+		filePath := filepath.Join(tempDir, "iostat.txt")
+		require.NoError(t, os.WriteFile(filePath, testutil.SampleFiles["iostat"].Content, 0600))
+
+		logger := &recordingLogger{}
+		_, err := GenerateIOStatReportWithThresholds(context.Background(), filePath, IOStatThresholds{UtilizationPct: 1, AwaitMs: 1}, logger)
+		require.NoError(t, err)
+
+		lines := strings.Join(logger.lines, "\n")
+		assert.Contains(t, lines, "INFO Using thresholds %util > 1% and await > 1ms")
+		assert.Contains(t, lines, "WARN Found ")
+	})
+
+	t.Run("Read failures are logged as errors", func(t *testing.T) {
+		logger := &recordingLogger{}
+		_, err := GenerateTTopReport(context.Background(), "/non/existent/file.txt", logger)
+		require.Error(t, err)
+
+		require.Len(t, logger.lines, 1)
+		assert.Contains(t, logger.lines[0], "ERROR Failed to read /non/existent/file.txt")
+	})
+}
+
+func TestGenerateReportsStopWhenCancelled(t *testing.T) {
+	generators := map[string]func(context.Context, string, ReportLogger) (string, error){
+		"ttop":           GenerateTTopReport,
+		"iostat":         GenerateIOStatReport,
+		"vmstat":         GenerateVMStatReport,
+		"dremio_profile": GenerateDremioProfileReport,
+		"thread_dump":    GenerateThreadDumpReport,
+		"queries_json":   GenerateQueriesReport,
+		"dremio_config":  GenerateConfigReport,
+	}
+
+	for fileType, generate := range generators {
+		t.Run(fileType, func(t *testing.T) {
+			tempDir := t.TempDir()
+			filePath := filepath.Join(tempDir, "input.txt")
+			require.NoError(t, os.WriteFile(filePath, testutil.SampleFiles[fileType].Content, 0600))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			reportJSON, err := generate(ctx, filePath, &recordingLogger{})
+			require.Error(t, err)
+			assert.ErrorIs(t, err, context.Canceled)
+			assert.Empty(t, reportJSON)
+		})
+	}
+}
+
+func TestContextReader(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	reader := newContextReader(ctx, strings.NewReader("first line\nsecond line\n"))
+
+	buf := make([]byte, 5)
+	n, err := reader.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "first", string(buf[:n]))
+
+	cancel()
+	n, err = reader.Read(buf)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Zero(t, n)
+}
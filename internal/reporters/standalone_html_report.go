@@ -0,0 +1,113 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporters
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// echartsScriptTag is how the HTML reports load echarts from the DDD server
+const echartsScriptTag = `<script src="/static/js/echarts.min.js"></script>`
+
+// externalResourcePattern matches attributes and CSS that load a resource from a URL,
+// either on another host or a path on the DDD server. Data URIs are matched too and
+// allowed by ValidateSelfContained since they carry the resource with them
+var externalResourcePattern = regexp.MustCompile(`(?i)(?:\b(?:src|href)\s*=\s*["']?\s*(?:[a-z][a-z0-9+.-]*:|//|/)|@import\b|url\(\s*["']?\s*(?:[a-z][a-z0-9+.-]*:|//|/))`)
+
+// GenerateStandaloneHTML turns stored report JSON into a single HTML file that opens with
+// no network and no DDD server, for sending to customers. The echarts library is inlined
+// in place of the script tag that loads it from the server and the report data, less the
+// HTML report itself, is embedded as JSON. Reports without an HTML report get a page with
+// their summary and analysis
+func GenerateStandaloneHTML(reportJSON string, echartsJS []byte) (string, error) {
+	var report map[string]any
+	if err := json.Unmarshal([]byte(reportJSON), &report); err != nil {
+		return "", fmt.Errorf("failed to parse report: %w", err)
+	}
+
+	page, ok := report["html_report"].(string)
+	if !ok || page == "" {
+		page = generateSummaryOnlyHTML(report)
+	}
+	delete(report, "html_report")
+
+	// A closing tag anywhere in the library would end the inlined script early
+	inlined := "<script>" + strings.ReplaceAll(string(echartsJS), "</script", `<\/script`) + "</script>"
+	page = strings.ReplaceAll(page, echartsScriptTag, inlined)
+
+	// json.Marshal escapes <, > and & so the data can't close the script it is embedded in
+	data, err := json.Marshal(report)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal report data: %w", err)
+	}
+	dataScript := `<script type="application/json" id="ddd-report-data">` + string(data) + "</script>\n"
+	if end := strings.LastIndex(page, "</body>"); end >= 0 {
+		page = page[:end] + dataScript + page[end:]
+	} else {
+		page += dataScript
+	}
+
+	if err := ValidateSelfContained(page); err != nil {
+		return "", err
+	}
+	return page, nil
+}
+
+// ValidateSelfContained checks that an HTML report loads nothing from a server, neither
+// another host nor the DDD server it was generated on, so it works as a standalone file
+func ValidateSelfContained(reportHTML string) error {
+	for _, match := range externalResourcePattern.FindAllString(reportHTML, -1) {
+		if strings.HasSuffix(strings.ToLower(match), "data:") {
+			continue
+		}
+		return fmt.Errorf("report is not self-contained: it loads an external resource at %q", match)
+	}
+	return nil
+}
+
+// generateSummaryOnlyHTML returns a page with the summary and analysis of a report that
+// has no HTML report of its own
+func generateSummaryOnlyHTML(report map[string]any) string {
+	title := "DDD Report"
+	if reportType, ok := report["type"].(string); ok && reportType != "" {
+		title = reportType + " Report"
+	}
+	summary, _ := report["summary"].(string)
+	analysis, _ := report["analysis"].(string)
+
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>%s</title>
+</head>
+<body>
+    <h1>%s</h1>
+    <h2>Summary</h2>
+    <p>%s</p>
+    <h2>Analysis</h2>
+    <p>%s</p>
+</body>
+</html>`,
+		html.EscapeString(title),
+		html.EscapeString(title),
+		html.EscapeString(valueOrNA(summary)),
+		html.EscapeString(valueOrNA(analysis)))
+}
@@ -0,0 +1,125 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporters
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rsvihladremio/ddd/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateStandaloneHTML(t *testing.T) {
+	echartsJS := []byte(`window.echarts = {}; var tag = "</script>";`)
+
+	t.Run("Inlines echarts and the report data", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "iostat.txt")
+		require.NoError(t, os.WriteFile(filePath, testutil.SampleFiles["iostat"].Content, 0644))
+
+		reportJSON, err := GenerateIOStatReport(context.Background(), filePath, nil)
+		require.NoError(t, err)
+
+		page, err := GenerateStandaloneHTML(reportJSON, echartsJS)
+		require.NoError(t, err)
+
+		assert.NotContains(t, page, echartsScriptTag)
+		assert.Contains(t, page, `<script>window.echarts = {}; var tag = "<\/script>";</script>`)
+		assert.NoError(t, ValidateSelfContained(page))
+
+		// The data is embedded before </body>, without a second copy of the HTML report
+		start := strings.Index(page, `<script type="application/json" id="ddd-report-data">`)
+		require.GreaterOrEqual(t, start, 0)
+		assert.Less(t, start, strings.LastIndex(page, "</body>"))
+		data := page[start+len(`<script type="application/json" id="ddd-report-data">`):]
+		data = data[:strings.Index(data, "</script>")]
+
+		var report map[string]any
+		require.NoError(t, json.Unmarshal([]byte(data), &report))
+		assert.Equal(t, "iostat", report["type"])
+		assert.NotContains(t, report, "html_report")
+	})
+
+	t.Run("Reports without charts get a summary page", func(t *testing.T) {
+		reportJSON := `{"type":"jfr","summary":"<b>Summary</b>","analysis":"Some analysis"}`
+
+		page, err := GenerateStandaloneHTML(reportJSON, echartsJS)
+		require.NoError(t, err)
+
+		assert.Contains(t, page, "<h1>jfr Report</h1>")
+		assert.Contains(t, page, "&lt;b&gt;Summary&lt;/b&gt;")
+		assert.Contains(t, page, "Some analysis")
+		assert.NotContains(t, page, "<b>Summary</b>")
+	})
+
+	t.Run("Rejects reports that still load external resources", func(t *testing.T) {
+		reportJSON, err := json.Marshal(map[string]any{
+			"type":        "ttop",
+			"html_report": `<html><head><script src="https://cdn.example.com/lib.js"></script></head><body></body></html>`,
+		})
+		require.NoError(t, err)
+
+		_, err = GenerateStandaloneHTML(string(reportJSON), echartsJS)
+		assert.ErrorContains(t, err, "not self-contained")
+	})
+
+	t.Run("Rejects invalid report JSON", func(t *testing.T) {
+		_, err := GenerateStandaloneHTML("not json", echartsJS)
+		assert.Error(t, err)
+	})
+}
+
+func TestValidateSelfContained(t *testing.T) {
+	tests := []struct {
+		name  string
+		html  string
+		valid bool
+	}{
+		{"Inline script", `<script>var a = 1;</script>`, true},
+		{"Fragment link", `<a href="#top">Top</a>`, true},
+		{"Data URI in CSS", `<style>.a { background: url(data:image/png;base64,AAAA); }</style>`, true},
+		{"SVG gradient reference", `<style>.a { fill: url(#grad); }</style>`, true},
+		{"CDN script", `<script src="https://cdn.jsdelivr.net/npm/echarts.js"></script>`, false},
+		{"Protocol relative script", `<script src='//cdn.example.com/a.js'></script>`, false},
+		{"DDD server script", `<script src="/static/js/echarts.min.js"></script>`, false},
+		{"Stylesheet", `<link rel="stylesheet" href="/static/css/styles.css">`, false},
+		{"CSS import", `<style>@import "fonts.css";</style>`, false},
+		{"CSS url", `<style>.a { background: url('/img.png'); }</style>`, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateSelfContained(tc.html)
+			if tc.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestBundledEChartsIsSelfContained(t *testing.T) {
+	echartsJS, err := os.ReadFile("../../web/static/js/echarts.min.js")
+	require.NoError(t, err)
+
+	// The bundled library must not trip the self-containment check
+	assert.NoError(t, ValidateSelfContained(string(echartsJS)))
+}
@@ -0,0 +1,848 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporters
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"time"
+)
+
+// threadStackSignatureDepth is how many top frames make up a stack signature
+const threadStackSignatureDepth = 10
+
+// maxThreadGroupRows limits the rows shown in each thread dump table
+const maxThreadGroupRows = 20
+
+// ThreadStateCount is the number of threads in a given state
+type ThreadStateCount struct {
+	State string `json:"state"`
+	Count int    `json:"count"`
+}
+
+// ThreadStackGroup is a set of threads sharing the same top stack frames
+type ThreadStackGroup struct {
+	Frames      []string `json:"frames"`       // Top frames shared by every thread in the group
+	Count       int      `json:"count"`        // Number of threads in the group
+	States      []string `json:"states"`       // Distinct states of threads in the group
+	ThreadNames []string `json:"thread_names"` // Names of the threads in the group
+}
+
+// ThreadFrameCount is the number of threads whose top frame is a given method
+type ThreadFrameCount struct {
+	Method string `json:"method"`
+	Count  int    `json:"count"`
+}
+
+// ThreadLockSummary describes a lock that threads are waiting on
+type ThreadLockSummary struct {
+	Address string `json:"address"` // Lock address, e.g. 0x000000008c0a1a30
+	Class   string `json:"class"`   // Class of the lock object
+	Owner   string `json:"owner"`   // Name of the thread holding the lock, if known
+	Blocked int    `json:"blocked"` // Threads in BLOCKED state waiting to lock it
+	Waiting int    `json:"waiting"` // Total threads waiting on it in any state
+}
+
+// ThreadBlockedStreak describes a thread that stayed BLOCKED across consecutive snapshots
+type ThreadBlockedStreak struct {
+	Name          string    `json:"name"`           // Thread name
+	NID           string    `json:"nid"`            // Native thread id
+	Snapshots     int       `json:"snapshots"`      // Length of the longest consecutive BLOCKED run
+	FirstSnapshot int       `json:"first_snapshot"` // Index of the first snapshot in the run
+	LastSnapshot  int       `json:"last_snapshot"`  // Index of the last snapshot in the run
+	Since         time.Time `json:"since"`          // Timestamp of the first snapshot in the run
+	Until         time.Time `json:"until"`          // Timestamp of the last snapshot in the run
+	WaitingOn     string    `json:"waiting_on"`     // Lock the thread was waiting on at the end of the run
+	LockClass     string    `json:"lock_class"`     // Class of that lock
+}
+
+// ThreadStateSeries is the number of threads in one state for each snapshot
+type ThreadStateSeries struct {
+	State  string `json:"state"`
+	Counts []int  `json:"counts"`
+}
+
+// GenerateThreadDumpHTML generates an HTML report for parsed thread dumps. The latest snapshot
+// drives the thread state, stack signature, top method and lock contention sections; when the
+// file holds several snapshots a timeline of thread states and threads that stayed BLOCKED
+// across consecutive snapshots are added.
+func GenerateThreadDumpHTML(data *ThreadDumpReportData) (string, error) {
+	dump := latestThreadDump(data)
+	if dump == nil || len(dump.Threads) == 0 {
+		return generateEmptyThreadDumpHTML(), nil
+	}
+
+	states := summarizeThreadStates(dump)
+	groups := groupThreadsByStack(dump)
+	frames := summarizeTopFrames(dump)
+	locks := summarizeThreadLocks(dump)
+	streaks := findPersistentlyBlockedThreads(data)
+
+	daemonCount := 0
+	for _, thread := range dump.Threads {
+		if thread.Daemon {
+			daemonCount++
+		}
+	}
+	blockedCount := 0
+	for _, state := range states {
+		if state.State == "BLOCKED" {
+			blockedCount = state.Count
+		}
+	}
+
+	type pieSlice struct {
+		Name  string `json:"name"`
+		Value int    `json:"value"`
+	}
+	stateData := make([]pieSlice, 0, len(states))
+	for _, state := range states {
+		stateData = append(stateData, pieSlice{Name: state.State, Value: state.Count})
+	}
+	stateJSON, err := json.Marshal(stateData)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal thread states: %w", err)
+	}
+
+	timelineHTML, timelineScript, err := generateThreadStateTimeline(data)
+	if err != nil {
+		return "", err
+	}
+
+	report := fmt.Sprintf(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Thread Dump Report</title>
+    <script src="/static/js/echarts.min.js"></script>
+    <style>
+        body {
+            font-family: 'Segoe UI', Tahoma, Geneva, Verdana, sans-serif;
+            margin: 0;
+            padding: 20px;
+            background-color: #f5f5f5;
+        }
+        .container {
+            max-width: 1400px;
+            margin: 0 auto;
+            background-color: white;
+            border-radius: 8px;
+            box-shadow: 0 2px 10px rgba(0,0,0,0.1);
+            overflow: hidden;
+        }
+        .header {
+            background: linear-gradient(135deg, #06b6d4 0%%, #0891b2 100%%);
+            color: white;
+            padding: 30px;
+            text-align: center;
+        }
+        .header h1 {
+            margin: 0 0 10px 0;
+            font-size: 2.5em;
+            font-weight: 300;
+        }
+        .header p {
+            margin: 0;
+            font-size: 1.1em;
+            opacity: 0.9;
+        }
+        .stats-grid {
+            display: grid;
+            grid-template-columns: repeat(auto-fit, minmax(200px, 1fr));
+            gap: 20px;
+            padding: 30px;
+            background-color: #f8f9fa;
+        }
+        .stat-card {
+            background: white;
+            padding: 20px;
+            border-radius: 8px;
+            text-align: center;
+            box-shadow: 0 2px 4px rgba(0,0,0,0.1);
+        }
+        .stat-value {
+            font-size: 2em;
+            font-weight: bold;
+            color: #06b6d4;
+            margin-bottom: 5px;
+        }
+        .stat-label {
+            color: #666;
+            font-size: 0.9em;
+        }
+        .chart-container {
+            padding: 30px;
+            border-bottom: 1px solid #eee;
+        }
+        .chart-container:last-child {
+            border-bottom: none;
+        }
+        .chart-title {
+            font-size: 1.5em;
+            margin-bottom: 20px;
+            color: #333;
+            text-align: center;
+        }
+        .chart {
+            width: 100%%;
+            height: 400px;
+        }
+        .summary-table {
+            width: 100%%;
+            border-collapse: collapse;
+            font-size: 0.9em;
+        }
+        .summary-table th,
+        .summary-table td {
+            padding: 8px 12px;
+            border-bottom: 1px solid #eee;
+            text-align: left;
+            vertical-align: top;
+        }
+        .summary-table thead th {
+            background-color: #f8f9fa;
+            color: #333;
+        }
+        .summary-table .count {
+            text-align: right;
+            font-weight: bold;
+        }
+        .stack {
+            white-space: pre-wrap;
+            word-break: break-all;
+            font-family: 'Roboto Mono', monospace;
+            font-size: 0.85em;
+            margin: 0;
+        }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>Thread Dump Report</h1>
+            <p>%s</p>
+        </div>
+
+        <div class="stats-grid">
+            <div class="stat-card">
+                <div class="stat-value">%d</div>
+                <div class="stat-label">Snapshots</div>
+            </div>
+            <div class="stat-card">
+                <div class="stat-value">%d</div>
+                <div class="stat-label">Threads</div>
+            </div>
+            <div class="stat-card">
+                <div class="stat-value">%d</div>
+                <div class="stat-label">Daemon Threads</div>
+            </div>
+            <div class="stat-card">
+                <div class="stat-value">%d</div>
+                <div class="stat-label">Blocked Threads</div>
+            </div>
+            <div class="stat-card">
+                <div class="stat-value">%d</div>
+                <div class="stat-label">Distinct Stacks</div>
+            </div>
+            <div class="stat-card">
+                <div class="stat-value">%d</div>
+                <div class="stat-label">Persistently Blocked</div>
+            </div>
+        </div>
+%s
+        <div class="chart-container">
+            <div class="chart-title">Threads Blocked Across Consecutive Snapshots</div>
+            %s
+        </div>
+
+        <div class="chart-container">
+            <div class="chart-title">Threads by State (Latest Snapshot)</div>
+            <div id="threadStateChart" class="chart"></div>
+        </div>
+
+        <div class="chart-container">
+            <div class="chart-title">Lock Contention</div>
+            %s
+        </div>
+
+        <div class="chart-container">
+            <div class="chart-title">Top Methods</div>
+            %s
+        </div>
+
+        <div class="chart-container">
+            <div class="chart-title">Threads Grouped by Stack</div>
+            %s
+        </div>
+    </div>
+
+    <script>
+        try {
+%s
+            const threadStateChart = echarts.init(document.getElementById('threadStateChart'));
+            threadStateChart.setOption({
+                tooltip: { trigger: 'item', formatter: '{b}: {c} ({d}%%)' },
+                legend: { orient: 'vertical', left: 'left' },
+                series: [
+                    {
+                        name: 'Thread State',
+                        type: 'pie',
+                        radius: '60%%',
+                        data: %s
+                    }
+                ]
+            });
+
+            // Handle window resize
+            window.addEventListener('resize', function() {
+                threadStateChart.resize();
+                if (typeof threadStateTimelineChart !== 'undefined') {
+                    threadStateTimelineChart.resize();
+                }
+            });
+
+        } catch (error) {
+            console.error('Error initializing charts:', error);
+            document.body.innerHTML += '<div style="color: red; padding: 20px; background: #ffe6e6; border: 1px solid red; margin: 20px;">Error initializing charts: ' + error.message + '</div>';
+        }
+    </script>
+</body>
+</html>`,
+		html.EscapeString(valueOrNA(dump.Header)),
+		len(data.Snapshots),
+		len(dump.Threads),
+		daemonCount,
+		blockedCount,
+		len(groups),
+		len(streaks),
+		timelineHTML,
+		generateBlockedStreaksHTML(streaks),
+		generateThreadLocksHTML(locks),
+		generateThreadFramesHTML(frames),
+		generateThreadGroupsHTML(groups),
+		timelineScript,
+		stateJSON)
+
+	return report, nil
+}
+
+// generateEmptyThreadDumpHTML returns HTML for when no threads were found
+func generateEmptyThreadDumpHTML() string {
+	return `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Thread Dump Report</title>
+</head>
+<body>
+    <h1>Thread Dump Report</h1>
+    <p>No data available for analysis.</p>
+</body>
+</html>`
+}
+
+// latestThreadDump returns the last snapshot in the file, or nil when there are none
+func latestThreadDump(data *ThreadDumpReportData) *ThreadDump {
+	if data == nil || len(data.Snapshots) == 0 {
+		return nil
+	}
+	return &data.Snapshots[len(data.Snapshots)-1]
+}
+
+// threadDumpSnapshotLabel labels a snapshot by its time, or by its position when it has none
+func threadDumpSnapshotLabel(index int, snapshot ThreadDump) string {
+	if snapshot.Timestamp.IsZero() {
+		return fmt.Sprintf("#%d", index+1)
+	}
+	return snapshot.Timestamp.Format("15:04:05")
+}
+
+// extractThreadStateSeries counts threads per state for every snapshot, states ordered by name
+func extractThreadStateSeries(data *ThreadDumpReportData) []ThreadStateSeries {
+	stateSet := map[string]bool{}
+	for _, snapshot := range data.Snapshots {
+		for _, thread := range snapshot.Threads {
+			stateSet[thread.State] = true
+		}
+	}
+	names := make([]string, 0, len(stateSet))
+	for state := range stateSet {
+		names = append(names, state)
+	}
+	sort.Strings(names)
+
+	series := make([]ThreadStateSeries, 0, len(names))
+	for _, state := range names {
+		counts := make([]int, len(data.Snapshots))
+		for i, snapshot := range data.Snapshots {
+			for _, thread := range snapshot.Threads {
+				if thread.State == state {
+					counts[i]++
+				}
+			}
+		}
+		series = append(series, ThreadStateSeries{State: state, Counts: counts})
+	}
+	return series
+}
+
+// generateThreadStateTimeline returns the container and script for the thread state timeline,
+// or empty strings when there is only a single snapshot to show
+func generateThreadStateTimeline(data *ThreadDumpReportData) (string, string, error) {
+	if len(data.Snapshots) < 2 {
+		return "", "", nil
+	}
+
+	labels := make([]string, 0, len(data.Snapshots))
+	for i, snapshot := range data.Snapshots {
+		labels = append(labels, threadDumpSnapshotLabel(i, snapshot))
+	}
+
+	type lineSeries struct {
+		Name   string `json:"name"`
+		Type   string `json:"type"`
+		Stack  string `json:"stack"`
+		Smooth bool   `json:"smooth"`
+		Data   []int  `json:"data"`
+	}
+	var legend []string
+	var series []lineSeries
+	for _, state := range extractThreadStateSeries(data) {
+		legend = append(legend, state.State)
+		series = append(series, lineSeries{Name: state.State, Type: "line", Stack: "threads", Smooth: true, Data: state.Counts})
+	}
+
+	labelsJSON, err := json.Marshal(labels)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to marshal snapshot labels: %w", err)
+	}
+	legendJSON, err := json.Marshal(legend)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to marshal thread state legend: %w", err)
+	}
+	seriesJSON, err := json.Marshal(series)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to marshal thread state series: %w", err)
+	}
+
+	container := `
+        <div class="chart-container">
+            <div class="chart-title">Thread States Over Time</div>
+            <div id="threadStateTimelineChart" class="chart"></div>
+        </div>
+`
+	script := fmt.Sprintf(`            // Thread States Over Time Chart
+            const threadStateTimelineChart = echarts.init(document.getElementById('threadStateTimelineChart'));
+            threadStateTimelineChart.setOption({
+                title: { text: 'Thread States Over Time' },
+                tooltip: { trigger: 'axis' },
+                legend: { data: %s },
+                toolbox: {
+                    show: true,
+                    feature: {
+                        saveAsImage: {
+                            show: true,
+                            title: 'Save as Image',
+                            type: 'png',
+                            name: 'thread_states_over_time'
+                        },
+                        dataView: {
+                            show: true,
+                            title: 'Data View',
+                            readOnly: false
+                        },
+                        dataZoom: {
+                            show: true,
+                            title: { zoom: 'Zoom', back: 'Reset Zoom' }
+                        },
+                        restore: {
+                            show: true,
+                            title: 'Restore'
+                        },
+                        magicType: {
+                            show: true,
+                            type: ['line', 'bar', 'stack'],
+                            title: { line: 'Line Chart', bar: 'Bar Chart', stack: 'Stacked' }
+                        }
+                    }
+                },
+                dataZoom: [
+                    {
+                        type: 'slider',
+                        show: true,
+                        xAxisIndex: [0],
+                        start: 0,
+                        end: 100
+                    },
+                    {
+                        type: 'inside',
+                        xAxisIndex: [0],
+                        start: 0,
+                        end: 100
+                    }
+                ],
+                xAxis: { type: 'category', data: %s },
+                yAxis: { type: 'value', name: 'Threads', min: 0 },
+                series: %s
+            });
+`, legendJSON, labelsJSON, seriesJSON)
+
+	return container, script, nil
+}
+
+// findPersistentlyBlockedThreads returns threads that were BLOCKED in at least two consecutive
+// snapshots, longest runs first. Threads are matched across snapshots by name and native id.
+func findPersistentlyBlockedThreads(data *ThreadDumpReportData) []ThreadBlockedStreak {
+	streaks := make([]ThreadBlockedStreak, 0)
+	if data == nil || len(data.Snapshots) < 2 {
+		return streaks
+	}
+
+	current := map[string]*ThreadBlockedStreak{}
+	longest := map[string]ThreadBlockedStreak{}
+	var order []string
+
+	for i, snapshot := range data.Snapshots {
+		blocked := map[string]bool{}
+		for _, thread := range snapshot.Threads {
+			if thread.State != "BLOCKED" {
+				continue
+			}
+			key := thread.Name + "|" + thread.NID
+			blocked[key] = true
+
+			streak, ok := current[key]
+			if !ok {
+				streak = &ThreadBlockedStreak{
+					Name:          thread.Name,
+					NID:           thread.NID,
+					FirstSnapshot: i,
+					Since:         snapshot.Timestamp,
+				}
+				current[key] = streak
+			}
+			streak.Snapshots++
+			streak.LastSnapshot = i
+			streak.Until = snapshot.Timestamp
+			streak.WaitingOn = thread.WaitingOn
+			streak.LockClass = thread.LockClass
+
+			if best, seen := longest[key]; !seen || streak.Snapshots > best.Snapshots {
+				if !seen {
+					order = append(order, key)
+				}
+				longest[key] = *streak
+			}
+		}
+
+		// A thread that is no longer blocked ends its run
+		for key := range current {
+			if !blocked[key] {
+				delete(current, key)
+			}
+		}
+	}
+
+	for _, key := range order {
+		if longest[key].Snapshots >= 2 {
+			streaks = append(streaks, longest[key])
+		}
+	}
+	sort.SliceStable(streaks, func(i, j int) bool {
+		return streaks[i].Snapshots > streaks[j].Snapshots
+	})
+	return streaks
+}
+
+// generateBlockedStreaksHTML renders the persistently blocked threads table
+func generateBlockedStreaksHTML(streaks []ThreadBlockedStreak) string {
+	if len(streaks) == 0 {
+		return `<p>No threads stayed BLOCKED across consecutive snapshots.</p>`
+	}
+
+	var rows []string
+	for _, streak := range streaks[:min(maxThreadGroupRows, len(streaks))] {
+		lock := "N/A"
+		if streak.WaitingOn != "" {
+			lock = fmt.Sprintf("<%s> (a %s)", streak.WaitingOn, streak.LockClass)
+		}
+		rows = append(rows, fmt.Sprintf(`                <tr>
+                    <td>%s</td>
+                    <td class="count">%d</td>
+                    <td>#%d &ndash; #%d</td>
+                    <td>%s</td>
+                </tr>`,
+			html.EscapeString(streak.Name),
+			streak.Snapshots,
+			streak.FirstSnapshot+1,
+			streak.LastSnapshot+1,
+			html.EscapeString(lock)))
+	}
+
+	return fmt.Sprintf(`<table class="summary-table">
+            <thead>
+                <tr>
+                    <th>Thread</th>
+                    <th>Snapshots</th>
+                    <th>Range</th>
+                    <th>Waiting To Lock</th>
+                </tr>
+            </thead>
+            <tbody>
+%s
+            </tbody>
+        </table>`, strings.Join(rows, "\n"))
+}
+
+// summarizeThreadStates counts threads per state, most common first
+func summarizeThreadStates(dump *ThreadDump) []ThreadStateCount {
+	counts := map[string]int{}
+	for _, thread := range dump.Threads {
+		counts[thread.State]++
+	}
+
+	states := make([]ThreadStateCount, 0, len(counts))
+	for state, count := range counts {
+		states = append(states, ThreadStateCount{State: state, Count: count})
+	}
+	sort.Slice(states, func(i, j int) bool {
+		if states[i].Count != states[j].Count {
+			return states[i].Count > states[j].Count
+		}
+		return states[i].State < states[j].State
+	})
+	return states
+}
+
+// groupThreadsByStack groups threads sharing the same top frames, largest groups first.
+// Threads without any Java frames (VM and GC threads) are left out.
+func groupThreadsByStack(dump *ThreadDump) []ThreadStackGroup {
+	bySignature := map[string]*ThreadStackGroup{}
+	var order []string
+	for _, thread := range dump.Threads {
+		if len(thread.Frames) == 0 {
+			continue
+		}
+		frames := thread.Frames[:min(threadStackSignatureDepth, len(thread.Frames))]
+		signature := strings.Join(frames, "\n")
+
+		group, ok := bySignature[signature]
+		if !ok {
+			group = &ThreadStackGroup{Frames: frames, States: []string{}, ThreadNames: []string{}}
+			bySignature[signature] = group
+			order = append(order, signature)
+		}
+		group.Count++
+		group.ThreadNames = append(group.ThreadNames, thread.Name)
+		if !containsString(group.States, thread.State) {
+			group.States = append(group.States, thread.State)
+		}
+	}
+
+	groups := make([]ThreadStackGroup, 0, len(order))
+	for _, signature := range order {
+		groups = append(groups, *bySignature[signature])
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Count > groups[j].Count
+	})
+	return groups
+}
+
+// summarizeTopFrames counts threads by the method at the top of their stack, most common first
+func summarizeTopFrames(dump *ThreadDump) []ThreadFrameCount {
+	counts := map[string]int{}
+	for _, thread := range dump.Threads {
+		if len(thread.Frames) > 0 {
+			counts[thread.Frames[0]]++
+		}
+	}
+
+	frames := make([]ThreadFrameCount, 0, len(counts))
+	for method, count := range counts {
+		frames = append(frames, ThreadFrameCount{Method: method, Count: count})
+	}
+	sort.Slice(frames, func(i, j int) bool {
+		if frames[i].Count != frames[j].Count {
+			return frames[i].Count > frames[j].Count
+		}
+		return frames[i].Method < frames[j].Method
+	})
+	return frames
+}
+
+// summarizeThreadLocks lists every lock that threads are waiting on along with its owner,
+// ordered by the number of blocked threads
+func summarizeThreadLocks(dump *ThreadDump) []ThreadLockSummary {
+	owners := map[string]string{}
+	for _, thread := range dump.Threads {
+		for _, address := range thread.LocksHeld {
+			owners[address] = thread.Name
+		}
+	}
+
+	byAddress := map[string]*ThreadLockSummary{}
+	for _, thread := range dump.Threads {
+		if thread.WaitingOn == "" {
+			continue
+		}
+		lock, ok := byAddress[thread.WaitingOn]
+		if !ok {
+			lock = &ThreadLockSummary{
+				Address: thread.WaitingOn,
+				Class:   thread.LockClass,
+				Owner:   owners[thread.WaitingOn],
+			}
+			byAddress[thread.WaitingOn] = lock
+		}
+		lock.Waiting++
+		if thread.State == "BLOCKED" {
+			lock.Blocked++
+		}
+	}
+
+	locks := make([]ThreadLockSummary, 0, len(byAddress))
+	for _, lock := range byAddress {
+		locks = append(locks, *lock)
+	}
+	sort.Slice(locks, func(i, j int) bool {
+		if locks[i].Blocked != locks[j].Blocked {
+			return locks[i].Blocked > locks[j].Blocked
+		}
+		if locks[i].Waiting != locks[j].Waiting {
+			return locks[i].Waiting > locks[j].Waiting
+		}
+		return locks[i].Address < locks[j].Address
+	})
+	return locks
+}
+
+// generateThreadLocksHTML renders the lock contention table
+func generateThreadLocksHTML(locks []ThreadLockSummary) string {
+	if len(locks) == 0 {
+		return `<p>No threads are waiting on locks.</p>`
+	}
+
+	var rows []string
+	for _, lock := range locks[:min(maxThreadGroupRows, len(locks))] {
+		rows = append(rows, fmt.Sprintf(`                <tr>
+                    <td class="count">%d</td>
+                    <td class="count">%d</td>
+                    <td>&lt;%s&gt; (a %s)</td>
+                    <td>%s</td>
+                </tr>`,
+			lock.Blocked,
+			lock.Waiting,
+			html.EscapeString(lock.Address),
+			html.EscapeString(lock.Class),
+			html.EscapeString(valueOrNA(lock.Owner))))
+	}
+
+	return fmt.Sprintf(`<table class="summary-table">
+            <thead>
+                <tr>
+                    <th>Blocked</th>
+                    <th>Waiting</th>
+                    <th>Lock</th>
+                    <th>Held By</th>
+                </tr>
+            </thead>
+            <tbody>
+%s
+            </tbody>
+        </table>`, strings.Join(rows, "\n"))
+}
+
+// generateThreadFramesHTML renders the top methods table
+func generateThreadFramesHTML(frames []ThreadFrameCount) string {
+	if len(frames) == 0 {
+		return `<p>No stack frames found.</p>`
+	}
+
+	var rows []string
+	for _, frame := range frames[:min(maxThreadGroupRows, len(frames))] {
+		rows = append(rows, fmt.Sprintf(`                <tr>
+                    <td class="count">%d</td>
+                    <td><pre class="stack">%s</pre></td>
+                </tr>`, frame.Count, html.EscapeString(frame.Method)))
+	}
+
+	return fmt.Sprintf(`<table class="summary-table">
+            <thead>
+                <tr>
+                    <th>Threads</th>
+                    <th>Top Frame</th>
+                </tr>
+            </thead>
+            <tbody>
+%s
+            </tbody>
+        </table>`, strings.Join(rows, "\n"))
+}
+
+// generateThreadGroupsHTML renders the stack signature groups table
+func generateThreadGroupsHTML(groups []ThreadStackGroup) string {
+	if len(groups) == 0 {
+		return `<p>No stack frames found.</p>`
+	}
+
+	var rows []string
+	for _, group := range groups[:min(maxThreadGroupRows, len(groups))] {
+		names := group.ThreadNames
+		more := ""
+		if len(names) > 5 {
+			more = fmt.Sprintf(" and %d more", len(names)-5)
+			names = names[:5]
+		}
+		rows = append(rows, fmt.Sprintf(`                <tr>
+                    <td class="count">%d</td>
+                    <td>%s</td>
+                    <td><pre class="stack">%s</pre><div>%s%s</div></td>
+                </tr>`,
+			group.Count,
+			html.EscapeString(strings.Join(group.States, ", ")),
+			html.EscapeString(strings.Join(group.Frames, "\n")),
+			html.EscapeString(strings.Join(names, ", ")),
+			more))
+	}
+
+	return fmt.Sprintf(`<table class="summary-table">
+            <thead>
+                <tr>
+                    <th>Threads</th>
+                    <th>States</th>
+                    <th>Stack</th>
+                </tr>
+            </thead>
+            <tbody>
+%s
+            </tbody>
+        </table>`, strings.Join(rows, "\n"))
+}
+
+// containsString reports whether values contains value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporters
+
+import (
+	"testing"
+
+	"github.com/rsvihladremio/ddd/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestThreadDumpSummaries(t *testing.T) {
+	data, err := ParseThreadDump(testutil.SampleFiles["thread_dump"].Content)
+	require.NoError(t, err)
+	dump := latestThreadDump(data)
+	require.NotNil(t, dump)
+
+	t.Run("States", func(t *testing.T) {
+		states := summarizeThreadStates(dump)
+		require.Len(t, states, 4)
+		assert.Equal(t, ThreadStateCount{State: "BLOCKED", Count: 2}, states[0])
+	})
+
+	t.Run("Stack groups", func(t *testing.T) {
+		groups := groupThreadsByStack(dump)
+		// The VM thread has no frames and is left out
+		require.Len(t, groups, 3)
+		assert.Equal(t, 2, groups[0].Count)
+		assert.Equal(t, []string{"worker-2", "worker-3"}, groups[0].ThreadNames)
+		assert.Equal(t, []string{"BLOCKED"}, groups[0].States)
+	})
+
+	t.Run("Top frames", func(t *testing.T) {
+		frames := summarizeTopFrames(dump)
+		require.NotEmpty(t, frames)
+		assert.Equal(t, ThreadFrameCount{Method: "com.example.Cache.load(Cache.java:40)", Count: 2}, frames[0])
+	})
+
+	t.Run("Locks", func(t *testing.T) {
+		locks := summarizeThreadLocks(dump)
+		require.Len(t, locks, 1)
+		assert.Equal(t, ThreadLockSummary{
+			Address: "0x000000008c0a1a30",
+			Class:   "java.lang.Object",
+			Owner:   "worker-1",
+			Blocked: 2,
+			Waiting: 2,
+		}, locks[0])
+	})
+}
+
+func TestGenerateThreadDumpHTML(t *testing.T) {
+	t.Run("Thread dump", func(t *testing.T) {
+		data, err := ParseThreadDump(testutil.SampleFiles["thread_dump"].Content)
+		require.NoError(t, err)
+
+		html, err := GenerateThreadDumpHTML(data)
+		require.NoError(t, err)
+
+		assert.Contains(t, html, "<title>Thread Dump Report</title>")
+		assert.Contains(t, html, "threadStateChart")
+		assert.Contains(t, html, `{"name":"BLOCKED","value":2}`)
+		assert.Contains(t, html, "&lt;0x000000008c0a1a30&gt; (a java.lang.Object)")
+		assert.Contains(t, html, "worker-2, worker-3")
+		// A single snapshot has no timeline
+		assert.NotContains(t, html, `id="threadStateTimelineChart"`)
+		assert.Contains(t, html, "No threads stayed BLOCKED across consecutive snapshots.")
+	})
+
+	t.Run("Multiple snapshots", func(t *testing.T) {
+		content := threadDumpSnapshot("2024-09-04 12:07:20", "BLOCKED") +
+			threadDumpSnapshot("2024-09-04 12:07:25", "BLOCKED") +
+			threadDumpSnapshot("2024-09-04 12:07:30", "RUNNABLE")
+		data, err := ParseThreadDump([]byte(content))
+		require.NoError(t, err)
+
+		html, err := GenerateThreadDumpHTML(data)
+		require.NoError(t, err)
+
+		assert.Contains(t, html, `id="threadStateTimelineChart"`)
+		assert.Contains(t, html, `["12:07:20","12:07:25","12:07:30"]`)
+		assert.Contains(t, html, `"name":"BLOCKED","type":"line","stack":"threads","smooth":true,"data":[1,1,0]`)
+		assert.Contains(t, html, "<td>#1 &ndash; #2</td>")
+	})
+
+	t.Run("No threads", func(t *testing.T) {
+		html, err := GenerateThreadDumpHTML(&ThreadDumpReportData{})
+		require.NoError(t, err)
+		assert.Contains(t, html, "No data available for analysis.")
+	})
+}
+
+func TestFindPersistentlyBlockedThreads(t *testing.T) {
+	t.Run("Consecutive blocked snapshots are flagged", func(t *testing.T) {
+		content := threadDumpSnapshot("2024-09-04 12:07:20", "BLOCKED") +
+			threadDumpSnapshot("2024-09-04 12:07:25", "RUNNABLE") +
+			threadDumpSnapshot("2024-09-04 12:07:30", "BLOCKED") +
+			threadDumpSnapshot("2024-09-04 12:07:35", "BLOCKED") +
+			threadDumpSnapshot("2024-09-04 12:07:40", "BLOCKED")
+		data, err := ParseThreadDump([]byte(content))
+		require.NoError(t, err)
+
+		streaks := findPersistentlyBlockedThreads(data)
+		require.Len(t, streaks, 1)
+		streak := streaks[0]
+		assert.Equal(t, "worker-2", streak.Name)
+		assert.Equal(t, "0x21", streak.NID)
+		assert.Equal(t, 3, streak.Snapshots)
+		assert.Equal(t, 2, streak.FirstSnapshot)
+		assert.Equal(t, 4, streak.LastSnapshot)
+		assert.Equal(t, "12:07:30", streak.Since.Format("15:04:05"))
+		assert.Equal(t, "12:07:40", streak.Until.Format("15:04:05"))
+		assert.Equal(t, "0x000000008c0a1a30", streak.WaitingOn)
+	})
+
+	t.Run("Isolated blocked snapshots are not flagged", func(t *testing.T) {
+		content := threadDumpSnapshot("2024-09-04 12:07:20", "BLOCKED") +
+			threadDumpSnapshot("2024-09-04 12:07:25", "RUNNABLE") +
+			threadDumpSnapshot("2024-09-04 12:07:30", "BLOCKED")
+		data, err := ParseThreadDump([]byte(content))
+		require.NoError(t, err)
+
+		assert.Empty(t, findPersistentlyBlockedThreads(data))
+	})
+
+	t.Run("Single snapshot", func(t *testing.T) {
+		data, err := ParseThreadDump(testutil.SampleFiles["thread_dump"].Content)
+		require.NoError(t, err)
+		assert.Empty(t, findPersistentlyBlockedThreads(data))
+	})
+}
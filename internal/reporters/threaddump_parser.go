@@ -0,0 +1,174 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporters
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ThreadDumpThread represents a single thread from a JVM thread dump
+type ThreadDumpThread struct {
+	Name       string   `json:"name"`        // Thread name from the quoted header
+	NID        string   `json:"nid"`         // Native thread id, stable across snapshots
+	Daemon     bool     `json:"daemon"`      // Whether the thread is a daemon thread
+	State      string   `json:"state"`       // java.lang.Thread.State, or UNKNOWN for VM threads
+	Frames     []string `json:"frames"`      // Stack frames without the leading "at "
+	WaitingOn  string   `json:"waiting_on"`  // Lock address the thread is blocked or parked on
+	LockClass  string   `json:"lock_class"`  // Class of the lock the thread is waiting on
+	LocksHeld  []string `json:"locks_held"`  // Lock addresses held by the thread
+	HeaderLine string   `json:"header_line"` // Raw thread header line
+}
+
+// ThreadDump represents a single parsed JVM thread dump
+type ThreadDump struct {
+	Timestamp time.Time          `json:"timestamp"` // When the dump was taken, zero if no timestamp line was found
+	Header    string             `json:"header"`    // The "Full thread dump ..." line
+	Threads   []ThreadDumpThread `json:"threads"`   // Threads in the order they appear
+}
+
+// ThreadDumpReportData represents every thread dump found in a file, in time order
+type ThreadDumpReportData struct {
+	Snapshots []ThreadDump `json:"snapshots"` // One entry per "Full thread dump" header
+}
+
+var (
+	threadHeaderRegex = regexp.MustCompile(`^"(.*)"(.*)$`)
+	threadNIDRegex    = regexp.MustCompile(`\bnid=(\S+)`)
+	lockRefRegex      = regexp.MustCompile(`<(0x[0-9a-fA-F]+)>\s*\(a ([^)]+)\)`)
+)
+
+// threadDumpTimestampLayout is the timestamp jstack and kill -3 print before each dump
+const threadDumpTimestampLayout = "2006-01-02 15:04:05"
+
+// ParseThreadDump parses jstack or kill -3 output into threads with their states,
+// stack frames and lock information. Files containing several appended dumps are split
+// on the repeated "Full thread dump" headers into one snapshot per dump.
+func ParseThreadDump(content []byte) (*ThreadDumpReportData, error) {
+	return ParseThreadDumpReader(bytes.NewReader(content))
+}
+
+// ParseThreadDumpReader parses thread dumps line by line from r like ParseThreadDump,
+// so files with many appended dumps are never held in memory as raw text
+func ParseThreadDumpReader(r io.Reader) (*ThreadDumpReportData, error) {
+	data := &ThreadDumpReportData{Snapshots: []ThreadDump{}}
+	// Some frames (lambdas, generated classes) produce very long lines
+	scanner := newLineScanner(r)
+
+	var dump *ThreadDump
+	var current *ThreadDumpThread
+	var lastTimestamp time.Time
+	flush := func() {
+		if current != nil {
+			dump.Threads = append(dump.Threads, *current)
+			current = nil
+		}
+	}
+	flushDump := func() {
+		flush()
+		if dump != nil {
+			data.Snapshots = append(data.Snapshots, *dump)
+			dump = nil
+		}
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		if ts, err := time.Parse(threadDumpTimestampLayout, trimmed); err == nil {
+			lastTimestamp = ts
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "Full thread dump") {
+			flushDump()
+			dump = &ThreadDump{Timestamp: lastTimestamp, Header: trimmed, Threads: []ThreadDumpThread{}}
+			lastTimestamp = time.Time{}
+			continue
+		}
+
+		if matches := threadHeaderRegex.FindStringSubmatch(line); matches != nil {
+			flush()
+			if dump == nil {
+				// Dumps without a header still get a snapshot
+				dump = &ThreadDump{Timestamp: lastTimestamp, Threads: []ThreadDumpThread{}}
+			}
+			current = &ThreadDumpThread{
+				Name:       matches[1],
+				Daemon:     strings.Contains(matches[2], " daemon"),
+				State:      "UNKNOWN",
+				Frames:     []string{},
+				LocksHeld:  []string{},
+				HeaderLine: line,
+			}
+			if nid := threadNIDRegex.FindStringSubmatch(matches[2]); nid != nil {
+				current.NID = nid[1]
+			}
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+
+		switch {
+		case trimmed == "":
+			flush()
+		case strings.HasPrefix(trimmed, "java.lang.Thread.State:"):
+			state := strings.TrimSpace(strings.TrimPrefix(trimmed, "java.lang.Thread.State:"))
+			if fields := strings.Fields(state); len(fields) > 0 {
+				current.State = fields[0]
+			}
+		case strings.HasPrefix(trimmed, "at "):
+			current.Frames = append(current.Frames, strings.TrimPrefix(trimmed, "at "))
+		case strings.HasPrefix(trimmed, "- waiting to lock"),
+			strings.HasPrefix(trimmed, "- parking to wait for"),
+			strings.HasPrefix(trimmed, "- waiting on"):
+			if matches := lockRefRegex.FindStringSubmatch(trimmed); matches != nil && current.WaitingOn == "" {
+				current.WaitingOn = matches[1]
+				current.LockClass = matches[2]
+			}
+		case strings.HasPrefix(trimmed, "- locked"):
+			if matches := lockRefRegex.FindStringSubmatch(trimmed); matches != nil {
+				current.LocksHeld = append(current.LocksHeld, matches[1])
+			}
+		}
+	}
+	flushDump()
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// Order snapshots by time when every dump carries a timestamp
+	timestamped := true
+	for _, snapshot := range data.Snapshots {
+		if snapshot.Timestamp.IsZero() {
+			timestamped = false
+			break
+		}
+	}
+	if timestamped {
+		sort.SliceStable(data.Snapshots, func(i, j int) bool {
+			return data.Snapshots[i].Timestamp.Before(data.Snapshots[j].Timestamp)
+		})
+	}
+	return data, nil
+}
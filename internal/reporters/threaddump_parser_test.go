@@ -0,0 +1,125 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporters
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rsvihladremio/ddd/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseThreadDump(t *testing.T) {
+	t.Run("jstack output", func(t *testing.T) {
+		data, err := ParseThreadDump(testutil.SampleFiles["thread_dump"].Content)
+		require.NoError(t, err)
+		require.Len(t, data.Snapshots, 1)
+		dump := data.Snapshots[0]
+
+		assert.Equal(t, time.Date(2024, 9, 4, 12, 7, 20, 0, time.UTC), dump.Timestamp)
+		assert.Equal(t, "Full thread dump OpenJDK 64-Bit Server VM (17.0.9+9 mixed mode, sharing):", dump.Header)
+		require.Len(t, dump.Threads, 5)
+
+		main := dump.Threads[0]
+		assert.Equal(t, "main", main.Name)
+		assert.Equal(t, "0x1", main.NID)
+		assert.False(t, main.Daemon)
+		assert.Equal(t, "TIMED_WAITING", main.State)
+		assert.Equal(t, []string{
+			"java.lang.Thread.sleep(java.base@17.0.9/Native Method)",
+			"com.example.Main.main(Main.java:10)",
+		}, main.Frames)
+
+		owner := dump.Threads[1]
+		assert.Equal(t, "worker-1", owner.Name)
+		assert.True(t, owner.Daemon)
+		assert.Equal(t, "RUNNABLE", owner.State)
+		assert.Equal(t, []string{"0x000000008c0a1a30"}, owner.LocksHeld)
+
+		blocked := dump.Threads[2]
+		assert.Equal(t, "BLOCKED", blocked.State)
+		assert.Equal(t, "0x000000008c0a1a30", blocked.WaitingOn)
+		assert.Equal(t, "java.lang.Object", blocked.LockClass)
+
+		vmThread := dump.Threads[4]
+		assert.Equal(t, "VM Thread", vmThread.Name)
+		assert.Equal(t, "UNKNOWN", vmThread.State)
+		assert.Empty(t, vmThread.Frames)
+	})
+
+	t.Run("Parked thread", func(t *testing.T) {
+		content := `"pool-1-thread-1" #30 prio=5 os_prio=0 tid=0x1 nid=0x2 waiting on condition  [0x3]
+   java.lang.Thread.State: WAITING (parking)
+	at jdk.internal.misc.Unsafe.park(java.base@17.0.9/Native Method)
+	- parking to wait for  <0x00000000c1b2a3d0> (a java.util.concurrent.locks.AbstractQueuedSynchronizer$ConditionObject)
+	at java.util.concurrent.locks.LockSupport.park(java.base@17.0.9/LockSupport.java:341)
+`
+		data, err := ParseThreadDump([]byte(content))
+		require.NoError(t, err)
+		// Threads without a "Full thread dump" header still form a snapshot
+		require.Len(t, data.Snapshots, 1)
+		dump := data.Snapshots[0]
+		assert.True(t, dump.Timestamp.IsZero())
+		require.Len(t, dump.Threads, 1)
+		assert.Equal(t, "WAITING", dump.Threads[0].State)
+		assert.Equal(t, "0x00000000c1b2a3d0", dump.Threads[0].WaitingOn)
+		assert.Equal(t, "java.util.concurrent.locks.AbstractQueuedSynchronizer$ConditionObject", dump.Threads[0].LockClass)
+		assert.Len(t, dump.Threads[0].Frames, 2)
+	})
+
+	t.Run("Multiple appended dumps", func(t *testing.T) {
+		content := threadDumpSnapshot("2024-09-04 12:07:25", "BLOCKED") +
+			threadDumpSnapshot("2024-09-04 12:07:20", "RUNNABLE") +
+			threadDumpSnapshot("2024-09-04 12:07:30", "BLOCKED")
+
+		data, err := ParseThreadDump([]byte(content))
+		require.NoError(t, err)
+		require.Len(t, data.Snapshots, 3)
+
+		// Snapshots are ordered by timestamp
+		assert.Equal(t, "12:07:20", data.Snapshots[0].Timestamp.Format("15:04:05"))
+		assert.Equal(t, "12:07:25", data.Snapshots[1].Timestamp.Format("15:04:05"))
+		assert.Equal(t, "12:07:30", data.Snapshots[2].Timestamp.Format("15:04:05"))
+		assert.Equal(t, "RUNNABLE", data.Snapshots[0].Threads[0].State)
+		for _, snapshot := range data.Snapshots {
+			assert.Len(t, snapshot.Threads, 2)
+		}
+	})
+
+	t.Run("Empty content", func(t *testing.T) {
+		data, err := ParseThreadDump([]byte(""))
+		require.NoError(t, err)
+		assert.Empty(t, data.Snapshots)
+	})
+}
+
+// threadDumpSnapshot builds a small dump where "worker-2" is in the given state
+func threadDumpSnapshot(timestamp, workerState string) string {
+	return timestamp + `
+Full thread dump OpenJDK 64-Bit Server VM (17.0.9+9 mixed mode, sharing):
+
+"worker-2" #21 daemon prio=5 os_prio=0 tid=0x00007f1c00101000 nid=0x21 waiting for monitor entry  [0x00007f1bd0900000]
+   java.lang.Thread.State: ` + workerState + `
+	at com.example.Cache.load(Cache.java:40)
+	- waiting to lock <0x000000008c0a1a30> (a java.lang.Object)
+
+"main" #1 prio=5 os_prio=0 tid=0x00007f1c00028000 nid=0x1 waiting on condition  [0x00007f1c05f1e000]
+   java.lang.Thread.State: TIMED_WAITING (sleeping)
+	at java.lang.Thread.sleep(java.base@17.0.9/Native Method)
+
+`
+}
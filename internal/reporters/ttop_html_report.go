@@ -15,113 +15,132 @@
 package reporters
 
 import (
+	"encoding/json"
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 )
 
+// Number of threads the ttop CPU chart shows. Each is a series over every snapshot, so the
+// maximum keeps the chart readable and the report a reasonable size
+const (
+	DefaultTTopTopThreads = 5
+	MaxTTopTopThreads     = 50
+)
+
+// ClampTTopTopThreads returns topThreads limited to MaxTTopTopThreads, or
+// DefaultTTopTopThreads when it isn't positive
+func ClampTTopTopThreads(topThreads int) int {
+	switch {
+	case topThreads <= 0:
+		return DefaultTTopTopThreads
+	case topThreads > MaxTTopTopThreads:
+		return MaxTTopTopThreads
+	}
+	return topThreads
+}
+
 // GenerateTTopHTML generates a self-contained HTML report with three charts:
 // 1. Threads by Name/ID CPU Usage Over Time
 // 2. System Memory Usage Over Time (using global memory data from ttop header)
 // 3. Thread States Over Time (using global thread counts from ttop header)
 func GenerateTTopHTML(data *TTopReportData) (string, error) {
+	return GenerateTTopHTMLWithMaxPoints(data, 0)
+}
+
+// GenerateTTopHTMLWithMaxPoints generates the same report as GenerateTTopHTML with the
+// charts limited to at most maxPoints points, 0 for every snapshot. Adjacent snapshots are
+// averaged into each point; the summary cards are always computed from every snapshot
+func GenerateTTopHTMLWithMaxPoints(data *TTopReportData, maxPoints int) (string, error) {
+	return GenerateTTopHTMLWithTheme(data, maxPoints, DefaultReportTheme)
+}
+
+// GenerateTTopHTMLWithTheme generates the same report as GenerateTTopHTMLWithMaxPoints
+// styled with the named report theme, falling back to the default theme for unknown names
+func GenerateTTopHTMLWithTheme(data *TTopReportData, maxPoints int, themeName string) (string, error) {
+	return GenerateTTopHTMLWithTopThreads(data, maxPoints, themeName, DefaultTTopTopThreads)
+}
+
+// GenerateTTopHTMLWithTopThreads generates the same report as GenerateTTopHTMLWithTheme with
+// the CPU chart showing the topThreads busiest threads, clamped by ClampTTopTopThreads
+func GenerateTTopHTMLWithTopThreads(data *TTopReportData, maxPoints int, themeName string, topThreads int) (string, error) {
 	if data == nil || len(data.Snapshots) == 0 {
 		return generateEmptyHTML(), nil
 	}
 
+	theme := lookupReportTheme(themeName)
+	topThreads = ClampTTopTopThreads(topThreads)
+
 	// Prepare data for charts
-	labels := extractTimeLabels(data)
-	threadByCPUData := extractThreadByCPUSeriesData(data)
-	memoryByTypeData := extractMemoryTypeSeriesData(data)
-	threadsByTypeData := extractThreadTypeSeriesData(data)
+	chartData := downsampleTTopData(data, maxPoints)
+	labels := extractTimeLabels(chartData)
+	chartTimes := make([]time.Time, len(chartData.Snapshots))
+	for i, snapshot := range chartData.Snapshots {
+		chartTimes[i] = snapshot.Timestamp
+	}
+	chartTimesData, err := chartTimesScript(chartTimes)
+	if err != nil {
+		return "", err
+	}
+	threadByCPUData, err := extractThreadByCPUSeriesData(chartData, topThreads)
+	if err != nil {
+		return "", err
+	}
+	threadByCPULegend, err := marshalLegendData(extractThreadByCPULegendData(chartData, topThreads))
+	if err != nil {
+		return "", err
+	}
+	threadByRESLegend, err := marshalLegendData(extractThreadByRESLegendData(chartData))
+	if err != nil {
+		return "", err
+	}
+	memoryByTypeLegend, err := marshalLegendData(extractMemoryTypeLegendData(chartData))
+	if err != nil {
+		return "", err
+	}
+	threadsByTypeLegend, err := marshalLegendData(extractThreadTypeLegendData(chartData))
+	if err != nil {
+		return "", err
+	}
+	threadByRESData, err := extractThreadByRESSeriesData(chartData)
+	if err != nil {
+		return "", err
+	}
+	memoryByTypeData := extractMemoryTypeSeriesData(chartData)
+	threadsByTypeData := extractThreadTypeSeriesData(chartData)
+	nouns := ttopRowNouns(data)
 
 	// Build the complete HTML document
-	html := fmt.Sprintf(`<!DOCTYPE html>
+	html := fmt.Sprintf(nouns.Replace(`<!DOCTYPE html>
 <html lang="en">
 <head>
     <meta charset="UTF-8">
     <meta name="viewport" content="width=device-width, initial-scale=1.0">
-    <title>TTop Analysis Report</title>
+    <title>{Title} Analysis Report</title>
     <script src="/static/js/echarts.min.js"></script>
+    %s
     <style>
-        body {
-            font-family: 'Segoe UI', Tahoma, Geneva, Verdana, sans-serif;
-            margin: 0;
-            padding: 20px;
-            background-color: #f5f5f5;
-        }
-        .container {
-            max-width: 1400px;
-            margin: 0 auto;
-            background-color: white;
-            border-radius: 8px;
-            box-shadow: 0 2px 10px rgba(0,0,0,0.1);
-            overflow: hidden;
-        }
-        .header {
-            background: linear-gradient(135deg, #06b6d4 0%%, #0891b2 100%%);
-            color: white;
-            padding: 30px;
+        .findings-ok {
+            color: var(--report-good);
             text-align: center;
         }
-        .header h1 {
-            margin: 0 0 10px 0;
-            font-size: 2.5em;
-            font-weight: 300;
-        }
-        .header p {
-            margin: 0;
-            font-size: 1.1em;
-            opacity: 0.9;
-        }
-        .stats-grid {
-            display: grid;
-            grid-template-columns: repeat(auto-fit, minmax(200px, 1fr));
-            gap: 20px;
-            padding: 30px;
-            background-color: #f8f9fa;
-        }
-        .stat-card {
-            background: white;
-            padding: 20px;
-            border-radius: 8px;
-            text-align: center;
-            box-shadow: 0 2px 4px rgba(0,0,0,0.1);
-        }
-        .stat-value {
-            font-size: 2em;
+        .findings-warning {
+            color: var(--report-bad);
             font-weight: bold;
-            color: #06b6d4;
-            margin-bottom: 5px;
-        }
-        .stat-label {
-            color: #666;
-            font-size: 0.9em;
-        }
-        .chart-container {
-            padding: 30px;
-            border-bottom: 1px solid #eee;
-        }
-        .chart-container:last-child {
-            border-bottom: none;
-        }
-        .chart-title {
-            font-size: 1.5em;
-            margin-bottom: 20px;
-            color: #333;
             text-align: center;
         }
-        .chart {
-            width: 100%%;
-            height: 400px;
+        .chart-options {
+            text-align: right;
+            font-size: 0.9em;
         }
     </style>
 </head>
 <body>
     <div class="container">
         <div class="header">
-            <h1>TTop Analysis Report</h1>
-            <p>Thread Activity Performance Analysis</p>
+            <h1>{Title} Analysis Report</h1>
+            <p>{Thread} Activity Performance Analysis</p>
         </div>
 
         <div class="stats-grid">
@@ -131,39 +150,65 @@ func GenerateTTopHTML(data *TTopReportData) (string, error) {
             </div>
             <div class="stat-card">
                 <div class="stat-value">%d</div>
-                <div class="stat-label">Unique Threads</div>
+                <div class="stat-label">Unique {Threads}</div>
             </div>
             <div class="stat-card">
                 <div class="stat-value">%d</div>
-                <div class="stat-label">Peak Thread Count</div>
+                <div class="stat-label">Peak {Thread} Count</div>
             </div>
+            <div class="stat-card">
+                <div class="stat-value">%.1f MiB</div>
+                <div class="stat-label">Peak RES</div>
+            </div>
+        </div>
+
+        <div class="chart-container">
+            <div class="chart-title">Findings</div>
+            %s
         </div>
 
         <div class="chart-container">
-            <div class="chart-title">Thread CPU Usage Over Time</div>
+            <div class="chart-title">{Thread} CPU Usage Over Time (Top %d)</div>
+            <div class="chart-options">%s</div>
             <div id="threadByCpuChart" class="chart"></div>
         </div>
 
+        <div class="chart-container">
+            <div class="chart-title">{Thread} Resident Memory Over Time</div>
+            <div id="threadByResChart" class="chart"></div>
+        </div>
+
         <div class="chart-container">
             <div class="chart-title">System Memory Usage Over Time</div>
             <div id="memoryByTypeChart" class="chart"></div>
         </div>
 
         <div class="chart-container">
-            <div class="chart-title">Thread States Over Time</div>
+            <div class="chart-title">{Thread} States Over Time</div>
             <div id="threadsByTypeChart" class="chart"></div>
         </div>
     </div>
 
+    %s
+    %s
     <script>
         console.log('Initializing charts...');
         try {
+                const timeAxisName = '%s';
+
+                // Series names come from process and thread names, so tooltips escape them
+                function escapeHtml(text) {
+                    return String(text).replace(/[&<>"']/g, function (c) {
+                        return { '&': '&amp;', '<': '&lt;', '>': '&gt;', '"': '&quot;', "'": '&#39;' }[c];
+                    });
+                }
+
                 // Thread by CPU Chart
-                const threadByCpuChart = echarts.init(document.getElementById('threadByCpuChart'));
+                const threadByCpuChart = echarts.init(document.getElementById('threadByCpuChart'), 'ddd');
                 const threadByCpuOption = {
-                    title: { text: 'Threads by Name/ID CPU Usage Over Time' },
+                    title: { text: '{Threads} by Name/ID CPU Usage Over Time' },
                     tooltip: { trigger: 'axis' },
-                    legend: { data: [] },
+                    legend: { type: 'scroll', top: 30, data: %s },
                     toolbox: {
                         show: true,
                         feature: {
@@ -208,14 +253,101 @@ func GenerateTTopHTML(data *TTopReportData) (string, error) {
                             end: 100
                         }
                     ],
-                    xAxis: { type: 'category', data: %s },
+                    xAxis: { type: 'category', name: timeAxisName, nameLocation: 'middle', nameGap: 30, data: %s },
                     yAxis: { type: 'value', name: 'CPU Usage (%%)', min: 0 },
                     series: %s
                 };
                 threadByCpuChart.setOption(threadByCpuOption);
 
+                // Per-thread CPU is a percentage of one core, so busy threads on large hosts
+                // exceed 100%%. Normalizing divides by the core count for a 0-100%% machine scale
+                const cpuCores = %d;
+                const rawCpuSeries = threadByCpuOption.series.map(function (series) { return series.data.slice(); });
+                const cpuNormalizeToggle = document.getElementById('cpuNormalizeToggle');
+                if (cpuNormalizeToggle) {
+                    cpuNormalizeToggle.addEventListener('change', function () {
+                        const normalized = cpuNormalizeToggle.checked;
+                        threadByCpuChart.setOption({
+                            yAxis: {
+                                name: normalized ? 'CPU Usage (%% of ' + cpuCores + ' cores)' : 'CPU Usage (%%)',
+                                max: normalized ? 100 : null
+                            },
+                            series: rawCpuSeries.map(function (data) {
+                                return {
+                                    data: normalized ? data.map(function (value) { return +(value / cpuCores).toFixed(2); }) : data
+                                };
+                            })
+                        });
+                    });
+                }
+
+                // Thread by RES Chart
+                const threadByResChart = echarts.init(document.getElementById('threadByResChart'), 'ddd');
+                const threadByResOption = {
+                    title: { text: 'Top Memory Consumers by Resident Memory' },
+                    tooltip: {
+                        trigger: 'axis',
+                        formatter: function (params) {
+                            let result = params[0].name + '<br/>';
+                            params.forEach(function (item) {
+                                result += item.marker + ' ' + escapeHtml(item.seriesName) + ': ' + item.value + ' MiB<br/>';
+                            });
+                            return result;
+                        }
+                    },
+                    legend: { type: 'scroll', top: 30, data: %s },
+                    toolbox: {
+                        show: true,
+                        feature: {
+                            saveAsImage: {
+                                show: true,
+                                title: 'Save as Image',
+                                type: 'png',
+                                name: 'thread_resident_memory'
+                            },
+                            dataView: {
+                                show: true,
+                                title: 'Data View',
+                                readOnly: false
+                            },
+                            dataZoom: {
+                                show: true,
+                                title: { zoom: 'Zoom', back: 'Reset Zoom' }
+                            },
+                            restore: {
+                                show: true,
+                                title: 'Restore'
+                            },
+                            magicType: {
+                                show: true,
+                                type: ['line', 'bar'],
+                                title: { line: 'Line Chart', bar: 'Bar Chart' }
+                            }
+                        }
+                    },
+                    dataZoom: [
+                        {
+                            type: 'slider',
+                            show: true,
+                            xAxisIndex: [0],
+                            start: 0,
+                            end: 100
+                        },
+                        {
+                            type: 'inside',
+                            xAxisIndex: [0],
+                            start: 0,
+                            end: 100
+                        }
+                    ],
+                    xAxis: { type: 'category', name: timeAxisName, nameLocation: 'middle', nameGap: 30, data: %s },
+                    yAxis: { type: 'value', name: 'RES (MiB)', min: 0 },
+                    series: %s
+                };
+                threadByResChart.setOption(threadByResOption);
+
                 // Memory by Type Chart
-                const memoryByTypeChart = echarts.init(document.getElementById('memoryByTypeChart'));
+                const memoryByTypeChart = echarts.init(document.getElementById('memoryByTypeChart'), 'ddd');
                 const memoryByTypeOption = {
                     title: { text: 'System Memory Usage Over Time' },
                     tooltip: {
@@ -223,12 +355,12 @@ func GenerateTTopHTML(data *TTopReportData) (string, error) {
                         formatter: function (params) {
                             let result = params[0].name + '<br/>';
                             params.forEach(function (item) {
-                                result += item.marker + ' ' + item.seriesName + ': ' + item.value + ' MiB<br/>';
+                                result += item.marker + ' ' + escapeHtml(item.seriesName) + ': ' + item.value + ' MiB<br/>';
                             });
                             return result;
                         }
                     },
-                    legend: { data: [] },
+                    legend: { type: 'scroll', top: 30, data: %s },
                     toolbox: {
                         show: true,
                         feature: {
@@ -273,27 +405,27 @@ func GenerateTTopHTML(data *TTopReportData) (string, error) {
                             end: 100
                         }
                     ],
-                    xAxis: { type: 'category', data: %s },
+                    xAxis: { type: 'category', name: timeAxisName, nameLocation: 'middle', nameGap: 30, data: %s },
                     yAxis: { type: 'value', name: 'Memory (MiB)', min: 0 },
                     series: %s
                 };
                 memoryByTypeChart.setOption(memoryByTypeOption);
 
                 // Threads by Type Chart
-                const threadsByTypeChart = echarts.init(document.getElementById('threadsByTypeChart'));
+                const threadsByTypeChart = echarts.init(document.getElementById('threadsByTypeChart'), 'ddd');
                 const threadsByTypeOption = {
-                    title: { text: 'Thread States Over Time' },
+                    title: { text: '{Thread} States Over Time' },
                     tooltip: {
                         trigger: 'axis',
                         formatter: function (params) {
                             let result = params[0].name + '<br/>';
                             params.forEach(function (item) {
-                                result += item.marker + ' ' + item.seriesName + ': ' + item.value + '<br/>';
+                                result += item.marker + ' ' + escapeHtml(item.seriesName) + ': ' + item.value + '<br/>';
                             });
                             return result;
                         }
                     },
-                    legend: { data: [] },
+                    legend: { type: 'scroll', top: 30, data: %s },
                     toolbox: {
                         show: true,
                         feature: {
@@ -338,15 +470,18 @@ func GenerateTTopHTML(data *TTopReportData) (string, error) {
                             end: 100
                         }
                     ],
-                    xAxis: { type: 'category', data: %s },
-                    yAxis: { type: 'value', name: 'Thread Count', min: 0 },
+                    xAxis: { type: 'category', name: timeAxisName, nameLocation: 'middle', nameGap: 30, data: %s },
+                    yAxis: { type: 'value', name: '{Thread} Count', min: 0 },
                     series: %s
                 };
                 threadsByTypeChart.setOption(threadsByTypeOption);
 
+                [threadByCpuChart, threadByResChart, memoryByTypeChart, threadsByTypeChart].forEach(dddAnnotate);
+
                 // Handle window resize
                 window.addEventListener('resize', function() {
                     threadByCpuChart.resize();
+                    threadByResChart.resize();
                     memoryByTypeChart.resize();
                     threadsByTypeChart.resize();
                 });
@@ -357,20 +492,55 @@ func GenerateTTopHTML(data *TTopReportData) (string, error) {
         }
     </script>
 </body>
-</html>`,
+</html>`),
+		theme.headHTML(),
 		len(data.Snapshots),
 		countUniqueThreads(data),
 		findPeakThreadCount(data),
+		findPeakRES(data)/(1024*1024),
+		generateMemoryGrowthHTML(findTTopMemoryGrowth(data)),
+		topThreads,
+		generateCPUScaleOptionHTML(data.CPUCores),
+		chartTimesData,
+		reportAnnotationsScript,
+		timeAxisName(data.Snapshots[0].Timestamp),
+		threadByCPULegend,
 		labels,
 		threadByCPUData,
+		data.CPUCores,
+		threadByRESLegend,
+		labels,
+		threadByRESData,
+		memoryByTypeLegend,
 		labels,
 		memoryByTypeData,
+		threadsByTypeLegend,
 		labels,
 		threadsByTypeData)
 
 	return html, nil
 }
 
+// ttopRowNouns returns a replacer filling the {Title}, {Thread} and {Threads} placeholders
+// of the report template. ttop runs top in thread mode, while a plain top -b capture lists
+// processes, so the labels follow what the rows are
+func ttopRowNouns(data *TTopReportData) *strings.Replacer {
+	if data.Processes {
+		return strings.NewReplacer("{Title}", "Top", "{Thread}", "Process", "{Threads}", "Processes")
+	}
+	return strings.NewReplacer("{Title}", "TTop", "{Thread}", "Thread", "{Threads}", "Threads")
+}
+
+// generateCPUScaleOptionHTML returns the control for switching the CPU chart between raw
+// per-thread percentages and percentages of the whole machine. Without a core count only
+// the raw scale can be shown, so it is explained instead
+func generateCPUScaleOptionHTML(cpuCores int) string {
+	if cpuCores <= 0 {
+		return `<span>CPU core count not found in the capture; 100% is one full core</span>`
+	}
+	return fmt.Sprintf(`<label><input type="checkbox" id="cpuNormalizeToggle"> Show as %% of all %d cores</label>`, cpuCores)
+}
+
 // generateEmptyHTML returns HTML for when no data is available
 func generateEmptyHTML() string {
 	return `<!DOCTYPE html>
@@ -418,14 +588,47 @@ func findPeakThreadCount(data *TTopReportData) int {
 	return peak
 }
 
-// escapeJSONString escapes special characters in strings for JSON output
-func escapeJSONString(s string) string {
-	s = strings.ReplaceAll(s, "\\", "\\\\")
-	s = strings.ReplaceAll(s, "\"", "\\\"")
-	s = strings.ReplaceAll(s, "\n", "\\n")
-	s = strings.ReplaceAll(s, "\r", "\\r")
-	s = strings.ReplaceAll(s, "\t", "\\t")
-	return s
+// findPeakRES finds the largest resident memory in bytes of any thread in any snapshot
+func findPeakRES(data *TTopReportData) float64 {
+	peak := 0.0
+	for _, snapshot := range data.Snapshots {
+		for _, thread := range snapshot.Threads {
+			if thread.RES > peak {
+				peak = thread.RES
+			}
+		}
+	}
+	return peak
+}
+
+// marshalLegendData returns the series names of a chart as a JSON array for its legend.
+// json.Marshal escapes <, > and &, so thread names can't close the report's script
+func marshalLegendData(names []string) (string, error) {
+	if names == nil {
+		names = []string{}
+	}
+	legend, err := json.Marshal(names)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal chart legend: %w", err)
+	}
+	return string(legend), nil
+}
+
+// threadSeries is the line of one thread in a ttop chart, its values formatted to one decimal
+type threadSeries struct {
+	Name string        `json:"name"`
+	Type string        `json:"type"`
+	Data []json.Number `json:"data"`
+}
+
+// marshalThreadSeries returns the thread series of a chart as a JSON array. Like
+// marshalLegendData it escapes <, > and &, so thread names can't close the report's script
+func marshalThreadSeries(series []threadSeries) (string, error) {
+	data, err := json.Marshal(series)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal chart series: %w", err)
+	}
+	return string(data), nil
 }
 
 // extractCPULegendData extracts legend data for CPU chart (top 5 threads)
@@ -452,8 +655,12 @@ func extractCPULegendData(data *TTopReportData) []string {
 		pairs = append(pairs, threadCPUPair{key, cpu})
 	}
 
+	// Order ties by name so the legend and series pick the same threads
 	sort.Slice(pairs, func(i, j int) bool {
-		return pairs[i].cpu > pairs[j].cpu
+		if pairs[i].cpu != pairs[j].cpu {
+			return pairs[i].cpu > pairs[j].cpu
+		}
+		return pairs[i].key < pairs[j].key
 	})
 
 	// Limit to top 5
@@ -585,21 +792,22 @@ func extractThreadTypeLegendData(data *TTopReportData) []string {
 		}
 	}
 
+	nouns := ttopRowNouns(data)
 	var result []string
 	// Always include total threads
-	result = append(result, "Total Threads")
+	result = append(result, nouns.Replace("Total {Threads}"))
 
 	if hasRunning {
-		result = append(result, "Running Threads")
+		result = append(result, nouns.Replace("Running {Threads}"))
 	}
 	if hasSleeping {
-		result = append(result, "Sleeping Threads")
+		result = append(result, nouns.Replace("Sleeping {Threads}"))
 	}
 	if hasStopped {
-		result = append(result, "Stopped Threads")
+		result = append(result, nouns.Replace("Stopped {Threads}"))
 	}
 	if hasZombie {
-		result = append(result, "Zombie Threads")
+		result = append(result, nouns.Replace("Zombie {Threads}"))
 	}
 	return result
 }
@@ -631,7 +839,7 @@ func extractThreadTypeSeriesData(data *TTopReportData) string {
 
 	// Always include total threads
 	datasets = append(datasets, fmt.Sprintf(`{
-		name: "Total Threads",
+		name: "Total {Threads}",
 		type: "line",
 		data: [%s]
 	}`, strings.Join(totalSeries, ", ")))
@@ -639,7 +847,7 @@ func extractThreadTypeSeriesData(data *TTopReportData) string {
 	// Include running threads if there are any non-zero values
 	if hasNonZeroValues(runningSeries) {
 		datasets = append(datasets, fmt.Sprintf(`{
-			name: "Running Threads",
+			name: "Running {Threads}",
 			type: "line",
 			data: [%s]
 		}`, strings.Join(runningSeries, ", ")))
@@ -648,7 +856,7 @@ func extractThreadTypeSeriesData(data *TTopReportData) string {
 	// Include sleeping threads if there are any non-zero values
 	if hasNonZeroValues(sleepingSeries) {
 		datasets = append(datasets, fmt.Sprintf(`{
-			name: "Sleeping Threads",
+			name: "Sleeping {Threads}",
 			type: "line",
 			data: [%s]
 		}`, strings.Join(sleepingSeries, ", ")))
@@ -657,7 +865,7 @@ func extractThreadTypeSeriesData(data *TTopReportData) string {
 	// Include stopped threads if there are any non-zero values
 	if hasNonZeroValues(stoppedSeries) {
 		datasets = append(datasets, fmt.Sprintf(`{
-			name: "Stopped Threads",
+			name: "Stopped {Threads}",
 			type: "line",
 			data: [%s]
 		}`, strings.Join(stoppedSeries, ", ")))
@@ -666,13 +874,13 @@ func extractThreadTypeSeriesData(data *TTopReportData) string {
 	// Include zombie threads if there are any non-zero values
 	if hasNonZeroValues(zombieSeries) {
 		datasets = append(datasets, fmt.Sprintf(`{
-			name: "Zombie Threads",
+			name: "Zombie {Threads}",
 			type: "line",
 			data: [%s]
 		}`, strings.Join(zombieSeries, ", ")))
 	}
 
-	return fmt.Sprintf("[%s]", strings.Join(datasets, ", "))
+	return ttopRowNouns(data).Replace(fmt.Sprintf("[%s]", strings.Join(datasets, ", ")))
 }
 
 // hasNonZeroValues checks if a series contains any non-zero values
@@ -696,8 +904,8 @@ func hasNonZeroFloatValues(series []string) bool {
 }
 
 // extractThreadByCPULegendData extracts legend data for thread by CPU chart
-func extractThreadByCPULegendData(data *TTopReportData) []string {
-	// Find the top 5 busiest threads across all snapshots
+func extractThreadByCPULegendData(data *TTopReportData, topThreads int) []string {
+	// Find the topThreads busiest threads across all snapshots
 	threadCPU := make(map[string]float64)
 	for _, snapshot := range data.Snapshots {
 		for _, thread := range snapshot.Threads {
@@ -708,7 +916,7 @@ func extractThreadByCPULegendData(data *TTopReportData) []string {
 		}
 	}
 
-	// Sort threads by CPU usage and get top 5
+	// Sort threads by CPU usage and get the top ones
 	type threadCPUPair struct {
 		key string
 		cpu float64
@@ -719,13 +927,17 @@ func extractThreadByCPULegendData(data *TTopReportData) []string {
 		pairs = append(pairs, threadCPUPair{key, cpu})
 	}
 
+	// Order ties by name so the legend and series pick the same threads
 	sort.Slice(pairs, func(i, j int) bool {
-		return pairs[i].cpu > pairs[j].cpu
+		if pairs[i].cpu != pairs[j].cpu {
+			return pairs[i].cpu > pairs[j].cpu
+		}
+		return pairs[i].key < pairs[j].key
 	})
 
-	// Limit to top 5
-	if len(pairs) > 5 {
-		pairs = pairs[:5]
+	// Limit to topThreads
+	if len(pairs) > topThreads {
+		pairs = pairs[:topThreads]
 	}
 
 	var result []string
@@ -736,8 +948,8 @@ func extractThreadByCPULegendData(data *TTopReportData) []string {
 }
 
 // extractThreadByCPUSeriesData extracts series data for thread by CPU chart
-func extractThreadByCPUSeriesData(data *TTopReportData) string {
-	// Find the top 5 busiest threads across all snapshots
+func extractThreadByCPUSeriesData(data *TTopReportData, topThreads int) (string, error) {
+	// Find the topThreads busiest threads across all snapshots
 	threadCPU := make(map[string]float64)
 	for _, snapshot := range data.Snapshots {
 		for _, thread := range snapshot.Threads {
@@ -748,7 +960,7 @@ func extractThreadByCPUSeriesData(data *TTopReportData) string {
 		}
 	}
 
-	// Sort threads by CPU usage and get top 5
+	// Sort threads by CPU usage and get the top ones
 	type threadCPUPair struct {
 		key string
 		cpu float64
@@ -759,19 +971,23 @@ func extractThreadByCPUSeriesData(data *TTopReportData) string {
 		pairs = append(pairs, threadCPUPair{key, cpu})
 	}
 
+	// Order ties by name so the legend and series pick the same threads
 	sort.Slice(pairs, func(i, j int) bool {
-		return pairs[i].cpu > pairs[j].cpu
+		if pairs[i].cpu != pairs[j].cpu {
+			return pairs[i].cpu > pairs[j].cpu
+		}
+		return pairs[i].key < pairs[j].key
 	})
 
-	// Limit to top 5
-	if len(pairs) > 5 {
-		pairs = pairs[:5]
+	// Limit to topThreads
+	if len(pairs) > topThreads {
+		pairs = pairs[:topThreads]
 	}
 
 	// Generate series data for each thread
-	var datasets []string
+	datasets := make([]threadSeries, 0, len(pairs))
 	for _, pair := range pairs {
-		var threadData []string
+		threadData := make([]json.Number, 0, len(data.Snapshots))
 		for _, snapshot := range data.Snapshots {
 			cpu := 0.0
 			for _, thread := range snapshot.Threads {
@@ -781,15 +997,80 @@ func extractThreadByCPUSeriesData(data *TTopReportData) string {
 					break
 				}
 			}
-			threadData = append(threadData, fmt.Sprintf("%.1f", cpu))
+			threadData = append(threadData, json.Number(fmt.Sprintf("%.1f", cpu)))
 		}
+		datasets = append(datasets, threadSeries{Name: pair.key, Type: "line", Data: threadData})
+	}
 
-		datasets = append(datasets, fmt.Sprintf(`{
-			name: "%s",
-			type: "line",
-			data: [%s]
-		}`, escapeJSONString(pair.key), strings.Join(threadData, ", ")))
+	return marshalThreadSeries(datasets)
+}
+
+// extractThreadByRESLegendData extracts legend data for the thread by RES chart
+func extractThreadByRESLegendData(data *TTopReportData) []string {
+	return topResidentThreads(data)
+}
+
+// topResidentThreads returns the keys of the top 5 threads by peak resident memory
+func topResidentThreads(data *TTopReportData) []string {
+	threadRES := make(map[string]float64)
+	for _, snapshot := range data.Snapshots {
+		for _, thread := range snapshot.Threads {
+			key := fmt.Sprintf("%s-%d", thread.Command, thread.PID)
+			if res, exists := threadRES[key]; !exists || thread.RES > res {
+				threadRES[key] = thread.RES
+			}
+		}
 	}
 
-	return fmt.Sprintf("[%s]", strings.Join(datasets, ", "))
+	type threadRESPair struct {
+		key string
+		res float64
+	}
+
+	var pairs []threadRESPair
+	for key, res := range threadRES {
+		pairs = append(pairs, threadRESPair{key, res})
+	}
+
+	// Ties are common since threads of one process share its RES, so order by name too
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].res != pairs[j].res {
+			return pairs[i].res > pairs[j].res
+		}
+		return pairs[i].key < pairs[j].key
+	})
+
+	// Limit to top 5
+	if len(pairs) > 5 {
+		pairs = pairs[:5]
+	}
+
+	result := make([]string, 0, len(pairs))
+	for _, pair := range pairs {
+		result = append(result, pair.key)
+	}
+	return result
+}
+
+// extractThreadByRESSeriesData extracts series data in MiB for the top 5 threads by peak
+// resident memory
+func extractThreadByRESSeriesData(data *TTopReportData) (string, error) {
+	keys := topResidentThreads(data)
+	datasets := make([]threadSeries, 0, len(keys))
+	for _, key := range keys {
+		threadData := make([]json.Number, 0, len(data.Snapshots))
+		for _, snapshot := range data.Snapshots {
+			res := 0.0
+			for _, thread := range snapshot.Threads {
+				if fmt.Sprintf("%s-%d", thread.Command, thread.PID) == key {
+					res = thread.RES
+					break
+				}
+			}
+			threadData = append(threadData, json.Number(fmt.Sprintf("%.1f", res/(1024*1024))))
+		}
+		datasets = append(datasets, threadSeries{Name: key, Type: "line", Data: threadData})
+	}
+
+	return marshalThreadSeries(datasets)
 }
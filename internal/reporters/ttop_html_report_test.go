@@ -15,6 +15,9 @@
 package reporters
 
 import (
+	"encoding/json"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -23,6 +26,62 @@ import (
 )
 
 func TestGenerateTTopHTML(t *testing.T) {
+	t.Run("Legends list the series of every chart", func(t *testing.T) {
+		data := &TTopReportData{
+			Snapshots: []TTopSnapshot{{
+				Timestamp:    time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+				ThreadCounts: &ThreadCounts{Total: 100, Running: 2, Sleeping: 98},
+				SystemMemory: &SystemMemory{MemTotal: 8000, MemFree: 4000, MemUsed: 3000, MemBuffCache: 1000},
+				Threads: []ThreadInfo{
+					{PID: 1234, CPU: 25.5, RES: 2 * 1024 * 1024, Command: "java"},
+					{PID: 5678, CPU: 15.0, RES: 1024 * 1024, Command: "C2 <CompilerThread0>"},
+				},
+			}},
+		}
+
+		html, err := GenerateTTopHTML(data)
+		require.NoError(t, err)
+
+		assert.NotContains(t, html, "legend: { data: [] }")
+		// The CPU and resident memory charts name the same two threads
+		assert.Equal(t, 2, strings.Count(html, `legend: { type: 'scroll', top: 30, data: ["java-1234","C2 \u003cCompilerThread0\u003e-5678"] }`))
+		assert.Contains(t, html, `legend: { type: 'scroll', top: 30, data: ["Memory Used (MiB)","Buffer/Cache (MiB)","Memory Free (MiB)"] }`)
+		assert.Contains(t, html, `legend: { type: 'scroll', top: 30, data: ["Total Threads","Running Threads","Sleeping Threads"] }`)
+	})
+
+	t.Run("Legends name processes for top captures", func(t *testing.T) {
+		data := &TTopReportData{
+			Processes: true,
+			Snapshots: []TTopSnapshot{{
+				Timestamp:    time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+				ThreadCounts: &ThreadCounts{Total: 10, Running: 1},
+				Threads:      []ThreadInfo{{PID: 1, CPU: 5, Command: "java"}},
+			}},
+		}
+
+		html, err := GenerateTTopHTML(data)
+		require.NoError(t, err)
+		assert.Contains(t, html, `data: ["Total Processes","Running Processes"]`)
+	})
+
+	t.Run("Every chart is annotated at the start time of its points", func(t *testing.T) {
+		start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+		data := &TTopReportData{}
+		for i := 0; i < 4; i++ {
+			data.Snapshots = append(data.Snapshots, TTopSnapshot{
+				Timestamp:    start.Add(time.Duration(i) * time.Minute),
+				ThreadCounts: &ThreadCounts{Total: 10},
+				Threads:      []ThreadInfo{{PID: 1, CPU: 5, Command: "java"}},
+			})
+		}
+
+		html, err := GenerateTTopHTMLWithMaxPoints(data, 2)
+		require.NoError(t, err)
+		assert.Contains(t, html, `<script type="application/json" id="ddd-chart-times">["2024-01-01T12:00:00Z","2024-01-01T12:02:00Z"]</script>`)
+		assert.Contains(t, html, "[threadByCpuChart, threadByResChart, memoryByTypeChart, threadsByTypeChart].forEach(dddAnnotate);")
+		assert.Contains(t, html, "'/api/reports/' + dddReportID + '/annotations'")
+	})
+
 	t.Run("Generate HTML with ECharts", func(t *testing.T) {
 		// Create test data
 		data := &TTopReportData{
@@ -94,8 +153,17 @@ func TestGenerateTTopHTML(t *testing.T) {
 
 		// Verify chart titles
 		assert.Contains(t, html, "Threads by Name/ID CPU Usage Over Time")
+		assert.Contains(t, html, "Thread Resident Memory Over Time")
 		assert.Contains(t, html, "System Memory Usage Over Time")
 		assert.Contains(t, html, "Thread States Over Time")
+		assert.Contains(t, html, "Peak RES")
+		assert.Contains(t, html, "No steady memory growth detected.")
+		assert.Contains(t, html, "CPU core count not found in the capture")
+		assert.NotContains(t, html, `id="cpuNormalizeToggle"`)
+
+		// The time axis names the zone the timestamps are in
+		assert.Contains(t, html, "const timeAxisName = 'Time (UTC)';")
+		assert.Equal(t, 4, strings.Count(html, "name: timeAxisName"))
 
 		// Verify ECharts initialization
 		assert.Contains(t, html, "echarts.init")
@@ -103,6 +171,48 @@ func TestGenerateTTopHTML(t *testing.T) {
 		assert.Contains(t, html, "resize()")
 	})
 
+	t.Run("Generate HTML with a known core count", func(t *testing.T) {
+		data := &TTopReportData{
+			CPUCores: 8,
+			Snapshots: []TTopSnapshot{
+				{
+					Timestamp: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+					Threads:   []ThreadInfo{{PID: 1234, User: "root", CPU: 240.0, Command: "java"}},
+				},
+			},
+		}
+
+		html, err := GenerateTTopHTML(data)
+		require.NoError(t, err)
+		assert.Contains(t, html, `<input type="checkbox" id="cpuNormalizeToggle"> Show as % of all 8 cores`)
+		assert.Contains(t, html, "const cpuCores = 8;")
+		assert.NotContains(t, html, "CPU core count not found")
+	})
+
+	t.Run("Generate HTML for a plain top capture", func(t *testing.T) {
+		data := &TTopReportData{
+			Processes: true,
+			Snapshots: []TTopSnapshot{
+				{
+					Timestamp:    time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+					ThreadCounts: &ThreadCounts{Total: 215, Running: 1, Sleeping: 214},
+					Threads:      []ThreadInfo{{PID: 1234, User: "dremio", CPU: 45.0, Command: "java"}},
+				},
+			},
+		}
+
+		html, err := GenerateTTopHTML(data)
+		require.NoError(t, err)
+		assert.Contains(t, html, "<h1>Top Analysis Report</h1>")
+		assert.Contains(t, html, "Processes by Name/ID CPU Usage Over Time")
+		assert.Contains(t, html, "Process States Over Time")
+		assert.Contains(t, html, "Unique Processes")
+		assert.Contains(t, html, `name: "Total Processes"`)
+		assert.NotContains(t, html, "Unique Threads")
+		assert.NotContains(t, html, "Thread States Over Time")
+		assert.NotContains(t, html, "{Thread")
+	})
+
 	t.Run("Generate HTML with empty data", func(t *testing.T) {
 		html, err := GenerateTTopHTML(nil)
 		require.NoError(t, err)
@@ -145,6 +255,39 @@ func TestGenerateTTopHTML(t *testing.T) {
 		assert.Contains(t, html, "Snapshots")
 		assert.Contains(t, html, "<div class=\"stat-value\">1</div>")
 	})
+
+	t.Run("Generate HTML with a theme", func(t *testing.T) {
+		data := &TTopReportData{
+			Snapshots: []TTopSnapshot{
+				{
+					Timestamp:    time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+					ThreadCounts: &ThreadCounts{Total: 50, Running: 1, Sleeping: 49},
+					Threads: []ThreadInfo{
+						{PID: 1234, User: "dremio", CPU: 25.5, MEM: 10.2, Command: "java"},
+					},
+				},
+			},
+		}
+
+		html, err := GenerateTTopHTMLWithTheme(data, 0, "dark")
+		require.NoError(t, err)
+
+		// Styles come from the theme rather than being hard-coded
+		assert.Contains(t, html, `<style id="ddd-report-theme">`)
+		assert.Contains(t, html, "--report-bg: #0b0f14;")
+		assert.Contains(t, html, "var(--report-chart-height)")
+		assert.NotContains(t, html, "#06b6d4")
+
+		// Every chart uses the registered echarts theme and its palette
+		assert.Contains(t, html, "echarts.registerTheme('ddd'")
+		assert.Contains(t, html, `"#22d3ee"`)
+		assert.Equal(t, 4, strings.Count(html, "'ddd');"))
+
+		// The default theme keeps the original look
+		html, err = GenerateTTopHTML(data)
+		require.NoError(t, err)
+		assert.Contains(t, html, "--report-accent: #06b6d4;")
+	})
 }
 
 func TestExtractCPULegendData(t *testing.T) {
@@ -314,7 +457,39 @@ func TestExtractThreadTypeSeriesData(t *testing.T) {
 	})
 }
 
+func TestClampTTopTopThreads(t *testing.T) {
+	assert.Equal(t, DefaultTTopTopThreads, ClampTTopTopThreads(0))
+	assert.Equal(t, DefaultTTopTopThreads, ClampTTopTopThreads(-1))
+	assert.Equal(t, 12, ClampTTopTopThreads(12))
+	assert.Equal(t, MaxTTopTopThreads, ClampTTopTopThreads(MaxTTopTopThreads+1))
+}
+
 func TestExtractThreadByCPUData(t *testing.T) {
+	t.Run("Chart the configured number of busiest threads", func(t *testing.T) {
+		snapshot := TTopSnapshot{Timestamp: time.Now()}
+		for i := 1; i <= 10; i++ {
+			snapshot.Threads = append(snapshot.Threads, ThreadInfo{PID: i, Command: "worker", CPU: float64(i * 10)})
+		}
+		data := &TTopReportData{Snapshots: []TTopSnapshot{snapshot}}
+
+		assert.Len(t, extractThreadByCPULegendData(data, DefaultTTopTopThreads), 5)
+		legend := extractThreadByCPULegendData(data, 8)
+		assert.Equal(t, []string{"worker-10", "worker-9", "worker-8", "worker-7", "worker-6", "worker-5", "worker-4", "worker-3"}, legend)
+
+		series, err := extractThreadByCPUSeriesData(data, 8)
+		require.NoError(t, err)
+		assert.Equal(t, 8, strings.Count(series, `"type":"line"`))
+		assert.Contains(t, series, "worker-3")
+		assert.NotContains(t, series, "worker-2\"")
+
+		html, err := GenerateTTopHTMLWithTopThreads(data, 0, DefaultReportTheme, 8)
+		require.NoError(t, err)
+		assert.Contains(t, html, "Thread CPU Usage Over Time (Top 8)")
+		html, err = GenerateTTopHTMLWithTopThreads(data, 0, DefaultReportTheme, 500)
+		require.NoError(t, err)
+		assert.Contains(t, html, fmt.Sprintf("Thread CPU Usage Over Time (Top %d)", MaxTTopTopThreads))
+	})
+
 	t.Run("Extract thread by CPU data", func(t *testing.T) {
 		data := &TTopReportData{
 			Snapshots: []TTopSnapshot{
@@ -333,37 +508,74 @@ func TestExtractThreadByCPUData(t *testing.T) {
 			},
 		}
 
-		legendResult := extractThreadByCPULegendData(data)
+		legendResult := extractThreadByCPULegendData(data, DefaultTTopTopThreads)
 		assert.NotEmpty(t, legendResult)
 		assert.Contains(t, legendResult, "java-1234")
 		assert.Contains(t, legendResult, "compiler-5678")
 
-		seriesResult := extractThreadByCPUSeriesData(data)
+		seriesResult, err := extractThreadByCPUSeriesData(data, DefaultTTopTopThreads)
+		require.NoError(t, err)
 		assert.NotEmpty(t, seriesResult)
 		assert.Contains(t, seriesResult, "java-1234")
 		assert.Contains(t, seriesResult, "compiler-5678")
-		assert.Contains(t, seriesResult, "25.5, 30.0") // CPU values across snapshots
-		assert.Contains(t, seriesResult, "15.0, 20.0") // CPU values across snapshots
+		assert.Contains(t, seriesResult, "[25.5,30.0]") // CPU values across snapshots
+		assert.Contains(t, seriesResult, "[15.0,20.0]") // CPU values across snapshots
 	})
-}
 
-func TestEscapeJSONString(t *testing.T) {
-	t.Run("Escape JSON string", func(t *testing.T) {
-		tests := []struct {
-			input    string
-			expected string
-		}{
-			{"simple", "simple"},
-			{"with\"quotes", "with\\\"quotes"},
-			{"with\\backslash", "with\\\\backslash"},
-			{"with\nnewline", "with\\nnewline"},
-			{"with\ttab", "with\\ttab"},
-			{"with\rcarriage", "with\\rcarriage"},
-		}
+	t.Run("Thread names can't break out of the report script", func(t *testing.T) {
+		command := `</script><svg/onload=alert(1)>"x`
+		data := &TTopReportData{Snapshots: []TTopSnapshot{
+			{Timestamp: time.Now(), Threads: []ThreadInfo{{PID: 1, Command: command, CPU: 50}}},
+		}}
 
-		for _, test := range tests {
-			result := escapeJSONString(test.input)
-			assert.Equal(t, test.expected, result)
-		}
+		series, err := extractThreadByCPUSeriesData(data, DefaultTTopTopThreads)
+		require.NoError(t, err)
+		var parsed []threadSeries
+		require.NoError(t, json.Unmarshal([]byte(series), &parsed))
+		require.Len(t, parsed, 1)
+		assert.Equal(t, command+"-1", parsed[0].Name)
+		assert.NotContains(t, series, "</script>")
+
+		series, err = extractThreadByRESSeriesData(data)
+		require.NoError(t, err)
+		assert.NotContains(t, series, "</script>")
+
+		html, err := GenerateTTopHTML(data)
+		require.NoError(t, err)
+		assert.NotContains(t, html, "</script><svg")
+		assert.NotContains(t, html, "' + item.seriesName + '", "Tooltips escape series names")
 	})
 }
+
+func TestExtractThreadByRESData(t *testing.T) {
+	const mib = 1024 * 1024
+	data := &TTopReportData{
+		Snapshots: []TTopSnapshot{
+			{
+				Threads: []ThreadInfo{
+					{PID: 1234, Command: "java", RES: 3072 * mib},
+					{PID: 5678, Command: "compiler", RES: 512 * mib},
+				},
+			},
+			{
+				Threads: []ThreadInfo{
+					{PID: 1234, Command: "java", RES: 3481.6 * mib},
+				},
+			},
+		},
+	}
+
+	seriesResult, err := extractThreadByRESSeriesData(data)
+	require.NoError(t, err)
+	assert.Contains(t, seriesResult, "java-1234")
+	assert.Contains(t, seriesResult, "[3072.0,3481.6]") // MiB across snapshots
+	assert.Contains(t, seriesResult, "[512.0,0.0]")     // Absent from the second snapshot
+	assert.Less(t, strings.Index(seriesResult, "java-1234"), strings.Index(seriesResult, "compiler-5678"),
+		"Largest consumer should come first")
+
+	assert.Equal(t, 3481.6*mib, findPeakRES(data))
+
+	html, err := GenerateTTopHTML(data)
+	require.NoError(t, err)
+	assert.Contains(t, html, `<div class="stat-value">3481.6 MiB</div>`)
+}
@@ -15,8 +15,11 @@
 package reporters
 
 import (
-	"bufio"
+	"bytes"
 	"fmt"
+	"io"
+	"math"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -28,10 +31,14 @@ type ThreadInfo struct {
 	User    string  `json:"user"`    // User running the process
 	CPU     float64 `json:"cpu"`     // CPU usage percentage
 	MEM     float64 `json:"mem"`     // Memory usage percentage
+	VIRT    float64 `json:"virt"`    // Virtual memory in bytes
+	RES     float64 `json:"res"`     // Resident memory in bytes
+	SHR     float64 `json:"shr"`     // Shared memory in bytes
 	Command string  `json:"command"` // Command name/line
 }
 
-// ThreadCounts represents the global thread counts from the "Threads:" line
+// ThreadCounts represents the global thread counts from the "Threads:" line, or the process
+// counts from the "Tasks:" line of a plain top -b capture
 type ThreadCounts struct {
 	Total    int `json:"total"`    // Total number of threads
 	Running  int `json:"running"`  // Number of running threads
@@ -40,7 +47,8 @@ type ThreadCounts struct {
 	Zombie   int `json:"zombie"`   // Number of zombie threads
 }
 
-// SystemMemory represents system memory information from "MiB Mem:" and "MiB Swap:" lines
+// SystemMemory represents system memory information from "MiB Mem:" and "MiB Swap:" lines.
+// Captures from top builds that report KiB or GiB are converted to MiB
 type SystemMemory struct {
 	MemTotal     float64 `json:"mem_total"`      // Total memory in MiB
 	MemFree      float64 `json:"mem_free"`       // Free memory in MiB
@@ -62,20 +70,60 @@ type TTopSnapshot struct {
 
 // TTopReportData represents the complete parsed ttop report data
 type TTopReportData struct {
-	Snapshots []TTopSnapshot `json:"snapshots"` // All snapshots from the ttop output
+	Snapshots []TTopSnapshot `json:"snapshots"`           // All snapshots from the ttop output
+	CPUCores  int            `json:"cpu_cores,omitempty"` // Logical CPUs on the host, 0 when the capture doesn't say
+	Processes bool           `json:"processes,omitempty"` // Rows are processes from a plain top -b capture rather than ttop threads
 }
 
+// ttopColumns holds the field index of each column parsed from a thread line, -1 for a
+// column the capture doesn't show. COMMAND is always last since it may contain spaces
+type ttopColumns struct {
+	pid, user, virt, res, shr, cpu, mem, command int
+}
+
+// defaultTTopColumns is the layout of ttop and of top's default field list:
+// PID USER PR NI VIRT RES SHR S %CPU %MEM TIME+ COMMAND
+var defaultTTopColumns = ttopColumns{pid: 0, user: 1, virt: 4, res: 5, shr: 6, cpu: 8, mem: 9, command: 11}
+
+// ttopMemoryUnits converts the unit prefix of top's memory summary lines to MiB
+var ttopMemoryUnits = map[string]float64{
+	"KiB": 1.0 / 1024,
+	"MiB": 1,
+	"GiB": 1024,
+	"TiB": 1024 * 1024,
+}
+
+// cpuCountRegex matches the "(N CPU)" core count that sysstat-style headers carry
+var cpuCountRegex = regexp.MustCompile(`\((\d+) CPU\)`)
+
 // ParseTTop parses ttop output content and extracts thread information over time
 // The parser looks for lines starting with "top - " to identify snapshot boundaries
-// and extracts thread information from subsequent lines that start with a PID (integer)
+// and extracts thread information from subsequent lines that start with a PID (integer).
+// Plain top -b captures are parsed the same way, with a "Tasks:" summary line marking the
+// rows as processes. Timestamps are treated as UTC
 func ParseTTop(content []byte) (*TTopReportData, error) {
-	if len(content) == 0 {
-		return &TTopReportData{Snapshots: []TTopSnapshot{}}, nil
-	}
+	return ParseTTopInLocation(content, time.UTC)
+}
 
-	scanner := bufio.NewScanner(strings.NewReader(string(content)))
-	var snapshots []TTopSnapshot
+// ParseTTopInLocation parses ttop output content like ParseTTop, interpreting the
+// zoneless "top - HH:MM:SS" timestamps in loc
+func ParseTTopInLocation(content []byte, loc *time.Location) (*TTopReportData, error) {
+	return ParseTTopReader(bytes.NewReader(content), loc)
+}
+
+// ParseTTopReader parses ttop output line by line from r, interpreting timestamps in loc.
+// Only the snapshot being parsed and the completed snapshots are held in memory, never
+// the raw output
+func ParseTTopReader(r io.Reader, loc *time.Location) (*TTopReportData, error) {
+	scanner := newLineScanner(r)
+	snapshots := []TTopSnapshot{}
 	var currentSnapshot *TTopSnapshot
+	// A "(N CPU)" header is authoritative; otherwise the per-core "%CpuN" lines top prints
+	// with its per-CPU view toggled on imply the count
+	headerCores, perCoreLines := 0, 0
+	processes := false
+	// The column header repeats in every snapshot, so the layout carries over between them
+	columns := defaultTTopColumns
 
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
@@ -85,6 +133,17 @@ func ParseTTop(content []byte) (*TTopReportData, error) {
 			continue
 		}
 
+		if match := cpuCountRegex.FindStringSubmatch(line); match != nil {
+			if cores, err := strconv.Atoi(match[1]); err == nil && cores > 0 {
+				headerCores = cores
+			}
+			continue
+		}
+		if core, ok := parsePerCoreCPULine(line); ok {
+			perCoreLines = max(perCoreLines, core+1)
+			continue
+		}
+
 		// Check if this line starts a new snapshot
 		if strings.HasPrefix(line, "top - ") {
 			// Save previous snapshot if it exists
@@ -93,10 +152,10 @@ func ParseTTop(content []byte) (*TTopReportData, error) {
 			}
 
 			// Parse timestamp from the "top - " line
-			timestamp, err := parseTimestampFromTopLine(line)
+			timestamp, err := parseTimestampFromTopLine(line, loc)
 			if err != nil {
 				// If we can't parse timestamp, use current time as fallback
-				timestamp = time.Now()
+				timestamp = time.Now().In(loc)
 			}
 
 			// Start new snapshot
@@ -109,17 +168,30 @@ func ParseTTop(content []byte) (*TTopReportData, error) {
 			continue
 		}
 
-		// Check if this line contains thread counts
-		if strings.HasPrefix(line, "Threads:") && currentSnapshot != nil {
+		// Check if this line contains thread counts, or process counts for plain top -b
+		if (strings.HasPrefix(line, "Threads:") || strings.HasPrefix(line, "Tasks:")) && currentSnapshot != nil {
 			threadCounts, err := parseThreadCountsLine(line)
 			if err == nil {
 				currentSnapshot.ThreadCounts = threadCounts
 			}
+			if strings.HasPrefix(line, "Tasks:") {
+				processes = true
+			}
+			continue
+		}
+
+		// Check if this line is the column header, which needn't start with PID when top's
+		// fields were reordered
+		if parsed, ok := parseTTopHeader(line); ok {
+			columns = parsed
+			continue
+		}
+		if strings.HasPrefix(line, "PID ") {
 			continue
 		}
 
 		// Check if this line contains memory information
-		if strings.HasPrefix(line, "MiB Mem") && currentSnapshot != nil {
+		if isTTopMemoryLine(line, "Mem") && currentSnapshot != nil {
 			if currentSnapshot.SystemMemory == nil {
 				currentSnapshot.SystemMemory = &SystemMemory{}
 			}
@@ -131,7 +203,7 @@ func ParseTTop(content []byte) (*TTopReportData, error) {
 		}
 
 		// Check if this line contains swap information
-		if strings.HasPrefix(line, "MiB Swap") && currentSnapshot != nil {
+		if isTTopMemoryLine(line, "Swap") && currentSnapshot != nil {
 			if currentSnapshot.SystemMemory == nil {
 				currentSnapshot.SystemMemory = &SystemMemory{}
 			}
@@ -144,7 +216,7 @@ func ParseTTop(content []byte) (*TTopReportData, error) {
 
 		// If we're in a snapshot, try to parse thread information
 		if currentSnapshot != nil {
-			threadInfo, err := parseThreadLine(line)
+			threadInfo, err := parseThreadLineWithColumns(line, columns)
 			if err == nil {
 				currentSnapshot.Threads = append(currentSnapshot.Threads, threadInfo)
 			}
@@ -161,11 +233,34 @@ func ParseTTop(content []byte) (*TTopReportData, error) {
 		return nil, fmt.Errorf("error reading ttop content: %w", err)
 	}
 
-	return &TTopReportData{Snapshots: snapshots}, nil
+	cores := headerCores
+	if cores == 0 {
+		cores = perCoreLines
+	}
+	return &TTopReportData{Snapshots: snapshots, CPUCores: cores, Processes: processes}, nil
+}
+
+// parsePerCoreCPULine returns the core index of a per-core line like
+// "%Cpu3  :  2.0 us,  1.0 sy, ...". The "%Cpu(s)" summary line is not per-core
+func parsePerCoreCPULine(line string) (int, bool) {
+	rest, ok := strings.CutPrefix(line, "%Cpu")
+	if !ok {
+		return 0, false
+	}
+	digits := rest[:len(rest)-len(strings.TrimLeft(rest, "0123456789"))]
+	if digits == "" {
+		return 0, false
+	}
+	core, err := strconv.Atoi(digits)
+	if err != nil {
+		return 0, false
+	}
+	return core, true
 }
 
 // parseTimestampFromTopLine extracts timestamp from a line like "top - 12:02:03 up  3:07,  0 users,  load average: 3.18, 1.16, 0.41"
-func parseTimestampFromTopLine(line string) (time.Time, error) {
+// and places it in loc
+func parseTimestampFromTopLine(line string, loc *time.Location) (time.Time, error) {
 	// Look for the timestamp pattern HH:MM:SS after "top - "
 	parts := strings.Fields(line)
 	if len(parts) < 3 {
@@ -183,15 +278,16 @@ func parseTimestampFromTopLine(line string) (time.Time, error) {
 
 	// Since we only have time, not date, we'll use today's date
 	// In a real scenario, you might want to handle date parsing differently
-	now := time.Now()
+	now := time.Now().In(loc)
 	return time.Date(now.Year(), now.Month(), now.Day(),
-		parsedTime.Hour(), parsedTime.Minute(), parsedTime.Second(), 0, now.Location()), nil
+		parsedTime.Hour(), parsedTime.Minute(), parsedTime.Second(), 0, loc), nil
 }
 
 // parseThreadCountsLine parses a line like "Threads: 262 total,   6 running, 256 sleeping,   0 stopped,   0 zombie"
+// or top's "Tasks: 215 total,   1 running, 214 sleeping,   0 stopped,   0 zombie"
 func parseThreadCountsLine(line string) (*ThreadCounts, error) {
-	// Remove "Threads: " prefix
-	line = strings.TrimPrefix(line, "Threads:")
+	// Remove the "Threads: " or "Tasks: " prefix
+	_, line, _ = strings.Cut(line, ":")
 	line = strings.TrimSpace(line)
 
 	// Split by commas to get individual counts
@@ -233,15 +329,35 @@ func parseThreadCountsLine(line string) (*ThreadCounts, error) {
 	return counts, nil
 }
 
+// isTTopMemoryLine reports whether line is a memory summary line of the given kind, "Mem"
+// or "Swap", such as "MiB Mem :" or "KiB Swap:"
+func isTTopMemoryLine(line, kind string) bool {
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return false
+	}
+	_, ok := ttopMemoryUnits[fields[0]]
+	return ok && strings.TrimSuffix(fields[1], ":") == kind
+}
+
+// ttopMemoryScale returns the factor converting the values of a memory summary line to
+// MiB, taken from its unit prefix
+func ttopMemoryScale(line string) float64 {
+	unit, _, _ := strings.Cut(line, " ")
+	if scale, ok := ttopMemoryUnits[unit]; ok {
+		return scale
+	}
+	return 1
+}
+
 // parseMemoryLine parses a line like "MiB Mem :  16008.2 total,  10953.7 free,   3713.5 used,   1341.1 buff/cache"
+// or "KiB Mem : 16392272 total, ...", storing the values in MiB
 func parseMemoryLine(line string, memory *SystemMemory) error {
-	// Remove "MiB Mem :" prefix
-	line = strings.TrimPrefix(line, "MiB Mem")
+	scale := ttopMemoryScale(line)
+
+	// Remove the "MiB Mem :" prefix
+	_, line, _ = strings.Cut(line, ":")
 	line = strings.TrimSpace(line)
-	if strings.HasPrefix(line, ":") {
-		line = strings.TrimPrefix(line, ":")
-		line = strings.TrimSpace(line)
-	}
 
 	// Split by commas to get individual memory values
 	parts := strings.Split(line, ",")
@@ -261,6 +377,7 @@ func parseMemoryLine(line string, memory *SystemMemory) error {
 		if err != nil {
 			continue
 		}
+		value *= scale
 
 		// Determine which type based on the second field
 		switch fields[1] {
@@ -278,10 +395,13 @@ func parseMemoryLine(line string, memory *SystemMemory) error {
 	return nil
 }
 
-// parseSwapLine parses a line like "MiB Swap:      0.0 total,      0.0 free,      0.0 used.  12032.0 avail Mem"
+// parseSwapLine parses a line like "MiB Swap:      0.0 total,      0.0 free,      0.0 used.  12032.0 avail Mem",
+// storing the values in MiB
 func parseSwapLine(line string, memory *SystemMemory) error {
-	// Remove "MiB Swap:" prefix
-	line = strings.TrimPrefix(line, "MiB Swap:")
+	scale := ttopMemoryScale(line)
+
+	// Remove the "MiB Swap:" prefix
+	_, line, _ = strings.Cut(line, ":")
 	line = strings.TrimSpace(line)
 
 	// Look for "avail Mem" to separate swap info from available memory
@@ -297,7 +417,7 @@ func parseSwapLine(line string, memory *SystemMemory) error {
 			// The last field should be the available memory value
 			availValue := fields[len(fields)-1]
 			if value, err := strconv.ParseFloat(availValue, 64); err == nil {
-				memory.MemAvail = value
+				memory.MemAvail = value * scale
 			}
 			// Remove the available memory part to get just the swap info
 			swapPart = strings.TrimSpace(strings.TrimSuffix(beforeAvail, availValue))
@@ -328,6 +448,7 @@ func parseSwapLine(line string, memory *SystemMemory) error {
 		if err != nil {
 			continue
 		}
+		value *= scale
 
 		// Determine which type based on the second field (remove trailing punctuation)
 		fieldType := strings.TrimSuffix(fields[1], ".")
@@ -344,48 +465,132 @@ func parseSwapLine(line string, memory *SystemMemory) error {
 	return nil
 }
 
+// parseTTopHeader maps the columns of a header line like
+// "PID USER      PR  NI    VIRT    RES    SHR S  %CPU  %MEM     TIME+ COMMAND", so captures
+// from a top with a reordered or trimmed field list still parse. Columns the header lacks,
+// %CPU included, read as zero rather than taking the values of their neighbours. ok is false
+// unless the header has PID and a trailing COMMAND, leaving the previous layout in place
+func parseTTopHeader(line string) (ttopColumns, bool) {
+	columns := ttopColumns{pid: -1, user: -1, virt: -1, res: -1, shr: -1, cpu: -1, mem: -1, command: -1}
+	fields := strings.Fields(line)
+	for i, field := range fields {
+		switch field {
+		case "PID":
+			columns.pid = i
+		case "USER":
+			columns.user = i
+		case "VIRT":
+			columns.virt = i
+		case "RES":
+			columns.res = i
+		case "SHR":
+			columns.shr = i
+		case "%CPU":
+			columns.cpu = i
+		case "%MEM":
+			columns.mem = i
+		case "COMMAND":
+			columns.command = i
+		}
+	}
+	if columns.pid < 0 || columns.command != len(fields)-1 {
+		return ttopColumns{}, false
+	}
+	return columns, true
+}
+
 // parseThreadLine parses a line that represents thread information
 // Expected format: PID USER PR NI VIRT RES SHR S %CPU %MEM TIME+ COMMAND
 // We need at least 12 columns to extract all required information
 func parseThreadLine(line string) (ThreadInfo, error) {
+	return parseThreadLineWithColumns(line, defaultTTopColumns)
+}
+
+// parseThreadLineWithColumns parses a thread line laid out as columns. Columns missing from
+// the layout, and values that fail to parse, default to zero
+func parseThreadLineWithColumns(line string, columns ttopColumns) (ThreadInfo, error) {
 	fields := strings.Fields(line)
 
-	// Need at least 12 fields to have all the required information
-	if len(fields) < 12 {
+	// Need every column up to COMMAND to have all the required information
+	if len(fields) <= columns.command {
 		return ThreadInfo{}, fmt.Errorf("insufficient fields in thread line")
 	}
 
-	// First field should be PID (integer)
-	pid, err := strconv.Atoi(fields[0])
+	// PID must be an integer
+	pid, err := strconv.Atoi(fields[columns.pid])
 	if err != nil {
 		return ThreadInfo{}, fmt.Errorf("invalid PID: %w", err)
 	}
 
-	// Second field is USER
-	user := fields[1]
-
-	// %CPU is at index 8 (9th column)
-	cpu, err := strconv.ParseFloat(fields[8], 64)
-	if err != nil {
-		// If CPU parsing fails, default to 0.0
-		cpu = 0.0
+	user := ""
+	if columns.user >= 0 {
+		user = fields[columns.user]
 	}
 
-	// %MEM is at index 9 (10th column)
-	mem, err := strconv.ParseFloat(fields[9], 64)
-	if err != nil {
-		// If MEM parsing fails, default to 0.0
-		mem = 0.0
+	percent := func(index int) float64 {
+		if index < 0 {
+			return 0.0
+		}
+		value, err := strconv.ParseFloat(fields[index], 64)
+		if err != nil {
+			return 0.0
+		}
+		return value
+	}
+	size := func(index int) float64 {
+		if index < 0 {
+			return 0.0
+		}
+		value, err := parseTTopSize(fields[index])
+		if err != nil {
+			return 0.0
+		}
+		return value
 	}
 
-	// COMMAND starts at index 11 (12th column) and may span multiple fields
-	command := strings.Join(fields[11:], " ")
+	// COMMAND is last and may span multiple fields
+	command := strings.Join(fields[columns.command:], " ")
 
 	return ThreadInfo{
 		PID:     pid,
 		User:    user,
-		CPU:     cpu,
-		MEM:     mem,
+		CPU:     percent(columns.cpu),
+		MEM:     percent(columns.mem),
+		VIRT:    size(columns.virt),
+		RES:     size(columns.res),
+		SHR:     size(columns.shr),
 		Command: command,
 	}, nil
 }
+
+// ttopSizeMultipliers converts top's memory column suffixes to bytes
+var ttopSizeMultipliers = map[string]float64{
+	"k": 1 << 10,
+	"m": 1 << 20,
+	"g": 1 << 30,
+	"t": 1 << 40,
+}
+
+// parseTTopSize parses a top memory column such as "98412" or "3.4g" into bytes. Bare
+// numbers are KiB, top's default unit; top switches to a k, m, g or t suffix once a value
+// no longer fits the column
+func parseTTopSize(s string) (float64, error) {
+	number := strings.TrimSpace(s)
+	multiplier := ttopSizeMultipliers["k"]
+	if number != "" {
+		if suffix, ok := ttopSizeMultipliers[strings.ToLower(number[len(number)-1:])]; ok {
+			multiplier = suffix
+			number = number[:len(number)-1]
+		}
+	}
+
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q: %w", s, err)
+	}
+	// ParseFloat accepts forms top never prints, so reject anything that isn't a plain size
+	if value < 0 || math.IsNaN(value) || math.IsInf(value, 0) || strings.ContainsAny(number, "eExXpP_") {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return value * multiplier, nil
+}
@@ -15,9 +15,12 @@
 package reporters
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/rsvihladremio/ddd/internal/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -184,28 +187,155 @@ Threads: 100 total,   2 running, 98 sleeping,   0 stopped,   0 zombie
 		assert.Equal(t, 10.2, thread.MEM)
 		assert.Equal(t, "test-process", thread.Command)
 	})
+
+	t.Run("Parse from a reader with long lines", func(t *testing.T) {
+		content := `top - 15:30:45 up 1 day,  5:23,  2 users,  load average: 1.23, 1.45, 1.67
+
+    PID USER      PR  NI    VIRT    RES    SHR S  %CPU  %MEM     TIME+ COMMAND
+   1234 root      20   0  123456  12345   1234 R  25.5  10.2   0:30.12 ` + strings.Repeat("x", 100*1024)
+
+		data, err := ParseTTopReader(strings.NewReader(content), time.UTC)
+		require.NoError(t, err)
+		require.Len(t, data.Snapshots, 1)
+		require.Len(t, data.Snapshots[0].Threads, 1)
+		assert.Len(t, data.Snapshots[0].Threads[0].Command, 100*1024)
+	})
+}
+
+func TestParseTTopCPUCores(t *testing.T) {
+	snapshot := `top - 12:02:03 up  3:07,  0 users,  load average: 3.18, 1.16, 0.41
+Threads: 262 total,   6 running, 256 sleeping,   0 stopped,   0 zombie
+%s
+    PID USER      PR  NI    VIRT    RES    SHR S  %%CPU  %%MEM     TIME+ COMMAND
+    997 dremio    20   0 7009048   3.4g  98412 R 287.5  21.9   1:36.52 C2 CompilerThre
+`
+	tests := []struct {
+		name    string
+		content string
+		cores   int
+	}{
+		{"Header core count", "Linux 5.10.0-32-cloud-amd64 (test-system) \t09/04/24 \t_x86_64_\t(16 CPU)\n" +
+			fmt.Sprintf(snapshot, "%Cpu(s): 85.7 us,  7.1 sy,  0.0 ni,  5.7 id"), 16},
+		{"Per-core lines", fmt.Sprintf(snapshot, "%Cpu0  : 85.7 us,  7.1 sy\n%Cpu1  : 80.0 us,  5.0 sy\n%Cpu2  : 10.0 us,  1.0 sy\n%Cpu3  :  1.0 us,  0.0 sy"), 4},
+		{"Header wins over per-core lines", "(8 CPU)\n" + fmt.Sprintf(snapshot, "%Cpu0  : 85.7 us\n%Cpu1  : 80.0 us"), 8},
+		{"Summary line only", fmt.Sprintf(snapshot, "%Cpu(s): 85.7 us,  7.1 sy,  0.0 ni,  5.7 id"), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := ParseTTop([]byte(tt.content))
+			require.NoError(t, err)
+			assert.Equal(t, tt.cores, data.CPUCores)
+			require.Len(t, data.Snapshots, 1)
+			require.Len(t, data.Snapshots[0].Threads, 1)
+			assert.Equal(t, 287.5, data.Snapshots[0].Threads[0].CPU)
+		})
+	}
+}
+
+func TestParseTopBatch(t *testing.T) {
+	t.Run("Plain top output is parsed as processes", func(t *testing.T) {
+		data, err := ParseTTop(testutil.SampleFiles["top"].Content)
+		require.NoError(t, err)
+		require.Len(t, data.Snapshots, 1)
+		assert.True(t, data.Processes)
+
+		snapshot := data.Snapshots[0]
+		require.NotNil(t, snapshot.ThreadCounts)
+		assert.Equal(t, 215, snapshot.ThreadCounts.Total)
+		assert.Equal(t, 214, snapshot.ThreadCounts.Sleeping)
+
+		// KiB summary lines are converted to MiB
+		require.NotNil(t, snapshot.SystemMemory)
+		assert.InDelta(t, 16008.078, snapshot.SystemMemory.MemTotal, 0.001)
+		assert.InDelta(t, 3155.523, snapshot.SystemMemory.MemBuffCache, 0.001)
+		assert.InDelta(t, 11568.551, snapshot.SystemMemory.MemAvail, 0.001)
+
+		require.Len(t, snapshot.Threads, 2)
+		assert.Equal(t, ThreadInfo{PID: 1234, User: "dremio", CPU: 45.0, MEM: 21.7, VIRT: 12.1 * (1 << 30), RES: 3.4 * (1 << 30), SHR: 51200 * 1024, Command: "java"}, snapshot.Threads[0])
+	})
+
+	t.Run("ttop output is parsed as threads", func(t *testing.T) {
+		data, err := ParseTTop([]byte("top - 12:02:03 up  3:07,  0 users,  load average: 3.18, 1.16, 0.41\n" +
+			"Threads: 262 total,   6 running, 256 sleeping,   0 stopped,   0 zombie\n"))
+		require.NoError(t, err)
+		require.Len(t, data.Snapshots, 1)
+		assert.False(t, data.Processes)
+		assert.Equal(t, 262, data.Snapshots[0].ThreadCounts.Total)
+	})
+
+	t.Run("Columns follow the header", func(t *testing.T) {
+		data, err := ParseTTop([]byte("top - 12:02:03 up  3:07,  0 users,  load average: 3.18, 1.16, 0.41\n" +
+			"Tasks: 2 total,   1 running,   1 sleeping,   0 stopped,   0 zombie\n" +
+			"  PID  %CPU  %MEM USER     RES COMMAND\n" +
+			" 1234  45.0  21.7 dremio  3.4g java -Xmx8g\n"))
+		require.NoError(t, err)
+		require.Len(t, data.Snapshots, 1)
+		require.Len(t, data.Snapshots[0].Threads, 1)
+		assert.Equal(t, ThreadInfo{PID: 1234, User: "dremio", CPU: 45.0, MEM: 21.7, RES: 3.4 * (1 << 30), Command: "java -Xmx8g"}, data.Snapshots[0].Threads[0])
+	})
+
+	t.Run("Reordered columns without %CPU", func(t *testing.T) {
+		data, err := ParseTTop([]byte("top - 12:02:03 up  3:07,  0 users,  load average: 3.18, 1.16, 0.41\n" +
+			"Threads: 2 total,   1 running,   1 sleeping,   0 stopped,   0 zombie\n" +
+			"USER       PID    VIRT    RES    SHR S  %MEM     TIME+ COMMAND\n" +
+			"dremio     997 7009048   3.4g  98412 R  21.9   1:36.52 C2 CompilerThre\n" +
+			"dremio    1042 7009048 120000  98412 S   0.7   0:01.02 qtp-41\n" +
+			"top - 12:02:06 up  3:07,  0 users,  load average: 3.18, 1.16, 0.41\n" +
+			"USER       PID    VIRT    RES    SHR S  %MEM     TIME+ COMMAND\n" +
+			"dremio     997 7009048   3.5g  98412 R  22.4   1:39.52 C2 CompilerThre\n"))
+		require.NoError(t, err)
+		require.Len(t, data.Snapshots, 2)
+		require.Len(t, data.Snapshots[0].Threads, 2)
+		assert.Equal(t, ThreadInfo{PID: 997, User: "dremio", CPU: 0, MEM: 21.9, VIRT: 7009048 * 1024, RES: 3.4 * (1 << 30), SHR: 98412 * 1024, Command: "C2 CompilerThre"}, data.Snapshots[0].Threads[0])
+		assert.Equal(t, 1042, data.Snapshots[0].Threads[1].PID)
+		assert.Equal(t, 0.7, data.Snapshots[0].Threads[1].MEM)
+		require.Len(t, data.Snapshots[1].Threads, 1)
+		assert.Equal(t, 22.4, data.Snapshots[1].Threads[0].MEM)
+		assert.Equal(t, 0.0, data.Snapshots[1].Threads[0].CPU, "The missing %CPU doesn't take a neighbour's value")
+	})
+
+	t.Run("Headers without the required columns keep the default layout", func(t *testing.T) {
+		columns, ok := parseTTopHeader("PID USER COMMAND")
+		assert.True(t, ok, "%CPU is optional")
+		assert.Equal(t, -1, columns.cpu)
+		_, ok = parseTTopHeader("PID %CPU COMMAND USER")
+		assert.False(t, ok)
+		_, ok = parseTTopHeader("USER %CPU %MEM COMMAND")
+		assert.False(t, ok)
+	})
 }
 
 func TestParseTimestampFromTopLine(t *testing.T) {
 	t.Run("Valid timestamp parsing", func(t *testing.T) {
 		line := "top - 12:02:03 up  3:07,  0 users,  load average: 3.18, 1.16, 0.41"
-		timestamp, err := parseTimestampFromTopLine(line)
+		timestamp, err := parseTimestampFromTopLine(line, time.UTC)
 		require.NoError(t, err)
 
 		assert.Equal(t, 12, timestamp.Hour())
 		assert.Equal(t, 2, timestamp.Minute())
 		assert.Equal(t, 3, timestamp.Second())
+		assert.Equal(t, time.UTC, timestamp.Location())
+	})
+
+	t.Run("Timestamp in a configured zone", func(t *testing.T) {
+		loc, err := time.LoadLocation("Asia/Tokyo")
+		require.NoError(t, err)
+
+		timestamp, err := parseTimestampFromTopLine("top - 12:02:03 up  3:07,  0 users,  load average: 3.18, 1.16, 0.41", loc)
+		require.NoError(t, err)
+		assert.Equal(t, loc, timestamp.Location())
+		assert.Equal(t, 12, timestamp.Hour())
 	})
 
 	t.Run("Invalid timestamp format", func(t *testing.T) {
 		line := "top - invalid"
-		_, err := parseTimestampFromTopLine(line)
+		_, err := parseTimestampFromTopLine(line, time.UTC)
 		require.Error(t, err)
 	})
 
 	t.Run("Missing timestamp", func(t *testing.T) {
 		line := "top -"
-		_, err := parseTimestampFromTopLine(line)
+		_, err := parseTimestampFromTopLine(line, time.UTC)
 		require.Error(t, err)
 	})
 }
@@ -220,6 +350,9 @@ func TestParseThreadLine(t *testing.T) {
 		assert.Equal(t, "dremio", thread.User)
 		assert.Equal(t, 87.5, thread.CPU)
 		assert.Equal(t, 21.9, thread.MEM)
+		assert.Equal(t, 7009048.0*1024, thread.VIRT)
+		assert.InDelta(t, 3.4*1024*1024*1024, thread.RES, 1)
+		assert.Equal(t, 98412.0*1024, thread.SHR)
 		assert.Equal(t, "C2 CompilerThre", thread.Command)
 	})
 
@@ -232,6 +365,7 @@ func TestParseThreadLine(t *testing.T) {
 		assert.Equal(t, "root", thread.User)
 		assert.Equal(t, 25.5, thread.CPU)
 		assert.Equal(t, 10.2, thread.MEM)
+		assert.Equal(t, 12345.0*1024, thread.RES, "Bare sizes are KiB")
 		assert.Equal(t, "java -jar application.jar", thread.Command)
 	})
 
@@ -261,6 +395,38 @@ func TestParseThreadLine(t *testing.T) {
 	})
 }
 
+func TestParseTTopSize(t *testing.T) {
+	t.Run("Suffixed and bare sizes", func(t *testing.T) {
+		tests := []struct {
+			input    string
+			expected float64
+		}{
+			{"3.4g", 3.4 * 1024 * 1024 * 1024},
+			{"512m", 512 * 1024 * 1024},
+			{"1024", 1024 * 1024}, // Bare numbers are KiB
+			{"98412", 98412 * 1024},
+			{"64k", 64 * 1024},
+			{"1.5t", 1.5 * 1024 * 1024 * 1024 * 1024},
+			{"2G", 2 * 1024 * 1024 * 1024},
+			{"0", 0},
+		}
+		for _, tt := range tests {
+			t.Run(tt.input, func(t *testing.T) {
+				size, err := parseTTopSize(tt.input)
+				require.NoError(t, err)
+				assert.InDelta(t, tt.expected, size, 1)
+			})
+		}
+	})
+
+	t.Run("Malformed sizes", func(t *testing.T) {
+		for _, input := range []string{"", "g", "abc", "3.4x", "-5", "1.2.3", "1e3", "NaN", "Inf", "0x10", "1_000"} {
+			_, err := parseTTopSize(input)
+			assert.Error(t, err, "input %q", input)
+		}
+	})
+}
+
 func TestTTopReportDataStructure(t *testing.T) {
 	t.Run("Data structure creation and access", func(t *testing.T) {
 		// Create test data
@@ -344,6 +510,17 @@ func TestParseMemoryLine(t *testing.T) {
 		assert.Equal(t, 1341.1, memory.MemBuffCache)
 	})
 
+	t.Run("Parse memory line in GiB", func(t *testing.T) {
+		line := "GiB Mem :     15.6 total,     10.7 free,      3.6 used,      1.3 buff/cache"
+		memory := &SystemMemory{}
+
+		err := parseMemoryLine(line, memory)
+		require.NoError(t, err)
+
+		assert.InDelta(t, 15974.4, memory.MemTotal, 0.001)
+		assert.InDelta(t, 1331.2, memory.MemBuffCache, 0.001)
+	})
+
 	t.Run("Parse malformed memory line", func(t *testing.T) {
 		line := "MiB Mem : invalid format"
 		memory := &SystemMemory{}
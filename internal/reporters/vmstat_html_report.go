@@ -0,0 +1,284 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporters
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+)
+
+// vmStatSeries is one echarts line series of a vmstat chart
+type vmStatSeries struct {
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	Stack     string    `json:"stack,omitempty"`
+	AreaStyle *struct{} `json:"areaStyle,omitempty"` // Set to fill the area of stacked series
+	Data      []float64 `json:"data"`
+}
+
+// vmStatChartData is the data of every chart of a vmstat report, embedded in the page
+type vmStatChartData struct {
+	Labels          []string       `json:"labels"`
+	RunQueue        []vmStatSeries `json:"run_queue"`
+	ContextSwitches []vmStatSeries `json:"context_switches"`
+	CPU             []vmStatSeries `json:"cpu"`
+	Swap            []vmStatSeries `json:"swap"`
+}
+
+// GenerateVMStatHTML generates an HTML report with four charts:
+// 1. Run Queue Over Time
+// 2. Context Switches and Interrupts Over Time
+// 3. CPU Breakdown Over Time
+// 4. Swap Activity Over Time
+// The charts hold at most maxPoints points, 0 for every snapshot, with adjacent snapshots
+// averaged into each point; the summary cards are always computed from every snapshot.
+// The report is styled with the named report theme, falling back to the default theme
+func GenerateVMStatHTML(data *VMStatReportData, maxPoints int, themeName string) (string, error) {
+	if data == nil || len(data.Snapshots) == 0 {
+		return generateEmptyVMStatHTML(), nil
+	}
+
+	theme := lookupReportTheme(themeName)
+
+	chartData := downsampleVMStatData(data, maxPoints)
+	chartJSON, err := json.Marshal(extractVMStatChartData(chartData, downsampleBucketStarts(len(data.Snapshots), maxPoints)))
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal chart data: %w", err)
+	}
+
+	// Captures without timestamps can't be annotated, so they list no chart times
+	var chartTimes []time.Time
+	axisName := "Sample"
+	if data.Timestamped {
+		for _, snapshot := range chartData.Snapshots {
+			chartTimes = append(chartTimes, snapshot.Timestamp)
+		}
+		axisName = timeAxisName(data.Snapshots[0].Timestamp)
+	}
+	chartTimesData, err := chartTimesScript(chartTimes)
+	if err != nil {
+		return "", err
+	}
+
+	html := fmt.Sprintf(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>VMStat Analysis Report</title>
+    <script src="/static/js/echarts.min.js"></script>
+    %s
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>VMStat Analysis Report</h1>
+            <p>Run Queue, CPU and Swap Analysis</p>
+        </div>
+
+        <div class="stats-grid">
+            <div class="stat-card">
+                <div class="stat-value">%d</div>
+                <div class="stat-label">Snapshots</div>
+            </div>
+            <div class="stat-card">
+                <div class="stat-value">%.0f</div>
+                <div class="stat-label">Peak Run Queue</div>
+            </div>
+            <div class="stat-card">
+                <div class="stat-value">%.0f</div>
+                <div class="stat-label">Peak Context Switches/s</div>
+            </div>
+            <div class="stat-card">
+                <div class="stat-value">%d</div>
+                <div class="stat-label">Snapshots Swapping</div>
+            </div>
+        </div>
+
+        <div class="chart-container">
+            <div class="chart-title">Run Queue Over Time</div>
+            <div id="runQueueChart" class="chart"></div>
+        </div>
+
+        <div class="chart-container">
+            <div class="chart-title">Context Switches and Interrupts Over Time</div>
+            <div id="contextSwitchChart" class="chart"></div>
+        </div>
+
+        <div class="chart-container">
+            <div class="chart-title">CPU Breakdown Over Time</div>
+            <div id="cpuChart" class="chart"></div>
+        </div>
+
+        <div class="chart-container">
+            <div class="chart-title">Swap Activity Over Time</div>
+            <div id="swapChart" class="chart"></div>
+        </div>
+    </div>
+
+    <script type="application/json" id="vmstatChartData">%s</script>
+    %s
+    %s
+
+    <script>
+        try {
+            const chartData = JSON.parse(document.getElementById('vmstatChartData').textContent);
+            const axisName = '%s';
+
+            function initChart(id, yAxis, series) {
+                const chart = echarts.init(document.getElementById(id), 'ddd');
+                chart.setOption({
+                    tooltip: { trigger: 'axis' },
+                    legend: { data: series.map(function(s) { return s.name; }) },
+                    grid: { left: '3%%', right: '4%%', bottom: 60, containLabel: true },
+                    dataZoom: [
+                        { type: 'slider', show: true, xAxisIndex: [0], start: 0, end: 100 },
+                        { type: 'inside', xAxisIndex: [0], start: 0, end: 100 }
+                    ],
+                    xAxis: { type: 'category', name: axisName, nameLocation: 'middle', nameGap: 30, boundaryGap: false, data: chartData.labels },
+                    yAxis: Object.assign({ type: 'value', min: 0 }, yAxis),
+                    series: series
+                });
+                dddAnnotate(chart);
+                return chart;
+            }
+
+            const charts = [
+                initChart('runQueueChart', { name: 'Processes' }, chartData.run_queue),
+                initChart('contextSwitchChart', { name: 'Per Second' }, chartData.context_switches),
+                initChart('cpuChart', { name: 'CPU %%', max: 100 }, chartData.cpu),
+                initChart('swapChart', { name: 'Per Second' }, chartData.swap)
+            ];
+
+            // Handle window resize
+            window.addEventListener('resize', function() {
+                charts.forEach(function(chart) {
+                    chart.resize();
+                });
+            });
+
+        } catch (error) {
+            console.error('Error initializing charts:', error);
+            document.body.innerHTML += '<div style="color: red; padding: 20px; background: #ffe6e6; border: 1px solid red; margin: 20px;">Error initializing charts: ' + error.message + '</div>';
+        }
+    </script>
+</body>
+</html>`,
+		theme.headHTML(),
+		len(data.Snapshots),
+		findPeakRunQueue(data),
+		findPeakContextSwitches(data),
+		countSwappingSnapshots(data),
+		chartJSON,
+		chartTimesData,
+		reportAnnotationsScript,
+		axisName)
+
+	return html, nil
+}
+
+// generateEmptyVMStatHTML returns HTML for when no data is available
+func generateEmptyVMStatHTML() string {
+	return `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>VMStat Analysis Report</title>
+</head>
+<body>
+    <h1>VMStat Analysis Report</h1>
+    <p>No data available for analysis.</p>
+</body>
+</html>`
+}
+
+// extractVMStatChartData builds the chart series of data, whose points start at the
+// snapshots numbered by starts. Points are labelled with their time, or with the number of
+// their first sample when the capture has no timestamps
+func extractVMStatChartData(data *VMStatReportData, starts []int) vmStatChartData {
+	labels := make([]string, len(data.Snapshots))
+	for i, snapshot := range data.Snapshots {
+		if data.Timestamped {
+			labels[i] = snapshot.Timestamp.Format("15:04:05")
+		} else {
+			labels[i] = fmt.Sprintf("#%d", starts[i]+1)
+		}
+	}
+
+	series := func(name, stack string, value func(VMStatSnapshot) float64) vmStatSeries {
+		s := vmStatSeries{Name: name, Type: "line", Stack: stack, Data: make([]float64, len(data.Snapshots))}
+		if stack != "" {
+			s.AreaStyle = &struct{}{}
+		}
+		for i, snapshot := range data.Snapshots {
+			s.Data[i] = math.Round(value(snapshot)*100) / 100
+		}
+		return s
+	}
+
+	return vmStatChartData{
+		Labels: labels,
+		RunQueue: []vmStatSeries{
+			series("Runnable (r)", "", func(s VMStatSnapshot) float64 { return s.Runnable }),
+			series("Blocked (b)", "", func(s VMStatSnapshot) float64 { return s.Blocked }),
+		},
+		ContextSwitches: []vmStatSeries{
+			series("Context Switches (cs)", "", func(s VMStatSnapshot) float64 { return s.ContextSwitchesPerS }),
+			series("Interrupts (in)", "", func(s VMStatSnapshot) float64 { return s.InterruptsPerS }),
+		},
+		CPU: []vmStatSeries{
+			series("User (us)", "cpu", func(s VMStatSnapshot) float64 { return s.User }),
+			series("System (sy)", "cpu", func(s VMStatSnapshot) float64 { return s.System }),
+			series("IOWait (wa)", "cpu", func(s VMStatSnapshot) float64 { return s.IOWait }),
+			series("Steal (st)", "cpu", func(s VMStatSnapshot) float64 { return s.Steal }),
+		},
+		Swap: []vmStatSeries{
+			series("Swap In (si)", "", func(s VMStatSnapshot) float64 { return s.SwapInPerS }),
+			series("Swap Out (so)", "", func(s VMStatSnapshot) float64 { return s.SwapOutPerS }),
+		},
+	}
+}
+
+// findPeakRunQueue finds the largest number of runnable processes in any snapshot
+func findPeakRunQueue(data *VMStatReportData) float64 {
+	peak := 0.0
+	for _, snapshot := range data.Snapshots {
+		peak = math.Max(peak, snapshot.Runnable)
+	}
+	return peak
+}
+
+// findPeakContextSwitches finds the highest context switch rate in any snapshot
+func findPeakContextSwitches(data *VMStatReportData) float64 {
+	peak := 0.0
+	for _, snapshot := range data.Snapshots {
+		peak = math.Max(peak, snapshot.ContextSwitchesPerS)
+	}
+	return peak
+}
+
+// countSwappingSnapshots counts the snapshots that swapped memory in or out
+func countSwappingSnapshots(data *VMStatReportData) int {
+	count := 0
+	for _, snapshot := range data.Snapshots {
+		if snapshot.SwapInPerS > 0 || snapshot.SwapOutPerS > 0 {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,86 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporters
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateVMStatHTML(t *testing.T) {
+	start := time.Date(2024, 9, 4, 12, 7, 21, 0, time.UTC)
+	data := &VMStatReportData{
+		Timestamped: true,
+		Snapshots: []VMStatSnapshot{
+			{Timestamp: start, Runnable: 3, Blocked: 1, ContextSwitchesPerS: 2410, User: 22, System: 5, Idle: 70, IOWait: 3},
+			{Timestamp: start.Add(time.Second), Runnable: 9, ContextSwitchesPerS: 1875, SwapInPerS: 4, SwapOutPerS: 16, User: 62, System: 8, Idle: 29, IOWait: 1},
+		},
+	}
+
+	t.Run("Charts every series with summary cards", func(t *testing.T) {
+		html, err := GenerateVMStatHTML(data, 0, DefaultReportTheme)
+		require.NoError(t, err)
+
+		assert.Contains(t, html, "VMStat Analysis Report")
+		for _, id := range []string{"runQueueChart", "contextSwitchChart", "cpuChart", "swapChart"} {
+			assert.Contains(t, html, `id="`+id+`"`)
+		}
+		assert.Contains(t, html, `<div class="stat-value">9</div>
+                <div class="stat-label">Peak Run Queue</div>`)
+		assert.Contains(t, html, `<div class="stat-value">2410</div>
+                <div class="stat-label">Peak Context Switches/s</div>`)
+		assert.Contains(t, html, `<div class="stat-value">1</div>
+                <div class="stat-label">Snapshots Swapping</div>`)
+
+		assert.Contains(t, html, `"labels":["12:07:21","12:07:22"]`)
+		assert.Contains(t, html, `{"name":"Runnable (r)","type":"line","data":[3,9]}`)
+		assert.Contains(t, html, `{"name":"User (us)","type":"line","stack":"cpu","areaStyle":{},"data":[22,62]}`)
+		assert.Contains(t, html, `{"name":"Swap Out (so)","type":"line","data":[0,16]}`)
+		assert.Contains(t, html, `const axisName = 'Time (UTC)';`)
+		assert.Contains(t, html, `<script type="application/json" id="ddd-chart-times">["2024-09-04T12:07:21Z","2024-09-04T12:07:22Z"]</script>`)
+		assert.Contains(t, html, "dddAnnotate(chart);")
+		assert.NotContains(t, html, "%!")
+	})
+
+	t.Run("Averages snapshots into at most maxPoints points", func(t *testing.T) {
+		html, err := GenerateVMStatHTML(data, 1, DefaultReportTheme)
+		require.NoError(t, err)
+
+		assert.Contains(t, html, `"labels":["12:07:21"]`)
+		assert.Contains(t, html, `{"name":"Runnable (r)","type":"line","data":[6]}`)
+		// The cards still come from every snapshot
+		assert.Contains(t, html, `<div class="stat-value">9</div>`)
+	})
+
+	t.Run("Captures without timestamps are labelled by sample", func(t *testing.T) {
+		untimed := &VMStatReportData{Snapshots: []VMStatSnapshot{{Runnable: 1}, {Runnable: 2}, {Runnable: 3}}}
+
+		html, err := GenerateVMStatHTML(untimed, 2, DefaultReportTheme)
+		require.NoError(t, err)
+
+		assert.Contains(t, html, `"labels":["#1","#2"]`)
+		assert.Contains(t, html, `const axisName = 'Sample';`)
+		assert.Contains(t, html, `<script type="application/json" id="ddd-chart-times">[]</script>`)
+	})
+
+	t.Run("No data", func(t *testing.T) {
+		html, err := GenerateVMStatHTML(&VMStatReportData{}, 0, DefaultReportTheme)
+		require.NoError(t, err)
+		assert.Contains(t, html, "No data available for analysis.")
+	})
+}
@@ -0,0 +1,188 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporters
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// VMStatSnapshot represents one sample of vmstat output. Memory is in the unit of the
+// capture, KiB unless vmstat was run with -S
+type VMStatSnapshot struct {
+	Timestamp           time.Time `json:"timestamp"`              // When the sample was taken, zero when the capture has no timestamps
+	Runnable            float64   `json:"runnable"`               // r - processes running or waiting for a CPU
+	Blocked             float64   `json:"blocked"`                // b - processes in uninterruptible sleep
+	SwapUsed            float64   `json:"swap_used"`              // swpd - virtual memory used
+	Free                float64   `json:"free"`                   // free - idle memory
+	Buffers             float64   `json:"buffers"`                // buff - memory used as buffers
+	Cache               float64   `json:"cache"`                  // cache - memory used as cache
+	SwapInPerS          float64   `json:"swap_in_per_s"`          // si - memory swapped in from disk per second
+	SwapOutPerS         float64   `json:"swap_out_per_s"`         // so - memory swapped out to disk per second
+	BlocksInPerS        float64   `json:"blocks_in_per_s"`        // bi - blocks received from block devices per second
+	BlocksOutPerS       float64   `json:"blocks_out_per_s"`       // bo - blocks sent to block devices per second
+	InterruptsPerS      float64   `json:"interrupts_per_s"`       // in - interrupts per second
+	ContextSwitchesPerS float64   `json:"context_switches_per_s"` // cs - context switches per second
+	User                float64   `json:"user"`                   // us - % CPU running user code
+	System              float64   `json:"system"`                 // sy - % CPU running kernel code
+	Idle                float64   `json:"idle"`                   // id - % CPU idle
+	IOWait              float64   `json:"iowait"`                 // wa - % CPU waiting for I/O
+	Steal               float64   `json:"steal"`                  // st - % CPU stolen by the hypervisor
+	Guest               float64   `json:"guest"`                  // gu - % CPU running guests, printed by procps-ng 4 and later
+}
+
+// VMStatReportData represents the complete parsed vmstat report data
+type VMStatReportData struct {
+	Timestamped bool             `json:"timestamped"` // Captured with vmstat -t, so every snapshot has a time
+	Snapshots   []VMStatSnapshot `json:"snapshots"`   // Every sample after the averages since boot
+}
+
+// vmStatFields maps vmstat header columns to the VMStatSnapshot field they fill. Columns
+// without a field here, such as the inact and active memory of vmstat -a, are ignored
+var vmStatFields = map[string]func(*VMStatSnapshot, float64){
+	"r":     func(s *VMStatSnapshot, v float64) { s.Runnable = v },
+	"b":     func(s *VMStatSnapshot, v float64) { s.Blocked = v },
+	"swpd":  func(s *VMStatSnapshot, v float64) { s.SwapUsed = v },
+	"free":  func(s *VMStatSnapshot, v float64) { s.Free = v },
+	"buff":  func(s *VMStatSnapshot, v float64) { s.Buffers = v },
+	"cache": func(s *VMStatSnapshot, v float64) { s.Cache = v },
+	"si":    func(s *VMStatSnapshot, v float64) { s.SwapInPerS = v },
+	"so":    func(s *VMStatSnapshot, v float64) { s.SwapOutPerS = v },
+	"bi":    func(s *VMStatSnapshot, v float64) { s.BlocksInPerS = v },
+	"bo":    func(s *VMStatSnapshot, v float64) { s.BlocksOutPerS = v },
+	"in":    func(s *VMStatSnapshot, v float64) { s.InterruptsPerS = v },
+	"cs":    func(s *VMStatSnapshot, v float64) { s.ContextSwitchesPerS = v },
+	"us":    func(s *VMStatSnapshot, v float64) { s.User = v },
+	"sy":    func(s *VMStatSnapshot, v float64) { s.System = v },
+	"id":    func(s *VMStatSnapshot, v float64) { s.Idle = v },
+	"wa":    func(s *VMStatSnapshot, v float64) { s.IOWait = v },
+	"st":    func(s *VMStatSnapshot, v float64) { s.Steal = v },
+	"gu":    func(s *VMStatSnapshot, v float64) { s.Guest = v },
+}
+
+// vmStatIgnoredColumns are the vmstat columns without a VMStatSnapshot field
+var vmStatIgnoredColumns = map[string]bool{"inact": true, "active": true}
+
+// ParseVMStat parses vmstat output content, treating the capture's timestamps as UTC
+func ParseVMStat(content []byte) (*VMStatReportData, error) {
+	return ParseVMStatReader(bytes.NewReader(content), time.UTC)
+}
+
+// ParseVMStatReader parses vmstat output line by line from r. vmstat repeats its two header
+// lines every screenful, and the first sample after the first header averages everything
+// since boot rather than the interval, so it is skipped. Captures taken with vmstat -t end
+// each sample with a date and time, in the zone named at the end of the header; only UTC
+// can be resolved from the name, so any other zone is interpreted in loc
+func ParseVMStatReader(r io.Reader, loc *time.Location) (*VMStatReportData, error) {
+	scanner := newLineScanner(r)
+	snapshots := []VMStatSnapshot{}
+	var columns []string
+	var timestamped, skipSinceBoot bool
+	sampleLoc := loc
+	var lineNumber int
+
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+
+		// Skip empty lines and the column group line above each header
+		if line == "" || strings.HasPrefix(line, "procs") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) > 1 && fields[0] == "r" && fields[1] == "b" {
+			skipSinceBoot = columns == nil
+			columns, timestamped = parseVMStatHeader(fields)
+			sampleLoc = loc
+			if timestamped && fields[len(fields)-1] == "UTC" {
+				sampleLoc = time.UTC
+			}
+			continue
+		}
+
+		if columns == nil {
+			return nil, fmt.Errorf("line %d: expected the vmstat header before the first sample", lineNumber)
+		}
+
+		snapshot, err := parseVMStatSample(fields, columns, timestamped, sampleLoc)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		if skipSinceBoot {
+			skipSinceBoot = false
+			continue
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading vmstat content: %w", err)
+	}
+
+	return &VMStatReportData{
+		Timestamped: timestamped,
+		Snapshots:   snapshots,
+	}, nil
+}
+
+// parseVMStatHeader returns the sample columns of a vmstat header line, and whether samples
+// end with a timestamp. vmstat -t names the timestamp's zone at the end of the header, which
+// is the one column that isn't a vmstat column
+func parseVMStatHeader(fields []string) ([]string, bool) {
+	last := fields[len(fields)-1]
+	if _, ok := vmStatFields[last]; ok || vmStatIgnoredColumns[last] {
+		return fields, false
+	}
+	return fields[:len(fields)-1], true
+}
+
+// parseVMStatSample parses one sample line of vmstat output with the given header columns
+func parseVMStatSample(fields, columns []string, timestamped bool, loc *time.Location) (VMStatSnapshot, error) {
+	var snapshot VMStatSnapshot
+
+	expected := len(columns)
+	if timestamped {
+		expected += 2
+	}
+	if len(fields) != expected {
+		return snapshot, fmt.Errorf("expected %d vmstat fields, got %d", expected, len(fields))
+	}
+
+	for i, column := range columns {
+		value, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return snapshot, fmt.Errorf("failed to parse %s: %w", column, err)
+		}
+		if set, ok := vmStatFields[column]; ok {
+			set(&snapshot, value)
+		}
+	}
+
+	if timestamped {
+		dateTimeStr := fields[len(columns)] + " " + fields[len(columns)+1]
+		timestamp, err := time.ParseInLocation("2006-01-02 15:04:05", dateTimeStr, loc)
+		if err != nil {
+			return snapshot, fmt.Errorf("failed to parse timestamp %s: %w", dateTimeStr, err)
+		}
+		snapshot.Timestamp = timestamp
+	}
+
+	return snapshot, nil
+}
@@ -0,0 +1,122 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporters
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rsvihladremio/ddd/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVMStat(t *testing.T) {
+	t.Run("Parse vmstat -t output", func(t *testing.T) {
+		data, err := ParseVMStat(testutil.SampleFiles["vmstat"].Content)
+		require.NoError(t, err)
+
+		assert.True(t, data.Timestamped)
+		// The first sample averages everything since boot, so it is skipped
+		require.Len(t, data.Snapshots, 2)
+
+		snapshot := data.Snapshots[0]
+		assert.Equal(t, time.Date(2024, 9, 4, 12, 7, 21, 0, time.UTC), snapshot.Timestamp)
+		assert.Equal(t, 3.0, snapshot.Runnable)
+		assert.Equal(t, 1.0, snapshot.Blocked)
+		assert.Equal(t, 0.0, snapshot.SwapUsed)
+		assert.Equal(t, 810112.0, snapshot.Free)
+		assert.Equal(t, 94512.0, snapshot.Buffers)
+		assert.Equal(t, 2151020.0, snapshot.Cache)
+		assert.Equal(t, 148.0, snapshot.BlocksOutPerS)
+		assert.Equal(t, 1190.0, snapshot.InterruptsPerS)
+		assert.Equal(t, 2410.0, snapshot.ContextSwitchesPerS)
+		assert.Equal(t, 22.0, snapshot.User)
+		assert.Equal(t, 5.0, snapshot.System)
+		assert.Equal(t, 70.0, snapshot.Idle)
+		assert.Equal(t, 3.0, snapshot.IOWait)
+
+		assert.Equal(t, 4.0, data.Snapshots[1].SwapInPerS)
+		assert.Equal(t, 16.0, data.Snapshots[1].SwapOutPerS)
+	})
+
+	t.Run("Samples without timestamps and repeated headers", func(t *testing.T) {
+		content := `procs -----------memory---------- ---swap-- -----io---- -system-- -------cpu-------
+ r  b   swpd   free   buff  cache   si   so    bi    bo   in   cs us sy id wa st gu
+ 1  0      0 812344  94512 2150876    0    0    12    30  210  380  6  2 91  1  0  0
+ 2  0      0 810112  94512 2151020    0    0     0   148 1190 2410 22  5 70  3  0  0
+procs -----------memory---------- ---swap-- -----io---- -system-- -------cpu-------
+ r  b   swpd   free   buff  cache   si   so    bi    bo   in   cs us sy id wa st gu
+ 4  0      0 809876  94520 2151100    0    0     0    64  980 1875 12  3 84  1  0  0
+`
+		data, err := ParseVMStat([]byte(content))
+		require.NoError(t, err)
+
+		assert.False(t, data.Timestamped)
+		// Only the first header is followed by the averages since boot
+		require.Len(t, data.Snapshots, 2)
+		assert.True(t, data.Snapshots[0].Timestamp.IsZero())
+		assert.Equal(t, 2.0, data.Snapshots[0].Runnable)
+		assert.Equal(t, 4.0, data.Snapshots[1].Runnable)
+	})
+
+	t.Run("Timestamps in another zone are read in the report's location", func(t *testing.T) {
+		loc, err := time.LoadLocation("America/New_York")
+		require.NoError(t, err)
+		content := strings.ReplaceAll(string(testutil.SampleFiles["vmstat"].Content), "UTC", "EDT")
+
+		data, err := ParseVMStatReader(strings.NewReader(content), loc)
+		require.NoError(t, err)
+		require.Len(t, data.Snapshots, 2)
+		assert.Equal(t, time.Date(2024, 9, 4, 12, 7, 21, 0, loc), data.Snapshots[0].Timestamp)
+	})
+
+	t.Run("vmstat -a memory columns are ignored", func(t *testing.T) {
+		content := ` r  b   swpd   free  inact active   si   so    bi    bo   in   cs us sy id wa st
+ 1  0      0 812344 500000 900000    0    0    12    30  210  380  6  2 91  1  0
+ 2  0      0 810112 500000 900000    0    0     0   148 1190 2410 22  5 70  3  0
+`
+		data, err := ParseVMStat([]byte(content))
+		require.NoError(t, err)
+
+		assert.False(t, data.Timestamped)
+		require.Len(t, data.Snapshots, 1)
+		assert.Equal(t, 810112.0, data.Snapshots[0].Free)
+		assert.Equal(t, 0.0, data.Snapshots[0].Cache)
+		assert.Equal(t, 2410.0, data.Snapshots[0].ContextSwitchesPerS)
+	})
+
+	t.Run("Samples before the header are an error", func(t *testing.T) {
+		_, err := ParseVMStat([]byte(" 1  0      0 812344  94512 2150876    0    0    12    30  210  380  6  2 91  1  0\n"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "line 1: expected the vmstat header")
+	})
+
+	t.Run("Samples with the wrong number of fields are an error", func(t *testing.T) {
+		content := ` r  b   swpd   free   buff  cache   si   so    bi    bo   in   cs us sy id wa st
+ 1  0      0 812344  94512 2150876    0    0    12    30  210  380  6  2 91
+`
+		_, err := ParseVMStat([]byte(content))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "line 2: expected 17 vmstat fields, got 15")
+	})
+
+	t.Run("Empty content", func(t *testing.T) {
+		data, err := ParseVMStat([]byte(""))
+		require.NoError(t, err)
+		assert.Empty(t, data.Snapshots)
+	})
+}
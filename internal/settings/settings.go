@@ -0,0 +1,276 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package settings reads the settings stored in the database as typed values, applying
+// the same defaults and validation wherever they are used
+package settings
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rsvihladremio/ddd/internal/config"
+	"github.com/rsvihladremio/ddd/internal/database"
+	"github.com/rsvihladremio/ddd/internal/reporters"
+)
+
+// Setting keys
+const (
+	KeyMaxDiskUsage              = "max_disk_usage"
+	KeyFileRetentionDays         = "file_retention_days"
+	KeyReportRetentionDays       = "report_retention_days"
+	KeyReportDataRetentionDays   = "report_data_retention_days"
+	KeyReportPollIntervalSeconds = "report_poll_interval_seconds"
+	KeyCleanupIntervalSeconds    = "cleanup_interval_seconds"
+	KeyIOStatUtilThreshold       = "iostat_util_threshold"
+	KeyIOStatAwaitThresholdMs    = "iostat_await_threshold_ms"
+	KeyIOStatPartitions          = "iostat_partitions"
+	KeyTTopTopThreads            = "ttop_top_threads"
+	KeyReadOnly                  = "read_only"
+	KeyAllowedFileTypes          = "allowed_file_types"
+	KeyHashAlgorithm             = "hash_algorithm"
+	KeyReportTheme               = "report_theme"
+	KeyTimezone                  = "timezone"
+)
+
+// FileRetentionByTypePrefix starts the keys of the per file type overrides of file_retention_days
+const FileRetentionByTypePrefix = KeyFileRetentionDays + "."
+
+// Intervals used when their setting is missing or invalid
+const (
+	DefaultReportPollInterval = 10 * time.Second
+	DefaultCleanupInterval    = time.Hour
+)
+
+// Settings reads typed settings from the database, falling back to the config or the
+// reporter defaults when a setting has not been saved
+type Settings struct {
+	db  *database.DB
+	cfg *config.Config
+}
+
+// New creates a Settings reading from db, with cfg supplying the defaults of settings
+// that have a configuration option
+func New(db *database.DB, cfg *config.Config) *Settings {
+	return &Settings{db: db, cfg: cfg}
+}
+
+// ReadOnly reports whether DDD was started with -read-only or the read_only setting is on
+func (s *Settings) ReadOnly() bool {
+	if s.cfg.ReadOnly {
+		return true
+	}
+	value, err := s.db.GetSetting(KeyReadOnly)
+	return err == nil && value == "true"
+}
+
+// MaxDiskUsage returns the fraction of the uploads disk to use before the oldest files
+// are cleaned up
+func (s *Settings) MaxDiskUsage() (float64, error) {
+	value, err := s.db.GetSetting(KeyMaxDiskUsage)
+	if err != nil {
+		// Fall back to config if setting not found
+		return s.cfg.MaxDiskUsage, nil
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+// FileRetentionDays returns the days uploaded files are kept
+func (s *Settings) FileRetentionDays() (int, error) {
+	return s.intOrConfig(KeyFileRetentionDays, s.cfg.FileRetentionDays)
+}
+
+// FileRetentionDaysByType returns the file_retention_days.{type} settings that override
+// the file retention for a file type
+func (s *Settings) FileRetentionDaysByType() (map[string]int, error) {
+	stored, err := s.db.GetAllSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	overrides := make(map[string]int)
+	for key, value := range stored {
+		fileType, ok := strings.CutPrefix(key, FileRetentionByTypePrefix)
+		if !ok {
+			continue
+		}
+		days, err := strconv.Atoi(value)
+		if err != nil {
+			log.Printf("Ignoring invalid %s setting %q: %v", key, value, err)
+			continue
+		}
+		overrides[fileType] = days
+	}
+	return overrides, nil
+}
+
+// ReportRetentionDays returns the days report data is kept after its file is deleted
+func (s *Settings) ReportRetentionDays() (int, error) {
+	return s.intOrConfig(KeyReportRetentionDays, s.cfg.ReportRetentionDays)
+}
+
+// ReportDataRetentionDays returns the days reports keep their charts and raw data
+func (s *Settings) ReportDataRetentionDays() (int, error) {
+	return s.intOrConfig(KeyReportDataRetentionDays, s.cfg.ReportDataRetentionDays)
+}
+
+// intOrConfig parses an integer setting, returning fallback when it has not been saved
+func (s *Settings) intOrConfig(key string, fallback int) (int, error) {
+	value, err := s.db.GetSetting(key)
+	if err != nil {
+		// Fall back to config if setting not found
+		return fallback, nil
+	}
+	return strconv.Atoi(value)
+}
+
+// ReportPollInterval returns how often pending reports are looked for
+func (s *Settings) ReportPollInterval() time.Duration {
+	return s.interval(KeyReportPollIntervalSeconds, DefaultReportPollInterval)
+}
+
+// CleanupInterval returns how often scheduled cleanups run
+func (s *Settings) CleanupInterval() time.Duration {
+	return s.interval(KeyCleanupIntervalSeconds, DefaultCleanupInterval)
+}
+
+// interval parses a setting holding a number of seconds, returning fallback when it is
+// missing or below one second
+func (s *Settings) interval(key string, fallback time.Duration) time.Duration {
+	value, err := s.db.GetSetting(key)
+	if err != nil {
+		return fallback
+	}
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 1 {
+		return fallback
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// IOStatThresholds returns the iostat finding thresholds, falling back to the defaults
+// for any setting that is missing or invalid
+func (s *Settings) IOStatThresholds() reporters.IOStatThresholds {
+	thresholds := reporters.DefaultIOStatThresholds()
+	thresholds.UtilizationPct = s.float(KeyIOStatUtilThreshold, thresholds.UtilizationPct)
+	thresholds.AwaitMs = s.float(KeyIOStatAwaitThresholdMs, thresholds.AwaitMs)
+	return thresholds
+}
+
+// float parses a float setting, returning fallback when it is missing or invalid
+func (s *Settings) float(key string, fallback float64) float64 {
+	value, err := s.db.GetSetting(key)
+	if err != nil {
+		return fallback
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("Invalid %s setting %q: %v", key, value, err)
+		return fallback
+	}
+	return parsed
+}
+
+// IOStatPartitions returns what iostat reports do with partition rows, falling back to
+// keeping them when the setting is missing or unknown
+func (s *Settings) IOStatPartitions() string {
+	value, err := s.db.GetSetting(KeyIOStatPartitions)
+	if err != nil || value == "" {
+		return reporters.IOStatPartitionsKeep
+	}
+	if !slices.Contains(reporters.IOStatPartitionModes(), value) {
+		log.Printf("Unknown %s setting %q, using %s", KeyIOStatPartitions, value, reporters.IOStatPartitionsKeep)
+		return reporters.IOStatPartitionsKeep
+	}
+	return value
+}
+
+// ReportTheme returns the HTML report theme, falling back to the default theme when the
+// setting is missing or unknown
+func (s *Settings) ReportTheme() string {
+	value, err := s.db.GetSetting(KeyReportTheme)
+	if err != nil || value == "" {
+		return reporters.DefaultReportTheme
+	}
+	if !reporters.IsKnownReportTheme(value) {
+		log.Printf("Unknown %s setting %q, using %s", KeyReportTheme, value, reporters.DefaultReportTheme)
+		return reporters.DefaultReportTheme
+	}
+	return value
+}
+
+// TTopTopThreads returns how many of the busiest threads ttop and top reports chart,
+// falling back to the default when the setting is missing or invalid
+func (s *Settings) TTopTopThreads() int {
+	value, err := s.db.GetSetting(KeyTTopTopThreads)
+	if err != nil || value == "" {
+		return reporters.DefaultTTopTopThreads
+	}
+	topThreads, err := strconv.Atoi(value)
+	if err != nil || topThreads < 1 {
+		log.Printf("Invalid %s setting %q, using %d", KeyTTopTopThreads, value, reporters.DefaultTTopTopThreads)
+		return reporters.DefaultTTopTopThreads
+	}
+	return reporters.ClampTTopTopThreads(topThreads)
+}
+
+// Location returns the time zone captured timestamps are interpreted in, the configured
+// time zone when the timezone setting has not been saved
+func (s *Settings) Location() (*time.Location, error) {
+	value, err := s.db.GetSetting(KeyTimezone)
+	if err != nil {
+		return s.cfg.Location()
+	}
+	loc, err := time.LoadLocation(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be an IANA zone name such as UTC or America/New_York, got %q", KeyTimezone, value)
+	}
+	return loc, nil
+}
+
+// AllowedFileTypes returns the file types uploads are accepted for, empty when every
+// type is accepted
+func (s *Settings) AllowedFileTypes() []string {
+	value, err := s.db.GetSetting(KeyAllowedFileTypes)
+	if err != nil {
+		return nil
+	}
+	return SplitList(value)
+}
+
+// HashAlgorithm returns the stored hash_algorithm, empty when it has not been saved. The
+// caller checks it names a supported algorithm
+func (s *Settings) HashAlgorithm() string {
+	value, err := s.db.GetSetting(KeyHashAlgorithm)
+	if err != nil {
+		return ""
+	}
+	return value
+}
+
+// SplitList splits a comma separated list setting into its trimmed, distinct items
+func SplitList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" && !slices.Contains(items, item) {
+			items = append(items, item)
+		}
+	}
+	return items
+}
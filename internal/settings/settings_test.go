@@ -0,0 +1,224 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package settings
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rsvihladremio/ddd/internal/database"
+	"github.com/rsvihladremio/ddd/internal/reporters"
+	"github.com/rsvihladremio/ddd/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testDB creates a test database with clean schema
+func testDB(t *testing.T) *database.DB {
+	t.Helper()
+
+	cfg := testutil.TestConfig(t)
+	db, err := database.Initialize(cfg.DBPath)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("Error closing database: %v", err)
+		}
+	})
+
+	return db
+}
+
+func TestSettings_ReadOnly(t *testing.T) {
+	db := testDB(t)
+	cfg := testutil.TestConfig(t)
+	s := New(db, cfg)
+
+	assert.False(t, s.ReadOnly())
+
+	require.NoError(t, db.SetSetting(KeyReadOnly, "true"))
+	assert.True(t, s.ReadOnly())
+
+	require.NoError(t, db.SetSetting(KeyReadOnly, "false"))
+	cfg.ReadOnly = true
+	assert.True(t, s.ReadOnly(), "the -read-only flag wins over the setting")
+}
+
+func TestSettings_RetentionDays(t *testing.T) {
+	db := testDB(t)
+	cfg := testutil.TestConfig(t)
+	s := New(db, cfg)
+
+	t.Run("Config when the setting is missing", func(t *testing.T) {
+		days, err := s.FileRetentionDays()
+		require.NoError(t, err)
+		assert.Equal(t, cfg.FileRetentionDays, days)
+	})
+
+	t.Run("Days read from settings", func(t *testing.T) {
+		require.NoError(t, db.SetSetting(KeyFileRetentionDays, "12"))
+		days, err := s.FileRetentionDays()
+		require.NoError(t, err)
+		assert.Equal(t, 12, days)
+	})
+
+	t.Run("Overrides by file type", func(t *testing.T) {
+		require.NoError(t, db.SetSetting(FileRetentionByTypePrefix+"jfr", "60"))
+		require.NoError(t, db.SetSetting(FileRetentionByTypePrefix+"iostat", "soon"))
+		overrides, err := s.FileRetentionDaysByType()
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int{"jfr": 60}, overrides)
+	})
+}
+
+func TestSettings_Intervals(t *testing.T) {
+	db := testDB(t)
+	s := New(db, testutil.TestConfig(t))
+
+	assert.Equal(t, DefaultReportPollInterval, s.ReportPollInterval())
+	assert.Equal(t, DefaultCleanupInterval, s.CleanupInterval())
+
+	require.NoError(t, db.SetSetting(KeyReportPollIntervalSeconds, "30"))
+	require.NoError(t, db.SetSetting(KeyCleanupIntervalSeconds, "0"))
+	assert.Equal(t, 30*time.Second, s.ReportPollInterval())
+	assert.Equal(t, DefaultCleanupInterval, s.CleanupInterval())
+}
+
+func TestSettings_IOStatThresholds(t *testing.T) {
+	db := testDB(t)
+	cfg := testutil.TestConfig(t)
+	s := New(db, cfg)
+
+	t.Run("Defaults when settings are missing", func(t *testing.T) {
+		thresholds := s.IOStatThresholds()
+		assert.Equal(t, reporters.DefaultIOStatThresholds(), thresholds)
+	})
+
+	t.Run("Thresholds read from settings", func(t *testing.T) {
+		require.NoError(t, db.SetSetting("iostat_util_threshold", "70"))
+		require.NoError(t, db.SetSetting("iostat_await_threshold_ms", "25.5"))
+
+		thresholds := s.IOStatThresholds()
+		assert.Equal(t, 70.0, thresholds.UtilizationPct)
+		assert.Equal(t, 25.5, thresholds.AwaitMs)
+	})
+
+	t.Run("Invalid setting falls back to default", func(t *testing.T) {
+		require.NoError(t, db.SetSetting("iostat_util_threshold", "not-a-number"))
+
+		thresholds := s.IOStatThresholds()
+		assert.Equal(t, reporters.DefaultIOStatThresholds().UtilizationPct, thresholds.UtilizationPct)
+		assert.Equal(t, 25.5, thresholds.AwaitMs)
+	})
+}
+
+func TestSettings_ReportTheme(t *testing.T) {
+	db := testDB(t)
+	cfg := testutil.TestConfig(t)
+	s := New(db, cfg)
+
+	t.Run("Default when the setting is missing", func(t *testing.T) {
+		assert.Equal(t, reporters.DefaultReportTheme, s.ReportTheme())
+	})
+
+	t.Run("Theme read from settings", func(t *testing.T) {
+		require.NoError(t, db.SetSetting("report_theme", "dark"))
+		assert.Equal(t, "dark", s.ReportTheme())
+	})
+
+	t.Run("Unknown theme falls back to default", func(t *testing.T) {
+		require.NoError(t, db.SetSetting("report_theme", "neon"))
+		assert.Equal(t, reporters.DefaultReportTheme, s.ReportTheme())
+	})
+}
+
+func TestSettings_IOStatPartitions(t *testing.T) {
+	db := testDB(t)
+	cfg := testutil.TestConfig(t)
+	s := New(db, cfg)
+
+	t.Run("Default when the setting is missing", func(t *testing.T) {
+		assert.Equal(t, reporters.IOStatPartitionsKeep, s.IOStatPartitions())
+	})
+
+	t.Run("Mode read from settings", func(t *testing.T) {
+		require.NoError(t, db.SetSetting("iostat_partitions", reporters.IOStatPartitionsRollup))
+		assert.Equal(t, reporters.IOStatPartitionsRollup, s.IOStatPartitions())
+	})
+
+	t.Run("Unknown mode falls back to default", func(t *testing.T) {
+		require.NoError(t, db.SetSetting("iostat_partitions", "merge"))
+		assert.Equal(t, reporters.IOStatPartitionsKeep, s.IOStatPartitions())
+	})
+}
+
+func TestSettings_TTopTopThreads(t *testing.T) {
+	db := testDB(t)
+	cfg := testutil.TestConfig(t)
+	s := New(db, cfg)
+
+	t.Run("Default when the setting is missing", func(t *testing.T) {
+		assert.Equal(t, reporters.DefaultTTopTopThreads, s.TTopTopThreads())
+	})
+
+	t.Run("Count read from settings", func(t *testing.T) {
+		require.NoError(t, db.SetSetting("ttop_top_threads", "15"))
+		assert.Equal(t, 15, s.TTopTopThreads())
+	})
+
+	t.Run("Counts past the maximum are clamped", func(t *testing.T) {
+		require.NoError(t, db.SetSetting("ttop_top_threads", "5000"))
+		assert.Equal(t, reporters.MaxTTopTopThreads, s.TTopTopThreads())
+	})
+
+	t.Run("Invalid setting falls back to default", func(t *testing.T) {
+		for _, invalid := range []string{"0", "-3", "lots"} {
+			require.NoError(t, db.SetSetting("ttop_top_threads", invalid))
+			assert.Equal(t, reporters.DefaultTTopTopThreads, s.TTopTopThreads(), invalid)
+		}
+	})
+}
+
+func TestSettings_Location(t *testing.T) {
+	db := testDB(t)
+	cfg := testutil.TestConfig(t)
+	cfg.Timezone = "Asia/Tokyo"
+	s := New(db, cfg)
+
+	t.Run("Config when the setting is missing", func(t *testing.T) {
+		loc, err := s.Location()
+		require.NoError(t, err)
+		assert.Equal(t, "Asia/Tokyo", loc.String())
+	})
+
+	t.Run("Zone read from settings", func(t *testing.T) {
+		require.NoError(t, db.SetSetting(KeyTimezone, "America/New_York"))
+		loc, err := s.Location()
+		require.NoError(t, err)
+		assert.Equal(t, "America/New_York", loc.String())
+	})
+
+	t.Run("Unknown zone is an error", func(t *testing.T) {
+		require.NoError(t, db.SetSetting(KeyTimezone, "Mars/Olympus_Mons"))
+		_, err := s.Location()
+		assert.ErrorContains(t, err, "timezone must be an IANA zone name")
+	})
+}
+
+func TestSplitList(t *testing.T) {
+	assert.Equal(t, []string{}, SplitList(""))
+	assert.Equal(t, []string{"jfr", "ttop"}, SplitList(" jfr, ,ttop,jfr "))
+}
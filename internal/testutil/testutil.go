@@ -59,6 +59,8 @@ func TestConfig(t *testing.T) *config.Config {
 		UploadsDir:        uploadsDir,
 		MaxDiskUsage:      0.8,
 		FileRetentionDays: 7,
+		BackupDir:         filepath.Join(tempDir, "backups"),
+		BackupRetention:   3,
 	}
 }
 
@@ -91,6 +93,20 @@ var SampleFiles = map[string]TestFile{
 		Content:  []byte("PID USER TIME %CPU COMMAND\n1234 root 10:30 25.5 java -jar app.jar\n5678 user 10:31 15.2 python script.py\n"),
 		FileType: "ttop",
 	},
+	"top": {
+		Name: "top.txt",
+		Content: []byte(`top - 12:02:03 up  3:07,  0 users,  load average: 3.18, 1.16, 0.41
+Tasks: 215 total,   1 running, 214 sleeping,   0 stopped,   0 zombie
+%Cpu(s):  5.1 us,  1.2 sy,  0.0 ni, 93.5 id,  0.1 wa,  0.0 hi,  0.1 si,  0.0 st
+KiB Mem : 16392272 total,  8973452 free,  4187564 used,  3231256 buff/cache
+KiB Swap:        0 total,        0 free,        0 used. 11846196 avail Mem
+
+  PID USER      PR  NI    VIRT    RES    SHR S  %CPU  %MEM     TIME+ COMMAND
+ 1234 dremio    20   0   12.1g   3.4g  51200 S  45.0  21.7  10:30.12 java
+ 5678 root      20   0  162148   4304   3520 R   0.7   0.0   0:00.03 top
+`),
+		FileType: "top",
+	},
 	"iostat": {
 		Name: "iostat.txt",
 		Content: []byte(`Linux 5.10.0-32-cloud-amd64 (test-system) 	09/04/24 	_x86_64_	(4 CPU)
@@ -110,11 +126,125 @@ Device            r/s     rkB/s   rrqm/s  %rrqm r_await rareq-sz     w/s     wkB
 sda              0.00      0.00     0.00   0.00    0.00     0.00  395.00  38116.00   133.00  25.19    8.65    96.50    1.00      4.00     0.00   0.00    1.00     4.00  122.00    0.06    3.42  39.20`),
 		FileType: "iostat",
 	},
+	"vmstat": {
+		Name: "vmstat.txt",
+		Content: []byte(`procs -----------memory---------- ---swap-- -----io---- -system-- ------cpu----- -----timestamp-----
+ r  b   swpd   free   buff  cache   si   so    bi    bo   in   cs us sy id wa st                 UTC
+ 2  0      0 812344  94512 2150876    0    0    12    30  210  380  6  2 91  1  0 2024-09-04 12:07:20
+ 3  1      0 810112  94512 2151020    0    0     0   148 1190 2410 22  5 70  3  0 2024-09-04 12:07:21
+ 9  0   1024 809876  94520 2151100    4   16     0    64  980 1875 62  8 29  1  0 2024-09-04 12:07:22
+`),
+		FileType: "vmstat",
+	},
 	"queries_json": {
-		Name:     "queries.json",
-		Content:  []byte(`{"queries": [{"id": "123", "sql": "SELECT * FROM table", "duration": 1000}]}`),
+		Name: "queries.json",
+		Content: []byte(`{"queryId":"1a2b3c4d-0001","queryText":"SELECT * FROM sales","start":1725451640000,"finish":1725451641200,"outcome":"COMPLETED","outcomeReason":"","username":"alice","queueName":"Low Cost User Queries","memoryAllocated":8388608}
+{"queryId":"1a2b3c4d-0002","queryText":"SELECT region, SUM(amount) FROM sales GROUP BY region","start":1725451650000,"finish":1725451695000,"outcome":"COMPLETED","outcomeReason":"","username":"bob","queueName":"High Cost User Queries","memoryAllocated":536870912}
+{"queryId":"1a2b3c4d-0003","queryText":"SELECT * FROM missing","start":1725451700000,"finish":1725451700150,"outcome":"FAILED","outcomeReason":"VALIDATION ERROR: Object 'missing' not found\nSQL Query SELECT * FROM missing","username":"alice","queueName":"Low Cost User Queries","memoryAllocated":0}
+{"queryId":"1a2b3c4d-0004","queryText":"SELECT COUNT(*) FROM sales","start":1725451710000,"finish":1725451712500,"outcome":"CANCELED","outcomeReason":"Query cancelled by user","username":"carol","queueName":"Low Cost User Queries","memoryAllocated":1048576}
+`),
 		FileType: "queries_json",
 	},
+	"dremio_profile": {
+		Name: "profile_attempt_0.json",
+		Content: []byte(`{
+  "id": {"part1": 2077149301186069504, "part2": 6953018618429890560},
+  "query": "SELECT * FROM sales WHERE region = 'EMEA'",
+  "user": "dremio",
+  "state": 2,
+  "start": 1725451640000,
+  "end": 1725451642500,
+  "planningStart": 1725451640010,
+  "planningEnd": 1725451640310,
+  "dremioVersion": "25.0.0",
+  "planPhases": [
+    {"phaseName": "Validation", "durationMillis": 20},
+    {"phaseName": "Logical Planning", "durationMillis": 180},
+    {"phaseName": "Physical Planning", "durationMillis": 100}
+  ],
+  "nonDefaultOptionsJSON": "[{\"kind\":\"LONG\",\"type\":\"SYSTEM\",\"name\":\"planner.slice_target\",\"num_val\":1000},{\"kind\":\"BOOLEAN\",\"type\":\"SESSION\",\"name\":\"exec.queue.enable\",\"bool_val\":false}]",
+  "jsonPlan": "{\"00-00\": {\"op\": \"com.dremio.exec.planner.physical.ScreenPrel\"}, \"00-01\": {\"op\": \"com.dremio.exec.planner.physical.FilterPrel\"}}",
+  "fragmentProfile": [
+    {
+      "majorFragmentId": 0,
+      "minorFragmentProfile": [
+        {
+          "minorFragmentId": 0,
+          "operatorProfile": [
+            {"operatorId": 0, "operatorType": 13, "setupNanos": 1000000, "processNanos": 2000000, "waitNanos": 0, "peakLocalMemoryAllocated": 1048576, "inputProfile": [{"records": 10, "batches": 1}]},
+            {"operatorId": 1, "operatorType": 14, "setupNanos": 500000, "processNanos": 300000000, "waitNanos": 100000000, "peakLocalMemoryAllocated": 4194304, "inputProfile": [{"records": 1000, "batches": 2}]}
+          ]
+        },
+        {
+          "minorFragmentId": 1,
+          "operatorProfile": [
+            {"operatorId": 1, "operatorType": 14, "setupNanos": 500000, "processNanos": 200000000, "waitNanos": 50000000, "peakLocalMemoryAllocated": 8388608, "inputProfile": [{"records": 500, "batches": 1}]}
+          ]
+        }
+      ]
+    }
+  ]
+}`),
+		FileType: "dremio_profile",
+	},
+	"thread_dump": {
+		Name: "jstack.txt",
+		Content: []byte(`2024-09-04 12:07:20
+Full thread dump OpenJDK 64-Bit Server VM (17.0.9+9 mixed mode, sharing):
+
+"main" #1 prio=5 os_prio=0 cpu=512.10ms elapsed=120.50s tid=0x00007f1c00028000 nid=0x1 waiting on condition  [0x00007f1c05f1e000]
+   java.lang.Thread.State: TIMED_WAITING (sleeping)
+	at java.lang.Thread.sleep(java.base@17.0.9/Native Method)
+	at com.example.Main.main(Main.java:10)
+
+"worker-1" #20 daemon prio=5 os_prio=0 cpu=10.00ms elapsed=100.00s tid=0x00007f1c00100000 nid=0x20 runnable  [0x00007f1bd0a00000]
+   java.lang.Thread.State: RUNNABLE
+	at com.example.Cache.load(Cache.java:42)
+	- locked <0x000000008c0a1a30> (a java.lang.Object)
+	at com.example.Worker.run(Worker.java:20)
+
+"worker-2" #21 daemon prio=5 os_prio=0 cpu=5.00ms elapsed=100.00s tid=0x00007f1c00101000 nid=0x21 waiting for monitor entry  [0x00007f1bd0900000]
+   java.lang.Thread.State: BLOCKED (on object monitor)
+	at com.example.Cache.load(Cache.java:40)
+	- waiting to lock <0x000000008c0a1a30> (a java.lang.Object)
+	at com.example.Worker.run(Worker.java:20)
+
+"worker-3" #22 daemon prio=5 os_prio=0 cpu=5.00ms elapsed=100.00s tid=0x00007f1c00102000 nid=0x22 waiting for monitor entry  [0x00007f1bd0800000]
+   java.lang.Thread.State: BLOCKED (on object monitor)
+	at com.example.Cache.load(Cache.java:40)
+	- waiting to lock <0x000000008c0a1a30> (a java.lang.Object)
+	at com.example.Worker.run(Worker.java:20)
+
+"VM Thread" os_prio=0 cpu=20.00ms elapsed=120.00s tid=0x00007f1c00090000 nid=0x10 runnable
+
+JNI global refs: 15, weak refs: 0
+`),
+		FileType: "thread_dump",
+	},
+	"dremio_config": {
+		Name: "dremio.conf",
+		Content: []byte(`paths: {
+  # the local path for dremio to store data.
+  local: ${DREMIO_HOME}"/data"
+
+  # the distributed path Dremio data including job results, downloads, uploads, etc
+  dist: "pdfs://"${paths.local}"/pdfs"
+
+  spilling: []
+}
+
+services: {
+  coordinator.enabled: true,
+  coordinator.master.enabled: true,
+  executor.enabled: true,
+  coordinator.web.ssl: { enabled: false }
+}
+
+debug.enabled: true
+zookeeper: "zk1:2181,zk2:2181"
+`),
+		FileType: "dremio_config",
+	},
 	"unknown": {
 		Name:     "unknown.txt",
 		Content:  []byte("This is an unknown file type"),
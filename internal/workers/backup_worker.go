@@ -0,0 +1,65 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workers
+
+import (
+	"log"
+	"time"
+
+	"github.com/rsvihladremio/ddd/internal/config"
+	"github.com/rsvihladremio/ddd/internal/database"
+)
+
+// BackupWorker backs up the database on the configured schedule
+type BackupWorker struct {
+	db  *database.DB
+	cfg *config.Config
+}
+
+// NewBackupWorker creates a new backup worker
+func NewBackupWorker(db *database.DB, cfg *config.Config) *BackupWorker {
+	return &BackupWorker{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// Start begins the backup worker loop. It returns immediately when scheduled backups
+// are turned off, leaving backups to POST /api/admin/backup
+func (w *BackupWorker) Start() {
+	interval := w.cfg.BackupInterval()
+	if interval <= 0 {
+		log.Println("Scheduled database backups are disabled")
+		return
+	}
+	log.Printf("Starting backup worker, backing up to %s every %s", w.cfg.BackupDir, interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		w.performBackup()
+	}
+}
+
+// performBackup writes a backup and prunes the old ones
+func (w *BackupWorker) performBackup() {
+	backup, err := w.db.Backup(w.cfg.BackupDir, w.cfg.BackupRetention)
+	if err != nil {
+		log.Printf("Error backing up database: %v", err)
+		return
+	}
+	log.Printf("Backed up database to %s (%d bytes)", backup.Path, backup.Size)
+}
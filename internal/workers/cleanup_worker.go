@@ -15,57 +15,52 @@
 package workers
 
 import (
+	"fmt"
 	"log"
 	"os"
-	"strconv"
+	"slices"
 	"syscall"
 	"time"
 
 	"github.com/rsvihladremio/ddd/internal/config"
 	"github.com/rsvihladremio/ddd/internal/database"
+	"github.com/rsvihladremio/ddd/internal/settings"
 )
 
 // CleanupWorker handles background file cleanup
 type CleanupWorker struct {
 	db          *database.DB
 	cfg         *config.Config
+	settings    *settings.Settings
 	triggerChan chan struct{}
+
+	interval  time.Duration // How often cleanup runs
+	resetChan chan struct{} // Signals that cleanup_interval_seconds may have changed
 }
 
 // NewCleanupWorker creates a new cleanup worker
 func NewCleanupWorker(db *database.DB, cfg *config.Config) *CleanupWorker {
-	return &CleanupWorker{
+	w := &CleanupWorker{
 		db:          db,
 		cfg:         cfg,
+		settings:    settings.New(db, cfg),
 		triggerChan: make(chan struct{}, 1), // Buffered channel to avoid blocking
+		resetChan:   make(chan struct{}, 1),
 	}
-}
-
-// getMaxDiskUsage retrieves max disk usage setting from database
-func (w *CleanupWorker) getMaxDiskUsage() (float64, error) {
-	value, err := w.db.GetSetting("max_disk_usage")
-	if err != nil {
-		// Fall back to config if setting not found
-		return w.cfg.MaxDiskUsage, nil
-	}
-	return strconv.ParseFloat(value, 64)
-}
-
-// getFileRetentionDays retrieves file retention days setting from database
-func (w *CleanupWorker) getFileRetentionDays() (int, error) {
-	value, err := w.db.GetSetting("file_retention_days")
-	if err != nil {
-		// Fall back to config if setting not found
-		return w.cfg.FileRetentionDays, nil
-	}
-	return strconv.Atoi(value)
+	w.interval = w.settings.CleanupInterval()
+	return w
 }
 
 // Start begins the cleanup worker loop
 func (w *CleanupWorker) Start() {
 	log.Println("Starting cleanup worker...")
+	w.run(nil)
+}
 
-	ticker := time.NewTicker(1 * time.Hour) // Check every hour
+// run performs cleanup every interval, and whenever triggered, until stop is closed. A nil
+// stop runs forever
+func (w *CleanupWorker) run(stop <-chan struct{}) {
+	ticker := time.NewTicker(w.interval)
 	defer ticker.Stop()
 
 	for {
@@ -75,10 +70,28 @@ func (w *CleanupWorker) Start() {
 		case <-w.triggerChan:
 			log.Println("Cleanup triggered by settings change")
 			w.performCleanup()
+		case <-w.resetChan:
+			if interval := w.settings.CleanupInterval(); interval != w.interval {
+				log.Printf("Cleanup interval changed from %s to %s", w.interval, interval)
+				w.interval = interval
+				ticker.Reset(interval)
+			}
+		case <-stop:
+			return
 		}
 	}
 }
 
+// ResetInterval makes the worker re-read cleanup_interval_seconds, so a changed interval
+// applies without waiting out the current one (non-blocking)
+func (w *CleanupWorker) ResetInterval() {
+	select {
+	case w.resetChan <- struct{}{}:
+	default:
+		// A reset is already pending
+	}
+}
+
 // TriggerCleanup triggers an immediate cleanup (non-blocking)
 func (w *CleanupWorker) TriggerCleanup() {
 	select {
@@ -91,6 +104,10 @@ func (w *CleanupWorker) TriggerCleanup() {
 
 // performCleanup performs file cleanup based on configured policies
 func (w *CleanupWorker) performCleanup() {
+	if w.settings.ReadOnly() {
+		log.Println("Skipping file cleanup in read-only mode")
+		return
+	}
 	log.Println("Starting file cleanup process...")
 
 	// Get disk usage
@@ -103,13 +120,13 @@ func (w *CleanupWorker) performCleanup() {
 	log.Printf("Current disk usage: %.2f%%", diskUsage*100)
 
 	// Get settings from database
-	maxDiskUsage, err := w.getMaxDiskUsage()
+	maxDiskUsage, err := w.settings.MaxDiskUsage()
 	if err != nil {
 		log.Printf("Error getting max disk usage setting: %v", err)
 		maxDiskUsage = w.cfg.MaxDiskUsage // fallback
 	}
 
-	fileRetentionDays, err := w.getFileRetentionDays()
+	fileRetentionDays, err := w.settings.FileRetentionDays()
 	if err != nil {
 		log.Printf("Error getting file retention days setting: %v", err)
 		fileRetentionDays = w.cfg.FileRetentionDays // fallback
@@ -190,17 +207,24 @@ func (w *CleanupWorker) performCleanup() {
 
 	// Clean up deleted file entries that have no reports
 	w.cleanupOrphanedFileEntries()
+	w.purgeDeletedFileReports()
+	w.trimExpiredReportData()
 }
 
 // cleanupOldFiles performs cleanup of old files based on retention policy
 func (w *CleanupWorker) cleanupOldFiles() {
 	// Get file retention days from database
-	fileRetentionDays, err := w.getFileRetentionDays()
+	fileRetentionDays, err := w.settings.FileRetentionDays()
 	if err != nil {
 		log.Printf("Error getting file retention days setting: %v", err)
 		fileRetentionDays = w.cfg.FileRetentionDays // fallback
 	}
 
+	retentionByType, err := w.settings.FileRetentionDaysByType()
+	if err != nil {
+		log.Printf("Error getting per type file retention settings: %v", err)
+	}
+
 	cutoffTime := time.Now().Add(-time.Duration(fileRetentionDays) * 24 * time.Hour)
 
 	files, err := w.getFilesForCleanup(cutoffTime, false)
@@ -209,6 +233,24 @@ func (w *CleanupWorker) cleanupOldFiles() {
 		return
 	}
 
+	// Types with their own retention are left to it, whether it is longer or shorter
+	files = slices.DeleteFunc(files, func(file *database.File) bool {
+		_, overridden := retentionByType[file.FileType]
+		return overridden
+	})
+	for fileType, days := range retentionByType {
+		typeCutoff := time.Now().Add(-time.Duration(days) * 24 * time.Hour)
+		typeFiles, err := w.db.GetFilesOlderThanByType(fileType, typeCutoff)
+		if err != nil {
+			log.Printf("Error getting %s files for cleanup: %v", fileType, err)
+			continue
+		}
+		if len(typeFiles) > 0 {
+			log.Printf("Found %d %s files older than their %d day retention", len(typeFiles), fileType, days)
+		}
+		files = append(files, typeFiles...)
+	}
+
 	for _, file := range files {
 		if err := w.deleteFile(file); err != nil {
 			log.Printf("Error deleting file %s: %v", file.FilePath, err)
@@ -217,6 +259,90 @@ func (w *CleanupWorker) cleanupOldFiles() {
 
 	// Clean up deleted file entries that have no reports
 	w.cleanupOrphanedFileEntries()
+	w.purgeDeletedFileReports()
+	w.trimExpiredReportData()
+}
+
+// purgeDeletedFileReports drops the data of reports whose file was deleted longer ago than
+// the report retention, keeping their rows as tombstones. A retention of 0 keeps report
+// data forever
+func (w *CleanupWorker) purgeDeletedFileReports() {
+	reportRetentionDays, err := w.settings.ReportRetentionDays()
+	if err != nil {
+		log.Printf("Error getting report retention days setting: %v", err)
+		reportRetentionDays = w.cfg.ReportRetentionDays // fallback
+	}
+	if reportRetentionDays <= 0 {
+		return
+	}
+
+	cutoffTime := time.Now().Add(-time.Duration(reportRetentionDays) * 24 * time.Hour)
+	reports, err := w.db.GetReportsForPurge(cutoffTime)
+	if err != nil {
+		log.Printf("Error getting reports for purge: %v", err)
+		return
+	}
+
+	purged := 0
+	for _, report := range reports {
+		if err := w.db.PurgeReport(report.ID); err != nil {
+			log.Printf("Error purging report %d: %v", report.ID, err)
+			continue
+		}
+		if err := w.db.InsertReportLog(&database.ReportLog{
+			ReportID:  report.ID,
+			Timestamp: time.Now(),
+			Level:     "INFO",
+			Message:   fmt.Sprintf("Report data purged %d days after its file was deleted", reportRetentionDays),
+		}); err != nil {
+			log.Printf("Error logging purge of report %d: %v", report.ID, err)
+		}
+		purged++
+	}
+	if purged > 0 {
+		log.Printf("Purged the data of %d reports whose files were deleted more than %d days ago", purged, reportRetentionDays)
+	}
+}
+
+// trimExpiredReportData drops the charts and raw data of reports older than the report data
+// retention, keeping their summary, analysis and findings. A retention of 0 keeps report
+// data forever
+func (w *CleanupWorker) trimExpiredReportData() {
+	retentionDays, err := w.settings.ReportDataRetentionDays()
+	if err != nil {
+		log.Printf("Error getting report data retention days setting: %v", err)
+		retentionDays = w.cfg.ReportDataRetentionDays // fallback
+	}
+	if retentionDays <= 0 {
+		return
+	}
+
+	cutoffTime := time.Now().Add(-time.Duration(retentionDays) * 24 * time.Hour)
+	reports, err := w.db.GetReportsForTrim(cutoffTime)
+	if err != nil {
+		log.Printf("Error getting reports for trim: %v", err)
+		return
+	}
+
+	trimmed := 0
+	for _, report := range reports {
+		if err := w.db.TrimReportData(report.ID); err != nil {
+			log.Printf("Error trimming report %d: %v", report.ID, err)
+			continue
+		}
+		if err := w.db.InsertReportLog(&database.ReportLog{
+			ReportID:  report.ID,
+			Timestamp: time.Now(),
+			Level:     "INFO",
+			Message:   fmt.Sprintf("Report charts and raw data removed after %d days, the summary and analysis were kept", retentionDays),
+		}); err != nil {
+			log.Printf("Error logging trim of report %d: %v", report.ID, err)
+		}
+		trimmed++
+	}
+	if trimmed > 0 {
+		log.Printf("Trimmed the data of %d reports older than %d days", trimmed, retentionDays)
+	}
 }
 
 // getDiskUsage calculates current disk usage percentage
@@ -258,12 +384,12 @@ func (w *CleanupWorker) deleteFile(file *database.File) error {
 	return w.db.MarkFileDeleted(file.ID)
 }
 
-// getOldestFiles retrieves the oldest files from the database
+// getOldestFiles retrieves the oldest unpinned files from the database
 func (w *CleanupWorker) getOldestFiles(limit int) ([]*database.File, error) {
 	query := `
 		SELECT id, original_name, file_path, hash, file_type, file_size, upload_time, deleted
 		FROM files
-		WHERE deleted = 0
+		WHERE deleted = 0 AND pinned = 0
 		ORDER BY upload_time ASC
 		LIMIT ?
 	`
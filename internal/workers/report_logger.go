@@ -0,0 +1,68 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workers
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/rsvihladremio/ddd/internal/database"
+	"github.com/rsvihladremio/ddd/internal/events"
+	"github.com/rsvihladremio/ddd/internal/reporters"
+)
+
+// dbReportLogger writes report generation logs to stdout and to the report_logs table,
+// and publishes each line as a progress event
+type dbReportLogger struct {
+	db       *database.DB
+	events   *events.Broker
+	reportID int
+}
+
+// newReportLogger creates a logger tagging every line with the given report ID
+func newReportLogger(db *database.DB, broker *events.Broker, reportID int) *dbReportLogger {
+	return &dbReportLogger{db: db, events: broker, reportID: reportID}
+}
+
+func (l *dbReportLogger) Infof(format string, args ...any) {
+	l.write(reporters.LogLevelInfo, format, args...)
+}
+
+func (l *dbReportLogger) Warnf(format string, args ...any) {
+	l.write(reporters.LogLevelWarn, format, args...)
+}
+
+func (l *dbReportLogger) Errorf(format string, args ...any) {
+	l.write(reporters.LogLevelError, format, args...)
+}
+
+// write logs the line to stdout and stores it; storage failures are only logged so
+// they never fail the report itself
+func (l *dbReportLogger) write(level, format string, args ...any) {
+	message := fmt.Sprintf(format, args...)
+	log.Printf("[report %d] %s %s", l.reportID, level, message)
+
+	entry := &database.ReportLog{
+		ReportID:  l.reportID,
+		Timestamp: time.Now(),
+		Level:     level,
+		Message:   message,
+	}
+	if err := l.db.InsertReportLog(entry); err != nil {
+		log.Printf("Error storing log for report %d: %v", l.reportID, err)
+	}
+	l.events.PublishProgress(l.reportID, level, message)
+}
@@ -15,51 +15,156 @@
 package workers
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/rsvihladremio/ddd/internal/config"
 	"github.com/rsvihladremio/ddd/internal/database"
+	"github.com/rsvihladremio/ddd/internal/events"
+	"github.com/rsvihladremio/ddd/internal/metrics"
 	"github.com/rsvihladremio/ddd/internal/reporters"
+	"github.com/rsvihladremio/ddd/internal/settings"
 )
 
+// cancelledMessage is the error message stored on reports cancelled while generating
+const cancelledMessage = "Cancelled while generating"
+
 // ReportWorker handles background report generation
 type ReportWorker struct {
-	db  *database.DB
-	cfg *config.Config
+	db       *database.DB
+	cfg      *config.Config
+	settings *settings.Settings
+	events   *events.Broker
+
+	mu      sync.Mutex
+	cancels map[int]context.CancelFunc // Cancels the generation of each running report
+
+	pollInterval time.Duration // How often pending reports are looked for
+	resetChan    chan struct{} // Signals that report_poll_interval_seconds may have changed
+}
+
+// NewReportWorker creates a new report worker that announces status changes and
+// progress on broker, which may be nil when nobody listens for live updates
+func NewReportWorker(db *database.DB, cfg *config.Config, broker *events.Broker) *ReportWorker {
+	w := &ReportWorker{
+		db:        db,
+		cfg:       cfg,
+		settings:  settings.New(db, cfg),
+		events:    broker,
+		cancels:   make(map[int]context.CancelFunc),
+		resetChan: make(chan struct{}, 1), // Buffered channel to avoid blocking
+	}
+	w.pollInterval = w.settings.ReportPollInterval()
+	return w
 }
 
-// NewReportWorker creates a new report worker
-func NewReportWorker(db *database.DB, cfg *config.Config) *ReportWorker {
-	return &ReportWorker{
-		db:  db,
-		cfg: cfg,
+// CancelReport stops the generation of a running report, which is then stored as
+// cancelled. It returns false when the report is not running on this worker
+func (w *ReportWorker) CancelReport(reportID int) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	cancel, ok := w.cancels[reportID]
+	if ok {
+		cancel()
 	}
+	return ok
+}
+
+// reportContext returns the context a report is generated under. It is cancelled by
+// CancelReport and, when report_timeout_seconds is set, once the report runs too long
+func (w *ReportWorker) reportContext() (context.Context, context.CancelFunc) {
+	if timeout := w.cfg.ReportTimeout(); timeout > 0 {
+		return context.WithTimeout(context.Background(), timeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// trackReport registers cancel so CancelReport can stop the report until untrackReport
+func (w *ReportWorker) trackReport(reportID int, cancel context.CancelFunc) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.cancels[reportID] = cancel
+}
+
+// untrackReport forgets the cancel function registered for a report
+func (w *ReportWorker) untrackReport(reportID int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.cancels, reportID)
 }
 
 // Start begins the report worker loop
 func (w *ReportWorker) Start() {
 	log.Println("Starting report worker...")
+	w.run(nil)
+}
 
-	ticker := time.NewTicker(10 * time.Second) // Check every 10 seconds
+// run processes pending reports every poll interval until stop is closed. A nil stop runs
+// forever
+func (w *ReportWorker) run(stop <-chan struct{}) {
+	ticker := time.NewTicker(w.pollInterval)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		w.processReports()
+	for {
+		select {
+		case <-ticker.C:
+			w.processReports()
+		case <-w.resetChan:
+			if interval := w.settings.ReportPollInterval(); interval != w.pollInterval {
+				log.Printf("Report poll interval changed from %s to %s", w.pollInterval, interval)
+				w.pollInterval = interval
+				ticker.Reset(interval)
+			}
+		case <-stop:
+			return
+		}
 	}
 }
 
-// processReports processes pending reports
-func (w *ReportWorker) processReports() {
-	reports, err := w.db.GetPendingReports()
-	if err != nil {
-		log.Printf("Error getting pending reports: %v", err)
-		return
+// ResetInterval makes the worker re-read report_poll_interval_seconds, so a changed
+// interval applies without waiting out the current one (non-blocking)
+func (w *ReportWorker) ResetInterval() {
+	select {
+	case w.resetChan <- struct{}{}:
+	default:
+		// A reset is already pending
 	}
+}
+
+// processReports processes pending reports, highest priority first. The queue is read
+// again after every report so one prioritized while others are generating is picked next
+func (w *ReportWorker) processReports() {
+	// Each report is tried once per pass, even if it could not be moved out of pending
+	attempted := make(map[int]bool)
+	for {
+		// Pending reports stay queued until read-only mode is switched off
+		if w.settings.ReadOnly() {
+			return
+		}
+
+		reports, err := w.db.GetPendingReports()
+		if err != nil {
+			log.Printf("Error getting pending reports: %v", err)
+			return
+		}
 
-	for _, report := range reports {
-		w.processReport(report)
+		var next *database.Report
+		for _, report := range reports {
+			if !attempted[report.ID] {
+				next = report
+				break
+			}
+		}
+		if next == nil {
+			return
+		}
+		attempted[next.ID] = true
+		w.processReport(next)
 	}
 }
 
@@ -67,50 +172,125 @@ func (w *ReportWorker) processReports() {
 func (w *ReportWorker) processReport(report *database.Report) {
 	log.Printf("Processing report %d for file %d (type: %s)", report.ID, report.FileID, report.ReportType)
 
-	// Update status to running
-	err := w.db.UpdateReport(report.ID, "running", "", "")
-	if err != nil {
-		log.Printf("Error updating report status: %v", err)
+	// Register the report before it starts running so a cancel request always finds it
+	ctx, cancel := w.reportContext()
+	defer cancel()
+	w.trackReport(report.ID, cancel)
+	defer w.untrackReport(report.ID)
+
+	// Update status to running unless the report was cancelled while queued
+	if err := w.db.StartReport(report.ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Report %d is no longer pending, skipping", report.ID)
+		} else {
+			log.Printf("Error updating report status: %v", err)
+		}
 		return
 	}
+	w.events.PublishStatus(report.ID, "running", "")
+	start := time.Now()
+
+	// Logs from a previous attempt are replaced by this run
+	if err := w.db.DeleteReportLogs(report.ID); err != nil {
+		log.Printf("Error clearing logs for report %d: %v", report.ID, err)
+	}
+	logger := newReportLogger(w.db, w.events, report.ID)
+	logger.Infof("Started %s report for file %d", report.ReportType, report.FileID)
 
 	// Get file information
 	file, err := w.getFileByID(report.FileID)
 	if err != nil {
-		log.Printf("Error getting file %d: %v", report.FileID, err)
+		logger.Errorf("File %d not found: %v", report.FileID, err)
+		if err := w.db.SetReportGenerationTime(report.ID, time.Since(start)); err != nil {
+			log.Printf("Error recording generation time for report %d: %v", report.ID, err)
+		}
 		if err := w.db.UpdateReport(report.ID, "failed", "", "File not found"); err != nil {
 			log.Printf("Error updating report status to failed: %v", err)
 		}
+		w.events.PublishStatus(report.ID, "failed", "File not found")
+		metrics.RecordReportFailed(report.ReportType)
 		return
 	}
 
 	// Generate report based on type
-	var reportData string
-	var reportErr error
-
-	switch report.ReportType {
-	case "ttop":
-		reportData, reportErr = reporters.GenerateTTopReport(file.FilePath)
-	case "iostat":
-		reportData, reportErr = reporters.GenerateIOStatReport(file.FilePath)
-	case "jfr":
-		reportData, reportErr = reporters.GenerateJFRReport(file.FilePath)
-	default:
-		reportErr = fmt.Errorf("unknown report type: %s", report.ReportType)
+	reportData, reportErr := w.generateReport(ctx, report.ReportType, file.FilePath, w.cfg.MaxChartPoints, logger)
+	if reportErr == nil {
+		reportData, reportErr = w.enforceReportSize(ctx, report.ReportType, file.FilePath, reportData, logger)
 	}
 
 	// Update report with results
-	if reportErr != nil {
-		log.Printf("Error generating report: %v", reportErr)
+	elapsed := time.Since(start)
+	if err := w.db.SetReportGenerationTime(report.ID, elapsed); err != nil {
+		log.Printf("Error recording generation time for report %d: %v", report.ID, err)
+	}
+	switch {
+	case errors.Is(reportErr, context.Canceled):
+		logger.Warnf("Report cancelled after %s", elapsed.Round(time.Millisecond))
+		if err := w.db.UpdateReport(report.ID, "cancelled", "", cancelledMessage); err != nil {
+			log.Printf("Error updating report status to cancelled: %v", err)
+		}
+		w.events.PublishStatus(report.ID, "cancelled", cancelledMessage)
+	case errors.Is(reportErr, context.DeadlineExceeded):
+		message := fmt.Sprintf("Report generation timed out after %s; the file may be malformed, or raise report_timeout_seconds for very large files", w.cfg.ReportTimeout())
+		logger.Errorf("%s", message)
+		metrics.RecordReportFailed(report.ReportType)
+		if err := w.db.UpdateReport(report.ID, "failed", "", message); err != nil {
+			log.Printf("Error updating report status to failed: %v", err)
+		}
+		w.events.PublishStatus(report.ID, "failed", message)
+	case reportErr != nil:
+		logger.Errorf("Report generation failed after %s: %v", elapsed.Round(time.Millisecond), reportErr)
+		metrics.RecordReportFailed(report.ReportType)
 		if err := w.db.UpdateReport(report.ID, "failed", "", reportErr.Error()); err != nil {
 			log.Printf("Error updating report status to failed: %v", err)
 		}
-	} else {
-		log.Printf("Report %d completed successfully", report.ID)
+		w.events.PublishStatus(report.ID, "failed", reportErr.Error())
+	default:
+		logger.Infof("Report completed in %s", elapsed.Round(time.Millisecond))
+		metrics.RecordReportGenerated(report.ReportType, elapsed)
 		if err := w.db.UpdateReport(report.ID, "completed", reportData, ""); err != nil {
 			log.Printf("Error updating report status to completed: %v", err)
 		}
+		w.events.PublishStatus(report.ID, "completed", "")
+	}
+}
+
+// generateReport runs the registered reporter for reportType on filePath. maxPoints limits
+// the number charted by the time series reports, 0 to chart every snapshot
+func (w *ReportWorker) generateReport(ctx context.Context, reportType, filePath string, maxPoints int, logger reporters.ReportLogger) (string, error) {
+	reporter, ok := reporters.Lookup(reportType)
+	if !ok {
+		return "", fmt.Errorf("unknown report type: %s", reportType)
+	}
+	return reporter.Generate(ctx, filePath, reporters.Options{
+		Location:         w.getLocation(),
+		MaxPoints:        maxPoints,
+		TopThreads:       w.settings.TTopTopThreads(),
+		Theme:            w.settings.ReportTheme(),
+		IOStatThresholds: w.settings.IOStatThresholds(),
+		IOStatPartitions: w.settings.IOStatPartitions(),
+		Logger:           logger,
+	})
+}
+
+// enforceReportSize keeps reports within the configured max_report_bytes. Time series
+// reports are regenerated with fewer chart points until they fit; anything still too
+// large is stored truncated to its summary with a warning
+func (w *ReportWorker) enforceReportSize(ctx context.Context, reportType, filePath, reportData string, logger reporters.ReportLogger) (string, error) {
+	return reporters.EnforceReportSize(ctx, reportType, reportData, w.cfg.MaxReportBytes, w.cfg.MaxChartPoints,
+		func(ctx context.Context, maxPoints int) (string, error) {
+			return w.generateReport(ctx, reportType, filePath, maxPoints, logger)
+		}, logger)
+}
+
+// getLocation returns the configured time zone for captured timestamps, falling back to UTC
+func (w *ReportWorker) getLocation() *time.Location {
+	loc, err := w.settings.Location()
+	if err != nil {
+		log.Printf("Invalid timezone setting, using UTC: %v", err)
+		return time.UTC
 	}
+	return loc
 }
 
 // getFileByID retrieves a file by ID (helper method)
@@ -118,14 +298,16 @@ func (w *ReportWorker) getFileByID(fileID int) (*database.File, error) {
 	// This is a simplified implementation - in a real app you'd add this method to the DB
 	// For now, we'll implement a basic query
 	query := `
-		SELECT id, hash, original_name, file_type, file_size, upload_time, file_path, deleted, deleted_time
+		SELECT id, hash, original_name, file_type, file_size, upload_time, file_path, deleted, deleted_time,
+		       detection_confidence, detection_signal
 		FROM files WHERE id = ?
 	`
 	row := w.db.QueryRow(query, fileID)
 
 	file := &database.File{}
 	err := row.Scan(&file.ID, &file.Hash, &file.OriginalName, &file.FileType,
-		&file.FileSize, &file.UploadTime, &file.FilePath, &file.Deleted, &file.DeletedTime)
+		&file.FileSize, &file.UploadTime, &file.FilePath, &file.Deleted, &file.DeletedTime,
+		&file.DetectionConfidence, &file.DetectionSignal)
 	if err != nil {
 		return nil, err
 	}
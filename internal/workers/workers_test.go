@@ -15,12 +15,20 @@
 package workers
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
 	"testing"
 	"time"
 
 	"github.com/rsvihladremio/ddd/internal/database"
+	"github.com/rsvihladremio/ddd/internal/events"
+	"github.com/rsvihladremio/ddd/internal/settings"
 	"github.com/rsvihladremio/ddd/internal/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -76,7 +84,7 @@ func TestReportWorker_ProcessReports(t *testing.T) {
 		require.NoError(t, err)
 
 		// Create worker and process reports
-		worker := NewReportWorker(db, cfg)
+		worker := NewReportWorker(db, cfg, nil)
 		worker.processReports()
 
 		// Check that report was processed
@@ -86,6 +94,79 @@ func TestReportWorker_ProcessReports(t *testing.T) {
 		assert.Equal(t, "completed", updatedReport.Status)
 		assert.NotEmpty(t, updatedReport.ReportData)
 		assert.NotNil(t, updatedReport.CompletedTime)
+
+		// Progress logs are stored against the report
+		logs, err := db.GetReportLogs(report.ID, "", "")
+		require.NoError(t, err)
+		require.NotEmpty(t, logs)
+		assert.Equal(t, "Started ttop report for file "+strconv.Itoa(file.ID), logs[0].Message)
+		assert.Contains(t, logs[len(logs)-1].Message, "Report completed in")
+		for _, entry := range logs {
+			assert.Equal(t, report.ID, entry.ReportID)
+		}
+	})
+
+	t.Run("Publishes status and progress events", func(t *testing.T) {
+		report := &database.Report{
+			FileID:      file.ID,
+			ReportType:  "ttop",
+			Status:      "pending",
+			CreatedTime: time.Now(),
+			DDDVersion:  "1.0.0",
+		}
+		require.NoError(t, db.InsertReport(report))
+
+		broker := events.NewBroker()
+		updates, unsubscribe := broker.Subscribe(report.ID)
+		defer unsubscribe()
+
+		worker := NewReportWorker(db, cfg, broker)
+		worker.processReports()
+
+		var statuses []string
+		progress := 0
+		for len(updates) > 0 {
+			event := <-updates
+			switch event.Type {
+			case events.TypeStatus:
+				statuses = append(statuses, event.Status)
+			case events.TypeProgress:
+				progress++
+			}
+		}
+		assert.Equal(t, []string{"running", "completed"}, statuses)
+		assert.Positive(t, progress)
+	})
+
+	t.Run("Process pending Dremio profile report", func(t *testing.T) {
+		profileHash, profilePath := testutil.CreateSampleFile(t, cfg.UploadsDir, "dremio_profile")
+		profileFile := &database.File{
+			Hash:         profileHash,
+			OriginalName: "profile_attempt_0.json",
+			FileType:     "dremio_profile",
+			FileSize:     int64(len(testutil.SampleFiles["dremio_profile"].Content)),
+			UploadTime:   time.Now(),
+			FilePath:     profilePath,
+		}
+		require.NoError(t, db.InsertFile(profileFile))
+
+		report := &database.Report{
+			FileID:      profileFile.ID,
+			ReportType:  "dremio_profile",
+			Status:      "pending",
+			CreatedTime: time.Now(),
+			DDDVersion:  "1.0.0",
+		}
+		require.NoError(t, db.InsertReport(report))
+
+		worker := NewReportWorker(db, cfg, nil)
+		worker.processReports()
+
+		updatedReport, err := db.GetReportByID(report.ID)
+		require.NoError(t, err)
+
+		assert.Equal(t, "completed", updatedReport.Status)
+		assert.Contains(t, updatedReport.ReportData, "Dremio Profile Report")
 	})
 
 	t.Run("Process report for non-existent file", func(t *testing.T) {
@@ -114,7 +195,7 @@ func TestReportWorker_ProcessReports(t *testing.T) {
 		require.NoError(t, err)
 
 		// Process reports
-		worker := NewReportWorker(db, cfg)
+		worker := NewReportWorker(db, cfg, nil)
 		worker.processReports()
 
 		// Check that report failed
@@ -124,6 +205,71 @@ func TestReportWorker_ProcessReports(t *testing.T) {
 		assert.Equal(t, "failed", updatedReport.Status)
 		assert.NotEmpty(t, updatedReport.ErrorMessage)
 		assert.NotNil(t, updatedReport.CompletedTime)
+
+		// The failure reason is available in the report logs
+		errorLogs, err := db.GetReportLogs(report.ID, "ERROR", "")
+		require.NoError(t, err)
+		require.NotEmpty(t, errorLogs)
+		assert.Contains(t, errorLogs[len(errorLogs)-1].Message, "Report generation failed")
+	})
+
+	t.Run("Processes higher priority reports first", func(t *testing.T) {
+		// A file has one queued report of each type, so the second is for another copy
+		copied := *file
+		copied.Hash += "-copy"
+		require.NoError(t, db.InsertFile(&copied))
+		low := &database.Report{
+			FileID:      file.ID,
+			ReportType:  "ttop",
+			Status:      "pending",
+			CreatedTime: time.Now().Add(-time.Minute),
+			DDDVersion:  "1.0.0",
+		}
+		high := &database.Report{
+			FileID:      copied.ID,
+			ReportType:  "ttop",
+			Status:      "pending",
+			CreatedTime: time.Now(),
+			Priority:    10,
+			DDDVersion:  "1.0.0",
+		}
+		require.NoError(t, db.InsertReport(low))
+		require.NoError(t, db.InsertReport(high))
+
+		worker := NewReportWorker(db, cfg, nil)
+		worker.processReports()
+
+		processedLow, err := db.GetReportByID(low.ID)
+		require.NoError(t, err)
+		processedHigh, err := db.GetReportByID(high.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "completed", processedLow.Status)
+		assert.Equal(t, "completed", processedHigh.Status)
+		require.NotNil(t, processedLow.CompletedTime)
+		require.NotNil(t, processedHigh.CompletedTime)
+		assert.True(t, processedHigh.CompletedTime.Before(*processedLow.CompletedTime),
+			"The prioritized report should finish before the older one")
+	})
+
+	t.Run("Skips reports cancelled while queued", func(t *testing.T) {
+		report := &database.Report{
+			FileID:      file.ID,
+			ReportType:  "ttop",
+			Status:      "pending",
+			CreatedTime: time.Now(),
+			DDDVersion:  "1.0.0",
+		}
+		require.NoError(t, db.InsertReport(report))
+
+		// The worker already read the queue when the report was cancelled
+		worker := NewReportWorker(db, cfg, nil)
+		require.NoError(t, db.CancelPendingReport(report.ID, "Cancelled"))
+		worker.processReport(report)
+
+		cancelled, err := db.GetReportByID(report.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "cancelled", cancelled.Status)
+		assert.Empty(t, cancelled.ReportData)
 	})
 
 	t.Run("Process multiple reports concurrently", func(t *testing.T) {
@@ -167,7 +313,7 @@ func TestReportWorker_ProcessReports(t *testing.T) {
 		}
 
 		// Process all reports
-		worker := NewReportWorker(db, cfg)
+		worker := NewReportWorker(db, cfg, nil)
 		worker.processReports()
 
 		// Check that all reports were processed
@@ -179,6 +325,114 @@ func TestReportWorker_ProcessReports(t *testing.T) {
 	})
 }
 
+func TestReportWorker_CancelRunningReport(t *testing.T) {
+	db := testDB(t)
+	cfg := testutil.TestConfig(t)
+
+	// Reads from a named pipe block until the test closes it, so the report is guaranteed
+	// to still be generating when it is cancelled
+	fifoPath := filepath.Join(t.TempDir(), "ttop.txt")
+	require.NoError(t, syscall.Mkfifo(fifoPath, 0600))
+
+	file := &database.File{
+		Hash:         "fifo",
+		OriginalName: "ttop.txt",
+		FileType:     "ttop",
+		UploadTime:   time.Now(),
+		FilePath:     fifoPath,
+	}
+	require.NoError(t, db.InsertFile(file))
+
+	report := &database.Report{
+		FileID:      file.ID,
+		ReportType:  "ttop",
+		Status:      "pending",
+		CreatedTime: time.Now(),
+		DDDVersion:  "1.0.0",
+	}
+	require.NoError(t, db.InsertReport(report))
+
+	worker := NewReportWorker(db, cfg, nil)
+	assert.False(t, worker.CancelReport(report.ID), "A queued report is not running yet")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		worker.processReport(report)
+	}()
+
+	// Opening the pipe for writing waits until the reporter has opened it for reading
+	writer, err := os.OpenFile(fifoPath, os.O_WRONLY, 0)
+	require.NoError(t, err)
+	assert.True(t, worker.CancelReport(report.ID))
+	require.NoError(t, writer.Close())
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Report generation did not stop after being cancelled")
+	}
+
+	cancelled, err := db.GetReportByID(report.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "cancelled", cancelled.Status)
+	assert.Equal(t, cancelledMessage, cancelled.ErrorMessage)
+	assert.Empty(t, cancelled.ReportData)
+	assert.False(t, worker.CancelReport(report.ID), "Finished reports are no longer tracked")
+}
+
+func TestReportWorker_ReportTimeout(t *testing.T) {
+	db := testDB(t)
+	cfg := testutil.TestConfig(t)
+	cfg.ReportTimeoutSeconds = 1
+
+	// The reporter blocks reading the named pipe until the test closes it after the timeout
+	fifoPath := filepath.Join(t.TempDir(), "iostat.txt")
+	require.NoError(t, syscall.Mkfifo(fifoPath, 0600))
+
+	file := &database.File{
+		Hash:         "fifo",
+		OriginalName: "iostat.txt",
+		FileType:     "iostat",
+		UploadTime:   time.Now(),
+		FilePath:     fifoPath,
+	}
+	require.NoError(t, db.InsertFile(file))
+
+	report := &database.Report{
+		FileID:      file.ID,
+		ReportType:  "iostat",
+		Status:      "pending",
+		CreatedTime: time.Now(),
+		DDDVersion:  "1.0.0",
+	}
+	require.NoError(t, db.InsertReport(report))
+
+	worker := NewReportWorker(db, cfg, nil)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		worker.processReport(report)
+	}()
+
+	writer, err := os.OpenFile(fifoPath, os.O_WRONLY, 0)
+	require.NoError(t, err)
+	time.Sleep(cfg.ReportTimeout())
+	require.NoError(t, writer.Close())
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Report generation did not stop after timing out")
+	}
+
+	failed, err := db.GetReportByID(report.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "failed", failed.Status)
+	assert.Contains(t, failed.ErrorMessage, "timed out after 1s")
+	assert.Contains(t, failed.ErrorMessage, "report_timeout_seconds")
+}
+
 func TestCleanupWorker_CleanupOldFiles(t *testing.T) {
 	db := testDB(t)
 	cfg := testutil.TestConfig(t)
@@ -271,6 +525,80 @@ func TestCleanupWorker_CleanupOldFiles(t *testing.T) {
 	})
 }
 
+func TestCleanupWorker_FileRetentionByType(t *testing.T) {
+	db := testDB(t)
+	cfg := testutil.TestConfig(t)
+	cfg.FileRetentionDays = 7
+	require.NoError(t, db.SetSetting("file_retention_days.jfr", "30"))
+	require.NoError(t, db.SetSetting("file_retention_days.iostat", "1"))
+
+	insert := func(name, fileType string, age time.Duration) *database.File {
+		content := []byte(name)
+		hash, filePath := testutil.CreateTestFile(t, cfg.UploadsDir, testutil.TestFile{Name: name, Content: content, FileType: fileType})
+		file := &database.File{Hash: hash, OriginalName: name, FileType: fileType, FileSize: int64(len(content)),
+			UploadTime: time.Now().Add(-age), FilePath: filePath}
+		require.NoError(t, db.InsertFile(file))
+		return file
+	}
+	day := 24 * time.Hour
+	jfrKept := insert("recording.jfr", "jfr", 10*day)        // Past the global retention, within its own
+	jfrDeleted := insert("old-recording.jfr", "jfr", 40*day) // Past its own retention
+	iostatDeleted := insert("iostat.txt", "iostat", 2*day)   // Within the global retention, past its own
+	ttopKept := insert("ttop.txt", "ttop", 3*day)            // Uses the global retention
+	ttopDeleted := insert("old-ttop.txt", "ttop", 8*day)
+
+	NewCleanupWorker(db, cfg).cleanupOldFiles()
+
+	for _, file := range []*database.File{jfrKept, ttopKept} {
+		testutil.AssertFileExists(t, file.FilePath)
+	}
+	for _, file := range []*database.File{jfrDeleted, iostatDeleted, ttopDeleted} {
+		testutil.AssertFileNotExists(t, file.FilePath)
+	}
+
+	files, err := db.GetFilesOlderThanByType("jfr", time.Now())
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, jfrKept.ID, files[0].ID)
+}
+
+func TestCleanupWorker_SkipsPinnedFiles(t *testing.T) {
+	db := testDB(t)
+	cfg := testutil.TestConfig(t)
+	cfg.FileRetentionDays = 7
+
+	insert := func(name string, pinned bool) *database.File {
+		content := []byte(name)
+		hash, filePath := testutil.CreateTestFile(t, cfg.UploadsDir, testutil.TestFile{Name: name, Content: content, FileType: "ttop"})
+		file := &database.File{Hash: hash, OriginalName: name, FileType: "ttop", FileSize: int64(len(content)),
+			UploadTime: time.Now().Add(-30 * 24 * time.Hour), FilePath: filePath}
+		require.NoError(t, db.InsertFile(file))
+		if pinned {
+			require.NoError(t, db.SetFilePinned(file.ID, true))
+		}
+		return file
+	}
+	pinned := insert("pinned.txt", true)
+	unpinned := insert("unpinned.txt", false)
+
+	worker := NewCleanupWorker(db, cfg)
+
+	// Aggressive cleanup works through the oldest files, which must not include pinned ones
+	oldest, err := worker.getOldestFiles(10)
+	require.NoError(t, err)
+	require.Len(t, oldest, 1)
+	assert.Equal(t, unpinned.ID, oldest[0].ID)
+
+	worker.cleanupOldFiles()
+
+	testutil.AssertFileExists(t, pinned.FilePath)
+	testutil.AssertFileNotExists(t, unpinned.FilePath)
+	file, err := db.GetFileByID(pinned.ID)
+	require.NoError(t, err)
+	assert.False(t, file.Deleted)
+	assert.True(t, file.Pinned)
+}
+
 func TestWorkerIntegration(t *testing.T) {
 	db := testDB(t)
 	cfg := testutil.TestConfig(t)
@@ -304,7 +632,7 @@ func TestWorkerIntegration(t *testing.T) {
 		require.NoError(t, err)
 
 		// Process the report
-		reportWorker := NewReportWorker(db, cfg)
+		reportWorker := NewReportWorker(db, cfg, nil)
 		reportWorker.processReports()
 
 		// Verify report was completed
@@ -335,7 +663,7 @@ func TestWorkerErrorHandling(t *testing.T) {
 	cfg := testutil.TestConfig(t)
 
 	t.Run("Report worker handles database errors gracefully", func(t *testing.T) {
-		worker := NewReportWorker(db, cfg)
+		worker := NewReportWorker(db, cfg, nil)
 
 		// This should not panic even if there are no pending reports
 		worker.processReports()
@@ -467,6 +795,195 @@ func TestWorkerConfiguration(t *testing.T) {
 	})
 }
 
+func TestReportWorker_Location(t *testing.T) {
+	db := testDB(t)
+	cfg := testutil.TestConfig(t)
+	cfg.Timezone = "Europe/Berlin"
+	worker := NewReportWorker(db, cfg, nil)
+
+	assert.Equal(t, "Europe/Berlin", worker.getLocation().String())
+
+	require.NoError(t, db.SetSetting("timezone", "America/Chicago"))
+	assert.Equal(t, "America/Chicago", worker.getLocation().String(), "The setting wins over the config")
+
+	require.NoError(t, db.SetSetting("timezone", "Nowhere/Special"))
+	assert.Equal(t, time.UTC, worker.getLocation())
+}
+
+func TestReportWorker_PollInterval(t *testing.T) {
+	db := testDB(t)
+	cfg := testutil.TestConfig(t)
+
+	t.Run("Interval read from settings", func(t *testing.T) {
+		assert.Equal(t, settings.DefaultReportPollInterval, NewReportWorker(db, cfg, nil).pollInterval)
+
+		require.NoError(t, db.SetSetting("report_poll_interval_seconds", "30"))
+		assert.Equal(t, 30*time.Second, NewReportWorker(db, cfg, nil).pollInterval)
+
+		require.NoError(t, db.SetSetting("report_poll_interval_seconds", "0"))
+		assert.Equal(t, settings.DefaultReportPollInterval, NewReportWorker(db, cfg, nil).pollInterval)
+	})
+
+	t.Run("A changed interval is honored without a restart", func(t *testing.T) {
+		require.NoError(t, db.SetSetting("report_poll_interval_seconds", "3600"))
+		worker := NewReportWorker(db, cfg, nil)
+
+		hash, filePath := testutil.CreateSampleFile(t, cfg.UploadsDir, "ttop")
+		file := &database.File{Hash: hash, OriginalName: "ttop.txt", FileType: "ttop",
+			FileSize: int64(len(testutil.SampleFiles["ttop"].Content)), UploadTime: time.Now(), FilePath: filePath}
+		require.NoError(t, db.InsertFile(file))
+		report := &database.Report{FileID: file.ID, ReportType: "ttop", Status: "pending", CreatedTime: time.Now(), DDDVersion: "1.0.0"}
+		require.NoError(t, db.InsertReport(report))
+
+		stop, done := make(chan struct{}), make(chan struct{})
+		go func() {
+			defer close(done)
+			worker.run(stop)
+		}()
+		defer func() {
+			close(stop)
+			<-done
+		}()
+
+		// Nothing is processed within the hour long interval
+		time.Sleep(200 * time.Millisecond)
+		pending, err := db.GetReportByID(report.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "pending", pending.Status)
+
+		require.NoError(t, db.SetSetting("report_poll_interval_seconds", "1"))
+		worker.ResetInterval()
+
+		assert.Eventually(t, func() bool {
+			processed, err := db.GetReportByID(report.ID)
+			return err == nil && processed.Status == "completed"
+		}, 5*time.Second, 50*time.Millisecond)
+	})
+}
+
+func TestReportWorker_EnforceReportSize(t *testing.T) {
+	db := testDB(t)
+	cfg := testutil.TestConfig(t)
+	worker := NewReportWorker(db, cfg, nil)
+
+	// A long capture with one snapshot per second
+	var capture strings.Builder
+	for i := 0; i < 2000; i++ {
+		fmt.Fprintf(&capture, "top - %02d:%02d:%02d up  3:07,  0 users,  load average: 3.18, 1.16, 0.41\n", i/3600, i/60%60, i%60)
+		capture.WriteString("Threads: 262 total,   6 running, 256 sleeping,   0 stopped,   0 zombie\n\n")
+		capture.WriteString("    PID USER      PR  NI    VIRT    RES    SHR S  %CPU  %MEM     TIME+ COMMAND\n")
+		fmt.Fprintf(&capture, "    997 dremio    20   0 7009048   3.4g  98412 R  %.1f  21.9   1:36.52 C2 CompilerThre\n\n", float64(i%100))
+	}
+	hash, filePath := testutil.CreateTestFile(t, cfg.UploadsDir, testutil.TestFile{Name: "ttop.txt", Content: []byte(capture.String())})
+	file := &database.File{
+		Hash:         hash,
+		OriginalName: "long_ttop.txt",
+		FileType:     "ttop",
+		FileSize:     int64(capture.Len()),
+		UploadTime:   time.Now(),
+		FilePath:     filePath,
+	}
+	require.NoError(t, db.InsertFile(file))
+	running := &database.Report{FileID: file.ID, ReportType: "ttop", Status: "running", CreatedTime: time.Now(), DDDVersion: "1.0.0"}
+	require.NoError(t, db.InsertReport(running))
+	logger := newReportLogger(db, nil, running.ID)
+
+	full, err := worker.generateReport(context.Background(), "ttop", filePath, 0, logger)
+	require.NoError(t, err)
+
+	t.Run("Reports within the limit are unchanged", func(t *testing.T) {
+		cfg.MaxReportBytes = int64(len(full))
+		reportData, err := worker.enforceReportSize(context.Background(), "ttop", filePath, full, logger)
+		require.NoError(t, err)
+		assert.Equal(t, full, reportData)
+	})
+
+	t.Run("Oversized time series reports are downsampled", func(t *testing.T) {
+		cfg.MaxReportBytes = int64(len(full)) - 1
+		reportData, err := worker.enforceReportSize(context.Background(), "ttop", filePath, full, logger)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, int64(len(reportData)), cfg.MaxReportBytes)
+
+		var report map[string]any
+		require.NoError(t, json.Unmarshal([]byte(reportData), &report))
+		assert.Equal(t, float64(2000), report["snapshot_count"])
+		assert.Nil(t, report["truncated"])
+	})
+
+	t.Run("Reports that cannot be downsampled enough are truncated", func(t *testing.T) {
+		cfg.MaxReportBytes = 4096
+		reportData, err := worker.enforceReportSize(context.Background(), "ttop", filePath, full, logger)
+		require.NoError(t, err)
+
+		var report map[string]any
+		require.NoError(t, json.Unmarshal([]byte(reportData), &report))
+		assert.Equal(t, true, report["truncated"])
+		assert.Equal(t, float64(len(full)), report["original_bytes"])
+		assert.Equal(t, "ttop", report["type"])
+		assert.Contains(t, report["html_report"], "Report Truncated")
+
+		warnings, err := db.GetReportLogs(running.ID, "WARN", "")
+		require.NoError(t, err)
+		require.NotEmpty(t, warnings)
+		assert.Contains(t, warnings[len(warnings)-1].Message, "Stored a truncated report")
+	})
+
+	t.Run("No limit when max_report_bytes is zero", func(t *testing.T) {
+		cfg.MaxReportBytes = 0
+		reportData, err := worker.enforceReportSize(context.Background(), "ttop", filePath, full, logger)
+		require.NoError(t, err)
+		assert.Equal(t, full, reportData)
+	})
+}
+
+func TestCleanupWorker_Interval(t *testing.T) {
+	db := testDB(t)
+	cfg := testutil.TestConfig(t)
+	cfg.FileRetentionDays = 7
+
+	t.Run("Interval read from settings", func(t *testing.T) {
+		assert.Equal(t, settings.DefaultCleanupInterval, NewCleanupWorker(db, cfg).interval)
+
+		require.NoError(t, db.SetSetting("cleanup_interval_seconds", "120"))
+		assert.Equal(t, 2*time.Minute, NewCleanupWorker(db, cfg).interval)
+
+		require.NoError(t, db.SetSetting("cleanup_interval_seconds", "soon"))
+		assert.Equal(t, settings.DefaultCleanupInterval, NewCleanupWorker(db, cfg).interval)
+	})
+
+	t.Run("A changed interval is honored without a restart", func(t *testing.T) {
+		require.NoError(t, db.SetSetting("cleanup_interval_seconds", "3600"))
+		worker := NewCleanupWorker(db, cfg)
+
+		content := []byte("old ttop capture")
+		hash, filePath := testutil.CreateTestFile(t, cfg.UploadsDir, testutil.TestFile{Name: "old.txt", Content: content, FileType: "ttop"})
+		require.NoError(t, db.InsertFile(&database.File{Hash: hash, OriginalName: "old.txt", FileType: "ttop",
+			FileSize: int64(len(content)), UploadTime: time.Now().Add(-30 * 24 * time.Hour), FilePath: filePath}))
+
+		stop, done := make(chan struct{}), make(chan struct{})
+		go func() {
+			defer close(done)
+			worker.run(stop)
+		}()
+		defer func() {
+			close(stop)
+			<-done
+		}()
+
+		// Nothing is cleaned up within the hour long interval
+		time.Sleep(200 * time.Millisecond)
+		testutil.AssertFileExists(t, filePath)
+
+		require.NoError(t, db.SetSetting("cleanup_interval_seconds", "1"))
+		worker.ResetInterval()
+
+		assert.Eventually(t, func() bool {
+			_, err := os.Stat(filePath)
+			return os.IsNotExist(err)
+		}, 5*time.Second, 50*time.Millisecond)
+	})
+}
+
 func TestCleanupWorker_AggressiveCleanup(t *testing.T) {
 	db := testDB(t)
 	cfg := testutil.TestConfig(t)
@@ -502,7 +1019,7 @@ func TestCleanupWorker_AggressiveCleanup(t *testing.T) {
 		}
 
 		// Verify files exist before cleanup
-		files, err := db.GetFiles(10, 0, false, "")
+		files, err := db.GetFiles(10, 0, false, "", nil)
 		require.NoError(t, err)
 		assert.Len(t, files, 3)
 
@@ -647,3 +1164,161 @@ func TestCleanupWorker_OrphanedFileCleanup(t *testing.T) {
 		assert.NoError(t, err, "Active file should still exist even without reports")
 	})
 }
+
+func TestBackupWorker_PerformBackup(t *testing.T) {
+	db := testDB(t)
+	cfg := testutil.TestConfig(t)
+	cfg.BackupRetention = 1
+	worker := NewBackupWorker(db, cfg)
+
+	worker.performBackup()
+	worker.performBackup()
+
+	matches, err := filepath.Glob(filepath.Join(cfg.BackupDir, "ddd-*.db"))
+	require.NoError(t, err)
+	assert.Len(t, matches, 1, "Backups beyond the retention count should be pruned")
+
+	t.Run("Disabled schedule returns immediately", func(t *testing.T) {
+		cfg.BackupIntervalHours = 0
+		done := make(chan struct{})
+		go func() {
+			worker.Start()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("Start should return when scheduled backups are disabled")
+		}
+	})
+}
+
+func TestCleanupWorker_PurgeDeletedFileReports(t *testing.T) {
+	db := testDB(t)
+	cfg := testutil.TestConfig(t)
+	worker := NewCleanupWorker(db, cfg)
+
+	file := &database.File{Hash: "purge-hash", OriginalName: "ttop.txt", FileType: "ttop", FileSize: 10, UploadTime: time.Now(), FilePath: "/uploads/purge-hash"}
+	require.NoError(t, db.InsertFile(file))
+	report := &database.Report{FileID: file.ID, ReportType: "ttop", Status: "completed", CreatedTime: time.Now(), DDDVersion: "1.0.0", ReportData: `{"summary":"old"}`}
+	require.NoError(t, db.InsertReport(report))
+	_, err := db.Exec(`UPDATE files SET deleted = TRUE, deleted_time = ? WHERE id = ?`, time.Now().Add(-10*24*time.Hour), file.ID)
+	require.NoError(t, err)
+
+	t.Run("Report data is kept forever by default", func(t *testing.T) {
+		worker.purgeDeletedFileReports()
+
+		kept, err := db.GetReportByID(report.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "completed", kept.Status)
+		assert.NotEmpty(t, kept.ReportData)
+	})
+
+	t.Run("Reports past the retention are purged", func(t *testing.T) {
+		require.NoError(t, db.SetSetting("report_retention_days", "7"))
+		worker.purgeDeletedFileReports()
+
+		purged, err := db.GetReportByID(report.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "purged", purged.Status)
+		assert.Empty(t, purged.ReportData)
+
+		logs, err := db.GetReportLogs(report.ID, "", "")
+		require.NoError(t, err)
+		require.Len(t, logs, 1)
+		assert.Equal(t, "Report data purged 7 days after its file was deleted", logs[0].Message)
+
+		// The tombstone keeps the deleted file entry from being removed as an orphan
+		worker.cleanupOrphanedFileEntries()
+		_, err = db.GetFileByID(file.ID)
+		assert.NoError(t, err)
+	})
+}
+
+func TestCleanupWorker_TrimExpiredReportData(t *testing.T) {
+	db := testDB(t)
+	cfg := testutil.TestConfig(t)
+	worker := NewCleanupWorker(db, cfg)
+
+	file := &database.File{Hash: "trim-hash", OriginalName: "ttop.txt", FileType: "ttop", FileSize: 10, UploadTime: time.Now(), FilePath: "/uploads/trim-hash"}
+	require.NoError(t, db.InsertFile(file))
+	report := &database.Report{FileID: file.ID, ReportType: "ttop", Status: "completed", CreatedTime: time.Now().Add(-10 * 24 * time.Hour), DDDVersion: "1.0.0",
+		ReportData: `{"summary":"old","html_report":"<html></html>"}`}
+	require.NoError(t, db.InsertReport(report))
+
+	t.Run("Report data is kept forever by default", func(t *testing.T) {
+		worker.trimExpiredReportData()
+
+		kept, err := db.GetReportByID(report.ID)
+		require.NoError(t, err)
+		assert.Contains(t, kept.ReportData, "html_report")
+	})
+
+	t.Run("Reports past the retention keep only their summary", func(t *testing.T) {
+		require.NoError(t, db.SetSetting("report_data_retention_days", "7"))
+		worker.trimExpiredReportData()
+
+		trimmed, err := db.GetReportByID(report.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "completed", trimmed.Status)
+		assert.NotContains(t, trimmed.ReportData, "html_report")
+		assert.Contains(t, trimmed.ReportData, `"summary":"old"`)
+
+		logs, err := db.GetReportLogs(report.ID, "", "")
+		require.NoError(t, err)
+		require.Len(t, logs, 1)
+		assert.Equal(t, "Report charts and raw data removed after 7 days, the summary and analysis were kept", logs[0].Message)
+
+		// Trimmed reports aren't trimmed and logged again
+		worker.trimExpiredReportData()
+		logs, err = db.GetReportLogs(report.ID, "", "")
+		require.NoError(t, err)
+		assert.Len(t, logs, 1)
+	})
+}
+
+func TestWorkers_ReadOnly(t *testing.T) {
+	db := testDB(t)
+	cfg := testutil.TestConfig(t)
+
+	hash, filePath := testutil.CreateSampleFile(t, cfg.UploadsDir, "ttop")
+	file := &database.File{
+		Hash:         hash,
+		OriginalName: "test_ttop.txt",
+		FileType:     "ttop",
+		FileSize:     int64(len(testutil.SampleFiles["ttop"].Content)),
+		UploadTime:   time.Now().Add(-60 * 24 * time.Hour),
+		FilePath:     filePath,
+	}
+	require.NoError(t, db.InsertFile(file))
+	report := &database.Report{FileID: file.ID, ReportType: "ttop", Status: "pending", CreatedTime: time.Now(), DDDVersion: "1.0.0"}
+	require.NoError(t, db.InsertReport(report))
+
+	require.NoError(t, db.SetSetting("read_only", "true"))
+
+	t.Run("Report generation is paused", func(t *testing.T) {
+		NewReportWorker(db, cfg, nil).processReports()
+
+		pending, err := db.GetReportByID(report.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "pending", pending.Status)
+	})
+
+	t.Run("Cleanup is paused", func(t *testing.T) {
+		NewCleanupWorker(db, cfg).performCleanup()
+
+		kept, err := db.GetFileByID(file.ID)
+		require.NoError(t, err)
+		assert.False(t, kept.Deleted, "Files past the retention are kept until read-only mode ends")
+	})
+
+	t.Run("Pending reports are generated once read-only mode ends", func(t *testing.T) {
+		require.NoError(t, db.SetSetting("read_only", "false"))
+		NewReportWorker(db, cfg, nil).processReports()
+
+		completed, err := db.GetReportByID(report.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "completed", completed.Status)
+	})
+}
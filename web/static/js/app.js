@@ -24,8 +24,14 @@ class DDDApp {
         this.currentFileId = null;
         this.totalFiles = 0;
         this.totalPages = 1;
+        this.lowConfidenceThreshold = 0.5;
         this.currentFileType = null;
         this.pollingInterval = null;
+        this.openLogPanels = new Set();
+        this.reportEventSources = new Map();
+        this.logSearchQueries = {};
+        this.throughputChart = null;
+        this.capabilities = null;
         this.init();
     }
 
@@ -34,6 +40,15 @@ class DDDApp {
         this.loadFiles();
         this.loadDiskUsage();
         this.loadSettings();
+        this.loadStats();
+        this.loadThroughput();
+        this.loadReadOnlyMode();
+        this.loadCapabilities();
+        setInterval(() => {
+            this.loadStats();
+            this.loadThroughput();
+            this.loadReadOnlyMode();
+        }, 60000);
     }
 
     setupEventListeners() {
@@ -66,6 +81,14 @@ class DDDApp {
             this.updateSearchUI();
         });
 
+        // Report content search events
+        document.getElementById('report-search-button').addEventListener('click', this.searchReportContents.bind(this));
+        document.getElementById('report-search-input').addEventListener('keypress', (e) => {
+            if (e.key === 'Enter') {
+                this.searchReportContents();
+            }
+        });
+
         // Pagination events
         document.getElementById('prev-page').addEventListener('click', () => {
             if (this.currentPage > 1) {
@@ -162,18 +185,19 @@ class DDDApp {
         
         const files = e.dataTransfer.files;
         if (files.length > 0) {
-            this.uploadFile(files[0]);
+            this.uploadFiles(files);
         }
     }
 
     handleFileSelect(e) {
         const files = e.target.files;
         if (files.length > 0) {
-            this.uploadFile(files[0]);
+            this.uploadFiles(files);
         }
     }
 
-    async uploadFile(file) {
+    // Files sent together are stored in one upload group so related artifacts stay linked
+    async uploadFiles(files) {
         const progressBar = document.getElementById('upload-progress');
         const statusDiv = document.getElementById('upload-status');
 
@@ -182,7 +206,9 @@ class DDDApp {
         statusDiv.style.display = 'none';
 
         const formData = new FormData();
-        formData.append('file', file);
+        for (const file of files) {
+            formData.append('file', file);
+        }
 
         try {
             const response = await fetch('/api/upload', {
@@ -193,10 +219,14 @@ class DDDApp {
             const result = await response.json();
 
             if (result.success) {
-                this.showStatus('File uploaded successfully!', 'success');
+                this.showStatus(files.length > 1 ? `${result.message}!` : 'File uploaded successfully!', 'success');
                 this.loadFiles(); // Refresh file list
+            } else if (result.failures && result.failures.length > 0) {
+                const failed = result.failures.map(failure => `${failure.file_name}: ${failure.error}`).join(', ');
+                this.showStatus(`${result.message}. Failed: ${failed}`, 'error');
+                this.loadFiles();
             } else {
-                this.showStatus('Upload failed: ' + (result.message || 'Unknown error'), 'error');
+                this.showStatus('Upload failed: ' + this.errorMessage(result, 'Unknown error'), 'error');
             }
         } catch (error) {
             this.showStatus('Upload failed: ' + error.message, 'error');
@@ -262,6 +292,51 @@ class DDDApp {
         }
     }
 
+    async searchReportContents() {
+        const query = document.getElementById('report-search-input').value.trim();
+        const resultsDiv = document.getElementById('report-search-results');
+
+        if (!query) {
+            resultsDiv.style.display = 'none';
+            resultsDiv.innerHTML = '';
+            return;
+        }
+
+        try {
+            const response = await fetch(`/api/search?q=${encodeURIComponent(query)}`);
+            if (!response.ok) {
+                throw await this.responseError(response);
+            }
+            const result = await response.json();
+            this.renderReportSearchResults(query, result.results || []);
+        } catch (error) {
+            console.error('Error searching reports:', error);
+            this.showToast('Failed to search reports: ' + error.message, 'error');
+        }
+    }
+
+    renderReportSearchResults(query, results) {
+        const resultsDiv = document.getElementById('report-search-results');
+        resultsDiv.style.display = 'block';
+
+        if (results.length === 0) {
+            resultsDiv.innerHTML = `<div class="search-status no-results">No reports found for "${this.escapeHtml(query)}"</div>`;
+            return;
+        }
+
+        // Snippets are escaped by the server and only contain <mark> highlighting
+        resultsDiv.innerHTML = results.map(result => `
+            <div class="report-search-result">
+                <div class="report-search-result-title">
+                    <a href="/report/${result.report_id}" target="_blank">${this.escapeHtml(result.file_name)}</a>
+                    <span class="report-search-result-type">${this.escapeHtml(result.report_type)}</span>
+                    <span class="report-search-result-date">${this.formatDate(result.created_time)}</span>
+                </div>
+                <div class="report-search-result-snippet">${result.snippet}</div>
+            </div>
+        `).join('');
+    }
+
     async loadFiles() {
         const loadingDiv = document.getElementById('files-loading');
         const emptyDiv = document.getElementById('files-empty');
@@ -290,11 +365,14 @@ class DDDApp {
                 const hasSearch = this.searchQuery && this.searchQuery.trim();
                 this.totalFiles = result.total || 0;
                 this.totalPages = result.total_pages || 1;
+                if (typeof result.low_confidence_threshold === 'number') {
+                    this.lowConfidenceThreshold = result.low_confidence_threshold;
+                }
                 this.updateSearchStatus(files, hasSearch);
                 this.renderFiles(files);
                 this.updatePagination();
             } else {
-                throw new Error(result.message || 'Failed to load files');
+                throw new Error(this.errorMessage(result, 'Failed to load files'));
             }
         } catch (error) {
             console.error('Error loading files:', error);
@@ -315,6 +393,35 @@ class DDDApp {
         }
     }
 
+    async showUploadGroup(groupId) {
+        try {
+            const response = await fetch(`/api/groups/${encodeURIComponent(groupId)}`);
+            if (!response.ok) {
+                throw await this.responseError(response);
+            }
+            const result = await response.json();
+            const files = result.files || [];
+
+            this.renderFiles(files);
+            const statusDiv = document.getElementById('search-status');
+            statusDiv.className = 'search-status';
+            statusDiv.style.display = 'block';
+            document.getElementById('search-status-text').textContent =
+                `Showing ${files.length} file${files.length !== 1 ? 's' : ''} uploaded together`;
+            // Clearing the search returns to the full listing
+            document.getElementById('clear-search-button').style.display = 'inline-block';
+        } catch (error) {
+            console.error('Error loading upload group:', error);
+            this.showToast('Failed to load upload group: ' + error.message, 'error');
+        }
+    }
+
+    isLowConfidence(file) {
+        // Files uploaded before confidence was recorded have no detection signal
+        return !file.deleted && !!file.detection_signal &&
+            file.detection_confidence <= this.lowConfidenceThreshold;
+    }
+
     renderFiles(files) {
         const filesList = document.getElementById('files-list');
         const emptyDiv = document.getElementById('files-empty');
@@ -339,6 +446,15 @@ class DDDApp {
                 <td class="mdl-data-table__cell--non-numeric">
                     ${this.highlightSearchTerm(this.escapeHtml(file.original_name))}
                     ${file.deleted ? '<span class="deleted-indicator">(File Removed)</span>' : ''}
+                    ${file.pinned ? `
+                        <i class="material-icons pinned-indicator"
+                           title="Pinned: cleanup will never remove this file">push_pin</i>
+                    ` : ''}
+                    ${file.upload_group_id ? `
+                        <i class="material-icons upload-group-indicator"
+                           onclick="app.showUploadGroup('${file.upload_group_id}')"
+                           title="Uploaded together with other files. Click to show the group.">folder_shared</i>
+                    ` : ''}
                 </td>
                 <td>
                     <span class="file-hash"
@@ -351,6 +467,10 @@ class DDDApp {
                     <span class="file-type-badge file-type-${file.file_type}">
                         ${file.file_type}
                     </span>
+                    ${this.isLowConfidence(file) ? `
+                        <i class="material-icons low-confidence-indicator"
+                           title="Low confidence detection (${Math.round(file.detection_confidence * 100)}%, matched on ${file.detection_signal}). Redetect or check the file type.">warning</i>
+                    ` : ''}
                 </td>
                 <td class="file-size">${this.formatFileSize(file.file_size)}</td>
                 <td>${this.formatDate(file.upload_time)}</td>
@@ -363,6 +483,16 @@ class DDDApp {
                         </button>
 
                         ${!file.deleted ? `
+                            <a class="mdl-button mdl-js-button mdl-button--icon"
+                               href="/api/files/${file.id}/download"
+                               title="Download Original File">
+                                <i class="material-icons">download</i>
+                            </a>
+                            <button class="mdl-button mdl-js-button mdl-button--icon${file.pinned ? ' mdl-button--colored' : ''}"
+                                    onclick="app.setFilePinned(${file.id}, ${!file.pinned})"
+                                    title="${file.pinned ? 'Unpin File' : 'Pin File (keep forever)'}">
+                                <i class="material-icons">push_pin</i>
+                            </button>
                             <button class="mdl-button mdl-js-button mdl-button--icon"
                                     onclick="app.redetectFileType(${file.id})"
                                     title="Redetect File Type">
@@ -474,11 +604,12 @@ class DDDApp {
     }
 
     async viewReports(fileId, fileType, isDeleted = false) {
+        this.loadFileUploads(fileId);
         try {
             const response = await fetch(`/api/reports/${fileId}`);
 
             if (!response.ok) {
-                throw new Error(`HTTP ${response.status}: ${response.statusText}`);
+                throw await this.responseError(response);
             }
 
             const result = await response.json();
@@ -488,7 +619,7 @@ class DDDApp {
                 const reports = Array.isArray(result.reports) ? result.reports : [];
                 this.showReportsDialog(reports, fileId, fileType, isDeleted);
             } else {
-                throw new Error(result.message || 'Failed to load reports');
+                throw new Error(this.errorMessage(result, 'Failed to load reports'));
             }
         } catch (error) {
             console.error('Error loading reports:', error);
@@ -498,6 +629,62 @@ class DDDApp {
         }
     }
 
+    async loadFileUploads(fileId) {
+        const container = document.getElementById('file-uploads');
+        container.innerHTML = '';
+        try {
+            const response = await fetch(`/api/files/${fileId}`);
+            const result = await response.json();
+
+            if (result.success) {
+                this.renderFileUploads(result.uploads || [], result.versions || [], fileId);
+            } else {
+                console.error('Failed to load file uploads:', this.errorMessage(result, 'Unknown error'));
+            }
+        } catch (error) {
+            console.error('Error loading file uploads:', error);
+        }
+    }
+
+    renderFileUploads(uploads, versions, fileId) {
+        const container = document.getElementById('file-uploads');
+        let html = '';
+
+        if (uploads.length > 0) {
+            html += `
+                <h4>Uploads (${uploads.length})</h4>
+                <p class="file-uploads-note">The same content was uploaded under each of these names and is stored once.</p>
+                <ul class="file-uploads-list">
+                    ${uploads.map(upload => `
+                        <li>
+                            <strong>${this.escapeHtml(upload.original_name)}</strong>
+                            <small>${this.formatDate(upload.upload_time)}</small>
+                        </li>
+                    `).join('')}
+                </ul>
+            `;
+        }
+
+        // A single version is the file itself, only show chains of re-uploaded captures
+        if (versions.length > 1) {
+            html += `
+                <h4>Versions (${versions.length})</h4>
+                <p class="file-uploads-note">Each version is a newer upload of the same growing capture, oldest first.</p>
+                <ol class="file-uploads-list">
+                    ${versions.map(version => `
+                        <li>
+                            <strong>${this.escapeHtml(version.original_name)}</strong>
+                            ${version.id === fileId ? '(this file)' : ''}
+                            <small>${this.formatFileSize(version.file_size)}, ${this.formatDate(version.upload_time)}</small>
+                        </li>
+                    `).join('')}
+                </ol>
+            `;
+        }
+
+        container.innerHTML = html;
+    }
+
     showReportsDialog(reports, fileId, fileType, isDeleted = false) {
         const dialog = document.getElementById('report-dialog');
 
@@ -620,6 +807,11 @@ class DDDApp {
                 if (reportItem) {
                     reportItem.remove();
                 }
+                const logsPanel = document.getElementById(`report-logs-${reportId}`);
+                if (logsPanel) {
+                    logsPanel.remove();
+                }
+                this.openLogPanels.delete(reportId);
 
                 // Show success message
                 this.showToast(result.message, 'success');
@@ -634,7 +826,7 @@ class DDDApp {
                 }
 
             } else {
-                throw new Error(result.message || 'Failed to delete report');
+                throw new Error(this.errorMessage(result, 'Failed to delete report'));
             }
         } catch (error) {
             console.error('Error deleting report:', error);
@@ -642,6 +834,100 @@ class DDDApp {
         }
     }
 
+    async cancelReport(reportId) {
+        try {
+            const response = await fetch(`/api/reports/${reportId}/cancel`, {
+                method: 'POST'
+            });
+
+            const result = await response.json();
+
+            if (result.success) {
+                this.showToast(result.message, 'success');
+                // Running reports switch to cancelled once the worker stops them
+                this.refreshReports();
+            } else {
+                throw new Error(this.errorMessage(result, 'Failed to cancel report'));
+            }
+        } catch (error) {
+            console.error('Error cancelling report:', error);
+            this.showToast('Failed to cancel report: ' + error.message, 'error');
+        }
+    }
+
+    async setReportStarred(reportId, starred) {
+        try {
+            const response = await fetch(`/api/reports/${reportId}/star`, {
+                method: 'PUT',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ starred })
+            });
+
+            const result = await response.json();
+
+            if (result.success) {
+                this.showToast(result.message, 'success');
+                this.refreshReports();
+            } else {
+                throw new Error(this.errorMessage(result, 'Failed to update star'));
+            }
+        } catch (error) {
+            console.error('Error updating report star:', error);
+            this.showToast('Failed to update star: ' + error.message, 'error');
+        }
+    }
+
+    async saveBaseline(reportId) {
+        const name = prompt('Baseline name (e.g. the hardware class this capture is healthy for):');
+        if (!name || !name.trim()) {
+            return;
+        }
+
+        try {
+            const response = await fetch('/api/baselines', {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ name: name.trim(), report_id: reportId })
+            });
+
+            const result = await response.json();
+
+            if (result.success) {
+                this.showToast(result.message, 'success');
+            } else {
+                throw new Error(this.errorMessage(result, 'Failed to save baseline'));
+            }
+        } catch (error) {
+            console.error('Error saving baseline:', error);
+            this.showToast('Failed to save baseline: ' + error.message, 'error');
+        }
+    }
+
+    async compareToBaseline(reportId) {
+        try {
+            const response = await fetch('/api/baselines');
+            const result = await response.json();
+
+            if (!result.success) {
+                throw new Error(this.errorMessage(result, 'Failed to load baselines'));
+            }
+            if (result.baselines.length === 0) {
+                this.showToast('No baselines saved yet, save a healthy iostat report as a baseline first', 'error');
+                return;
+            }
+
+            const names = result.baselines.map(baseline => baseline.name);
+            const name = names.length === 1 ? names[0] : prompt(`Compare to which baseline? (${names.join(', ')})`, names[0]);
+            if (!name) {
+                return;
+            }
+            window.open(`/api/reports/${reportId}/compare?baseline=${encodeURIComponent(name.trim())}`, '_blank');
+        } catch (error) {
+            console.error('Error comparing to baseline:', error);
+            this.showToast('Failed to compare to baseline: ' + error.message, 'error');
+        }
+    }
+
     renderReportData(reportDataStr) {
         try {
             const reportData = JSON.parse(reportDataStr);
@@ -659,9 +945,9 @@ class DDDApp {
             return `
                 <div class="report-content">
                     <h4>Report Summary</h4>
-                    <p>${reportData.summary || 'No summary available'}</p>
+                    <p>${this.escapeHtml(reportData.summary || 'No summary available')}</p>
                     <h4>Analysis</h4>
-                    <p>${reportData.analysis || 'No analysis available'}</p>
+                    <p>${this.escapeHtml(reportData.analysis || 'No analysis available')}</p>
                     ${reportData.charts ? this.renderCharts(reportData.charts) : ''}
                 </div>
             `;
@@ -679,7 +965,7 @@ class DDDApp {
         charts.forEach((chart, index) => {
             chartsHTML += `
                 <div class="chart-container">
-                    <div class="chart-title">${chart.title}</div>
+                    <div class="chart-title">${this.escapeHtml(chart.title)}</div>
                     <div id="chart-${index}" style="width: 100%; height: 400px;"></div>
                 </div>
             `;
@@ -780,7 +1066,7 @@ class DDDApp {
         return chartsHTML;
     }
 
-    async createReport(fileId, fileType) {
+    async createReport(fileId, reportType) {
         try {
             const response = await fetch(`/api/reports/${fileId}`, {
                 method: 'POST',
@@ -788,7 +1074,7 @@ class DDDApp {
                     'Content-Type': 'application/json'
                 },
                 body: JSON.stringify({
-                    report_type: fileType
+                    report_type: reportType
                 })
             });
 
@@ -799,11 +1085,11 @@ class DDDApp {
                 // Immediately refresh the reports to show the new pending report
                 this.refreshReports();
                 // Restart polling in case it was stopped
-                if (!this.pollingInterval) {
+                if (!this.pollingInterval && this.reportEventSources.size === 0) {
                     this.startPolling();
                 }
             } else {
-                throw new Error(result.message || 'Failed to create report');
+                throw new Error(this.errorMessage(result, 'Failed to create report'));
             }
         } catch (error) {
             console.error('Error creating report:', error);
@@ -815,6 +1101,13 @@ class DDDApp {
         // Stop any existing polling
         this.stopPolling();
 
+        // Prefer live updates over server-sent events, see watchActiveReports
+        if (window.EventSource) {
+            console.log('Watching report events for file:', this.currentFileId);
+            this.refreshReports();
+            return;
+        }
+
         console.log('Starting polling for file:', this.currentFileId);
         // Start polling every 2 seconds
         this.pollingInterval = setInterval(() => {
@@ -828,6 +1121,44 @@ class DDDApp {
             clearInterval(this.pollingInterval);
             this.pollingInterval = null;
         }
+        this.reportEventSources.forEach(source => source.close());
+        this.reportEventSources.clear();
+    }
+
+    watchActiveReports(reports) {
+        if (!window.EventSource) {
+            return;
+        }
+
+        reports
+            .filter(report => report.status === 'pending' || report.status === 'running')
+            .forEach(report => {
+                if (this.reportEventSources.has(report.id)) {
+                    return;
+                }
+
+                const source = new EventSource(`/api/reports/${report.id}/events`);
+                const stopWatching = () => {
+                    source.close();
+                    this.reportEventSources.delete(report.id);
+                };
+
+                source.addEventListener('status', () => this.refreshReports());
+                source.addEventListener('progress', () => {
+                    if (this.openLogPanels.has(report.id)) {
+                        this.loadReportLogs(report.id);
+                    }
+                });
+                // The server sends "done" before closing the stream for a finished report
+                source.addEventListener('done', stopWatching);
+                source.onerror = () => {
+                    // Don't let the browser reconnect in a loop; re-check once instead
+                    stopWatching();
+                    setTimeout(() => this.refreshReports(), 2000);
+                };
+
+                this.reportEventSources.set(report.id, source);
+            });
     }
 
     async refreshReports() {
@@ -842,7 +1173,7 @@ class DDDApp {
             const response = await fetch(`/api/reports/${this.currentFileId}`);
 
             if (!response.ok) {
-                throw new Error(`HTTP ${response.status}: ${response.statusText}`);
+                throw await this.responseError(response);
             }
 
             const result = await response.json();
@@ -850,6 +1181,7 @@ class DDDApp {
             if (result.success) {
                 const reports = Array.isArray(result.reports) ? result.reports : [];
                 this.updateReportsInDialog(reports);
+                this.watchActiveReports(reports);
 
                 // Stop polling if no reports are pending or running
                 const hasActiveReports = reports.some(report =>
@@ -907,11 +1239,7 @@ class DDDApp {
                 content.innerHTML = `
                     <div class="no-reports-container">
                         <p>No reports found for this file.</p>
-                        <button class="mdl-button mdl-js-button mdl-button--raised mdl-button--colored"
-                                onclick="app.createReport(${fileId}, '${fileType}')">
-                            <i class="material-icons">play_arrow</i>
-                            Generate New Report
-                        </button>
+                        ${this.renderGenerateReportControl(fileId, fileType)}
                     </div>
                 `;
             }
@@ -920,13 +1248,11 @@ class DDDApp {
                 <div class="reports-container">
                     <div class="reports-list">
                         <div class="reports-header">
-                            <h4>Reports ${hasActiveReports ? '<span class="polling-indicator" title="Auto-refreshing every 2 seconds"></span>' : ''}</h4>
+                            <h4>Reports ${hasActiveReports ? '<span class="polling-indicator" title="Updating live"></span>' : ''}</h4>
                             ${!isDeleted ? `
-                                <button class="mdl-button mdl-js-button mdl-button--raised mdl-button--colored"
-                                        onclick="app.createReport(${fileId}, '${fileType}')">
-                                    <i class="material-icons">play_arrow</i>
-                                    Generate New Report
-                                </button>
+                                <div class="generate-report-control">
+                                    ${this.renderGenerateReportControl(fileId, fileType)}
+                                </div>
                             ` : `
                                 <div class="deleted-file-message">
                                     <p><strong>File removed from disk.</strong> Upload file again to generate new reports.</p>
@@ -939,19 +1265,27 @@ class DDDApp {
                                     <div>
                                         <strong>${report.report_type}</strong>
                                         <span class="status-badge status-${report.status}">${report.status}</span>
+                                        ${report.starred ? '<i class="material-icons starred-indicator" title="Starred">star</i>' : ''}
                                     </div>
                                     <div>
                                         <small>Created: ${this.formatDate(report.created_time)}</small>
                                     </div>
                                     <div>
                                         <small>Version: ${report.ddd_version}</small>
+                                        ${report.stale ? '<span class="stale-badge" title="Generated by an older DDD version; generate a new report to pick up reporter improvements">outdated</span>' : ''}
                                     </div>
                                     <div>
                                         ${report.completed_time ? `<small>Completed: ${this.formatDate(report.completed_time)}</small>` : ''}
+                                        ${report.generation_ms ? `<small>Generated in ${this.formatDuration(report.generation_ms)}</small>` : ''}
                                         ${report.error_message ? `<small style="color: #d32f2f;">Error: ${report.error_message}</small>` : ''}
                                     </div>
                                 </div>
                                 <div class="report-actions">
+                                    <button class="mdl-button mdl-js-button mdl-button--icon${report.starred ? ' mdl-button--colored' : ''}"
+                                            onclick="app.setReportStarred(${report.id}, ${!report.starred})"
+                                            title="${report.starred ? 'Unstar Report' : 'Star Report'}">
+                                        <i class="material-icons">${report.starred ? 'star' : 'star_border'}</i>
+                                    </button>
                                     ${report.status === 'completed' ? `
                                         <button class="mdl-button mdl-js-button mdl-button--icon"
                                                 onclick="app.copyReportLink(${report.id})" title="Copy Report Link">
@@ -961,23 +1295,118 @@ class DDDApp {
                                            class="mdl-button mdl-js-button mdl-button--icon" title="Open Report in New Tab">
                                             <i class="material-icons">open_in_new</i>
                                         </a>
+                                        <a href="/api/reports/${report.id}/standalone.html" download
+                                           class="mdl-button mdl-js-button mdl-button--icon" title="Download Standalone HTML">
+                                            <i class="material-icons">download</i>
+                                        </a>
+                                        <a href="/api/reports/${report.id}/bundle?include_file=true" download
+                                           class="mdl-button mdl-js-button mdl-button--icon" title="Export Report Bundle">
+                                            <i class="material-icons">ios_share</i>
+                                        </a>
+                                        ${report.report_type === 'iostat' ? `
+                                            <button class="mdl-button mdl-js-button mdl-button--icon"
+                                                    onclick="app.saveBaseline(${report.id})" title="Save as Baseline">
+                                                <i class="material-icons">bookmark_add</i>
+                                            </button>
+                                            <button class="mdl-button mdl-js-button mdl-button--icon"
+                                                    onclick="app.compareToBaseline(${report.id})" title="Compare to Baseline">
+                                                <i class="material-icons">compare_arrows</i>
+                                            </button>
+                                        ` : ''}
+                                    ` : ''}
+                                    ${report.status === 'pending' || report.status === 'running' ? `
+                                        <button class="mdl-button mdl-js-button mdl-button--icon"
+                                                onclick="app.cancelReport(${report.id})" title="Cancel Report">
+                                            <i class="material-icons">cancel</i>
+                                        </button>
                                     ` : ''}
+                                    <button class="mdl-button mdl-js-button mdl-button--icon"
+                                            onclick="app.toggleReportLogs(${report.id})" title="Show Report Logs">
+                                        <i class="material-icons">subject</i>
+                                    </button>
                                     <button class="mdl-button mdl-js-button mdl-button--icon"
                                             onclick="app.deleteReport(${report.id})" title="Delete Report">
                                         <i class="material-icons">delete</i>
                                     </button>
                                 </div>
                             </div>
+                            <div class="report-logs" id="report-logs-${report.id}" ${this.openLogPanels.has(report.id) ? '' : 'hidden'}>
+                                <input type="search" class="report-logs-search" placeholder="Search logs..."
+                                       value="${this.escapeHtml(this.logSearchQueries[report.id] || '')}"
+                                       oninput="app.searchReportLogs(${report.id}, this.value)">
+                                <div class="report-logs-list"></div>
+                            </div>
                         `).join('')}
                     </div>
                 </div>
             `;
         }
 
+        // Reload logs for panels that were open before re-rendering
+        this.openLogPanels.forEach(reportId => this.loadReportLogs(reportId));
+
         // Re-initialize MDL components for any new buttons
         componentHandler.upgradeDom();
     }
 
+    toggleReportLogs(reportId) {
+        const panel = document.getElementById(`report-logs-${reportId}`);
+        if (!panel) {
+            return;
+        }
+
+        if (this.openLogPanels.has(reportId)) {
+            this.openLogPanels.delete(reportId);
+            panel.hidden = true;
+        } else {
+            this.openLogPanels.add(reportId);
+            panel.hidden = false;
+            this.loadReportLogs(reportId);
+        }
+    }
+
+    searchReportLogs(reportId, query) {
+        this.logSearchQueries[reportId] = query;
+        this.loadReportLogs(reportId);
+    }
+
+    async loadReportLogs(reportId) {
+        const panel = document.getElementById(`report-logs-${reportId}`);
+        if (!panel) {
+            return;
+        }
+        const list = panel.querySelector('.report-logs-list');
+        const search = this.logSearchQueries[reportId] || '';
+
+        try {
+            const response = await fetch(`/api/reports/${reportId}/logs?search=${encodeURIComponent(search)}`);
+
+            if (!response.ok) {
+                throw await this.responseError(response);
+            }
+
+            const result = await response.json();
+            list.innerHTML = this.renderReportLogs(result.logs || []);
+        } catch (error) {
+            console.error('Error loading report logs:', error);
+            list.innerHTML = `<div class="report-logs-empty">Failed to load logs: ${this.escapeHtml(error.message)}</div>`;
+        }
+    }
+
+    renderReportLogs(logs) {
+        if (logs.length === 0) {
+            return '<div class="report-logs-empty">No log lines found.</div>';
+        }
+
+        return logs.map(entry => `
+            <div class="report-log-line report-log-${entry.level.toLowerCase()}">
+                <span class="report-log-time">${this.formatDate(entry.timestamp)}</span>
+                <span class="report-log-level">${this.escapeHtml(entry.level)}</span>
+                <span class="report-log-message">${this.escapeHtml(entry.message)}</span>
+            </div>
+        `).join('');
+    }
+
     async redetectFileType(fileId) {
         try {
             const response = await fetch(`/api/files/${fileId}/redetect`, {
@@ -991,7 +1420,7 @@ class DDDApp {
                 // Refresh file list after a short delay to show updated type
                 setTimeout(() => this.loadFiles(), 1000);
             } else {
-                throw new Error(result.message || 'Failed to redetect file type');
+                throw new Error(this.errorMessage(result, 'Failed to redetect file type'));
             }
         } catch (error) {
             console.error('Error redetecting file type:', error);
@@ -999,6 +1428,28 @@ class DDDApp {
         }
     }
 
+    async setFilePinned(fileId, pinned) {
+        try {
+            const response = await fetch(`/api/files/${fileId}/pin`, {
+                method: 'PUT',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ pinned })
+            });
+
+            const result = await response.json();
+
+            if (result.success) {
+                this.showToast(pinned ? 'File pinned, cleanup will keep it' : 'File unpinned');
+                this.loadFiles();
+            } else {
+                throw new Error(this.errorMessage(result, 'Failed to update pin'));
+            }
+        } catch (error) {
+            console.error('Error updating file pin:', error);
+            this.showToast('Failed to update pin: ' + error.message);
+        }
+    }
+
     async deleteFile(fileId) {
         if (!confirm('Are you sure you want to delete this file?')) {
             return;
@@ -1014,7 +1465,7 @@ class DDDApp {
             if (result.success) {
                 this.loadFiles(); // Refresh file list
             } else {
-                throw new Error(result.message || 'Failed to delete file');
+                throw new Error(this.errorMessage(result, 'Failed to delete file'));
             }
         } catch (error) {
             console.error('Error deleting file:', error);
@@ -1122,13 +1573,161 @@ class DDDApp {
             if (result.success) {
                 this.updateDiskUsageUI(result.uploads, result.database);
             } else {
-                console.error('Failed to load disk usage:', result.message);
+                console.error('Failed to load disk usage:', this.errorMessage(result, 'Unknown error'));
             }
         } catch (error) {
             console.error('Error loading disk usage:', error);
         }
     }
 
+    async loadReadOnlyMode() {
+        try {
+            const response = await fetch('/api/settings');
+            const result = await response.json();
+
+            if (result.success) {
+                document.getElementById('read-only-banner').hidden = !result.read_only_mode;
+            }
+        } catch (error) {
+            console.error('Error loading read-only mode:', error);
+        }
+    }
+
+    async loadCapabilities() {
+        try {
+            const response = await fetch('/api/capabilities');
+            const result = await response.json();
+
+            if (result.success) {
+                this.capabilities = result;
+            }
+        } catch (error) {
+            console.error('Error loading capabilities:', error);
+        }
+    }
+
+    // Report types that can be requested for a file type, as advertised by the server
+    reportTypesFor(fileType) {
+        if (!this.capabilities) {
+            return [fileType];
+        }
+        const capability = this.capabilities.file_types.find(c => c.file_type === fileType);
+        return capability ? capability.report_types : [];
+    }
+
+    renderGenerateReportControl(fileId, fileType) {
+        const reportTypes = this.reportTypesFor(fileType);
+        if (reportTypes.length === 0) {
+            return `<p>No reports are available for ${this.escapeHtml(fileType)} files.</p>`;
+        }
+        const picker = reportTypes.length > 1 ? `
+            <select id="report-type-select" class="report-type-select">
+                ${reportTypes.map(type => `<option value="${type}">${type}</option>`).join('')}
+            </select>
+        ` : '';
+        const reportType = reportTypes.length > 1
+            ? `document.getElementById('report-type-select').value`
+            : `'${reportTypes[0]}'`;
+        return `
+            ${picker}
+            <button class="mdl-button mdl-js-button mdl-button--raised mdl-button--colored"
+                    onclick="app.createReport(${fileId}, ${reportType})">
+                <i class="material-icons">play_arrow</i>
+                Generate New Report
+            </button>
+        `;
+    }
+
+    async loadStats() {
+        try {
+            const response = await fetch('/api/stats');
+            const result = await response.json();
+
+            if (result.success) {
+                this.renderStats(result.stats);
+            } else {
+                console.error('Failed to load stats:', this.errorMessage(result, 'Unknown error'));
+            }
+        } catch (error) {
+            console.error('Error loading stats:', error);
+        }
+    }
+
+    renderStats(stats) {
+        const byType = Object.entries(stats.files_by_type)
+            .sort((a, b) => b[1] - a[1])
+            .map(([type, count]) => `${count} ${type}`)
+            .join(', ');
+        const pending = (stats.reports_by_status.pending || 0) + (stats.reports_by_status.running || 0);
+        const failed = stats.reports_by_status.failed || 0;
+
+        document.getElementById('stats-summary').textContent =
+            `${stats.total_files} files (${this.formatFileSize(stats.total_bytes)})` +
+            (byType ? ` - ${byType}` : '') +
+            ` | ${stats.total_reports} reports, ${pending} in progress, ${failed} failed` +
+            ` | ${stats.reports_last_24h} generated in the last 24h`;
+    }
+
+    async loadThroughput() {
+        try {
+            const response = await fetch('/api/workers/throughput');
+            const result = await response.json();
+
+            if (result.success) {
+                this.renderThroughput(result.buckets);
+            } else {
+                console.error('Failed to load report throughput:', this.errorMessage(result, 'Unknown error'));
+            }
+        } catch (error) {
+            console.error('Error loading report throughput:', error);
+        }
+    }
+
+    renderThroughput(buckets) {
+        const summary = document.getElementById('throughput-summary');
+        const chartElement = document.getElementById('throughput-chart');
+
+        let queued = 0;
+        let finished = 0;
+        buckets.forEach(bucket => {
+            queued += bucket.queued;
+            finished += bucket.completed + bucket.failed;
+        });
+        summary.textContent = `Last hour: ${queued} queued, ${finished} finished` +
+            (queued > finished ? ` (${queued - finished} behind)` : '');
+
+        if (typeof echarts === 'undefined') {
+            return;
+        }
+        if (!this.throughputChart) {
+            this.throughputChart = echarts.init(chartElement);
+            window.addEventListener('resize', () => this.throughputChart.resize());
+        }
+
+        const labels = buckets.map(bucket => new Date(bucket.start).toLocaleTimeString([], { hour: '2-digit', minute: '2-digit' }));
+        this.throughputChart.setOption({
+            tooltip: { trigger: 'axis' },
+            legend: { data: ['Queued', 'Completed', 'Failed', 'Avg Generation (s)'] },
+            grid: { left: '3%', right: '4%', bottom: '3%', containLabel: true },
+            xAxis: { type: 'category', data: labels },
+            yAxis: [
+                { type: 'value', name: 'Reports/min', minInterval: 1 },
+                { type: 'value', name: 'Seconds' }
+            ],
+            series: [
+                { name: 'Queued', type: 'line', data: buckets.map(bucket => bucket.queued) },
+                { name: 'Completed', type: 'bar', stack: 'finished', data: buckets.map(bucket => bucket.completed) },
+                { name: 'Failed', type: 'bar', stack: 'finished', data: buckets.map(bucket => bucket.failed) },
+                {
+                    name: 'Avg Generation (s)',
+                    type: 'line',
+                    yAxisIndex: 1,
+                    data: buckets.map(bucket => (bucket.avg_generation_ms / 1000).toFixed(2))
+                }
+            ]
+        });
+    }
+
     updateDiskUsageUI(uploads, database) {
         const diskUsageDisplay = document.getElementById('disk-usage-display');
         const currentUsageDisplay = document.getElementById('current-disk-usage');
@@ -1188,7 +1787,7 @@ class DDDApp {
                 document.getElementById('max-disk-usage').textContent = maxDiskUsage;
                 document.getElementById('file-retention-days').textContent = retentionDays;
             } else {
-                console.error('Failed to load settings:', result.message);
+                console.error('Failed to load settings:', this.errorMessage(result, 'Unknown error'));
                 // Set defaults if loading fails
                 document.getElementById('max-disk-usage').textContent = 50;
                 document.getElementById('file-retention-days').textContent = 14;
@@ -1221,7 +1820,7 @@ class DDDApp {
             if (result.success) {
                 this.showToast('Settings saved successfully!');
             } else {
-                throw new Error(result.message || 'Failed to save settings');
+                throw new Error(this.errorMessage(result, 'Failed to save settings'));
             }
         } catch (error) {
             console.error('Error saving settings:', error);
@@ -1229,11 +1828,35 @@ class DDDApp {
         }
     }
 
+    // API errors look like {"success": false, "error": {"message": "...", "code": "..."}}
+    errorMessage(result, fallback) {
+        return (result && result.error && result.error.message) || fallback;
+    }
+
+    async responseError(response) {
+        const fallback = `HTTP ${response.status}: ${response.statusText}`;
+        try {
+            return new Error(this.errorMessage(await response.json(), fallback));
+        } catch (e) {
+            return new Error(fallback);
+        }
+    }
+
     formatDate(dateStr) {
         const date = new Date(dateStr);
         return date.toLocaleDateString() + ' ' + date.toLocaleTimeString();
     }
 
+    formatDuration(ms) {
+        if (ms < 1000) {
+            return `${ms}ms`;
+        }
+        if (ms < 60000) {
+            return `${(ms / 1000).toFixed(1)}s`;
+        }
+        return `${Math.floor(ms / 60000)}m ${Math.round((ms % 60000) / 1000)}s`;
+    }
+
     escapeHtml(text) {
         const div = document.createElement('div');
         div.textContent = text;
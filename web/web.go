@@ -0,0 +1,34 @@
+//	Copyright 2025 Ryan SVIHLA Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package web bundles the DDD user interface: the index page, static assets and page templates
+package web
+
+import (
+	"embed"
+	"io/fs"
+	"os"
+)
+
+//go:embed index.html static templates
+var embedded embed.FS
+
+// Assets returns the web assets. When dir is empty the copy embedded in the binary is used,
+// otherwise files are read live from dir so UI changes show up without rebuilding.
+func Assets(dir string) fs.FS {
+	if dir == "" {
+		return embedded
+	}
+	return os.DirFS(dir)
+}